@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/armaniacs/usacloud-update/internal/provenance"
+)
+
+// validateChangesCSVConfig は --changes-csv が変換結果を出力するモードで指定されているか確認
+func validateChangesCSVConfig(cfg *Config) error {
+	if cfg.ChangesCSVPath == "" {
+		return nil
+	}
+	if cfg.SandboxMode || cfg.ValidateOnly || cfg.InteractiveMode || cfg.Watch || cfg.DryRun {
+		return fmt.Errorf("--changes-csv は変換モードでのみ指定できます（--sandbox / --validate-only / --interactive-mode / --watch / --dry-run とは同時に指定できません）")
+	}
+	return nil
+}
+
+// collectChanges は処理結果から変更ごとの行を集める（コメント列は変換ルールの理由）
+func (cli *IntegratedCLI) collectChanges(path string, results []*ProcessResult) []provenance.ChangeRow {
+	describe := func(ruleName string) string {
+		explanation, _ := cli.transformEngine.Explain(ruleName)
+		return explanation.Description
+	}
+	var rows []provenance.ChangeRow
+	for _, r := range results {
+		rows = append(rows, provenance.NewChangeRows(path, r.LineNumber, r.TransformResult, describe)...)
+	}
+	return rows
+}
+
+// writeChangesCSV は --changes-csv のファイルに変更の一覧を CSV で書き出す
+// 変更がなくてもヘッダー行だけのファイルを作成する
+func (cli *IntegratedCLI) writeChangesCSV(rows []provenance.ChangeRow) error {
+	f, err := os.Create(cli.config.ChangesCSVPath)
+	if err != nil {
+		return fmt.Errorf("変更一覧の出力に失敗しました: %s: %w", cli.config.ChangesCSVPath, err)
+	}
+
+	if err := provenance.WriteChangesCSV(f, rows); err != nil {
+		f.Close()
+		return fmt.Errorf("変更一覧の出力に失敗しました: %s: %w", cli.config.ChangesCSVPath, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("変更一覧の出力に失敗しました: %s: %w", cli.config.ChangesCSVPath, err)
+	}
+	return nil
+}
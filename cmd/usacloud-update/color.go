@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/cli/errors"
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// --color で指定できる色付けの方法
+const (
+	colorAuto   = "auto"   // 出力先が端末の場合だけ色付け
+	colorAlways = "always" // 常に色付け
+	colorNever  = "never"  // 色付けしない
+)
+
+// colorModeFlag は --color の値（auto/always/never）
+// 以前の真偽値のフラグとの互換のため、--color・--color=true は always、--color=false は never として受け付ける
+type colorModeFlag struct {
+	mode       string
+	deprecated string // 真偽値で指定された場合の元の値
+}
+
+func (f *colorModeFlag) String() string {
+	if f == nil || f.mode == "" {
+		return colorAuto
+	}
+	return f.mode
+}
+
+func (f *colorModeFlag) Set(value string) error {
+	switch v := strings.ToLower(value); v {
+	case colorAuto, colorAlways, colorNever:
+		f.mode, f.deprecated = v, ""
+	case "true", "1":
+		f.mode, f.deprecated = colorAlways, value
+	case "false", "0":
+		f.mode, f.deprecated = colorNever, value
+	default:
+		return fmt.Errorf("auto/always/never のいずれかを指定してください: %s", value)
+	}
+	return nil
+}
+
+// IsBoolFlag は値を省略した --color を以前と同じく受け付けるためのもの（flag パッケージが参照）
+// そのため --color never の never は値にならないので、consumeColorModeArg で読み直す
+func (f *colorModeFlag) IsBoolFlag() bool {
+	return true
+}
+
+// consumeColorModeArg は値を省略した --color の直後に残った auto/always/never をその値として読み、
+// 解析が止まった残りの引数を改めて解析する
+func consumeColorModeArg(fs *flag.FlagSet, f *colorModeFlag) error {
+	args := fs.Args()
+	if f.deprecated != "true" || len(args) == 0 {
+		return nil
+	}
+	switch mode := strings.ToLower(args[0]); mode {
+	case colorAuto, colorAlways, colorNever:
+		f.mode, f.deprecated = mode, ""
+		return fs.Parse(args[1:])
+	}
+	return nil
+}
+
+// colorMode は --color のフラグを登録
+func colorMode(name, usage string) *colorModeFlag {
+	f := &colorModeFlag{mode: colorAuto}
+	flag.Var(f, name, usage)
+	return f
+}
+
+// deprecationWarning は真偽値で指定された場合の非推奨の警告（それ以外は空文字列）
+func (f *colorModeFlag) deprecationWarning() string {
+	if f.deprecated == "" {
+		return ""
+	}
+	return fmt.Sprintf("⚠️  --color=%s は非推奨です。--color=%s を使用してください\n", f.deprecated, f.mode)
+}
+
+// colorEnabledFor は --color の指定と出力先 f から色付けするかどうかを判定
+// auto では f が端末で、環境変数 NO_COLOR が未設定かつ TERM=dumb でない場合に色付けする
+func colorEnabledFor(mode string, f *os.File) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return f != nil && term.IsTerminal(int(f.Fd()))
+}
+
+// setColorEnabled は変更行の表示・検証レポート・エラー表示の色付けをまとめて切り替える
+func (cli *IntegratedCLI) setColorEnabled(enabled bool) {
+	cli.config.ColorEnabled = enabled
+	color.NoColor = !enabled
+	cli.errorFormatter.SetColorEnabled(enabled)
+	cli.cliErrorFormatter = errors.NewErrorFormatter(enabled)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/cli/exit"
+	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// completionShells は補完スクリプトを生成できるシェル
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionFlagValues は値が決まっているフラグの補完候補
+var completionFlagValues = map[string][]string{
+	"color":              {colorAuto, colorAlways, colorNever},
+	"output-format":      {"text", "json", "sarif"},
+	"treat-unknown-as":   {unknownAsError, unknownAsWarning, unknownAsIgnore},
+	"help-mode":          {"basic", "enhanced", "interactive"},
+	"language":           {"ja", "en"},
+	"usacloud-version":   {"1.0", "1.1"},
+	"since":              {"v0", "v1.0"},
+	"target-version":     {"v1.0", "v1.1"},
+	"input-encoding":     {"utf-8", "shift_jis", "euc-jp", "iso-2022-jp"},
+	"output-encoding":    {"utf-8", "shift_jis", "euc-jp", "iso-2022-jp"},
+	"line-ending":        {"lf", "crlf", "auto"},
+	"report":             {"json"},
+	"risk-report-format": {"text", "json"},
+	"list-rules-format":  {"text", "json"},
+	"benchmark-format":   {"text", "json"},
+	"dump-parse-format":  {"text", "json"},
+}
+
+// completionRuleFlags は変換ルール名をカンマ区切りで指定するフラグ
+var completionRuleFlags = []string{"rule-order", "enable-rules", "disable-rules", "disable-rule", "enable-only"}
+
+// isCompletionCommand は引数がシェル補完のコマンドかどうかを判定
+// 補完スクリプトが候補の取得に呼び出す __complete も含む
+func isCompletionCommand(arg string) bool {
+	return arg == "completion" || arg == cobra.ShellCompRequestCmd || arg == cobra.ShellCompNoDescRequestCmd
+}
+
+// runCompletionCommand は `completion <shell>` と補完スクリプトからの __complete を実行
+func runCompletionCommand(args []string) {
+	cmd := newCompletionRootCommand(flag.CommandLine)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+}
+
+// newCompletionRootCommand は補完に使うコマンド構成を作成
+// フラグは flags（通常は flag.CommandLine）から、サブコマンドは runMainLogic が受け付けるものから作成する
+func newCompletionRootCommand(flags *flag.FlagSet) *cobra.Command {
+	root := &cobra.Command{
+		Use:               "usacloud-update",
+		Short:             "usacloud コマンド変換ツール",
+		Run:               func(cmd *cobra.Command, args []string) {},
+		SilenceErrors:     true,
+		SilenceUsage:      true,
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
+	}
+	root.Flags().AddGoFlagSet(flags)
+	for name, values := range completionFlagValues {
+		if root.Flags().Lookup(name) != nil {
+			_ = root.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+		}
+	}
+	for _, name := range completionRuleFlags {
+		if root.Flags().Lookup(name) != nil {
+			_ = root.RegisterFlagCompletionFunc(name, completeRuleNames)
+		}
+	}
+	if root.Flags().Lookup("profile") != nil {
+		_ = root.RegisterFlagCompletionFunc("profile", completeProfileNames)
+	}
+
+	configCmd := &cobra.Command{Use: "config", Short: "設定ファイルの検証・表示・編集・移行"}
+	configCmd.AddCommand(
+		completionSubcommand("validate", "設定ファイルを検証", func(fs *flag.FlagSet) {
+			fs.String("config", "", "検証する設定ファイルパス")
+		}),
+		completionSubcommand("current", "環境変数とプロファイルを反映した設定を表示", func(fs *flag.FlagSet) {
+			fs.String("config", "", "表示する設定ファイルパス")
+		}),
+		completionSubcommand("edit", "設定項目を TUI で編集", func(fs *flag.FlagSet) {
+			fs.String("config", "", "編集する設定ファイルパス")
+		}),
+		completionSubcommand("migrate", ".env を設定ファイルに移行", func(fs *flag.FlagSet) {
+			fs.String("from", ".env", "移行元の .env ファイルパス")
+			fs.String("to", "", "作成する設定ファイルパス")
+			fs.Bool("force", false, "既存の設定ファイルを上書き")
+		}),
+	)
+
+	listCmd := completionSubcommand("list", "廃止コマンドの一覧を表示", func(fs *flag.FlagSet) {
+		fs.String("output-format", "text", "出力形式 (text/json)")
+	})
+	listCmd.ValidArgsFunction = cobra.NoFileCompletions
+	_ = listCmd.RegisterFlagCompletionFunc("output-format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	deprecatedCmd := &cobra.Command{Use: "deprecated", Short: "廃止コマンドの情報"}
+	deprecatedCmd.AddCommand(listCmd)
+
+	completionCmd := &cobra.Command{
+		Use:       "completion [bash|zsh|fish]",
+		Short:     "シェル補完スクリプトを生成",
+		ValidArgs: completionShells,
+		Args:      cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeCompletionScript(cmd.OutOrStdout(), cmd.Root(), args[0])
+		},
+	}
+
+	root.AddCommand(configCmd, deprecatedCmd, completionCmd)
+	return root
+}
+
+// completionSubcommand は補完用のサブコマンドを作成（flags で Go の flag としてオプションを登録）
+func completionSubcommand(name, short string, flags func(fs *flag.FlagSet)) *cobra.Command {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags(fs)
+	cmd := &cobra.Command{
+		Use:   name,
+		Short: short,
+		Args:  cobra.NoArgs,
+		Run:   func(cmd *cobra.Command, args []string) {},
+	}
+	cmd.Flags().AddGoFlagSet(fs)
+	return cmd
+}
+
+// writeCompletionScript は shell 用の補完スクリプトを w に書き出す
+func writeCompletionScript(w io.Writer, root *cobra.Command, shell string) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletionV2(w, true)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	default:
+		return fmt.Errorf("補完スクリプトを生成できないシェルです: %s (%s のいずれかを指定してください)", shell, strings.Join(completionShells, "/"))
+	}
+}
+
+// completeRuleNames は変換ルール名を補完候補として返す
+// 入力済みのカンマまでは残し、次のルール名を補完する
+func completeRuleNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	prefix := toComplete[:strings.LastIndex(toComplete, ",")+1]
+	var names []string
+	for _, name := range transform.NewDefaultEngine().RuleNames() {
+		names = append(names, prefix+name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfileNames は --config の設定ファイルと組み込みのプロファイル名を補完候補として返す
+func completeProfileNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	path := ""
+	if f := cmd.Flags().Lookup("config"); f != nil {
+		path = f.Value.String()
+	}
+	cfg, err := config.ReadIntegratedConfigProfile(path, "default")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return cfg.ProfileNames(), cobra.ShellCompDirectiveNoFileComp
+}
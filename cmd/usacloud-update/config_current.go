@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/fatih/color"
+)
+
+// parseConfigCurrentArgs は `config current` 以降の引数から設定ファイルのパスを取得
+// 未指定時は `config` より前の --config、それもなければ既定の設定ファイル
+func parseConfigCurrentArgs(args []string, defaultPath string) (string, error) {
+	return parseConfigPathArgs("config current", "表示する設定ファイルパス", args, defaultPath)
+}
+
+// settingSourceLabel は設定値の由来の表示名
+func settingSourceLabel(s config.EffectiveSetting) string {
+	switch s.Source {
+	case config.SettingSourceFile:
+		return "設定ファイル"
+	case config.SettingSourceEnv:
+		return "環境変数 " + s.Origin
+	case config.SettingSourceProfile:
+		return "プロファイル " + s.Origin
+	default:
+		return "既定値"
+	}
+}
+
+// runConfigCurrentMode は環境変数とプロファイルを反映した実際に使われる設定を、セクションごとに値の由来付きで表示
+// profileName（--profile）を指定した場合は、環境変数・設定ファイルのプロファイルの代わりにそれを適用
+func runConfigCurrentMode(w io.Writer, configPath, profileName string) error {
+	effective, err := config.ResolveEffectiveConfigProfile(configPath, profileName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "📋 有効な設定: %s\n", effective.Path)
+	if !effective.FileFound {
+		fmt.Fprint(w, color.YellowString("⚠️  設定ファイルが見つからないため既定値を表示します\n"))
+	}
+	profile := effective.Profile
+	if profile == "" {
+		profile = "なし"
+	}
+	fmt.Fprintf(w, "プロファイル: %s\n", profile)
+
+	section := ""
+	for _, s := range effective.Settings {
+		if s.Section != section {
+			section = s.Section
+			fmt.Fprintf(w, "\n[%s]\n", section)
+		}
+		source := settingSourceLabel(s)
+		if s.Source != config.SettingSourceDefault {
+			source = color.CyanString(source)
+		}
+		fmt.Fprintf(w, "  %s = %s  (%s)\n", s.Key, s.Value, source)
+	}
+	return nil
+}
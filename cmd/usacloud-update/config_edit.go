@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/armaniacs/usacloud-update/internal/tui"
+)
+
+// parseConfigEditArgs は `config edit` 以降の引数から設定ファイルのパスを取得
+// 未指定時は `config` より前の --config、それもなければ既定の設定ファイル
+func parseConfigEditArgs(args []string, defaultPath string) (string, error) {
+	return parseConfigPathArgs("config edit", "編集する設定ファイルパス", args, defaultPath)
+}
+
+// runConfigEditMode は設定ファイルの各項目を TUI で編集する
+// 環境変数やプロファイルの値は反映せずに読み込むため、保存してもファイルに書き込まれない
+func runConfigEditMode(w io.Writer, configPath string) error {
+	cfg, err := config.ReadIntegratedConfigFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	editor := tui.NewSettingsEditor(cfg)
+	if err := editor.Run(); err != nil {
+		return fmt.Errorf("設定の編集画面を起動できません: %w", err)
+	}
+	if editor.Modified() {
+		fmt.Fprintf(w, "⚠️  保存していない変更を破棄しました: %s\n", configPath)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/fatih/color"
+)
+
+// configMigrateOptions は `config migrate` サブコマンドの引数
+type configMigrateOptions struct {
+	From  string // 移行元の .env ファイル
+	To    string // 作成する INI 形式の設定ファイル
+	Force bool   // 既存の設定ファイルを上書きする
+}
+
+// parseConfigMigrateArgs は `config migrate` 以降の引数を解析
+// --to 未指定時は既定の設定ファイル
+func parseConfigMigrateArgs(args []string) (configMigrateOptions, error) {
+	var opts configMigrateOptions
+	fs := flag.NewFlagSet("config migrate", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.From, "from", ".env", "移行元の .env ファイルパス")
+	fs.StringVar(&opts.To, "to", "", "作成する設定ファイルパス")
+	fs.BoolVar(&opts.Force, "force", false, "既存の設定ファイルを上書き")
+	if err := fs.Parse(args); err != nil {
+		return opts, fmt.Errorf("config migrate の引数が不正です: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("config migrate の引数が不正です: %v", fs.Args())
+	}
+	if opts.To == "" {
+		path, err := config.ConfigPath()
+		if err != nil {
+			return opts, err
+		}
+		opts.To = path
+	}
+	return opts, nil
+}
+
+// runConfigEnvMigrateMode は旧形式の .env ファイルを INI 形式の設定ファイルに変換し、移行内容を表示
+// 対応する設定がないキーは [legacy] セクションに保持して警告する
+func runConfigEnvMigrateMode(w io.Writer, opts configMigrateOptions) error {
+	if _, err := os.Stat(opts.To); err == nil && !opts.Force {
+		return fmt.Errorf("設定ファイルが既に存在します: %s (上書きする場合は --force を指定してください)", opts.To)
+	}
+
+	result, err := config.MigrateEnvFile(opts.From, opts.To)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf(".envファイルが見つかりません: %s", opts.From)
+		}
+		return fmt.Errorf(".envファイルの移行に失敗しました: %s: %w", opts.From, err)
+	}
+
+	fmt.Fprintf(w, "🔄 %s を INI 形式に変換しました\n", opts.From)
+	for _, migrated := range result.Migrated {
+		fmt.Fprintf(w, "  ✓ %s\n", migrated)
+	}
+	for _, line := range result.InvalidLines {
+		fmt.Fprintf(w, color.YellowString("⚠️  %d行目: KEY=value 形式ではないため無視しました\n"), line)
+	}
+	for _, key := range result.Legacy {
+		fmt.Fprintf(w, color.YellowString("⚠️  %s: 対応する設定がないため [%s] に保持しました\n"), key, config.LegacySection)
+	}
+	fmt.Fprintf(w, "✅ 新しい設定ファイルを作成しました: %s\n", opts.To)
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/fatih/color"
+)
+
+// isConfigSubcommand は位置引数が `config <name>` サブコマンドかどうかを判定
+func isConfigSubcommand(args []string, name string) bool {
+	return len(args) >= 2 && args[0] == "config" && args[1] == name
+}
+
+// parseConfigValidateArgs は `config validate` 以降の引数から設定ファイルのパスを取得
+// 未指定時は `config` より前の --config、それもなければ既定の設定ファイル
+func parseConfigValidateArgs(args []string, defaultPath string) (string, error) {
+	return parseConfigPathArgs("config validate", "検証する設定ファイルパス", args, defaultPath)
+}
+
+// parseConfigPathArgs は --config だけを受け付けるサブコマンドの引数から設定ファイルのパスを取得
+func parseConfigPathArgs(name, usage string, args []string, defaultPath string) (string, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String("config", defaultPath, usage)
+	if err := fs.Parse(args); err != nil {
+		return "", fmt.Errorf("%s の引数が不正です: %w", name, err)
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("%s の引数が不正です: %v", name, fs.Args())
+	}
+	if *path == "" {
+		return config.ConfigPath()
+	}
+	return *path, nil
+}
+
+// runConfigValidateMode は設定ファイルの問題を行番号・セクション付きで一覧表示し、エラーがあれば false を返す
+// 警告（無視される設定項目）だけの場合は true
+func runConfigValidateMode(w io.Writer, configPath string) (bool, error) {
+	problems, err := config.CheckFile(configPath)
+	if err != nil {
+		if config.IsConfigNotFound(err) {
+			return false, fmt.Errorf("設定ファイルが見つかりません: %s", configPath)
+		}
+		return false, err
+	}
+
+	fmt.Fprintf(w, "🔍 設定ファイルを検証しました: %s\n", configPath)
+	if len(problems) == 0 {
+		fmt.Fprint(w, color.GreenString("✅ 問題は見つかりませんでした\n"))
+		return true, nil
+	}
+
+	errorCount := 0
+	for _, p := range problems {
+		location := "未設定"
+		if p.Line > 0 {
+			location = fmt.Sprintf("%d行目", p.Line)
+		}
+		section := p.Section
+		if section == "" {
+			section = "セクションなし"
+		}
+		subject := "[" + section + "]"
+		if p.Key != "" {
+			subject += " " + p.Key
+		}
+
+		if p.Severity == config.ProblemWarning {
+			fmt.Fprintf(w, color.YellowString("  ⚠️  %s %s: %s\n"), location, subject, p.Message)
+		} else {
+			errorCount++
+			fmt.Fprintf(w, color.RedString("  ❌ %s %s: %s\n"), location, subject, p.Message)
+		}
+	}
+	fmt.Fprintf(w, "\nエラー %d件、警告 %d件\n", errorCount, len(problems)-errorCount)
+	return errorCount == 0, nil
+}
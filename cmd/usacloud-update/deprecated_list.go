@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/armaniacs/usacloud-update/internal/validation"
+)
+
+// isDeprecatedSubcommand は位置引数が `deprecated <name>` サブコマンドかどうかを判定
+func isDeprecatedSubcommand(args []string, name string) bool {
+	return len(args) >= 2 && args[0] == "deprecated" && args[1] == name
+}
+
+// deprecatedListOptions は `deprecated list` の引数
+type deprecatedListOptions struct {
+	Prefix string // 廃止コマンド名の前方一致で絞り込む（空はすべて）
+	Format string // text/json
+}
+
+// parseDeprecatedListArgs は `deprecated list` 以降の引数を解析
+// --output-format は前方一致の指定の前後どちらでも受け付け、未指定時は `deprecated` より前の --output-format
+func parseDeprecatedListArgs(args []string, defaultFormat string) (deprecatedListOptions, error) {
+	fs := flag.NewFlagSet("deprecated list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("output-format", defaultFormat, "出力形式 (text/json)")
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return deprecatedListOptions{}, fmt.Errorf("deprecated list の引数が不正です: %w", err)
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(positional) > 1 {
+		return deprecatedListOptions{}, fmt.Errorf("deprecated list に指定できる前方一致は1つです: %v", positional)
+	}
+	if *format != "text" && *format != "json" {
+		return deprecatedListOptions{}, fmt.Errorf("deprecated list の出力形式が不正です: %s (text/json のいずれかを指定してください)", *format)
+	}
+
+	opts := deprecatedListOptions{Format: *format}
+	if len(positional) == 1 {
+		opts.Prefix = positional[0]
+	}
+	return opts, nil
+}
+
+// deprecatedListEntry は廃止コマンドの一覧の1件（JSON出力の要素）
+type deprecatedListEntry struct {
+	Command            string   `json:"command"`
+	ReplacementCommand string   `json:"replacement_command"` // 代替がない場合は空
+	Type               string   `json:"type"`                // renamed/discontinued
+	Message            string   `json:"message"`
+	AlternativeActions []string `json:"alternative_actions"`
+	DocumentationURL   string   `json:"documentation_url,omitempty"`
+}
+
+// deprecatedListEntries は前方一致する廃止コマンドをコマンド名の順に返す
+func deprecatedListEntries(detector *validation.DeprecatedCommandDetector, prefix string) []deprecatedListEntry {
+	entries := []deprecatedListEntry{}
+	for command, info := range detector.GetAllDeprecatedCommands() {
+		if !strings.HasPrefix(command, prefix) {
+			continue
+		}
+		entries = append(entries, deprecatedListEntry{
+			Command:            command,
+			ReplacementCommand: info.ReplacementCommand,
+			Type:               info.DeprecationType,
+			Message:            info.Message,
+			AlternativeActions: append([]string{}, info.AlternativeActions...),
+			DocumentationURL:   info.DocumentationURL,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Command < entries[j].Command })
+	return entries
+}
+
+// runDeprecatedListMode は検出できる廃止コマンドの一覧（廃止コマンド・代替コマンド・説明）を出力
+func runDeprecatedListMode(w io.Writer, detector *validation.DeprecatedCommandDetector, opts deprecatedListOptions) error {
+	entries := deprecatedListEntries(detector, opts.Prefix)
+
+	if opts.Format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintf(w, "%q で始まる廃止コマンドはありません\n", opts.Prefix)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMMAND\tREPLACEMENT\tMESSAGE")
+	for _, entry := range entries {
+		replacement := entry.ReplacementCommand
+		if replacement == "" {
+			replacement = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", entry.Command, replacement, entry.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n%d件の廃止コマンド\n", len(entries))
+	return nil
+}
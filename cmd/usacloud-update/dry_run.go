@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/armaniacs/usacloud-update/internal/cli/exit"
+	cliio "github.com/armaniacs/usacloud-update/internal/cli/io"
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/fatih/color"
+)
+
+// dryRunSummary は変換モードの --dry-run で集計した移行の規模
+type dryRunSummary struct {
+	Files        int // 変換したファイル数
+	ChangedFiles int // 変更される行のあるファイル数
+	Skipped      int // バイナリ・空・変換済みのためスキップしたファイル数
+	Stats        transform.Stats
+}
+
+// validateDryRunConfig は変換モードの --dry-run と他のオプションの組み合わせを確認
+// --sandbox と併せた場合はサンドボックスのドライランのため対象外
+func validateDryRunConfig(cfg *Config) error {
+	if !cfg.DryRun || cfg.SandboxMode {
+		return nil
+	}
+	if cfg.ValidateOnly || cfg.InteractiveMode || cfg.Watch || cfg.Review {
+		return fmt.Errorf("--dry-run は --sandbox なしでは変換モードでのみ指定できます（--validate-only / --interactive-mode / --watch / --review とは同時に指定できません）")
+	}
+	if cfg.DiffMode || cfg.ProvenancePath != "" {
+		return fmt.Errorf("--dry-run と --diff / --provenance は同時に指定できません")
+	}
+	return nil
+}
+
+// runDryRunMode は入力をすべて変換して変更の規模だけを w に表示し、ファイルには何も書き出さない
+// 入出力と設定のエラー以外では失敗しない（廃止コマンドや変換済みの入力も終了コードに影響しない）
+func (cli *IntegratedCLI) runDryRunMode(w io.Writer) error {
+	paths := cli.config.InputPaths
+	if len(paths) == 0 {
+		paths = []string{cli.config.InputPath}
+	}
+	var excluded int
+	recursive := cli.isRecursiveInput()
+	if recursive {
+		root := cli.config.InputPath
+		var err error
+		paths, excluded, err = cliio.FindScriptsExcluding(root, cli.config.Include, cli.config.Exclude)
+		if err != nil {
+			return exit.New(exit.IO, fmt.Errorf("ディレクトリの走査に失敗しました: %s: %w", root, err))
+		}
+	}
+
+	summary, err := cli.dryRun(paths, recursive)
+	if err != nil {
+		return err
+	}
+
+	if cli.config.ShowStats {
+		writeTransformStats(os.Stderr, summary.Stats)
+	}
+	writeDryRunSummary(w, summary, excluded)
+	return nil
+}
+
+// dryRun は paths を順に読み込んで変換し、変更の件数を集計
+// --force がなければ変換済みのファイルはスキップし、recursive ではバイナリ・空のファイルもスキップする（再帰処理と同じ）
+func (cli *IntegratedCLI) dryRun(paths []string, recursive bool) (dryRunSummary, error) {
+	original := *cli.config
+	defer func() { *cli.config = original }()
+	// 行ごとの変更は表示せず、集計だけを表示する
+	cli.config.Quiet = true
+	// 検証エラーのある行も集計に含める（--strict-validation で中断しない）
+	cli.config.StrictValidation = false
+
+	var summary dryRunSummary
+	finishProgress := cli.startProgress(len(paths))
+	defer finishProgress()
+	for _, path := range paths {
+		cli.config.InputPath = path
+		cli.progress.SetLabel(path)
+
+		if recursive && skipDryRunFile(path) {
+			summary.Skipped++
+			cli.progress.Add(1)
+			continue
+		}
+
+		lines, err := cli.readInputFile()
+		if err != nil {
+			return summary, fmt.Errorf("入力ファイル読み込みエラー: %w", err)
+		}
+		if lines, err = cli.checkAlreadyProcessed(lines); err != nil {
+			summary.Skipped++
+			cli.progress.Add(1)
+			continue
+		}
+		if _, err := cli.processLines(lines); err != nil {
+			return summary, fmt.Errorf("処理エラー: %s: %w", path, err)
+		}
+
+		summary.Files++
+		if cli.stats.ChangedLines > 0 {
+			summary.ChangedFiles++
+		}
+		summary.Stats.Merge(cli.stats)
+		cli.progress.Add(1)
+	}
+	return summary, nil
+}
+
+// skipDryRunFile は再帰処理で変換対象外とするバイナリ・空のファイルかどうかを判定
+func skipDryRunFile(path string) bool {
+	if err := cliio.DetectBinaryFile(path); cliio.IsBinaryFileError(err) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Size() == 0
+}
+
+// writeDryRunSummary は --dry-run の集計を1行で表示（スキップ・除外したファイルがあればその数も）
+func writeDryRunSummary(w io.Writer, summary dryRunSummary, excluded int) {
+	fmt.Fprint(w, color.CyanString("🔍 ドライラン: %dファイル中 %dファイルで %d行が %d個のルールにより変更されます（ファイルへの出力は行いません）\n",
+		summary.Files, summary.ChangedFiles, summary.Stats.ChangedLines, len(summary.Stats.RuleHits)))
+	if summary.Skipped > 0 || excluded > 0 {
+		fmt.Fprintf(w, "スキップ: %d  除外: %d\n", summary.Skipped, excluded)
+	}
+}
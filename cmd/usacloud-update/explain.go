@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/armaniacs/usacloud-update/internal/validation"
+	"github.com/fatih/color"
+)
+
+// isExplainSubcommand は位置引数が `explain <command>` サブコマンドかどうかを判定
+func isExplainSubcommand(args []string) bool {
+	return len(args) >= 1 && args[0] == "explain"
+}
+
+// parseExplainArgs は `explain` 以降の引数から説明するコマンド行を組み立てる
+// 1つの引数で渡されたコマンドも単語ごとに渡されたコマンドも受け付け、先頭の usacloud は省略可能
+func parseExplainArgs(args []string) (string, error) {
+	line := strings.TrimSpace(strings.Join(args, " "))
+	if line == "" {
+		return "", fmt.Errorf("explain には説明する usacloud コマンドを指定してください（例: usacloud-update explain \"usacloud server list --output-type=csv\"）")
+	}
+	if !validation.NewParser().IsUsacloudCommand(line) {
+		line = "usacloud " + line
+	}
+	return line, nil
+}
+
+// runExplainMode は1つのコマンドについて、適用される変換ルール・廃止情報・検証結果と修正候補を説明する
+func (cli *IntegratedCLI) runExplainMode(w io.Writer, line string) {
+	fmt.Fprintf(w, color.CyanString("🔎 コマンド: %s\n\n"), line)
+
+	cli.explainTransform(w, line)
+	cli.explainDeprecation(w, line)
+	cli.explainValidation(w, line)
+}
+
+// explainTransform は変換結果と適用された各ルールの説明を出力
+func (cli *IntegratedCLI) explainTransform(w io.Writer, line string) {
+	fmt.Fprint(w, color.CyanString("🔄 変換\n"))
+
+	result := cli.transformEngine.Apply(line)
+	if !result.Changed {
+		fmt.Fprint(w, "  適用される変換ルールはありません\n\n")
+		return
+	}
+
+	fmt.Fprintf(w, "  変換後: %s\n", result.Line)
+	for _, change := range result.Changes {
+		explanation, ok := cli.transformEngine.Explain(change.RuleName)
+		if !ok {
+			explanation = transform.Explanation{RuleName: change.RuleName}
+		}
+		fmt.Fprintf(w, "  %s => %s\n", change.Before, change.After)
+		fmt.Fprint(w, explanation.Format())
+	}
+	fmt.Fprint(w, "\n")
+}
+
+// explainDeprecation はメインコマンドが廃止されている場合に、その理由と代替手段を出力
+func (cli *IntegratedCLI) explainDeprecation(w io.Writer, line string) {
+	parsed, err := validation.NewParser().Parse(line)
+	if err != nil || !cli.deprecatedDetector.IsDeprecated(parsed.MainCommand) {
+		return
+	}
+
+	info := cli.deprecatedDetector.Detect(parsed.MainCommand)
+	fmt.Fprint(w, color.YellowString("⚠️  廃止されたコマンド\n"))
+	fmt.Fprintf(w, "  %s\n", info.Message)
+	if info.ReplacementCommand != "" {
+		fmt.Fprintf(w, "  代替コマンド: usacloud %s\n", info.ReplacementCommand)
+	}
+	for _, action := range info.AlternativeActions {
+		fmt.Fprintf(w, "  • %s\n", action)
+	}
+	if info.DocumentationURL != "" {
+		fmt.Fprintf(w, "  参考: %s\n", info.DocumentationURL)
+	}
+	fmt.Fprint(w, "\n")
+}
+
+// explainValidation は検証で見つかった問題と修正候補を ComprehensiveErrorFormatter で出力
+func (cli *IntegratedCLI) explainValidation(w io.Writer, line string) {
+	fmt.Fprint(w, color.CyanString("🔍 検証\n"))
+
+	result := cli.validateLine(line, 1)
+	if result == nil {
+		fmt.Fprint(w, color.GreenString("  ✅ 問題は見つかりませんでした\n"))
+		return
+	}
+
+	context := &validation.ErrorContext{
+		InputCommand:   result.Line,
+		DetectedIssues: convertToValidationIssues(result.Issues),
+		Suggestions:    result.Suggestions,
+	}
+	fmt.Fprint(w, cli.errorFormatter.FormatError(context))
+	fmt.Fprint(w, "\n")
+}
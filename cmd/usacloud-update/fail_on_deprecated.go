@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/cli/exit"
+	"github.com/fatih/color"
+)
+
+// deprecatedOccurrence は入力中の廃止コマンドの出現箇所
+type deprecatedOccurrence struct {
+	Path        string
+	LineNumber  int
+	Command     string
+	Replacement string // 代替コマンド（廃止のみで代替がない場合は空）
+}
+
+// deprecatedCommandsError は --fail-on-deprecated で廃止コマンドが見つかったことを表す
+type deprecatedCommandsError struct {
+	Occurrences []deprecatedOccurrence
+}
+
+func (e *deprecatedCommandsError) Error() string {
+	return fmt.Sprintf("%d件の廃止コマンドが見つかりました (--fail-on-deprecated)", len(e.Occurrences))
+}
+
+// ExitCode は廃止コマンドが見つかった場合の終了コード（CI で検証エラーと区別できるよう専用のコード）
+func (e *deprecatedCommandsError) ExitCode() int {
+	return exit.Deprecated
+}
+
+// deprecatedCommandIn は行のメインコマンドが廃止コマンドであればその名前を返す
+// --skip-deprecated による検証の省略とは関係なく判定する
+func (cli *IntegratedCLI) deprecatedCommandIn(line string) string {
+	trim := strings.TrimSpace(line)
+	if trim == "" || strings.HasPrefix(trim, "#") {
+		return ""
+	}
+	parsed, err := cli.newParser().Parse(line)
+	if err != nil || !cli.deprecatedDetector.IsDeprecated(parsed.MainCommand) {
+		return ""
+	}
+	return parsed.MainCommand
+}
+
+// collectDeprecated は処理結果から廃止コマンドの出現箇所を集める
+func (cli *IntegratedCLI) collectDeprecated(path string, results []*ProcessResult) []deprecatedOccurrence {
+	var found []deprecatedOccurrence
+	for _, r := range results {
+		if r.DeprecatedCommand == "" {
+			continue
+		}
+		found = append(found, deprecatedOccurrence{
+			Path:        path,
+			LineNumber:  r.LineNumber,
+			Command:     r.DeprecatedCommand,
+			Replacement: cli.deprecatedDetector.GetReplacementCommand(r.DeprecatedCommand),
+		})
+	}
+	return found
+}
+
+// deprecatedFailure は廃止コマンドが見つかっていれば --fail-on-deprecated のエラーを返す
+func deprecatedFailure(found []deprecatedOccurrence) error {
+	if len(found) == 0 {
+		return nil
+	}
+	return &deprecatedCommandsError{Occurrences: found}
+}
+
+// writeDeprecatedReport は見つかった廃止コマンドを出現順に一覧表示
+func writeDeprecatedReport(w io.Writer, e *deprecatedCommandsError) {
+	fmt.Fprintf(w, color.RedString("\n🚫 廃止されたコマンドが見つかりました (%d件):\n"), len(e.Occurrences))
+	for _, o := range e.Occurrences {
+		location := fmt.Sprintf("L%d", o.LineNumber)
+		if o.Path != "" && o.Path != "-" {
+			location = fmt.Sprintf("%s:%d", o.Path, o.LineNumber)
+		}
+		if o.Replacement != "" {
+			fmt.Fprintf(w, "  %s: %s → %s\n", location, o.Command, o.Replacement)
+		} else {
+			fmt.Fprintf(w, "  %s: %s（代替コマンドなし）\n", location, o.Command)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/armaniacs/usacloud-update/internal/cli/progress"
+	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/armaniacs/usacloud-update/internal/validation"
+)
+
+// lineOutcome は1行の変換と検証の結果（ワーカーで計算し、processLines が行順に組み立てる）
+type lineOutcome struct {
+	transform    transform.Result
+	validation   *ValidationResult
+	deprecated   string
+	missingPaths []validation.MissingPath
+}
+
+// resolveJobs は processLines のワーカー数を決定（--jobs > 設定ファイルの [performance] > 既定値のCPU数）
+// --jobs と worker_count の 0 はCPU数、parallel_processing = false では --jobs を指定しない限り1
+func resolveJobs() int {
+	jobs := *jobsFlag
+	if !explicitlySetFlags()["jobs"] {
+		performance := config.NewIntegratedConfig().Performance
+		if fileCfg, err := readIntegratedConfig(); err == nil && fileCfg != nil {
+			performance = fileCfg.Performance
+		}
+		if !performance.ParallelProcessing {
+			return 1
+		}
+		jobs = performance.WorkerCount
+	}
+	if jobs <= 0 {
+		return runtime.NumCPU()
+	}
+	return jobs
+}
+
+// evaluateLines は各行の変換と検証を cli.config.Jobs 個のワーカーで並列に実行し、行順の結果を返す
+// 厳格検証モードでは検証エラーのある最初の行の位置（0始まり、なければ -1）も返す。ワーカーは行を
+// 先頭から順に取るため、エラーの行が見つかった時点でそれより後の行は新たに処理しない
+func (cli *IntegratedCLI) evaluateLines(lines []string, apply func(string) transform.Result, bar *progress.Bar) ([]lineOutcome, int) {
+	outcomes := make([]lineOutcome, len(lines))
+	workers := cli.config.Jobs
+	if workers > len(lines) {
+		workers = len(lines)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var next atomic.Int64
+	var failedAt atomic.Int64
+	failedAt.Store(int64(len(lines)))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := next.Add(1) - 1
+				if i >= int64(len(lines)) || i > failedAt.Load() {
+					return
+				}
+				outcomes[i] = cli.evaluateLine(lines[i], int(i)+1, apply)
+				bar.Add(1)
+
+				if cli.config.StrictValidation && outcomes[i].validation != nil && outcomes[i].validation.HasErrors() {
+					storeMin(&failedAt, i)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if f := int(failedAt.Load()); f < len(lines) {
+		return outcomes, f
+	}
+	return outcomes, -1
+}
+
+// storeMin は v が a の現在の値より小さければ a を v に更新
+func storeMin(a *atomic.Int64, v int64) {
+	for {
+		current := a.Load()
+		if v >= current || a.CompareAndSwap(current, v) {
+			return
+		}
+	}
+}
+
+// evaluateLine は1行の変換と検証を実行（表示は行わないため、複数のワーカーから同時に呼び出せる）
+func (cli *IntegratedCLI) evaluateLine(line string, lineNumber int, apply func(string) transform.Result) lineOutcome {
+	start := time.Now()
+	outcome := lineOutcome{transform: apply(line)}
+	cli.timer.record(phaseTransform, start)
+
+	// 新しい検証処理（変換前）
+	defer cli.timer.record(phaseValidate, time.Now())
+	if !cli.config.SkipDeprecated {
+		outcome.validation = cli.validateLine(line, lineNumber)
+	}
+	if cli.config.FailOnDeprecated {
+		outcome.deprecated = cli.deprecatedCommandIn(line)
+	}
+	if cli.config.CheckPaths {
+		outcome.missingPaths = cli.missingPaths(line)
+	}
+	return outcome
+}
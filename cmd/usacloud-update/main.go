@@ -4,14 +4,19 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/armaniacs/usacloud-update/internal/benchmark"
 	"github.com/armaniacs/usacloud-update/internal/cli/errors"
+	"github.com/armaniacs/usacloud-update/internal/cli/exit"
 	"github.com/armaniacs/usacloud-update/internal/cli/helpers"
 	cliio "github.com/armaniacs/usacloud-update/internal/cli/io"
+	"github.com/armaniacs/usacloud-update/internal/cli/progress"
 	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/armaniacs/usacloud-update/internal/provenance"
 	"github.com/armaniacs/usacloud-update/internal/sandbox"
 	"github.com/armaniacs/usacloud-update/internal/transform"
 	"github.com/armaniacs/usacloud-update/internal/tui"
@@ -95,6 +100,8 @@ type ProcessResult struct {
 	OriginalLine     string
 	TransformResult  *transform.Result
 	ValidationResult *ValidationResult
+
+	DeprecatedCommand string // --fail-on-deprecated で検出した廃止コマンド
 }
 
 // ValidationResult は検証結果
@@ -110,6 +117,15 @@ type ValidationIssue struct {
 	Type      IssueType
 	Message   string
 	Component string // 問題のあるコマンド・サブコマンド名
+	Advisory  bool   // 警告として報告するだけで検証失敗にはしない（--treat-unknown-as=warning）
+}
+
+// EffectiveSeverity は問題の重要度を返す（Advisory の問題は常に警告）
+func (i ValidationIssue) EffectiveSeverity() string {
+	if i.Advisory {
+		return severityWarning
+	}
+	return i.Type.Severity()
 }
 
 // IssueType は問題タイプ
@@ -121,19 +137,25 @@ const (
 	IssueInvalidSubCommand
 	IssueDeprecatedCommand
 	IssueSyntaxError
+	IssueOutputFormatMismatch
+	IssueMissingFile
+	IssueInvalidOption
+	IssueMissingAssumeYes
 )
 
-// HasErrors は ValidationResult がエラーを持つかチェック
+// HasErrors は ValidationResult がエラーを持つかチェック（Advisory の問題は除く）
 func (vr *ValidationResult) HasErrors() bool {
-	return len(vr.Issues) > 0
+	return vr.GetErrorSummary() != ""
 }
 
 // GetErrorSummary は ValidationResult のエラー要約を取得
 func (vr *ValidationResult) GetErrorSummary() string {
-	if len(vr.Issues) == 0 {
-		return ""
+	for _, issue := range vr.Issues {
+		if !issue.Advisory {
+			return issue.Message
+		}
 	}
-	return vr.Issues[0].Message
+	return ""
 }
 
 // FileAnalysis はファイル分析結果
@@ -155,19 +177,74 @@ type InteractiveIssue struct {
 // Config は統合された設定
 type Config struct {
 	// 既存設定
-	InputPath  string
-	OutputPath string
-	ShowStats  bool
+	InputPath           string
+	InputPaths          []string // all --in values in order; more than one is concatenated
+	StdinFilename       string   // 標準入力の内容をメッセージ・JSON/SARIF で表すファイル名（--stdin-filename）
+	OutputPath          string
+	ShowStats           bool
+	Quiet               bool // 変更行ごとの表示を抑制（最後の統計と完了メッセージは表示）
+	Review              bool // 出力前に変更を TUI で確認
+	ExplainChanges      bool
+	ProvenancePath      string
+	StatFormat          string
+	ChangesCSVPath      string // 変更ごとの一覧を書き出す CSV ファイル（--changes-csv）
+	DiffMode            bool
+	AddAssumeYes        bool // 確認を求めるコマンドに -y を付与（--add-assumeyes）
+	ReverseMode         bool
+	NoHeader            bool // 生成ヘッダーを付けずに出力（--no-header）
+	Passes              int
+	MaxLineLength       int  // これより長い行を含むコマンドは変換・検証せずにそのまま出力（0 は無制限）
+	TransformHeredocs   bool // ヒアドキュメントの本文も変換・検証（既定ではそのまま出力）
+	Recursive           bool
+	Include             string
+	Exclude             []string // --recursive で除外するパスのglobパターン
+	InPlace             bool
+	BackupOriginal      bool
+	Force               bool // 変換済み（生成ヘッダーを含む）の入力も生成ヘッダーを取り除いて再変換
+	RiskReportPath      string
+	RiskReportFormat    string
+	MigrationReport     string // コマンドを対応済み・移行済み・手動対応が必要に分類したレポートの出力先（--migration-report）
+	PreservePermissions bool
+	LineEnding          string
+	InputEncoding       string
+	OutputEncoding      string
+	Watch               bool
+	Jobs                int // processLines のワーカー数（--jobs または設定ファイルの [performance]）
+
+	// 進捗表示（設定ファイルの [output] show_progress / progress_style）
+	ShowProgress  bool
+	ProgressStyle string
+
+	// 変換統計のベースライン（CIでのルール適用数の変化検出）
+	DumpStatsBaseline      string
+	CompareStatsBaseline   string
+	StatsBaselineTolerance float64
 
 	// 新しい検証設定
 	ValidateOnly     bool
 	StrictValidation bool
 	InteractiveMode  bool
+	SummaryThreshold int
+	Timing           bool // 処理の段階ごとの経過時間を表示（--timing）
+	SummaryOnly      bool // 検証のみモードで行ごとの詳細を表示せず件数だけを表示（--report-summary-only）
+	GroupErrors      bool
+	OutputFormat     string
+	TreatUnknownAs   string
 	HelpMode         string
 	SuggestionLevel  int
 	SkipDeprecated   bool
-	ColorEnabled     bool
+	FailOnDeprecated bool
+	CheckPaths       bool
+	ColorMode        string // --color (auto/always/never)
+	ColorEnabled     bool   // ColorMode を標準エラー出力に対して判定した結果
 	LanguageCode     string
+	UsacloudVersion  string
+	RuleOrder        []string
+	EnableRules      []string
+	DisableRules     []string
+	EnableOnly       []string
+	SinceVersion     string // 移行元の usacloud バージョン（このバージョン以前向けのルールは適用しない）
+	TargetVersion    string // 移行先の usacloud バージョン（これより新しいバージョン向けのルールは適用しない）
 
 	// サンドボックス設定
 	SandboxMode        bool
@@ -177,16 +254,19 @@ type Config struct {
 
 	// 設定ファイル
 	ConfigFile string
+	Profile    string // 適用するプロファイル名（--profile）
 }
 
 // ValidationConfig は検証システム設定
 type ValidationConfig struct {
 	MaxSuggestions        int
 	MaxDistance           int
+	DistanceAlgorithm     string // 類似コマンドの編集距離 (levenshtein/damerau-levenshtein)
 	EnableTypoDetection   bool
 	EnableInteractiveHelp bool
 	ErrorFormat           string
 	LogLevel              string
+	CacheSizeMB           int // 類似コマンド提案のキャッシュ上限（0 でキャッシュしない）
 }
 
 // IntegratedCLI は統合CLIインターフェース
@@ -196,40 +276,80 @@ type IntegratedCLI struct {
 	transformEngine    *transform.Engine
 	mainValidator      *validation.MainCommandValidator
 	subValidator       *validation.SubcommandValidator
+	optionValidator    *validation.OptionValidator
 	deprecatedDetector *validation.DeprecatedCommandDetector
 	similarSuggester   *validation.SimilarCommandSuggester
+	pipelineAnalyzer   *validation.PipelineAnalyzer
 	errorFormatter     *validation.ComprehensiveErrorFormatter
 	helpSystem         *validation.UserFriendlyHelpSystem
 	cliErrorFormatter  *errors.ErrorFormatter
 	fileReader         *cliio.FileReader
+	changeFormat       *transform.ChangeFormat
+	decisionInput      *bufio.Reader   // interactive answers (stdin or /dev/tty)
+	decisionCloser     io.Closer       // terminal opened for decisions
+	stats              transform.Stats // aggregate of the last processLines call
+	progress           *progress.Bar   // progress of the running multi-file or large-file run, nil when not drawn
+	binaryVars         map[string]bool // variables the file being processed assigns the usacloud binary to
+	timer              *phaseTimer     // time spent in each phase for --timing, nil when not timed
 }
 
 // NewIntegratedCLI は新しい統合CLIを作成
 func NewIntegratedCLI() *IntegratedCLI {
 	cfg := parseFlags()
+	// 進捗表示の設定は設定ファイルから読むため、何度も呼ばれる parseFlags ではなくここで一度だけ決定
+	cfg.ShowProgress, cfg.ProgressStyle = resolveProgressSettings()
 	valCfg := loadValidationConfig()
 
 	// 検証システムの初期化
-	mainValidator := validation.NewMainCommandValidator()
+	mainValidator, err := validation.NewMainCommandValidatorForVersion(cfg.UsacloudVersion)
+	if err != nil {
+		// runMainLogic rejects unknown versions up front; fall back for other callers
+		mainValidator = validation.NewMainCommandValidator()
+	}
 	subValidator := validation.NewSubcommandValidator(mainValidator)
 	deprecatedDetector := validation.NewDeprecatedCommandDetector()
-	similarSuggester := validation.NewSimilarCommandSuggester(valCfg.MaxDistance, valCfg.MaxSuggestions)
+	// runMainLogic rejects unknown algorithms up front; other callers fall back to Levenshtein
+	algorithm, _ := validation.ParseDistanceAlgorithm(valCfg.DistanceAlgorithm)
+	similarSuggester := validation.NewSimilarCommandSuggester(valCfg.MaxDistance, valCfg.MaxSuggestions,
+		validation.SuggesterOptions{Algorithm: algorithm})
+	similarSuggester.SetCacheSize(int64(valCfg.CacheSizeMB) << 20)
 	errorFormatter := validation.NewDefaultComprehensiveErrorFormatter()
-	helpSystem := validation.NewDefaultUserFriendlyHelpSystem()
-	cliErrorFormatter := errors.NewErrorFormatter(*colorEnabled)
+	errorFormatter.SetColorEnabled(cfg.ColorEnabled)
+	errorFormatter.SetSuggestionLevel(cfg.SuggestionLevel)
+	helpSystem := validation.NewUserFriendlyHelpSystem(mainValidator, subValidator, errorFormatter, true, cfg.LanguageCode)
+	cliErrorFormatter := errors.NewErrorFormatter(cfg.ColorEnabled)
+
+	transformEngine := transform.NewDefaultEngine()
+	if rules, err := resolveEffectiveRules(cfg); err == nil {
+		transformEngine = transform.NewEngineFromEffectiveRules(rules)
+	}
+	if since, target, err := resolveMigrationVersions(cfg); err == nil {
+		transformEngine = transformEngine.WithVersions(since, target)
+	}
+	if cfg.AddAssumeYes {
+		transformEngine = transformEngine.WithAssumeYes()
+	}
+	// runMainLogic rejects invalid rule settings up front; other callers fall back to the defaults
+	changeFormat, _ := transform.ParseChangeFormat(cfg.StatFormat)
 
 	cli := &IntegratedCLI{
 		config:             cfg,
 		validationConfig:   valCfg,
-		transformEngine:    transform.NewDefaultEngine(),
+		transformEngine:    transformEngine,
 		mainValidator:      mainValidator,
 		subValidator:       subValidator,
+		optionValidator:    validation.NewOptionValidator(mainValidator),
 		deprecatedDetector: deprecatedDetector,
 		similarSuggester:   similarSuggester,
+		pipelineAnalyzer:   validation.NewPipelineAnalyzer(),
 		errorFormatter:     errorFormatter,
 		helpSystem:         helpSystem,
 		cliErrorFormatter:  cliErrorFormatter,
 		fileReader:         cliio.NewFileReader(),
+		changeFormat:       changeFormat,
+	}
+	if cfg.Timing {
+		cli.timer = newPhaseTimer()
 	}
 
 	return cli
@@ -253,11 +373,26 @@ func (cli *IntegratedCLI) runValidationMode() error {
 
 // runIntegratedMode は変換と検証を統合したモードを実行
 func (cli *IntegratedCLI) runIntegratedMode() error {
+	if cli.isRecursiveInput() {
+		return cli.runRecursiveMode()
+	}
+	if len(cli.config.InputPaths) > 1 {
+		return cli.runMultiInputMode()
+	}
+	// 標準入力から標準出力への変換は入力全体を読み込まずに行単位で変換する
+	if cli.canStream() {
+		return cli.runStreamMode()
+	}
+
 	// 入力ファイル読み込み
 	content, err := cli.readInputFile()
 	if err != nil {
 		return fmt.Errorf("入力ファイル読み込みエラー: %w", err)
 	}
+	content, err = cli.checkAlreadyProcessed(content)
+	if err != nil {
+		return err
+	}
 
 	// バッチモード処理
 	results, err := cli.processLines(content)
@@ -265,92 +400,346 @@ func (cli *IntegratedCLI) runIntegratedMode() error {
 		return fmt.Errorf("処理エラー: %w", err)
 	}
 
+	// 受け入れた変更だけを出力する（来歴・統計も確認後の結果で出力）
+	if cli.config.Review {
+		if err := cli.reviewChanges(results); err != nil {
+			return err
+		}
+	}
+
+	// 監査用の変換来歴は --in-place で上書きされる前の入力からハッシュを計算し、出力に成功してから書き出す
+	var records []provenance.Record
+	if cli.config.ProvenancePath != "" {
+		if records, err = cli.provenanceRecords(results, time.Now()); err != nil {
+			return fmt.Errorf("変換来歴の出力に失敗しました: %s: %w", cli.config.ProvenancePath, err)
+		}
+	}
+
 	// 出力生成
 	err = cli.generateOutput(results)
 	if err != nil {
 		return err
 	}
 
-	// 変換完了メッセージを標準出力に出力
-	fmt.Println("✅ 変換完了")
+	if cli.config.ProvenancePath != "" {
+		if err := cli.writeProvenance(records); err != nil {
+			return fmt.Errorf("変換来歴の出力に失敗しました: %s: %w", cli.config.ProvenancePath, err)
+		}
+	}
 
-	return nil
+	if cli.config.ChangesCSVPath != "" {
+		if err := cli.writeChangesCSV(cli.collectChanges(cli.inputName(), results)); err != nil {
+			return err
+		}
+	}
+	if cli.config.MigrationReport != "" {
+		if err := writeMigrationReports(cli.config.MigrationReport, []fileMigration{cli.classifyMigration(cli.inputName(), results)}); err != nil {
+			return err
+		}
+	}
+
+	if cli.config.ShowStats {
+		writeTransformStats(os.Stderr, cli.stats)
+	}
+
+	// 変換完了メッセージを標準出力に出力（差分出力時はパッチとして扱えるよう出力しない）
+	if !cli.config.DiffMode {
+		fmt.Println("✅ 変換完了")
+	}
+
+	if err := cli.finishStatsBaseline(cli.stats); err != nil {
+		return err
+	}
+	return deprecatedFailure(cli.collectDeprecated(cli.inputName(), results))
 }
 
 // readInputFile は入力ファイルを読み込み
 func (cli *IntegratedCLI) readInputFile() ([]string, error) {
+	defer cli.timer.record(phaseRead, time.Now())
+	if err := cli.fileReader.SetEncoding(cli.config.InputEncoding); err != nil {
+		return nil, exit.New(exit.Config, err)
+	}
+
 	lines, err := cli.fileReader.ReadInputLines(cli.config.InputPath)
 	if err != nil {
 		// Handle different error types with appropriate formatting
+		name := cli.inputName()
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("%s", cli.cliErrorFormatter.FormatFileNotFound(cli.config.InputPath))
+			return nil, exit.New(exit.IO, fmt.Errorf("%s", cli.cliErrorFormatter.FormatFileNotFound(name)))
 		}
 		if os.IsPermission(err) {
-			return nil, fmt.Errorf("%s", cli.cliErrorFormatter.FormatFilePermission(cli.config.InputPath, "読み取り"))
+			return nil, exit.New(exit.IO, fmt.Errorf("%s", cli.cliErrorFormatter.FormatFilePermission(name, "読み取り")))
 		}
 		if cliio.IsBinaryFileError(err) {
-			return nil, fmt.Errorf("%s", cli.cliErrorFormatter.FormatBinaryFile(cli.config.InputPath))
+			return nil, exit.New(exit.IO, fmt.Errorf("%s", cli.cliErrorFormatter.FormatBinaryFile(name)))
+		}
+		if cliio.IsDirectoryInputError(err) {
+			return nil, exit.New(exit.IO, fmt.Errorf("%s", cli.cliErrorFormatter.FormatDirectoryInput(name)))
 		}
-		return nil, fmt.Errorf("%s", cli.cliErrorFormatter.FormatFileRead(cli.config.InputPath, err))
+		return nil, exit.New(exit.IO, fmt.Errorf("%s", cli.cliErrorFormatter.FormatFileRead(name, err)))
 	}
 
 	// Check for empty file (but not stdin) - CLI-level validation
 	if cli.config.InputPath != "-" && len(lines) == 0 {
-		return nil, fmt.Errorf("空のファイルは処理できません: %s", cli.config.InputPath)
+		return nil, exit.New(exit.IO, fmt.Errorf("空のファイルは処理できません: %s", cli.config.InputPath))
 	}
 
 	return lines, nil
 }
 
+// inputName はメッセージや出力で入力を表す名前を返す
+// 標準入力は --stdin-filename の値（未指定時は "-"）、ファイルはそのパス
+func (cli *IntegratedCLI) inputName() string {
+	if cli.config.InputPath == "-" && cli.config.StdinFilename != "" {
+		return cli.config.StdinFilename
+	}
+	return cli.config.InputPath
+}
+
+// checkAlreadyProcessed は入力が usacloud-update で変換済み（生成ヘッダーを含む）か確認
+// 再変換するとルールやコメントが重複するため、--force がなければエラーにし、--force では生成ヘッダーの行を取り除いて返す
+// 逆変換は変換済みのスクリプトを入力とするため確認しない
+func (cli *IntegratedCLI) checkAlreadyProcessed(lines []string) ([]string, error) {
+	if cli.config.ReverseMode {
+		return lines, nil
+	}
+
+	var kept []string
+	headerLine := 0
+	for i, line := range lines {
+		if transform.IsGeneratedHeader(line) {
+			if headerLine == 0 {
+				headerLine = i + 1
+			}
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if headerLine == 0 {
+		return lines, nil
+	}
+
+	if !cli.config.Force {
+		return nil, cli.alreadyProcessedError(headerLine)
+	}
+	fmt.Fprintf(cli.stderr(), color.YellowString("⚠️  %s は変換済みです。既存の生成ヘッダーを取り除いて再変換します\n"), cli.alreadyProcessedName())
+	return kept, nil
+}
+
+// alreadyProcessedError は headerLine 行目に生成ヘッダーがある入力を変換しないエラーを返す
+func (cli *IntegratedCLI) alreadyProcessedError(headerLine int) error {
+	return exit.New(exit.AlreadyProcessed, fmt.Errorf(
+		"%s は usacloud-update で変換済みです（%d行目に生成ヘッダーがあります）。再変換するとルールやコメントが重複するおそれがあるため、再変換する場合は --force を指定してください",
+		cli.alreadyProcessedName(), headerLine))
+}
+
+// alreadyProcessedName は変換済みのメッセージで入力を表す名前（標準入力は「標準入力」）
+func (cli *IntegratedCLI) alreadyProcessedName() string {
+	if name := cli.inputName(); name != "-" {
+		return name
+	}
+	return "標準入力"
+}
+
 // processLines は行ごとの処理を実行（変換と検証の統合）
+// 各行の変換と検証は --jobs 個のワーカーで並列に行い、結果と表示は行順に組み立てる
 func (cli *IntegratedCLI) processLines(lines []string) ([]*ProcessResult, error) {
-	var results []*ProcessResult
+	// $USACLOUD のように usacloud のパスを代入した変数経由のコマンドも変換・検証する
+	cli.binaryVars = validation.BinaryVariables(lines)
+	engine := cli.transformEngine.WithBinaryVariables(cli.binaryVars)
+
+	// 既存の変換処理（--reverse ではv0の構文へ逆変換）
+	apply := engine.Apply
+	switch {
+	case cli.config.ReverseMode:
+		apply = engine.ApplyReverse
+	case cli.config.Passes > 1:
+		apply = func(line string) transform.Result {
+			return engine.ApplyPasses(line, cli.config.Passes)
+		}
+	}
 
-	for lineNumber, line := range lines {
-		lineNum := lineNumber + 1
+	// 行末の \ で継続された行は1つのコマンドとして変換・検証し、出力では元の改行位置で分割する
+	// ヒアドキュメントの本文は --transform-heredocs がなければ長すぎる行と同じく変換・検証しない
+	logical := transform.JoinContinuations(lines)
+	commands := make([]string, len(logical))
+	verbatim := make([]bool, len(logical))
+	for i, l := range logical {
+		verbatim[i] = (l.Heredoc && !cli.config.TransformHeredocs) || cli.hasOversizedLine(l)
+		if !verbatim[i] {
+			commands[i] = l.Text()
+		}
+	}
 
-		// 既存の変換処理
-		transformResult := cli.transformEngine.Apply(line)
+	// 大きなファイルは行単位の進捗を表示（複数ファイルの処理中はファイル単位の進捗を優先）
+	var bar *progress.Bar
+	if cli.progress == nil && len(lines) >= progressLineThreshold {
+		defer cli.startProgress(len(commands))()
+		cli.progress.SetLabel(cli.inputName())
+		bar = cli.progress
+	}
 
-		// 新しい検証処理（変換前）
-		var validationResult *ValidationResult
-		if !cli.config.SkipDeprecated {
-			validationResult = cli.validateLine(line, lineNum)
+	outcomes, failedAt := cli.evaluateLines(commands, apply, bar)
 
-			// 厳格検証モードでエラーがあれば停止
-			if cli.config.StrictValidation && validationResult != nil && validationResult.HasErrors() {
-				return nil, fmt.Errorf("行 %d で検証エラー: %s", lineNum, validationResult.GetErrorSummary())
-			}
+	results := make([]*ProcessResult, 0, len(lines))
+	cli.stats = transform.Stats{}
+	for i, l := range logical {
+		outcome := &outcomes[i]
+		if verbatim[i] {
+			// 変換・検証せずに元の行のまま出力する
+			*outcome = lineOutcome{transform: transform.Result{Original: l.Text(), Line: l.Text()}}
 		}
 
-		// 統合結果の作成
-		result := &ProcessResult{
-			LineNumber:       lineNum,
-			OriginalLine:     line,
-			TransformResult:  &transformResult,
-			ValidationResult: validationResult,
+		// 厳格検証モードでは検証エラーのある最初の行で停止
+		if i == failedAt {
+			return nil, exit.New(exit.Validation, fmt.Errorf("行 %d で検証エラー: %s", l.Start+1, outcome.validation.GetErrorSummary()))
 		}
 
-		results = append(results, result)
+		for k, physical := range l.Expand(outcome.transform) {
+			lineNum := l.Start + k + 1
+			transformResult := &physical
+			cli.stats.Add(*transformResult)
+
+			// 統合結果の作成（検証結果はコマンドの先頭行に付ける）
+			result := &ProcessResult{
+				LineNumber:      lineNum,
+				OriginalLine:    l.Parts[k],
+				TransformResult: transformResult,
+			}
+			if k == 0 {
+				// evaluateLines はコマンド単位で番号を振るため、物理行の番号に合わせる
+				if outcome.validation != nil {
+					outcome.validation.LineNumber = lineNum
+				}
+				result.ValidationResult = outcome.validation
+				result.DeprecatedCommand = outcome.deprecated
+
+				// 参照しているローカルファイルが存在しなければ警告（変換結果は変えない）
+				for _, m := range outcome.missingPaths {
+					fmt.Fprintf(cli.stderr(), color.YellowString("⚠️  L%d: %s\n"), lineNum, missingPathMessage(m))
+				}
+			}
+
+			results = append(results, result)
+
+			// リアルタイム出力（既存機能、--quiet では最後の統計のみ、--review では確認画面で表示）
+			if len(transformResult.Changes) > 0 && cli.config.ShowStats && !cli.config.Quiet && !cli.config.Review {
+				cli.outputColorizedChange(result.TransformResult, lineNum)
+			}
+
+			// 変更理由の詳細説明
+			if len(transformResult.Changes) > 0 && cli.config.ExplainChanges {
+				cli.writeExplanations(cli.stderr(), result.TransformResult, lineNum)
+			}
 
-		// リアルタイム出力（既存機能）
-		if transformResult.Changed && cli.config.ShowStats {
-			cli.outputColorizedChange(result.TransformResult, lineNum)
+			// 逆変換で元に戻せなかったルール（出力は元のv0スクリプトと一致しない可能性がある）
+			for _, s := range transformResult.Skipped {
+				fmt.Fprintf(cli.stderr(), color.YellowString("⚠️  L%d: %s は元に戻せません: %s\n"), lineNum, s.RuleName, s.Reason)
+			}
 		}
 	}
 
 	return results, nil
 }
 
+// hasOversizedLine は --max-line-length を超える行がコマンドに含まれるかを判定し、超える行ごとに警告を表示
+func (cli *IntegratedCLI) hasOversizedLine(l transform.LogicalLine) bool {
+	oversized := false
+	for _, k := range cli.oversizedParts(l) {
+		fmt.Fprintf(cli.stderr(), color.YellowString("⚠️  %s:%d: 行が長すぎるため変換・検証せずにそのまま出力します (%d バイト、--max-line-length %d)\n"),
+			cli.inputName(), l.Start+k+1, len(l.Parts[k]), cli.config.MaxLineLength)
+		oversized = true
+	}
+	return oversized
+}
+
+// oversizedParts はコマンドのうち --max-line-length を超える行の位置を返す
+func (cli *IntegratedCLI) oversizedParts(l transform.LogicalLine) []int {
+	if cli.config.MaxLineLength <= 0 {
+		return nil
+	}
+	var parts []int
+	for k, part := range l.Parts {
+		if len(part) > cli.config.MaxLineLength {
+			parts = append(parts, k)
+		}
+	}
+	return parts
+}
+
+// missingPaths は行のusacloudコマンドが参照する存在しないローカルファイルを返す
+// 相対パスは変換を実行しているカレントディレクトリを基準に確認する
+func (cli *IntegratedCLI) missingPaths(line string) []validation.MissingPath {
+	if strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return nil
+	}
+	parsed, err := cli.newParser().Parse(line)
+	if err != nil {
+		return nil
+	}
+	return cli.mainValidator.CheckFilePaths(parsed, ".")
+}
+
+func missingPathMessage(m validation.MissingPath) string {
+	return fmt.Sprintf("--%s で指定されたファイルが見つかりません: %s", m.Option, m.Path)
+}
+
+// writeTransformStats は変換全体の統計（変更行数とルールごとの適用回数）を表示
+func writeTransformStats(w io.Writer, stats transform.Stats) {
+	fmt.Fprintf(w, "\n📊 変換統計: %d行中 %d行を変更\n", stats.TotalLines, stats.ChangedLines)
+	for _, hit := range stats.SortedRuleHits() {
+		fmt.Fprintf(w, "  • %s: %d回適用\n", hit.RuleName, hit.Count)
+	}
+}
+
+// writeSuggestionCacheStats は類似コマンド提案のキャッシュの利用状況を表示（log_level = debug の診断用）
+func writeSuggestionCacheStats(w io.Writer, stats validation.CacheStats) {
+	if stats.MaxBytes == 0 {
+		fmt.Fprintln(w, "🔍 類似コマンド提案キャッシュ: 無効")
+		return
+	}
+	fmt.Fprintf(w, "🔍 類似コマンド提案キャッシュ: ヒット %d件・ミス %d件、%d件を保持 (約 %.1f KB / 上限 %d MB)\n",
+		stats.Hits, stats.Misses, stats.Entries, float64(stats.Bytes)/1024, stats.MaxBytes>>20)
+}
+
+// unknownSubcommandIssue はコマンドカタログで mainCommand のサブコマンドにない subCommand の問題を
+// --treat-unknown-as に従って返す（ignore では報告しないため false）
+func (cli *IntegratedCLI) unknownSubcommandIssue(mainCommand, subCommand string) (ValidationIssue, bool) {
+	switch cli.config.TreatUnknownAs {
+	case unknownAsIgnore:
+		return ValidationIssue{}, false
+	case unknownAsWarning:
+		return ValidationIssue{
+			Type:      IssueInvalidSubCommand,
+			Message:   fmt.Sprintf("'%s' は usacloud %s のコマンドカタログで %s コマンドのサブコマンドにありません（新しいサブコマンドの可能性があります）", subCommand, cli.mainValidator.Version(), mainCommand),
+			Component: subCommand,
+			Advisory:  true,
+		}, true
+	default:
+		return ValidationIssue{
+			Type:      IssueInvalidSubCommand,
+			Message:   fmt.Sprintf("'%s' は %s コマンドの有効なサブコマンドではありません", subCommand, mainCommand),
+			Component: subCommand,
+		}, true
+	}
+}
+
+// newParser は処理中のファイルで usacloud のパスを代入した変数も解釈するパーサーを作成
+func (cli *IntegratedCLI) newParser() *validation.Parser {
+	return validation.NewParserWithBinaryVariables(cli.binaryVars)
+}
+
 // validateLine は単一行の検証を実行
+// sudo・env などを前に付けたコマンドやバイナリのパス・変数経由のコマンドも検証する
 func (cli *IntegratedCLI) validateLine(line string, lineNumber int) *ValidationResult {
-	// usacloudコマンドでない行はスキップ
-	if !strings.Contains(line, "usacloud") {
+	parser := cli.newParser()
+
+	// usacloudコマンドを実行しない行（usacloud を含むだけの代入や echo など）はスキップ
+	if !parser.IsUsacloudCommand(line) {
 		return nil
 	}
 
 	// コマンド解析
-	parser := validation.NewParser()
 	parsed, err := parser.Parse(line)
 	if err != nil {
 		return &ValidationResult{
@@ -397,15 +786,13 @@ func (cli *IntegratedCLI) validateLine(line string, lineNumber int) *ValidationR
 			// 廃止コマンドのサブコマンドは代替コマンドに対して検証
 			replacementCommand := deprecatedInfo.ReplacementCommand
 			if replacementCommand != "" && !cli.subValidator.IsValidSubcommand(replacementCommand, parsed.SubCommand) {
-				issues = append(issues, ValidationIssue{
-					Type:      IssueInvalidSubCommand,
-					Message:   fmt.Sprintf("'%s' は %s コマンドの有効なサブコマンドではありません", parsed.SubCommand, parsed.MainCommand),
-					Component: parsed.SubCommand,
-				})
+				if issue, ok := cli.unknownSubcommandIssue(parsed.MainCommand, parsed.SubCommand); ok {
+					issues = append(issues, issue)
 
-				// サブコマンド提案を取得（代替コマンド用）
-				subSuggestions := cli.similarSuggester.SuggestSubcommands(replacementCommand, parsed.SubCommand)
-				suggestions = append(suggestions, subSuggestions...)
+					// サブコマンド提案を取得（代替コマンド用）
+					subSuggestions := cli.similarSuggester.SuggestSubcommands(replacementCommand, parsed.SubCommand)
+					suggestions = append(suggestions, subSuggestions...)
+				}
 			} else if replacementCommand == "" {
 				// 代替コマンドがない場合、サブコマンドも無効として扱う
 				issues = append(issues, ValidationIssue{
@@ -419,14 +806,40 @@ func (cli *IntegratedCLI) validateLine(line string, lineNumber int) *ValidationR
 		// 廃止されていない場合のみメインコマンドの有効性を検証
 		mainValidationResult := cli.mainValidator.Validate(parsed.MainCommand)
 		if !mainValidationResult.IsValid {
-			issues = append(issues, ValidationIssue{
-				Type:      IssueInvalidMainCommand,
-				Message:   fmt.Sprintf("'%s' は有効なusacloudコマンドではありません", parsed.MainCommand),
-				Component: parsed.MainCommand,
-			})
+			// カタログにないコマンドは --treat-unknown-as に従って報告する
+			switch cli.config.TreatUnknownAs {
+			case unknownAsIgnore:
+			case unknownAsWarning:
+				issues = append(issues, ValidationIssue{
+					Type:      IssueInvalidMainCommand,
+					Message:   fmt.Sprintf("'%s' は usacloud %s のコマンドカタログにありません（新しいコマンドの可能性があります）", parsed.MainCommand, cli.mainValidator.Version()),
+					Component: parsed.MainCommand,
+					Advisory:  true,
+				})
+				suggestions = cli.similarSuggester.SuggestMainCommands(parsed.MainCommand)
+			default:
+				issues = append(issues, ValidationIssue{
+					Type:      IssueInvalidMainCommand,
+					Message:   fmt.Sprintf("'%s' は有効なusacloudコマンドではありません", parsed.MainCommand),
+					Component: parsed.MainCommand,
+				})
 
-			// 類似提案を取得
-			suggestions = cli.similarSuggester.SuggestMainCommands(parsed.MainCommand)
+				// 類似提案を取得
+				suggestions = cli.similarSuggester.SuggestMainCommands(parsed.MainCommand)
+
+				// サブコマンドも誤っている場合は「メイン サブ」の組で提案する
+				if parsed.SubCommand != "" {
+					for _, c := range cli.similarSuggester.SuggestCommandPairs(parsed.MainCommand, parsed.SubCommand) {
+						if c.SubCommand == parsed.SubCommand {
+							continue // サブコマンドが正しければメインコマンドの提案で足りる
+						}
+						suggestions = append(suggestions, validation.SimilarityResult{
+							Command: c.String(),
+							Score:   c.Score,
+						})
+					}
+				}
+			}
 		} else if mainValidationResult.Message != "" {
 			// Case sensitivity issue - treat as invalid for strict validation
 			issues = append(issues, ValidationIssue{
@@ -445,19 +858,61 @@ func (cli *IntegratedCLI) validateLine(line string, lineNumber int) *ValidationR
 		} else {
 			// メインコマンドが有効な場合のみサブコマンド検証を行う
 			if parsed.SubCommand != "" && !cli.subValidator.IsValidSubcommand(parsed.MainCommand, parsed.SubCommand) {
+				if issue, ok := cli.unknownSubcommandIssue(parsed.MainCommand, parsed.SubCommand); ok {
+					issues = append(issues, issue)
+
+					// サブコマンド提案を取得
+					subSuggestions := cli.similarSuggester.SuggestSubcommands(parsed.MainCommand, parsed.SubCommand)
+					suggestions = append(suggestions, subSuggestions...)
+				}
+			}
+
+			// --zone・--output-type などのオプションの値と、コマンドにないオプションの検証
+			// コマンドにないオプションはカタログの一覧が不完全な可能性があるため警告にとどめる
+			for _, optionIssue := range cli.optionValidator.Validate(parsed) {
 				issues = append(issues, ValidationIssue{
-					Type:      IssueInvalidSubCommand,
-					Message:   fmt.Sprintf("'%s' は %s コマンドの有効なサブコマンドではありません", parsed.SubCommand, parsed.MainCommand),
-					Component: parsed.SubCommand,
+					Type:      IssueInvalidOption,
+					Message:   optionIssue.Message,
+					Component: "--" + optionIssue.Option,
+					Advisory:  optionIssue.Unknown,
 				})
-
-				// サブコマンド提案を取得
-				subSuggestions := cli.similarSuggester.SuggestSubcommands(parsed.MainCommand, parsed.SubCommand)
-				suggestions = append(suggestions, subSuggestions...)
 			}
 		}
 	}
 
+	// jq へのパイプで出力形式がJSONでない場合の助言
+	if cli.pipelineAnalyzer != nil {
+		if advisory := cli.pipelineAnalyzer.Analyze(line); advisory != nil {
+			issues = append(issues, ValidationIssue{
+				Type:      IssueOutputFormatMismatch,
+				Message:   fmt.Sprintf("%s（%s）", advisory.Message, advisory.Suggestion),
+				Component: advisory.Consumer,
+			})
+		}
+	}
+
+	// --check-paths: 参照しているローカルファイルの存在確認
+	if cli.config.CheckPaths {
+		for _, m := range cli.mainValidator.CheckFilePaths(parsed, ".") {
+			issues = append(issues, ValidationIssue{
+				Type:      IssueMissingFile,
+				Message:   missingPathMessage(m),
+				Component: "--" + m.Option,
+				Advisory:  true,
+			})
+		}
+	}
+
+	// 確認を求めるコマンドは -y がないと端末のない環境で応答待ちになる
+	if validation.MissingAssumeYes(parsed) {
+		issues = append(issues, ValidationIssue{
+			Type:      IssueMissingAssumeYes,
+			Message:   missingAssumeYesMessage(parsed),
+			Component: parsed.SubCommand,
+			Advisory:  true,
+		})
+	}
+
 	if len(issues) == 0 {
 		return nil
 	}
@@ -470,51 +925,274 @@ func (cli *IntegratedCLI) validateLine(line string, lineNumber int) *ValidationR
 	}
 }
 
-// outputColorizedChange は変更をカラー出力
+func missingAssumeYesMessage(parsed *validation.CommandLine) string {
+	return fmt.Sprintf("'%s %s' は実行前に確認を求めるため、cron や CI など端末のない環境では停止または失敗します。-y (--assumeyes) を指定してください", parsed.MainCommand, parsed.SubCommand)
+}
+
+// outputColorizedChange は変更をカラー出力し、手動での確認が必要な変更は警告として区別して表示
 func (cli *IntegratedCLI) outputColorizedChange(result *transform.Result, lineNumber int) {
 	for _, change := range result.Changes {
-		fmt.Fprintf(os.Stderr, color.YellowString("#L%-5d %s => %s [%s]\n"),
-			lineNumber, change.Before, change.After, change.RuleName)
+		c := transform.ChangeLine{Line: lineNumber, Before: change.Before, After: change.After, Rule: change.RuleName}
+		line, err := cli.changeFormat.Format(c)
+		if err != nil {
+			// --stat-format は起動時に検査済み。実行時に失敗した場合は既定の形式で表示する
+			line, _ = (*transform.ChangeFormat)(nil).Format(c)
+		}
+		fmt.Fprintln(cli.stderr(), color.YellowString("%s", line))
+	}
+	for _, d := range result.Warnings() {
+		fmt.Fprintf(cli.stderr(), color.MagentaString("⚠️  L%d: 要確認: %s [%s]\n"), lineNumber, d.Message, d.RuleName)
+	}
+}
+
+// writeExplanations は適用された各変換ルールの詳細な説明を出力
+func (cli *IntegratedCLI) writeExplanations(w io.Writer, result *transform.Result, lineNumber int) {
+	fmt.Fprintf(w, color.CyanString("📖 L%d の変更内容:\n"), lineNumber)
+	for _, change := range result.Changes {
+		explanation, ok := cli.transformEngine.Explain(change.RuleName)
+		if !ok {
+			explanation = transform.Explanation{RuleName: change.RuleName}
+		}
+		fmt.Fprintf(w, "  %s => %s\n", change.Before, change.After)
+		fmt.Fprint(w, explanation.Format())
 	}
+	fmt.Fprint(w, "\n")
 }
 
 // generateOutput は出力を生成
 func (cli *IntegratedCLI) generateOutput(results []*ProcessResult) error {
+	defer cli.timer.record(phaseWrite, time.Now())
 	var outLines []string
 
 	for _, result := range results {
 		outLines = append(outLines, result.TransformResult.Line)
 	}
 
-	output := strings.Join(append([]string{transform.GeneratedHeader()}, outLines...), "\n") + "\n"
+	lineEnding, err := cli.resolveLineEnding()
+	if err != nil {
+		return err
+	}
+	sep := cliio.Separator(lineEnding)
+	header := transform.GeneratedHeader()
+	if cli.transformEngine != nil {
+		header = transform.GeneratedHeaderFor(cli.transformEngine.TargetVersion())
+	}
+	if cli.config.ReverseMode {
+		header = transform.ReverseHeader()
+	}
+	if !cli.config.NoHeader {
+		outLines = append([]string{header}, outLines...)
+	}
+	var output string
+	if len(outLines) > 0 {
+		output = strings.Join(outLines, sep) + sep
+	}
+	if cli.config.DiffMode {
+		output = cli.buildDiff(results)
+		if sep != "\n" {
+			output = strings.ReplaceAll(output, "\n", sep)
+		}
+	}
+
+	return cli.writeOutput(output)
+}
+
+// writeOutput は出力内容を --out（--in-place では入力ファイル）に書き出す
+func (cli *IntegratedCLI) writeOutput(output string) error {
+	// 出力文字コード未指定時は入力と同じ文字コードで書き出す
+	outputEncoding := cli.config.OutputEncoding
+	if outputEncoding == "" {
+		outputEncoding = cli.config.InputEncoding
+	}
+	output, err := cliio.EncodeContent(output, outputEncoding)
+	if err != nil {
+		return err
+	}
+
+	outputPath := cli.config.OutputPath
+	if cli.config.InPlace {
+		if cli.config.InputPath == "-" {
+			return fmt.Errorf("--in-place は標準入力 (-) には使用できません。--in でファイルを指定してください")
+		}
+		// 上書き前に元の内容をパーミッションごと退避する
+		outputPath = cli.config.InputPath
+		if cli.config.BackupOriginal {
+			backupPath := cli.config.InputPath + cliio.BackupSuffix
+			if err := cliio.BackupFile(cli.config.InputPath, backupPath); err != nil {
+				return fmt.Errorf("バックアップの作成に失敗しました: %s: %w", backupPath, err)
+			}
+		}
+	}
 
-	err := cliio.WriteOutputFile(cli.config.OutputPath, output)
+	err = cliio.WriteOutputFile(outputPath, output)
 	if err != nil {
 		// Handle different error types with appropriate formatting
 		if os.IsPermission(err) {
-			return fmt.Errorf("%s", cli.cliErrorFormatter.FormatFilePermission(cli.config.OutputPath, "書き込み"))
+			return exit.New(exit.IO, fmt.Errorf("%s", cli.cliErrorFormatter.FormatFilePermission(outputPath, "書き込み")))
 		}
 		if strings.Contains(err.Error(), "is a directory") {
-			return fmt.Errorf("出力先がディレクトリです: %s", cli.config.OutputPath)
+			return exit.New(exit.IO, fmt.Errorf("出力先がディレクトリです: %s", outputPath))
+		}
+		return exit.New(exit.IO, fmt.Errorf("%s", cli.cliErrorFormatter.FormatFileWrite(outputPath, err)))
+	}
+
+	// 入力ファイルのパーミッション（実行ビット等）を出力に引き継ぐ
+	if cli.config.PreservePermissions {
+		if err := cliio.CopyFileMode(cli.config.InputPath, outputPath); err != nil {
+			return fmt.Errorf("パーミッションの引き継ぎに失敗しました: %s: %w", outputPath, err)
 		}
-		return fmt.Errorf("%s", cli.cliErrorFormatter.FormatFileWrite(cli.config.OutputPath, err))
 	}
 
 	return nil
 }
 
+// buildDiff は元の入力と変換結果の差分をunified diff形式で生成（変更がなければ空）
+func (cli *IntegratedCLI) buildDiff(results []*ProcessResult) string {
+	lines := make([]cliio.DiffLine, 0, len(results))
+	for _, result := range results {
+		line := cliio.DiffLine{Original: result.OriginalLine, Transformed: result.TransformResult.Line}
+		for _, c := range result.TransformResult.Changes {
+			line.Rules = append(line.Rules, c.RuleName)
+		}
+		lines = append(lines, line)
+	}
+
+	name := cli.inputName()
+	if name == "" || name == "-" {
+		name = "stdin"
+	}
+	return cliio.UnifiedDiff("a/"+name, "b/"+name, lines, cliio.DefaultDiffContext)
+}
+
+// provenanceRecords は変更された行ごとの来歴を作成
+func (cli *IntegratedCLI) provenanceRecords(results []*ProcessResult, timestamp time.Time) ([]provenance.Record, error) {
+	src := provenance.Source{Path: cli.inputName()}
+	if cli.transformEngine != nil {
+		src.SourceVersion, src.TargetVersion = cli.transformEngine.SourceVersion(), cli.transformEngine.TargetVersion()
+	}
+
+	// ファイル入力は元のバイト列、標準入力は読み込んだ行からハッシュを計算
+	if cli.config.InputPath != "-" {
+		content, err := os.ReadFile(cli.config.InputPath)
+		if err != nil {
+			return nil, err
+		}
+		src.SHA256 = provenance.HashContent(content)
+	} else {
+		lines := make([]string, len(results))
+		for i, result := range results {
+			lines[i] = result.OriginalLine
+		}
+		src.SHA256 = provenance.HashContent([]byte(strings.Join(lines, "\n") + "\n"))
+	}
+
+	var records []provenance.Record
+	for _, result := range results {
+		if record, ok := provenance.NewRecord(src, result.LineNumber, result.TransformResult, timestamp); ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// writeProvenance は来歴レコードを JSON Lines で書き出す
+func (cli *IntegratedCLI) writeProvenance(records []provenance.Record) error {
+	f, err := os.Create(cli.config.ProvenancePath)
+	if err != nil {
+		return err
+	}
+	if err := provenance.Write(f, records); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// resolveLineEnding は出力に使用する改行コードを決定
+func (cli *IntegratedCLI) resolveLineEnding() (string, error) {
+	switch cli.config.LineEnding {
+	case "", cliio.LineEndingLF:
+		return cliio.LineEndingLF, nil
+	case cliio.LineEndingCRLF:
+		return cliio.LineEndingCRLF, nil
+	case "auto":
+		// 入力で最も多く使われている改行コードに統一
+		if cli.fileReader == nil {
+			return cliio.LineEndingLF, nil
+		}
+		return cli.fileReader.LineEndings().Dominant(), nil
+	default:
+		return "", fmt.Errorf("無効な改行コード指定です: %s (lf/crlf/auto のいずれかを指定してください)", cli.config.LineEnding)
+	}
+}
+
+// warnMixedLineEndings は入力ファイルの改行コード混在を警告
+func (cli *IntegratedCLI) warnMixedLineEndings() {
+	if cli.fileReader == nil {
+		return
+	}
+
+	endings := cli.fileReader.LineEndings()
+	if !endings.IsMixed() {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, color.YellowString("⚠️  改行コードが混在しています: CRLF %d行, LF %d行 (主な改行コード: %s)\n"),
+		endings.CRLF, endings.LF, strings.ToUpper(endings.Dominant()))
+	fmt.Fprintf(os.Stderr, "   --line-ending=%s を指定すると変換時に改行コードを統一できます\n\n", endings.Dominant())
+}
+
 // performValidationOnly は検証のみを実行
 func (cli *IntegratedCLI) performValidationOnly(lines []string) error {
-	fmt.Fprint(os.Stderr, color.CyanString("🔍 検証を実行中...\n\n"))
+	// JSON/SARIF出力時は jq 等にそのまま渡せるよう、検証結果以外は出力しない
+	structuredOutput := cli.config.OutputFormat == "json" || cli.config.OutputFormat == "sarif"
+	if !structuredOutput {
+		if !cli.config.SummaryOnly {
+			fmt.Fprint(os.Stderr, color.CyanString("🔍 検証を実行中...\n\n"))
+		}
+		cli.warnMixedLineEndings()
+	}
 
 	var allIssues []ValidationResult
+	cli.binaryVars = validation.BinaryVariables(lines)
 
-	for lineNumber, line := range lines {
-		result := cli.validateLine(line, lineNumber+1)
+	// 行末の \ で継続された行は1つのコマンドとして先頭行の行番号で検証する
+	validateStart := time.Now()
+	for _, l := range transform.JoinContinuations(lines) {
+		if l.Heredoc && !cli.config.TransformHeredocs {
+			continue
+		}
+		result := cli.validateLine(l.Text(), l.Start+1)
 		if result != nil {
 			allIssues = append(allIssues, *result)
 		}
 	}
+	cli.timer.record(phaseValidate, validateStart)
+
+	if structuredOutput {
+		var err error
+		if cli.config.OutputFormat == "sarif" {
+			err = writeValidationSARIF(os.Stdout, cli.inputName(), allIssues)
+		} else {
+			// --timing の計測結果は metadata として同じ JSON に含める
+			var metadata *validationReportMetadata
+			if cli.timer != nil {
+				timing := cli.timer.report()
+				metadata = &validationReportMetadata{Timing: &timing}
+			}
+			err = writeValidationJSON(os.Stdout, cli.inputName(), allIssues, metadata)
+		}
+		if err != nil {
+			return fmt.Errorf("検証結果の出力に失敗しました: %w", err)
+		}
+		return validationFailure(allIssues)
+	}
+
+	// --report-summary-only では問題の件数だけを表示
+	if cli.config.SummaryOnly {
+		writeValidationSummary(os.Stdout, allIssues)
+		return validationFailure(allIssues)
+	}
 
 	// 結果表示
 	if len(allIssues) == 0 {
@@ -523,24 +1201,17 @@ func (cli *IntegratedCLI) performValidationOnly(lines []string) error {
 		return nil
 	}
 
+	// 問題数がしきい値以下ならレポートを省略する（終了ステータスは変えない）
+	if len(allIssues) <= cli.config.SummaryThreshold {
+		return validationFailure(allIssues)
+	}
+
 	// 構造化されたエラーレポートを出力
 	fmt.Fprint(os.Stderr, color.CyanString("📋 検証結果\n"))
 	fmt.Fprintf(os.Stderr, color.YellowString("⚠️  %d個の問題が見つかりました:\n\n"), len(allIssues))
 
 	// エラーと警告を分類
-	var errorCount, warningCount int
-	for _, issue := range allIssues {
-		for _, issueDetail := range issue.Issues {
-			switch issueDetail.Type {
-			case IssueInvalidMainCommand, IssueInvalidSubCommand:
-				errorCount++
-			case IssueDeprecatedCommand:
-				warningCount++
-			default:
-				errorCount++
-			}
-		}
-	}
+	errorCount, warningCount := countIssueSeverities(allIssues)
 
 	// セクション別レポート
 	if errorCount > 0 {
@@ -552,6 +1223,7 @@ func (cli *IntegratedCLI) performValidationOnly(lines []string) error {
 	fmt.Fprint(os.Stderr, "\n")
 
 	// 詳細なエラー情報を表示
+	var grouped []validation.LineErrorContext
 	for _, issue := range allIssues {
 		context := &validation.ErrorContext{
 			InputCommand:   issue.Line,
@@ -559,27 +1231,75 @@ func (cli *IntegratedCLI) performValidationOnly(lines []string) error {
 			Suggestions:    issue.Suggestions,
 		}
 
+		// --group-errors では同じコマンドの問題をまとめて最後に表示
+		if cli.config.GroupErrors {
+			grouped = append(grouped, validation.LineErrorContext{LineNumber: issue.LineNumber, Context: context})
+			continue
+		}
+
 		errorMessage := cli.errorFormatter.FormatError(context)
 		fmt.Fprint(os.Stderr, errorMessage)
 		fmt.Fprint(os.Stderr, "\n")
 	}
+	if len(grouped) > 0 {
+		fmt.Fprint(os.Stderr, cli.errorFormatter.FormatGroupedErrors(grouped))
+		fmt.Fprint(os.Stderr, "\n")
+	}
 
-	return fmt.Errorf("%d個の検証エラーが見つかりました", len(allIssues))
+	return validationFailure(allIssues)
 }
 
-// convertToValidationIssues は内部のValidationIssueを検証システムの型に変換
-func convertToValidationIssues(issues []ValidationIssue) []validation.ValidationIssue {
-	var result []validation.ValidationIssue
-
-	for _, issue := range issues {
-		// 対応する変換処理
-		validationIssue := validation.ValidationIssue{
-			Type:      convertIssueType(issue.Type),
+// countIssueSeverities は検証結果の問題をエラーと警告に分けて数える
+func countIssueSeverities(results []ValidationResult) (errorCount, warningCount int) {
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			if issue.EffectiveSeverity() == severityWarning {
+				warningCount++
+			} else {
+				errorCount++
+			}
+		}
+	}
+	return errorCount, warningCount
+}
+
+// writeValidationSummary は --report-summary-only の検証結果（エラー・警告の件数と問題のあるファイル数・行数）を1行で表示
+// 問題がなくても件数0として表示する
+func writeValidationSummary(w io.Writer, results []ValidationResult) {
+	errorCount, warningCount := countIssueSeverities(results)
+	files := 0
+	if len(results) > 0 {
+		files = 1
+	}
+	fmt.Fprintf(w, "📋 検証結果: エラー %d件・警告 %d件（問題のあるファイル %d件・行 %d件）\n", errorCount, warningCount, files, len(results))
+}
+
+// validationFailure は検証失敗とする問題があればエラーを返す（Advisory の問題のみなら成功）
+func validationFailure(results []ValidationResult) error {
+	for _, result := range results {
+		if result.HasErrors() {
+			return exit.New(exit.Validation, fmt.Errorf("%d個の検証エラーが見つかりました", len(results)))
+		}
+	}
+	return nil
+}
+
+// convertToValidationIssues は内部のValidationIssueを検証システムの型に変換
+func convertToValidationIssues(issues []ValidationIssue) []validation.ValidationIssue {
+	var result []validation.ValidationIssue
+
+	for _, issue := range issues {
+		// 対応する変換処理
+		validationIssue := validation.ValidationIssue{
+			Type:      convertIssueType(issue.Type),
 			Severity:  validation.SeverityError, // デフォルト
 			Component: issue.Component,          // コマンドやサブコマンド名を設定
 			Message:   issue.Message,
 			Expected:  []string{},
 		}
+		if issue.Type.Severity() == severityWarning || issue.Advisory {
+			validationIssue.Severity = validation.SeverityWarning
+		}
 		result = append(result, validationIssue)
 	}
 
@@ -597,6 +1317,14 @@ func convertIssueType(issueType IssueType) validation.IssueType {
 		return validation.IssueDeprecatedCommand
 	case IssueSyntaxError:
 		return validation.IssueSyntaxError
+	case IssueOutputFormatMismatch:
+		return validation.IssueOutputFormatMismatch
+	case IssueMissingFile:
+		return validation.IssueMissingFile
+	case IssueInvalidOption:
+		return validation.IssueInvalidOption
+	case IssueMissingAssumeYes:
+		return validation.IssueMissingAssumeYes
 	default:
 		return validation.IssueInvalidMainCommand
 	}
@@ -617,9 +1345,10 @@ func (cli *IntegratedCLI) runInteractiveValidation(lines []string) error {
 	}
 
 	selectedIssues := cli.selectIssuesInteractively(issues)
+	cli.closeDecisionReader()
 
 	// 推奨変更の適用
-	return cli.applySelectedChanges(selectedIssues)
+	return cli.applySelectedChanges(lines, selectedIssues)
 }
 
 // analyzeFile はファイル全体を分析
@@ -629,14 +1358,19 @@ func (cli *IntegratedCLI) analyzeFile(lines []string) *FileAnalysis {
 		UsacloudLines: 0,
 		Issues:        []ValidationResult{},
 	}
+	cli.binaryVars = validation.BinaryVariables(lines)
 
-	for lineNumber, line := range lines {
-		result := cli.validateLine(line, lineNumber+1)
+	for _, l := range transform.JoinContinuations(lines) {
+		if l.Heredoc && !cli.config.TransformHeredocs {
+			continue
+		}
+		line := l.Text()
+		result := cli.validateLine(line, l.Start+1)
 		if result != nil {
 			analysis.Issues = append(analysis.Issues, *result)
 		}
 
-		if strings.Contains(line, "usacloud") {
+		if strings.Contains(line, "usacloud") || cli.newParser().IsUsacloudCommand(line) {
 			analysis.UsacloudLines++
 		}
 	}
@@ -665,29 +1399,40 @@ func (cli *IntegratedCLI) identifyIssues(analysis *FileAnalysis) []InteractiveIs
 }
 
 // generateSuggestedFix は修正提案を生成
+// パーサーが返すトークン位置で誤っているメイン・サブコマンドの語だけを置換し、
+// 前後のオプション・引数やコメント中の同じ文字列はそのまま残す
 func (cli *IntegratedCLI) generateSuggestedFix(result ValidationResult) string {
-	// 簡単な修正提案生成
-	if len(result.Suggestions) > 0 {
-		suggestion := result.Suggestions[0]
-		// 元のコマンドを提案で置換
-		return strings.Replace(result.Line, extractCommand(result.Line), suggestion.Command, 1)
+	if len(result.Suggestions) == 0 {
+		return result.Line // 提案がない場合は元のまま
 	}
+	suggestion := result.Suggestions[0]
 
-	return result.Line // 提案がない場合は元のまま
-}
+	parsed, err := cli.newParser().Parse(result.Line)
+	if err != nil {
+		return result.Line
+	}
 
-// extractCommand は行からコマンド部分を抽出
-func extractCommand(line string) string {
-	parts := strings.Fields(line)
-	for i, part := range parts {
-		if part == "usacloud" && i+1 < len(parts) {
-			if i+2 < len(parts) {
-				return parts[i+1] + " " + parts[i+2] // main + sub
+	var span validation.Span
+	for _, issue := range result.Issues {
+		switch issue.Type {
+		case IssueInvalidMainCommand:
+			span = parsed.MainCommandSpan
+			// 「メイン サブ」の組の提案はサブコマンドまで置換
+			if strings.Contains(suggestion.Command, " ") && !parsed.SubCommandSpan.Empty() {
+				span.End = parsed.SubCommandSpan.End
 			}
-			return parts[i+1] // main のみ
+		case IssueInvalidSubCommand:
+			span = parsed.SubCommandSpan
+		default:
+			continue
 		}
+		break
 	}
-	return ""
+	if span.Empty() {
+		return result.Line
+	}
+
+	return result.Line[:span.Start] + suggestion.Command + result.Line[span.End:]
 }
 
 // generateReason は理由を生成
@@ -699,6 +1444,14 @@ func (cli *IntegratedCLI) generateReason(issue ValidationIssue) string {
 		return "指定されたサブコマンドがこのメインコマンドでサポートされていません"
 	case IssueDeprecatedCommand:
 		return "このコマンドは廃止されており、新しい代替コマンドの使用が推奨されます"
+	case IssueOutputFormatMismatch:
+		return "jq はJSON入力を前提としているため、usacloudの出力形式をJSONにする必要があります"
+	case IssueMissingFile:
+		return "コマンドで指定されたファイルが存在しないため、実行時に失敗します"
+	case IssueInvalidOption:
+		return "オプションの値が不正か、コマンドにないオプションが指定されているため、実行時に失敗します"
+	case IssueMissingAssumeYes:
+		return "実行前に確認を求めるコマンドのため、端末のない環境では応答待ちで停止または失敗します"
 	default:
 		return "構文エラーが検出されました"
 	}
@@ -738,15 +1491,16 @@ func (cli *IntegratedCLI) selectIssuesInteractively(issues []InteractiveIssue) [
 
 // readUserInput はユーザー入力を読み取り
 func (cli *IntegratedCLI) readUserInput() string {
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		return strings.TrimSpace(scanner.Text())
+	line, err := cli.decisionReader().ReadString('\n')
+	if err != nil && line == "" {
+		return ""
 	}
-	return ""
+	return strings.TrimSpace(line)
 }
 
-// applySelectedChanges は選択された変更を適用
-func (cli *IntegratedCLI) applySelectedChanges(issues []InteractiveIssue) error {
+// applySelectedChanges は選択された変更を入力の該当行に反映し、--out（--in-place では入力ファイル）に書き出す
+// 選択しなかった問題の行は元のまま出力する
+func (cli *IntegratedCLI) applySelectedChanges(lines []string, issues []InteractiveIssue) error {
 	if len(issues) == 0 {
 		fmt.Fprint(os.Stderr, color.YellowString("適用する変更がありません\n"))
 		return nil
@@ -754,70 +1508,505 @@ func (cli *IntegratedCLI) applySelectedChanges(issues []InteractiveIssue) error
 
 	fmt.Fprintf(os.Stderr, color.CyanString("🔧 %d個の変更を適用中...\n\n"), len(issues))
 
-	for _, issue := range issues {
-		fmt.Printf("行 %d: %s\n", issue.LineNumber, issue.SuggestedCode)
+	fixed, applied, skipped := applyInteractiveFixes(lines, issues)
+	for _, lineNumber := range skipped {
+		fmt.Fprintf(os.Stderr, color.YellowString("⚠️  行 %d: 修正候補がないため適用しませんでした\n"), lineNumber)
+	}
+	if len(applied) == 0 {
+		fmt.Fprint(os.Stderr, color.YellowString("適用できる変更がないため出力しませんでした\n"))
+		return nil
 	}
 
-	fmt.Fprint(os.Stderr, color.GreenString("✅ 変更適用完了\n"))
+	lineEnding, err := cli.resolveLineEnding()
+	if err != nil {
+		return err
+	}
+	sep := cliio.Separator(lineEnding)
+	if err := cli.writeOutput(strings.Join(fixed, sep) + sep); err != nil {
+		return err
+	}
+
+	lineNumbers := make([]string, len(applied))
+	for i, lineNumber := range applied {
+		lineNumbers[i] = fmt.Sprintf("%d", lineNumber)
+	}
+	destination := cli.config.OutputPath
+	if cli.config.InPlace {
+		destination = cli.config.InputPath
+	}
+	if destination == "-" {
+		destination = "標準出力"
+	}
+	fmt.Fprintf(os.Stderr, color.GreenString("✅ %d件の修正を適用しました（行: %s）→ %s\n"), len(applied), strings.Join(lineNumbers, ", "), destination)
 	return nil
 }
 
+// applyInteractiveFixes は受け入れた問題の修正候補で該当するコマンドの行を置き換えた内容を返す
+// 継続行で書かれたコマンドは元の行数のまま書き換える。同じコマンドの2件目以降の問題は同じ修正のため無視し、
+// 修正候補がない（または内容が一致しない）問題の行番号は skipped に返す
+func applyInteractiveFixes(lines []string, issues []InteractiveIssue) (fixed []string, applied, skipped []int) {
+	fixed = append([]string(nil), lines...)
+	commands := make(map[int]transform.LogicalLine)
+	for _, l := range transform.JoinContinuations(lines) {
+		commands[l.Start+1] = l
+	}
+
+	done := make(map[int]bool)
+	for _, issue := range issues {
+		if done[issue.LineNumber] {
+			continue
+		}
+		done[issue.LineNumber] = true
+
+		l, ok := commands[issue.LineNumber]
+		if !ok || issue.SuggestedCode == issue.CurrentCode || l.Text() != issue.CurrentCode {
+			skipped = append(skipped, issue.LineNumber)
+			continue
+		}
+		copy(fixed[l.Start:], l.Split(issue.SuggestedCode))
+		applied = append(applied, issue.LineNumber)
+	}
+	return fixed, applied, skipped
+}
+
 // parseFlags はフラグから設定を解析
 func parseFlags() *Config {
-	return &Config{
-		InputPath:          *inFile,
-		OutputPath:         *outFile,
-		ShowStats:          *stats,
-		ValidateOnly:       *validateOnly,
-		StrictValidation:   *strictValidation,
-		InteractiveMode:    *interactiveMode,
-		HelpMode:           *helpMode,
-		SuggestionLevel:    *suggestionLevel,
-		SkipDeprecated:     *skipDeprecated,
-		ColorEnabled:       *colorEnabled,
-		LanguageCode:       *languageCode,
-		SandboxMode:        *sandboxMode,
-		DryRun:             *dryRun,
-		BatchMode:          *batch,
-		SandboxInteractive: *interactive,
-		ConfigFile:         *configFile,
-	}
+	cfg := &Config{
+		InputPath:           inFile.Paths()[0],
+		InputPaths:          inFile.Paths(),
+		StdinFilename:       *stdinFilename,
+		OutputPath:          *outFile,
+		ShowStats:           *stats,
+		StatFormat:          *statFormat,
+		Quiet:               *quiet,
+		Review:              *review,
+		ExplainChanges:      *explainChanges,
+		ProvenancePath:      *provenancePath,
+		ChangesCSVPath:      *changesCSV,
+		DiffMode:            *diffMode,
+		AddAssumeYes:        *addAssumeYes,
+		ReverseMode:         *reverseMode,
+		NoHeader:            *noHeader,
+		Passes:              *passes,
+		MaxLineLength:       *maxLineLength,
+		TransformHeredocs:   *transformHeredocs,
+		Recursive:           *recursive,
+		Include:             *include,
+		Exclude:             *exclude,
+		InPlace:             *inPlace,
+		BackupOriginal:      resolveBackupOriginal(),
+		Force:               *force,
+		RiskReportPath:      *riskReport,
+		RiskReportFormat:    *riskReportFormat,
+		MigrationReport:     *migrationReport,
+		PreservePermissions: *preservePermissions,
+		LineEnding:          *lineEnding,
+		InputEncoding:       *inputEncoding,
+		OutputEncoding:      *outputEncoding,
+		Watch:               *watchMode,
+		Jobs:                resolveJobs(),
+		ValidateOnly:        *validateOnly,
+		StrictValidation:    *strictValidation,
+		InteractiveMode:     *interactiveMode,
+		SummaryThreshold:    *summaryThreshold,
+		Timing:              *timingFlag,
+		SummaryOnly:         *summaryOnly,
+		GroupErrors:         *groupErrors,
+		OutputFormat:        *outputFormat,
+		TreatUnknownAs:      *treatUnknownAs,
+		HelpMode:            *helpMode,
+		SuggestionLevel:     *suggestionLevel,
+		SkipDeprecated:      *skipDeprecated,
+		FailOnDeprecated:    *failOnDeprecated,
+		CheckPaths:          *checkPaths,
+		ColorMode:           colorFlag.String(),
+		ColorEnabled:        colorEnabledFor(colorFlag.String(), os.Stderr),
+		LanguageCode:        *languageCode,
+		UsacloudVersion:     *usacloudVersion,
+		RuleOrder:           resolveRuleOrder(),
+		EnableRules:         *enableRules,
+		DisableRules:        append(append([]string(nil), *disableRules...), *disableRule...),
+		EnableOnly:          *enableOnly,
+		SinceVersion:        *sinceVersion,
+		TargetVersion:       *targetVersion,
+		SandboxMode:         *sandboxMode,
+		DryRun:              *dryRun,
+		BatchMode:           *batch,
+		SandboxInteractive:  *interactive,
+		ConfigFile:          *configFile,
+		Profile:             *profileName,
+
+		DumpStatsBaseline:      *dumpStatsBaseline,
+		CompareStatsBaseline:   *compareStatsBaseline,
+		StatsBaselineTolerance: *statsBaselineTolerance,
+	}
+	return cfg
 }
 
+// --treat-unknown-as で指定できるカタログにないコマンドの扱い
+const (
+	unknownAsError   = "error"
+	unknownAsWarning = "warning"
+	unknownAsIgnore  = "ignore"
+)
+
+// 類似コマンド提案パラメータの許容範囲
+const (
+	minMaxDistance    = 1
+	maxMaxDistance    = 10
+	minMaxSuggestions = 1
+	maxMaxSuggestions = 20
+)
+
 // loadValidationConfig は検証設定を読み込み
 func loadValidationConfig() *ValidationConfig {
-	return &ValidationConfig{
-		MaxSuggestions:        5,
-		MaxDistance:           3,
+	cfg := &ValidationConfig{
+		MaxSuggestions:        validation.DefaultMaxSuggestions,
+		MaxDistance:           validation.DefaultMaxDistance,
+		DistanceAlgorithm:     string(validation.DistanceLevenshtein),
 		EnableTypoDetection:   true,
 		EnableInteractiveHelp: true,
 		ErrorFormat:           "comprehensive",
 		LogLevel:              "info",
+		CacheSizeMB:           100,
+	}
+
+	if *configFile != "" || *profileName != "" {
+		if err := applyValidationFileSettings(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, color.YellowString("⚠️  設定ファイルの検証設定を読み込めませんでした（デフォルト値を使用）: %v\n"), err)
+		}
+	}
+
+	applyValidationFlagOverrides(cfg, explicitlySetFlags())
+	return cfg
+}
+
+// readIntegratedConfig は --config の設定ファイルを --profile のプロファイルを適用して読み込む
+// どちらも未指定の場合は nil
+func readIntegratedConfig() (*config.IntegratedConfig, error) {
+	if *configFile == "" && *profileName == "" {
+		return nil, nil
+	}
+	return config.ReadIntegratedConfigProfile(*configFile, *profileName)
+}
+
+// readTransformFileSettings は設定ファイルの [transform] セクションを読み込み（未指定・読み込み失敗時は nil）
+func readTransformFileSettings() *config.TransformConfig {
+	fileCfg, err := readIntegratedConfig()
+	if err != nil || fileCfg == nil {
+		return nil
+	}
+	return fileCfg.Transform
+}
+
+// resolveBackupOriginal は --in-place 時に .bak を作成するか決定（--no-backup > 設定ファイルの backup_original > 既定値の有効）
+func resolveBackupOriginal() bool {
+	if *noBackup {
+		return false
+	}
+	if transformCfg := readTransformFileSettings(); transformCfg != nil {
+		return transformCfg.BackupOriginal
+	}
+	return true
+}
+
+// validateInPlaceConfig は --in-place と他のオプションの組み合わせを確認
+func validateInPlaceConfig(cfg *Config) error {
+	if !cfg.InPlace {
+		return nil
+	}
+	if !cfg.Recursive && cfg.InputPath == "-" {
+		return fmt.Errorf("--in-place は標準入力 (-) には使用できません。--in でファイルを指定してください")
+	}
+	if cfg.OutputPath != "-" {
+		return fmt.Errorf("--in-place と --out は同時に指定できません")
+	}
+	if cfg.DiffMode {
+		return fmt.Errorf("--in-place と --diff は同時に指定できません")
+	}
+	return nil
+}
+
+// validatePositionalArgs はサブコマンドとして扱われなかった位置引数を拒否
+// 値を区切って指定したフラグ（--color never など）の値が位置引数として残った場合に、以降のフラグが無視されたまま実行しないようにする
+func validatePositionalArgs(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	return fmt.Errorf("不明な引数です: %s（オプションの値は --name=value の形式でも指定できます）", strings.Join(args, " "))
+}
+
+// resolveRuleOrder は --rule-order または設定ファイルの rule_order から変換ルールの適用順序を決定
+func resolveRuleOrder() []string {
+	order := *ruleOrder
+	if order == "" {
+		if transformCfg := readTransformFileSettings(); transformCfg != nil {
+			order = transformCfg.RuleOrder
+		}
+	}
+	return splitRuleList(order)
+}
+
+// resolveEffectiveRules は設定ファイル・コマンドラインの有効/無効指定を反映した最終的なルール一覧を決定
+// 後から適用される指定が優先される（設定ファイル < コマンドライン）
+func resolveEffectiveRules(cfg *Config) ([]transform.EffectiveRule, error) {
+	since, target, err := resolveMigrationVersions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	outside, err := transform.RulesOutsideVersions(since, target)
+	if err != nil {
+		return nil, err
+	}
+
+	// 移行範囲外のルールは最初に無効化し、設定ファイル・コマンドラインで明示的に有効化できるようにする
+	overrides := []transform.RuleOverride{{
+		Source:  "--since/--target-version",
+		Disable: outside,
+	}}
+	if transformCfg := readTransformFileSettings(); transformCfg != nil {
+		overrides = append(overrides, transform.RuleOverride{
+			Source:  "config (" + *configFile + ")",
+			Enable:  splitRuleList(transformCfg.EnabledRules),
+			Disable: splitRuleList(transformCfg.DisabledRules),
+		})
+	}
+	overrides = append(overrides, transform.RuleOverride{
+		Source:  "command line",
+		Only:    cfg.EnableOnly,
+		Enable:  cfg.EnableRules,
+		Disable: cfg.DisableRules,
+	})
+
+	return transform.ResolveRules(cfg.RuleOrder, overrides...)
+}
+
+// resolveMigrationVersions は --since / --target-version を正規化し、移行元が移行先より古いことを確認
+// 未指定（空文字列）の場合は既定の移行元・移行先を使用
+func resolveMigrationVersions(cfg *Config) (string, string, error) {
+	since, target := cfg.SinceVersion, cfg.TargetVersion
+	if since == "" {
+		since = transform.MigrationSourceVersion
+	}
+	if target == "" {
+		target = transform.MigrationTargetVersion
+	}
+
+	since, err := transform.NormalizeVersion(since)
+	if err != nil {
+		return "", "", fmt.Errorf("--since の値が不正です: %w", err)
+	}
+	target, err = transform.NormalizeVersion(target)
+	if err != nil {
+		return "", "", fmt.Errorf("--target-version の値が不正です: %w", err)
+	}
+	if _, err := transform.RulesOutsideVersions(since, target); err != nil {
+		return "", "", fmt.Errorf("--since には --target-version より古いバージョンを指定してください: %w", err)
+	}
+	return since, target, nil
+}
+
+// splitRuleList はカンマ区切りのルール名一覧を分割
+func splitRuleList(list string) []string {
+	if strings.TrimSpace(list) == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+// stringListFlag は繰り返し指定できる文字列の一覧のフラグ（1回の指定でカンマ区切りも可、空の要素は無視）
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringListFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*f = append(*f, item)
+		}
+	}
+	return nil
+}
+
+// stringList は繰り返し指定できる文字列の一覧のフラグを登録
+func stringList(name, usage string) *stringListFlag {
+	f := &stringListFlag{}
+	flag.Var(f, name, usage)
+	return f
+}
+
+// applyValidationFileSettings は設定ファイルの検証関連セクションを検証設定に反映
+func applyValidationFileSettings(cfg *ValidationConfig) error {
+	fileCfg, err := readIntegratedConfig()
+	if err != nil || fileCfg == nil {
+		return err
+	}
+
+	cfg.MaxSuggestions = fileCfg.Validation.MaxSuggestions
+	cfg.MaxDistance = fileCfg.Validation.MaxEditDistance
+	cfg.DistanceAlgorithm = fileCfg.Validation.DistanceAlgorithm
+	cfg.EnableTypoDetection = fileCfg.Validation.TypoDetectionEnabled
+	cfg.EnableInteractiveHelp = fileCfg.HelpSystem.EnableInteractiveHelp
+	cfg.ErrorFormat = fileCfg.ErrorFeedback.ErrorFormat
+	cfg.LogLevel = fileCfg.General.LogLevel
+	cfg.CacheSizeMB = fileCfg.Performance.CacheSizeMB
+	if !fileCfg.Performance.CacheEnabled {
+		cfg.CacheSizeMB = 0
+	}
+	return nil
+}
+
+// explicitlySetFlags はコマンドラインで明示的に指定されたフラグ名を返す
+func explicitlySetFlags() map[string]bool {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// applyValidationFlagOverrides は明示的に指定されたフラグで検証設定を上書き
+func applyValidationFlagOverrides(cfg *ValidationConfig, setFlags map[string]bool) {
+	if setFlags["max-distance"] {
+		cfg.MaxDistance = *maxDistance
+	}
+	if setFlags["max-suggestions"] {
+		cfg.MaxSuggestions = *maxSuggestions
+	} else if setFlags["suggestion-level"] {
+		// --suggestion-level は表示する数だけ提案を求める（--max-suggestions の指定が優先）
+		cfg.MaxSuggestions = *suggestionLevel
 	}
 }
 
+// validateValidationConfig は検証設定の値が許容範囲内か確認
+func validateValidationConfig(cfg *ValidationConfig) error {
+	if cfg.MaxDistance < minMaxDistance || cfg.MaxDistance > maxMaxDistance {
+		return fmt.Errorf("最大編集距離 (--max-distance / max_edit_distance) は %d から %d の範囲で指定してください: %d", minMaxDistance, maxMaxDistance, cfg.MaxDistance)
+	}
+	if cfg.MaxSuggestions < minMaxSuggestions || cfg.MaxSuggestions > maxMaxSuggestions {
+		return fmt.Errorf("最大提案数 (--max-suggestions / max_suggestions) は %d から %d の範囲で指定してください: %d", minMaxSuggestions, maxMaxSuggestions, cfg.MaxSuggestions)
+	}
+	if _, err := validation.ParseDistanceAlgorithm(cfg.DistanceAlgorithm); err != nil {
+		return fmt.Errorf("編集距離のアルゴリズム (distance_algorithm) は %s または %s を指定してください: %q", validation.DistanceLevenshtein, validation.DistanceDamerauLevenshtein, cfg.DistanceAlgorithm)
+	}
+	return nil
+}
+
 var (
-	inFile      = flag.String("in", "-", "入力ファイルパス ('-'で標準入力)")
+	inFile      = inputList("in", "入力ファイルパス ('-'で標準入力、未指定時は標準入力)。繰り返し指定すると順に変換して1つの出力に連結")
 	outFile     = flag.String("out", "-", "出力ファイルパス ('-'で標準出力)")
 	stats       = flag.Bool("stats", true, "変更の統計情報を標準エラー出力に表示")
+	review      = flag.Bool("review", false, "出力前に変更された行ごとの変更前・変更後と適用ルールをTUIで確認し、Spaceで受け入れ/却下を切り替えてEnterで受け入れた変更だけを出力（却下した行は元のまま）")
+	statFormat  = flag.String("stat-format", "", "変更行の表示形式を Go の text/template で指定（例: \"{{.Line}}:{{.Rule}}\"、フィールドは .Line .Before .After .Rule、未指定時は #L<行番号> 変更前 => 変更後 [ルール]）")
+	quiet       = flag.Bool("quiet", false, "変更行ごとの表示（#L<行番号> 変更前 => 変更後 [ルール]）を抑制し、最後の統計と完了メッセージだけを表示")
 	showVersion = flag.Bool("version", false, "バージョン情報を表示")
+	helpAll     = flag.Bool("help-all", false, "登録されているすべてのオプションを既定値・説明付きで表示（オプションの定義から生成）")
+
+	stdinFilename = flag.String("stdin-filename", "", "標準入力の内容をエラーメッセージ・差分・来歴・JSON/SARIF の出力で表すファイル名（例: deploy.sh、読み込み元は変わらない）")
+
+	explainChanges = flag.Bool("explain-changes", false, "変更された行ごとに変換理由・v0とv1の違い・注意点を標準エラー出力に表示")
+	provenancePath = flag.String("provenance", "", "変更行ごとの監査記録（元の行・変換後・適用ルール・移行元/先バージョン・時刻・入力ハッシュ）をJSON Lines形式で出力するファイルパス")
+	changesCSV     = flag.String("changes-csv", "", "変更ごとの一覧（ファイル・行番号・ルール・変更前・変更後・理由）をスプレッドシートで確認できるCSV形式で出力するファイルパス")
+	recursive      = flag.Bool("recursive", false, "--in にディレクトリを指定し、配下のスクリプトを再帰的に変換（出力は元ファイルの隣に .updated を付けて書き出し）")
+	include        = flag.String("include", "", "--recursive で変換するファイル名のglobパターン（例: \"*.sh\"、未指定時は .sh またはシェルのシェバンで始まるファイル）")
+	exclude        = stringList("exclude", "--recursive で変換しないパスのglobパターン（例: \"vendor/**,*.generated.sh\"、繰り返し・カンマ区切りで複数指定可、** は任意の深さのディレクトリ）")
+	inPlace        = flag.Bool("in-place", false, "変換結果で入力ファイルを直接上書き（元の内容は .bak に退避、--recursive では .updated の代わりに上書き）")
+	noBackup       = flag.Bool("no-backup", false, "--in-place で .bak バックアップを作成しない")
+	force          = flag.Bool("force", false, "usacloud-update で変換済み（生成ヘッダーを含む）の入力も、既存の生成ヘッダーを取り除いて再変換")
+	diffMode       = flag.Bool("diff", false, "変換結果全体の代わりに元の入力との差分をunified diff形式で出力（ハンクごとに行番号と適用ルールを表示）")
+	ruleOrder      = flag.String("rule-order", "", "先に適用する変換ルール名をカンマ区切りで指定（上級者向け、未指定のルールは既定の順序で後続）")
+	enableRules    = stringList("enable-rules", "有効にする変換ルール名をカンマ区切りで指定（繰り返し指定可、設定ファイルの disabled_rules より優先）")
+	disableRules   = stringList("disable-rules", "無効にする変換ルール名をカンマ区切りで指定（繰り返し指定可）")
+
+	sinceVersion  = flag.String("since", transform.MigrationSourceVersion, "スクリプトが現在対応している usacloud のバージョン (v0/v1.0)。このバージョン以前向けの変換ルールは適用しない")
+	targetVersion = flag.String("target-version", transform.MigrationTargetVersion, "移行先の usacloud のバージョン (v1.0/v1.1)。これより新しいバージョン向けの変換ルールは適用せず、生成ヘッダーにも反映")
+
+	disableRule = stringList("disable-rule", "無効にする変換ルール名（繰り返し指定可）")
+	enableOnly  = stringList("enable-only", "指定した変換ルールだけを適用（繰り返し指定可、それ以外のルールはすべて無効）")
+
+	riskReport       = flag.String("risk-report", "", "--recursive で変換したファイルを移行リスク（手動対応・代替のない廃止コマンド・確度の低い提案）の高い順に並べたレポートの出力先 ('-'で標準出力)")
+	riskReportFormat = flag.String("risk-report-format", "text", "リスクレポートの出力形式 (text/json)")
+	migrationReport  = flag.String("migration-report", "", "usacloud コマンドを対応済み（v1 の構文）・移行済み（自動変換）・手動対応が必要に分類し、行番号付きで一覧にしたレポートの出力先 ('-'で標準出力)")
+
+	watchMode = flag.Bool("watch", false, "--in のファイルまたはディレクトリを監視し、.sh ファイルが変更されるたびに変換と検証を再実行して結果の要約を表示（ファイルへの出力は行わない、Ctrl+C で終了）")
+
+	jobsFlag = flag.Int("jobs", 0, "各行の変換と検証を並列に実行するワーカー数（0: CPU数、指定しない場合は設定ファイルの [performance] に従う）")
+
+	reverseMode = flag.Bool("reverse", false, "v1のスクリプトをv0の構文に逆変換（参照用。元に戻せないルールは警告として標準エラー出力に表示）")
+	noHeader    = flag.Bool("no-header", false, "変換結果の先頭に生成ヘッダー（# Updated for usacloud ... by usacloud-update）を付けずに出力（他のファイルに連結する断片の変換用）")
+	passes      = flag.Int("passes", 1, "変換結果に対して変化がなくなるまで変換を繰り返す最大回数（ルールの結果が別のルールに該当する場合用）")
+
+	transformHeredocs = flag.Bool("transform-heredocs", false, "ヒアドキュメント（cat <<EOF ... EOF など）の本文に含まれるusacloudコマンドも変換・検証（既定では本文をそのまま出力）")
+	maxLineLength     = flag.Int("max-line-length", cliio.BufferSize, "変換・検証する行の最大バイト数。これより長い行は警告を表示してそのまま出力")
+
+	printEffectiveRules = flag.Bool("print-effective-rules", false, "フラグ・設定ファイルを反映した最終的な変換ルール一覧（順序・有効/無効・指定元）を表示")
+
+	addAssumeYes    = flag.Bool("add-assumeyes", false, "削除・停止など実行前に確認を求めるusacloudコマンド（delete/shutdown/reset）に -y が指定されていない場合は付与（cron や CI での応答待ちを防ぐ、手動実行でも確認されなくなる）")
+	listRules       = flag.Bool("list-rules", false, "適用される変換ルールごとの名前・説明・変換前後の例・逆変換の可否を表示（ドキュメント・エディタ連携用）")
+	listRulesFormat = flag.String("list-rules-format", "text", "--list-rules の出力形式 (text/json)")
+
+	inputEncoding       = flag.String("input-encoding", "utf-8", "入力ファイルの文字コード (utf-8/shift_jis/euc-jp/iso-2022-jp)")
+	outputEncoding      = flag.String("output-encoding", "", "出力ファイルの文字コード（指定しない場合は入力と同じ）")
+	lineEnding          = flag.String("line-ending", "lf", "出力の改行コード (lf/crlf/auto: 入力で多い方に統一)")
+	preservePermissions = flag.Bool("preserve-permissions", true, "入力ファイルのパーミッション（実行ビット等）を出力ファイルに引き継ぐ")
 
 	// Sandbox functionality flags
 	sandboxMode = flag.Bool("sandbox", false, "サンドボックス環境での実際のコマンド実行")
 	interactive = flag.Bool("interactive", true, "インタラクティブTUIモード (sandboxとの組み合わせで使用)")
-	dryRun      = flag.Bool("dry-run", false, "実際の実行を行わず変換結果のみ表示")
+	dryRun      = flag.Bool("dry-run", false, "実際の実行を行わず変換結果のみ表示（--sandbox なしでは変換を書き出さずに変更行数・ルール数・ファイル数の集計のみ表示）")
 	batch       = flag.Bool("batch", false, "バッチモード: 選択した全コマンドを自動実行")
+	assumeYes   = flag.Bool("yes", false, "バッチモードで削除・停止などの破壊的な操作を含むコマンドも確認せずに実行（設定ファイルの [sandbox] の destructive_commands で対象を変更可能）")
+
+	parallel = flag.Int("parallel", 1, "サンドボックスで複数のファイルを選択した場合に同時に実行するファイル数")
+
+	rateLimit = flag.Float64("rate-limit", 0, "サンドボックスで1秒あたりに実行するコマンド数の上限（超える場合は失敗せずに待機、並列実行するファイル全体で共有、0: 無制限、指定しない場合は設定ファイルの [sandbox] の rate_limit に従う）")
+
+	reportFormat = flag.String("report", "", "サンドボックスのバッチ実行の結果をレポートとして出力する形式 (json)")
+	reportOut    = flag.String("report-out", "-", "--report の出力先ファイルパス ('-'で標準出力)")
+
+	dryRunDiff = flag.Bool("dry-run-diff", false, "サンドボックスで実行せずに、各行の元のコマンド・変換後のコマンド・実行されるかどうか（スキップ理由）を表で標準エラー出力に表示")
 
 	// New validation functionality flags
 	validateOnly     = flag.Bool("validate-only", false, "検証のみ実行（変換は行わない）")
 	strictValidation = flag.Bool("strict-validation", false, "厳格検証モード（エラー発生時に処理を停止）")
 	interactiveMode  = flag.Bool("interactive-mode", false, "インタラクティブ検証・修正モード")
+	treatUnknownAs   = flag.String("treat-unknown-as", unknownAsError, "コマンドカタログにないusacloudのメインコマンド・サブコマンドの扱い (error/warning/ignore)。warning/ignore では検証失敗にしない")
+	outputFormat     = flag.String("output-format", "text", "検証のみモードの結果の出力形式 (text/json/sarif)。json/sarif では検証結果を標準出力に出力")
+	summaryThreshold = flag.Int("summary-threshold", 0, "検証のみモードで問題数がこの値を超えた場合だけ詳細レポートを表示（終了コードは変わらない）")
+	timingFlag       = flag.Bool("timing", false, "読み込み・変換・検証・書き出しの各段階にかかった時間と合計を標準エラー出力に表示（--output-format=json では結果の metadata に含める）")
+	summaryOnly      = flag.Bool("report-summary-only", false, "検証のみモードで行ごとの詳細を表示せず、エラー・警告の件数と問題のあるファイル数・行数だけを標準出力に表示（json/sarif の出力は変わらない）")
 	helpMode         = flag.String("help-mode", "enhanced", "ヘルプモード (basic/enhanced/interactive)")
-	suggestionLevel  = flag.Int("suggestion-level", 3, "提案レベル設定 (1-5)")
+	suggestionLevel  = flag.Int("suggestion-level", validation.DefaultSuggestionLevel, "類似コマンド提案の詳しさ (1-5)。1 は最良の1件のみ、3 以上は類似度付きでレベルの数まで、5 はヘルプの使用例も表示")
+	maxDistance      = flag.Int("max-distance", validation.DefaultMaxDistance, "類似コマンド提案で許容する最大編集距離 (1-10)")
+	maxSuggestions   = flag.Int("max-suggestions", validation.DefaultMaxSuggestions, "表示する類似コマンド提案の最大数 (1-20)")
 	skipDeprecated   = flag.Bool("skip-deprecated", false, "廃止コマンド警告をスキップ")
-	colorEnabled     = flag.Bool("color", true, "カラー出力を有効にする")
+	failOnDeprecated = flag.Bool("fail-on-deprecated", false, "変換モードで入力に廃止コマンドが含まれる場合、変換結果と一覧を出力した後に終了コード7で終了（--strict-validation とは独立）")
+	colorFlag        = colorMode("color", "色付けの方法 (auto: 出力先が端末の場合のみ/always/never)。--color=mode・--color mode のどちらでも指定可。--color=true・--color=false は非推奨の別名")
 	languageCode     = flag.String("language", "ja", "言語設定 (ja/en)")
+	usacloudVersion  = flag.String("usacloud-version", validation.DefaultCatalogVersion, "検証に使用するusacloudのバージョン別コマンドカタログ (1.0/1.1)")
 	configFile       = flag.String("config", "", "設定ファイルパス（指定しない場合はデフォルト設定を使用）")
+	profileName      = flag.String("profile", "", "適用する設定ファイルのプロファイル名（USACLOUD_UPDATE_PROFILE と設定ファイルの profile より優先、beginner/expert/ci などの組み込みプロファイルも指定可）")
+
+	checkPaths  = flag.Bool("check-paths", false, "usacloudコマンドが参照するローカルファイル（--iso-file 等）が存在しない場合に警告（相対パスはカレントディレクトリ基準）")
+	groupErrors = flag.Bool("group-errors", false, "検証のみモードで同じコマンドの問題をファイル全体でまとめ、出現回数と行番号を1回だけ表示")
+
+	// Stats baseline flags
+	dumpStatsBaseline      = flag.String("dump-stats-baseline", "", "変換全体の統計（変更行数とルールごとの適用回数）をベースラインとしてJSONファイルに書き出す")
+	compareStatsBaseline   = flag.String("compare-stats-baseline", "", "変換全体の統計をベースラインのJSONファイルと比較し、許容範囲を超えて変化した場合は終了コード5で終了")
+	statsBaselineTolerance = flag.Float64("stats-baseline-tolerance", 0, "--compare-stats-baseline で許容する変化の割合（0.1 で±10%）")
+
+	// Self-benchmark flags
+	benchmarkMode   = flag.Bool("benchmark", false, "変換・検証エンジンのセルフベンチマークを実行")
+	benchmarkFormat = flag.String("benchmark-format", "text", "ベンチマーク結果の出力形式 (text/json)")
+
+	// Config maintenance flags
+	configMigrate = flag.Bool("config-migrate", false, "古い形式の設定ファイル（--config、未指定時は既定の設定ファイル）を現在の形式に更新（元のファイルはバックアップ）")
+
+	// Parser debugging flags
+	dumpParse       = flag.String("dump-parse", "", "指定したusacloudコマンドのパーサー解析結果を表示（デバッグ用）")
+	dumpParseFormat = flag.String("dump-parse-format", "text", "パーサー解析結果の出力形式 (text/json)")
 )
 
 // printHelpMessage prints help message to stdout
@@ -827,6 +2016,14 @@ func printHelpMessage() {
 	fmt.Print(helpers.GetFooterContent())
 }
 
+// printAllHelpMessage は --help-all のヘルプを表示
+// オプションの一覧は手動で管理する GetOptionsContent ではなく、登録されているフラグから生成する
+func printAllHelpMessage(w io.Writer) {
+	fmt.Fprint(w, helpers.GetHelpContent(version))
+	fmt.Fprint(w, helpers.GetAllOptionsContent(flag.CommandLine))
+	fmt.Fprint(w, helpers.GetFooterContent())
+}
+
 func init() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "無効なオプションが指定されました。正しい使用方法については --help オプションを参照してください。\n\n")
@@ -836,8 +2033,227 @@ func init() {
 	}
 }
 
+// parseCommandLine は args を fs のフラグとして解析する
+// flag パッケージの ExitOnError は不正なフラグを終了コード 2（検証エラーと同じ）で終了させるため、
+// ContinueOnError で解析して誤りを exit.Config のエラーとして返す（-h/-help は flag.ErrHelp のまま返す）
+func parseCommandLine(fs *flag.FlagSet, args []string) error {
+	fs.Init(fs.Name(), flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return exit.New(exit.Config, err)
+	}
+	return nil
+}
+
+// parseFlagsOrExit は args を flag.CommandLine に解析し、-h/-help では終了コード 0、
+// 不正なフラグでは exit.Config で終了する（誤りと使用方法は flag.Usage が表示済み）
+func parseFlagsOrExit(args []string) {
+	if err := parseCommandLine(flag.CommandLine, args); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(exit.Success)
+		}
+		os.Exit(exit.Code(err))
+	}
+}
+
+// runBenchmarkMode runs the self-benchmark and prints the report to stdout
+func runBenchmarkMode(format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("無効なベンチマーク出力形式です: %s (text/json のいずれかを指定してください)", format)
+	}
+
+	if format == "text" {
+		fmt.Fprint(os.Stderr, color.CyanString("⏱️  ベンチマークを実行中...\n\n"))
+	}
+
+	report := benchmark.Run(version, benchmark.DefaultOptions())
+	if format == "json" {
+		return report.WriteJSON(os.Stdout)
+	}
+	return report.WriteText(os.Stdout)
+}
+
+// runDumpParseMode writes the parser's interpretation of a command line
+func runDumpParseMode(w io.Writer, commandLine, format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("無効なパーサー解析結果の出力形式です: %s (text/json のいずれかを指定してください)", format)
+	}
+
+	dump := validation.DumpParse(commandLine)
+	if format == "json" {
+		return dump.WriteJSON(w)
+	}
+	return dump.WriteText(w)
+}
+
+// runListRulesMode は変換ルールのメタデータを text または json で出力
+func runListRulesMode(w io.Writer, engine *transform.Engine, format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("無効な変換ルール一覧の出力形式です: %s (text/json のいずれかを指定してください)", format)
+	}
+
+	var err error
+	if format == "json" {
+		err = transform.WriteRulesJSON(w, engine.Rules())
+	} else {
+		err = transform.WriteRulesText(w, engine.Rules())
+	}
+	return exit.New(exit.IO, err)
+}
+
+// runConfigMigrateMode upgrades an old config file to the current schema and reports the changes
+func runConfigMigrateMode(w io.Writer, configPath string) error {
+	if configPath == "" {
+		path, err := config.ConfigPath()
+		if err != nil {
+			return err
+		}
+		configPath = path
+	}
+
+	result, err := config.MigrateConfigFile(configPath)
+	if err != nil {
+		return fmt.Errorf("設定ファイルの移行に失敗しました: %s: %w", configPath, err)
+	}
+
+	if !result.Migrated() {
+		fmt.Fprintf(w, "✅ 設定ファイルは最新の形式です (v%s): %s\n", result.ToVersion, configPath)
+	} else {
+		fmt.Fprintf(w, "✅ 設定ファイルを v%s から v%s に更新しました: %s\n", result.FromVersion, result.ToVersion, configPath)
+		fmt.Fprintf(w, "   バックアップ: %s\n", result.BackupPath)
+		for _, change := range result.Changes {
+			fmt.Fprintf(w, "  ✓ %s\n", change)
+		}
+	}
+	if len(result.UnknownKeys) > 0 {
+		fmt.Fprint(w, color.YellowString("⚠️  現在の形式では使用されない設定項目（必要に応じて手動で削除・置き換えてください）:\n"))
+		for _, key := range result.UnknownKeys {
+			fmt.Fprintf(w, "  • %s\n", key)
+		}
+	}
+	return nil
+}
+
 // runMainLogic contains the original main logic extracted for cobra integration
 func runMainLogic() {
+	if err := consumeColorModeArg(flag.CommandLine, colorFlag); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+	// 表示の多くは標準エラー出力のため、--color=auto はその出力先で判定する
+	color.NoColor = !colorEnabledFor(colorFlag.String(), os.Stderr)
+	if warning := colorFlag.deprecationWarning(); warning != "" {
+		fmt.Fprint(os.Stderr, color.YellowString(warning))
+	}
+
+	if *helpAll {
+		printAllHelpMessage(os.Stdout)
+		return
+	}
+
+	// Reject an unknown --profile before doing any work, including the subcommands
+	if *profileName != "" {
+		if _, err := readIntegratedConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.Config)
+		}
+	}
+
+	// usacloud-update config validate [--config path]
+	if args := flag.Args(); isConfigSubcommand(args, "validate") {
+		configPath, err := parseConfigValidateArgs(args[2:], *configFile)
+		if err == nil {
+			var ok bool
+			ok, err = runConfigValidateMode(os.Stdout, configPath)
+			if err == nil && !ok {
+				os.Exit(exit.Config)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.CodeOr(err, exit.Config))
+		}
+		return
+	}
+
+	// usacloud-update config current [--config path]
+	if args := flag.Args(); isConfigSubcommand(args, "current") {
+		configPath, err := parseConfigCurrentArgs(args[2:], *configFile)
+		if err == nil {
+			err = runConfigCurrentMode(os.Stdout, configPath, *profileName)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.CodeOr(err, exit.Config))
+		}
+		return
+	}
+
+	// usacloud-update config edit [--config path]
+	if args := flag.Args(); isConfigSubcommand(args, "edit") {
+		configPath, err := parseConfigEditArgs(args[2:], *configFile)
+		if err == nil {
+			err = runConfigEditMode(os.Stderr, configPath)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.CodeOr(err, exit.Config))
+		}
+		return
+	}
+
+	// usacloud-update deprecated list [prefix] [--output-format=json]
+	if args := flag.Args(); isDeprecatedSubcommand(args, "list") {
+		opts, err := parseDeprecatedListArgs(args[2:], *outputFormat)
+		if err == nil {
+			err = runDeprecatedListMode(os.Stdout, validation.NewDeprecatedCommandDetector(), opts)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.CodeOr(err, exit.Config))
+		}
+		return
+	}
+
+	// usacloud-update config migrate [--from old.env] [--to new.conf] [--force]
+	if args := flag.Args(); isConfigSubcommand(args, "migrate") {
+		opts, err := parseConfigMigrateArgs(args[2:])
+		if err == nil {
+			err = runConfigEnvMigrateMode(os.Stdout, opts)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.CodeOr(err, exit.Config))
+		}
+		return
+	}
+
+	if *benchmarkMode {
+		if err := runBenchmarkMode(*benchmarkFormat); err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.Code(err))
+		}
+		return
+	}
+
+	if *dumpParse != "" {
+		if err := runDumpParseMode(os.Stdout, *dumpParse, *dumpParseFormat); err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.Code(err))
+		}
+		return
+	}
+
+	// 古い形式の設定ファイルは読み込みで失敗し得るため、--config の検証より先に移行する
+	if *configMigrate {
+		if err := runConfigMigrateMode(os.Stdout, *configFile); err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.CodeOr(err, exit.Config))
+		}
+		return
+	}
 
 	// Load and validate configuration if --config flag is provided
 	if *configFile != "" {
@@ -848,67 +2264,274 @@ func runMainLogic() {
 				fmt.Fprint(os.Stderr, color.YellowString("デフォルト設定を使用します。\n"))
 				fmt.Fprintf(os.Stderr, "修正方法: 設定ファイルのパスを確認してください。\n")
 				fmt.Fprintf(os.Stderr, "設定例については README-Usage.md を確認してください。\n")
-				os.Exit(1)
+				os.Exit(exit.Config)
 			}
 			fmt.Fprintf(os.Stderr, color.RedString("設定ファイルエラー: %v\n"), err)
 			fmt.Fprint(os.Stderr, color.YellowString("フォールバック: デフォルト値を使用します。\n"))
 			fmt.Fprintf(os.Stderr, "修正方法: 設定ファイルの形式を確認してください。\n")
 			fmt.Fprintf(os.Stderr, "設定例については usacloud-update.conf.sample を参照してください。\n")
-			os.Exit(1)
+			os.Exit(exit.Config)
+		}
+	}
+
+	// Reject out-of-range suggestion tuning before doing any work
+	if err := validateValidationConfig(loadValidationConfig()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+
+	// Reject unknown or reversed migration versions before doing any work
+	if _, _, err := resolveMigrationVersions(parseFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+
+	// Reject invalid rule settings before doing any work
+	effectiveRules, err := resolveEffectiveRules(parseFlags())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: ルール設定が不正です: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+
+	if *printEffectiveRules {
+		if err := transform.WriteEffectiveRules(os.Stdout, effectiveRules); err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.Config)
 		}
+		return
+	}
+
+	if *listRules {
+		engine := transform.NewEngineFromEffectiveRules(effectiveRules)
+		if *addAssumeYes {
+			engine = engine.WithAssumeYes()
+		}
+		if err := runListRulesMode(os.Stdout, engine, *listRulesFormat); err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.CodeOr(err, exit.Config))
+		}
+		return
+	}
+
+	if err := validateRecursiveConfig(parseFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+	if err := validateInPlaceConfig(parseFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+	if err := validateMultiInputConfig(parseFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+	if err := validateStatsBaselineConfig(parseFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+	if err := validateWatchConfig(parseFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+	if err := validateReviewConfig(parseFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+	if err := validateDryRunConfig(parseFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+	if err := validateChangesCSVConfig(parseFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+	if err := validateMigrationReportConfig(parseFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+
+	switch *treatUnknownAs {
+	case unknownAsError, unknownAsWarning, unknownAsIgnore:
+	default:
+		fmt.Fprintf(os.Stderr, color.RedString("Error: 無効な --treat-unknown-as の値です: %s (error/warning/ignore のいずれかを指定してください)\n"), *treatUnknownAs)
+		os.Exit(exit.Config)
+	}
+
+	if *outputFormat != "text" && *outputFormat != "json" && *outputFormat != "sarif" {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: 無効な出力形式です: %s (text/json/sarif のいずれかを指定してください)\n"), *outputFormat)
+		os.Exit(exit.Config)
+	}
+
+	if !validation.IsSupportedHelpLanguage(*languageCode) {
+		helpers.PrintWarning("⚠️  未対応の言語です: %s（日本語で表示します）\n", *languageCode)
+	}
+
+	if *summaryThreshold < 0 {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: --summary-threshold には0以上の値を指定してください: %d\n"), *summaryThreshold)
+		os.Exit(exit.Config)
+	}
+	if _, err := transform.ParseChangeFormat(*statFormat); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: --stat-format のテンプレートが不正です: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+	if *summaryOnly && !*validateOnly {
+		fmt.Fprint(os.Stderr, color.RedString("Error: --report-summary-only は --validate-only と同時に指定してください\n"))
+		os.Exit(exit.Config)
+	}
+	if *parallel < 1 {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: --parallel には1以上の値を指定してください: %d\n"), *parallel)
+		os.Exit(exit.Config)
+	}
+	if !(*rateLimit >= 0) { // NaN も拒否する
+		fmt.Fprintf(os.Stderr, color.RedString("Error: --rate-limit には0以上の値を指定してください: %g\n"), *rateLimit)
+		os.Exit(exit.Config)
+	}
+	if *jobsFlag < 0 {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: --jobs には0以上の値を指定してください: %d\n"), *jobsFlag)
+		os.Exit(exit.Config)
+	}
+	if *dryRunDiff && !*sandboxMode {
+		fmt.Fprint(os.Stderr, color.RedString("Error: --dry-run-diff は --sandbox と同時に指定してください\n"))
+		os.Exit(exit.Config)
+	}
+	if *reportFormat != "" && *reportFormat != "json" {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: 無効なレポート形式です: %s (json を指定してください)\n"), *reportFormat)
+		os.Exit(exit.Config)
+	}
+	if *reportFormat != "" && (!*sandboxMode || *dryRunDiff) {
+		fmt.Fprint(os.Stderr, color.RedString("Error: --report はサンドボックスの実行（--sandbox、--dry-run-diff 以外）でのみ指定できます\n"))
+		os.Exit(exit.Config)
+	}
+	if *passes < 1 {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: --passes には1以上の値を指定してください: %d\n"), *passes)
+		os.Exit(exit.Config)
+	}
+	if *suggestionLevel < validation.MinSuggestionLevel || *suggestionLevel > validation.MaxSuggestionLevel {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: --suggestion-level は %d から %d の範囲で指定してください: %d\n"), validation.MinSuggestionLevel, validation.MaxSuggestionLevel, *suggestionLevel)
+		os.Exit(exit.Config)
+	}
+	if *maxLineLength < 1 {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: --max-line-length には1以上の値を指定してください: %d\n"), *maxLineLength)
+		os.Exit(exit.Config)
+	}
+
+	// Reject unknown catalog versions before doing any work
+	if _, err := validation.LoadCommandCatalog(*usacloudVersion); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
 	}
 
 	// Create integrated CLI
 	cli := NewIntegratedCLI()
 
+	// usacloud-update explain <command>
+	if args := flag.Args(); isExplainSubcommand(args) {
+		line, err := parseExplainArgs(args[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.Config)
+		}
+		cli.setColorEnabled(colorEnabledFor(cli.config.ColorMode, os.Stdout))
+		cli.runExplainMode(os.Stdout, line)
+		return
+	}
+
+	// サブコマンド以外の位置引数は受け付けない（flag パッケージは最初の位置引数以降のフラグを解析しない）
+	if err := validatePositionalArgs(flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Config)
+	}
+
 	// Handle different modes
 	if cli.config.SandboxMode {
 		runSandboxMode()
 		return
 	}
 
+	if cli.config.Watch {
+		if err := cli.runWatchMode(); err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.Code(err))
+		}
+		return
+	}
+
 	// Check if validation-only or interactive mode is requested
 	if cli.config.ValidateOnly || cli.config.InteractiveMode {
-		if err := cli.runValidationMode(); err != nil {
+		err := cli.runValidationMode()
+		if cli.config.OutputFormat != "json" || !cli.config.ValidateOnly {
+			cli.finishTiming()
+		}
+		if err != nil {
 			fmt.Fprintf(os.Stderr, color.RedString("Validation error: %v\n"), err)
-			os.Exit(1)
+			os.Exit(exit.Code(err))
+		}
+		return
+	}
+
+	// 変換結果を書き出さずに変更の規模だけを表示
+	if cli.config.DryRun {
+		err := cli.runDryRunMode(os.Stdout)
+		cli.finishTiming()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.Code(err))
 		}
 		return
 	}
 
 	// Traditional conversion mode with optional validation
-	if err := cli.runIntegratedMode(); err != nil {
+	err = cli.runIntegratedMode()
+	cli.finishTiming()
+	if cli.validationConfig.LogLevel == "debug" {
+		writeSuggestionCacheStats(os.Stderr, cli.similarSuggester.CacheStats())
+	}
+	if err != nil {
+		if deprecatedErr, ok := err.(*deprecatedCommandsError); ok {
+			writeDeprecatedReport(os.Stderr, deprecatedErr)
+		}
+		if driftErr, ok := err.(*statsDriftError); ok {
+			writeStatsDriftReport(os.Stderr, driftErr)
+		}
 		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
-		os.Exit(1)
+		os.Exit(exit.Code(err))
 	}
 }
 
 // runSandboxMode executes the new sandbox functionality
 func runSandboxMode() {
-	// Load configuration with new file-based system
-	cfg, err := config.LoadConfig(*configFile)
-	if err != nil {
-		helpers.FatalError("Error loading configuration: %v", err)
+	// --dry-run-diff never executes, so it needs neither credentials nor the usacloud CLI
+	cfg := config.DefaultConfig()
+	if !*dryRunDiff {
+		var err error
+		cfg, err = config.LoadConfig(*configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error loading configuration: %v\n"), err)
+			os.Exit(exit.CodeOr(err, exit.Config))
+		}
 	}
 
 	// Override config with command line flags
-	cfg.Enabled = *sandboxMode
-	cfg.DryRun = *dryRun
+	cfg.Enabled = *sandboxMode && !*dryRunDiff
+	cfg.DryRun = *dryRun || *dryRunDiff
 	cfg.Interactive = *interactive && !*batch
+	if explicitlySetFlags()["rate-limit"] {
+		cfg.RateLimit = *rateLimit
+	}
 
 	// Validate configuration if sandbox is enabled
 	if cfg.Enabled {
 		if err := cfg.Validate(); err != nil {
 			fmt.Fprint(os.Stderr, color.RedString("Configuration validation failed:\n"))
 			cfg.PrintGuide()
-			os.Exit(1)
+			os.Exit(exit.Config)
 		}
 
 		// Check if usacloud CLI is installed
 		if !sandbox.IsUsacloudInstalled() {
 			helpers.PrintError("Error: usacloud CLI not found")
 			fmt.Fprintf(os.Stderr, "Please install usacloud CLI: https://docs.usacloud.jp/usacloud/installation/\n")
-			os.Exit(1)
+			os.Exit(exit.Generic)
 		}
 	}
 
@@ -916,14 +2539,15 @@ func runSandboxMode() {
 	var lines []string
 	var inputSource string
 
-	if *inFile != "-" {
+	if inputPath := inFile.Paths()[0]; inputPath != "-" {
 		// Explicit file input
 		var err error
-		lines, err = cliio.ReadFileLines(*inFile)
+		lines, err = cliio.ReadFileLines(inputPath)
 		if err != nil {
-			helpers.FatalError("Error reading input file: %v", err)
+			fmt.Fprintf(os.Stderr, color.RedString("Error reading input file: %v\n"), err)
+			os.Exit(exit.CodeOr(err, exit.IO))
 		}
-		inputSource = *inFile
+		inputSource = inputPath
 	} else {
 		// No explicit input file - check if stdin has data or use file selector
 		stat, _ := os.Stdin.Stat()
@@ -932,19 +2556,29 @@ func runSandboxMode() {
 			var err error
 			lines, err = cliio.ReadFileLines("-")
 			if err != nil {
-				helpers.FatalError("Error reading stdin: %v", err)
+				fmt.Fprintf(os.Stderr, color.RedString("Error reading stdin: %v\n"), err)
+				os.Exit(exit.CodeOr(err, exit.IO))
 			}
 			inputSource = "<stdin>"
+			if *stdinFilename != "" {
+				inputSource = *stdinFilename
+			}
 		} else {
 			// No stdin data - use file selector
 			selectedFiles, err := runFileSelector(cfg)
 			if err != nil {
-				helpers.FatalError("Error in file selection: %v", err)
+				fmt.Fprintf(os.Stderr, color.RedString("Error in file selection: %v\n"), err)
+				os.Exit(exit.Code(err))
 			}
 
 			if len(selectedFiles) == 0 {
 				helpers.PrintWarning("No files selected. Exiting.")
-				os.Exit(0)
+				os.Exit(exit.Success)
+			}
+
+			if *dryRunDiff {
+				runDryRunDiff(cfg, selectedFiles)
+				return
 			}
 
 			// Process multiple files
@@ -956,7 +2590,8 @@ func runSandboxMode() {
 			// Single file selected
 			lines, err = cliio.ReadFileLines(selectedFiles[0])
 			if err != nil {
-				helpers.FatalError("Error reading selected file: %v", err)
+				fmt.Fprintf(os.Stderr, color.RedString("Error reading selected file: %v\n"), err)
+				os.Exit(exit.CodeOr(err, exit.IO))
 			}
 			inputSource = selectedFiles[0]
 		}
@@ -967,13 +2602,22 @@ func runSandboxMode() {
 		fmt.Fprintf(os.Stderr, color.CyanString("[DEBUG] Input source: %s\n"), inputSource)
 	}
 
+	if *dryRunDiff {
+		writeDryRunDiff(os.Stderr, inputSource, planDryRunDiff(transform.NewDefaultEngine(), sandbox.NewExecutor(cfg), lines))
+		return
+	}
+
 	// Handle different execution modes
 	if cfg.Interactive && !*batch {
+		if *reportFormat != "" {
+			fmt.Fprint(os.Stderr, color.RedString("Error: --report requires --batch or --interactive=false\n"))
+			os.Exit(exit.Config)
+		}
 		// Interactive TUI mode
 		runInteractiveMode(cfg, lines)
 	} else {
 		// Batch mode or non-interactive mode
-		runBatchMode(cfg, lines)
+		runBatchMode(cfg, lines, inputSource)
 	}
 }
 
@@ -1010,61 +2654,68 @@ func runFileSelector(cfg *config.SandboxConfig) ([]string, error) {
 	return selectedFiles, selectorError
 }
 
-// runMultiFileMode processes multiple files sequentially
+// runMultiFileMode processes multiple files, up to --parallel at a time
 func runMultiFileMode(cfg *config.SandboxConfig, filePaths []string) {
-	fmt.Fprintf(os.Stderr, "🔄 Processing %d files in batch mode...\n\n", len(filePaths))
-
-	var allResults []*sandbox.ExecutionResult
-	executor := sandbox.NewExecutor(cfg)
-
-	for i, filePath := range filePaths {
-		fmt.Fprintf(os.Stderr, color.BlueString("📄 Processing file %d/%d: %s\n"), i+1, len(filePaths), filePath)
-
-		// Read file
-		lines, err := readFileLines(filePath)
-		if err != nil {
-			helpers.PrintError("Error reading file %s: %v", filePath, err)
-			continue
+	// Ask once for the destructive commands of all files; unreadable files fail when executed
+	var scripts []sandboxScript
+	for _, path := range filePaths {
+		if lines, err := readFileLines(path); err == nil {
+			scripts = append(scripts, sandboxScript{Path: path, Lines: lines})
 		}
+	}
+	confirmBatchExecution(cfg, scripts)
 
-		// Execute
-		results, err := executor.ExecuteScript(lines)
-		if err != nil {
-			helpers.PrintError("Error executing file %s: %v", filePath, err)
-			continue
-		}
-
-		allResults = append(allResults, results...)
-
-		// Print individual file summary
-		succeeded := 0
-		failed := 0
-		skipped := 0
+	fmt.Fprintf(os.Stderr, "🔄 Processing %d files in batch mode (parallel: %d)...\n\n", len(filePaths), *parallel)
 
-		for _, result := range results {
-			if result.Skipped {
-				skipped++
-			} else if result.Success {
-				succeeded++
-			} else {
-				failed++
-			}
+	progressCfg := parseFlags()
+	progressCfg.ShowProgress, progressCfg.ProgressStyle = resolveProgressSettings()
+	bar := newProgress(progressCfg, len(filePaths))
+	// 同時に実行するファイルの間でもレート制限を共有する
+	limiter := sandbox.NewRateLimiter(cfg.RateLimit)
+	execute := executeSandboxFile(func() *sandbox.Executor {
+		executor := sandbox.NewExecutor(cfg)
+		executor.SetRateLimiter(limiter)
+		if bar != nil {
+			executor.SetOutput(bar.Bypass())
+		}
+		return executor
+	})
+	executions := executeFiles(filePaths, *parallel, func(path string) ([]*sandbox.ExecutionResult, error) {
+		defer bar.Add(1)
+		return execute(path)
+	})
+	bar.Finish()
+	allResults := writeFileExecutionReport(os.Stderr, executions)
+	if *reportFormat != "" {
+		if err := writeSandboxReport(executions); err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.CodeOr(err, exit.IO))
 		}
-
-		fmt.Fprintf(os.Stderr, "  ✅ %d successful, ❌ %d failed, ⏭️  %d skipped\n\n", succeeded, failed, skipped)
 	}
 
 	// Print overall summary
 	if len(allResults) > 0 {
 		fmt.Fprint(os.Stderr, color.HiWhiteString("📊 Overall Summary:\n"))
-		executor.PrintSummary(allResults)
+		sandbox.NewExecutor(cfg).PrintSummary(allResults)
 	}
 
-	// Exit with error code if any commands failed
-	for _, result := range allResults {
-		if !result.Success && !result.Skipped {
-			os.Exit(1)
+	// Exit with error code if any file or command failed, after all files have finished
+	if fileExecutionsFailed(executions) {
+		os.Exit(exit.Generic)
+	}
+}
+
+// runDryRunDiff prints the --dry-run-diff table of each selected file in order
+func runDryRunDiff(cfg *config.SandboxConfig, filePaths []string) {
+	engine := transform.NewDefaultEngine()
+	executor := sandbox.NewExecutor(cfg)
+	for _, path := range filePaths {
+		lines, err := readFileLines(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error reading file %s: %v\n"), path, err)
+			os.Exit(exit.CodeOr(err, exit.IO))
 		}
+		writeDryRunDiff(os.Stderr, path, planDryRunDiff(engine, executor, lines))
 	}
 }
 
@@ -1079,7 +2730,7 @@ func runInteractiveMode(cfg *config.SandboxConfig, lines []string) {
 
 	if err := app.LoadScript(lines); err != nil {
 		fmt.Fprintf(os.Stderr, color.RedString("Error loading script: %v\n"), err)
-		os.Exit(1)
+		os.Exit(exit.Code(err))
 	}
 
 	fmt.Fprint(os.Stderr, color.CyanString("🚀 Starting interactive sandbox mode...\n"))
@@ -1087,12 +2738,13 @@ func runInteractiveMode(cfg *config.SandboxConfig, lines []string) {
 
 	if err := app.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, color.RedString("Error running TUI: %v\n"), err)
-		os.Exit(1)
+		os.Exit(exit.Generic)
 	}
 }
 
 // runBatchMode runs all commands automatically without user interaction
-func runBatchMode(cfg *config.SandboxConfig, lines []string) {
+func runBatchMode(cfg *config.SandboxConfig, lines []string, inputSource string) {
+	confirmBatchExecution(cfg, []sandboxScript{{Path: inputSource, Lines: lines}})
 	executor := sandbox.NewExecutor(cfg)
 
 	fmt.Fprint(os.Stderr, color.CyanString("🔄 Starting batch sandbox execution...\n\n"))
@@ -1100,27 +2752,38 @@ func runBatchMode(cfg *config.SandboxConfig, lines []string) {
 	results, err := executor.ExecuteScript(lines)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, color.RedString("Error executing script: %v\n"), err)
-		os.Exit(1)
+		os.Exit(exit.Code(err))
 	}
 
 	// Print results to stdout (for potential piping/redirection)
-	for _, result := range results {
-		if !result.Skipped && result.Success && result.Output != "" {
-			fmt.Println(result.Output)
+	if !reportToStdout() {
+		for _, result := range results {
+			if !result.Skipped && result.Success && result.Output != "" {
+				fmt.Println(result.Output)
+			}
 		}
 	}
 
 	// Print summary to stderr
 	executor.PrintSummary(results)
+	if *reportFormat != "" {
+		if err := writeSandboxReport([]fileExecution{{Path: inputSource, Results: results}}); err != nil {
+			fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+			os.Exit(exit.Code(err))
+		}
+	}
 
 	// Exit with error code if any commands failed
 	for _, result := range results {
 		if !result.Success && !result.Skipped {
-			os.Exit(1)
+			os.Exit(exit.Generic)
 		}
 	}
 }
 
+// stdinProbe is the shared detector for os.Stdin so repeated checks never spawn extra readers
+var stdinProbe = newStdinDetector(os.Stdin)
+
 // detectStdinInput checks if there's input from stdin within the timeout
 // Returns true if input is available, false if timeout occurs
 func detectStdinInput(timeout time.Duration) bool {
@@ -1136,49 +2799,16 @@ func detectStdinInput(timeout time.Duration) bool {
 		return false
 	}
 
-	// If stdin is not a terminal (pipe or redirect), check if there's actual data
-	if (stat.Mode() & os.ModeCharDevice) == 0 {
-		// For pipes/redirects, try to read with timeout to handle /dev/null case
-		inputAvailable := make(chan bool, 1)
-		go func() {
-			scanner := bufio.NewScanner(os.Stdin)
-			if scanner.Scan() && len(scanner.Text()) > 0 {
-				inputAvailable <- true
-			} else {
-				inputAvailable <- false
-			}
-			close(inputAvailable)
-		}()
-
-		// Even for pipes, apply timeout to handle cases like /dev/null redirection
-		select {
-		case available := <-inputAvailable:
-			return available
-		case <-time.After(timeout):
-			return false
-		}
-	}
-
-	// For interactive terminal, check for input with timeout
-	inputAvailable := make(chan bool, 1)
-	go func() {
-		scanner := bufio.NewScanner(os.Stdin)
-		if scanner.Scan() {
-			// Put the scanned line back for main processing
-			// This is a simple approach - in practice we might need a more sophisticated buffering
-			inputAvailable <- true
-		} else {
-			inputAvailable <- false
-		}
-		close(inputAvailable)
-	}()
-
-	select {
-	case available := <-inputAvailable:
-		return available
-	case <-time.After(timeout):
+	// For pipes/redirects, require actual content to handle /dev/null case;
+	// for interactive terminals any line counts as input
+	isPipe := (stat.Mode() & os.ModeCharDevice) == 0
+	if !stdinProbe.Detect(timeout, isPipe) {
 		return false
 	}
+
+	// 判定のために読み取った内容を後続の入力処理で再生する
+	cliio.SetStdinReader(stdinProbe.Reader())
+	return true
 }
 
 // main function using cobra
@@ -1191,6 +2821,12 @@ func main() {
 	//     return
 	// }
 
+	// シェル補完は cobra の rootCmd より先に処理する（補完スクリプトが呼び出す __complete を含む）
+	if len(os.Args) > 1 && isCompletionCommand(os.Args[1]) {
+		runCompletionCommand(os.Args[1:])
+		return
+	}
+
 	// Check for stdin timeout only if no arguments provided
 	if len(os.Args) == 1 {
 		// Check for input with 2-second timeout
@@ -1,13 +1,31 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/armaniacs/usacloud-update/internal/cli/errors"
+	"github.com/armaniacs/usacloud-update/internal/cli/exit"
+	"github.com/armaniacs/usacloud-update/internal/cli/helpers"
 	cliio "github.com/armaniacs/usacloud-update/internal/cli/io"
+	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/armaniacs/usacloud-update/internal/provenance"
+	"github.com/armaniacs/usacloud-update/internal/risk"
+	"github.com/armaniacs/usacloud-update/internal/sandbox"
 	"github.com/armaniacs/usacloud-update/internal/transform"
 	"github.com/armaniacs/usacloud-update/internal/validation"
 )
@@ -155,6 +173,59 @@ func TestIntegratedCLI_generateOutput(t *testing.T) {
 	}
 }
 
+func TestIntegratedCLI_generateOutput_PreservePermissions(t *testing.T) {
+	tests := []struct {
+		name     string
+		preserve bool
+		wantExec bool
+	}{
+		{"preserve enabled", true, true},
+		{"preserve disabled", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			inputPath := filepath.Join(tmpDir, "input.sh")
+			outputPath := filepath.Join(tmpDir, "output.sh")
+
+			if err := os.WriteFile(inputPath, []byte("usacloud server list\n"), 0755); err != nil {
+				t.Fatalf("Failed to create input file: %v", err)
+			}
+			if err := os.Chmod(inputPath, 0755); err != nil {
+				t.Fatalf("Failed to chmod input file: %v", err)
+			}
+
+			cli := &IntegratedCLI{
+				config: &Config{
+					InputPath:           inputPath,
+					OutputPath:          outputPath,
+					PreservePermissions: tt.preserve,
+				},
+			}
+
+			results := []*ProcessResult{
+				{LineNumber: 1, TransformResult: &transform.Result{Line: "usacloud server list"}},
+			}
+			if err := cli.generateOutput(results); err != nil {
+				t.Fatalf("generateOutput failed: %v", err)
+			}
+
+			info, err := os.Stat(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to stat output file: %v", err)
+			}
+			isExec := info.Mode().Perm()&0111 != 0
+			if isExec != tt.wantExec {
+				t.Errorf("Expected executable=%v, got mode %v", tt.wantExec, info.Mode().Perm())
+			}
+			if tt.preserve && info.Mode().Perm() != 0755 {
+				t.Errorf("Expected mode 0755, got %v", info.Mode().Perm())
+			}
+		})
+	}
+}
+
 func TestIntegratedCLI_outputColorizedChange(t *testing.T) {
 	cli := &IntegratedCLI{}
 
@@ -171,6 +242,53 @@ func TestIntegratedCLI_outputColorizedChange(t *testing.T) {
 	// No assertion needed - just testing it doesn't crash
 }
 
+func TestIntegratedCLI_outputColorizedChange_Warnings(t *testing.T) {
+	cli := &IntegratedCLI{}
+	summary := transform.NewDefaultEngine().Apply("usacloud summary")
+	cdrom := transform.NewDefaultEngine().Apply("usacloud iso-image list")
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	cli.outputColorizedChange(&summary, 3)
+	cli.outputColorizedChange(&cdrom, 4)
+	w.Close()
+	os.Stderr = oldStderr
+
+	captured, _ := io.ReadAll(r)
+	r.Close()
+	out := string(captured)
+
+	if !strings.Contains(out, "L3: 要確認:") || !strings.Contains(out, "[summary-removed]") {
+		t.Errorf("Expected the commented out command as a warning, got %q", out)
+	}
+	if strings.Contains(out, "L4: 要確認:") {
+		t.Errorf("Expected no warning for a plain rename, got %q", out)
+	}
+}
+
+func TestIntegratedCLI_outputColorizedChange_StatFormat(t *testing.T) {
+	format, err := transform.ParseChangeFormat("{{.Line}}|{{.Before}}|{{.After}}|{{.Rule}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &IntegratedCLI{changeFormat: format}
+	cdrom := transform.NewDefaultEngine().Apply("usacloud iso-image list")
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	cli.outputColorizedChange(&cdrom, 4)
+	w.Close()
+	os.Stderr = oldStderr
+
+	captured, _ := io.ReadAll(r)
+	r.Close()
+	if want := "4|usacloud iso-image|usacloud cdrom|iso-image-to-cdrom\n"; string(captured) != want {
+		t.Errorf("Expected %q, got %q", want, captured)
+	}
+}
+
 func TestReadFileLines(t *testing.T) {
 	// Create test file
 	tmpFile, err := os.CreateTemp("", "test_lines_*.txt")
@@ -594,26 +712,6 @@ func TestGenerateReason(t *testing.T) {
 	}
 }
 
-func TestExtractCommand(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		{"usacloud server list", "server list"},
-		{"usacloud server", "server"},
-		{"echo usacloud server list", "server list"},
-		{"echo hello world", ""},
-		{"usacloud", ""},
-	}
-
-	for _, tt := range tests {
-		result := extractCommand(tt.input)
-		if result != tt.expected {
-			t.Errorf("extractCommand('%s') = '%s', expected '%s'", tt.input, result, tt.expected)
-		}
-	}
-}
-
 func TestValidateLineIntegration(t *testing.T) {
 	cli := NewIntegratedCLI()
 
@@ -738,6 +836,259 @@ func TestIntegratedCLI_runIntegratedMode_Success(t *testing.T) {
 	}
 }
 
+func TestIntegratedCLI_runIntegratedMode_FailOnDeprecated(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "deprecated.sh")
+	content := "usacloud iso-image list\nusacloud server list\n# usacloud summary\nusacloud summary\n"
+	if err := os.WriteFile(input, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = input
+	cli.config.OutputPath = filepath.Join(dir, "out.sh")
+	cli.config.ShowStats = false
+	cli.config.SkipDeprecated = true // --strict-validation や警告の有無とは独立
+	cli.config.FailOnDeprecated = true
+
+	err := cli.runIntegratedMode()
+	deprecatedErr, ok := err.(*deprecatedCommandsError)
+	if !ok {
+		t.Fatalf("Expected deprecatedCommandsError, got %v", err)
+	}
+	if _, err := os.Stat(cli.config.OutputPath); err != nil {
+		t.Errorf("Expected the conversion to be written before failing: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writeDeprecatedReport(&buf, deprecatedErr)
+	for _, want := range []string{"(2件)", input + ":1: iso-image → cdrom", input + ":4: summary（代替コマンドなし）"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	cli.config.FailOnDeprecated = false
+	if err := cli.runIntegratedMode(); err != nil {
+		t.Errorf("Expected deprecated commands not to fail without the flag, got %v", err)
+	}
+}
+
+func TestIntegratedCLI_runIntegratedMode_StatsBaseline(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "corpus.sh")
+	content := "usacloud server list --output-type csv\nusacloud iso-image list\nusacloud server list\n"
+	if err := os.WriteFile(input, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	baseline := filepath.Join(dir, "baseline.json")
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = input
+	cli.config.OutputPath = filepath.Join(dir, "out.sh")
+	cli.config.ShowStats = false
+	cli.config.DumpStatsBaseline = baseline
+	if err := cli.runIntegratedMode(); err != nil {
+		t.Fatalf("Failed to dump the baseline: %v", err)
+	}
+
+	cli.config.DumpStatsBaseline = ""
+	cli.config.CompareStatsBaseline = baseline
+	if err := cli.runIntegratedMode(); err != nil {
+		t.Errorf("Expected an unchanged corpus to match its baseline, got %v", err)
+	}
+
+	// 同じコーパスでもルールを無効にすると適用数が変わる
+	engine, err := transform.NewEngineWithOptions(transform.EngineOptions{DisableRules: []string{"iso-image-to-cdrom"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli.transformEngine = engine
+	err = cli.runIntegratedMode()
+	driftErr, ok := err.(*statsDriftError)
+	if !ok {
+		t.Fatalf("Expected statsDriftError, got %v", err)
+	}
+	if code := exit.Code(err); code != exit.StatsDrift {
+		t.Errorf("Expected exit code %d, got %d", exit.StatsDrift, code)
+	}
+
+	var buf bytes.Buffer
+	writeStatsDriftReport(&buf, driftErr)
+	for _, want := range []string{"changed_lines: 2 → 1 (-50%)", "iso-image-to-cdrom: 1 → 0 (-100%)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	cli.config.StatsBaselineTolerance = 1
+	if err := cli.runIntegratedMode(); err != nil {
+		t.Errorf("Expected drift within the tolerance to pass, got %v", err)
+	}
+}
+
+func TestValidateStatsBaselineConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"none", Config{}, false},
+		{"dump", Config{DumpStatsBaseline: "b.json"}, false},
+		{"compare", Config{CompareStatsBaseline: "b.json", StatsBaselineTolerance: 0.1}, false},
+		{"both", Config{DumpStatsBaseline: "b.json", CompareStatsBaseline: "b.json"}, true},
+		{"validate only", Config{CompareStatsBaseline: "b.json", ValidateOnly: true}, true},
+		{"negative tolerance", Config{CompareStatsBaseline: "b.json", StatsBaselineTolerance: -0.1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateStatsBaselineConfig(&tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateStatsBaselineConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIntegratedCLI_ExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.sh")
+	invalid := filepath.Join(dir, "invalid.sh")
+	deprecated := filepath.Join(dir, "deprecated.sh")
+	for path, content := range map[string]string{
+		valid:      "usacloud server list\n",
+		invalid:    "usacloud invalidcommand list\n",
+		deprecated: "usacloud iso-image list\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		configure func(cfg *Config)
+		validate  bool // run the --validate-only mode instead of the conversion
+		want      int
+	}{
+		{"success", func(cfg *Config) {}, false, exit.Success},
+		{"missing input", func(cfg *Config) { cfg.InputPath = filepath.Join(dir, "missing.sh") }, false, exit.IO},
+		{"missing input when validating", func(cfg *Config) { cfg.InputPath = filepath.Join(dir, "missing.sh") }, true, exit.IO},
+		{"directory as output", func(cfg *Config) { cfg.OutputPath = dir }, false, exit.IO},
+		{"unsupported encoding", func(cfg *Config) { cfg.InputEncoding = "utf-7" }, false, exit.Config},
+		{"validation errors", func(cfg *Config) { cfg.InputPath = invalid }, true, exit.Validation},
+		{"strict validation", func(cfg *Config) { cfg.InputPath = invalid; cfg.StrictValidation = true }, false, exit.Validation},
+		{"deprecated commands", func(cfg *Config) { cfg.InputPath = deprecated; cfg.FailOnDeprecated = true }, false, exit.Deprecated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewIntegratedCLI()
+			cli.config.InputPath = valid
+			cli.config.OutputPath = filepath.Join(t.TempDir(), "out.sh")
+			cli.config.ShowStats = false
+			tt.configure(cli.config)
+
+			var err error
+			if tt.validate {
+				cli.config.ValidateOnly = true
+				err = cli.runValidationMode()
+			} else {
+				err = cli.runIntegratedMode()
+			}
+			if code := exit.Code(err); code != tt.want {
+				t.Errorf("Expected exit code %d, got %d (error: %v)", tt.want, code, err)
+			}
+		})
+	}
+}
+
+// runStdinConversion は input を標準入力として runIntegratedMode を実行し、標準出力と標準エラー出力を返す
+func runStdinConversion(t *testing.T, cli *IntegratedCLI, input string) (string, string, error) {
+	t.Helper()
+	cliio.SetStdinReader(strings.NewReader(input))
+	defer cliio.SetStdinReader(nil)
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+	outCh, errCh := make(chan []byte), make(chan []byte)
+	go func() { b, _ := io.ReadAll(outR); outCh <- b }()
+	go func() { b, _ := io.ReadAll(errR); errCh <- b }()
+
+	err := cli.runIntegratedMode()
+	outW.Close()
+	errW.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	return string(<-outCh), string(<-errCh), err
+}
+
+func TestIntegratedCLI_runIntegratedMode_StreamsStdin(t *testing.T) {
+	long := "usacloud server list --name " + strings.Repeat("x", 200)
+	input := "#!/bin/bash\nusacloud iso-image list\n" + long + "\nusacloud server list \\\n  --output-type csv\necho done\n"
+
+	newCLI := func() *IntegratedCLI {
+		cli := NewIntegratedCLI()
+		cli.config.InputPath = "-"
+		cli.config.InputPaths = []string{"-"}
+		cli.config.OutputPath = "-"
+		cli.config.MaxLineLength = 100
+		return cli
+	}
+
+	streaming := newCLI()
+	if !streaming.canStream() {
+		t.Fatal("expected a plain stdin to stdout conversion to be streamed")
+	}
+	streamed, streamedStderr, err := runStdinConversion(t, streaming, input)
+	if err != nil {
+		t.Fatalf("streamed conversion failed: %v", err)
+	}
+
+	// --timing は入力全体を読み込んで変換するため、同じ入力の結果と比較できる
+	buffering := newCLI()
+	buffering.config.Timing = true
+	if buffering.canStream() {
+		t.Fatal("expected --timing to read the whole input")
+	}
+	buffered, bufferedStderr, err := runStdinConversion(t, buffering, input)
+	if err != nil {
+		t.Fatalf("buffered conversion failed: %v", err)
+	}
+
+	if streamed != buffered {
+		t.Errorf("streamed output differs from the buffered one:\nstreamed:\n%s\nbuffered:\n%s", streamed, buffered)
+	}
+	// 行単位の変換では警告が変更の表示と行順に混ざるため、順序を除いて比較する
+	streamedLines, bufferedLines := strings.Split(streamedStderr, "\n"), strings.Split(bufferedStderr, "\n")
+	sort.Strings(streamedLines)
+	sort.Strings(bufferedLines)
+	if !reflect.DeepEqual(streamedLines, bufferedLines) {
+		t.Errorf("streamed messages differ from the buffered ones:\nstreamed:\n%s\nbuffered:\n%s", streamedStderr, bufferedStderr)
+	}
+	for _, want := range []string{"-:3: 行が長すぎる", "📊 変換統計: 6行中"} {
+		if !strings.Contains(streamedStderr, want) {
+			t.Errorf("expected %q in the messages, got:\n%s", want, streamedStderr)
+		}
+	}
+}
+
+func TestIntegratedCLI_runIntegratedMode_StreamsStdinAlreadyProcessed(t *testing.T) {
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = "-"
+	cli.config.InputPaths = []string{"-"}
+	cli.config.OutputPath = "-"
+
+	input := transform.GeneratedHeader() + "\nusacloud cdrom list\n"
+	stdout, _, err := runStdinConversion(t, cli, input)
+	if exit.Code(err) != exit.AlreadyProcessed {
+		t.Fatalf("expected exit code %d, got %d (%v)", exit.AlreadyProcessed, exit.Code(err), err)
+	}
+	if stdout != "" {
+		t.Errorf("expected no output for an already processed input, got %q", stdout)
+	}
+}
+
 func TestIntegratedCLI_runIntegratedMode_FileReadError(t *testing.T) {
 	cli := NewIntegratedCLI()
 	cli.config.InputPath = "/nonexistent/file/path"
@@ -815,6 +1166,68 @@ func TestIntegratedCLI_processLines_Success(t *testing.T) {
 	}
 }
 
+func TestIntegratedCLI_processLines_Stats(t *testing.T) {
+	cli := NewIntegratedCLI()
+	cli.config.ShowStats = false
+
+	if _, err := cli.processLines([]string{
+		"usacloud server list --output-type=csv",
+		"echo 'test'",
+		"usacloud iso-image list --output-type=tsv",
+	}); err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+	if cli.stats.TotalLines != 3 || cli.stats.ChangedLines != 2 {
+		t.Errorf("Unexpected stats: %+v", cli.stats)
+	}
+
+	var buf bytes.Buffer
+	writeTransformStats(&buf, cli.stats)
+	want := "\n📊 変換統計: 3行中 2行を変更\n  • output-type-csv-tsv: 2回適用\n  • iso-image-to-cdrom: 1回適用\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected stats output:\n%s", buf.String())
+	}
+}
+
+func TestIntegratedCLI_processLines_Heredoc(t *testing.T) {
+	lines := []string{
+		"ssh host <<'EOF'",
+		"usacloud iso-image list",
+		"EOF",
+		"usacloud iso-image list",
+	}
+
+	tests := []struct {
+		name      string
+		transform bool
+		changed   []bool
+	}{
+		{"untouched by default", false, []bool{false, false, false, true}},
+		{"transform-heredocs", true, []bool{false, true, false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewIntegratedCLI()
+			cli.config.ShowStats = false
+			cli.config.TransformHeredocs = tt.transform
+
+			results, err := cli.processLines(lines)
+			if err != nil {
+				t.Fatalf("processLines failed: %v", err)
+			}
+			for i, r := range results {
+				if r.TransformResult.Changed != tt.changed[i] {
+					t.Errorf("L%d: expected changed=%v, got %q", i+1, tt.changed[i], r.TransformResult.Line)
+				}
+			}
+			if !tt.transform && results[1].ValidationResult != nil {
+				t.Errorf("Expected the heredoc body not to be validated, got %+v", results[1].ValidationResult)
+			}
+		})
+	}
+}
+
 func TestIntegratedCLI_processLines_StrictValidationError(t *testing.T) {
 	cli := NewIntegratedCLI()
 	cli.config.StrictValidation = true
@@ -906,114 +1319,568 @@ func TestIntegratedCLI_performValidationOnly_WithIssues(t *testing.T) {
 	}
 }
 
-func TestIntegratedCLI_performValidationOnly_DeprecatedCommands(t *testing.T) {
-	cli := NewIntegratedCLI()
-
+func TestIntegratedCLI_performValidationOnly_SummaryThreshold(t *testing.T) {
 	testLines := []string{
-		"usacloud iso-image list",      // Deprecated command should cause issue
-		"usacloud startup-script list", // Another deprecated command
+		"usacloud invalidcommand list",
+		"usacloud server invalidaction",
 	}
 
-	err := cli.performValidationOnly(testLines)
-	// Deprecated commands should cause validation issues
-	if err == nil {
-		t.Error("performValidationOnly should return error when deprecated commands found")
+	tests := []struct {
+		name       string
+		threshold  int
+		wantReport bool
+	}{
+		{"below threshold", 5, false},
+		{"at threshold", 2, false},
+		{"above threshold", 1, true},
+		{"default", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewIntegratedCLI()
+			cli.config.SummaryThreshold = tt.threshold
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			err := cli.performValidationOnly(testLines)
+			w.Close()
+			os.Stderr = oldStderr
+
+			captured, _ := io.ReadAll(r)
+			r.Close()
+
+			// しきい値に関係なく終了ステータスは問題の有無で決まる
+			if err == nil || !strings.Contains(err.Error(), "2個の検証エラー") {
+				t.Errorf("Expected validation error for 2 issues, got: %v", err)
+			}
+			if got := strings.Contains(string(captured), "検証結果"); got != tt.wantReport {
+				t.Errorf("Expected report printed = %v, got output:\n%s", tt.wantReport, captured)
+			}
+		})
 	}
 }
 
-func TestIntegratedCLI_performValidationOnly_MixedIssues(t *testing.T) {
-	cli := NewIntegratedCLI()
+func TestIntegratedCLI_performValidationOnly_SummaryOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    string
+		wantErr bool
+	}{
+		{"issues", []string{"usacloud invalidcommand list", "usacloud server list", "usacloud server invalidaction"}, "エラー 2件・警告 0件（問題のあるファイル 1件・行 2件）", true},
+		{"no issues", []string{"usacloud server list"}, "エラー 0件・警告 0件（問題のあるファイル 0件・行 0件）", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewIntegratedCLI()
+			cli.config.SummaryOnly = true
+
+			oldStdout, oldStderr := os.Stdout, os.Stderr
+			outR, outW, _ := os.Pipe()
+			errR, errW, _ := os.Pipe()
+			os.Stdout, os.Stderr = outW, errW
+			err := cli.performValidationOnly(tt.lines)
+			outW.Close()
+			errW.Close()
+			os.Stdout, os.Stderr = oldStdout, oldStderr
+
+			stdout, _ := io.ReadAll(outR)
+			stderr, _ := io.ReadAll(errR)
+			outR.Close()
+			errR.Close()
+
+			if (err != nil) != tt.wantErr || (err != nil && exit.Code(err) != exit.Validation) {
+				t.Errorf("Expected validation error = %v, got: %v", tt.wantErr, err)
+			}
+			if !strings.Contains(string(stdout), tt.want) || strings.Count(string(stdout), "\n") != 1 {
+				t.Errorf("Expected a single summary line with %q, got:\n%s", tt.want, stdout)
+			}
+			if len(stderr) != 0 {
+				t.Errorf("Expected no per-issue detail, got:\n%s", stderr)
+			}
+		})
+	}
+}
 
+func TestIntegratedCLI_performValidationOnly_GroupErrors(t *testing.T) {
 	testLines := []string{
-		"usacloud server list",         // Valid command
-		"usacloud invalidcommand list", // Invalid command (error)
-		"usacloud iso-image list",      // Deprecated command (warning)
-		"echo 'test'",                  // Non-usacloud line
+		"usacloud serv list",
+		"echo 'usacloud serv'",
+		"usacloud serv list",
+		"usacloud server lst",
+		"usacloud serv read 1",
 	}
 
+	cli := NewIntegratedCLI()
+	cli.config.GroupErrors = true
+	cli.errorFormatter.SetColorEnabled(false)
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
 	err := cli.performValidationOnly(testLines)
+	w.Close()
+	os.Stderr = oldStderr
+
+	captured, _ := io.ReadAll(r)
+	r.Close()
+	out := string(captured)
+
 	if err == nil {
-		t.Error("performValidationOnly should return error when validation issues found")
+		t.Error("Expected validation error")
 	}
-
-	// Should report the total number of issues found
-	if !strings.Contains(err.Error(), "検証エラーが見つかりました") {
-		t.Errorf("Expected error to contain validation message, got: %v", err)
+	if n := strings.Count(out, "'serv' は有効なusacloudコマンドではありません"); n != 1 {
+		t.Errorf("Expected one block for the repeated command, got %d in:\n%s", n, out)
+	}
+	for _, want := range []string{"3回使用 (行 1, 3, 5)", "もしかして 'server' ですか？", "'lst' は有効なサブコマンドではありません", "1回使用 (行 4)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
 	}
 }
 
-// Phase 1 Coverage Improvement Tests - Additional High-Impact Functions
-
-func TestPrintHelpMessage(t *testing.T) {
-	// Capture stdout to test help message output
-	// This function outputs to stdout, so we test its execution without error
-	defer func() {
-		if r := recover(); r != nil {
-			t.Errorf("printHelpMessage should not panic, got: %v", r)
-		}
-	}()
+func TestIntegratedCLI_performValidationOnly_JSON(t *testing.T) {
+	run := func(t *testing.T, lines []string) (string, error) {
+		t.Helper()
+		cli := NewIntegratedCLI()
+		cli.config.OutputFormat = "json"
 
-	// Execute the function - it should not panic or error
-	printHelpMessage()
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		err := cli.performValidationOnly(lines)
+		w.Close()
+		os.Stdout = oldStdout
 
-	// If we reach here without panic, the test passes
-	// The function outputs help text to stdout which is its expected behavior
-}
+		captured, _ := io.ReadAll(r)
+		r.Close()
+		return string(captured), err
+	}
 
-func TestReadFileLines_Success(t *testing.T) {
-	// Create temporary test file
-	tmpFile, err := os.CreateTemp("", "test_readlines_*.txt")
-	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
+	output, err := run(t, []string{
+		"usacloud server list",
+		"usacloud serverr list",
+		"usacloud iso-image list",
+	})
+	if err == nil {
+		t.Error("Expected a non-nil error when issues are found")
 	}
-	defer os.Remove(tmpFile.Name())
-	defer tmpFile.Close()
 
-	testContent := "line 1\nline 2\nline 3\n"
-	_, err = tmpFile.WriteString(testContent)
-	if err != nil {
-		t.Fatalf("Failed to write test content: %v", err)
+	var report []struct {
+		LineNumber int    `json:"line_number"`
+		Line       string `json:"line"`
+		Issues     []struct {
+			Type      string `json:"type"`
+			Severity  string `json:"severity"`
+			Component string `json:"component"`
+			Message   string `json:"message"`
+		} `json:"issues"`
+		Suggestions []struct {
+			Command string  `json:"command"`
+			Score   float64 `json:"score"`
+		} `json:"suggestions"`
+	}
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Expected stdout to be a JSON array, got %v:\n%s", err, output)
+	}
+	if len(report) != 2 {
+		t.Fatalf("Expected 2 entries, got %d:\n%s", len(report), output)
 	}
-	tmpFile.Close()
 
-	lines, err := readFileLines(tmpFile.Name())
-	if err != nil {
-		t.Errorf("readFileLines should succeed with valid file, got error: %v", err)
+	typo := report[0]
+	if typo.LineNumber != 2 || typo.Line != "usacloud serverr list" {
+		t.Errorf("Unexpected first entry: %+v", typo)
+	}
+	if len(typo.Issues) == 0 || typo.Issues[0].Type != "InvalidMainCommand" || typo.Issues[0].Severity != "error" || typo.Issues[0].Component != "serverr" {
+		t.Errorf("Unexpected issues for the typo: %+v", typo.Issues)
+	}
+	if len(typo.Suggestions) == 0 || typo.Suggestions[0].Command != "server" {
+		t.Errorf("Expected 'server' to be suggested, got %+v", typo.Suggestions)
 	}
 
-	expectedLines := []string{"line 1", "line 2", "line 3"}
-	if len(lines) != len(expectedLines) {
-		t.Errorf("Expected %d lines, got %d", len(expectedLines), len(lines))
+	deprecated := report[1]
+	if deprecated.LineNumber != 3 || deprecated.Issues[0].Type != "DeprecatedCommand" || deprecated.Issues[0].Severity != "warning" {
+		t.Errorf("Unexpected deprecated entry: %+v", deprecated)
 	}
 
-	for i, expected := range expectedLines {
-		if i < len(lines) && lines[i] != expected {
-			t.Errorf("Expected line %d to be '%s', got '%s'", i, expected, lines[i])
-		}
+	// 問題がなければ空配列を出力して成功
+	output, err = run(t, []string{"usacloud server list"})
+	if err != nil {
+		t.Errorf("Expected success without issues, got: %v", err)
+	}
+	if strings.TrimSpace(output) != "[]" {
+		t.Errorf("Expected empty JSON array, got %q", output)
 	}
 }
 
-func TestReadFileLines_FileNotFound(t *testing.T) {
-	lines, err := readFileLines("/nonexistent/file/path")
-	if err == nil {
-		t.Error("readFileLines should return error when file doesn't exist")
+func TestWriteValidationSARIF(t *testing.T) {
+	results := []ValidationResult{
+		{LineNumber: 3, Line: "usacloud serverr list", Issues: []ValidationIssue{{Type: IssueInvalidMainCommand, Message: "invalid", Component: "serverr"}}},
+		{LineNumber: 7, Line: "usacloud iso-image list", Issues: []ValidationIssue{{Type: IssueDeprecatedCommand, Message: "deprecated", Component: "iso-image"}}},
+		{LineNumber: 9, Line: "usacloud foo list", Issues: []ValidationIssue{{Type: IssueInvalidMainCommand, Message: "invalid", Component: "foo"}}},
 	}
 
-	if lines != nil {
-		t.Error("readFileLines should return nil lines when file doesn't exist")
+	var buf bytes.Buffer
+	if err := writeValidationSARIF(&buf, "./scripts/deploy.sh", results); err != nil {
+		t.Fatalf("writeValidationSARIF failed: %v", err)
 	}
-}
 
-func TestReadFileLines_EmptyFile(t *testing.T) {
-	// Create empty temporary file
-	tmpFile, err := os.CreateTemp("", "test_empty_*.txt")
-	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid SARIF JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || !strings.Contains(log.Schema, "sarif-2.1.0") || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected SARIF header: %+v", log)
 	}
-	defer os.Remove(tmpFile.Name())
-	tmpFile.Close()
 
-	lines, err := readFileLines(tmpFile.Name())
+	driver := log.Runs[0].Tool.Driver
+	if driver.Name != "usacloud-update" || driver.Version != version {
+		t.Errorf("Unexpected driver: %+v", driver)
+	}
+	if len(driver.Rules) != 2 || driver.Rules[0].ID != "InvalidMainCommand" || driver.Rules[1].ID != "DeprecatedCommand" {
+		t.Errorf("Expected one rule per issue type in order of appearance, got %+v", driver.Rules)
+	}
+
+	got := log.Runs[0].Results
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(got))
+	}
+	expected := []struct {
+		ruleID string
+		level  string
+		line   int
+	}{
+		{"InvalidMainCommand", "error", 3},
+		{"DeprecatedCommand", "warning", 7},
+		{"InvalidMainCommand", "error", 9},
+	}
+	for i, want := range expected {
+		loc := got[i].Locations[0].PhysicalLocation
+		if got[i].RuleID != want.ruleID || got[i].Level != want.level || loc.Region.StartLine != want.line {
+			t.Errorf("result %d: got %s/%s line %d, want %s/%s line %d", i, got[i].RuleID, got[i].Level, loc.Region.StartLine, want.ruleID, want.level, want.line)
+		}
+		if loc.ArtifactLocation.URI != "scripts/deploy.sh" {
+			t.Errorf("result %d: expected uri scripts/deploy.sh, got %q", i, loc.ArtifactLocation.URI)
+		}
+	}
+
+	// 問題がなくても空の results を持つ有効なSARIFを出力
+	buf.Reset()
+	if err := writeValidationSARIF(&buf, "-", nil); err != nil {
+		t.Fatalf("writeValidationSARIF failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"results": []`) {
+		t.Errorf("Expected empty results array, got:\n%s", buf.String())
+	}
+}
+
+func TestStdinFilename(t *testing.T) {
+	cli := &IntegratedCLI{config: &Config{InputPath: "-", StdinFilename: "deploy.sh"}}
+	if got := cli.inputName(); got != "deploy.sh" {
+		t.Errorf("Expected stdin to be named after --stdin-filename, got %q", got)
+	}
+	cli.config.InputPath = "scripts/setup.sh"
+	if got := cli.inputName(); got != "scripts/setup.sh" {
+		t.Errorf("Expected a file input to keep its path, got %q", got)
+	}
+	cli.config.InputPath, cli.config.StdinFilename = "-", ""
+	if got := cli.inputName(); got != "-" {
+		t.Errorf("Expected stdin without --stdin-filename to stay -, got %q", got)
+	}
+
+	results := []ValidationResult{{LineNumber: 2, Line: "usacloud serv list", Issues: []ValidationIssue{{Type: IssueInvalidMainCommand, Message: "invalid"}}}}
+	var buf bytes.Buffer
+	if err := writeValidationJSON(&buf, "deploy.sh", results, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"file": "deploy.sh"`) {
+		t.Errorf("Expected the file name in the JSON report, got:\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := writeValidationJSON(&buf, "-", results, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `"file"`) {
+		t.Errorf("Expected no file name for unnamed stdin, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteValidationJSON_Metadata(t *testing.T) {
+	results := []ValidationResult{{LineNumber: 2, Line: "usacloud serv list", Issues: []ValidationIssue{{Type: IssueInvalidMainCommand, Message: "invalid"}}}}
+	metadata := &validationReportMetadata{Timing: &timingReport{ReadMs: 1.5, TotalMs: 4}}
+
+	var buf bytes.Buffer
+	if err := writeValidationJSON(&buf, "deploy.sh", results, metadata); err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Results  []validationReportEntry `json:"results"`
+		Metadata struct {
+			Timing timingReport `json:"timing"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid JSON report: %v\n%s", err, buf.String())
+	}
+	if len(report.Results) != 1 || report.Results[0].LineNumber != 2 {
+		t.Errorf("Unexpected results: %+v", report.Results)
+	}
+	if report.Metadata.Timing.ReadMs != 1.5 || report.Metadata.Timing.TotalMs != 4 {
+		t.Errorf("Unexpected timing metadata: %+v", report.Metadata.Timing)
+	}
+}
+
+func TestIntegratedCLI_Timing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deploy.sh")
+	if err := os.WriteFile(path, []byte("usacloud iso-image list\nusacloud server list\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = path
+	cli.config.OutputPath = filepath.Join(t.TempDir(), "out.sh")
+	cli.config.ShowStats = false
+	cli.timer = newPhaseTimer()
+
+	oldStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	err := cli.runIntegratedMode()
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("runIntegratedMode failed: %v", err)
+	}
+
+	r := cli.timer.report()
+	if r.ReadMs <= 0 || r.TransformMs <= 0 || r.ValidateMs <= 0 || r.WriteMs <= 0 {
+		t.Errorf("Expected every phase to be timed, got %+v", r)
+	}
+	if r.TotalMs < r.ReadMs+r.WriteMs {
+		t.Errorf("Expected the total to cover the phases, got %+v", r)
+	}
+
+	var buf bytes.Buffer
+	writeTimings(&buf, r)
+	for _, label := range []string{"読み込み", "変換", "検証", "書き出し", "合計"} {
+		if !strings.Contains(buf.String(), "• "+label+": ") {
+			t.Errorf("Expected %s in the timings, got:\n%s", label, buf.String())
+		}
+	}
+
+	// 計測しない場合は記録しない
+	var none *phaseTimer
+	none.record(phaseRead, time.Now())
+}
+
+func TestIntegratedCLI_TreatUnknownAs(t *testing.T) {
+	tests := []struct {
+		mode        string
+		wantIssue   bool
+		wantAdvice  bool
+		wantFailure bool
+	}{
+		{unknownAsError, true, false, true},
+		{"", true, false, true}, // 未指定時は error
+		{unknownAsWarning, true, true, false},
+		{unknownAsIgnore, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run("mode="+tt.mode, func(t *testing.T) {
+			cli := NewIntegratedCLI()
+			cli.config.TreatUnknownAs = tt.mode
+
+			result := cli.validateLine("usacloud brandnewservice list", 1)
+			if (result != nil) != tt.wantIssue {
+				t.Fatalf("Expected issue = %v, got %+v", tt.wantIssue, result)
+			}
+			if result != nil {
+				issue := result.Issues[0]
+				if issue.Type != IssueInvalidMainCommand || issue.Advisory != tt.wantAdvice {
+					t.Errorf("Unexpected issue: %+v", issue)
+				}
+				if result.HasErrors() != tt.wantFailure {
+					t.Errorf("Expected HasErrors() = %v, got %v", tt.wantFailure, result.HasErrors())
+				}
+				wantSeverity := severityError
+				if tt.wantAdvice {
+					wantSeverity = severityWarning
+				}
+				if issue.EffectiveSeverity() != wantSeverity {
+					t.Errorf("Expected severity %s, got %s", wantSeverity, issue.EffectiveSeverity())
+				}
+			}
+
+			err := cli.performValidationOnly([]string{"usacloud brandnewservice list", "usacloud server list"})
+			if (err != nil) != tt.wantFailure {
+				t.Errorf("Expected validation failure = %v, got: %v", tt.wantFailure, err)
+			}
+
+			// カタログにないサブコマンドも同じ扱い
+			result = cli.validateLine("usacloud server brandnewaction", 1)
+			if (result != nil) != tt.wantIssue {
+				t.Fatalf("Expected subcommand issue = %v, got %+v", tt.wantIssue, result)
+			}
+			if result != nil {
+				issue := result.Issues[0]
+				if issue.Type != IssueInvalidSubCommand || issue.Advisory != tt.wantAdvice || result.HasErrors() != tt.wantFailure {
+					t.Errorf("Unexpected subcommand issue: %+v", issue)
+				}
+			}
+
+			// 廃止コマンドなど他の問題は引き続き検証失敗になる
+			if err := cli.performValidationOnly([]string{"usacloud brandnewservice list", "usacloud server brandnewaction", "usacloud summary"}); err == nil {
+				t.Error("Expected a deprecated command to still fail validation")
+			}
+		})
+	}
+}
+
+func TestIntegratedCLI_performValidationOnly_DeprecatedCommands(t *testing.T) {
+	cli := NewIntegratedCLI()
+
+	testLines := []string{
+		"usacloud iso-image list",      // Deprecated command should cause issue
+		"usacloud startup-script list", // Another deprecated command
+	}
+
+	err := cli.performValidationOnly(testLines)
+	// Deprecated commands should cause validation issues
+	if err == nil {
+		t.Error("performValidationOnly should return error when deprecated commands found")
+	}
+}
+
+func TestIntegratedCLI_performValidationOnly_MixedIssues(t *testing.T) {
+	cli := NewIntegratedCLI()
+
+	testLines := []string{
+		"usacloud server list",         // Valid command
+		"usacloud invalidcommand list", // Invalid command (error)
+		"usacloud iso-image list",      // Deprecated command (warning)
+		"echo 'test'",                  // Non-usacloud line
+	}
+
+	err := cli.performValidationOnly(testLines)
+	if err == nil {
+		t.Error("performValidationOnly should return error when validation issues found")
+	}
+
+	// Should report the total number of issues found
+	if !strings.Contains(err.Error(), "検証エラーが見つかりました") {
+		t.Errorf("Expected error to contain validation message, got: %v", err)
+	}
+}
+
+// Phase 1 Coverage Improvement Tests - Additional High-Impact Functions
+
+func TestPrintHelpMessage(t *testing.T) {
+	// Capture stdout to test help message output
+	// This function outputs to stdout, so we test its execution without error
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("printHelpMessage should not panic, got: %v", r)
+		}
+	}()
+
+	// Execute the function - it should not panic or error
+	printHelpMessage()
+
+	// If we reach here without panic, the test passes
+	// The function outputs help text to stdout which is its expected behavior
+}
+
+func TestParseCommandLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantHelp bool
+		wantCode int
+	}{
+		{"valid flags", []string{"--count", "2", "input.sh"}, false, exit.Success},
+		{"unknown flag", []string{"--bogus"}, false, exit.Config},
+		{"invalid value", []string{"--count", "abc"}, false, exit.Config},
+		{"help", []string{"-h"}, true, exit.Success},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("usacloud-update", flag.ExitOnError)
+			fs.SetOutput(io.Discard)
+			fs.Int("count", 0, "")
+
+			err := parseCommandLine(fs, tt.args)
+			if tt.wantHelp {
+				if err != flag.ErrHelp {
+					t.Errorf("expected flag.ErrHelp, got %v", err)
+				}
+				return
+			}
+			if code := exit.Code(err); code != tt.wantCode {
+				t.Errorf("expected exit code %d, got %d (%v)", tt.wantCode, code, err)
+			}
+		})
+	}
+}
+
+func TestReadFileLines_Success(t *testing.T) {
+	// Create temporary test file
+	tmpFile, err := os.CreateTemp("", "test_readlines_*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	testContent := "line 1\nline 2\nline 3\n"
+	_, err = tmpFile.WriteString(testContent)
+	if err != nil {
+		t.Fatalf("Failed to write test content: %v", err)
+	}
+	tmpFile.Close()
+
+	lines, err := readFileLines(tmpFile.Name())
+	if err != nil {
+		t.Errorf("readFileLines should succeed with valid file, got error: %v", err)
+	}
+
+	expectedLines := []string{"line 1", "line 2", "line 3"}
+	if len(lines) != len(expectedLines) {
+		t.Errorf("Expected %d lines, got %d", len(expectedLines), len(lines))
+	}
+
+	for i, expected := range expectedLines {
+		if i < len(lines) && lines[i] != expected {
+			t.Errorf("Expected line %d to be '%s', got '%s'", i, expected, lines[i])
+		}
+	}
+}
+
+func TestReadFileLines_FileNotFound(t *testing.T) {
+	lines, err := readFileLines("/nonexistent/file/path")
+	if err == nil {
+		t.Error("readFileLines should return error when file doesn't exist")
+	}
+
+	if lines != nil {
+		t.Error("readFileLines should return nil lines when file doesn't exist")
+	}
+}
+
+func TestReadFileLines_EmptyFile(t *testing.T) {
+	// Create empty temporary file
+	tmpFile, err := os.CreateTemp("", "test_empty_*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	lines, err := readFileLines(tmpFile.Name())
 	if err != nil {
 		t.Errorf("readFileLines should succeed with empty file, got error: %v", err)
 	}
@@ -1076,6 +1943,68 @@ func TestIntegratedCLI_generateSuggestedFix_WithSuggestions(t *testing.T) {
 	}
 }
 
+func TestIntegratedCLI_generateSuggestedFix_ReplacesOffendingToken(t *testing.T) {
+	cli := NewIntegratedCLI()
+
+	tests := []struct {
+		name       string
+		line       string
+		issue      IssueType
+		suggestion string
+		expected   string
+	}{
+		{
+			name:       "コメント中の同じ文字列は残す",
+			line:       "usacloud sever list # sever list",
+			issue:      IssueInvalidMainCommand,
+			suggestion: "server",
+			expected:   "usacloud server list # sever list",
+		},
+		{
+			name:       "引数中のコマンド名は残す",
+			line:       "usacloud sever list --name sever",
+			issue:      IssueInvalidMainCommand,
+			suggestion: "server",
+			expected:   "usacloud server list --name sever",
+		},
+		{
+			name:       "パス中のコマンド名は残す",
+			line:       "/opt/sever/usacloud sever list --zone is1a",
+			issue:      IssueInvalidMainCommand,
+			suggestion: "server",
+			expected:   "/opt/sever/usacloud server list --zone is1a",
+		},
+		{
+			name:       "サブコマンドだけを置換",
+			line:       "usacloud server lst --selector lst",
+			issue:      IssueInvalidSubCommand,
+			suggestion: "list",
+			expected:   "usacloud server list --selector lst",
+		},
+		{
+			name:       "メイン・サブの組の提案",
+			line:       "  usacloud sevrer lst -y  # usacloud sevrer lst",
+			issue:      IssueInvalidMainCommand,
+			suggestion: "server list",
+			expected:   "  usacloud server list -y  # usacloud sevrer lst",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ValidationResult{
+				LineNumber:  1,
+				Line:        tt.line,
+				Issues:      []ValidationIssue{{Type: tt.issue}},
+				Suggestions: []validation.SimilarityResult{{Command: tt.suggestion, Score: 0.8}},
+			}
+			if got := cli.generateSuggestedFix(result); got != tt.expected {
+				t.Errorf("generateSuggestedFix(%q) = %q, expected %q", tt.line, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestIntegratedCLI_generateSuggestedFix_NoSuggestions(t *testing.T) {
 	cli := NewIntegratedCLI()
 
@@ -1094,3 +2023,2972 @@ func TestIntegratedCLI_generateSuggestedFix_NoSuggestions(t *testing.T) {
 		t.Errorf("Expected original line '%s', got '%s'", result.Line, suggestedFix)
 	}
 }
+
+func TestIntegratedCLI_readInputFile_MixedLineEndings(t *testing.T) {
+	cli := &IntegratedCLI{
+		config:     &Config{InputPath: "../../testdata/mixed_line_endings.sh"},
+		fileReader: cliio.NewFileReader(),
+	}
+
+	lines, err := cli.readInputFile()
+	if err != nil {
+		t.Fatalf("readInputFile failed: %v", err)
+	}
+	for _, line := range lines {
+		if strings.HasSuffix(line, "\r") {
+			t.Errorf("Line should not contain trailing CR: %q", line)
+		}
+	}
+
+	endings := cli.fileReader.LineEndings()
+	if !endings.IsMixed() {
+		t.Errorf("Expected mixed line endings, got %+v", endings)
+	}
+	if endings.CRLF != 3 || endings.LF != 1 {
+		t.Errorf("Expected CRLF=3, LF=1, got %+v", endings)
+	}
+	if endings.Dominant() != cliio.LineEndingCRLF {
+		t.Errorf("Expected dominant line ending crlf, got %s", endings.Dominant())
+	}
+}
+
+func TestIntegratedCLI_resolveLineEnding(t *testing.T) {
+	reader := cliio.NewFileReader()
+	if _, err := reader.ReadInputLines("../../testdata/mixed_line_endings.sh"); err != nil {
+		t.Fatalf("ReadInputLines failed: %v", err)
+	}
+
+	tests := []struct {
+		lineEnding string
+		expected   string
+		wantErr    bool
+	}{
+		{"", cliio.LineEndingLF, false},
+		{"lf", cliio.LineEndingLF, false},
+		{"crlf", cliio.LineEndingCRLF, false},
+		{"auto", cliio.LineEndingCRLF, false},
+		{"cr", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lineEnding, func(t *testing.T) {
+			cli := &IntegratedCLI{config: &Config{LineEnding: tt.lineEnding}, fileReader: reader}
+			got, err := cli.resolveLineEnding()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveLineEnding() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("resolveLineEnding() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIntegratedCLI_generateOutput_CRLF(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.sh")
+	cli := &IntegratedCLI{config: &Config{InputPath: "-", OutputPath: outputPath, LineEnding: "crlf"}}
+
+	results := []*ProcessResult{
+		{LineNumber: 1, TransformResult: &transform.Result{Line: "usacloud server list"}},
+		{LineNumber: 2, TransformResult: &transform.Result{Line: "echo done"}},
+	}
+	if err := cli.generateOutput(results); err != nil {
+		t.Fatalf("generateOutput failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := transform.GeneratedHeader() + "\r\nusacloud server list\r\necho done\r\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(data))
+	}
+}
+
+func TestIntegratedCLI_generateOutput_NoHeader(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.sh")
+	cli := &IntegratedCLI{config: &Config{InputPath: "-", OutputPath: outputPath, NoHeader: true}}
+
+	results := []*ProcessResult{
+		{LineNumber: 1, TransformResult: &transform.Result{Line: "usacloud server list"}},
+	}
+	if err := cli.generateOutput(results); err != nil {
+		t.Fatalf("generateOutput failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(data) != "usacloud server list\n" {
+		t.Errorf("Expected output without header, got %q", string(data))
+	}
+
+	// 入力の生成ヘッダーは --no-header でも変換済みとして検出する
+	if _, err := cli.checkAlreadyProcessed([]string{transform.GeneratedHeader(), "usacloud server list"}); err == nil {
+		t.Error("Expected header in input to be detected with --no-header")
+	}
+}
+
+func TestIntegratedCLI_generateOutput_Diff(t *testing.T) {
+	engine := transform.NewDefaultEngine()
+	newResults := func(lines ...string) []*ProcessResult {
+		var results []*ProcessResult
+		for i, line := range lines {
+			tr := engine.Apply(line)
+			results = append(results, &ProcessResult{LineNumber: i + 1, OriginalLine: line, TransformResult: &tr})
+		}
+		return results
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "output.diff")
+	cli := &IntegratedCLI{config: &Config{InputPath: "deploy.sh", OutputPath: outputPath, DiffMode: true}}
+	if err := cli.generateOutput(newResults("#!/bin/bash", "usacloud server list --output-type=csv", "echo done")); err != nil {
+		t.Fatalf("generateOutput failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	diff := string(data)
+	for _, want := range []string{
+		"--- a/deploy.sh\n+++ b/deploy.sh\n",
+		"@@ -1,3 +1,3 @@ L2: output-type-csv-tsv\n",
+		" #!/bin/bash\n-usacloud server list --output-type=csv\n+usacloud server list --output-type=json",
+		" echo done\n",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Expected diff to contain %q, got:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, transform.GeneratedHeader()) {
+		t.Errorf("Diff should not include the generated header, got:\n%s", diff)
+	}
+
+	// 変更がなければ差分は空
+	if err := cli.generateOutput(newResults("#!/bin/bash", "echo done")); err != nil {
+		t.Fatalf("generateOutput failed: %v", err)
+	}
+	data, err = os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("Expected empty diff, got %q", string(data))
+	}
+}
+
+func TestIntegratedCLI_Reverse(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.sh")
+	cli := &IntegratedCLI{
+		config:          &Config{OutputPath: outputPath, ReverseMode: true, SkipDeprecated: true},
+		transformEngine: transform.NewDefaultEngine(),
+	}
+
+	results, err := cli.processLines([]string{
+		"usacloud cdrom list --output-type json",
+		"# usacloud summary # usacloud-update: summaryコマンドはv1で廃止",
+	})
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+	if skipped := results[1].TransformResult.Skipped; len(skipped) != 1 || skipped[0].RuleName != "summary-removed" {
+		t.Errorf("Expected summary-removed to be skipped, got %+v", skipped)
+	}
+
+	if err := cli.generateOutput(results); err != nil {
+		t.Fatalf("generateOutput failed: %v", err)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	if lines[0] != transform.ReverseHeader() {
+		t.Errorf("Expected reverse header, got %q", lines[0])
+	}
+	if lines[1] != "usacloud iso-image list --output-type csv" {
+		t.Errorf("Unexpected reversed line: %q", lines[1])
+	}
+}
+
+func TestRunConfigMigrateMode(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	if err := os.WriteFile(configPath, []byte("[general]\nstrict_mode = true\n\n[transform]\napply_rules = true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := runConfigMigrateMode(&buf, configPath); err != nil {
+		t.Fatalf("runConfigMigrateMode failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"v0 から v" + config.CurrentConfigVersion, "バックアップ: " + configPath + ".backup.", "[general] strict_mode → [validation] strict_mode = true", "[transform] apply_rules"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := runConfigMigrateMode(&buf, configPath); err != nil || !strings.Contains(buf.String(), "最新の形式です") {
+		t.Errorf("Expected an up-to-date message, got %q (%v)", buf.String(), err)
+	}
+
+	if err := runConfigMigrateMode(&buf, filepath.Join(t.TempDir(), "missing.conf")); err == nil {
+		t.Error("Expected error for a missing config file")
+	}
+}
+
+func TestRunBenchmarkMode_InvalidFormat(t *testing.T) {
+	err := runBenchmarkMode("xml")
+	if err == nil {
+		t.Fatal("Expected error for invalid benchmark format")
+	}
+	if !strings.Contains(err.Error(), "xml") {
+		t.Errorf("Expected error to mention the format, got: %v", err)
+	}
+}
+
+func TestIntegratedCLI_validateLine_JqPipelines(t *testing.T) {
+	cli := NewIntegratedCLI()
+
+	lines, err := readFileLines("../../testdata/inputs/jq_pipelines.sh")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	var mismatchLines []int
+	for i, line := range lines {
+		result := cli.validateLine(line, i+1)
+		if result == nil {
+			continue
+		}
+		for _, issue := range result.Issues {
+			if issue.Type == IssueOutputFormatMismatch {
+				mismatchLines = append(mismatchLines, i+1)
+			}
+		}
+	}
+
+	expected := []int{5, 6, 9}
+	if len(mismatchLines) != len(expected) {
+		t.Fatalf("Expected jq advisories on lines %v, got %v", expected, mismatchLines)
+	}
+	for i := range expected {
+		if mismatchLines[i] != expected[i] {
+			t.Errorf("Expected jq advisories on lines %v, got %v", expected, mismatchLines)
+			break
+		}
+	}
+}
+
+func TestIntegratedCLI_validateLine_TransformedOutput(t *testing.T) {
+	cli := NewIntegratedCLI()
+	engine := transform.NewDefaultEngine()
+
+	lines, err := readFileLines("../../testdata/transform/sample_v0_v1_mixed.sh")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	lines = append(lines, "usacloud server list --output-type=csv # try --foo later")
+
+	// 変換で付いた注記（"# usacloud-update: ... --query/jq ..."）の単語をオプションとして扱わない
+	for i, line := range lines {
+		out := engine.Apply(line).Line
+		result := cli.validateLine(out, i+1)
+		if result == nil {
+			continue
+		}
+		for _, issue := range result.Issues {
+			if issue.Type == IssueInvalidOption {
+				t.Errorf("Unexpected option issue for transformed line %d %q: %s", i+1, out, issue.Message)
+			}
+		}
+	}
+}
+
+func TestIntegratedCLI_validateLine_MistypedCommandPair(t *testing.T) {
+	cli := NewIntegratedCLI()
+
+	result := cli.validateLine("usacloud serv lst", 1)
+	if result == nil {
+		t.Fatal("Expected a validation result for a mistyped command")
+	}
+	if len(result.Suggestions) == 0 || result.Suggestions[0].Command != "server" {
+		t.Fatalf("Expected the main command suggestion first, got %+v", result.Suggestions)
+	}
+	found := false
+	for _, s := range result.Suggestions {
+		if s.Command == "server list" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a combined 'server list' suggestion, got %+v", result.Suggestions)
+	}
+
+	// サブコマンドが正しい場合は組の提案を追加しない
+	result = cli.validateLine("usacloud sever list", 1)
+	for _, s := range result.Suggestions {
+		if strings.Contains(s.Command, " ") {
+			t.Errorf("Unexpected combined suggestion %q for a valid subcommand", s.Command)
+		}
+	}
+}
+
+func TestIntegratedCLI_validateLine_CheckPaths(t *testing.T) {
+	existing := filepath.Join(t.TempDir(), "ubuntu.iso")
+	if err := os.WriteFile(existing, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing.iso")
+
+	cli := NewIntegratedCLI()
+	if result := cli.validateLine("usacloud cdrom create --iso-file "+missing, 1); result != nil {
+		t.Errorf("Expected paths not to be checked without --check-paths, got %+v", result.Issues)
+	}
+
+	cli.config.CheckPaths = true
+	if result := cli.validateLine("usacloud cdrom create --iso-file "+existing, 1); result != nil {
+		t.Errorf("Expected no issue for an existing file, got %+v", result.Issues)
+	}
+	result := cli.validateLine("usacloud cdrom create --iso-file="+missing, 2)
+	if result == nil || len(result.Issues) != 1 {
+		t.Fatalf("Expected one issue for a missing file, got %+v", result)
+	}
+	issue := result.Issues[0]
+	if issue.Type != IssueMissingFile || issue.EffectiveSeverity() != severityWarning || !strings.Contains(issue.Message, missing) {
+		t.Errorf("Unexpected issue: %+v", issue)
+	}
+	if result.HasErrors() {
+		t.Error("Missing files should only be reported as warnings")
+	}
+}
+
+func TestIntegratedCLI_validateLine_HyphenatedCommands(t *testing.T) {
+	cli := NewIntegratedCLI()
+
+	lines, err := readFileLines("../../testdata/inputs/hyphenated_commands.sh")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	parser := validation.NewParser()
+	var checked int
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "usacloud ") {
+			continue
+		}
+		// 直前のコメント行が期待するメインコマンド名
+		expected := strings.TrimPrefix(lines[i-1], "# ")
+
+		parsed, err := parser.Parse(line)
+		if err != nil {
+			t.Fatalf("line %d: Parse failed: %v", i+1, err)
+		}
+		if parsed.MainCommand != expected {
+			t.Errorf("line %d: expected main command %q, got %q", i+1, expected, parsed.MainCommand)
+		}
+
+		if result := cli.validateLine(line, i+1); result != nil {
+			for _, issue := range result.Issues {
+				if issue.Type == IssueInvalidMainCommand {
+					t.Errorf("line %d: unexpected invalid command report for %q: %s", i+1, line, issue.Message)
+				}
+			}
+		}
+		checked++
+	}
+
+	if checked != 20 {
+		t.Errorf("Expected 20 commands in the fixture, checked %d", checked)
+	}
+}
+
+func TestIntegratedCLI_ShiftJISRoundTrip(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.sh")
+	cli := &IntegratedCLI{
+		config: &Config{
+			InputPath:     "../../testdata/inputs/shift_jis_script.sh",
+			OutputPath:    outputPath,
+			InputEncoding: "shift_jis",
+		},
+		transformEngine: transform.NewDefaultEngine(),
+		fileReader:      cliio.NewFileReader(),
+	}
+
+	lines, err := cli.readInputFile()
+	if err != nil {
+		t.Fatalf("readInputFile failed: %v", err)
+	}
+	if lines[1] != "# サーバ一覧をCSVで取得する（Shift_JIS）" {
+		t.Errorf("Shift_JIS input was not decoded correctly: %q", lines[1])
+	}
+
+	var results []*ProcessResult
+	for i, line := range lines {
+		tr := cli.transformEngine.Apply(line)
+		results = append(results, &ProcessResult{LineNumber: i + 1, TransformResult: &tr})
+	}
+	if err := cli.generateOutput(results); err != nil {
+		t.Fatalf("generateOutput failed: %v", err)
+	}
+
+	// 出力は入力と同じShift_JISで書き出される
+	reader := cliio.NewFileReader()
+	if err := reader.SetEncoding("shift_jis"); err != nil {
+		t.Fatalf("SetEncoding failed: %v", err)
+	}
+	outLines, err := reader.ReadInputLines(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if outLines[4] != `echo "完了しました"` {
+		t.Errorf("Expected re-encoded Japanese text, got %q", outLines[4])
+	}
+	if !strings.Contains(outLines[3], "--output-type=json") {
+		t.Errorf("Expected transformed command in output, got %q", outLines[3])
+	}
+}
+
+func TestIntegratedCLI_readInputFile_UnsupportedEncoding(t *testing.T) {
+	cli := &IntegratedCLI{
+		config:     &Config{InputPath: "../../testdata/inputs/shift_jis_script.sh", InputEncoding: "latin-9"},
+		fileReader: cliio.NewFileReader(),
+	}
+
+	if _, err := cli.readInputFile(); err == nil || !strings.Contains(err.Error(), "latin-9") {
+		t.Errorf("Expected unsupported encoding error, got: %v", err)
+	}
+}
+
+func TestIntegratedCLI_writeExplanations(t *testing.T) {
+	cli := &IntegratedCLI{transformEngine: transform.NewDefaultEngine()}
+
+	result := cli.transformEngine.Apply("usacloud iso-image list --output-type=csv")
+	var buf bytes.Buffer
+	cli.writeExplanations(&buf, &result, 7)
+
+	output := buf.String()
+	expected := []string{
+		"L7",
+		"[output-type-csv-tsv]",
+		"[iso-image-to-cdrom]",
+		"v0とv1の違い:",
+		"注意点:",
+		"https://docs.usacloud.jp/usacloud/upgrade/v1_0_0/",
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected explanation output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestNewIntegratedCLI_UsacloudVersion(t *testing.T) {
+	original := *usacloudVersion
+	defer func() { *usacloudVersion = original }()
+
+	*usacloudVersion = "1.0"
+	cli := NewIntegratedCLI()
+	if cli.mainValidator.Version() != "1.0" {
+		t.Errorf("Expected validator for version 1.0, got %s", cli.mainValidator.Version())
+	}
+	if cli.mainValidator.IsValidCommand("autoscale") {
+		t.Error("Expected 'autoscale' to be unavailable in the 1.0 catalog")
+	}
+
+	*usacloudVersion = "9.9"
+	cli = NewIntegratedCLI()
+	if cli.mainValidator.Version() != validation.DefaultCatalogVersion {
+		t.Errorf("Expected fallback to default catalog, got %s", cli.mainValidator.Version())
+	}
+}
+
+func TestApplyValidationFlagOverrides(t *testing.T) {
+	origDistance, origSuggestions := *maxDistance, *maxSuggestions
+	defer func() { *maxDistance, *maxSuggestions = origDistance, origSuggestions }()
+
+	*maxDistance = 1
+	*maxSuggestions = 2
+
+	cfg := &ValidationConfig{MaxDistance: 3, MaxSuggestions: 5}
+	applyValidationFlagOverrides(cfg, map[string]bool{})
+	if cfg.MaxDistance != 3 || cfg.MaxSuggestions != 5 {
+		t.Errorf("Expected unset flags to keep defaults, got distance=%d suggestions=%d", cfg.MaxDistance, cfg.MaxSuggestions)
+	}
+
+	applyValidationFlagOverrides(cfg, map[string]bool{"max-distance": true, "max-suggestions": true})
+	if cfg.MaxDistance != 1 {
+		t.Errorf("Expected max distance override 1, got %d", cfg.MaxDistance)
+	}
+	if cfg.MaxSuggestions != 2 {
+		t.Errorf("Expected max suggestions override 2, got %d", cfg.MaxSuggestions)
+	}
+
+	suggester := validation.NewSimilarCommandSuggester(cfg.MaxDistance, cfg.MaxSuggestions)
+	if results := suggester.SuggestMainCommands("servr"); len(results) > cfg.MaxSuggestions {
+		t.Errorf("Expected at most %d suggestions, got %d", cfg.MaxSuggestions, len(results))
+	}
+}
+
+func TestApplyValidationFlagOverrides_SuggestionLevel(t *testing.T) {
+	origSuggestions, origLevel := *maxSuggestions, *suggestionLevel
+	defer func() { *maxSuggestions, *suggestionLevel = origSuggestions, origLevel }()
+
+	*maxSuggestions = 2
+	*suggestionLevel = 1
+
+	cfg := &ValidationConfig{MaxSuggestions: 5}
+	applyValidationFlagOverrides(cfg, map[string]bool{"suggestion-level": true})
+	if cfg.MaxSuggestions != 1 {
+		t.Errorf("Expected --suggestion-level to set max suggestions 1, got %d", cfg.MaxSuggestions)
+	}
+
+	applyValidationFlagOverrides(cfg, map[string]bool{"suggestion-level": true, "max-suggestions": true})
+	if cfg.MaxSuggestions != 2 {
+		t.Errorf("Expected --max-suggestions to take precedence, got %d", cfg.MaxSuggestions)
+	}
+}
+
+func TestValidateValidationConfig(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxDistance    int
+		maxSuggestions int
+		expectError    bool
+	}{
+		{"defaults", 3, 5, false},
+		{"lower bounds", 1, 1, false},
+		{"upper bounds", 10, 20, false},
+		{"zero distance", 0, 5, true},
+		{"distance too large", 11, 5, true},
+		{"zero suggestions", 3, 0, true},
+		{"too many suggestions", 3, 21, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateValidationConfig(&ValidationConfig{MaxDistance: tt.maxDistance, MaxSuggestions: tt.maxSuggestions})
+			if tt.expectError && err == nil {
+				t.Error("Expected range error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	for algorithm, expectError := range map[string]bool{"levenshtein": false, "damerau-levenshtein": false, "hamming": true} {
+		err := validateValidationConfig(&ValidationConfig{MaxDistance: 3, MaxSuggestions: 5, DistanceAlgorithm: algorithm})
+		if (err != nil) != expectError {
+			t.Errorf("distance_algorithm = %q: expected error %v, got %v", algorithm, expectError, err)
+		}
+	}
+}
+
+func TestLoadValidationConfig_FromConfigFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	content := `[sakura-cloud]
+access_token = "token"
+access_token_secret = "secret"
+zone = "tk1v"
+
+[general]
+log_level = debug
+
+[validation]
+max_suggestions = 8
+max_edit_distance = 2
+distance_algorithm = damerau-levenshtein
+typo_detection_enabled = false
+
+[error_feedback]
+error_format = simple
+
+[help_system]
+enable_interactive_help = false
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	original := *configFile
+	defer func() { *configFile = original }()
+	*configFile = configPath
+
+	cfg := loadValidationConfig()
+	if cfg.MaxSuggestions != 8 {
+		t.Errorf("Expected max suggestions 8 from config file, got %d", cfg.MaxSuggestions)
+	}
+	if cfg.MaxDistance != 2 {
+		t.Errorf("Expected max distance 2 from config file, got %d", cfg.MaxDistance)
+	}
+	if cfg.DistanceAlgorithm != "damerau-levenshtein" {
+		t.Errorf("Expected distance algorithm 'damerau-levenshtein' from config file, got %q", cfg.DistanceAlgorithm)
+	}
+	if cfg.EnableTypoDetection {
+		t.Error("Expected typo detection to be disabled by config file")
+	}
+	if cfg.EnableInteractiveHelp {
+		t.Error("Expected interactive help to be disabled by config file")
+	}
+	if cfg.ErrorFormat != "simple" {
+		t.Errorf("Expected error format 'simple', got %q", cfg.ErrorFormat)
+	}
+	if cfg.LogLevel != "debug" {
+		t.Errorf("Expected log level 'debug', got %q", cfg.LogLevel)
+	}
+
+	if _, err := config.LoadConfig(configPath); err != nil {
+		t.Errorf("Expected sandbox loader to accept validation sections, got: %v", err)
+	}
+}
+
+func TestRunDumpParseMode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := runDumpParseMode(&buf, "usacloud server list --zone=tk1v", "json"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{`"main_command": "server"`, `"sub_command": "list"`, `"name": "zone"`, `"value": "tk1v"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected JSON output to contain %s, got:\n%s", want, buf.String())
+		}
+	}
+
+	if err := runDumpParseMode(&buf, "usacloud server list", "yaml"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+type trackingReadCloser struct {
+	io.Reader
+	closed bool
+}
+
+func (t *trackingReadCloser) Close() error {
+	t.closed = true
+	return nil
+}
+
+func TestInteractiveValidation_PipedScriptWithTTYDecisions(t *testing.T) {
+	origOpenTTY, origStdinIsPipe := openTTY, stdinIsPipe
+	defer func() {
+		openTTY, stdinIsPipe = origOpenTTY, origStdinIsPipe
+		cliio.SetStdinReader(nil)
+	}()
+
+	// The script arrives on stdin while answers come from the terminal
+	cliio.SetStdinReader(strings.NewReader("usacloud sever list\nusacloud iso-image list\n"))
+	tty := &trackingReadCloser{Reader: strings.NewReader("n\ny\n")}
+	stdinIsPipe = func() bool { return true }
+	openTTY = func() (io.ReadCloser, error) { return tty, nil }
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = "-"
+
+	lines, err := cli.readInputFile()
+	if err != nil {
+		t.Fatalf("Failed to read piped script: %v", err)
+	}
+
+	issues := cli.identifyIssues(cli.analyzeFile(lines))
+	if len(issues) < 2 {
+		t.Fatalf("Expected at least 2 issues from piped script, got %d", len(issues))
+	}
+
+	selected := cli.selectIssuesInteractively(issues[:2])
+	if len(selected) != 1 || selected[0].LineNumber != issues[1].LineNumber {
+		t.Errorf("Expected only the second issue to be selected from tty answers, got %+v", selected)
+	}
+
+	cli.closeDecisionReader()
+	if !tty.closed {
+		t.Error("Expected tty to be closed after decisions")
+	}
+}
+
+func TestApplyInteractiveFixes(t *testing.T) {
+	lines := []string{
+		"#!/bin/bash",
+		"usacloud sever list",
+		`usacloud sever read \`,
+		"  --zone tk1a 123",
+		"usacloud serer list",
+		"echo done",
+	}
+	issues := []InteractiveIssue{
+		{LineNumber: 2, CurrentCode: "usacloud sever list", SuggestedCode: "usacloud server list"},
+		{LineNumber: 2, CurrentCode: "usacloud sever list", SuggestedCode: "usacloud server list"},
+		{LineNumber: 3, CurrentCode: "usacloud sever read   --zone tk1a 123", SuggestedCode: "usacloud server read   --zone tk1a 123"},
+		{LineNumber: 5, CurrentCode: "usacloud serer list", SuggestedCode: "usacloud serer list"},
+	}
+
+	fixed, applied, skipped := applyInteractiveFixes(lines, issues)
+	want := []string{
+		"#!/bin/bash",
+		"usacloud server list",
+		`usacloud server read \`,
+		"  --zone tk1a 123",
+		"usacloud serer list",
+		"echo done",
+	}
+	if strings.Join(fixed, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected fixed lines:\n%s", strings.Join(fixed, "\n"))
+	}
+	if fmt.Sprint(applied) != "[2 3]" || fmt.Sprint(skipped) != "[5]" {
+		t.Errorf("Expected lines 2 and 3 applied and 5 skipped, got %v %v", applied, skipped)
+	}
+	if lines[1] != "usacloud sever list" {
+		t.Error("Expected the input lines to be left unchanged")
+	}
+}
+
+func TestApplySelectedChanges_WritesOutput(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "script.sh")
+	if err := os.WriteFile(inputPath, []byte("usacloud sever list\necho ok\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	issues := []InteractiveIssue{{LineNumber: 1, CurrentCode: "usacloud sever list", SuggestedCode: "usacloud server list"}}
+	lines := []string{"usacloud sever list", "echo ok"}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = inputPath
+	cli.config.OutputPath = filepath.Join(dir, "fixed.sh")
+	if err := cli.applySelectedChanges(lines, issues); err != nil {
+		t.Fatalf("applySelectedChanges failed: %v", err)
+	}
+	if got, _ := os.ReadFile(cli.config.OutputPath); string(got) != "usacloud server list\necho ok\n" {
+		t.Errorf("Unexpected output %q", got)
+	}
+
+	cli.config.OutputPath, cli.config.InPlace, cli.config.BackupOriginal = "-", true, true
+	if err := cli.applySelectedChanges(lines, issues); err != nil {
+		t.Fatalf("applySelectedChanges in place failed: %v", err)
+	}
+	if got, _ := os.ReadFile(inputPath); string(got) != "usacloud server list\necho ok\n" {
+		t.Errorf("Expected the input to be fixed in place, got %q", got)
+	}
+	if got, _ := os.ReadFile(inputPath + cliio.BackupSuffix); string(got) != "usacloud sever list\necho ok\n" {
+		t.Errorf("Expected a backup of the original, got %q", got)
+	}
+}
+
+func TestReadUserInput_NoTTYAvailable(t *testing.T) {
+	origOpenTTY, origStdinIsPipe := openTTY, stdinIsPipe
+	defer func() { openTTY, stdinIsPipe = origOpenTTY, origStdinIsPipe }()
+
+	stdinIsPipe = func() bool { return true }
+	openTTY = func() (io.ReadCloser, error) { return nil, os.ErrNotExist }
+
+	cli := &IntegratedCLI{}
+	if got := cli.readUserInput(); got != "" {
+		t.Errorf("Expected empty answer when no tty is available, got %q", got)
+	}
+}
+
+func TestResolveRuleOrder(t *testing.T) {
+	origOrder, origConfig := *ruleOrder, *configFile
+	defer func() { *ruleOrder, *configFile = origOrder, origConfig }()
+
+	*ruleOrder, *configFile = "", ""
+	if order := resolveRuleOrder(); order != nil {
+		t.Errorf("Expected default order, got %v", order)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	if err := os.WriteFile(configPath, []byte("[transform]\nrule_order = iso-image-to-cdrom\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	*configFile = configPath
+	if order := resolveRuleOrder(); len(order) != 1 || order[0] != "iso-image-to-cdrom" {
+		t.Errorf("Expected rule order from config file, got %v", order)
+	}
+
+	*ruleOrder = "zone-all-normalize,selector-to-arg"
+	order := resolveRuleOrder()
+	if len(order) != 2 || order[0] != "zone-all-normalize" {
+		t.Errorf("Expected --rule-order to take precedence, got %v", order)
+	}
+	if _, err := transform.NewEngineWithOrder(order); err != nil {
+		t.Errorf("Expected resolved order to be valid: %v", err)
+	}
+}
+
+func TestResolveEffectiveRules_ConfigAndFlags(t *testing.T) {
+	origConfig := *configFile
+	defer func() { *configFile = origConfig }()
+
+	configPath := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	content := "[transform]\ndisabled_rules = iso-image-to-cdrom,selector-to-arg\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	*configFile = configPath
+
+	cfg := &Config{
+		EnableRules:  splitRuleList("iso-image-to-cdrom"),
+		DisableRules: splitRuleList("zone-all-normalize"),
+	}
+	rules, err := resolveEffectiveRules(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	status := make(map[string]transform.EffectiveRule)
+	for _, r := range rules {
+		status[r.Name] = r
+	}
+	if r := status["iso-image-to-cdrom"]; !r.Enabled || r.Source != "command line" {
+		t.Errorf("Expected command line enable to override config, got %+v", r)
+	}
+	if r := status["selector-to-arg"]; r.Enabled || !strings.HasPrefix(r.Source, "config") {
+		t.Errorf("Expected selector-to-arg to be disabled by config, got %+v", r)
+	}
+	if r := status["zone-all-normalize"]; r.Enabled || r.Source != "command line" {
+		t.Errorf("Expected zone-all-normalize to be disabled by command line, got %+v", r)
+	}
+
+	cfg.DisableRules = splitRuleList("iso-image-to-cdrom")
+	if _, err := resolveEffectiveRules(cfg); err == nil {
+		t.Error("Expected error when the command line both enables and disables a rule")
+	}
+
+	// --disable-rules は繰り返し指定でき、1回の指定でカンマ区切りも併用できる
+	var disable stringListFlag
+	for _, v := range []string{"output-type-csv-tsv", "summary-removed, zone-all-normalize,"} {
+		if err := disable.Set(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(disable) != 3 || disable[2] != "zone-all-normalize" {
+		t.Errorf("Expected the names to be trimmed without empty ones, got %q", disable)
+	}
+	rules, err = resolveEffectiveRules(&Config{DisableRules: disable})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, r := range rules {
+		listed := r.Name == "output-type-csv-tsv" || r.Name == "summary-removed" || r.Name == "zone-all-normalize"
+		if listed && (r.Enabled || r.Source != "command line") {
+			t.Errorf("Expected %s to be disabled by command line, got %+v", r.Name, r)
+		}
+	}
+	if _, err := resolveEffectiveRules(&Config{DisableRules: []string{"output-type-json"}}); err == nil || !strings.Contains(err.Error(), "available:") {
+		t.Errorf("Expected unknown rule error listing valid names, got %v", err)
+	}
+
+	// --enable-only はそれ以外のルールをすべて無効にする
+	rules, err = resolveEffectiveRules(&Config{EnableOnly: disable})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if names := transform.NewEngineFromEffectiveRules(rules).RuleNames(); len(names) != 3 || names[0] != "output-type-csv-tsv" {
+		t.Errorf("Expected only the listed rules to be enabled, got %v", names)
+	}
+	if _, err := resolveEffectiveRules(&Config{EnableOnly: []string{"output-type-json"}}); err == nil || !strings.Contains(err.Error(), "available:") {
+		t.Errorf("Expected unknown rule error listing valid names, got %v", err)
+	}
+}
+
+func TestResolveEffectiveRules_Versions(t *testing.T) {
+	origConfig := *configFile
+	defer func() { *configFile = origConfig }()
+	*configFile = filepath.Join(t.TempDir(), "missing.conf")
+
+	// すべての既定ルールは v1.0 向けのため、v1.0 対応済みのスクリプトには適用しない
+	cfg := &Config{SinceVersion: "1.0", TargetVersion: "v1.1", EnableRules: []string{"iso-image-to-cdrom"}}
+	rules, err := resolveEffectiveRules(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, r := range rules {
+		if r.Name == "iso-image-to-cdrom" {
+			if !r.Enabled || r.Source != "command line" {
+				t.Errorf("Expected --enable-rules to override the version range, got %+v", r)
+			}
+		} else if r.Enabled || r.Source != "--since/--target-version" {
+			t.Errorf("Expected %s to be disabled by the version range, got %+v", r.Name, r)
+		}
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "output.sh")
+	cli := &IntegratedCLI{
+		config:          &Config{InputPath: "-", OutputPath: outputPath},
+		transformEngine: transform.NewDefaultEngine().WithVersions("v0", "v1.0"),
+	}
+	if err := cli.generateOutput(nil); err != nil {
+		t.Fatalf("generateOutput() error = %v", err)
+	}
+	if output, _ := os.ReadFile(outputPath); !strings.HasPrefix(string(output), transform.GeneratedHeaderFor("v1.0")) {
+		t.Errorf("Expected the header for the target version, got %q", output)
+	}
+
+	for _, cfg := range []*Config{{SinceVersion: "v1.1"}, {TargetVersion: "v2"}, {SinceVersion: "v1.0", TargetVersion: "v1.0"}} {
+		if _, _, err := resolveMigrationVersions(cfg); err == nil {
+			t.Errorf("Expected an error for %s -> %s", cfg.SinceVersion, cfg.TargetVersion)
+		}
+	}
+}
+
+func TestIntegratedCLI_readInputFile_Directory(t *testing.T) {
+	dir := t.TempDir()
+	cli := &IntegratedCLI{
+		config:            &Config{InputPath: dir + "/"},
+		fileReader:        cliio.NewFileReader(),
+		cliErrorFormatter: errors.NewErrorFormatter(false),
+	}
+
+	_, err := cli.readInputFile()
+	if err == nil {
+		t.Fatal("Expected error for directory input")
+	}
+	for _, want := range []string{"ディレクトリは入力ファイルとして指定できません", dir, "--in " + dir + " --recursive"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestIntegratedCLI_writeProvenance(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.sh")
+	content := "#!/bin/bash\nusacloud server list --output-type csv\necho done\nusacloud iso-image list\n"
+	if err := os.WriteFile(inputPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	provenancePath := filepath.Join(dir, "provenance.jsonl")
+	cli := &IntegratedCLI{
+		config:          &Config{InputPath: inputPath, ProvenancePath: provenancePath, SkipDeprecated: true},
+		transformEngine: transform.NewDefaultEngine(),
+	}
+
+	results, err := cli.processLines(strings.Split(strings.TrimSuffix(content, "\n"), "\n"))
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+	records, err := cli.provenanceRecords(results, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("provenanceRecords failed: %v", err)
+	}
+	if err := cli.writeProvenance(records); err != nil {
+		t.Fatalf("writeProvenance failed: %v", err)
+	}
+
+	data, err := os.ReadFile(provenancePath)
+	if err != nil {
+		t.Fatalf("Failed to read provenance: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 records (one per changed line), got %d:\n%s", len(lines), data)
+	}
+
+	var record provenance.Record
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("Invalid record: %v", err)
+	}
+	if record.LineNumber != 4 || record.Original != "usacloud iso-image list" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+	if record.InputPath != inputPath || record.InputSHA256 != provenance.HashContent([]byte(content)) {
+		t.Errorf("Expected input path and hash of the file, got %s %s", record.InputPath, record.InputSHA256)
+	}
+	if len(record.Rules) != 1 || record.Rules[0] != "iso-image-to-cdrom" {
+		t.Errorf("Unexpected rules: %v", record.Rules)
+	}
+	if record.SourceVersion != "v0" || record.TargetVersion != "v1.1" {
+		t.Errorf("Unexpected versions: %s -> %s", record.SourceVersion, record.TargetVersion)
+	}
+}
+
+func TestIntegratedCLI_runIntegratedMode_ProvenanceAfterOutput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.sh")
+	if err := os.WriteFile(input, []byte("usacloud iso-image list\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = input
+	cli.config.OutputPath = filepath.Join(dir, "missing", "out.sh")
+	cli.config.ShowStats = false
+	cli.config.ProvenancePath = filepath.Join(dir, "provenance.jsonl")
+
+	if err := cli.runIntegratedMode(); err == nil {
+		t.Fatal("Expected the output to fail in a missing directory")
+	}
+	if _, err := os.Stat(cli.config.ProvenancePath); !os.IsNotExist(err) {
+		t.Errorf("Expected no provenance when the output fails, got %v", err)
+	}
+}
+
+func TestIntegratedCLI_writeChangesCSV(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "changes.csv")
+	cli := &IntegratedCLI{
+		config:          &Config{InputPath: "deploy.sh", ChangesCSVPath: csvPath, SkipDeprecated: true},
+		transformEngine: transform.NewDefaultEngine(),
+	}
+
+	results, err := cli.processLines([]string{"#!/bin/bash", "usacloud iso-image list --output-type csv", "echo done"})
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+	if err := cli.writeChangesCSV(cli.collectChanges(cli.inputName(), results)); err != nil {
+		t.Fatalf("writeChangesCSV failed: %v", err)
+	}
+
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatalf("Failed to open CSV: %v", err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(records) != 3 || !reflect.DeepEqual(records[0], provenance.ChangesCSVHeader) {
+		t.Fatalf("Expected a header and one row per change, got %q", records)
+	}
+	for i, rule := range []string{"output-type-csv-tsv", "iso-image-to-cdrom"} {
+		row := records[i+1]
+		if row[0] != "deploy.sh" || row[1] != "2" || row[2] != rule || row[3] == "" || row[4] == "" || row[5] == "" {
+			t.Errorf("Unexpected row %d: %q", i+1, row)
+		}
+	}
+}
+
+func TestValidateChangesCSVConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"disabled", Config{ValidateOnly: true}, false},
+		{"conversion", Config{ChangesCSVPath: "c.csv", Recursive: true}, false},
+		{"validate only", Config{ChangesCSVPath: "c.csv", ValidateOnly: true}, true},
+		{"sandbox", Config{ChangesCSVPath: "c.csv", SandboxMode: true}, true},
+		{"dry run", Config{ChangesCSVPath: "c.csv", DryRun: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateChangesCSVConfig(&tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateChangesCSVConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMigrationReportConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"disabled", Config{ValidateOnly: true}, false},
+		{"file", Config{MigrationReport: "report.txt", OutputPath: "-"}, false},
+		{"stdout with output file", Config{MigrationReport: "-", OutputPath: "out.sh"}, false},
+		{"stdout with recursive", Config{MigrationReport: "-", OutputPath: "-", Recursive: true}, false},
+		{"stdout with output to stdout", Config{MigrationReport: "-", OutputPath: "-"}, true},
+		{"validate only", Config{MigrationReport: "report.txt", ValidateOnly: true}, true},
+		{"reverse", Config{MigrationReport: "report.txt", ReverseMode: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateMigrationReportConfig(&tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateMigrationReportConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIntegratedCLI_classifyMigration(t *testing.T) {
+	// startup-script の変換ルールを無効にして、変換後も廃止コマンドが残る場合を確認する
+	engine, err := transform.NewEngineWithOptions(transform.EngineOptions{DisableRules: []string{"startup-script-to-note"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &IntegratedCLI{
+		config:             &Config{InputPath: "deploy.sh", SkipDeprecated: true},
+		transformEngine:    engine,
+		deprecatedDetector: validation.NewDeprecatedCommandDetector(),
+	}
+
+	results, err := cli.processLines([]string{
+		"#!/bin/bash",
+		"usacloud server list --output-type json",
+		"usacloud iso-image list \\",
+		"  --zone is1a",
+		"usacloud summary",
+		"usacloud startup-script list",
+		"cat <<EOF",
+		"usacloud ipv4 list",
+		"EOF",
+		"echo done",
+	})
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+	report := cli.classifyMigration("deploy.sh", results)
+
+	lineNumbers := func(entries []migrationEntry) []int {
+		var lines []int
+		for _, e := range entries {
+			lines = append(lines, e.LineNumber)
+		}
+		return lines
+	}
+	if got := lineNumbers(report.Current); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("Current = %v, want [2]", got)
+	}
+	if got := lineNumbers(report.Migrated); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("Migrated = %v, want [3]", got)
+	}
+	if got := lineNumbers(report.Manual); !reflect.DeepEqual(got, []int{5, 6}) {
+		t.Errorf("Manual = %v, want [5 6]", got)
+	}
+	if !report.mixed() {
+		t.Error("Expected the report to be mixed")
+	}
+
+	migrated := report.Migrated[0]
+	if migrated.Command != "usacloud iso-image list --zone is1a" || !reflect.DeepEqual(migrated.Rules, []string{"iso-image-to-cdrom"}) {
+		t.Errorf("Unexpected migrated entry: %+v", migrated)
+	}
+	for _, e := range report.Manual {
+		if e.Reason == "" {
+			t.Errorf("Expected a reason for manual entry: %+v", e)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeMigrationReport(&buf, report)
+	for _, want := range []string{"# 移行レポート: deploy.sh", "対応済み: 1  移行済み: 1  手動対応が必要: 2", "混在しています", "L6     usacloud startup-script list"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestIntegratedCLI_classifyMigration_OversizedLine(t *testing.T) {
+	cli := &IntegratedCLI{
+		config:             &Config{InputPath: "deploy.sh", SkipDeprecated: true, MaxLineLength: 40},
+		transformEngine:    transform.NewDefaultEngine(),
+		deprecatedDetector: validation.NewDeprecatedCommandDetector(),
+	}
+
+	results, err := cli.processLines([]string{
+		"usacloud server list",
+		"usacloud iso-image list --zone is1a --selector env=production",
+		"echo " + strings.Repeat("x", 60),
+	})
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+	report := cli.classifyMigration("deploy.sh", results)
+
+	if len(report.Current) != 1 || len(report.Migrated) != 0 {
+		t.Errorf("Expected only line 1 to be classified as current, got %+v", report)
+	}
+	if len(report.Manual) != 2 {
+		t.Fatalf("Expected oversized lines to need manual migration, got %+v", report.Manual)
+	}
+	for i, e := range report.Manual {
+		if e.LineNumber != i+2 || e.Reason != "未処理（行が長すぎる）" {
+			t.Errorf("Unexpected manual entry: %+v", e)
+		}
+	}
+}
+
+func TestIntegratedCLI_runIntegratedMode_Recursive(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"deploy.sh":         "#!/bin/bash\nusacloud iso-image list\n",
+		"lib/backup.sh":     "usacloud server list --output-type=csv\necho done\n",
+		"lib/README.md":     "usacloud iso-image list\n",
+		"lib/tool.sh":       "bin\x00ary",
+		"lib/old.sh.update": "not matched",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = root
+	cli.config.Recursive = true
+	cli.config.Include = "*.sh"
+	cli.config.ShowStats = false
+
+	if err := cli.runIntegratedMode(); err != nil {
+		t.Fatalf("runIntegratedMode failed: %v", err)
+	}
+	if cli.config.InputPath != root {
+		t.Errorf("Expected config to be restored, got input path %q", cli.config.InputPath)
+	}
+
+	for name, want := range map[string]string{
+		"deploy.sh.updated":     "usacloud cdrom list",
+		"lib/backup.sh.updated": "--output-type=json",
+	} {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatalf("Expected output %s: %v", name, err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s to contain %q, got:\n%s", name, want, data)
+		}
+	}
+	for _, name := range []string{"lib/README.md.updated", "lib/tool.sh.updated"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be written", name)
+		}
+	}
+
+	// --in-place は元ファイルを上書きする
+	cli.config.InPlace = true
+	if err := cli.runIntegratedMode(); err != nil {
+		t.Fatalf("runIntegratedMode failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "deploy.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "usacloud cdrom list") {
+		t.Errorf("Expected deploy.sh to be converted in place, got:\n%s", data)
+	}
+}
+
+func TestIntegratedCLI_runIntegratedMode_RecursiveInPlaceLeavesOtherFiles(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "outside.sh")
+	files := map[string]string{
+		"deploy":    "#!/bin/bash\nusacloud iso-image list\n",
+		"clean.sh":  "#!/bin/bash\nusacloud server list\n",
+		".git/HEAD": "ref: refs/heads/main\n",
+		"notes.txt": "usacloud iso-image list\n",
+		outside:     "usacloud iso-image list\n",
+	}
+	for name, content := range files {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, name)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link.sh")); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = root
+	cli.config.Recursive = true
+	cli.config.InPlace = true
+	cli.config.BackupOriginal = true
+	cli.config.ShowStats = false
+
+	if err := cli.runIntegratedMode(); err != nil {
+		t.Fatalf("runIntegratedMode failed: %v", err)
+	}
+
+	// シェバンのあるスクリプトだけを変換し、バックアップを残す
+	if data, _ := os.ReadFile(filepath.Join(root, "deploy")); !strings.Contains(string(data), "usacloud cdrom list") {
+		t.Errorf("Expected deploy to be converted in place, got:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "deploy"+cliio.BackupSuffix)); err != nil {
+		t.Errorf("Expected a backup of deploy: %v", err)
+	}
+	// 変更のないファイル、.git 配下、シェルスクリプト以外、リンク先は書き換えない
+	for path, want := range map[string]string{
+		filepath.Join(root, "clean.sh"):  files["clean.sh"],
+		filepath.Join(root, ".git/HEAD"): files[".git/HEAD"],
+		filepath.Join(root, "notes.txt"): files["notes.txt"],
+		outside:                          files[outside],
+	} {
+		if data, _ := os.ReadFile(path); string(data) != want {
+			t.Errorf("Expected %s to be left unchanged, got:\n%s", path, data)
+		}
+		if _, err := os.Stat(path + cliio.BackupSuffix); !os.IsNotExist(err) {
+			t.Errorf("Expected no backup of %s", path)
+		}
+	}
+}
+
+func TestIntegratedCLI_runIntegratedMode_RecursiveExclude(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"deploy.sh", "vendor/lib.sh", "vendor/deep/tool.sh", "gen/api.generated.sh"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("usacloud iso-image list\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = root
+	cli.config.Recursive = true
+	// ディレクトリ名だけのパターンで配下すべてを除外する
+	cli.config.Exclude = []string{"vendor", "*.generated.sh"}
+	cli.config.ShowStats = false
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	err := cli.runIntegratedMode()
+	w.Close()
+	os.Stderr = oldStderr
+	captured, _ := io.ReadAll(r)
+	r.Close()
+	stderr := string(captured)
+
+	if err != nil {
+		t.Fatalf("runIntegratedMode failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "deploy.sh.updated")); err != nil {
+		t.Errorf("Expected deploy.sh to be converted: %v", err)
+	}
+	for _, name := range []string{"vendor/lib.sh", "vendor/deep/tool.sh", "gen/api.generated.sh"} {
+		if _, err := os.Stat(filepath.Join(root, name+cliio.UpdatedSuffix)); !os.IsNotExist(err) {
+			t.Errorf("Expected excluded %s not to be converted", name)
+		}
+	}
+	if !strings.Contains(stderr, "変換: 1  スキップ: 0  失敗: 0  除外: 2") {
+		t.Errorf("Expected the summary to report vendor and gen/api.generated.sh as excluded, got:\n%s", stderr)
+	}
+}
+
+func TestIntegratedCLI_runIntegratedMode_MultipleInputs(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.sh")
+	b := filepath.Join(dir, "b.sh")
+	empty := filepath.Join(dir, "empty.sh")
+	out := filepath.Join(dir, "combined.sh")
+	for path, content := range map[string]string{a: "usacloud iso-image list\n", b: "echo start\nusacloud server list --output-type=csv\n", empty: ""} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = a
+	cli.config.InputPaths = []string{a, b}
+	cli.config.OutputPath = out
+	cli.config.ShowStats = false
+
+	if err := cli.runIntegratedMode(); err != nil {
+		t.Fatalf("runIntegratedMode failed: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 6 || lines[0] != transform.GeneratedHeader() || strings.Count(string(data), transform.GeneratedHeader()) != 1 {
+		t.Fatalf("Expected a single generated header followed by both files, got:\n%s", data)
+	}
+	if lines[1] != sourceHeader(a) || !strings.HasPrefix(lines[2], "usacloud cdrom list") || lines[3] != sourceHeader(b) || lines[4] != "echo start" || !strings.Contains(lines[5], "--output-type=json") {
+		t.Errorf("Unexpected combined output:\n%s", data)
+	}
+	if cli.stats.TotalLines != 3 || cli.stats.ChangedLines != 2 {
+		t.Errorf("Expected stats over both files, got %+v", cli.stats)
+	}
+	if cli.config.InputPath != a {
+		t.Errorf("Expected config to be restored, got input path %q", cli.config.InputPath)
+	}
+
+	// 空のファイルはどのファイルかを示してエラーにする
+	cli.config.InputPaths = []string{a, empty, b}
+	if err := cli.runIntegratedMode(); err == nil || !strings.Contains(err.Error(), empty) {
+		t.Errorf("Expected error naming the empty file, got %v", err)
+	}
+}
+
+func TestValidateMultiInputConfig(t *testing.T) {
+	two := []string{"a.sh", "b.sh"}
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"single input", Config{InputPaths: []string{"a.sh"}, InPlace: true}, false},
+		{"multiple inputs", Config{InputPaths: two, OutputPath: "out.sh"}, false},
+		{"with in-place", Config{InputPaths: two, InPlace: true}, true},
+		{"with diff", Config{InputPaths: two, DiffMode: true}, true},
+		{"with validate-only", Config{InputPaths: two, ValidateOnly: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateMultiInputConfig(&tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateMultiInputConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	var f inputListFlag
+	if paths := f.Paths(); len(paths) != 1 || paths[0] != "-" {
+		t.Errorf("Expected stdin by default, got %v", paths)
+	}
+	_ = f.Set("a,b.sh")
+	_ = f.Set("c.sh")
+	if paths := f.Paths(); len(paths) != 2 || paths[0] != "a,b.sh" {
+		t.Errorf("Expected each --in value to be kept as is, got %v", paths)
+	}
+}
+
+func TestValidateRecursiveConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"disabled", Config{OutputPath: "-"}, false},
+		{"recursive", Config{OutputPath: "-", Recursive: true, Include: "*.sh"}, false},
+		{"include without recursive", Config{OutputPath: "-", Include: "*.sh"}, true},
+		{"with --out", Config{OutputPath: "out.sh", Recursive: true}, true},
+		{"with --diff", Config{OutputPath: "-", Recursive: true, DiffMode: true}, true},
+		{"malformed include", Config{OutputPath: "-", Recursive: true, Include: "[*.sh"}, true},
+		{"exclude", Config{OutputPath: "-", Recursive: true, Exclude: []string{"vendor/**", "*.generated.sh"}}, false},
+		{"exclude without recursive", Config{OutputPath: "-", Exclude: []string{"vendor/**"}}, true},
+		{"malformed exclude", Config{OutputPath: "-", Recursive: true, Exclude: []string{"vendor/[a"}}, true},
+		{"risk report", Config{OutputPath: "-", Recursive: true, RiskReportPath: "-", RiskReportFormat: "json"}, false},
+		{"risk report without recursive", Config{OutputPath: "-", RiskReportPath: "-", RiskReportFormat: "text"}, true},
+		{"risk report with unknown format", Config{OutputPath: "-", Recursive: true, RiskReportPath: "-", RiskReportFormat: "xml"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRecursiveConfig(&tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateRecursiveConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIntegratedCLI_generateOutput_InPlace(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "deploy.sh")
+	original := "#!/bin/bash\nusacloud iso-image list\n"
+	if err := os.WriteFile(inputPath, []byte(original), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	results := []*ProcessResult{
+		{LineNumber: 1, TransformResult: &transform.Result{Line: "#!/bin/bash"}},
+		{LineNumber: 2, TransformResult: &transform.Result{Line: "usacloud cdrom list"}},
+	}
+
+	cli := &IntegratedCLI{config: &Config{InputPath: inputPath, OutputPath: "-", InPlace: true, BackupOriginal: true}}
+	if err := cli.generateOutput(results); err != nil {
+		t.Fatalf("generateOutput failed: %v", err)
+	}
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "usacloud cdrom list") {
+		t.Errorf("Expected input to be rewritten, got:\n%s", data)
+	}
+
+	backup, err := os.ReadFile(inputPath + ".bak")
+	if err != nil {
+		t.Fatalf("Expected backup file: %v", err)
+	}
+	if string(backup) != original {
+		t.Errorf("Expected backup to hold the original content, got:\n%s", backup)
+	}
+	for _, path := range []string{inputPath, inputPath + ".bak"} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0755 {
+			t.Errorf("Expected %s to keep mode 0755, got %o", path, info.Mode().Perm())
+		}
+	}
+
+	// --no-backup ではバックアップを作成しない
+	os.Remove(inputPath + ".bak")
+	cli.config.BackupOriginal = false
+	if err := cli.generateOutput(results); err != nil {
+		t.Fatalf("generateOutput failed: %v", err)
+	}
+	if _, err := os.Stat(inputPath + ".bak"); !os.IsNotExist(err) {
+		t.Error("Expected no backup file with backup disabled")
+	}
+
+	// 標準入力は上書きできない
+	cli.config.InputPath = "-"
+	if err := cli.generateOutput(results); err == nil || !strings.Contains(err.Error(), "標準入力") {
+		t.Errorf("Expected stdin to be refused, got: %v", err)
+	}
+}
+
+func TestValidateInPlaceConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"disabled", Config{InputPath: "-", OutputPath: "-"}, false},
+		{"file", Config{InputPath: "deploy.sh", OutputPath: "-", InPlace: true}, false},
+		{"stdin", Config{InputPath: "-", OutputPath: "-", InPlace: true}, true},
+		{"with --out", Config{InputPath: "deploy.sh", OutputPath: "out.sh", InPlace: true}, true},
+		{"with --diff", Config{InputPath: "deploy.sh", OutputPath: "-", InPlace: true, DiffMode: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateInPlaceConfig(&tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateInPlaceConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIntegratedCLI_runRecursiveMode_RiskReport(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"safe.sh":    "#!/bin/bash\nusacloud server list\n",
+		"renamed.sh": "usacloud iso-image list\n",
+		"manual.sh":  "usacloud summary\nusacloud object-storage list\nusacloud server list\n",
+		"typo.sh":    "usacloud zzzzzz list\n",
+		"binary.sh":  "bin\x00ary",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reportPath := filepath.Join(t.TempDir(), "risk.json")
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = root
+	cli.config.Recursive = true
+	cli.config.ShowStats = false
+	cli.config.RiskReportPath = reportPath
+	cli.config.RiskReportFormat = "json"
+
+	if err := cli.runIntegratedMode(); err != nil {
+		t.Fatalf("runIntegratedMode failed: %v", err)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("Failed to read risk report: %v", err)
+	}
+	var ranked []risk.FileRisk
+	if err := json.Unmarshal(data, &ranked); err != nil {
+		t.Fatalf("Invalid risk report JSON: %v\n%s", err, data)
+	}
+
+	var order []string
+	for _, fr := range ranked {
+		order = append(order, filepath.Base(fr.Path))
+	}
+	expected := []string{"manual.sh", "typo.sh", "renamed.sh", "safe.sh"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("Expected ranking %v, got %v\n%s", expected, order, data)
+	}
+
+	manual := ranked[0]
+	if manual.ManualReview != 2 || manual.UnmappableDeprecations != 2 || manual.Score <= ranked[1].Score {
+		t.Errorf("Unexpected findings for manual.sh: %+v", manual)
+	}
+	if ranked[1].LowConfidenceSuggestions != 1 {
+		t.Errorf("Expected a low-confidence suggestion for typo.sh, got %+v", ranked[1])
+	}
+	if ranked[2].Score != 0 || ranked[3].Score != 0 {
+		t.Errorf("Expected renamed.sh and safe.sh to carry no risk, got %+v", ranked[2:])
+	}
+}
+
+func TestExecuteFiles_Parallel(t *testing.T) {
+	paths := []string{"a.sh", "b.sh", "c.sh", "d.sh", "e.sh"}
+	delays := map[string]time.Duration{"a.sh": 40 * time.Millisecond, "b.sh": 0, "c.sh": 20 * time.Millisecond, "d.sh": 10 * time.Millisecond, "e.sh": 0}
+
+	var running, peak int32
+	execute := func(path string) ([]*sandbox.ExecutionResult, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&running, -1)
+
+		time.Sleep(delays[path])
+		if path == "b.sh" {
+			return nil, fmt.Errorf("error reading file %s", path)
+		}
+		return []*sandbox.ExecutionResult{{Command: "usacloud server list " + path, Success: path != "d.sh"}}, nil
+	}
+
+	executions := executeFiles(paths, 2, execute)
+	if peak > 2 {
+		t.Errorf("Expected at most 2 files at a time, got %d", peak)
+	}
+	for i, e := range executions {
+		if e.Path != paths[i] {
+			t.Fatalf("Expected executions in input order, got %s at %d", e.Path, i)
+		}
+		if (e.Err != nil) != (e.Path == "b.sh") {
+			t.Errorf("%s: unexpected error %v", e.Path, e.Err)
+		}
+	}
+	if !fileExecutionsFailed(executions) {
+		t.Error("Expected the run to fail")
+	}
+
+	var buf bytes.Buffer
+	all := writeFileExecutionReport(&buf, executions)
+	if len(all) != 4 {
+		t.Errorf("Expected results of the 4 executed files, got %d", len(all))
+	}
+	report := buf.String()
+	for i, want := range []string{"File 1/5: a.sh", "File 2/5: b.sh", "error reading file b.sh", "File 3/5: c.sh", "File 4/5: d.sh", "❌ 1 failed", "File 5/5: e.sh"} {
+		idx := strings.Index(report, want)
+		if idx < 0 {
+			t.Fatalf("Expected report to contain %q (#%d), got:\n%s", want, i, report)
+		}
+		report = report[idx:]
+	}
+}
+
+func TestFileExecutionsFailed(t *testing.T) {
+	ok := []fileExecution{{Path: "a.sh", Results: []*sandbox.ExecutionResult{{Success: true}, {Skipped: true}}}}
+	if fileExecutionsFailed(ok) {
+		t.Error("Expected successful and skipped commands not to fail the run")
+	}
+	if !fileExecutionsFailed(append(ok, fileExecution{Path: "b.sh", Results: []*sandbox.ExecutionResult{{Success: false}}})) {
+		t.Error("Expected a failed command to fail the run")
+	}
+	if !fileExecutionsFailed(append(ok, fileExecution{Path: "c.sh", Err: fmt.Errorf("error executing file c.sh")})) {
+		t.Error("Expected a file error to fail the run")
+	}
+}
+
+func TestPlanDryRunDiff(t *testing.T) {
+	lines := []string{
+		"usacloud iso-image list",
+		"",
+		"usacloud summary",
+		"echo done",
+		"usacloud disk list --zone is1a",
+	}
+	rows := planDryRunDiff(transform.NewDefaultEngine(), sandbox.NewExecutor(config.DefaultConfig()), lines)
+	if len(rows) != 4 {
+		t.Fatalf("Expected blank lines to be left out, got %d rows", len(rows))
+	}
+
+	if rows[0].LineNumber != 1 || rows[0].Transformed != "usacloud cdrom list" || !rows[0].Plan.Execute {
+		t.Errorf("Expected the converted command to be executed, got %+v", rows[0])
+	}
+	if rows[1].LineNumber != 3 || rows[1].Plan.Execute || !strings.Contains(rows[1].Plan.SkipReason, "manual intervention") {
+		t.Errorf("Expected the commented out summary to be skipped, got %+v", rows[1])
+	}
+	if rows[2].Transformed != "" || rows[2].Plan.SkipReason != "Not a usacloud command" {
+		t.Errorf("Expected an unchanged non-usacloud line, got %+v", rows[2])
+	}
+	if rows[3].Plan.Execute || !strings.Contains(rows[3].Plan.SkipReason, "--zone=tk1v") {
+		t.Errorf("Expected a non-sandbox zone to be rejected, got %+v", rows[3])
+	}
+
+	var buf bytes.Buffer
+	writeDryRunDiff(&buf, "script.sh", rows)
+	out := buf.String()
+	for _, want := range []string{"LINE  ORIGINAL", "1     usacloud iso-image list         usacloud cdrom list  execute", "(unchanged)", "skip: Not a usacloud command", "1 to execute, 3 skipped"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestConfirmDestructiveCommands(t *testing.T) {
+	files := []fileDestructiveCommands{{
+		Path:     "cleanup.sh",
+		Commands: []sandbox.DestructiveCommand{{LineNumber: 3, Command: "usacloud server delete 1", Operation: "delete"}},
+	}}
+
+	tests := []struct {
+		name      string
+		files     []fileDestructiveCommands
+		canPrompt bool
+		answer    string
+		wantCode  int
+	}{
+		{"nothing destructive", nil, false, "", exit.Success},
+		{"confirmed", files, true, "y\n", exit.Success},
+		{"confirmed with yes", files, true, "YES\n", exit.Success},
+		{"declined", files, true, "\n", exit.Generic},
+		{"no answer", files, true, "", exit.Generic},
+		{"non-interactive", files, false, "y\n", exit.Config},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := confirmDestructiveCommands(strings.NewReader(tt.answer), &out, tt.canPrompt, tt.files)
+			if code := exit.Code(err); code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d (err: %v)", code, tt.wantCode, err)
+			}
+			if tt.files != nil && !strings.Contains(out.String(), "cleanup.sh:3: usacloud server delete 1 [delete]") {
+				t.Errorf("Expected the destructive command to be listed, got:\n%s", out.String())
+			}
+			if strings.Contains(out.String(), "Proceed?") != (tt.files != nil && tt.canPrompt) {
+				t.Errorf("Unexpected prompt in output:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestBuildSandboxReport(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	executions := []fileExecution{
+		{Path: "a.sh", Results: []*sandbox.ExecutionResult{
+			{Command: "usacloud iso-image list", Success: true, Stdout: "[]\n", Stderr: "warn\n", Attempts: 1, Duration: 1500 * time.Millisecond},
+			{Command: "echo done", Success: true, Skipped: true, SkipReason: "Not a usacloud command"},
+			{Command: "usacloud server list", Error: "timeout: command timed out after 30s (after 2 attempts)", Attempts: 2, TimedOut: true},
+		}},
+		{Path: "b.sh", Err: fmt.Errorf("error reading file b.sh")},
+	}
+
+	report := buildSandboxReport(transform.NewDefaultEngine(), executions, at)
+	wantTotal := sandboxReportCounts{Total: 3, Executed: 2, Succeeded: 1, Failed: 1, Skipped: 1}
+	if report.Summary != wantTotal || report.Files[0].Summary != wantTotal {
+		t.Errorf("Unexpected counts: %+v / %+v", report.Summary, report.Files[0].Summary)
+	}
+	if report.Files[1].Error != "error reading file b.sh" || len(report.Files[1].Commands) != 0 {
+		t.Errorf("Expected the unreadable file to be reported with its error, got %+v", report.Files[1])
+	}
+
+	first := report.Files[0].Commands[0]
+	if first.LineNumber != 1 || !strings.HasPrefix(first.Transformed, "usacloud cdrom list") || first.Stdout != "[]\n" || first.Stderr != "warn\n" || first.DurationMS != 1500 {
+		t.Errorf("Unexpected first command: %+v", first)
+	}
+	if third := report.Files[0].Commands[2]; third.LineNumber != 3 || third.Transformed != "" || !third.TimedOut || third.Attempts != 2 {
+		t.Errorf("Unexpected third command: %+v", third)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"generated_at":"2026-01-02T03:04:05Z"`, `"skip_reason":"Not a usacloud command"`, `"succeeded":1`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s in %s", want, data)
+		}
+	}
+}
+
+func TestConfigValidateCommand(t *testing.T) {
+	if !isConfigSubcommand([]string{"config", "validate", "--config", "a.conf"}, "validate") || isConfigSubcommand([]string{"config"}, "validate") {
+		t.Error("Unexpected config validate detection")
+	}
+
+	if path, err := parseConfigValidateArgs([]string{"--config", "a.conf"}, "b.conf"); err != nil || path != "a.conf" {
+		t.Errorf("Expected the subcommand --config to win, got %q, %v", path, err)
+	}
+	if path, err := parseConfigValidateArgs(nil, "b.conf"); err != nil || path != "b.conf" {
+		t.Errorf("Expected the global --config as the default, got %q, %v", path, err)
+	}
+	if _, err := parseConfigValidateArgs([]string{"extra"}, "b.conf"); err == nil {
+		t.Error("Expected an error for an extra argument")
+	}
+
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.conf")
+	if err := os.WriteFile(bad, []byte("[sakura-cloud]\naccess_token = \"t\"\naccess_token_secret = \"s\"\nzone = \"is1a\"\n\n[validation]\nstrictt = true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	ok, err := runConfigValidateMode(&buf, bad)
+	if err != nil || ok {
+		t.Fatalf("Expected problems to fail the validation, got ok=%v err=%v", ok, err)
+	}
+	for _, want := range []string{"4行目 [sakura-cloud] zone: SAKURACLOUD_ZONE must be 'tk1v'", "7行目 [validation] strictt: unknown validation key", "エラー 1件、警告 1件"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, buf.String())
+		}
+	}
+
+	warnOnly := filepath.Join(dir, "warn.conf")
+	if err := os.WriteFile(warnOnly, []byte("[sakura-cloud]\naccess_token = \"t\"\naccess_token_secret = \"s\"\nzone = \"tk1v\"\n\n[output]\ncolour = true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if ok, err := runConfigValidateMode(&buf, warnOnly); err != nil || !ok {
+		t.Errorf("Expected warnings alone to pass, got ok=%v err=%v:\n%s", ok, err, buf.String())
+	}
+
+	if _, err := runConfigValidateMode(&buf, filepath.Join(dir, "missing.conf")); err == nil || !strings.Contains(err.Error(), "設定ファイルが見つかりません") {
+		t.Errorf("Expected a not-found error, got %v", err)
+	}
+}
+
+func TestConfigCurrentCommand(t *testing.T) {
+	if !isConfigSubcommand([]string{"config", "current"}, "current") {
+		t.Error("Expected config current to be detected")
+	}
+	if path, err := parseConfigCurrentArgs([]string{"--config", "a.conf"}, ""); err != nil || path != "a.conf" {
+		t.Errorf("Unexpected path %q, %v", path, err)
+	}
+	if _, err := parseConfigCurrentArgs([]string{"extra"}, "a.conf"); err == nil || !strings.Contains(err.Error(), "config current") {
+		t.Errorf("Expected an error for an extra argument, got %v", err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "current.conf")
+	if err := os.WriteFile(configPath, []byte("[general]\nlog_level = warn\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("USACLOUD_UPDATE_VERBOSE", "true")
+	var buf bytes.Buffer
+	if err := runConfigCurrentMode(&buf, configPath, ""); err != nil {
+		t.Fatalf("runConfigCurrentMode failed: %v", err)
+	}
+	for _, want := range []string{"[general]", "log_level = warn  (設定ファイル)", "verbose = true  (環境変数 USACLOUD_UPDATE_VERBOSE)", "language = ja  (既定値)", "[output]"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, buf.String())
+		}
+	}
+
+	// --profile is shown as the active profile
+	buf.Reset()
+	if err := runConfigCurrentMode(&buf, configPath, "expert"); err != nil {
+		t.Fatalf("runConfigCurrentMode failed: %v", err)
+	}
+	for _, want := range []string{"プロファイル: expert", "show_progress = false  (プロファイル expert)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, buf.String())
+		}
+	}
+	if err := runConfigCurrentMode(&buf, configPath, "nosuch"); err == nil {
+		t.Error("Expected an unknown profile to be rejected")
+	}
+}
+
+func TestConfigEditArgs(t *testing.T) {
+	if !isConfigSubcommand([]string{"config", "edit"}, "edit") {
+		t.Error("Expected config edit to be detected")
+	}
+	if path, err := parseConfigEditArgs(nil, "a.conf"); err != nil || path != "a.conf" {
+		t.Errorf("Expected the --config given before the subcommand, got %q, %v", path, err)
+	}
+	if _, err := parseConfigEditArgs([]string{"extra"}, "a.conf"); err == nil || !strings.Contains(err.Error(), "config edit") {
+		t.Errorf("Expected an error for an extra argument, got %v", err)
+	}
+}
+
+func TestConfigMigrateCommand(t *testing.T) {
+	if !isConfigSubcommand([]string{"config", "migrate", "--from", "old.env"}, "migrate") {
+		t.Error("Expected config migrate to be detected")
+	}
+	opts, err := parseConfigMigrateArgs([]string{"--from", "old.env", "--to", "new.conf", "--force"})
+	if err != nil || opts != (configMigrateOptions{From: "old.env", To: "new.conf", Force: true}) {
+		t.Errorf("Unexpected options %+v, %v", opts, err)
+	}
+	if _, err := parseConfigMigrateArgs([]string{"extra"}); err == nil {
+		t.Error("Expected an error for an extra argument")
+	}
+
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "old.env")
+	if err := os.WriteFile(envPath, []byte("SAKURACLOUD_ACCESS_TOKEN=t\nMY_PROJECT=demo\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	opts = configMigrateOptions{From: envPath, To: filepath.Join(dir, "new.conf")}
+	var buf bytes.Buffer
+	if err := runConfigEnvMigrateMode(&buf, opts); err != nil {
+		t.Fatalf("runConfigEnvMigrateMode failed: %v", err)
+	}
+	for _, want := range []string{"✓ SAKURACLOUD_ACCESS_TOKEN → [sakura-cloud] access_token", "MY_PROJECT: 対応する設定がないため [legacy] に保持しました", "新しい設定ファイルを作成しました"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, buf.String())
+		}
+	}
+
+	if err := runConfigEnvMigrateMode(&buf, opts); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected an existing file to be kept without --force, got %v", err)
+	}
+	opts.Force = true
+	if err := runConfigEnvMigrateMode(&buf, opts); err != nil {
+		t.Errorf("Expected --force to overwrite, got %v", err)
+	}
+
+	opts.From = filepath.Join(dir, "missing.env")
+	if err := runConfigEnvMigrateMode(&buf, opts); err == nil || !strings.Contains(err.Error(), ".envファイルが見つかりません") {
+		t.Errorf("Expected a not-found error, got %v", err)
+	}
+}
+
+func TestValidateWatchConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"disabled", Config{InputPath: "-", InputPaths: []string{"-"}, OutputPath: "-"}, false},
+		{"directory", Config{Watch: true, InputPath: "scripts", InputPaths: []string{"scripts"}, OutputPath: "-"}, false},
+		{"stdin", Config{Watch: true, InputPath: "-", InputPaths: []string{"-"}, OutputPath: "-"}, true},
+		{"multiple inputs", Config{Watch: true, InputPath: "a.sh", InputPaths: []string{"a.sh", "b.sh"}, OutputPath: "-"}, true},
+		{"with --out", Config{Watch: true, InputPath: "a.sh", InputPaths: []string{"a.sh"}, OutputPath: "out.sh"}, true},
+		{"with --in-place", Config{Watch: true, InputPath: "a.sh", InputPaths: []string{"a.sh"}, OutputPath: "-", InPlace: true}, true},
+		{"with --sandbox", Config{Watch: true, InputPath: "a.sh", InputPaths: []string{"a.sh"}, OutputPath: "-", SandboxMode: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateWatchConfig(&tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateWatchConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsWatchTarget(t *testing.T) {
+	if !isWatchTarget("scripts", true, "scripts/sub/deploy.sh") || isWatchTarget("scripts", true, "scripts/README.md") {
+		t.Error("Expected only .sh files to be watched in a directory")
+	}
+	if !isWatchTarget("./deploy.txt", false, "deploy.txt") || isWatchTarget("deploy.txt", false, "other.sh") {
+		t.Error("Expected only the given file to be watched")
+	}
+}
+
+func TestDebounceChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan string)
+	runs := make(chan []string, 4)
+	done := make(chan struct{})
+	go func() {
+		debounceChanges(ctx, changes, 50*time.Millisecond, func(paths []string) { runs <- paths })
+		close(done)
+	}()
+
+	// Rapid writes are reported once, deduplicated and sorted
+	for _, path := range []string{"b.sh", "a.sh", "b.sh", "b.sh"} {
+		changes <- path
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case paths := <-runs:
+		if !reflect.DeepEqual(paths, []string{"a.sh", "b.sh"}) {
+			t.Errorf("Expected [a.sh b.sh], got %v", paths)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the changes to be run")
+	}
+
+	changes <- "c.sh"
+	select {
+	case paths := <-runs:
+		if !reflect.DeepEqual(paths, []string{"c.sh"}) {
+			t.Errorf("Expected only the new change, got %v", paths)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the second change to be run")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected debounceChanges to return on cancel")
+	}
+}
+
+func TestWatchSummary(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deploy.sh")
+	if err := os.WriteFile(path, []byte("usacloud server list --output-type=csv\nusacloud serverr list\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewIntegratedCLI()
+	inputPath := cli.config.InputPath
+	first := cli.summarizeWatchedFile(path)
+	if first.Err != nil || first.TotalLines != 2 || first.ChangedLines != 1 {
+		t.Fatalf("Unexpected summary: %+v", first)
+	}
+	if errorCount, _ := first.issueCounts(); errorCount != 1 || first.Issues[0].LineNumber != 2 {
+		t.Errorf("Expected the unknown command on line 2, got %+v", first.Issues)
+	}
+	if cli.config.InputPath != inputPath {
+		t.Error("Expected the config to be restored")
+	}
+
+	// Fixing the typo is reported against the previous run
+	if err := os.WriteFile(path, []byte("usacloud server list --output-type=csv\nusacloud server list\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	writeWatchSummary(&buf, cli.summarizeWatchedFile(path), first, true)
+	if want := "2行中 1行を変更、エラー 0件・警告 0件 (前回から 変更 ±0、エラー -1、警告 ±0)"; !strings.Contains(buf.String(), want) {
+		t.Errorf("Expected %q in output:\n%s", want, buf.String())
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if removed := cli.summarizeWatchedFile(path); !removed.Removed {
+		t.Errorf("Expected the removed file to be reported, got %+v", removed)
+	}
+}
+
+func TestResolveProgressSettings(t *testing.T) {
+	original := *configFile
+	defer func() { *configFile = original }()
+
+	*configFile = ""
+	if show, style := resolveProgressSettings(); !show || style != "bar" {
+		t.Errorf("Expected the default progress settings, got %v %q", show, style)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	if err := os.WriteFile(configPath, []byte("[output]\nshow_progress = false\nprogress_style = dots\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	*configFile = configPath
+	if show, style := resolveProgressSettings(); show || style != "dots" {
+		t.Errorf("Expected the progress settings of the config file, got %v %q", show, style)
+	}
+
+	// parseFlags leaves them to NewIntegratedCLI, which reads the file once
+	if cfg := parseFlags(); cfg.ProgressStyle != "" {
+		t.Errorf("Expected parseFlags not to read the progress settings, got %q", cfg.ProgressStyle)
+	}
+	if cfg := NewIntegratedCLI().config; cfg.ShowProgress || cfg.ProgressStyle != "dots" {
+		t.Errorf("Expected the CLI to use the progress settings of the config file, got %v %q", cfg.ShowProgress, cfg.ProgressStyle)
+	}
+}
+
+func TestProfileFlag(t *testing.T) {
+	origConfig, origProfile := *configFile, *profileName
+	defer func() { *configFile, *profileName = origConfig, origProfile }()
+
+	*configFile, *profileName = "", "expert"
+	if show, _ := resolveProgressSettings(); show {
+		t.Error("Expected the expert profile to turn progress off")
+	}
+	cfg := loadValidationConfig()
+	if cfg.MaxSuggestions != 3 {
+		t.Errorf("Expected the max suggestions of the expert profile, got %d", cfg.MaxSuggestions)
+	}
+
+	*profileName = "nosuch"
+	if _, err := readIntegratedConfig(); err == nil || !strings.Contains(err.Error(), "利用可能なプロファイル: beginner, ci, default, expert") {
+		t.Errorf("Expected an unknown profile to list the available ones, got %v", err)
+	}
+
+	*profileName = ""
+	if fileCfg, err := readIntegratedConfig(); fileCfg != nil || err != nil {
+		t.Errorf("Expected nothing to read without --config or --profile, got %v, %v", fileCfg, err)
+	}
+}
+
+func TestNewProgress_Disabled(t *testing.T) {
+	// The tests never run with stderr on a terminal, and CI=true disables progress regardless
+	t.Setenv("CI", "true")
+	if bar := newProgress(&Config{ShowProgress: true, ProgressStyle: "bar"}, 10); bar != nil {
+		t.Error("Expected no progress bar outside a terminal")
+	}
+
+	// Without a bar, processing output goes straight to stderr
+	cli := &IntegratedCLI{config: &Config{ShowProgress: true}}
+	finish := cli.startProgress(10)
+	if cli.progress != nil || cli.stderr() != os.Stderr {
+		t.Error("Expected progress to be disabled")
+	}
+	finish()
+}
+
+func TestResolveJobs(t *testing.T) {
+	origJobs, origConfig := *jobsFlag, *configFile
+	defer func() { *jobsFlag, *configFile = origJobs, origConfig }()
+	*jobsFlag, *configFile = 0, ""
+
+	// The defaults use every CPU
+	if jobs := resolveJobs(); jobs != runtime.NumCPU() {
+		t.Errorf("Expected %d workers by default, got %d", runtime.NumCPU(), jobs)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	*configFile = configPath
+	writeConfig("[performance]\nparallel_processing = true\nworker_count = 3\n")
+	if jobs := resolveJobs(); jobs != 3 {
+		t.Errorf("Expected worker_count to be used, got %d", jobs)
+	}
+	writeConfig("[performance]\nparallel_processing = false\nworker_count = 3\n")
+	if jobs := resolveJobs(); jobs != 1 {
+		t.Errorf("Expected parallel_processing = false to process lines sequentially, got %d", jobs)
+	}
+}
+
+// parallelTestLines returns lines mixing changed, unchanged and invalid commands
+func parallelTestLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		switch i % 4 {
+		case 0:
+			lines[i] = "usacloud server list --output-type=csv"
+		case 1:
+			lines[i] = "echo 'test'"
+		case 2:
+			lines[i] = "usacloud disk list --output-type=tsv"
+		default:
+			lines[i] = "usacloud disk read --selector name=mydisk"
+		}
+	}
+	return lines
+}
+
+func TestProcessLines_ParallelPreservesOrder(t *testing.T) {
+	lines := parallelTestLines(500)
+	lines[321] = "usacloud invalidcommand list"
+
+	cli := NewIntegratedCLI()
+	cli.config.ShowStats = false
+	cli.config.Jobs = 1
+	sequential, err := cli.processLines(lines)
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+	sequentialStats := cli.stats
+
+	cli.config.Jobs = 8
+	parallel, err := cli.processLines(lines)
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(sequential, parallel) {
+		t.Error("Expected the parallel results to match the sequential results")
+	}
+	if !reflect.DeepEqual(sequentialStats, cli.stats) {
+		t.Errorf("Expected the same stats, got %+v and %+v", sequentialStats, cli.stats)
+	}
+	if parallel[321].LineNumber != 322 || parallel[321].ValidationResult == nil || !parallel[321].ValidationResult.HasErrors() {
+		t.Errorf("Expected the invalid command on line 322, got %+v", parallel[321])
+	}
+}
+
+func TestProcessLines_ParallelStrictStopsAtFirstError(t *testing.T) {
+	lines := parallelTestLines(2000)
+	for _, i := range []int{1500, 700, 1999} {
+		lines[i] = "usacloud invalidcommand list"
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.ShowStats = false
+	cli.config.StrictValidation = true
+	cli.config.Jobs = 8
+
+	_, err := cli.processLines(lines)
+	if err == nil || !strings.Contains(err.Error(), "行 701 で検証エラー") {
+		t.Errorf("Expected the strict validation error of the earliest invalid line, got %v", err)
+	}
+
+	outcomes, failedAt := cli.evaluateLines(lines, cli.transformEngine.Apply, nil)
+	if failedAt != 700 {
+		t.Errorf("Expected the earliest invalid line at 700, got %d", failedAt)
+	}
+	for i := 0; i < failedAt; i++ {
+		if outcomes[i].transform.Original != lines[i] {
+			t.Fatalf("Expected line %d before the error to be processed", i+1)
+		}
+	}
+}
+
+func TestLoadValidationConfig_CacheSize(t *testing.T) {
+	original := *configFile
+	defer func() { *configFile = original }()
+
+	*configFile = ""
+	if cfg := loadValidationConfig(); cfg.CacheSizeMB != 100 {
+		t.Errorf("Expected the default cache size of 100MB, got %d", cfg.CacheSizeMB)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	*configFile = configPath
+	for content, expected := range map[string]int{
+		"[performance]\ncache_enabled = true\ncache_size_mb = 8\n":  8,
+		"[performance]\ncache_enabled = false\ncache_size_mb = 8\n": 0,
+	} {
+		if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if cfg := loadValidationConfig(); cfg.CacheSizeMB != expected {
+			t.Errorf("Expected a cache size of %dMB for %q, got %d", expected, content, cfg.CacheSizeMB)
+		}
+	}
+}
+
+func TestWriteSuggestionCacheStats(t *testing.T) {
+	var buf bytes.Buffer
+	writeSuggestionCacheStats(&buf, validation.CacheStats{Hits: 9, Misses: 3, Entries: 3, Bytes: 2048, MaxBytes: 100 << 20})
+	if want := "ヒット 9件・ミス 3件、3件を保持 (約 2.0 KB / 上限 100 MB)"; !strings.Contains(buf.String(), want) {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+
+	buf.Reset()
+	writeSuggestionCacheStats(&buf, validation.CacheStats{})
+	if !strings.Contains(buf.String(), "無効") {
+		t.Errorf("Expected the cache to be reported as disabled, got %q", buf.String())
+	}
+}
+
+func TestExplainCommand(t *testing.T) {
+	if !isExplainSubcommand([]string{"explain", "server", "list"}) || isExplainSubcommand([]string{"config", "validate"}) || isExplainSubcommand(nil) {
+		t.Error("Unexpected explain detection")
+	}
+
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{[]string{"usacloud server list --output-type=csv"}, "usacloud server list --output-type=csv", false},
+		{[]string{"server", "list"}, "usacloud server list", false},
+		{[]string{"usacloud", "iso-image", "list"}, "usacloud iso-image list", false},
+		{nil, "", true},
+		{[]string{"  "}, "", true},
+	}
+	for _, tt := range tests {
+		line, err := parseExplainArgs(tt.args)
+		if (err != nil) != tt.wantErr || line != tt.want {
+			t.Errorf("parseExplainArgs(%q) = %q, %v; want %q (error %v)", tt.args, line, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestIntegratedCLI_runExplainMode(t *testing.T) {
+	cli := NewIntegratedCLI()
+
+	var buf bytes.Buffer
+	cli.runExplainMode(&buf, "usacloud iso-image list --output-type=csv")
+	out := buf.String()
+	for _, want := range []string{"[output-type-csv-tsv]", "[iso-image-to-cdrom]", "v0とv1の違い", "廃止されたコマンド", "代替コマンド: usacloud cdrom", "cdrom"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in explanation:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	cli.runExplainMode(&buf, "usacloud serv lst")
+	out = buf.String()
+	for _, want := range []string{"適用される変換ルールはありません", "'serv' は有効なusacloudコマンドではありません", "server list"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in explanation:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "廃止されたコマンド") {
+		t.Errorf("Unexpected deprecation section for a mistyped command:\n%s", out)
+	}
+
+	buf.Reset()
+	cli.runExplainMode(&buf, "usacloud server list")
+	if out = buf.String(); !strings.Contains(out, "問題は見つかりませんでした") {
+		t.Errorf("Expected a clean validation for a valid command:\n%s", out)
+	}
+}
+
+func TestProcessLines_Continuations(t *testing.T) {
+	lines := []string{
+		`usacloud iso-image list \`,
+		`    --output-type csv`,
+		`usacloud serv list \`,
+		`    --zone tk1v`,
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.ShowStats = false
+	results, err := cli.processLines(lines)
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+	if len(results) != len(lines) {
+		t.Fatalf("Expected one result per physical line, got %d", len(results))
+	}
+
+	if results[0].TransformResult.Line != `usacloud cdrom list \` || !strings.HasPrefix(results[1].TransformResult.Line, "    --output-type json # usacloud-update:") {
+		t.Errorf("Unexpected transformed lines: %q, %q", results[0].TransformResult.Line, results[1].TransformResult.Line)
+	}
+	if results[1].LineNumber != 2 || results[1].OriginalLine != lines[1] {
+		t.Errorf("Unexpected continuation line result: %+v", results[1])
+	}
+
+	// 検証はコマンド全体に対して行い、先頭行に付ける
+	if v := results[2].ValidationResult; v == nil || v.LineNumber != 3 || v.Line != "usacloud serv list     --zone tk1v" {
+		t.Errorf("Expected the joined command to be validated on line 3, got %+v", v)
+	}
+	if results[3].ValidationResult != nil {
+		t.Errorf("Unexpected validation result on a continuation line: %+v", results[3].ValidationResult)
+	}
+	if cli.stats.TotalLines != 4 || cli.stats.RuleHits["iso-image-to-cdrom"] != 1 {
+		t.Errorf("Unexpected stats: %+v", cli.stats)
+	}
+}
+
+func TestProcessLines_WrappedInvocations(t *testing.T) {
+	lines := []string{
+		`USACLOUD="$(command -v usacloud)"`,
+		`sudo usacloud serv list`,
+		`"$USACLOUD" iso-image list`,
+		`echo "usacloud is installed"`,
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.ShowStats = false
+	results, err := cli.processLines(lines)
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+
+	if v := results[1].ValidationResult; v == nil || len(v.Issues) == 0 || v.Issues[0].Type != IssueInvalidMainCommand {
+		t.Errorf("Expected the sudo command to be validated, got %+v", v)
+	}
+	if got := cli.generateSuggestedFix(*results[1].ValidationResult); !strings.HasPrefix(got, "sudo usacloud server") {
+		t.Errorf("generateSuggestedFix() = %q", got)
+	}
+	if !strings.HasPrefix(results[2].TransformResult.Line, `"$USACLOUD" cdrom list # usacloud-update:`) {
+		t.Errorf("Expected the command run through the variable to be transformed, got %q", results[2].TransformResult.Line)
+	}
+	if v := results[2].ValidationResult; v == nil || v.Issues[0].Type != IssueDeprecatedCommand {
+		t.Errorf("Expected the command run through the variable to be validated, got %+v", v)
+	}
+	for _, i := range []int{0, 3} {
+		if results[i].TransformResult.Changed || results[i].ValidationResult != nil {
+			t.Errorf("Expected line %d to be left untouched, got %+v", i+1, results[i])
+		}
+	}
+}
+
+func TestProcessLines_Quiet(t *testing.T) {
+	lines := []string{"usacloud iso-image list", "usacloud server list --output-type csv"}
+
+	for _, tt := range []struct {
+		name      string
+		showStats bool
+		quiet     bool
+		want      bool
+	}{
+		{"stats", true, false, true},
+		{"quiet", true, true, false},
+		{"quiet without stats", false, true, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewIntegratedCLI()
+			cli.config.ShowStats = tt.showStats
+			cli.config.Quiet = tt.quiet
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			_, err := cli.processLines(lines)
+			w.Close()
+			os.Stderr = oldStderr
+
+			captured, _ := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatalf("processLines failed: %v", err)
+			}
+			if got := strings.Contains(string(captured), "#L1 "); got != tt.want {
+				t.Errorf("Expected per-change output %v, got:\n%s", tt.want, captured)
+			}
+			// 統計は --quiet でも集計される
+			if cli.stats.ChangedLines != 2 {
+				t.Errorf("Unexpected stats: %+v", cli.stats)
+			}
+		})
+	}
+}
+
+func TestColorModeFlag(t *testing.T) {
+	tests := []struct {
+		value      string
+		want       string
+		deprecated bool
+	}{
+		{"auto", colorAuto, false},
+		{"ALWAYS", colorAlways, false},
+		{"never", colorNever, false},
+		{"true", colorAlways, true}, // also a bare --color
+		{"false", colorNever, true},
+	}
+
+	for _, tt := range tests {
+		f := &colorModeFlag{mode: colorAuto}
+		if err := f.Set(tt.value); err != nil {
+			t.Fatalf("Set(%q) error = %v", tt.value, err)
+		}
+		if f.String() != tt.want || (f.deprecationWarning() != "") != tt.deprecated {
+			t.Errorf("Set(%q) = %q (warning %q), want %q", tt.value, f.String(), f.deprecationWarning(), tt.want)
+		}
+	}
+
+	if err := (&colorModeFlag{}).Set("sometimes"); err == nil {
+		t.Error("Expected an unknown value to be rejected")
+	}
+}
+
+func TestConsumeColorModeArg(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantMode string
+		wantIn   string
+		wantArgs int
+	}{
+		{[]string{"--color", "never", "--in", "script.sh"}, colorNever, "script.sh", 0},
+		{[]string{"--in", "script.sh", "--color", "ALWAYS"}, colorAlways, "script.sh", 0},
+		{[]string{"--color", "--in", "script.sh"}, colorAlways, "script.sh", 0},
+		{[]string{"--color=auto", "never"}, colorAuto, "", 1}, // already has its value
+		{[]string{"--color", "script.sh"}, colorAlways, "", 1},
+	}
+
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		f := &colorModeFlag{mode: colorAuto}
+		fs.Var(f, "color", "")
+		in := fs.String("in", "", "")
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%v) error = %v", tt.args, err)
+		}
+		if err := consumeColorModeArg(fs, f); err != nil {
+			t.Fatalf("consumeColorModeArg(%v) error = %v", tt.args, err)
+		}
+		if f.String() != tt.wantMode || *in != tt.wantIn || fs.NArg() != tt.wantArgs {
+			t.Errorf("%v: mode %q, --in %q, args %v; want %q, %q, %d args", tt.args, f.String(), *in, fs.Args(), tt.wantMode, tt.wantIn, tt.wantArgs)
+		}
+	}
+
+	if err := validatePositionalArgs([]string{"script.sh"}); err == nil || !strings.Contains(err.Error(), "script.sh") {
+		t.Errorf("Expected a stray positional argument to be rejected, got %v", err)
+	}
+	if err := validatePositionalArgs(nil); err != nil {
+		t.Errorf("Expected no arguments to be accepted, got %v", err)
+	}
+}
+
+func TestColorEnabledFor(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+
+	file, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if colorEnabledFor(colorAuto, file) {
+		t.Error("Expected auto to disable color when the output is redirected to a file")
+	}
+	if !colorEnabledFor(colorAlways, file) || colorEnabledFor(colorNever, os.Stderr) {
+		t.Error("Expected always/never to ignore the output")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabledFor(colorAuto, os.Stderr) {
+		t.Error("Expected NO_COLOR to disable color in auto mode")
+	}
+}
+
+func TestValidateReviewConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"disabled", Config{Recursive: true}, false},
+		{"single file", Config{Review: true, InputPaths: []string{"a.sh"}}, false},
+		{"recursive", Config{Review: true, Recursive: true}, true},
+		{"multiple inputs", Config{Review: true, InputPaths: []string{"a.sh", "b.sh"}}, true},
+		{"validate only", Config{Review: true, ValidateOnly: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateReviewConfig(&tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateReviewConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDryRunConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"disabled", Config{Review: true}, false},
+		{"conversion", Config{DryRun: true, Recursive: true}, false},
+		{"sandbox", Config{DryRun: true, SandboxMode: true, Review: true}, false},
+		{"validate only", Config{DryRun: true, ValidateOnly: true}, true},
+		{"watch", Config{DryRun: true, Watch: true}, true},
+		{"review", Config{DryRun: true, Review: true}, true},
+		{"diff", Config{DryRun: true, DiffMode: true}, true},
+		{"provenance", Config{DryRun: true, ProvenancePath: "p.json"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateDryRunConfig(&tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateDryRunConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIntegratedCLI_runDryRunMode(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.sh":      "usacloud iso-image list\nusacloud startup-script list\n",
+		"b.sh":      "usacloud iso-image list\n",
+		"same.sh":   "usacloud server list\n",
+		"binary.sh": "bin\x00ary",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = root
+	cli.config.Recursive = true
+	cli.config.DryRun = true
+	cli.config.ShowStats = false
+	cli.config.Exclude = []string{"b.sh"}
+
+	var out bytes.Buffer
+	if err := cli.runDryRunMode(&out); err != nil {
+		t.Fatalf("runDryRunMode failed: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{"2ファイル中 1ファイルで 2行が 2個のルール", "スキップ: 1  除外: 1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in summary, got:\n%s", want, got)
+		}
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(files) {
+		t.Errorf("Expected no files to be written, got %d entries", len(entries))
+	}
+	if cli.config.InputPath != root || cli.config.Quiet {
+		t.Errorf("Expected the configuration to be restored, got %+v", cli.config)
+	}
+}
+
+func TestIntegratedCLI_runDryRunMode_StrictValidation(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "invalid.sh")
+	if err := os.WriteFile(input, []byte("usacloud iso-image list\nusacloud nosuchcommand list\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPaths = []string{input}
+	cli.config.DryRun = true
+	cli.config.ShowStats = false
+	cli.config.StrictValidation = true
+
+	var out bytes.Buffer
+	if err := cli.runDryRunMode(&out); err != nil {
+		t.Fatalf("Expected the invalid line not to stop the dry run, got %v", err)
+	}
+	if !strings.Contains(out.String(), "1ファイル中 1ファイルで 1行") {
+		t.Errorf("Expected the summary, got:\n%s", out.String())
+	}
+	if !cli.config.StrictValidation {
+		t.Error("Expected the configuration to be restored")
+	}
+}
+
+func TestIntegratedCLI_runDryRunMode_MissingInput(t *testing.T) {
+	cli := NewIntegratedCLI()
+	cli.config.InputPaths = []string{filepath.Join(t.TempDir(), "missing.sh")}
+	cli.config.DryRun = true
+	cli.config.ShowStats = false
+
+	err := cli.runDryRunMode(io.Discard)
+	if err == nil || exit.Code(err) != exit.IO {
+		t.Errorf("Expected an IO error, got %v", err)
+	}
+}
+
+func TestIntegratedCLI_applyReviewedResults(t *testing.T) {
+	cli := NewIntegratedCLI()
+	cli.config.ShowStats = false
+	results, err := cli.processLines([]string{"usacloud iso-image list", "usacloud startup-script list"})
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+
+	// 2行目の変更を却下
+	reviewed := []transform.Result{*results[0].TransformResult, {Original: results[1].OriginalLine, Line: results[1].OriginalLine}}
+	cli.applyReviewedResults(results, reviewed)
+
+	if results[1].TransformResult.Line != "usacloud startup-script list" || !results[0].TransformResult.Changed {
+		t.Errorf("Unexpected results: %+v, %+v", results[0].TransformResult, results[1].TransformResult)
+	}
+	if cli.stats.ChangedLines != 1 || cli.stats.RuleHits["startup-script-to-note"] != 0 {
+		t.Errorf("Expected the stats to count the accepted change only, got %+v", cli.stats)
+	}
+}
+
+func TestProcessLines_MaxLineLength(t *testing.T) {
+	long := "usacloud iso-image list --name " + strings.Repeat("x", 100)
+	lines := []string{
+		"usacloud iso-image list",
+		long,
+		"usacloud iso-image list \\",
+		"  --name " + strings.Repeat("y", 100),
+		"usacloud summary",
+	}
+
+	cli := NewIntegratedCLI()
+	cli.config.InputPath = "long.sh"
+	cli.config.ShowStats = false
+	cli.config.MaxLineLength = 64
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	results, err := cli.processLines(lines)
+	w.Close()
+	os.Stderr = oldStderr
+	captured, _ := io.ReadAll(r)
+	r.Close()
+
+	if err != nil {
+		t.Fatalf("processLines failed: %v", err)
+	}
+	if len(results) != len(lines) {
+		t.Fatalf("Expected every line to be kept, got %d results", len(results))
+	}
+
+	// 長すぎる行を含むコマンドは継続行も含めて元のまま
+	for i, want := range []bool{true, false, false, false, true} {
+		if results[i].TransformResult.Changed != want {
+			t.Errorf("line %d: Changed = %v, want %v (%q)", i+1, results[i].TransformResult.Changed, want, results[i].TransformResult.Line)
+		}
+	}
+	for _, i := range []int{1, 2, 3} {
+		if results[i].TransformResult.Line != lines[i] || results[i].ValidationResult != nil {
+			t.Errorf("Expected line %d to be passed through unchanged, got %+v", i+1, results[i])
+		}
+	}
+
+	for _, want := range []string{"long.sh:2: 行が長すぎるため", "long.sh:4: 行が長すぎるため", "--max-line-length 64"} {
+		if !strings.Contains(string(captured), want) {
+			t.Errorf("Expected the warning to contain %q, got:\n%s", want, captured)
+		}
+	}
+	if strings.Contains(string(captured), "long.sh:1:") || strings.Contains(string(captured), "long.sh:3:") {
+		t.Errorf("Expected warnings only for the oversized lines, got:\n%s", captured)
+	}
+}
+
+func TestDeprecatedListCommand(t *testing.T) {
+	if !isDeprecatedSubcommand([]string{"deprecated", "list"}, "list") || isDeprecatedSubcommand([]string{"deprecated"}, "list") || isDeprecatedSubcommand([]string{"config", "list"}, "list") {
+		t.Error("Unexpected deprecated list detection")
+	}
+
+	tests := []struct {
+		args    []string
+		want    deprecatedListOptions
+		wantErr bool
+	}{
+		{nil, deprecatedListOptions{Format: "text"}, false},
+		{[]string{"product-"}, deprecatedListOptions{Prefix: "product-", Format: "text"}, false},
+		{[]string{"product-", "--output-format=json"}, deprecatedListOptions{Prefix: "product-", Format: "json"}, false},
+		{[]string{"--output-format", "json", "iso"}, deprecatedListOptions{Prefix: "iso", Format: "json"}, false},
+		{[]string{"a", "b"}, deprecatedListOptions{}, true},
+		{[]string{"--output-format=yaml"}, deprecatedListOptions{}, true},
+		{[]string{"--unknown"}, deprecatedListOptions{}, true},
+	}
+	for _, tt := range tests {
+		opts, err := parseDeprecatedListArgs(tt.args, "text")
+		if (err != nil) != tt.wantErr || opts != tt.want {
+			t.Errorf("parseDeprecatedListArgs(%q) = %+v, %v; want %+v (error %v)", tt.args, opts, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRunDeprecatedListMode(t *testing.T) {
+	detector := validation.NewDeprecatedCommandDetector()
+
+	var buf bytes.Buffer
+	if err := runDeprecatedListMode(&buf, detector, deprecatedListOptions{Format: "text"}); err != nil {
+		t.Fatalf("runDeprecatedListMode() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"COMMAND", "iso-image", "cdrom", "summary", fmt.Sprintf("%d件の廃止コマンド", len(detector.GetAllDeprecatedCommands()))} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the list:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "ipv4") > strings.Index(out, "summary") {
+		t.Errorf("Expected the commands in name order:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := runDeprecatedListMode(&buf, detector, deprecatedListOptions{Prefix: "product-", Format: "json"}); err != nil {
+		t.Fatalf("runDeprecatedListMode() error = %v", err)
+	}
+	var entries []deprecatedListEntry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected the 3 product-* commands, got %+v", entries)
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Command, "product-") || e.ReplacementCommand == "" || e.Type != "renamed" {
+			t.Errorf("Unexpected entry: %+v", e)
+		}
+	}
+
+	buf.Reset()
+	if err := runDeprecatedListMode(&buf, detector, deprecatedListOptions{Prefix: "server", Format: "text"}); err != nil {
+		t.Fatalf("runDeprecatedListMode() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "廃止コマンドはありません") {
+		t.Errorf("Expected a note for no matches, got %q", buf.String())
+	}
+}
+
+func TestValidateLine_Options(t *testing.T) {
+	cli := NewIntegratedCLI()
+
+	result := cli.validateLine("usacloud server list --zone tk1x", 1)
+	if result == nil || !result.HasErrors() || result.Issues[0].Type != IssueInvalidOption || result.Issues[0].Component != "--zone" {
+		t.Fatalf("Expected an invalid --zone error, got %+v", result)
+	}
+	if !strings.Contains(result.Issues[0].Message, "tk1a") {
+		t.Errorf("Expected the similar zones in the message: %s", result.Issues[0].Message)
+	}
+
+	result = cli.validateLine("usacloud server list --nmes web", 2)
+	if result == nil || result.HasErrors() || !result.Issues[0].Advisory {
+		t.Fatalf("Expected an unknown option warning, got %+v", result)
+	}
+
+	if result := cli.validateLine("usacloud server list --zone is1a --output-type csv", 3); result != nil {
+		t.Errorf("Unexpected issues: %+v", result.Issues)
+	}
+}
+
+func TestRunListRulesMode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := runListRulesMode(&buf, transform.NewDefaultEngine(), "json"); err != nil {
+		t.Fatalf("runListRulesMode() error = %v", err)
+	}
+	var infos []transform.RuleInfo
+	if err := json.Unmarshal(buf.Bytes(), &infos); err != nil || len(infos) == 0 {
+		t.Fatalf("Expected a JSON array of rules, got %v: %s", err, buf.String())
+	}
+
+	if err := runListRulesMode(&buf, transform.NewDefaultEngine(), "yaml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestCheckAlreadyProcessed(t *testing.T) {
+	lines := []string{transform.GeneratedHeader(), "#!/bin/bash", "usacloud cdrom list"}
+
+	cli := &IntegratedCLI{config: &Config{InputPath: "updated.sh"}}
+	if _, err := cli.checkAlreadyProcessed(lines); exit.Code(err) != exit.AlreadyProcessed || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected an already processed error suggesting --force, got %v", err)
+	}
+
+	plain := lines[1:]
+	if got, err := cli.checkAlreadyProcessed(plain); err != nil || len(got) != len(plain) {
+		t.Errorf("Expected an unprocessed input to pass through, got %v, %v", got, err)
+	}
+
+	cli.config.ReverseMode = true
+	if got, err := cli.checkAlreadyProcessed(lines); err != nil || len(got) != len(lines) {
+		t.Errorf("Expected --reverse to accept a processed input as is, got %v, %v", got, err)
+	}
+
+	cli.config.ReverseMode, cli.config.Force = false, true
+	got, err := cli.checkAlreadyProcessed(lines)
+	if err != nil || len(got) != 2 || got[0] != "#!/bin/bash" {
+		t.Errorf("Expected --force to strip the generated header, got %v, %v", got, err)
+	}
+}
+
+func TestWriteCompletionScript(t *testing.T) {
+	fs := flag.NewFlagSet("usacloud-update", flag.ContinueOnError)
+	fs.String("output-format", "text", "")
+	root := newCompletionRootCommand(fs)
+
+	for _, shell := range completionShells {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeCompletionScript(&buf, root, shell); err != nil {
+				t.Fatalf("writeCompletionScript(%s) error: %v", shell, err)
+			}
+			if !strings.Contains(buf.String(), "usacloud-update") {
+				t.Errorf("%s の補完スクリプトにコマンド名がありません", shell)
+			}
+		})
+	}
+
+	if err := writeCompletionScript(io.Discard, root, "tcsh"); err == nil {
+		t.Error("未対応のシェルでエラーになりません")
+	}
+}
+
+func TestCompletionCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+		excluded []string
+	}{
+		{"サブコマンド", []string{""}, []string{"config", "deprecated", "completion"}, nil},
+		{"config のサブコマンド", []string{"config", ""}, []string{"validate", "current", "edit", "migrate"}, nil},
+		{"フラグ名", []string{"--output-f"}, []string{"--output-format"}, []string{"--in"}},
+		{"フラグの値", []string{"--output-format", ""}, []string{"text", "json", "sarif"}, nil},
+		{"ルール名はカンマの後を補完", []string{"--disable-rules", "a,"}, []string{"a," + transform.NewDefaultEngine().RuleNames()[0]}, nil},
+		{"シェル", []string{"completion", ""}, []string{"bash", "zsh", "fish"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("usacloud-update", flag.ContinueOnError)
+			fs.String("output-format", "text", "")
+			fs.String("disable-rules", "", "")
+			fs.String("in", "-", "")
+			root := newCompletionRootCommand(fs)
+
+			var buf bytes.Buffer
+			root.SetOut(&buf)
+			root.SetArgs(append([]string{"__completeNoDesc"}, tt.args...))
+			if err := root.Execute(); err != nil {
+				t.Fatalf("__complete %v error: %v", tt.args, err)
+			}
+			candidates := strings.Split(buf.String(), "\n")
+			contains := func(s string) bool {
+				for _, c := range candidates {
+					if c == s {
+						return true
+					}
+				}
+				return false
+			}
+			for _, want := range tt.expected {
+				if !contains(want) {
+					t.Errorf("__complete %v に %q がありません: %v", tt.args, want, candidates)
+				}
+			}
+			for _, unwanted := range tt.excluded {
+				if contains(unwanted) {
+					t.Errorf("__complete %v に %q が含まれています", tt.args, unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAllOptionsContent(t *testing.T) {
+	fs := flag.NewFlagSet("usacloud-update", flag.ContinueOnError)
+	fs.Bool("dry-run", false, "実際の実行を行わず変換結果のみ表示")
+	fs.Bool("stats", true, "統計を表示")
+	fs.String("out", "-", "出力ファイルパス")
+	fs.String("config", "", "設定ファイルパス")
+	fs.Int("jobs", 0, "ワーカー数")
+	fs.Int("passes", 1, "最大回数")
+
+	expected := `
+
+オプション:
+  --config string
+        設定ファイルパス
+  --dry-run
+        実際の実行を行わず変換結果のみ表示
+  --jobs int
+        ワーカー数
+  --out string
+        出力ファイルパス (default "-")
+  --passes int
+        最大回数 (default 1)
+  --stats
+        統計を表示 (default true)
+
+`
+	if got := helpers.GetAllOptionsContent(fs); got != expected {
+		t.Errorf("GetAllOptionsContent() = %q, expected %q", got, expected)
+	}
+}
+
+// 手動で管理しているヘルプのオプション一覧が、登録されているフラグとずれていないことを確認
+func TestOptionsContentCoversAllFlags(t *testing.T) {
+	content := helpers.GetOptionsContent()
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, "test.") {
+			return // go test のフラグ
+		}
+		if !strings.Contains(content, "\n  --"+f.Name+" ") && !strings.Contains(content, "\n  --"+f.Name+"\n") {
+			t.Errorf("--%s がヘルプのオプション一覧にありません（internal/cli/helpers/help.go）", f.Name)
+		}
+	})
+}
+
+func TestIntegratedCLI_validateLine_MissingAssumeYes(t *testing.T) {
+	cli := NewIntegratedCLI()
+
+	if result := cli.validateLine("usacloud server delete -y 123456789012", 1); result != nil {
+		t.Errorf("Expected no issue with -y, got %+v", result.Issues)
+	}
+	result := cli.validateLine("usacloud server shutdown 123456789012", 2)
+	if result == nil || len(result.Issues) != 1 {
+		t.Fatalf("Expected one issue for a shutdown without -y, got %+v", result)
+	}
+	issue := result.Issues[0]
+	if issue.Type != IssueMissingAssumeYes || issue.EffectiveSeverity() != severityWarning || !strings.Contains(issue.Message, "-y") {
+		t.Errorf("Unexpected issue: %+v", issue)
+	}
+	if result.HasErrors() {
+		t.Error("A missing -y should only be reported as a warning")
+	}
+
+	// 警告のみのため --validate-only は失敗しない
+	cli.config.Quiet = true
+	if err := cli.performValidationOnly([]string{"usacloud server shutdown 123456789012"}); err != nil {
+		t.Errorf("Expected a missing -y not to fail validation, got: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/transform"
+)
+
+// migrationEntry は移行レポートの1コマンド（継続行で続くコマンドは先頭の行番号で表す）
+type migrationEntry struct {
+	LineNumber int
+	Command    string   // 変換前のコマンド（空白は1つにまとめる）
+	Rules      []string // 適用された変換ルール（適用順・重複なし）
+	Reason     string   // 手動対応が必要な理由
+}
+
+// fileMigration は1ファイルの usacloud コマンドを移行の状況ごとに分類したもの
+type fileMigration struct {
+	Path     string
+	Current  []migrationEntry // 変換の必要がない（v1 の構文の）コマンド
+	Migrated []migrationEntry // 自動で変換したコマンド
+	Manual   []migrationEntry // 自動で移行できず手動対応が必要なコマンド
+}
+
+// mixed は v1 のコマンドと変換が必要なコマンドが混在しているかを返す
+func (r fileMigration) mixed() bool {
+	return len(r.Current) > 0 && len(r.Migrated)+len(r.Manual) > 0
+}
+
+// validateMigrationReportConfig は --migration-report が変換モードで指定されているか確認
+func validateMigrationReportConfig(cfg *Config) error {
+	if cfg.MigrationReport == "" {
+		return nil
+	}
+	if cfg.SandboxMode || cfg.ValidateOnly || cfg.InteractiveMode || cfg.Watch || cfg.DryRun || cfg.ReverseMode {
+		return fmt.Errorf("--migration-report は変換モードでのみ指定できます（--sandbox / --validate-only / --interactive-mode / --watch / --dry-run / --reverse とは同時に指定できません）")
+	}
+	if cfg.MigrationReport == "-" && cfg.OutputPath == "-" && !cfg.Recursive && !cfg.InPlace {
+		return fmt.Errorf("--migration-report - は変換結果を標準出力に出力する場合は指定できません（--out で出力先を指定してください）")
+	}
+	return nil
+}
+
+// classifyMigration は処理結果の usacloud コマンドを、対応済み・移行済み・手動対応が必要の3つに分類
+// 変換ルールが要確認とした変更（v1 に代替のないコマンドのコメントアウトや注記のみの変更）と、
+// 変換後も廃止コマンドのまま残ったコマンドを手動対応が必要とする
+func (cli *IntegratedCLI) classifyMigration(path string, results []*ProcessResult) fileMigration {
+	report := fileMigration{Path: path}
+	parser := cli.newParser()
+
+	lines := make([]string, len(results))
+	for i, r := range results {
+		lines[i] = r.OriginalLine
+	}
+	for _, l := range transform.JoinContinuations(lines) {
+		if l.Heredoc && !cli.config.TransformHeredocs {
+			continue
+		}
+		head := results[l.Start].TransformResult
+		command := l.Text()
+		// 長すぎる行は変換・検証せずに出力したため、usacloud コマンドかどうかにかかわらず手動対応とする
+		if len(cli.oversizedParts(l)) > 0 {
+			report.Manual = append(report.Manual, migrationEntry{LineNumber: results[l.Start].LineNumber, Command: strings.Join(strings.Fields(command), " "), Reason: "未処理（行が長すぎる）"})
+			continue
+		}
+		if len(head.Changes) == 0 && !parser.IsUsacloudCommand(command) {
+			continue
+		}
+
+		entry := migrationEntry{LineNumber: results[l.Start].LineNumber, Command: strings.Join(strings.Fields(command), " ")}
+		seen := make(map[string]bool)
+		for _, c := range head.Changes {
+			if !seen[c.RuleName] {
+				seen[c.RuleName] = true
+				entry.Rules = append(entry.Rules, c.RuleName)
+			}
+		}
+
+		transformed := transform.LogicalLine{Start: l.Start}
+		for _, r := range results[l.Start : l.Start+len(l.Parts)] {
+			transformed.Parts = append(transformed.Parts, r.TransformResult.Line)
+		}
+		warnings := head.Warnings()
+		remaining := cli.deprecatedCommandIn(transformed.Text())
+		switch {
+		case len(warnings) > 0:
+			entry.Reason = warnings[0].Message
+			report.Manual = append(report.Manual, entry)
+		case remaining != "":
+			entry.Reason = cli.deprecatedDetector.GetDeprecationMessage(remaining)
+			report.Manual = append(report.Manual, entry)
+		case len(entry.Rules) > 0:
+			report.Migrated = append(report.Migrated, entry)
+		default:
+			report.Current = append(report.Current, entry)
+		}
+	}
+	return report
+}
+
+// writeMigrationReports はファイルごとの移行レポートを出力（"-" は標準出力）
+func writeMigrationReports(path string, reports []fileMigration) error {
+	var b strings.Builder
+	for i, r := range reports {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		writeMigrationReport(&b, r)
+	}
+
+	if path == "-" {
+		if _, err := io.WriteString(os.Stdout, b.String()); err != nil {
+			return fmt.Errorf("移行レポートの出力に失敗しました: %s: %w", path, err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("移行レポートの出力に失敗しました: %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeMigrationReport は1ファイルの移行レポートを分類ごとに行番号付きで書き出す
+func writeMigrationReport(w io.Writer, r fileMigration) {
+	fmt.Fprintf(w, "# 移行レポート: %s\n", r.Path)
+	fmt.Fprintf(w, "対応済み: %d  移行済み: %d  手動対応が必要: %d\n", len(r.Current), len(r.Migrated), len(r.Manual))
+	if r.mixed() {
+		fmt.Fprintln(w, "⚠️  v1 のコマンドと移行が必要なコマンドが混在しています")
+	}
+
+	sections := []struct {
+		title   string
+		entries []migrationEntry
+	}{
+		{"対応済み（変換不要）", r.Current},
+		{"移行済み（自動変換）", r.Migrated},
+		{"手動対応が必要", r.Manual},
+	}
+	for _, s := range sections {
+		if len(s.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n## %s\n", s.title)
+		for _, e := range s.entries {
+			fmt.Fprintf(w, "L%-5d %s", e.LineNumber, e.Command)
+			if len(e.Rules) > 0 {
+				fmt.Fprintf(w, " [%s]", strings.Join(e.Rules, ", "))
+			}
+			fmt.Fprintln(w)
+			if e.Reason != "" {
+				fmt.Fprintf(w, "       → %s\n", e.Reason)
+			}
+		}
+	}
+}
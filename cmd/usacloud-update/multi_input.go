@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/provenance"
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/fatih/color"
+)
+
+// inputListFlag は繰り返し指定できる入力ファイルパスのフラグ（未指定時は標準入力）
+type inputListFlag []string
+
+func (f *inputListFlag) String() string {
+	return strings.Join(f.Paths(), ",")
+}
+
+func (f *inputListFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// Paths は指定された入力パスを指定順に返す（未指定時は標準入力 "-" のみ）
+func (f *inputListFlag) Paths() []string {
+	if f == nil || len(*f) == 0 {
+		return []string{"-"}
+	}
+	return *f
+}
+
+// inputList は繰り返し指定できる入力ファイルパスのフラグを登録
+func inputList(name, usage string) *inputListFlag {
+	f := &inputListFlag{}
+	flag.Var(f, name, usage)
+	return f
+}
+
+// validateMultiInputConfig は複数の --in と他のオプションの組み合わせを確認
+func validateMultiInputConfig(cfg *Config) error {
+	if len(cfg.InputPaths) <= 1 {
+		return nil
+	}
+	if cfg.Recursive || cfg.InPlace {
+		return fmt.Errorf("複数の --in と --recursive / --in-place は同時に指定できません")
+	}
+	if cfg.DiffMode || cfg.ProvenancePath != "" {
+		return fmt.Errorf("複数の --in と --diff / --provenance は同時に指定できません")
+	}
+	if cfg.ValidateOnly || cfg.InteractiveMode || cfg.SandboxMode {
+		return fmt.Errorf("複数の --in は変換モードでのみ指定できます")
+	}
+	return nil
+}
+
+// sourceHeader は連結した出力で各入力ファイルの変換結果の先頭に置くコメント
+func sourceHeader(path string) string {
+	if path == "-" {
+		path = "stdin"
+	}
+	return "# ---- source: " + path + " ----"
+}
+
+// runMultiInputMode は複数の入力ファイルを指定順に変換し、1つの出力に連結
+// 生成ヘッダーは先頭に1回だけ出力し、ファイルごとの変換結果の前に元のファイル名を示すコメントを置く
+func (cli *IntegratedCLI) runMultiInputMode() error {
+	// ファイルごとに入力パスを差し替えて既存の読み込み・変換処理を再利用する
+	original := *cli.config
+	defer func() { *cli.config = original }()
+
+	var results []*ProcessResult
+	var total transform.Stats
+	var deprecated []deprecatedOccurrence
+	var changes []provenance.ChangeRow
+	var migrations []fileMigration
+	finishProgress := cli.startProgress(len(original.InputPaths))
+	defer finishProgress()
+	for _, path := range original.InputPaths {
+		cli.config.InputPath = path
+		cli.progress.SetLabel(path)
+		if cli.config.ShowStats {
+			fmt.Fprintf(cli.stderr(), color.CyanString("📄 %s\n"), path)
+		}
+
+		lines, err := cli.readInputFile()
+		if err != nil {
+			return fmt.Errorf("入力ファイル読み込みエラー: %w", err)
+		}
+		lines, err = cli.checkAlreadyProcessed(lines)
+		if err != nil {
+			return err
+		}
+		processed, err := cli.processLines(lines)
+		if err != nil {
+			return fmt.Errorf("処理エラー: %s: %w", path, err)
+		}
+		total.Merge(cli.stats)
+		deprecated = append(deprecated, cli.collectDeprecated(cli.inputName(), processed)...)
+		changes = append(changes, cli.collectChanges(cli.inputName(), processed)...)
+		migrations = append(migrations, cli.classifyMigration(cli.inputName(), processed))
+
+		header := sourceHeader(cli.inputName())
+		results = append(results, &ProcessResult{OriginalLine: header, TransformResult: &transform.Result{Original: header, Line: header}})
+		results = append(results, processed...)
+		cli.progress.Add(1)
+	}
+	finishProgress()
+	cli.stats = total
+
+	// パーミッションは最初の入力ファイルから引き継ぐ
+	cli.config.InputPath = original.InputPaths[0]
+	if err := cli.generateOutput(results); err != nil {
+		return err
+	}
+	if cli.config.ChangesCSVPath != "" {
+		if err := cli.writeChangesCSV(changes); err != nil {
+			return err
+		}
+	}
+	if cli.config.MigrationReport != "" {
+		if err := writeMigrationReports(cli.config.MigrationReport, migrations); err != nil {
+			return err
+		}
+	}
+
+	if cli.config.ShowStats {
+		writeTransformStats(os.Stderr, cli.stats)
+	}
+	fmt.Println("✅ 変換完了")
+	if err := cli.finishStatsBaseline(cli.stats); err != nil {
+		return err
+	}
+	return deprecatedFailure(deprecated)
+}
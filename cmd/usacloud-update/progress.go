@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/armaniacs/usacloud-update/internal/cli/progress"
+	"github.com/armaniacs/usacloud-update/internal/config"
+)
+
+// progressLineThreshold はこの行数以上のファイルで行単位の進捗を表示する
+const progressLineThreshold = 1000
+
+// resolveProgressSettings は設定ファイルの [output] から進捗表示の設定を決定（未指定・読み込み失敗時は既定値）
+func resolveProgressSettings() (bool, string) {
+	output := config.NewIntegratedConfig().Output
+	if fileCfg, err := readIntegratedConfig(); err == nil && fileCfg != nil {
+		output = fileCfg.Output
+	}
+	return output.ShowProgress, output.ProgressStyle
+}
+
+// newProgress は total 件の進捗を標準エラー出力に表示するバーを作成
+// show_progress が無効、標準エラー出力が端末でない、または CI=true の場合は nil（何も表示しない）
+func newProgress(cfg *Config, total int) *progress.Bar {
+	if !cfg.ShowProgress || !progress.Enabled(os.Stderr) {
+		return nil
+	}
+	return progress.New(os.Stderr, total, progress.Options{Style: cfg.ProgressStyle, Color: cfg.ColorEnabled})
+}
+
+// stderr は処理中のメッセージの出力先（進捗の表示中は進捗の行を消してから出力する）
+func (cli *IntegratedCLI) stderr() io.Writer {
+	if cli.progress != nil {
+		return cli.progress.Bypass()
+	}
+	return os.Stderr
+}
+
+// startProgress は total 件（ファイル数または行数）の進捗の表示を開始し、表示を終了する関数を返す
+func (cli *IntegratedCLI) startProgress(total int) func() {
+	cli.progress = newProgress(cli.config, total)
+	return func() {
+		cli.progress.Finish()
+		cli.progress = nil
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	cliio "github.com/armaniacs/usacloud-update/internal/cli/io"
+	"github.com/armaniacs/usacloud-update/internal/provenance"
+	"github.com/armaniacs/usacloud-update/internal/risk"
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/fatih/color"
+)
+
+// Per-file outcomes of a recursive run
+const (
+	fileStatusConverted = "converted"
+	fileStatusSkipped   = "skipped"
+	fileStatusFailed    = "failed"
+)
+
+// FileResult は再帰処理における1ファイルの処理結果
+type FileResult struct {
+	InputPath    string
+	OutputPath   string
+	Status       string
+	ChangedLines int
+	Stats        transform.Stats
+	Deprecated   []deprecatedOccurrence
+	Changes      []provenance.ChangeRow
+	Risk         risk.FileRisk
+	Migration    fileMigration
+	Err          error
+}
+
+// validateRecursiveConfig は --recursive 関連オプションの組み合わせを確認
+func validateRecursiveConfig(cfg *Config) error {
+	if !cfg.Recursive && cfg.Include != "" {
+		return fmt.Errorf("--include は --recursive と併せて指定してください")
+	}
+	if !cfg.Recursive && len(cfg.Exclude) > 0 {
+		return fmt.Errorf("--exclude は --recursive と併せて指定してください")
+	}
+	if cfg.RiskReportPath != "" {
+		if !cfg.Recursive {
+			return fmt.Errorf("--risk-report は --recursive と併せて指定してください")
+		}
+		if cfg.RiskReportFormat != "text" && cfg.RiskReportFormat != "json" {
+			return fmt.Errorf("無効なリスクレポートの出力形式です: %s (text/json のいずれかを指定してください)", cfg.RiskReportFormat)
+		}
+	}
+
+	if !cfg.Recursive {
+		return nil
+	}
+	if cfg.OutputPath != "-" {
+		return fmt.Errorf("--recursive では --out は指定できません（出力は元ファイルの隣に %s を付けて書き出すか、--in-place で上書きします）", cliio.UpdatedSuffix)
+	}
+	if cfg.DiffMode || cfg.ProvenancePath != "" {
+		return fmt.Errorf("--recursive と --diff / --provenance は同時に指定できません")
+	}
+	for _, pattern := range cfg.Exclude {
+		if err := cliio.ValidateExcludePattern(pattern); err != nil {
+			return err
+		}
+	}
+	return cliio.ValidateIncludePattern(cfg.Include)
+}
+
+// isRecursiveInput は入力パスをディレクトリとして再帰処理するか判定
+func (cli *IntegratedCLI) isRecursiveInput() bool {
+	if !cli.config.Recursive || cli.config.InputPath == "-" {
+		return false
+	}
+	info, err := os.Stat(cli.config.InputPath)
+	return err == nil && info.IsDir()
+}
+
+// runRecursiveMode はディレクトリ配下の対象スクリプトをすべて変換
+func (cli *IntegratedCLI) runRecursiveMode() error {
+	root := cli.config.InputPath
+	// 除外パターンに一致するファイルとディレクトリはバイナリ判定などの前に走査の段階で除く
+	paths, excluded, err := cliio.FindScriptsExcluding(root, cli.config.Include, cli.config.Exclude)
+	if err != nil {
+		return fmt.Errorf("ディレクトリの走査に失敗しました: %s: %w", root, err)
+	}
+
+	// ファイルごとに入出力パスを差し替えて既存の変換処理を再利用する
+	original := *cli.config
+	defer func() { *cli.config = original }()
+
+	var results []FileResult
+	finishProgress := cli.startProgress(len(paths))
+	for _, path := range paths {
+		cli.progress.SetLabel(path)
+		results = append(results, cli.convertFile(path))
+		cli.progress.Add(1)
+	}
+	finishProgress()
+
+	writeRecursiveSummary(os.Stderr, root, results, excluded)
+	var total transform.Stats
+	for _, r := range results {
+		total.Merge(r.Stats)
+	}
+	if cli.config.ShowStats {
+		writeTransformStats(os.Stderr, total)
+	}
+
+	if cli.config.ChangesCSVPath != "" {
+		var changes []provenance.ChangeRow
+		for _, r := range results {
+			changes = append(changes, r.Changes...)
+		}
+		if err := cli.writeChangesCSV(changes); err != nil {
+			return err
+		}
+	}
+
+	if cli.config.RiskReportPath != "" {
+		if err := writeRiskReport(cli.config.RiskReportPath, cli.config.RiskReportFormat, results); err != nil {
+			return fmt.Errorf("リスクレポートの出力に失敗しました: %s: %w", cli.config.RiskReportPath, err)
+		}
+	}
+
+	if cli.config.MigrationReport != "" {
+		var reports []fileMigration
+		for _, r := range results {
+			if r.Status == fileStatusConverted {
+				reports = append(reports, r.Migration)
+			}
+		}
+		if err := writeMigrationReports(cli.config.MigrationReport, reports); err != nil {
+			return err
+		}
+	}
+
+	var failed int
+	var deprecated []deprecatedOccurrence
+	for _, r := range results {
+		if r.Status == fileStatusFailed {
+			failed++
+		}
+		deprecated = append(deprecated, r.Deprecated...)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d個のファイルの変換に失敗しました", failed)
+	}
+	if err := cli.finishStatsBaseline(total); err != nil {
+		return err
+	}
+	return deprecatedFailure(deprecated)
+}
+
+// convertFile は1ファイルを変換し、元ファイルの隣または同じパスに書き出す
+func (cli *IntegratedCLI) convertFile(path string) FileResult {
+	result := FileResult{InputPath: path, OutputPath: path + cliio.UpdatedSuffix}
+	if cli.config.InPlace {
+		result.OutputPath = path
+	}
+	cli.config.InputPath = result.InputPath
+	cli.config.OutputPath = result.OutputPath
+
+	if cli.config.ShowStats {
+		fmt.Fprintf(cli.stderr(), color.CyanString("📄 %s\n"), path)
+	}
+
+	// --in-place ではツリーの外にあるかもしれないシンボリックリンクの先を書き換えない
+	if cli.config.InPlace {
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			result.Status, result.Err = fileStatusSkipped, fmt.Errorf("シンボリックリンク（--in-place ではリンク先を書き換えません）")
+			return result
+		}
+	}
+
+	// バイナリファイルと空のファイルは変換対象外としてスキップ
+	if err := cliio.DetectBinaryFile(path); cliio.IsBinaryFileError(err) {
+		result.Status, result.Err = fileStatusSkipped, err
+		return result
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() == 0 {
+		result.Status, result.Err = fileStatusSkipped, fmt.Errorf("空のファイル")
+		return result
+	}
+
+	lines, err := cli.readInputFile()
+	if err != nil {
+		result.Status, result.Err = fileStatusFailed, err
+		return result
+	}
+	// 変換済みのファイル（前回の .updated など）は --force がなければスキップ
+	lines, err = cli.checkAlreadyProcessed(lines)
+	if err != nil {
+		result.Status, result.Err = fileStatusSkipped, err
+		return result
+	}
+
+	processed, err := cli.processLines(lines)
+	if err != nil {
+		result.Status, result.Err = fileStatusFailed, err
+		return result
+	}
+	// 変更のないファイルは書き出さず、バックアップも作らない
+	if cli.stats.ChangedLines == 0 {
+		result.OutputPath = ""
+	} else if err := cli.generateOutput(processed); err != nil {
+		result.Status, result.Err = fileStatusFailed, err
+		return result
+	}
+
+	result.Stats = cli.stats
+	result.ChangedLines = cli.stats.ChangedLines
+	result.Deprecated = cli.collectDeprecated(path, processed)
+	result.Changes = cli.collectChanges(path, processed)
+	result.Risk = cli.assessRisk(path, processed)
+	result.Migration = cli.classifyMigration(path, processed)
+	result.Status = fileStatusConverted
+	return result
+}
+
+// writeRecursiveSummary はファイルごとの処理結果と合計を表示
+// excluded は --exclude で走査から除いたファイルとディレクトリの数
+func writeRecursiveSummary(w io.Writer, root string, results []FileResult, excluded int) {
+	var converted, skipped, failed int
+
+	fmt.Fprintf(w, "\n📋 %s の変換結果 (%dファイル)\n", root, len(results))
+	for _, r := range results {
+		switch r.Status {
+		case fileStatusConverted:
+			converted++
+			if r.OutputPath == "" {
+				fmt.Fprintf(w, "  ✅ %s (変更なし)\n", r.InputPath)
+				continue
+			}
+			fmt.Fprintf(w, color.GreenString("  ✅ %s → %s (%d行変更)\n"), r.InputPath, r.OutputPath, r.ChangedLines)
+		case fileStatusSkipped:
+			skipped++
+			fmt.Fprintf(w, color.YellowString("  ⏭️  %s: スキップ (%v)\n"), r.InputPath, r.Err)
+		default:
+			failed++
+			fmt.Fprintf(w, color.RedString("  ❌ %s: %v\n"), r.InputPath, r.Err)
+		}
+	}
+	fmt.Fprintf(w, "変換: %d  スキップ: %d  失敗: %d", converted, skipped, failed)
+	if excluded > 0 {
+		fmt.Fprintf(w, "  除外: %d", excluded)
+	}
+	fmt.Fprintln(w)
+}
+
+// assessRisk は変換・検証結果から手動対応が必要になりそうな箇所を数える
+func (cli *IntegratedCLI) assessRisk(path string, processed []*ProcessResult) risk.FileRisk {
+	fr := risk.FileRisk{Path: path}
+
+	for _, p := range processed {
+		for _, c := range p.TransformResult.Changes {
+			if transform.RequiresManualReview(c.RuleName) {
+				fr.ManualReview++
+				break
+			}
+		}
+
+		if p.ValidationResult == nil {
+			continue
+		}
+		var invalidCommand bool
+		for _, issue := range p.ValidationResult.Issues {
+			switch issue.Type {
+			case IssueDeprecatedCommand:
+				if cli.deprecatedDetector.GetReplacementCommand(issue.Component) == "" {
+					fr.UnmappableDeprecations++
+				}
+			case IssueInvalidMainCommand, IssueInvalidSubCommand:
+				invalidCommand = true
+			}
+		}
+		if invalidCommand {
+			var best float64
+			for _, s := range p.ValidationResult.Suggestions {
+				best = max(best, s.Score)
+			}
+			if best < risk.LowConfidenceScore {
+				fr.LowConfidenceSuggestions++
+			}
+		}
+	}
+
+	return fr
+}
+
+// writeRiskReport は変換できたファイルを移行リスクの高い順に並べて出力（"-" は標準出力）
+func writeRiskReport(path, format string, results []FileResult) error {
+	var files []risk.FileRisk
+	for _, r := range results {
+		if r.Status == fileStatusConverted {
+			files = append(files, r.Risk)
+		}
+	}
+	ranked := risk.Rank(files)
+
+	write := risk.WriteText
+	if format == "json" {
+		write = risk.WriteJSON
+	}
+	if path == "-" {
+		return write(os.Stdout, ranked)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, ranked); err != nil {
+		f.Close()
+		return err
+	}
+	// 書き込みの失敗が Close で初めて分かることがあるため、そのエラーも返す
+	return f.Close()
+}
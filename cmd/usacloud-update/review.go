@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/armaniacs/usacloud-update/internal/tui"
+)
+
+// validateReviewConfig は --review と他のオプションの組み合わせを確認
+func validateReviewConfig(cfg *Config) error {
+	if !cfg.Review {
+		return nil
+	}
+	if cfg.Recursive || len(cfg.InputPaths) > 1 || cfg.Watch {
+		return fmt.Errorf("--review は1つのファイルの変換でのみ指定できます（--recursive・複数の --in・--watch とは同時に指定できません）")
+	}
+	if cfg.ValidateOnly || cfg.InteractiveMode || cfg.SandboxMode {
+		return fmt.Errorf("--review は変換モードでのみ指定できます")
+	}
+	return nil
+}
+
+// reviewChanges は変換結果を TUI で1件ずつ確認し、受け入れた変更だけを残す
+// 受け入れなかった変更の行は元の行のまま出力され、統計も確認後の結果で集計し直す
+func (cli *IntegratedCLI) reviewChanges(results []*ProcessResult) error {
+	lines := make([]string, len(results))
+	transformed := make([]transform.Result, len(results))
+	for i, r := range results {
+		lines[i] = r.OriginalLine
+		transformed[i] = *r.TransformResult
+	}
+
+	var reviewed []transform.Result
+	reviewer := tui.NewChangeReviewer(lines, transformed)
+	reviewer.SetOnApply(func(r []transform.Result) {
+		reviewed = r
+	})
+	if err := reviewer.Run(); err != nil {
+		return fmt.Errorf("変更の確認画面を起動できません: %w", err)
+	}
+	if reviewed == nil {
+		return fmt.Errorf("変更の確認をキャンセルしました（出力は書き込んでいません）")
+	}
+
+	cli.applyReviewedResults(results, reviewed)
+	return nil
+}
+
+// applyReviewedResults は確認後の変換結果を処理結果に反映し、統計を集計し直す
+func (cli *IntegratedCLI) applyReviewedResults(results []*ProcessResult, reviewed []transform.Result) {
+	cli.stats = transform.Stats{}
+	for i, r := range results {
+		transformResult := reviewed[i]
+		r.TransformResult = &transformResult
+		cli.stats.Add(transformResult)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/cli/exit"
+	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/armaniacs/usacloud-update/internal/sandbox"
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// fileDestructiveCommands are the destructive commands found in one file of a batch run
+type fileDestructiveCommands struct {
+	Path     string
+	Commands []sandbox.DestructiveCommand
+}
+
+// confirmDestructiveCommands lists the destructive commands a batch run would
+// execute and asks once whether to proceed. Without a terminal to ask on,
+// such as when the script itself was read from stdin, the run is aborted;
+// --yes skips the check.
+func confirmDestructiveCommands(in io.Reader, out io.Writer, canPrompt bool, files []fileDestructiveCommands) error {
+	total := 0
+	for _, f := range files {
+		total += len(f.Commands)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(out, color.YellowString("⚠️  %d destructive command(s) will be executed:\n"), total)
+	for _, f := range files {
+		for _, c := range f.Commands {
+			fmt.Fprintf(out, "  %s:%d: %s [%s]\n", f.Path, c.LineNumber, c.Command, c.Operation)
+		}
+	}
+
+	if !canPrompt {
+		return exit.New(exit.Config, fmt.Errorf("destructive commands require confirmation; re-run with --yes to execute them without a prompt"))
+	}
+	fmt.Fprint(out, "Proceed? [y/N]: ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return exit.New(exit.Generic, fmt.Errorf("aborted: destructive commands were not confirmed"))
+}
+
+// sandboxScript is a script of a batch run with the lines it would execute
+type sandboxScript struct {
+	Path  string
+	Lines []string
+}
+
+// confirmBatchExecution checks the destructive commands of the scripts of a
+// batch run before anything is executed, exiting if they are not confirmed.
+// Dry runs execute nothing and are not checked.
+func confirmBatchExecution(cfg *config.SandboxConfig, scripts []sandboxScript) {
+	if *assumeYes || cfg.DryRun {
+		return
+	}
+
+	executor := sandbox.NewExecutor(cfg)
+	var found []fileDestructiveCommands
+	for _, s := range scripts {
+		if commands := executor.FindDestructiveCommands(s.Lines); len(commands) > 0 {
+			found = append(found, fileDestructiveCommands{Path: s.Path, Commands: commands})
+		}
+	}
+
+	// A script piped to stdin leaves no terminal to read the answer from
+	canPrompt := term.IsTerminal(int(os.Stdin.Fd()))
+	if err := confirmDestructiveCommands(os.Stdin, os.Stderr, canPrompt, found); err != nil {
+		fmt.Fprintf(os.Stderr, color.RedString("Error: %v\n"), err)
+		os.Exit(exit.Code(err))
+	}
+}
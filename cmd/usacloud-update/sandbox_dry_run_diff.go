@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/armaniacs/usacloud-update/internal/sandbox"
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/fatih/color"
+)
+
+// dryRunDiffRow is one line of the --dry-run-diff table
+type dryRunDiffRow struct {
+	LineNumber  int
+	Original    string
+	Transformed string // without the inline change comment; empty when the line is unchanged
+	Plan        sandbox.LinePlan
+}
+
+// planDryRunDiff transforms each line as the interactive sandbox does and asks
+// the executor whether the result would be run. Blank lines are left out.
+func planDryRunDiff(engine *transform.Engine, executor *sandbox.Executor, lines []string) []dryRunDiffRow {
+	var rows []dryRunDiffRow
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		result := engine.Apply(line)
+		row := dryRunDiffRow{LineNumber: i + 1, Original: line, Plan: executor.PlanLine(result.Line)}
+		if result.Changed {
+			row.Transformed = withoutChangeComment(result.Line)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// writeDryRunDiff prints the rows as an aligned table followed by the number
+// of commands that would be executed and skipped
+func writeDryRunDiff(w io.Writer, source string, rows []dryRunDiffRow) {
+	fmt.Fprint(w, color.CyanString("🔍 Dry run diff: %s (nothing is executed)\n\n", source))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LINE\tORIGINAL\tTRANSFORMED\tACTION")
+	execute := 0
+	for _, row := range rows {
+		transformed := row.Transformed
+		if transformed == "" {
+			transformed = "(unchanged)"
+		}
+		action := "skip: " + row.Plan.SkipReason
+		if row.Plan.Execute {
+			action = "execute"
+			execute++
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", row.LineNumber, tableCell(row.Original), tableCell(transformed), action)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d to execute, %d skipped\n\n", execute, len(rows)-execute)
+}
+
+// tableCell keeps tabs in a line from breaking the table columns
+func tableCell(s string) string {
+	return strings.ReplaceAll(strings.TrimSpace(s), "\t", " ")
+}
+
+// withoutChangeComment drops the "# usacloud-update:" comment the engine
+// appends to changed lines, which would make the table too wide to read
+func withoutChangeComment(line string) string {
+	if i := strings.LastIndex(line, " # usacloud-update:"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
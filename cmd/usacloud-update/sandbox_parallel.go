@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/armaniacs/usacloud-update/internal/sandbox"
+	"github.com/fatih/color"
+)
+
+// fileExecution is the sandbox outcome of one file in a multi-file run
+type fileExecution struct {
+	Path    string
+	Results []*sandbox.ExecutionResult
+	Err     error
+}
+
+// executeFiles runs execute for each file with up to parallel files at a time.
+// The executions are returned in the order of filePaths regardless of which
+// finished first, and a failing file never stops the others.
+func executeFiles(filePaths []string, parallel int, execute func(path string) ([]*sandbox.ExecutionResult, error)) []fileExecution {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	executions := make([]fileExecution, len(filePaths))
+	semaphore := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, path := range filePaths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results, err := execute(path)
+			executions[i] = fileExecution{Path: path, Results: results, Err: err}
+		}(i, path)
+	}
+	wg.Wait()
+
+	return executions
+}
+
+// executeSandboxFile reads and executes one file with its own executor, so
+// concurrent files never share executor state
+func executeSandboxFile(newExecutor func() *sandbox.Executor) func(path string) ([]*sandbox.ExecutionResult, error) {
+	return func(path string) ([]*sandbox.ExecutionResult, error) {
+		lines, err := readFileLines(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file %s: %w", path, err)
+		}
+		results, err := newExecutor().ExecuteScript(lines)
+		if err != nil {
+			return nil, fmt.Errorf("error executing file %s: %w", path, err)
+		}
+		return results, nil
+	}
+}
+
+// writeFileExecutionReport prints the per-file summaries in input order and
+// returns all results for the overall summary
+func writeFileExecutionReport(w io.Writer, executions []fileExecution) []*sandbox.ExecutionResult {
+	var allResults []*sandbox.ExecutionResult
+	for i, e := range executions {
+		fmt.Fprintf(w, color.BlueString("📄 File %d/%d: %s\n"), i+1, len(executions), e.Path)
+		if e.Err != nil {
+			fmt.Fprintf(w, color.RedString("  %v\n\n"), e.Err)
+			continue
+		}
+
+		allResults = append(allResults, e.Results...)
+
+		succeeded, failed, skipped := 0, 0, 0
+		for _, result := range e.Results {
+			if result.Skipped {
+				skipped++
+			} else if result.Success {
+				succeeded++
+			} else {
+				failed++
+			}
+		}
+		fmt.Fprintf(w, "  ✅ %d successful, ❌ %d failed, ⏭️  %d skipped\n\n", succeeded, failed, skipped)
+	}
+	return allResults
+}
+
+// fileExecutionsFailed reports whether any file could not be executed or
+// contains a failed command
+func fileExecutionsFailed(executions []fileExecution) bool {
+	for _, e := range executions {
+		if e.Err != nil {
+			return true
+		}
+		for _, result := range e.Results {
+			if !result.Success && !result.Skipped {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/armaniacs/usacloud-update/internal/sandbox"
+	"github.com/armaniacs/usacloud-update/internal/transform"
+)
+
+// sandboxReportCounts are the command counts of a file or of the whole run
+type sandboxReportCounts struct {
+	Total     int `json:"total"`
+	Executed  int `json:"executed"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+}
+
+// sandboxReportCommand is the outcome of one line in the --report=json output
+type sandboxReportCommand struct {
+	LineNumber  int    `json:"line_number"`
+	Original    string `json:"original"`
+	Transformed string `json:"transformed,omitempty"` // conversion of the line; omitted when unchanged
+	Success     bool   `json:"success"`
+	Skipped     bool   `json:"skipped"`
+	SkipReason  string `json:"skip_reason,omitempty"`
+	Stdout      string `json:"stdout"`
+	Stderr      string `json:"stderr"`
+	Error       string `json:"error,omitempty"`
+	Attempts    int    `json:"attempts,omitempty"`
+	TimedOut    bool   `json:"timed_out,omitempty"`
+	DurationMS  int64  `json:"duration_ms"`
+}
+
+// sandboxReportFile is the outcome of one input file
+type sandboxReportFile struct {
+	Path     string                 `json:"path"`
+	Error    string                 `json:"error,omitempty"` // the file could not be read or executed
+	Summary  sandboxReportCounts    `json:"summary"`
+	Commands []sandboxReportCommand `json:"commands"`
+}
+
+// sandboxReport is the --report=json output of a batch sandbox run
+type sandboxReport struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	Summary     sandboxReportCounts `json:"summary"`
+	Files       []sandboxReportFile `json:"files"`
+}
+
+// add counts one result
+func (c *sandboxReportCounts) add(result *sandbox.ExecutionResult) {
+	c.Total++
+	switch {
+	case result.Skipped:
+		c.Skipped++
+	case result.Success:
+		c.Executed++
+		c.Succeeded++
+	default:
+		c.Executed++
+		c.Failed++
+	}
+}
+
+// merge adds the counts of o
+func (c *sandboxReportCounts) merge(o sandboxReportCounts) {
+	c.Total += o.Total
+	c.Executed += o.Executed
+	c.Succeeded += o.Succeeded
+	c.Failed += o.Failed
+	c.Skipped += o.Skipped
+}
+
+// buildSandboxReport builds the report of the executions in input order. The
+// results hold one entry per line, so the line number is the result index.
+func buildSandboxReport(engine *transform.Engine, executions []fileExecution, generatedAt time.Time) sandboxReport {
+	report := sandboxReport{GeneratedAt: generatedAt, Files: make([]sandboxReportFile, 0, len(executions))}
+	for _, e := range executions {
+		file := sandboxReportFile{Path: e.Path, Commands: make([]sandboxReportCommand, 0, len(e.Results))}
+		if e.Err != nil {
+			file.Error = e.Err.Error()
+		}
+		for i, result := range e.Results {
+			file.Summary.add(result)
+			command := sandboxReportCommand{
+				LineNumber: i + 1,
+				Original:   result.Command,
+				Success:    result.Success,
+				Skipped:    result.Skipped,
+				SkipReason: result.SkipReason,
+				Stdout:     result.Stdout,
+				Stderr:     result.Stderr,
+				Error:      result.Error,
+				Attempts:   result.Attempts,
+				TimedOut:   result.TimedOut,
+				DurationMS: result.Duration.Milliseconds(),
+			}
+			if converted := engine.Apply(result.Command); converted.Changed {
+				command.Transformed = converted.Line
+			}
+			file.Commands = append(file.Commands, command)
+		}
+		report.Summary.merge(file.Summary)
+		report.Files = append(report.Files, file)
+	}
+	return report
+}
+
+// writeSandboxReport writes the --report output of the executions to --report-out
+func writeSandboxReport(executions []fileExecution) error {
+	report := buildSandboxReport(transform.NewDefaultEngine(), executions, time.Now())
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	data = append(data, '\n')
+
+	if *reportOut == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(*reportOut, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "📝 Report written to %s\n", *reportOut)
+	return nil
+}
+
+// reportToStdout reports whether --report takes stdout, in which case the
+// command outputs are left to the report
+func reportToStdout() bool {
+	return *reportFormat != "" && *reportOut == "-"
+}
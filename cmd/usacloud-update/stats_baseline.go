@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/armaniacs/usacloud-update/internal/cli/exit"
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/fatih/color"
+)
+
+// statsDriftError は変換統計がベースラインから許容範囲を超えて変化したことを表す
+type statsDriftError struct {
+	BaselinePath string
+	Tolerance    float64
+	Drifts       []transform.StatsDrift
+}
+
+func (e *statsDriftError) Error() string {
+	return fmt.Sprintf("変換統計がベースライン %s から%d項目で変化しました (許容範囲 %.0f%%)", e.BaselinePath, len(e.Drifts), e.Tolerance*100)
+}
+
+// ExitCode は変換統計がベースラインから外れた場合の終了コード
+func (e *statsDriftError) ExitCode() int {
+	return exit.StatsDrift
+}
+
+// validateStatsBaselineConfig は変換統計ベースライン関連オプションの組み合わせを確認
+func validateStatsBaselineConfig(cfg *Config) error {
+	if cfg.DumpStatsBaseline == "" && cfg.CompareStatsBaseline == "" {
+		return nil
+	}
+	if cfg.DumpStatsBaseline != "" && cfg.CompareStatsBaseline != "" {
+		return fmt.Errorf("--dump-stats-baseline と --compare-stats-baseline は同時に指定できません")
+	}
+	if cfg.ValidateOnly || cfg.InteractiveMode || cfg.SandboxMode {
+		return fmt.Errorf("--dump-stats-baseline / --compare-stats-baseline は変換モードでのみ指定できます")
+	}
+	if cfg.StatsBaselineTolerance < 0 {
+		return fmt.Errorf("--stats-baseline-tolerance には0以上の値を指定してください: %g", cfg.StatsBaselineTolerance)
+	}
+	return nil
+}
+
+// finishStatsBaseline は入力全体の変換統計をベースラインとして書き出すか、ベースラインと比較する
+func (cli *IntegratedCLI) finishStatsBaseline(stats transform.Stats) error {
+	if path := cli.config.DumpStatsBaseline; path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("ベースラインの書き込みに失敗しました: %s: %w", path, err)
+		}
+		if err := transform.WriteStatsBaseline(f, stats); err != nil {
+			f.Close()
+			return fmt.Errorf("ベースラインの書き込みに失敗しました: %s: %w", path, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("ベースラインの書き込みに失敗しました: %s: %w", path, err)
+		}
+		fmt.Fprintf(os.Stderr, "📊 変換統計のベースラインを書き出しました: %s\n", path)
+		return nil
+	}
+
+	path := cli.config.CompareStatsBaseline
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("ベースラインの読み込みに失敗しました: %s: %w", path, err)
+	}
+	defer f.Close()
+	baseline, err := transform.ReadStatsBaseline(f)
+	if err != nil {
+		return fmt.Errorf("ベースラインの読み込みに失敗しました: %s: %w", path, err)
+	}
+
+	tolerance := cli.config.StatsBaselineTolerance
+	if drifts := transform.CompareStats(baseline, stats, tolerance); len(drifts) > 0 {
+		return &statsDriftError{BaselinePath: path, Tolerance: tolerance, Drifts: drifts}
+	}
+	return nil
+}
+
+// writeStatsDriftReport はベースラインから変化した項目を一覧表示
+func writeStatsDriftReport(w io.Writer, e *statsDriftError) {
+	fmt.Fprint(w, color.RedString("\n📉 変換統計がベースラインから変化しました:\n"))
+	for _, d := range e.Drifts {
+		change := "新規"
+		if !math.IsInf(d.Change(), 1) {
+			change = fmt.Sprintf("%+.0f%%", d.Change()*100)
+		}
+		fmt.Fprintf(w, "  • %s: %d → %d (%s)\n", d.Name, d.Baseline, d.Current, change)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stdinDetector detects stdin input with a timeout without leaking reader goroutines.
+// At most one probe goroutine reads the input: one left pending by a timeout is
+// reused by the next Detect call, and read data is replayed through Reader().
+type stdinDetector struct {
+	mu       sync.Mutex
+	source   *bufio.Reader
+	pending  chan stdinProbeResult
+	consumed []byte
+}
+
+// stdinProbeResult は読み取りゴルーチンの結果
+type stdinProbeResult struct {
+	line []byte
+	err  error
+}
+
+// newStdinDetector creates a detector reading from r
+func newStdinDetector(r io.Reader) *stdinDetector {
+	return &stdinDetector{
+		source: bufio.NewReaderSize(r, 64*1024),
+	}
+}
+
+// Detect waits up to timeout for the first line of input.
+// When requireContent is true, an empty first line is treated as no input.
+func (d *stdinDetector) Detect(timeout time.Duration, requireContent bool) bool {
+	probe := d.probe()
+	select {
+	case res := <-probe:
+		d.finish(res)
+		if res.err != nil && len(res.line) == 0 {
+			return false
+		}
+		if requireContent {
+			return len(strings.TrimRight(string(res.line), "\r\n")) > 0
+		}
+		return true
+	case <-time.After(timeout):
+		// ゴルーチンは保留中のまま保持し、次回のDetectで再利用する
+		return false
+	}
+}
+
+// probe returns the pending probe channel or starts a new reader goroutine
+func (d *stdinDetector) probe() chan stdinProbeResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pending != nil {
+		return d.pending
+	}
+
+	ch := make(chan stdinProbeResult, 1)
+	d.pending = ch
+	go func() {
+		line, err := d.source.ReadBytes('\n')
+		ch <- stdinProbeResult{line: line, err: err}
+	}()
+	return ch
+}
+
+// finish records the probe result so that the next Detect starts a new probe
+func (d *stdinDetector) finish(res stdinProbeResult) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.consumed = append(d.consumed, res.line...)
+	d.pending = nil
+}
+
+// Reader returns a reader that replays consumed data followed by the remaining input
+func (d *stdinDetector) Reader() io.Reader {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return io.MultiReader(bytes.NewReader(d.consumed), d.source)
+}
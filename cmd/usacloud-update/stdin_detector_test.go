@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitGoroutines polls until the goroutine count drops to at most want
+func waitGoroutines(want int, deadline time.Duration) int {
+	end := time.Now().Add(deadline)
+	n := runtime.NumGoroutine()
+	for n > want && time.Now().Before(end) {
+		time.Sleep(10 * time.Millisecond)
+		n = runtime.NumGoroutine()
+	}
+	return n
+}
+
+func TestStdinDetector_NoLeakOnTimeout(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	pr, pw := io.Pipe()
+	detector := newStdinDetector(pr)
+
+	// 繰り返しタイムアウトしてもゴルーチンは1つだけ
+	for i := 0; i < 10; i++ {
+		if detector.Detect(5*time.Millisecond, true) {
+			t.Fatal("Detect should time out when no data is written")
+		}
+	}
+	if n := runtime.NumGoroutine(); n > base+1 {
+		t.Errorf("Expected at most %d goroutines after repeated timeouts, got %d", base+1, n)
+	}
+
+	// 保留中のゴルーチンが再利用され、データを受け取れる
+	go func() {
+		_, _ = pw.Write([]byte("usacloud server list\n"))
+		_ = pw.Close()
+	}()
+	if !detector.Detect(time.Second, true) {
+		t.Fatal("Detect should report input once data is written")
+	}
+
+	if n := waitGoroutines(base, time.Second); n > base {
+		t.Errorf("Reader goroutine leaked: expected %d goroutines, got %d", base, n)
+	}
+}
+
+func TestStdinDetector_ReplaysConsumedInput(t *testing.T) {
+	input := "usacloud server list\necho done\n"
+	detector := newStdinDetector(strings.NewReader(input))
+
+	if !detector.Detect(time.Second, true) {
+		t.Fatal("Detect should report available input")
+	}
+
+	data, err := io.ReadAll(detector.Reader())
+	if err != nil {
+		t.Fatalf("Reading replayed input failed: %v", err)
+	}
+	if string(data) != input {
+		t.Errorf("Expected replayed input %q, got %q", input, string(data))
+	}
+}
+
+func TestStdinDetector_RequireContent(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		requireContent bool
+		expected       bool
+	}{
+		{"empty input", "", true, false},
+		{"empty first line with content required", "\nusacloud server list\n", true, false},
+		{"empty first line on terminal", "\n", false, true},
+		{"line without newline", "usacloud server list", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := newStdinDetector(strings.NewReader(tt.input))
+			if got := detector.Detect(time.Second, tt.requireContent); got != tt.expected {
+				t.Errorf("Detect() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
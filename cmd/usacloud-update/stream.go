@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/armaniacs/usacloud-update/internal/cli/exit"
+	cliio "github.com/armaniacs/usacloud-update/internal/cli/io"
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/fatih/color"
+)
+
+// canStream は入力全体を読み込まずに行単位で変換できるかを判定
+// 標準入力から標準出力への変換で、行の長さに上限があり（--max-line-length）、変換結果の全体を必要とする
+// オプション（--review・--diff・--provenance など）や入力全体を見て判定するオプション
+// （--strict-validation・--fail-on-deprecated など）を指定していない場合に限る
+func (cli *IntegratedCLI) canStream() bool {
+	c := cli.config
+	if c.InputPath != "-" || len(c.InputPaths) > 1 || c.OutputPath != "-" || c.InPlace || c.MaxLineLength <= 0 {
+		return false
+	}
+	if c.Review || c.DiffMode || c.ReverseMode || c.Passes > 1 || c.NoHeader || c.Force || c.Timing {
+		return false
+	}
+	if c.ProvenancePath != "" || c.ChangesCSVPath != "" || c.MigrationReport != "" {
+		return false
+	}
+	if c.StrictValidation || c.FailOnDeprecated || c.CheckPaths || c.TransformHeredocs {
+		return false
+	}
+	if c.LineEnding != "" && c.LineEnding != cliio.LineEndingLF {
+		return false
+	}
+	for _, name := range []string{c.InputEncoding, c.OutputEncoding} {
+		if enc, err := cliio.LookupEncoding(name); err != nil || enc != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// runStreamMode は標準入力を行単位で変換して標準出力に書き出す（canStream の場合）
+// 保持するのは継続行でつながったコマンド1つ分までで、--max-line-length を超える行と
+// 継続行の合計がそれを超えるコマンドは、警告を表示して変換せずにそのまま出力する
+func (cli *IntegratedCLI) runStreamMode() error {
+	input, err := cli.fileReader.ReadInputFile("-")
+	if err != nil {
+		return exit.New(exit.IO, fmt.Errorf("入力ファイル読み込みエラー: %s", cli.cliErrorFormatter.FormatFileRead(cli.inputName(), err)))
+	}
+
+	cli.stats = transform.Stats{}
+	hooks := transform.StreamHooks{
+		Result: func(lineNumber int, result transform.Result) error {
+			// 変換済みの入力は変換しない（--force では canStream が false になり checkAlreadyProcessed で処理する）
+			if transform.IsGeneratedHeader(result.Original) {
+				return cli.alreadyProcessedError(lineNumber)
+			}
+
+			cli.stats.Add(result)
+			if len(result.Changes) > 0 && cli.config.ShowStats && !cli.config.Quiet {
+				cli.outputColorizedChange(&result, lineNumber)
+			}
+			if len(result.Changes) > 0 && cli.config.ExplainChanges {
+				cli.writeExplanations(cli.stderr(), &result, lineNumber)
+			}
+			return nil
+		},
+		Oversized: func(lineNumber, size int) {
+			fmt.Fprintf(cli.stderr(), color.YellowString("⚠️  %s:%d: 行が長すぎるため変換・検証せずにそのまま出力します (%d バイト、--max-line-length %d)\n"),
+				cli.inputName(), lineNumber, size, cli.config.MaxLineLength)
+		},
+	}
+
+	if err := cli.transformEngine.StreamWithHooks(input, os.Stdout, cli.config.MaxLineLength, hooks); err != nil {
+		if exit.CodeOr(err, exit.IO) != exit.IO {
+			return err
+		}
+		return exit.New(exit.IO, fmt.Errorf("標準入力の変換に失敗しました: %w", err))
+	}
+
+	if cli.config.ShowStats {
+		writeTransformStats(os.Stderr, cli.stats)
+	}
+	fmt.Println("✅ 変換完了")
+
+	return cli.finishStatsBaseline(cli.stats)
+}
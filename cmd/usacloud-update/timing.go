@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// timingPhase は --timing で計測する処理の段階
+type timingPhase int
+
+const (
+	phaseRead timingPhase = iota
+	phaseTransform
+	phaseValidate
+	phaseWrite
+	phaseCount
+)
+
+// timingPhaseLabels は処理の段階ごとの表示名
+var timingPhaseLabels = [phaseCount]string{"読み込み", "変換", "検証", "書き出し"}
+
+// phaseTimer は処理の段階ごとの経過時間を合計する（nil では何も計測しない）
+// 変換と検証は --jobs のワーカーから同時に加算されるため、各ワーカーの時間の合計になる
+type phaseTimer struct {
+	start     time.Time
+	durations [phaseCount]atomic.Int64
+}
+
+// newPhaseTimer は計測を開始した phaseTimer を返す
+func newPhaseTimer() *phaseTimer {
+	return &phaseTimer{start: time.Now()}
+}
+
+// record は start からの経過時間を phase に加算（defer t.record(phase, time.Now()) で関数全体を計測できる）
+func (t *phaseTimer) record(phase timingPhase, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.durations[phase].Add(int64(time.Since(start)))
+}
+
+// timingReport は --timing の計測結果（JSON 出力では metadata.timing として出力）
+type timingReport struct {
+	ReadMs      float64 `json:"read_ms"`
+	TransformMs float64 `json:"transform_ms"`
+	ValidateMs  float64 `json:"validate_ms"`
+	WriteMs     float64 `json:"write_ms"`
+	TotalMs     float64 `json:"total_ms"` // 計測開始からの経過時間
+}
+
+// report はこれまでの計測結果を返す
+func (t *phaseTimer) report() timingReport {
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	return timingReport{
+		ReadMs:      ms(time.Duration(t.durations[phaseRead].Load())),
+		TransformMs: ms(time.Duration(t.durations[phaseTransform].Load())),
+		ValidateMs:  ms(time.Duration(t.durations[phaseValidate].Load())),
+		WriteMs:     ms(time.Duration(t.durations[phaseWrite].Load())),
+		TotalMs:     ms(time.Since(t.start)),
+	}
+}
+
+// writeTimings は処理の段階ごとの経過時間と合計を表示
+func writeTimings(w io.Writer, r timingReport) {
+	fmt.Fprint(w, color.CyanString("\n⏱️  処理時間:\n"))
+	for i, v := range []float64{r.ReadMs, r.TransformMs, r.ValidateMs, r.WriteMs} {
+		fmt.Fprintf(w, "  • %s: %.3fms\n", timingPhaseLabels[i], v)
+	}
+	fmt.Fprintf(w, "  • 合計: %.3fms\n", r.TotalMs)
+}
+
+// finishTiming は --timing の計測結果を標準エラー出力に表示（--timing がなければ何もしない）
+func (cli *IntegratedCLI) finishTiming() {
+	if cli.timer == nil {
+		return
+	}
+	writeTimings(cli.stderr(), cli.timer.report())
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ttyDevicePath returns the controlling terminal device for the current platform
+func ttyDevicePath() string {
+	if runtime.GOOS == "windows" {
+		return "CONIN$"
+	}
+	return "/dev/tty"
+}
+
+// openTTY opens the controlling terminal for reading decisions (replaced in tests)
+var openTTY = func() (io.ReadCloser, error) {
+	return os.Open(ttyDevicePath())
+}
+
+// stdinIsPipe reports whether stdin is a pipe or redirect (replaced in tests)
+var stdinIsPipe = func() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) == 0
+}
+
+// decisionReader returns the reader used for interactive decisions.
+// When the script arrives on stdin, answers are read from the terminal instead,
+// the same way `git add -p` does.
+func (cli *IntegratedCLI) decisionReader() *bufio.Reader {
+	if cli.decisionInput != nil {
+		return cli.decisionInput
+	}
+
+	var r io.Reader = os.Stdin
+	if stdinIsPipe() {
+		tty, err := openTTY()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, color.YellowString("⚠️  標準入力がパイプのため端末 (%s) から応答を読み取れません: %v\n"), ttyDevicePath(), err)
+			fmt.Fprint(os.Stderr, color.YellowString("   すべての変更は適用されません。--in でファイルを指定してください\n\n"))
+			r = strings.NewReader("")
+		} else {
+			r = tty
+			cli.decisionCloser = tty
+		}
+	}
+
+	cli.decisionInput = bufio.NewReader(r)
+	return cli.decisionInput
+}
+
+// closeDecisionReader releases the terminal opened for decisions, if any
+func (cli *IntegratedCLI) closeDecisionReader() {
+	if cli.decisionCloser != nil {
+		cli.decisionCloser.Close()
+		cli.decisionCloser = nil
+	}
+	cli.decisionInput = nil
+}
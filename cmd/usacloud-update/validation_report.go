@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+)
+
+// 検証結果の重要度
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// String は問題タイプの名前を返す
+func (t IssueType) String() string {
+	switch t {
+	case IssueParseError:
+		return "ParseError"
+	case IssueInvalidMainCommand:
+		return "InvalidMainCommand"
+	case IssueInvalidSubCommand:
+		return "InvalidSubCommand"
+	case IssueDeprecatedCommand:
+		return "DeprecatedCommand"
+	case IssueSyntaxError:
+		return "SyntaxError"
+	case IssueOutputFormatMismatch:
+		return "OutputFormatMismatch"
+	case IssueMissingFile:
+		return "MissingFile"
+	case IssueInvalidOption:
+		return "InvalidOption"
+	case IssueMissingAssumeYes:
+		return "MissingAssumeYes"
+	default:
+		return "Unknown"
+	}
+}
+
+// Severity は問題タイプの重要度を返す（廃止コマンド・出力形式の不一致・存在しないファイル・-y のない確認付きコマンドは警告、それ以外はエラー）
+func (t IssueType) Severity() string {
+	switch t {
+	case IssueDeprecatedCommand, IssueOutputFormatMismatch, IssueMissingFile, IssueMissingAssumeYes:
+		return severityWarning
+	default:
+		return severityError
+	}
+}
+
+// validationReportIssue はJSONレポートにおける1件の問題
+type validationReportIssue struct {
+	Type      string `json:"type"`
+	Severity  string `json:"severity"`
+	Component string `json:"component"`
+	Message   string `json:"message"`
+}
+
+// validationReportSuggestion はJSONレポートにおける修正候補
+type validationReportSuggestion struct {
+	Command  string  `json:"command"`
+	Distance int     `json:"distance"`
+	Score    float64 `json:"score"`
+}
+
+// validationReportEntry はJSONレポートにおける1行分の検証結果
+type validationReportEntry struct {
+	File        string                       `json:"file,omitempty"` // 入力ファイル（標準入力は --stdin-filename の指定時のみ）
+	LineNumber  int                          `json:"line_number"`
+	Line        string                       `json:"line"`
+	Issues      []validationReportIssue      `json:"issues"`
+	Suggestions []validationReportSuggestion `json:"suggestions"`
+}
+
+// validationReportMetadata はJSONレポートに結果と併せて出力する情報
+type validationReportMetadata struct {
+	Timing *timingReport `json:"timing,omitempty"`
+}
+
+// validationReportWithMetadata は metadata を含むJSONレポート
+type validationReportWithMetadata struct {
+	Results  []validationReportEntry  `json:"results"`
+	Metadata validationReportMetadata `json:"metadata"`
+}
+
+// writeValidationJSON は検証結果を1つのJSON配列として書き出す（問題がなければ空配列）
+// inputPath が標準入力 ("-") の場合はファイル名を出力しない
+// metadata を指定した場合は配列の代わりに {"results": [...], "metadata": {...}} の形で書き出す
+func writeValidationJSON(w io.Writer, inputPath string, results []ValidationResult, metadata *validationReportMetadata) error {
+	file := ""
+	if inputPath != "-" {
+		file = inputPath
+	}
+
+	entries := make([]validationReportEntry, 0, len(results))
+	for _, result := range results {
+		entry := validationReportEntry{
+			File:        file,
+			LineNumber:  result.LineNumber,
+			Line:        result.Line,
+			Issues:      make([]validationReportIssue, 0, len(result.Issues)),
+			Suggestions: make([]validationReportSuggestion, 0, len(result.Suggestions)),
+		}
+		for _, issue := range result.Issues {
+			entry.Issues = append(entry.Issues, validationReportIssue{
+				Type:      issue.Type.String(),
+				Severity:  issue.EffectiveSeverity(),
+				Component: issue.Component,
+				Message:   issue.Message,
+			})
+		}
+		for _, s := range result.Suggestions {
+			entry.Suggestions = append(entry.Suggestions, validationReportSuggestion{
+				Command:  s.Command,
+				Distance: s.Distance,
+				Score:    s.Score,
+			})
+		}
+		entries = append(entries, entry)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if metadata != nil {
+		return encoder.Encode(validationReportWithMetadata{Results: entries, Metadata: *metadata})
+	}
+	return encoder.Encode(entries)
+}
+
+// SARIF 2.1.0 のスキーマとツール情報
+const (
+	sarifVersion        = "2.1.0"
+	sarifSchema         = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName       = "usacloud-update"
+	sarifInformationURI = "https://github.com/armaniacs/usacloud-update"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifRuleDescriptions は問題タイプごとのルール説明
+var sarifRuleDescriptions = map[IssueType]string{
+	IssueParseError:           "usacloudコマンドを解析できません",
+	IssueInvalidMainCommand:   "usacloudに存在しないコマンドです",
+	IssueInvalidSubCommand:    "このコマンドに存在しないサブコマンドです",
+	IssueDeprecatedCommand:    "v1で廃止されたusacloudコマンドです",
+	IssueSyntaxError:          "usacloudコマンドの構文が不正です",
+	IssueOutputFormatMismatch: "jqに渡すusacloudの出力形式がJSONではありません",
+	IssueMissingFile:          "コマンドで指定されたローカルファイルが存在しません",
+	IssueInvalidOption:        "オプションの値が不正か、コマンドにないオプションです",
+	IssueMissingAssumeYes:     "確認を求めるコマンドに -y (--assumeyes) が指定されていません",
+}
+
+// writeValidationSARIF は検証結果を SARIF 2.1.0 形式で書き出す
+// 各問題を1件の result とし、ruleId は問題タイプ、位置は入力ファイルの行番号とする
+func writeValidationSARIF(w io.Writer, inputPath string, results []ValidationResult) error {
+	uri := "stdin"
+	if inputPath != "" && inputPath != "-" {
+		uri = filepath.ToSlash(filepath.Clean(inputPath))
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           sarifToolName,
+			Version:        version,
+			InformationURI: sarifInformationURI,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	// ルールは結果に現れた問題タイプのみ、初出順に列挙する
+	seen := make(map[IssueType]bool)
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			if !seen[issue.Type] {
+				seen[issue.Type] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					ID:                   issue.Type.String(),
+					ShortDescription:     sarifMessage{Text: sarifRuleDescriptions[issue.Type]},
+					DefaultConfiguration: sarifConfiguration{Level: issue.Type.Severity()},
+				})
+			}
+
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  issue.Type.String(),
+				Level:   issue.EffectiveSeverity(),
+				Message: sarifMessage{Text: issue.Message},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: uri},
+					Region:           sarifRegion{StartLine: result.LineNumber},
+				}}},
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/armaniacs/usacloud-update/internal/cli/exit"
+	cliio "github.com/armaniacs/usacloud-update/internal/cli/io"
+	"github.com/fatih/color"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce はエディタの連続した書き込みを1回の変更としてまとめる待ち時間
+const watchDebounce = 200 * time.Millisecond
+
+// watchSummary は --watch で変換・検証した1ファイルの結果
+type watchSummary struct {
+	Path         string
+	TotalLines   int
+	ChangedLines int
+	Issues       []watchIssue // 行番号順
+	Removed      bool
+	Err          error
+}
+
+// watchIssue は --watch の要約に表示する検証の問題
+type watchIssue struct {
+	LineNumber int
+	Message    string
+	Warning    bool
+}
+
+// issueCounts はエラーと警告の件数を返す
+func (s watchSummary) issueCounts() (errorCount, warningCount int) {
+	for _, issue := range s.Issues {
+		if issue.Warning {
+			warningCount++
+		} else {
+			errorCount++
+		}
+	}
+	return errorCount, warningCount
+}
+
+// validateWatchConfig は --watch と他のオプションの組み合わせを確認
+func validateWatchConfig(cfg *Config) error {
+	if !cfg.Watch {
+		return nil
+	}
+	if len(cfg.InputPaths) > 1 {
+		return fmt.Errorf("--watch では --in は1つだけ指定できます")
+	}
+	if cfg.InputPath == "-" {
+		return fmt.Errorf("--watch には --in で監視するファイルまたはディレクトリを指定してください")
+	}
+	if cfg.OutputPath != "-" || cfg.InPlace || cfg.DiffMode || cfg.ProvenancePath != "" {
+		return fmt.Errorf("--watch と --out / --in-place / --diff / --provenance は同時に指定できません（監視中は結果の要約のみ表示します）")
+	}
+	if cfg.ValidateOnly || cfg.InteractiveMode || cfg.SandboxMode {
+		return fmt.Errorf("--watch は変換モードでのみ指定できます")
+	}
+	return nil
+}
+
+// isWatchTarget は監視対象のファイルかどうかを判定
+// ディレクトリ内では .sh ファイルのみ、--in にファイルを指定した場合はそのファイルのみ
+func isWatchTarget(root string, rootIsDir bool, path string) bool {
+	if !rootIsDir {
+		return filepath.Clean(path) == filepath.Clean(root)
+	}
+	return strings.HasSuffix(path, ".sh")
+}
+
+// runWatchMode は --in のファイルまたはディレクトリを監視し、変更のたびに変換と検証を再実行
+// 最初に全対象ファイルを処理し、以降は変更されたファイルだけを処理して前回からの差分を表示する
+func (cli *IntegratedCLI) runWatchMode() error {
+	root := cli.config.InputPath
+	info, err := os.Stat(root)
+	if err != nil {
+		return exit.New(exit.IO, fmt.Errorf("監視対象が見つかりません: %s", root))
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("ファイル監視を開始できません: %w", err)
+	}
+	defer watcher.Close()
+
+	var initial []string
+	if info.IsDir() {
+		if err := addWatchDirs(watcher, root); err != nil {
+			return fmt.Errorf("ファイル監視を開始できません: %s: %w", root, err)
+		}
+		if initial, err = cliio.FindScripts(root, "*.sh"); err != nil {
+			return fmt.Errorf("ディレクトリの走査に失敗しました: %s: %w", root, err)
+		}
+	} else {
+		// エディタは一時ファイルの rename で保存することが多いため、親ディレクトリを監視する
+		if err := watcher.Add(filepath.Dir(root)); err != nil {
+			return fmt.Errorf("ファイル監視を開始できません: %s: %w", root, err)
+		}
+		initial = []string{root}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	previous := make(map[string]watchSummary)
+	run := func(paths []string) {
+		fmt.Fprintf(os.Stdout, color.CyanString("\n🔄 %s 変換と検証を実行しました (%d ファイル)\n"), time.Now().Format("15:04:05"), len(paths))
+		for _, path := range paths {
+			summary := cli.summarizeWatchedFile(path)
+			prev, seen := previous[path]
+			writeWatchSummary(os.Stdout, summary, prev, seen)
+			if summary.Removed {
+				delete(previous, path)
+			} else {
+				previous[path] = summary
+			}
+		}
+	}
+
+	run(initial)
+	fmt.Fprintf(os.Stdout, "\n👀 %s を監視しています（Ctrl+C で終了）\n", root)
+
+	changes := make(chan string)
+	go func() {
+		defer close(changes)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// 新しく作成されたディレクトリも監視対象に加える
+				if info.IsDir() && event.Has(fsnotify.Create) {
+					if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+						_ = addWatchDirs(watcher, event.Name)
+						continue
+					}
+				}
+				if event.Op == fsnotify.Chmod || !isWatchTarget(root, info.IsDir(), event.Name) {
+					continue
+				}
+				select {
+				case changes <- event.Name:
+				case <-ctx.Done():
+					return
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(os.Stderr, color.YellowString("⚠️  ファイル監視エラー: %v\n"), err)
+			}
+		}
+	}()
+
+	debounceChanges(ctx, changes, watchDebounce, run)
+	fmt.Fprint(os.Stdout, "\n👋 監視を終了しました\n")
+	return nil
+}
+
+// addWatchDirs は dir 配下のすべてのディレクトリを監視対象に加える
+// (fsnotify はサブディレクトリを再帰的に監視しないため)
+func addWatchDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
+
+// debounceChanges は changes から受け取ったパスを delay の間まとめ、書き込みが落ち着いてから
+// 重複を除いて辞書順に run に渡す。ctx が終了するか changes が閉じられるまで戻らない
+func debounceChanges(ctx context.Context, changes <-chan string, delay time.Duration, run func(paths []string)) {
+	pending := make(map[string]bool)
+	timer := time.NewTimer(delay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case path, ok := <-changes:
+			if !ok {
+				return
+			}
+			pending[path] = true
+			timer.Reset(delay)
+		case <-timer.C:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			pending = make(map[string]bool)
+			run(paths)
+		}
+	}
+}
+
+// summarizeWatchedFile は1ファイルを変換・検証して結果を要約する（ファイルへの出力は行わない）
+func (cli *IntegratedCLI) summarizeWatchedFile(path string) watchSummary {
+	summary := watchSummary{Path: path}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		summary.Removed = true
+		return summary
+	}
+
+	// 入力パスを差し替えて既存の変換処理を再利用する。変更行ごとの表示は要約にまとめる
+	original := *cli.config
+	defer func() { *cli.config = original }()
+	cli.config.InputPath = path
+	cli.config.ShowStats = false
+
+	lines, err := cli.readInputFile()
+	if err != nil {
+		summary.Err = err
+		return summary
+	}
+	results, err := cli.processLines(lines)
+	if err != nil {
+		summary.Err = err
+		return summary
+	}
+
+	summary.TotalLines = cli.stats.TotalLines
+	summary.ChangedLines = cli.stats.ChangedLines
+	for _, r := range results {
+		if r.ValidationResult == nil {
+			continue
+		}
+		for _, issue := range r.ValidationResult.Issues {
+			summary.Issues = append(summary.Issues, watchIssue{
+				LineNumber: r.LineNumber,
+				Message:    issue.Message,
+				Warning:    issue.EffectiveSeverity() == severityWarning,
+			})
+		}
+	}
+	return summary
+}
+
+// writeWatchSummary は1ファイルの結果と、前回の結果 (seen が true の場合) からの変化を表示
+func writeWatchSummary(w io.Writer, s, prev watchSummary, seen bool) {
+	switch {
+	case s.Removed:
+		fmt.Fprintf(w, "📄 %s: 削除されました\n", s.Path)
+		return
+	case s.Err != nil:
+		fmt.Fprintf(w, color.RedString("📄 %s: ❌ %v\n"), s.Path, s.Err)
+		return
+	}
+
+	errorCount, warningCount := s.issueCounts()
+	line := fmt.Sprintf("📄 %s: %d行中 %d行を変更、エラー %d件・警告 %d件", s.Path, s.TotalLines, s.ChangedLines, errorCount, warningCount)
+	if seen && prev.Err == nil {
+		prevErrors, prevWarnings := prev.issueCounts()
+		line += fmt.Sprintf(" (前回から 変更 %s、エラー %s、警告 %s)",
+			signedDelta(s.ChangedLines-prev.ChangedLines), signedDelta(errorCount-prevErrors), signedDelta(warningCount-prevWarnings))
+	}
+	fmt.Fprintln(w, line)
+
+	for _, issue := range s.Issues {
+		if issue.Warning {
+			fmt.Fprintf(w, color.YellowString("  ⚠️  L%d: %s\n"), issue.LineNumber, issue.Message)
+		} else {
+			fmt.Fprintf(w, color.RedString("  ❌ L%d: %s\n"), issue.LineNumber, issue.Message)
+		}
+	}
+}
+
+// signedDelta は増減を符号付きで表す（変化なしは ±0）
+func signedDelta(n int) string {
+	if n == 0 {
+		return "±0"
+	}
+	return fmt.Sprintf("%+d", n)
+}
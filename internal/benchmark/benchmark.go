@@ -0,0 +1,180 @@
+// Package benchmark provides a self-benchmark of the transform and validation engines
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/armaniacs/usacloud-update/internal/validation"
+)
+
+// workloadCommands は代表的な変換対象コマンド
+var workloadCommands = []string{
+	"usacloud server list --output-type csv",
+	"usacloud disk list --output-type tsv",
+	"usacloud iso-image list",
+	"usacloud server read --selector name=test",
+}
+
+// GenerateWorkload generates a representative script with the given number of lines
+func GenerateWorkload(lines int) string {
+	var input strings.Builder
+	for i := 0; i < lines; i++ {
+		input.WriteString(workloadCommands[i%len(workloadCommands)])
+		input.WriteString("\n")
+	}
+	return input.String()
+}
+
+// Options はベンチマーク実行設定
+type Options struct {
+	Lines      int
+	Iterations int
+	Warmup     int
+}
+
+// DefaultOptions returns the default benchmark options
+func DefaultOptions() Options {
+	return Options{
+		Lines:      1000,
+		Iterations: 10,
+		Warmup:     2,
+	}
+}
+
+// Result は単一ベンチマークの結果
+type Result struct {
+	Name           string        `json:"name"`
+	Iterations     int           `json:"iterations"`
+	NanosPerOp     int64         `json:"nanos_per_op"`
+	BytesPerOp     int64         `json:"bytes_per_op"`
+	AllocsPerOp    int64         `json:"allocs_per_op"`
+	LinesPerSecond float64       `json:"lines_per_second"`
+	ExecutionTime  time.Duration `json:"execution_time"`
+}
+
+// Report はベンチマーク全体のレポート
+type Report struct {
+	Version   string   `json:"version"`
+	GoVersion string   `json:"go_version"`
+	OS        string   `json:"os"`
+	Arch      string   `json:"arch"`
+	NumCPU    int      `json:"num_cpu"`
+	Lines     int      `json:"lines"`
+	Results   []Result `json:"results"`
+}
+
+// Run executes the transform and validation benchmarks
+func Run(version string, opts Options) *Report {
+	if opts.Lines <= 0 {
+		opts.Lines = DefaultOptions().Lines
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultOptions().Iterations
+	}
+
+	lines := strings.Split(strings.TrimSuffix(GenerateWorkload(opts.Lines), "\n"), "\n")
+
+	engine := transform.NewDefaultEngine()
+	transformOp := func() {
+		for _, line := range lines {
+			engine.Apply(line)
+		}
+	}
+
+	parser := validation.NewParser()
+	mainValidator := validation.NewMainCommandValidator()
+	subValidator := validation.NewSubcommandValidator(mainValidator)
+	deprecatedDetector := validation.NewDeprecatedCommandDetector()
+	validationOp := func() {
+		for _, line := range lines {
+			parsed, err := parser.Parse(line)
+			if err != nil || parsed.MainCommand == "" {
+				continue
+			}
+			if deprecatedDetector.IsDeprecated(parsed.MainCommand) {
+				continue
+			}
+			if mainValidator.Validate(parsed.MainCommand).IsValid && parsed.SubCommand != "" {
+				subValidator.IsValidSubcommand(parsed.MainCommand, parsed.SubCommand)
+			}
+		}
+	}
+
+	return &Report{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		NumCPU:    runtime.NumCPU(),
+		Lines:     len(lines),
+		Results: []Result{
+			measure("transform", len(lines), opts, transformOp),
+			measure("validation", len(lines), opts, validationOp),
+		},
+	}
+}
+
+// measure runs op with warmup and collects timing and allocation statistics
+func measure(name string, lines int, opts Options, op func()) Result {
+	for i := 0; i < opts.Warmup; i++ {
+		op()
+	}
+
+	// 計測前にGCを実行して前回の割り当ての影響を排除
+	runtime.GC()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	for i := 0; i < opts.Iterations; i++ {
+		op()
+	}
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	iterations := int64(opts.Iterations)
+	result := Result{
+		Name:          name,
+		Iterations:    opts.Iterations,
+		NanosPerOp:    elapsed.Nanoseconds() / iterations,
+		BytesPerOp:    int64(after.TotalAlloc-before.TotalAlloc) / iterations,
+		AllocsPerOp:   int64(after.Mallocs-before.Mallocs) / iterations,
+		ExecutionTime: elapsed,
+	}
+	if elapsed > 0 {
+		result.LinesPerSecond = float64(lines) * float64(opts.Iterations) / elapsed.Seconds()
+	}
+
+	return result
+}
+
+// WriteText writes the report in a human readable format
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "usacloud-update v%s benchmark\n", r.Version)
+	fmt.Fprintf(&b, "環境: %s %s/%s (CPU: %d)\n", r.GoVersion, r.OS, r.Arch, r.NumCPU)
+	fmt.Fprintf(&b, "ワークロード: %d 行\n\n", r.Lines)
+
+	for _, res := range r.Results {
+		fmt.Fprintf(&b, "%-12s %6d iterations  %12s/op  %10d B/op  %8d allocs/op  %12.0f lines/sec\n",
+			res.Name, res.Iterations, time.Duration(res.NanosPerOp), res.BytesPerOp, res.AllocsPerOp, res.LinesPerSecond)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
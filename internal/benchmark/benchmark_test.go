@@ -0,0 +1,87 @@
+package benchmark
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGenerateWorkload(t *testing.T) {
+	workload := GenerateWorkload(6)
+	lines := strings.Split(strings.TrimSuffix(workload, "\n"), "\n")
+
+	if len(lines) != 6 {
+		t.Fatalf("Expected 6 lines, got %d", len(lines))
+	}
+	if lines[0] != workloadCommands[0] || lines[4] != workloadCommands[0] {
+		t.Errorf("Expected workload to cycle through commands, got %v", lines)
+	}
+	if !strings.HasSuffix(workload, "\n") {
+		t.Error("Workload should end with a newline")
+	}
+}
+
+func TestRun(t *testing.T) {
+	report := Run("1.0.0", Options{Lines: 20, Iterations: 2, Warmup: 1})
+
+	if report.Lines != 20 {
+		t.Errorf("Expected 20 lines, got %d", report.Lines)
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(report.Results))
+	}
+
+	for _, res := range report.Results {
+		if res.Iterations != 2 {
+			t.Errorf("%s: expected 2 iterations, got %d", res.Name, res.Iterations)
+		}
+		if res.NanosPerOp <= 0 {
+			t.Errorf("%s: expected positive ns/op, got %d", res.Name, res.NanosPerOp)
+		}
+		if res.LinesPerSecond <= 0 {
+			t.Errorf("%s: expected positive throughput, got %f", res.Name, res.LinesPerSecond)
+		}
+	}
+}
+
+func TestRun_DefaultOptions(t *testing.T) {
+	report := Run("1.0.0", Options{Iterations: 1})
+
+	if report.Lines != DefaultOptions().Lines {
+		t.Errorf("Expected default %d lines, got %d", DefaultOptions().Lines, report.Lines)
+	}
+}
+
+func TestReport_WriteText(t *testing.T) {
+	report := Run("1.2.3", Options{Lines: 4, Iterations: 1})
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"v1.2.3", "transform", "validation", "allocs/op", "lines/sec"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected text output to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	report := Run("1.2.3", Options{Lines: 4, Iterations: 1})
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if decoded.Version != "1.2.3" || len(decoded.Results) != 2 {
+		t.Errorf("Unexpected decoded report: %+v", decoded)
+	}
+}
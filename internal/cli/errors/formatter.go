@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/fatih/color"
 )
@@ -101,6 +102,13 @@ func (ef *ErrorFormatter) FormatBinaryFile(filePath string) string {
 	return ef.FormatError(ErrorFileBinary, fmt.Sprintf("バイナリファイルは処理できません: %s", filePath))
 }
 
+// FormatDirectoryInput formats errors for a directory passed as an input file
+func (ef *ErrorFormatter) FormatDirectoryInput(dirPath string) string {
+	return ef.FormatError(ErrorFileRead, fmt.Sprintf("ディレクトリは入力ファイルとして指定できません: %s", dirPath),
+		"--in には1つのスクリプトファイルを指定するか、--recursive を指定してください",
+		fmt.Sprintf("ディレクトリ内のスクリプトをまとめて変換する例: usacloud-update --in %s --recursive --include \"*.sh\"", strings.TrimRight(dirPath, "/")))
+}
+
 // FormatFileRead formats file read errors
 func (ef *ErrorFormatter) FormatFileRead(filePath string, err error) string {
 	return ef.FormatError(ErrorFileRead, fmt.Sprintf("ファイル読み込み失敗: %s", filePath), err.Error())
@@ -0,0 +1,79 @@
+// Package exit defines the exit codes of usacloud-update, so that scripts and
+// CI pipelines can tell the categories of failure apart, and the errors that
+// carry them.
+package exit
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// Exit codes
+const (
+	Success          = 0 // everything succeeded
+	Generic          = 1 // any failure without a more specific code
+	Validation       = 2 // the input has validation errors
+	IO               = 3 // an input, output or backup file could not be read or written
+	Config           = 4 // invalid flags, flag combinations or configuration file
+	StatsDrift       = 5 // the transformation statistics drifted from the baseline (--compare-stats-baseline)
+	AlreadyProcessed = 6 // the input was already transformed by usacloud-update (without --force)
+	Deprecated       = 7 // the input uses deprecated commands (--fail-on-deprecated)
+)
+
+// Coder is implemented by errors that determine their own exit code
+type Coder interface {
+	ExitCode() int
+}
+
+// Error is an error with the exit code it should end the process with
+type Error struct {
+	Code int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the error
+func (e *Error) ExitCode() int {
+	return e.Code
+}
+
+// New attaches an exit code to err. It returns nil when err is nil.
+func New(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Code returns the exit code for err: Success for nil, the code of the
+// outermost Coder in its chain, IO for file system errors and Generic otherwise
+func Code(err error) int {
+	return CodeOr(err, Generic)
+}
+
+// CodeOr is like Code but returns fallback instead of Generic
+func CodeOr(err error, fallback int) int {
+	if err == nil {
+		return Success
+	}
+
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	if errors.As(err, &pathErr) || errors.As(err, &linkErr) {
+		return IO
+	}
+	return fallback
+}
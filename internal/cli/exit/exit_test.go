@@ -0,0 +1,64 @@
+package exit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+type driftError struct{}
+
+func (driftError) Error() string { return "drift" }
+func (driftError) ExitCode() int { return StatsDrift }
+
+func TestCode(t *testing.T) {
+	_, notFound := os.Open("/nonexistent/usacloud-update/input.sh")
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, Success},
+		{"plain error", errors.New("failed"), Generic},
+		{"validation", New(Validation, errors.New("2 errors")), Validation},
+		{"wrapped config", fmt.Errorf("context: %w", New(Config, errors.New("bad flag"))), Config},
+		{"path error", fmt.Errorf("read: %w", notFound), IO},
+		{"link error", &os.LinkError{Op: "rename", Old: "a", New: "b", Err: os.ErrExist}, IO},
+		{"explicit code wins over path error", New(Validation, notFound), Validation},
+		{"coder", fmt.Errorf("compare: %w", driftError{}), StatsDrift},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Code(tt.err); got != tt.want {
+				t.Errorf("Code(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeOr(t *testing.T) {
+	if got := CodeOr(errors.New("bad value"), Config); got != Config {
+		t.Errorf("CodeOr() = %d, want the fallback %d", got, Config)
+	}
+	if got := CodeOr(New(IO, errors.New("write")), Config); got != IO {
+		t.Errorf("CodeOr() = %d, want the attached code %d", got, IO)
+	}
+	if got := CodeOr(nil, Config); got != Success {
+		t.Errorf("CodeOr(nil) = %d, want %d", got, Success)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if New(IO, nil) != nil {
+		t.Error("New(code, nil) should be nil")
+	}
+
+	base := errors.New("disk full")
+	err := New(IO, base)
+	if err.Error() != "disk full" || !errors.Is(err, base) {
+		t.Errorf("New() should keep the message and wrap the error, got %v", err)
+	}
+}
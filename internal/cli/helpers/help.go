@@ -1,8 +1,11 @@
 package helpers
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
 
 	"github.com/fatih/color"
 )
@@ -34,6 +37,12 @@ func GetHelpContent(version string) string {
   # 統計出力を無効にして変換
   usacloud-update --in script.sh --out updated.sh --stats=false
 
+  # 検出できる廃止コマンドの一覧（前方一致で絞り込み可能）
+  usacloud-update deprecated list [prefix] [--output-format=json]
+
+  # シェル補完スクリプトを生成（bash/zsh/fish）
+  source <(usacloud-update completion bash)
+
 サンドボックス機能の使用例:
   # インタラクティブTUIでサンドボックス実行
   usacloud-update --sandbox --in script.sh
@@ -53,6 +62,10 @@ func GetHelpContent(version string) string {
   【推奨】設定ファイル方式:
     usacloud-update.conf.sample を参考に ~/.config/usacloud-update/usacloud-update.conf を作成
     初回実行時に対話的に作成することも可能
+    usacloud-update config validate --config <path> で設定ファイルの問題を一覧表示
+    usacloud-update config current --config <path> で実際に使われる設定を値の由来付きで表示
+    usacloud-update config edit --config <path> で設定項目を TUI で編集
+    usacloud-update config migrate --from <.env> --to <path> で旧 .env ファイルを変換
 
     設定ファイルディレクトリのカスタマイズ:
       USACLOUD_UPDATE_CONFIG_DIR=/path/to/config - カスタム設定ディレクトリを指定
@@ -66,46 +79,220 @@ func GetOptionsContent() string {
 	return `
 
 オプション:
+  --add-assumeyes
+        削除・停止など実行前に確認を求めるusacloudコマンド（delete/shutdown/reset）に -y が指定されていない場合は付与（cron や CI での応答待ちを防ぐ、手動実行でも確認されなくなる）
   --batch
         バッチモード: 選択した全コマンドを自動実行
-  --color
-        カラー出力を有効にする (default true)
+  --benchmark
+        変換・検証エンジンのセルフベンチマークを実行
+  --benchmark-format string
+        ベンチマーク結果の出力形式 (text/json) (default "text")
+  --changes-csv string
+        変更ごとの一覧（ファイル・行番号・ルール・変更前・変更後・理由）をスプレッドシートで確認できるCSV形式で出力するファイルパス
+  --check-paths
+        usacloudコマンドが参照するローカルファイル（--iso-file 等）が存在しない場合に警告（相対パスはカレントディレクトリ基準）
+  --color mode
+        色付けの方法 (auto: 出力先が端末の場合のみ/always/never)。--color=mode・--color mode のどちらでも指定可。--color=true・--color=false は非推奨の別名 (default auto)
+  --compare-stats-baseline string
+        変換全体の統計をベースラインのJSONファイルと比較し、許容範囲を超えて変化した場合は終了コード5で終了
   --config string
         設定ファイルパス（指定しない場合はデフォルト設定を使用）
+  --config-migrate
+        古い形式の設定ファイルを現在の形式に更新する（元のファイルはバックアップ）
+  --diff
+        変換結果全体の代わりに元の入力との差分をunified diff形式で出力
+  --disable-rule value
+        無効にする変換ルール名（繰り返し指定可）
+  --disable-rules value
+        無効にする変換ルール名をカンマ区切りで指定（繰り返し指定可）
   --dry-run
-        実際の実行を行わず変換結果のみ表示
+        実際の実行を行わず変換結果のみ表示（--sandbox なしでは変換を書き出さずに変更行数・ルール数・ファイル数の集計のみ表示）
+  --dry-run-diff
+        サンドボックスで実行せずに、各行の元のコマンド・変換後のコマンド・実行されるかどうか（スキップ理由）を表で標準エラー出力に表示
+  --dump-parse string
+        指定したusacloudコマンドのパーサー解析結果を表示（デバッグ用）
+  --dump-parse-format string
+        パーサー解析結果の出力形式 (text/json) (default "text")
+  --dump-stats-baseline string
+        変換全体の統計（変更行数とルールごとの適用回数）をベースラインとしてJSONファイルに書き出す
+  --enable-only value
+        指定した変換ルールだけを適用（繰り返し指定可、それ以外のルールはすべて無効）
+  --enable-rules value
+        有効にする変換ルール名をカンマ区切りで指定（繰り返し指定可、設定ファイルの disabled_rules より優先）
+  --exclude value
+        --recursive で変換しないパスのglobパターン（例: "vendor/**,*.generated.sh"、繰り返し・カンマ区切りで複数指定可、** は任意の深さのディレクトリ）
+  --explain-changes
+        変更された行ごとに変換理由・v0とv1の違い・注意点を標準エラー出力に表示
+  --fail-on-deprecated
+        変換モードで入力に廃止コマンドが含まれる場合、変換結果と一覧を出力した後に終了コード7で終了
+        （--strict-validation とは独立。--skip-deprecated の指定にかかわらず判定）
+  --force
+        usacloud-update で変換済み（生成ヘッダーを含む）の入力も、既存の生成ヘッダーを取り除いて再変換
+        （指定しない場合は変換せずに終了コード6で終了）
+  --group-errors
+        検証のみモードで同じコマンドの問題をファイル全体でまとめ、出現回数と行番号を1回だけ表示
   --help
         ヘルプメッセージを表示
+  --help-all
+        登録されているすべてのオプションを既定値・説明付きで表示（オプションの定義から生成）
   --help-mode string
         ヘルプモード (basic/enhanced/interactive) (default "enhanced")
-  --in string
-        入力ファイルパス ('-'で標準入力) (default "-")
+  --in value
+        入力ファイルパス ('-'で標準入力、未指定時は標準入力)。繰り返し指定すると順に変換して1つの出力に連結
+  --in-place
+        変換結果で入力ファイルを直接上書き（元の内容は .bak に退避、--recursive では .updated の代わりに上書き）
+  --include string
+        --recursive で変換するファイル名のglobパターン（例: "*.sh"、未指定時はシェルスクリプト）
+  --input-encoding string
+        入力ファイルの文字コード (utf-8/shift_jis/euc-jp/iso-2022-jp) (default "utf-8")
   --interactive
         インタラクティブTUIモード (sandboxとの組み合わせで使用) (default true)
   --interactive-mode
         インタラクティブ検証・修正モード
+  --jobs int
+        各行の変換と検証を並列に実行するワーカー数（0: CPU数、指定しない場合は設定ファイルの [performance] に従う）
   --language string
         言語設定 (ja/en) (default "ja")
+  --line-ending string
+        出力の改行コード (lf/crlf/auto: 入力で多い方に統一) (default "lf")
+  --list-rules
+        適用される変換ルールごとの名前・説明・変換前後の例・逆変換の可否を表示（ドキュメント・エディタ連携用）
+  --list-rules-format string
+        --list-rules の出力形式 (text/json) (default "text")
+  --max-distance int
+        類似コマンド提案で許容する最大編集距離 (1-10) (default 3)
+  --max-line-length int
+        変換・検証する行の最大バイト数。これより長い行は警告を表示してそのまま出力 (default 1048576)
+  --max-suggestions int
+        表示する類似コマンド提案の最大数 (1-20) (default 5)
+  --migration-report string
+        usacloud コマンドを対応済み（v1 の構文）・移行済み（自動変換）・手動対応が必要に分類し、行番号付きで一覧にしたレポートの出力先 ('-'で標準出力)
+  --no-backup
+        --in-place で .bak バックアップを作成しない
+  --no-header
+        変換結果の先頭に生成ヘッダー（# Updated for usacloud ... by usacloud-update）を付けずに出力（他のファイルに連結する断片の変換用）
   --out string
         出力ファイルパス ('-'で標準出力) (default "-")
+  --output-encoding string
+        出力ファイルの文字コード（指定しない場合は入力と同じ）
+  --output-format string
+        検証のみモードの結果の出力形式 (text/json/sarif)。json/sarif では検証結果を標準出力に出力 (default "text")
+  --parallel int
+        サンドボックスで複数のファイルを選択した場合に同時に実行するファイル数 (default 1)
+  --passes int
+        変換結果に対して変化がなくなるまで変換を繰り返す最大回数（ルールの結果が別のルールに該当する場合用） (default 1)
+  --preserve-permissions
+        入力ファイルのパーミッション（実行ビット等）を出力ファイルに引き継ぐ (default true)
+  --profile string
+        適用する設定ファイルのプロファイル名（USACLOUD_UPDATE_PROFILE と設定ファイルの profile より優先、beginner/expert/ci などの組み込みプロファイルも指定可）
+  --print-effective-rules
+        フラグ・設定ファイルを反映した最終的な変換ルール一覧（順序・有効/無効・指定元）を表示
+  --provenance string
+        変更行ごとの監査記録（元の行・変換後・適用ルール・移行元/先バージョン・時刻・入力ハッシュ）をJSON Lines形式で出力するファイルパス
+  --quiet
+        変更行ごとの表示（#L<行番号> 変更前 => 変更後 [ルール]）を抑制し、最後の統計と完了メッセージだけを表示
+  --rate-limit float
+        サンドボックスで1秒あたりに実行するコマンド数の上限（超える場合は失敗せずに待機、並列実行するファイル全体で共有、0: 無制限、指定しない場合は設定ファイルの [sandbox] の rate_limit に従う）
+  --recursive
+        --in にディレクトリを指定し、配下のスクリプトを再帰的に変換（出力は元ファイルの隣に .updated を付けて書き出し）
+  --report string
+        サンドボックスのバッチ実行の結果をレポートとして出力する形式 (json)
+  --report-out string
+        --report の出力先ファイルパス ('-'で標準出力) (default "-")
+  --report-summary-only
+        検証のみモードで行ごとの詳細を表示せず、エラー・警告の件数と問題のあるファイル数・行数だけを標準出力に表示（json/sarif の出力は変わらない）
+  --reverse
+        v1のスクリプトをv0の構文に逆変換（参照用。元に戻せないルールは警告として標準エラー出力に表示）
+  --review
+        出力前に変更された行ごとの変更前・変更後と適用ルールをTUIで確認し、Spaceで受け入れ/却下を切り替えてEnterで受け入れた変更だけを出力（却下した行は元のまま）
+  --risk-report string
+        --recursive で変換したファイルを移行リスク（手動対応・代替のない廃止コマンド・確度の低い提案）の高い順に並べたレポートの出力先 ('-'で標準出力)
+  --risk-report-format string
+        リスクレポートの出力形式 (text/json) (default "text")
+  --rule-order string
+        先に適用する変換ルール名をカンマ区切りで指定（上級者向け、未指定のルールは既定の順序で後続）
   --sandbox
         サンドボックス環境での実際のコマンド実行
+  --since string
+        スクリプトが現在対応している usacloud のバージョン (v0/v1.0)。このバージョン以前向けの変換ルールは適用しない (default "v0")
   --skip-deprecated
         廃止コマンド警告をスキップ
+  --stat-format string
+        変更行の表示形式を Go の text/template で指定（例: "{{.Line}}:{{.Rule}}"、フィールドは .Line .Before .After .Rule、未指定時は #L<行番号> 変更前 => 変更後 [ルール]）
   --stats
-        変更の統計情報を標準エラー出力に表示 (default true)
+        変更の統計情報を標準エラー出力に表示（最後に変更行数とルールごとの適用回数を集計） (default true)
+  --stats-baseline-tolerance float
+        --compare-stats-baseline で許容する変化の割合（0.1 で±10%）
+  --stdin-filename string
+        標準入力の内容をエラーメッセージ・差分・来歴・JSON/SARIF の出力で表すファイル名（例: deploy.sh、読み込み元は変わらない）
   --strict-validation
         厳格検証モード（エラー発生時に処理を停止）
   --suggestion-level int
-        提案レベル設定 (1-5) (default 3)
+        類似コマンド提案の詳しさ (1-5)。1 は最良の1件のみ、3 以上は類似度付きでレベルの数まで、5 はヘルプの使用例も表示 (default 3)
+  --summary-threshold int
+        検証のみモードで問題数がこの値を超えた場合だけ詳細レポートを表示（終了コードは変わらない）
+  --target-version string
+        移行先の usacloud のバージョン (v1.0/v1.1)。これより新しいバージョン向けの変換ルールは適用せず、生成ヘッダーにも反映 (default "v1.1")
+  --timing
+        読み込み・変換・検証・書き出しの各段階にかかった時間と合計を標準エラー出力に表示（--output-format=json では結果の metadata に含める）
+  --transform-heredocs
+        ヒアドキュメント（cat <<EOF ... EOF など）の本文に含まれるusacloudコマンドも変換・検証（既定では本文をそのまま出力）
+  --treat-unknown-as string
+        カタログにないメインコマンド・サブコマンドの扱い (error/warning/ignore) (default "error")
+  --usacloud-version string
+        検証に使用するusacloudのバージョン別コマンドカタログ (1.0/1.1) (default "1.1")
   --validate-only
         検証のみ実行（変換は行わない）
   --version
         バージョン情報を表示
+  --watch
+        --in のファイルまたはディレクトリを監視し、.sh ファイルが変更されるたびに変換と検証を再実行して要約を表示（Ctrl+C で終了）
+  --yes
+        バッチモードで削除・停止などの破壊的な操作を含むコマンドも確認せずに実行（設定ファイルの [sandbox] の destructive_commands で対象を変更可能）
 
 `
 }
 
+// GetAllOptionsContent returns the options help content generated from the
+// flags registered in fs, in the format of GetOptionsContent, so that it
+// cannot drift from the flags the program accepts
+func GetAllOptionsContent(fs *flag.FlagSet) string {
+	var b strings.Builder
+	b.WriteString("\n\nオプション:\n")
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		b.WriteString("  --" + f.Name)
+		if name != "" {
+			b.WriteString(" " + name)
+		}
+		b.WriteString("\n        " + strings.ReplaceAll(usage, "\n", "\n        "))
+		if !isZeroDefault(f) {
+			if name == "string" {
+				fmt.Fprintf(&b, " (default %q)", f.DefValue)
+			} else {
+				fmt.Fprintf(&b, " (default %v)", f.DefValue)
+			}
+		}
+		b.WriteString("\n")
+	})
+	b.WriteString("\n")
+	return b.String()
+}
+
+// isZeroDefault reports whether the default of f is the zero value of its
+// type, which flag.PrintDefaults does not show either
+func isZeroDefault(f *flag.Flag) bool {
+	typ := reflect.TypeOf(f.Value)
+	var zero reflect.Value
+	if typ.Kind() == reflect.Pointer {
+		zero = reflect.New(typ.Elem())
+	} else {
+		zero = reflect.Zero(typ)
+	}
+	value, ok := zero.Interface().(flag.Value)
+	return ok && f.DefValue == value.String()
+}
+
 // GetFooterContent returns the footer help content
 func GetFooterContent() string {
 	return `詳細な使用方法とルールについては README-Usage.md を参照してください。
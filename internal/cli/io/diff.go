@@ -0,0 +1,110 @@
+package io
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultDiffContext is the number of unchanged lines shown around each change (same as diff -u)
+const DefaultDiffContext = 3
+
+// DiffLine pairs an input line with its transformed form.
+// Lines are compared one-to-one since transformations never add or remove lines.
+type DiffLine struct {
+	Original    string
+	Transformed string
+	Rules       []string // names of the rules that changed the line
+}
+
+func (l DiffLine) changed() bool {
+	return l.Original != l.Transformed
+}
+
+// UnifiedDiff formats lines as a unified diff (like diff -u). Each hunk header
+// lists the changed line numbers with the rules applied to them. Returns an
+// empty string when no line changed.
+func UnifiedDiff(fromName, toName string, lines []DiffLine, context int) string {
+	hunks := diffHunks(lines, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n", fromName)
+	fmt.Fprintf(&b, "+++ %s\n", toName)
+
+	for _, h := range hunks {
+		var notes []string
+		for i := h.start; i < h.end; i++ {
+			if lines[i].changed() && len(lines[i].Rules) > 0 {
+				notes = append(notes, fmt.Sprintf("L%d: %s", i+1, strings.Join(lines[i].Rules, ", ")))
+			}
+		}
+
+		rng := hunkRange(h.start, h.end-h.start)
+		fmt.Fprintf(&b, "@@ -%s +%s @@", rng, rng)
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, " %s", strings.Join(notes, "; "))
+		}
+		b.WriteString("\n")
+
+		// Runs of changed lines are written as all removals followed by all additions
+		for i := h.start; i < h.end; {
+			if !lines[i].changed() {
+				fmt.Fprintf(&b, " %s\n", lines[i].Original)
+				i++
+				continue
+			}
+			j := i
+			for j < h.end && lines[j].changed() {
+				j++
+			}
+			for k := i; k < j; k++ {
+				fmt.Fprintf(&b, "-%s\n", lines[k].Original)
+			}
+			for k := i; k < j; k++ {
+				fmt.Fprintf(&b, "+%s\n", lines[k].Transformed)
+			}
+			i = j
+		}
+	}
+
+	return b.String()
+}
+
+// hunk is a half-open range of line indexes
+type hunk struct {
+	start, end int
+}
+
+// diffHunks groups changed lines into hunks, merging changes whose context overlaps
+func diffHunks(lines []DiffLine, context int) []hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	var hunks []hunk
+	for i, line := range lines {
+		if !line.changed() {
+			continue
+		}
+
+		start := max(0, i-context)
+		end := min(len(lines), i+context+1)
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+			continue
+		}
+		hunks = append(hunks, hunk{start: start, end: end})
+	}
+
+	return hunks
+}
+
+// hunkRange formats a 0-based start and line count as a 1-based diff range
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
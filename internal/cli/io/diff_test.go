@@ -0,0 +1,43 @@
+package io
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := []DiffLine{
+		{Original: "a", Transformed: "a"},
+		{Original: "b", Transformed: "B", Rules: []string{"rule-1", "rule-2"}},
+		{Original: "c", Transformed: "c"},
+		{Original: "d", Transformed: "d"},
+		{Original: "e", Transformed: "e"},
+		{Original: "f", Transformed: "F", Rules: []string{"rule-3"}},
+	}
+
+	expected := "--- a/x\n+++ b/x\n" +
+		"@@ -1,3 +1,3 @@ L2: rule-1, rule-2\n a\n-b\n+B\n c\n" +
+		"@@ -5,2 +5,2 @@ L6: rule-3\n e\n-f\n+F\n"
+	if got := UnifiedDiff("a/x", "b/x", lines, 1); got != expected {
+		t.Errorf("UnifiedDiff() =\n%s\nexpected\n%s", got, expected)
+	}
+
+	// Overlapping context merges changes into one hunk
+	expected = "--- a/x\n+++ b/x\n" +
+		"@@ -1,6 +1,6 @@ L2: rule-1, rule-2; L6: rule-3\n a\n-b\n+B\n c\n d\n e\n-f\n+F\n"
+	if got := UnifiedDiff("a/x", "b/x", lines, DefaultDiffContext); got != expected {
+		t.Errorf("UnifiedDiff() =\n%s\nexpected\n%s", got, expected)
+	}
+}
+
+func TestUnifiedDiff_AdjacentChangesAndNoChanges(t *testing.T) {
+	lines := []DiffLine{
+		{Original: "a", Transformed: "A", Rules: []string{"r"}},
+		{Original: "b", Transformed: "B", Rules: []string{"r"}},
+	}
+	expected := "--- a/x\n+++ b/x\n@@ -1,2 +1,2 @@ L1: r; L2: r\n-a\n-b\n+A\n+B\n"
+	if got := UnifiedDiff("a/x", "b/x", lines, 0); got != expected {
+		t.Errorf("UnifiedDiff() =\n%s\nexpected\n%s", got, expected)
+	}
+
+	if got := UnifiedDiff("a/x", "b/x", []DiffLine{{Original: "a", Transformed: "a"}}, DefaultDiffContext); got != "" {
+		t.Errorf("Expected empty diff, got %q", got)
+	}
+}
@@ -2,22 +2,132 @@ package io
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
 )
 
 const (
-	// BufferSize defines the buffer size for reading files (1MB)
+	// BufferSize defines the buffer size for reading files (1MB), also the
+	// default longest line the CLI transforms (--max-line-length)
 	BufferSize = 1024 * 1024
 	// BinaryDetectionSize defines how many bytes to check for binary content
 	BinaryDetectionSize = 512
 )
 
+// Line ending identifiers
+const (
+	LineEndingLF   = "lf"
+	LineEndingCRLF = "crlf"
+)
+
+// LineEndingStats holds the number of lines terminated by each line ending
+type LineEndingStats struct {
+	CRLF int
+	LF   int
+}
+
+// IsMixed reports whether both CRLF and LF line endings were found
+func (s LineEndingStats) IsMixed() bool {
+	return s.CRLF > 0 && s.LF > 0
+}
+
+// Dominant returns the most frequent line ending (LF on ties)
+func (s LineEndingStats) Dominant() string {
+	if s.CRLF > s.LF {
+		return LineEndingCRLF
+	}
+	return LineEndingLF
+}
+
+// Separator returns the line separator string for a line ending identifier
+func Separator(lineEnding string) string {
+	if lineEnding == LineEndingCRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// EncodingUTF8 is the default input/output encoding
+const EncodingUTF8 = "utf-8"
+
+// supportedEncodings maps accepted encoding names to their implementations (nil means UTF-8)
+var supportedEncodings = map[string]encoding.Encoding{
+	"utf-8":       nil,
+	"utf8":        nil,
+	"shift_jis":   japanese.ShiftJIS,
+	"shift-jis":   japanese.ShiftJIS,
+	"sjis":        japanese.ShiftJIS,
+	"cp932":       japanese.ShiftJIS,
+	"euc-jp":      japanese.EUCJP,
+	"eucjp":       japanese.EUCJP,
+	"iso-2022-jp": japanese.ISO2022JP,
+	"jis":         japanese.ISO2022JP,
+}
+
+// SupportedEncodingNames returns the canonical names of supported encodings
+func SupportedEncodingNames() []string {
+	return []string{"utf-8", "shift_jis", "euc-jp", "iso-2022-jp"}
+}
+
+// LookupEncoding returns the encoding for name (nil for UTF-8)
+func LookupEncoding(name string) (encoding.Encoding, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	enc, ok := supportedEncodings[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("サポートされていない文字コードです: %s (対応: %s)", name, strings.Join(SupportedEncodingNames(), ", "))
+	}
+	return enc, nil
+}
+
+// legacyTransliterator maps runes that legacy Japanese encodings cannot represent to close equivalents
+var legacyTransliterator = strings.NewReplacer(
+	"\u2014", "\u2015", // EM DASH -> HORIZONTAL BAR
+)
+
+// EncodeContent converts UTF-8 content to the named encoding
+func EncodeContent(content string, name string) (string, error) {
+	enc, err := LookupEncoding(name)
+	if err != nil {
+		return "", err
+	}
+	if enc == nil {
+		return content, nil
+	}
+
+	encoded, err := enc.NewEncoder().String(legacyTransliterator.Replace(content))
+	if err != nil {
+		return "", fmt.Errorf("%s に変換できない文字が含まれています: %w", name, err)
+	}
+	return encoded, nil
+}
+
+// stdinReader is the reader used when the input path is "-"
+var stdinReader io.Reader = os.Stdin
+
+// SetStdinReader replaces the reader used for "-" input; nil restores os.Stdin
+func SetStdinReader(r io.Reader) {
+	if r == nil {
+		r = os.Stdin
+	}
+	stdinReader = r
+}
+
 // FileReader provides unified file reading capabilities
 type FileReader struct {
 	enableBinaryDetection bool
+	lineEndings           LineEndingStats
+	encoding              encoding.Encoding
 }
 
 // NewFileReader creates a new file reader with binary detection enabled by default
@@ -32,11 +142,59 @@ func (fr *FileReader) SetBinaryDetection(enabled bool) {
 	fr.enableBinaryDetection = enabled
 }
 
+// SetEncoding sets the encoding used to decode input (empty or "utf-8" disables decoding)
+func (fr *FileReader) SetEncoding(name string) error {
+	enc, err := LookupEncoding(name)
+	if err != nil {
+		return err
+	}
+	fr.encoding = enc
+	return nil
+}
+
+// GzipSuffix is the extension of gzip-compressed input and output files
+const GzipSuffix = ".gz"
+
+// gzipMagic are the first bytes of gzip-compressed content
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// IsGzipPath reports whether path names a gzip-compressed file by its extension
+func IsGzipPath(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), GzipSuffix)
+}
+
+// decompress returns the decompressed content of r when it is gzip-compressed,
+// as told by the gzip magic bytes or, with compressed, regardless of them
+func decompress(r io.Reader, compressed bool) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(gzipMagic)); !compressed && !bytes.Equal(magic, gzipMagic) {
+		return br, nil
+	}
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("gzip の展開に失敗: %w", err)
+	}
+	return gz, nil
+}
+
+// inputFile is the content of an input file, decompressed if needed, that
+// closes the file
+type inputFile struct {
+	io.Reader
+	file *os.File
+}
+
+func (f *inputFile) Close() error {
+	return f.file.Close()
+}
+
 // ReadInputFile reads from the specified path or stdin if path is "-"
-// Returns an io.Reader for the content and any error encountered
+// gzip-compressed content, told by the .gz extension or the gzip magic bytes,
+// is decompressed, and binary detection checks the decompressed content.
+// Returns an io.Reader for the content, an io.Closer for files, and any error encountered
 func (fr *FileReader) ReadInputFile(path string) (io.Reader, error) {
 	if path == "-" {
-		return os.Stdin, nil
+		return decompress(stdinReader, false)
 	}
 
 	f, err := os.Open(path)
@@ -44,21 +202,34 @@ func (fr *FileReader) ReadInputFile(path string) (io.Reader, error) {
 		return nil, err
 	}
 
+	// Directories open successfully but fail on read with an opaque error
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		f.Close()
+		return nil, &DirectoryInputError{Path: path}
+	}
+
+	reader, err := decompress(f, IsGzipPath(path))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
 	// Check for binary content if enabled
 	if fr.enableBinaryDetection {
-		if err := fr.DetectBinaryContent(f); err != nil {
+		br := bufio.NewReaderSize(reader, BinaryDetectionSize)
+		head, err := br.Peek(BinaryDetectionSize)
+		if err != nil && err != io.EOF {
 			f.Close()
-			return nil, err
+			return nil, fmt.Errorf("ファイル読み込み中にエラーが発生: %w", err)
 		}
-
-		// Reset file position to beginning after binary check
-		if _, err := f.Seek(0, 0); err != nil {
+		if err := fr.DetectBinaryContent(bytes.NewReader(head)); err != nil {
 			f.Close()
-			return nil, fmt.Errorf("ファイル位置のリセットに失敗: %w", err)
+			return nil, err
 		}
+		reader = br
 	}
 
-	return f, nil
+	return &inputFile{Reader: reader, file: f}, nil
 }
 
 // ReadInputLines reads lines from the specified path or stdin if path is "-"
@@ -70,20 +241,31 @@ func (fr *FileReader) ReadInputLines(path string) ([]string, error) {
 	}
 
 	// Close file if it's not stdin
-	if f, ok := reader.(*os.File); ok && f != os.Stdin {
+	if f, ok := reader.(*inputFile); ok {
 		defer f.Close()
 	}
 
-	scanner := bufio.NewScanner(reader)
-	scanner.Buffer(make([]byte, 0, BufferSize), BufferSize)
-
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	if fr.encoding != nil {
+		reader = transform.NewReader(reader, fr.encoding.NewDecoder())
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	// Lines of any length are read so that callers can pass oversized lines
+	// through instead of failing on them
+	fr.lineEndings = LineEndingStats{}
+	br := bufio.NewReaderSize(reader, BufferSize)
+
+	var lines []string
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			lines = append(lines, fr.trimLineEnding(line))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Note: Empty files are allowed - the original readFileLines function allowed them
@@ -91,6 +273,25 @@ func (fr *FileReader) ReadInputLines(path string) ([]string, error) {
 	return lines, nil
 }
 
+// LineEndings returns the line ending statistics of the last ReadInputLines call
+func (fr *FileReader) LineEndings() LineEndingStats {
+	return fr.lineEndings
+}
+
+// trimLineEnding removes the line ending of line and counts it. Like
+// bufio.ScanLines, a carriage return before the end of the input is dropped too.
+func (fr *FileReader) trimLineEnding(line string) string {
+	switch {
+	case strings.HasSuffix(line, "\r\n"):
+		fr.lineEndings.CRLF++
+		return line[:len(line)-2]
+	case strings.HasSuffix(line, "\n"):
+		fr.lineEndings.LF++
+		return line[:len(line)-1]
+	}
+	return strings.TrimSuffix(line, "\r")
+}
+
 // DetectBinaryContent checks if the reader contains binary content by looking for null bytes
 func (fr *FileReader) DetectBinaryContent(reader io.Reader) error {
 	// Create a buffer to read the first bytes
@@ -113,19 +314,64 @@ func (fr *FileReader) DetectBinaryContent(reader io.Reader) error {
 }
 
 // WriteOutputFile writes content to the specified path or stdout if path is "-"
+// A path ending in .gz is written gzip-compressed
 func WriteOutputFile(path string, content string) error {
-	var w io.Writer = os.Stdout
-	if path != "-" {
-		f, err := os.Create(path)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		w = f
+	if path == "-" {
+		_, err := io.WriteString(os.Stdout, content)
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if !IsGzipPath(path) {
+		_, err = io.WriteString(f, content)
+		return err
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := io.WriteString(gz, content); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// CopyFileMode applies the permission bits of src to dst
+// stdin/stdout ("-") are ignored since they have no meaningful file mode
+func CopyFileMode(src, dst string) error {
+	if src == "-" || dst == "-" {
+		return nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
 	}
 
-	_, err := io.WriteString(w, content)
-	return err
+	return os.Chmod(dst, info.Mode().Perm())
+}
+
+// BackupSuffix is appended to a file path to name the backup taken before editing it in place
+const BackupSuffix = ".bak"
+
+// BackupFile copies src to dst, keeping the permission bits of src
+func BackupFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	// WriteFile applies the mode only when creating the file and is subject to umask
+	return os.Chmod(dst, info.Mode().Perm())
 }
 
 // BinaryFileError represents an error when a binary file is detected
@@ -143,6 +389,21 @@ func IsBinaryFileError(err error) bool {
 	return ok
 }
 
+// DirectoryInputError represents an error when a directory is given as input
+type DirectoryInputError struct {
+	Path string
+}
+
+func (e *DirectoryInputError) Error() string {
+	return fmt.Sprintf("入力パスはディレクトリです: %s", e.Path)
+}
+
+// IsDirectoryInputError checks if the error is a directory input error
+func IsDirectoryInputError(err error) bool {
+	_, ok := err.(*DirectoryInputError)
+	return ok
+}
+
 // ReadFileLines is a standalone function for reading file lines
 // Useful for compatibility with existing code
 func ReadFileLines(path string) ([]string, error) {
@@ -151,20 +412,17 @@ func ReadFileLines(path string) ([]string, error) {
 }
 
 // DetectBinaryFile is a standalone function for binary detection
-// Useful for quick binary file checks
+// Useful for quick binary file checks; gzip-compressed files are checked decompressed
 func DetectBinaryFile(path string) error {
 	if path == "-" {
 		return nil // Don't check stdin for binary content
 	}
 
-	f, err := os.Open(path)
+	reader, err := NewFileReader().ReadInputFile(path)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	reader := NewFileReader()
-	return reader.DetectBinaryContent(f)
+	return reader.(io.Closer).Close()
 }
 
 // ValidateFilePath checks if a file path is valid for reading
@@ -0,0 +1,160 @@
+package io
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadInputLines_LongLines(t *testing.T) {
+	long := strings.Repeat("x", BufferSize*2+10)
+	path := filepath.Join(t.TempDir(), "long.sh")
+	if err := os.WriteFile(path, []byte("usacloud server list\r\n"+long+"\nlast\r"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := NewFileReader()
+	lines, err := reader.ReadInputLines(path)
+	if err != nil {
+		t.Fatalf("ReadInputLines() error = %v", err)
+	}
+	if len(lines) != 3 || lines[0] != "usacloud server list" || lines[1] != long || lines[2] != "last" {
+		t.Errorf("Unexpected lines: %d lines, first %q, last %q", len(lines), lines[0], lines[len(lines)-1])
+	}
+	if endings := reader.LineEndings(); endings.CRLF != 1 || endings.LF != 1 {
+		t.Errorf("Unexpected line endings: %+v", endings)
+	}
+}
+
+func TestReadInputLines_LineEndings(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{"a\n", []string{"a"}},
+		{"a\n\n", []string{"a", ""}},
+		{"a\r\nb\r\n", []string{"a", "b"}},
+		{"\n", []string{""}},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "input.sh")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		lines, err := NewFileReader().ReadInputLines(path)
+		if err != nil {
+			t.Fatalf("ReadInputLines(%q) error = %v", tt.content, err)
+		}
+		if strings.Join(lines, "|") != strings.Join(tt.want, "|") || len(lines) != len(tt.want) {
+			t.Errorf("ReadInputLines(%q) = %q, want %q", tt.content, lines, tt.want)
+		}
+	}
+}
+
+func gzipBytes(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadInputLines_Gzip(t *testing.T) {
+	content := "#!/bin/bash\nusacloud server list --output-type=csv\n"
+	compressed := gzipBytes(t, content)
+	if !bytes.Contains(compressed, []byte{0}) {
+		t.Fatal("圧縮データにヌルバイトが含まれず、バイナリ判定の確認にならない")
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"deploy.sh.gz", "deploy.sh"} {
+		t.Run(name, func(t *testing.T) {
+			// 拡張子がなくても gzip のマジックバイトで展開する
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, compressed, 0644); err != nil {
+				t.Fatal(err)
+			}
+			lines, err := ReadFileLines(path)
+			if err != nil {
+				t.Fatalf("ReadFileLines() error = %v", err)
+			}
+			if strings.Join(lines, "\n")+"\n" != content {
+				t.Errorf("ReadFileLines() = %q", lines)
+			}
+			if err := DetectBinaryFile(path); err != nil {
+				t.Errorf("DetectBinaryFile() error = %v", err)
+			}
+		})
+	}
+
+	t.Run("stdin", func(t *testing.T) {
+		SetStdinReader(bytes.NewReader(compressed))
+		defer SetStdinReader(nil)
+		lines, err := ReadFileLines("-")
+		if err != nil {
+			t.Fatalf("ReadFileLines(-) error = %v", err)
+		}
+		if len(lines) != 2 || lines[1] != "usacloud server list --output-type=csv" {
+			t.Errorf("ReadFileLines(-) = %q", lines)
+		}
+	})
+
+	t.Run("展開後がバイナリ", func(t *testing.T) {
+		path := filepath.Join(dir, "binary.gz")
+		if err := os.WriteFile(path, gzipBytes(t, "\x00\x01\x02"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadFileLines(path); !IsBinaryFileError(err) {
+			t.Errorf("ReadFileLines() error = %v, want BinaryFileError", err)
+		}
+	})
+
+	t.Run("gzip でない .gz", func(t *testing.T) {
+		path := filepath.Join(dir, "plain.sh.gz")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadFileLines(path); err == nil || !strings.Contains(err.Error(), "gzip") {
+			t.Errorf("ReadFileLines() error = %v, want gzip error", err)
+		}
+	})
+}
+
+func TestWriteOutputFile_Gzip(t *testing.T) {
+	content := "usacloud server list\n"
+	dir := t.TempDir()
+
+	gzPath := filepath.Join(dir, "out.sh.gz")
+	if err := WriteOutputFile(gzPath, content); err != nil {
+		t.Fatalf("WriteOutputFile() error = %v", err)
+	}
+	data, err := os.ReadFile(gzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, gzipMagic) {
+		t.Errorf(".gz の出力が gzip で圧縮されていません: %q", data)
+	}
+	if lines, err := ReadFileLines(gzPath); err != nil || len(lines) != 1 || lines[0] != "usacloud server list" {
+		t.Errorf("ReadFileLines() = %q, %v", lines, err)
+	}
+
+	plainPath := filepath.Join(dir, "out.sh")
+	if err := WriteOutputFile(plainPath, content); err != nil {
+		t.Fatalf("WriteOutputFile() error = %v", err)
+	}
+	if data, _ := os.ReadFile(plainPath); string(data) != content {
+		t.Errorf("WriteOutputFile() wrote %q", data)
+	}
+}
@@ -0,0 +1,209 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// UpdatedSuffix is appended to a source path to name its converted output
+const UpdatedSuffix = ".updated"
+
+// ValidateIncludePattern checks that a --include glob is well formed
+func ValidateIncludePattern(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("無効な --include パターンです: %s: %w", pattern, err)
+	}
+	return nil
+}
+
+// ValidateExcludePattern checks that an --exclude glob is well formed
+func ValidateExcludePattern(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("無効な --exclude パターンです: %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// MatchExcludePattern reports whether rel, a slash-separated path relative to
+// the walked root, matches an --exclude pattern. A pattern without a slash
+// matches the base name at any depth, "**" matches any number of directories
+// and a pattern ending in a slash matches everything below the directory.
+func MatchExcludePattern(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches path segments against glob segments, "**" matching
+// zero or more of them
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// shellInterpreters are the interpreters of a shebang that IsShellScript accepts
+var shellInterpreters = map[string]bool{"sh": true, "bash": true, "dash": true, "ash": true, "ksh": true, "zsh": true}
+
+// IsShellScript reports whether the file at path is a shell script: its name
+// ends in .sh or its first line is a shebang running a shell, directly or
+// through env ("#!/usr/bin/env bash")
+func IsShellScript(path string) bool {
+	if strings.HasSuffix(path, ".sh") {
+		return true
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && line == "" {
+		return false
+	}
+	if !strings.HasPrefix(line, "#!") {
+		return false
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return false
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+	return shellInterpreters[interpreter]
+}
+
+// FindScripts walks root and returns the regular files whose base name matches
+// include (the shell scripts, see IsShellScript, when include is empty), in
+// lexical order. Hidden directories, which include those of version control
+// such as .git, .hg and .svn, are not entered, symlinked directories are not
+// followed to avoid loops, and outputs of a previous run (files ending in
+// UpdatedSuffix or BackupSuffix) are skipped.
+func FindScripts(root, include string) ([]string, error) {
+	paths, _, err := FindScriptsExcluding(root, include, nil)
+	return paths, err
+}
+
+// FindScriptsExcluding is FindScripts that also leaves out the files matching
+// one of the exclude patterns (see MatchExcludePattern). A directory matching
+// a pattern is not entered, so that "vendor" excludes everything below it.
+// It returns how many files and directories were excluded, a directory
+// counting once.
+func FindScriptsExcluding(root, include string, exclude []string) ([]string, int, error) {
+	if err := ValidateIncludePattern(include); err != nil {
+		return nil, 0, err
+	}
+	for _, pattern := range exclude {
+		if err := ValidateExcludePattern(pattern); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var paths []string
+	var excluded int
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if excludedPath(root, path, exclude) {
+				excluded++
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+		} else if !d.Type().IsRegular() {
+			return nil
+		}
+
+		name := d.Name()
+		if strings.HasSuffix(name, UpdatedSuffix) || strings.HasSuffix(name, BackupSuffix) {
+			return nil
+		}
+		if include != "" {
+			if matched, _ := filepath.Match(include, name); !matched {
+				return nil
+			}
+		} else if !IsShellScript(path) {
+			return nil
+		}
+		if excludedPath(root, path, exclude) {
+			excluded++
+			return nil
+		}
+
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return paths, excluded, nil
+}
+
+// excludedPath reports whether path, below root, matches one of the exclude patterns
+func excludedPath(root, path string, exclude []string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range exclude {
+		if MatchExcludePattern(pattern, filepath.ToSlash(rel)) {
+			return true
+		}
+	}
+	return false
+}
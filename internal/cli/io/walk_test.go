@@ -0,0 +1,186 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindScripts(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.sh", "b.txt", "sub/c.sh", "sub/c.sh.updated", "sub/deep/d.sh"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("echo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A symlink back to the root must not be followed
+	if err := os.Symlink(root, filepath.Join(root, "sub", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindScripts(root, "*.sh")
+	if err != nil {
+		t.Fatalf("FindScripts failed: %v", err)
+	}
+	expected := []string{
+		filepath.Join(root, "a.sh"),
+		filepath.Join(root, "sub", "c.sh"),
+		filepath.Join(root, "sub", "deep", "d.sh"),
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("FindScripts() = %v, expected %v", got, expected)
+	}
+
+	// Without include filter only shell scripts are found, outside hidden directories
+	files := map[string]string{
+		"deploy":          "#!/usr/bin/env bash\nusacloud server list\n",
+		"tool":            "#!/bin/sh\n",
+		"script.py":       "#!/usr/bin/python3\n",
+		".git/HEAD":       "ref: refs/heads/main\n",
+		".git/hooks/a.sh": "echo\n",
+		".svn/entries.sh": "echo\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all, err := FindScripts(root, "")
+	if err != nil {
+		t.Fatalf("FindScripts failed: %v", err)
+	}
+	expected = []string{
+		filepath.Join(root, "a.sh"),
+		filepath.Join(root, "deploy"),
+		filepath.Join(root, "sub", "c.sh"),
+		filepath.Join(root, "sub", "deep", "d.sh"),
+		filepath.Join(root, "tool"),
+	}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("FindScripts() without include filter = %v, expected %v", all, expected)
+	}
+
+	// Hidden directories are skipped even when the include pattern matches their files
+	withHidden, err := FindScripts(root, "*")
+	if err != nil {
+		t.Fatalf("FindScripts failed: %v", err)
+	}
+	for _, path := range withHidden {
+		if strings.Contains(path, ".git") || strings.Contains(path, ".svn") {
+			t.Errorf("Expected hidden directories to be skipped, got %s", path)
+		}
+	}
+}
+
+func TestIsShellScript(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"a.sh", "echo\n", true},
+		{"bash", "#!/bin/bash\n", true},
+		{"env", "#!/usr/bin/env -S zsh -e\n", true},
+		{"spaced", "#! /bin/sh", true},
+		{"python", "#!/usr/bin/env python3\n", false},
+		{"plain", "usacloud server list\n", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := IsShellScript(path); got != tt.want {
+			t.Errorf("IsShellScript(%s) = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindScripts_InvalidPattern(t *testing.T) {
+	if _, err := FindScripts(t.TempDir(), "[*.sh"); err == nil {
+		t.Error("Expected error for malformed include pattern")
+	}
+}
+
+func TestFindScriptsExcluding(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"deploy.sh", "vendor/lib.sh", "vendor/deep/tool.sh", "gen/api.generated.sh", "notes.txt"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("echo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, excluded, err := FindScriptsExcluding(root, "*.sh", []string{"vendor/**", "*.generated.sh"})
+	if err != nil {
+		t.Fatalf("FindScriptsExcluding failed: %v", err)
+	}
+	if expected := []string{filepath.Join(root, "deploy.sh")}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("FindScriptsExcluding() = %v, expected %v", got, expected)
+	}
+	// notes.txt is not counted: --include leaves it out before the exclusion.
+	// vendor is not entered and counts once.
+	if excluded != 2 {
+		t.Errorf("Expected 2 excluded paths, got %d", excluded)
+	}
+
+	// A bare directory name excludes everything below it, at any depth
+	for _, pattern := range []string{"vendor", "deep"} {
+		got, _, err := FindScriptsExcluding(root, "*.sh", []string{pattern})
+		if err != nil {
+			t.Fatalf("FindScriptsExcluding failed: %v", err)
+		}
+		for _, path := range got {
+			if strings.Contains(path, string(filepath.Separator)+pattern+string(filepath.Separator)) {
+				t.Errorf("Expected --exclude=%s to exclude %s", pattern, path)
+			}
+		}
+	}
+
+	if _, _, err := FindScriptsExcluding(root, "", []string{"vendor/[a"}); err == nil {
+		t.Error("Expected error for malformed exclude pattern")
+	}
+}
+
+func TestMatchExcludePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"vendor/**", "vendor/lib.sh", true},
+		{"vendor/**", "vendor/a/b/c.sh", true},
+		{"vendor/**", "src/vendor/lib.sh", false},
+		{"**/vendor/**", "src/vendor/lib.sh", true},
+		{"vendor/", "vendor/a/lib.sh", true},
+		{"./vendor/*.sh", "vendor/lib.sh", true},
+		{"vendor/*.sh", "vendor/a/lib.sh", false},
+		{"*.generated.sh", "api.generated.sh", true},
+		{"*.generated.sh", "gen/deep/api.generated.sh", true},
+		{"*.generated.sh", "api.sh", false},
+		{"scripts/**/test_*.sh", "scripts/test_a.sh", true},
+		{"scripts/**/test_*.sh", "scripts/x/y/test_a.sh", true},
+		{"scripts/**/test_*.sh", "scripts/x/a.sh", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchExcludePattern(tt.pattern, tt.rel); got != tt.want {
+			t.Errorf("MatchExcludePattern(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+}
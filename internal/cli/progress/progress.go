@@ -0,0 +1,215 @@
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// Styles of a Bar, the values of the [output] progress_style setting
+const (
+	StyleBar        = "bar"        // [#########-----------]  45% (9/20)
+	StylePercentage = "percentage" //  45% (9/20)
+	StyleDots       = "dots"       // .........  9/20
+)
+
+const (
+	barWidth       = 30
+	maxLabelWidth  = 40
+	redrawInterval = 100 * time.Millisecond
+	clearLine      = "\r\033[K"
+)
+
+// spinnerFrames are drawn in turn when the total is unknown
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// Options configures a Bar
+type Options struct {
+	Style string // one of the Style constants; unknown styles fall back to StyleBar
+	Color bool
+}
+
+// Bar is a progress bar drawn on one line of a terminal, or a spinner when
+// the total is unknown. It is safe for concurrent use, and SetLabel, Add and
+// Finish of a nil Bar do nothing, so callers can keep a nil Bar when progress
+// is disabled.
+type Bar struct {
+	w        io.Writer
+	total    int
+	options  Options
+	interval time.Duration
+
+	mutex    sync.Mutex
+	current  int
+	label    string
+	frame    int
+	drawn    bool
+	lastDraw time.Time
+	finished bool
+}
+
+// Enabled reports whether progress can be drawn on f: it must be a terminal,
+// and CI=true, which CI services set, disables progress
+func Enabled(f *os.File) bool {
+	if strings.EqualFold(os.Getenv("CI"), "true") {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// New creates a progress bar of total steps written to w. A total of zero or
+// less draws a spinner with the number of steps done instead.
+func New(w io.Writer, total int, options Options) *Bar {
+	return &Bar{w: w, total: total, options: options, interval: redrawInterval}
+}
+
+// SetLabel sets the text drawn after the progress, such as the file being processed
+func (b *Bar) SetLabel(label string) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.label = label
+	b.update()
+}
+
+// Add advances the progress by n steps
+func (b *Bar) Add(n int) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.current += n
+	b.frame++
+	b.update()
+}
+
+// update redraws the progress line, at most once per 100ms except for the
+// last step; the mutex must be held
+func (b *Bar) update() {
+	if b.finished {
+		return
+	}
+	if b.drawn && b.current != b.total && time.Since(b.lastDraw) < b.interval {
+		return
+	}
+	b.draw()
+}
+
+// Finish clears the progress line; later calls of Add draw nothing
+func (b *Bar) Finish() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.clear()
+	b.finished = true
+}
+
+// Bypass returns a writer for output printed while the bar is drawn on the
+// same terminal. Each write clears the progress line first and draws it again
+// below the output once a complete line was written.
+func (b *Bar) Bypass() io.Writer {
+	return bypassWriter{b}
+}
+
+type bypassWriter struct {
+	b *Bar
+}
+
+func (bw bypassWriter) Write(p []byte) (int, error) {
+	b := bw.b
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.clear()
+	n, err := b.w.Write(p)
+	if !b.finished && endsLine(p) {
+		b.draw()
+	}
+	return n, err
+}
+
+// trailingColorCodes matches the color reset fatih/color writes after a newline
+var trailingColorCodes = regexp.MustCompile(`(\x1b\[[0-9;]*m)+$`)
+
+// endsLine reports whether p ends with a newline, ignoring trailing color codes.
+// The bar is not drawn after a partial line, which clearing it would erase.
+func endsLine(p []byte) bool {
+	return bytes.HasSuffix(trailingColorCodes.ReplaceAll(p, nil), []byte("\n"))
+}
+
+// draw writes the progress line; the mutex must be held
+func (b *Bar) draw() {
+	fmt.Fprint(b.w, clearLine+b.render())
+	b.drawn = true
+	b.lastDraw = time.Now()
+}
+
+// clear erases the progress line if it is drawn; the mutex must be held
+func (b *Bar) clear() {
+	if b.drawn {
+		fmt.Fprint(b.w, clearLine)
+		b.drawn = false
+	}
+}
+
+// render formats the progress line
+func (b *Bar) render() string {
+	var line string
+	if b.total <= 0 {
+		line = fmt.Sprintf("%s %d", spinnerFrames[b.frame%len(spinnerFrames)], b.current)
+	} else {
+		current := b.current
+		if current > b.total {
+			current = b.total
+		}
+		percent := current * 100 / b.total
+		count := fmt.Sprintf("%d/%d", current, b.total)
+		switch b.options.Style {
+		case StylePercentage:
+			line = fmt.Sprintf("%3d%% (%s)", percent, count)
+		case StyleDots:
+			line = b.colorize(strings.Repeat(".", current*barWidth/b.total)) + " " + count
+		default:
+			filled := current * barWidth / b.total
+			line = fmt.Sprintf("[%s%s] %3d%% (%s)",
+				b.colorize(strings.Repeat("#", filled)), strings.Repeat("-", barWidth-filled), percent, count)
+		}
+	}
+	if b.label != "" {
+		line += " " + truncateLabel(b.label)
+	}
+	return line
+}
+
+// colorize colors the completed part when color is enabled. The color is
+// forced on because color.NoColor follows stdout, not the terminal drawn on.
+func (b *Bar) colorize(s string) string {
+	if !b.options.Color || s == "" {
+		return s
+	}
+	c := color.New(color.FgGreen)
+	c.EnableColor()
+	return c.Sprint(s)
+}
+
+// truncateLabel keeps the end of a long label, which for a path is the file
+// name, so that the progress line does not wrap
+func truncateLabel(label string) string {
+	runes := []rune(label)
+	if len(runes) <= maxLabelWidth {
+		return label
+	}
+	return "…" + string(runes[len(runes)-maxLabelWidth+1:])
+}
@@ -0,0 +1,164 @@
+package progress
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lastLine returns the progress line drawn last
+func lastLine(buf *bytes.Buffer) string {
+	lines := strings.Split(buf.String(), clearLine)
+	return lines[len(lines)-1]
+}
+
+func TestBar_Styles(t *testing.T) {
+	tests := []struct {
+		style    string
+		expected string
+	}{
+		{StyleBar, "[###############---------------]  50% (5/10) a.sh"},
+		{"", "[###############---------------]  50% (5/10) a.sh"},
+		{StylePercentage, " 50% (5/10) a.sh"},
+		{StyleDots, "............... 5/10 a.sh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			var buf bytes.Buffer
+			bar := New(&buf, 10, Options{Style: tt.style})
+			bar.interval = 0
+			bar.SetLabel("a.sh")
+			bar.Add(5)
+			if got := lastLine(&buf); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBar_Spinner(t *testing.T) {
+	var buf bytes.Buffer
+	bar := New(&buf, 0, Options{})
+	bar.interval = 0
+	bar.Add(1)
+	first := lastLine(&buf)
+	bar.Add(1)
+	second := lastLine(&buf)
+	if first != "/ 1" || second != "- 2" {
+		t.Errorf("Expected the spinner to turn, got %q and %q", first, second)
+	}
+}
+
+func TestBar_Color(t *testing.T) {
+	var buf bytes.Buffer
+	bar := New(&buf, 2, Options{Color: true})
+	bar.Add(1)
+	if !strings.Contains(buf.String(), "\x1b[32m") {
+		t.Errorf("Expected the completed part in green, got %q", buf.String())
+	}
+}
+
+func TestBar_Throttle(t *testing.T) {
+	var buf bytes.Buffer
+	bar := New(&buf, 100, Options{Style: StylePercentage})
+	for i := 0; i < 99; i++ {
+		bar.Add(1)
+	}
+	if draws := strings.Count(buf.String(), clearLine); draws != 1 {
+		t.Errorf("Expected redraws within 100ms to be skipped, got %d draws", draws)
+	}
+	bar.Add(1)
+	if got := lastLine(&buf); got != "100% (100/100)" {
+		t.Errorf("Expected the last step to be drawn, got %q", got)
+	}
+}
+
+func TestBar_BypassAndFinish(t *testing.T) {
+	var buf bytes.Buffer
+	bar := New(&buf, 4, Options{Style: StylePercentage})
+	bar.Add(1)
+
+	w := bar.Bypass()
+	if _, err := w.Write([]byte("message\n")); err != nil {
+		t.Fatal(err)
+	}
+	expected := clearLine + " 25% (1/4)" + clearLine + "message\n" + clearLine + " 25% (1/4)"
+	if buf.String() != expected {
+		t.Errorf("Expected the bar to be redrawn below the message, got %q", buf.String())
+	}
+
+	// A colored message ends with a color reset after its newline
+	buf.Reset()
+	if _, err := w.Write([]byte("\x1b[33mwarning\n\x1b[0m")); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastLine(&buf); got != " 25% (1/4)" {
+		t.Errorf("Expected the bar to be redrawn below a colored message, got %q", buf.String())
+	}
+
+	// A partial line is not followed by the bar
+	buf.Reset()
+	if _, err := w.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != clearLine+"partial" {
+		t.Errorf("Expected no bar after a partial line, got %q", buf.String())
+	}
+
+	buf.Reset()
+	bar.Finish()
+	bar.Add(1)
+	if _, err := w.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "after\n" {
+		t.Errorf("Expected nothing drawn after Finish, got %q", buf.String())
+	}
+}
+
+func TestBar_Nil(t *testing.T) {
+	var bar *Bar
+	bar.SetLabel("a.sh")
+	bar.Add(1)
+	bar.Finish()
+}
+
+func TestTruncateLabel(t *testing.T) {
+	long := strings.Repeat("d/", 30) + "script.sh"
+	got := truncateLabel(long)
+	if len([]rune(got)) != maxLabelWidth || !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "script.sh") {
+		t.Errorf("Unexpected truncated label %q", got)
+	}
+	if truncateLabel("a.sh") != "a.sh" {
+		t.Error("Expected short labels unchanged")
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	t.Setenv("CI", "")
+	if Enabled(file) {
+		t.Error("Expected progress to be disabled when not writing to a terminal")
+	}
+
+	// CI=true disables progress even on a terminal
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip("no terminal available")
+	}
+	defer tty.Close()
+	if !Enabled(tty) {
+		t.Error("Expected progress to be enabled on a terminal")
+	}
+	t.Setenv("CI", "true")
+	if Enabled(tty) {
+		t.Error("Expected CI=true to disable progress")
+	}
+}
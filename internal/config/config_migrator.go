@@ -50,49 +50,25 @@ func (cm *ConfigMigrator) MigrateConfig(configPath string) error {
 func (cm *ConfigMigrator) MigrateFromEnvFile(envPath, configPath string) error {
 	fmt.Println("🔄 .envファイルから新設定形式への移行を開始します")
 
-	envVars, err := cm.loadEnvFile(envPath)
+	result, err := MigrateEnvFile(envPath, configPath)
 	if err != nil {
-		return err
+		return fmt.Errorf("設定保存に失敗: %w", err)
 	}
 
-	config := NewIntegratedConfig()
-
-	envMappings := map[string]func(string){
-		"SAKURACLOUD_ACCESS_TOKEN": func(v string) {
-		},
-		"SAKURACLOUD_ACCESS_TOKEN_SECRET": func(v string) {
-		},
-		"USACLOUD_COLOR_OUTPUT": func(v string) {
-			config.General.ColorOutput = (v == "true")
-		},
-		"USACLOUD_VERBOSE": func(v string) {
-			config.General.Verbose = (v == "true")
-		},
-		"USACLOUD_INTERACTIVE": func(v string) {
-			config.General.InteractiveByDefault = (v == "true")
-		},
-		"USACLOUD_STRICT_MODE": func(v string) {
-			config.Validation.StrictMode = (v == "true")
-		},
+	for _, line := range result.InvalidLines {
+		fmt.Printf("  ⚠️  無効な行をスキップ (行 %d)\n", line)
 	}
-
-	migratedCount := 0
-	for envKey, value := range envVars {
-		if mapper, exists := envMappings[envKey]; exists {
-			mapper(value)
-			migratedCount++
-			fmt.Printf("  ✓ %s: %s\n", envKey, value)
-		}
+	for _, migrated := range result.Migrated {
+		fmt.Printf("  ✓ %s\n", migrated)
+	}
+	for _, key := range result.Legacy {
+		fmt.Printf("  ⚠️  %s: 対応する設定がないため [%s] に保持しました\n", key, LegacySection)
 	}
 
-	if migratedCount == 0 {
+	if len(result.Migrated) == 0 {
 		fmt.Println("  💡 移行可能な設定項目が見つかりませんでした")
 	} else {
-		fmt.Printf("  📊 %d個の設定項目を移行しました\n", migratedCount)
-	}
-
-	if err := config.SaveAs(configPath); err != nil {
-		return fmt.Errorf("設定保存に失敗: %w", err)
+		fmt.Printf("  📊 %d個の設定項目を移行しました\n", len(result.Migrated))
 	}
 
 	backupPath := envPath + ".migrated." + time.Now().Format("20060102-150405")
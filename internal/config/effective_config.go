@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/armaniacs/usacloud-update/internal/config/profile"
+	"gopkg.in/ini.v1"
+)
+
+// Sources of an EffectiveSetting, from the lowest precedence
+const (
+	SettingSourceDefault = "default" // built-in default
+	SettingSourceFile    = "file"    // set in the configuration file
+	SettingSourceEnv     = "env"     // overridden by an environment variable
+	SettingSourceProfile = "profile" // overridden by the active profile
+)
+
+// EffectiveSetting is a setting as a run uses it, with where its value comes from
+type EffectiveSetting struct {
+	Section string
+	Key     string
+	Value   string // masked for sensitive keys
+	Source  string // one of the SettingSource constants
+	Origin  string // environment variable or profile name for those sources
+}
+
+// EffectiveConfig is the configuration resolved as ReadIntegratedConfig does
+type EffectiveConfig struct {
+	Path      string
+	FileFound bool   // false when the defaults are used because the file does not exist
+	Profile   string // active profile, "" when the file does not define it
+	Settings  []EffectiveSetting
+}
+
+// ResolveEffectiveConfig loads configPath like ReadIntegratedConfig, applying
+// the environment variable overrides and the active profile, and returns every
+// setting with its source. The settings are in section order, then in the order
+// the file writes them. A missing file is not an error: the defaults are used.
+func ResolveEffectiveConfig(configPath string) (*EffectiveConfig, error) {
+	return ResolveEffectiveConfigProfile(configPath, "")
+}
+
+// ResolveEffectiveConfigProfile is like ResolveEffectiveConfig but applies
+// profileName, when not empty, as ReadIntegratedConfigProfile does: it takes
+// precedence over the environment and the file, may name a built-in profile
+// and must exist.
+func ResolveEffectiveConfigProfile(configPath, profileName string) (*EffectiveConfig, error) {
+	ic := NewIntegratedConfig()
+	ic.configPath = configPath
+	ic.autoSave = false
+
+	result := &EffectiveConfig{Path: configPath}
+	sources := make(map[string]EffectiveSetting)
+	var file *ini.File
+	if _, err := os.Stat(configPath); err == nil {
+		if err := ic.loadFromFile(); err != nil {
+			return nil, fmt.Errorf("設定ファイル読み込みに失敗: %w", err)
+		}
+		if file, err = ini.Load(configPath); err != nil {
+			return nil, fmt.Errorf("設定ファイル読み込みに失敗: %w", err)
+		}
+		result.FileFound = true
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("設定ファイル読み込みに失敗: %w", err)
+	}
+
+	for _, override := range ic.applyEnvironmentOverrides() {
+		sources[override.Section+"."+override.Key] = EffectiveSetting{Source: SettingSourceEnv, Origin: override.Name}
+	}
+
+	if profileName != "" {
+		if err := ic.selectProfile(profileName); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, exists := ic.Profiles[ic.General.Profile]; exists {
+		overrides, err := ic.profileOverrides(ic.General.Profile)
+		if err != nil {
+			return nil, fmt.Errorf("プロファイル適用に失敗: %w", err)
+		}
+		keySections := make(map[string]string)
+		for section, keys := range schemaSectionKeys() {
+			for key := range keys {
+				keySections[key] = section
+			}
+		}
+		for _, override := range overrides {
+			if ic.applyOverride(override.Key, override.Value) {
+				sources[keySections[override.Key]+"."+override.Key] = EffectiveSetting{Source: SettingSourceProfile, Origin: override.Profile}
+			}
+		}
+		ic.profileName = ic.General.Profile
+		result.Profile = ic.profileName
+	}
+
+	for _, section := range SettingSections {
+		v := reflect.ValueOf(ic.sectionStruct(section)).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			key := v.Type().Field(i).Tag.Get("ini")
+			if key == "" || key == "-" {
+				continue
+			}
+			setting, overridden := sources[section+"."+key]
+			if !overridden {
+				setting.Source = SettingSourceDefault
+				if file != nil && file.Section(section).HasKey(key) {
+					setting.Source = SettingSourceFile
+				}
+			}
+			setting.Section, setting.Key = section, key
+			setting.Value = fmt.Sprintf("%v", v.Field(i).Interface())
+			if profile.IsSensitiveKey(key) {
+				setting.Value = profile.MaskValue(setting.Value)
+			}
+			result.Settings = append(result.Settings, setting)
+		}
+	}
+	return result, nil
+}
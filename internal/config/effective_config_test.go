@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveEffectiveConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "test-config.conf")
+	content := `[general]
+profile = ci
+log_level = warn
+
+[validation]
+max_suggestions = 7
+
+[profiles.expert]
+strict_mode = true
+show_progress = false
+
+[profiles.ci]
+based_on = expert
+show_progress = true
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	t.Setenv("USACLOUD_UPDATE_VERBOSE", "true")
+	t.Setenv("USACLOUD_UPDATE_STRICT_MODE", "false")
+
+	effective, err := ResolveEffectiveConfig(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !effective.FileFound || effective.Profile != "ci" {
+		t.Errorf("Expected the file and profile ci, got %+v", effective)
+	}
+	if first := effective.Settings[0]; first.Section != "general" || first.Key != "version" {
+		t.Errorf("Expected the settings to start with general.version, got %+v", first)
+	}
+
+	settings := make(map[string]EffectiveSetting)
+	for _, s := range effective.Settings {
+		settings[s.Section+"."+s.Key] = s
+	}
+	tests := []struct {
+		key, value, source, origin string
+	}{
+		{"general.log_level", "warn", SettingSourceFile, ""},
+		{"validation.max_suggestions", "7", SettingSourceFile, ""},
+		{"general.language", "ja", SettingSourceDefault, ""},
+		{"general.verbose", "true", SettingSourceEnv, "USACLOUD_UPDATE_VERBOSE"},
+		// The profile is applied after the environment variables
+		{"validation.strict_mode", "true", SettingSourceProfile, "expert"},
+		{"output.show_progress", "true", SettingSourceProfile, "ci"},
+	}
+	for _, tt := range tests {
+		s := settings[tt.key]
+		if s.Value != tt.value || s.Source != tt.source || s.Origin != tt.origin {
+			t.Errorf("%s: expected %s from %s %s, got %+v", tt.key, tt.value, tt.source, tt.origin, s)
+		}
+	}
+}
+
+func TestResolveEffectiveConfig_MissingFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.conf")
+
+	effective, err := ResolveEffectiveConfig(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if effective.FileFound || effective.Profile != "" {
+		t.Errorf("Expected defaults without a profile, got %+v", effective)
+	}
+	for _, s := range effective.Settings {
+		if s.Source != SettingSourceDefault {
+			t.Errorf("Expected only defaults, got %+v", s)
+		}
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Error("Expected missing config file not to be created")
+	}
+}
+
+func TestResolveEffectiveConfigProfile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "test-config.conf")
+	content := `[general]
+profile = ci
+
+[profiles.ci]
+show_progress = true
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	// The profile given takes precedence over the file and may be a built-in one
+	effective, err := ResolveEffectiveConfigProfile(configPath, "expert")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if effective.Profile != "expert" {
+		t.Errorf("Expected profile expert, got %q", effective.Profile)
+	}
+	for _, s := range effective.Settings {
+		if s.Section == "output" && s.Key == "show_progress" && (s.Value != "false" || s.Source != SettingSourceProfile || s.Origin != "expert") {
+			t.Errorf("Expected show_progress from the expert profile, got %+v", s)
+		}
+	}
+
+	if _, err := ResolveEffectiveConfigProfile(configPath, "nosuch"); err == nil || !strings.Contains(err.Error(), "beginner, ci, default, expert") {
+		t.Errorf("Expected an unknown profile to list the available ones, got %v", err)
+	}
+}
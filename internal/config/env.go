@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/armaniacs/usacloud-update/internal/validation"
 )
 
 // SandboxConfig holds all configuration for sandbox functionality
@@ -19,12 +21,19 @@ type SandboxConfig struct {
 
 	// Application settings
 	Enabled     bool
-	Timeout     time.Duration
+	Timeout     time.Duration // per command
+	RetryCount  int           // retries of a command after a transient failure
+	RateLimit   float64       // commands sent to the API per second (0: unlimited)
+	Destructive []string      // subcommands a batch run asks to confirm before executing
 	Debug       bool
 	DryRun      bool
 	Interactive bool
 }
 
+// DefaultDestructiveCommands are the subcommands that delete or stop resources:
+// the ones usacloud asks to confirm and those that stop a server without asking
+var DefaultDestructiveCommands = append(append([]string(nil), validation.ConfirmationSubcommands...), "power-off", "reboot")
+
 // DefaultConfig returns the default sandbox configuration
 func DefaultConfig() *SandboxConfig {
 	return &SandboxConfig{
@@ -32,9 +41,11 @@ func DefaultConfig() *SandboxConfig {
 		APIEndpoint: "https://secure.sakura.ad.jp/cloud/zone/tk1v/api/cloud/1.1/",
 		Enabled:     false,
 		Timeout:     30 * time.Second,
+		RetryCount:  2,
 		Debug:       false,
 		DryRun:      false,
 		Interactive: true,
+		Destructive: DefaultDestructiveCommands,
 	}
 }
 
@@ -105,6 +116,16 @@ func LoadFromEnv() (*SandboxConfig, error) {
 			config.Timeout = time.Duration(timeout) * time.Second
 		}
 	}
+	if retryStr := getEnv("USACLOUD_UPDATE_RETRY_COUNT", ""); retryStr != "" {
+		if retryCount, err := strconv.Atoi(retryStr); err == nil && retryCount >= 0 {
+			config.RetryCount = retryCount
+		}
+	}
+	if rateStr := getEnv("USACLOUD_UPDATE_RATE_LIMIT", ""); rateStr != "" {
+		if rateLimit, err := strconv.ParseFloat(rateStr, 64); err == nil && rateLimit >= 0 {
+			config.RateLimit = rateLimit
+		}
+	}
 
 	return config, nil
 }
@@ -116,24 +137,40 @@ func (c *SandboxConfig) Validate() error {
 	}
 
 	var errors []string
+	for _, problem := range c.validationProblems() {
+		errors = append(errors, problem.Message)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("configuration validation failed: %s", strings.Join(errors, ", "))
+	}
+
+	return nil
+}
+
+// validationProblem is a setting that makes Validate fail
+type validationProblem struct {
+	Key     string // configuration file key of the setting
+	Message string
+}
+
+// validationProblems returns the settings the sandbox cannot run with
+func (c *SandboxConfig) validationProblems() []validationProblem {
+	var problems []validationProblem
 
 	if c.AccessToken == "" {
-		errors = append(errors, "SAKURACLOUD_ACCESS_TOKEN is required")
+		problems = append(problems, validationProblem{"access_token", "SAKURACLOUD_ACCESS_TOKEN is required"})
 	}
 
 	if c.AccessTokenSecret == "" {
-		errors = append(errors, "SAKURACLOUD_ACCESS_TOKEN_SECRET is required")
+		problems = append(problems, validationProblem{"access_token_secret", "SAKURACLOUD_ACCESS_TOKEN_SECRET is required"})
 	}
 
 	if c.Zone != "tk1v" {
-		errors = append(errors, "SAKURACLOUD_ZONE must be 'tk1v' for sandbox operations")
+		problems = append(problems, validationProblem{"zone", "SAKURACLOUD_ZONE must be 'tk1v' for sandbox operations"})
 	}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("configuration validation failed: %s", strings.Join(errors, ", "))
-	}
-
-	return nil
+	return problems
 }
 
 // GetUsacloudEnv returns environment variables formatted for usacloud command execution
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/validation"
 )
 
 // UsacloudEnvVars represents the environment variables used by usacloud
@@ -66,9 +68,8 @@ func (e *EnvDetector) ValidateEnvVarValues(envVars *UsacloudEnvVars) error {
 
 	// Validate zone format if provided
 	if envVars.Zone != "" {
-		validZones := []string{"tk1v", "tk1a", "is1a", "is1b", "tk1b"}
 		isValidZone := false
-		for _, zone := range validZones {
+		for _, zone := range validation.Zones {
 			if envVars.Zone == zone {
 				isValidZone = true
 				break
@@ -76,7 +77,7 @@ func (e *EnvDetector) ValidateEnvVarValues(envVars *UsacloudEnvVars) error {
 		}
 		if !isValidZone {
 			return fmt.Errorf("SAKURACLOUD_ZONE '%s' is not a valid zone. Valid zones are: %s",
-				envVars.Zone, strings.Join(validZones, ", "))
+				envVars.Zone, strings.Join(validation.Zones, ", "))
 		}
 	}
 
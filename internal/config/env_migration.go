@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// LegacySection holds the .env keys MigrateEnvFile has no setting for. The
+// section is kept as it is and not read by either configuration.
+const LegacySection = "legacy"
+
+// envSetting is where a legacy .env key goes in the INI configuration
+type envSetting struct {
+	section, key string
+}
+
+// envSettings maps the keys read by LoadFromEnv, and the USACLOUD_* general
+// settings of earlier releases, to their INI settings
+var envSettings = map[string]envSetting{
+	"SAKURACLOUD_ACCESS_TOKEN":        {"sakura-cloud", "access_token"},
+	"SAKURACLOUD_ACCESS_TOKEN_SECRET": {"sakura-cloud", "access_token_secret"},
+	"SAKURACLOUD_ZONE":                {"sakura-cloud", "zone"},
+	"SAKURACLOUD_API_URL":             {"sakura-cloud", "api_endpoint"},
+	"USACLOUD_UPDATE_SANDBOX_ENABLED": {"sandbox", "enabled"},
+	"USACLOUD_UPDATE_DEBUG":           {"sandbox", "debug"},
+	"USACLOUD_UPDATE_DRY_RUN":         {"sandbox", "dry_run"},
+	"USACLOUD_UPDATE_INTERACTIVE":     {"sandbox", "interactive"},
+	"USACLOUD_UPDATE_TIMEOUT":         {"sandbox", "timeout"},
+	"USACLOUD_UPDATE_RETRY_COUNT":     {"sandbox", "retry_count"},
+	"USACLOUD_UPDATE_RATE_LIMIT":      {"sandbox", "rate_limit"},
+	"USACLOUD_COLOR_OUTPUT":           {"general", "color_output"},
+	"USACLOUD_VERBOSE":                {"general", "verbose"},
+	"USACLOUD_INTERACTIVE":            {"general", "interactive_by_default"},
+	"USACLOUD_STRICT_MODE":            {"validation", "strict_mode"},
+}
+
+// EnvFileEntry is a KEY=value line of a .env file
+type EnvFileEntry struct {
+	Line  int
+	Key   string
+	Value string // without surrounding quotes
+}
+
+// EnvMigrationResult describes what MigrateEnvFile wrote
+type EnvMigrationResult struct {
+	Migrated     []string // "KEY → [section] key", in file order
+	Legacy       []string // keys kept in [legacy], in file order
+	InvalidLines []int    // lines that are not KEY=value, skipped
+}
+
+// ReadEnvFile returns the KEY=value lines of a .env file in file order,
+// accepting an optional "export " prefix, and the numbers of the other
+// non-comment lines
+func ReadEnvFile(envPath string) ([]EnvFileEntry, []int, error) {
+	file, err := os.Open(envPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var entries []EnvFileEntry
+	var invalid []int
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || key == "" {
+			invalid = append(invalid, lineNumber)
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+		if (strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") && len(value) >= 2) ||
+			(strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) >= 2) {
+			value = value[1 : len(value)-1]
+		}
+		entries = append(entries, EnvFileEntry{Line: lineNumber, Key: key, Value: value})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf(".envファイル読み込みエラー: %w", err)
+	}
+	return entries, invalid, nil
+}
+
+// MigrateEnvFile writes the settings of a legacy .env file to a new INI
+// configuration at configPath. The credentials and sandbox settings go to
+// [sakura-cloud] and [sandbox], the others to the IntegratedConfig sections,
+// which are written with their defaults as by SaveAs, and keys without a
+// setting are preserved in [legacy]. The file is written with the same 0600
+// permissions as SaveAs; the .env file is left untouched.
+func MigrateEnvFile(envPath, configPath string) (*EnvMigrationResult, error) {
+	entries, invalid, err := ReadEnvFile(envPath)
+	if err != nil {
+		return nil, err
+	}
+	result := &EnvMigrationResult{InvalidLines: invalid}
+
+	cfg := ini.Empty()
+	for _, section := range []string{"sakura-cloud", "sandbox"} {
+		if _, err := cfg.NewSection(section); err != nil {
+			return nil, err
+		}
+	}
+	if err := NewIntegratedConfig().writeSections(cfg); err != nil {
+		return nil, err
+	}
+
+	var legacy []EnvFileEntry
+	for _, entry := range entries {
+		setting, ok := envSettings[entry.Key]
+		if !ok {
+			legacy = append(legacy, entry)
+			result.Legacy = append(result.Legacy, entry.Key)
+			continue
+		}
+		cfg.Section(setting.section).Key(setting.key).SetValue(entry.Value)
+		result.Migrated = append(result.Migrated, fmt.Sprintf("%s → [%s] %s", entry.Key, setting.section, setting.key))
+	}
+
+	if len(legacy) > 0 {
+		section, err := cfg.NewSection(LegacySection)
+		if err != nil {
+			return nil, err
+		}
+		section.Comment = "# .env から移行できなかった設定（usacloud-update は読み込みません）"
+		for _, entry := range legacy {
+			section.Key(entry.Key).SetValue(entry.Value)
+		}
+	}
+
+	if err := saveINIFile(cfg, configPath); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
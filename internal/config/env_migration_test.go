@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	envContent := `# legacy settings
+SAKURACLOUD_ACCESS_TOKEN="token-123"
+export SAKURACLOUD_ACCESS_TOKEN_SECRET='secret-456'
+SAKURACLOUD_ZONE=tk1v
+USACLOUD_UPDATE_TIMEOUT=90
+USACLOUD_STRICT_MODE=true
+MY_PROJECT=demo
+not a setting
+`
+	if err := os.WriteFile(envPath, []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(dir, "usacloud-update.conf")
+	result, err := MigrateEnvFile(envPath, configPath)
+	if err != nil {
+		t.Fatalf("MigrateEnvFile() failed: %v", err)
+	}
+
+	wantMigrated := []string{
+		"SAKURACLOUD_ACCESS_TOKEN → [sakura-cloud] access_token",
+		"SAKURACLOUD_ACCESS_TOKEN_SECRET → [sakura-cloud] access_token_secret",
+		"SAKURACLOUD_ZONE → [sakura-cloud] zone",
+		"USACLOUD_UPDATE_TIMEOUT → [sandbox] timeout",
+		"USACLOUD_STRICT_MODE → [validation] strict_mode",
+	}
+	if !reflect.DeepEqual(result.Migrated, wantMigrated) {
+		t.Errorf("Migrated = %v, want %v", result.Migrated, wantMigrated)
+	}
+	if !reflect.DeepEqual(result.Legacy, []string{"MY_PROJECT"}) || !reflect.DeepEqual(result.InvalidLines, []int{8}) {
+		t.Errorf("unexpected legacy keys %v / invalid lines %v", result.Legacy, result.InvalidLines)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("config file permissions = %o, want 600", perm)
+		}
+	}
+
+	// Both configurations read the migrated file
+	sandboxConfig, err := LoadFromFileWithPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromFileWithPath() failed: %v", err)
+	}
+	if sandboxConfig.AccessToken != "token-123" || sandboxConfig.AccessTokenSecret != "secret-456" || sandboxConfig.Timeout != 90*time.Second {
+		t.Errorf("unexpected sandbox config: %+v", sandboxConfig)
+	}
+	integrated, err := ReadIntegratedConfig(configPath)
+	if err != nil {
+		t.Fatalf("ReadIntegratedConfig() failed: %v", err)
+	}
+	if !integrated.Validation.StrictMode {
+		t.Error("strict_mode should have been migrated")
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "[legacy]") || !strings.Contains(string(data), "MY_PROJECT") {
+		t.Errorf("unmapped keys should be kept in [legacy]:\n%s", data)
+	}
+
+	// The only remark on the migrated file is the preserved key
+	problems, err := CheckFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Section != LegacySection || problems[0].Severity != ProblemWarning {
+		t.Errorf("unexpected problems: %+v", problems)
+	}
+}
+
+func TestMigrateEnvFile_CommentCharactersInValue(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	envContent := "SAKURACLOUD_ACCESS_TOKEN=token-123\nSAKURACLOUD_ACCESS_TOKEN_SECRET=\"se#cr;et\"\n"
+	if err := os.WriteFile(envPath, []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(dir, "usacloud-update.conf")
+	if _, err := MigrateEnvFile(envPath, configPath); err != nil {
+		t.Fatalf("MigrateEnvFile() failed: %v", err)
+	}
+
+	sandboxConfig, err := LoadFromFileWithPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromFileWithPath() failed: %v", err)
+	}
+	if sandboxConfig.AccessTokenSecret != "se#cr;et" {
+		t.Errorf("AccessTokenSecret = %q, want %q", sandboxConfig.AccessTokenSecret, "se#cr;et")
+	}
+}
+
+func TestMigrateEnvFile_NotFound(t *testing.T) {
+	if _, err := MigrateEnvFile(filepath.Join(t.TempDir(), ".env"), filepath.Join(t.TempDir(), "out.conf")); !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/armaniacs/usacloud-update/internal/validation"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -47,6 +49,17 @@ func TestDefaultConfig(t *testing.T) {
 	if !config.Interactive {
 		t.Error("Default config should have Interactive=true")
 	}
+
+	// Every subcommand usacloud asks to confirm is destructive
+	for _, sub := range validation.ConfirmationSubcommands {
+		found := false
+		for _, op := range config.Destructive {
+			found = found || op == sub
+		}
+		if !found {
+			t.Errorf("Expected %q in the default destructive commands %v", sub, config.Destructive)
+		}
+	}
 }
 
 func TestLoadFromEnv(t *testing.T) {
@@ -3,6 +3,7 @@ package config
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -105,8 +106,43 @@ func LoadFromFileWithPath(configPath string) (*SandboxConfig, error) {
 	}
 	defer file.Close()
 
-	// Parse INI-style configuration
-	scanner := bufio.NewScanner(file)
+	lines, err := scanConfigLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	for _, line := range lines {
+		switch {
+		case line.Header:
+			continue
+		case line.Invalid:
+			return nil, fmt.Errorf("invalid syntax at line %d: %s", line.Number, line.Text)
+		}
+
+		// Apply configuration based on current section
+		if err := applyConfigValue(config, line.Section, line.Key, line.Value); err != nil {
+			return nil, fmt.Errorf("error at line %d: %w", line.Number, err)
+		}
+	}
+
+	return config, nil
+}
+
+// configLine is a section header or key = value line of a configuration file
+type configLine struct {
+	Number  int
+	Section string // lower-cased section the line belongs to
+	Header  bool   // the line starts Section
+	Key     string
+	Value   string // without surrounding quotes
+	Text    string // trimmed line as written
+	Invalid bool   // neither a section header nor key = value
+}
+
+// scanConfigLines parses an INI-style configuration, leaving out empty lines and comments
+func scanConfigLines(r io.Reader) ([]configLine, error) {
+	var lines []configLine
+	scanner := bufio.NewScanner(r)
 	lineNum := 0
 	currentSection := ""
 
@@ -122,35 +158,39 @@ func LoadFromFileWithPath(configPath string) (*SandboxConfig, error) {
 		// Handle sections
 		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
 			currentSection = strings.ToLower(strings.Trim(line, "[]"))
+			lines = append(lines, configLine{Number: lineNum, Section: currentSection, Header: true, Text: line})
 			continue
 		}
 
 		// Parse key=value pairs
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid syntax at line %d: %s", lineNum, line)
+			lines = append(lines, configLine{Number: lineNum, Section: currentSection, Text: line, Invalid: true})
+			continue
 		}
 
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
 
-		// Remove quotes if present
-		if (strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")) ||
-			(strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'")) {
-			value = value[1 : len(value)-1]
-		}
-
-		// Apply configuration based on current section
-		if err := applyConfigValue(config, currentSection, key, value); err != nil {
-			return nil, fmt.Errorf("error at line %d: %w", lineNum, err)
-		}
+		lines = append(lines, configLine{Number: lineNum, Section: currentSection, Key: key, Value: unquoteConfigValue(value), Text: line})
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading config file: %w", err)
-	}
+	return lines, scanner.Err()
+}
 
-	return config, nil
+// unquoteConfigValue removes the quotes around a value, including the
+// backticks and triple quotes the ini package writes around values that
+// contain '#', ';' or '`' when the IntegratedConfig sections are saved
+func unquoteConfigValue(value string) string {
+	if len(value) >= 6 && strings.HasPrefix(value, `"""`) && strings.HasSuffix(value, `"""`) {
+		return value[3 : len(value)-3]
+	}
+	for _, quote := range []string{"\"", "'", "`"} {
+		if len(value) >= 2 && strings.HasPrefix(value, quote) && strings.HasSuffix(value, quote) {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
 }
 
 // applyConfigValue applies a configuration key-value pair to the config
@@ -197,21 +237,62 @@ func applyConfigValue(config *SandboxConfig, section, key, value string) error {
 			} else {
 				return fmt.Errorf("invalid boolean value for %s: %s", key, value)
 			}
-		case "timeout":
+		case "timeout", "timeout_seconds":
 			if timeout, err := strconv.Atoi(value); err == nil {
 				config.Timeout = time.Duration(timeout) * time.Second
 			} else {
 				return fmt.Errorf("invalid timeout value: %s", value)
 			}
+		case "retry_count", "retrycount":
+			if retryCount, err := strconv.Atoi(value); err == nil && retryCount >= 0 {
+				config.RetryCount = retryCount
+			} else {
+				return fmt.Errorf("invalid retry_count value: %s", value)
+			}
+		case "rate_limit", "ratelimit":
+			if rateLimit, err := strconv.ParseFloat(value, 64); err == nil && rateLimit >= 0 {
+				config.RateLimit = rateLimit
+			} else {
+				return fmt.Errorf("invalid rate_limit value: %s", value)
+			}
+		case "destructive_commands":
+			config.Destructive = nil
+			for _, command := range strings.Split(value, ",") {
+				if command = strings.TrimSpace(command); command != "" {
+					config.Destructive = append(config.Destructive, command)
+				}
+			}
 		default:
 			return fmt.Errorf("unknown sandbox key: %s", key)
 		}
 	default:
+		// Sections owned by IntegratedConfig may share the same file
+		if isIntegratedConfigSection(section) || section == LegacySection {
+			return nil
+		}
 		return fmt.Errorf("unknown section: %s", section)
 	}
 	return nil
 }
 
+// integratedConfigSections lists the sections read by IntegratedConfig
+var integratedConfigSections = map[string]bool{
+	"general":        true,
+	"transform":      true,
+	"validation":     true,
+	"error_feedback": true,
+	"help_system":    true,
+	"performance":    true,
+	"output":         true,
+}
+
+// isIntegratedConfigSection checks if a section belongs to IntegratedConfig
+func isIntegratedConfigSection(section string) bool {
+	return integratedConfigSections[section] ||
+		strings.HasPrefix(section, "profiles.") ||
+		strings.HasPrefix(section, "environments.")
+}
+
 // SaveToFile saves configuration to the configuration file
 func (c *SandboxConfig) SaveToFile() error {
 	configPath, err := ConfigPath()
@@ -265,6 +346,9 @@ func (c *SandboxConfig) generateConfigContent() string {
 	content.WriteString(fmt.Sprintf("dry_run = %t\n", c.DryRun))
 	content.WriteString(fmt.Sprintf("interactive = %t\n", c.Interactive))
 	content.WriteString(fmt.Sprintf("timeout = %d\n", int(c.Timeout.Seconds())))
+	content.WriteString(fmt.Sprintf("retry_count = %d\n", c.RetryCount))
+	content.WriteString(fmt.Sprintf("rate_limit = %g\n", c.RateLimit))
+	content.WriteString(fmt.Sprintf("destructive_commands = %s\n", strings.Join(c.Destructive, ",")))
 	content.WriteString("\n")
 
 	content.WriteString("# Configuration notes:\n")
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Severities of a FileProblem
+const (
+	ProblemError   = "error"   // the sandbox cannot run with the file
+	ProblemWarning = "warning" // the setting is ignored
+)
+
+// FileProblem is a problem found in a configuration file
+type FileProblem struct {
+	Line     int    // 0 when the problem is a missing setting
+	Section  string // lower-cased section, empty outside any section
+	Key      string
+	Severity string
+	Message  string
+}
+
+// CheckFile checks the configuration file at configPath and returns every
+// problem found, where LoadFromFileWithPath stops at the first. Besides what
+// the loader rejects, the sandbox settings are checked as by Validate with the
+// sandbox enabled, and unknown keys in the sections read by IntegratedConfig,
+// which the loader ignores, are reported as warnings. The problems are in line
+// order, followed by the missing settings.
+func CheckFile(configPath string) ([]FileProblem, error) {
+	file, err := os.Open(configPath)
+	if os.IsNotExist(err) {
+		return nil, &ConfigNotFoundError{Path: configPath}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	lines, err := scanConfigLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	config := DefaultConfig()
+	integratedKeys := schemaSectionKeys()
+	var problems []FileProblem
+	keyLines := make(map[string]configLine) // sakura-cloud and sandbox keys by normalized name
+	for _, line := range lines {
+		problem := FileProblem{Line: line.Number, Section: line.Section, Key: line.Key, Severity: ProblemError}
+		switch {
+		case line.Header:
+			if !isSandboxConfigSection(line.Section) && !isIntegratedConfigSection(line.Section) && line.Section != LegacySection {
+				problem.Message = fmt.Sprintf("unknown section: %s", line.Section)
+				problems = append(problems, problem)
+			}
+		case line.Invalid:
+			problem.Message = fmt.Sprintf("invalid syntax: %s", line.Text)
+			problems = append(problems, problem)
+		case isSandboxConfigSection(line.Section):
+			if err := applyConfigValue(config, line.Section, line.Key, line.Value); err != nil {
+				problem.Message = err.Error()
+				problems = append(problems, problem)
+				continue
+			}
+			keyLines[normalizeConfigKey(line.Key)] = line
+		case line.Section == LegacySection:
+			problem.Severity = ProblemWarning
+			problem.Message = fmt.Sprintf("legacy key: %s (not used)", line.Key)
+			problems = append(problems, problem)
+		case isIntegratedConfigSection(line.Section):
+			// Profiles and environments accept arbitrary overrides
+			if known, ok := integratedKeys[line.Section]; ok && !known[strings.ToLower(line.Key)] {
+				problem.Severity = ProblemWarning
+				problem.Message = fmt.Sprintf("unknown %s key: %s (ignored)", line.Section, line.Key)
+				problems = append(problems, problem)
+			}
+		}
+		// Keys of an unknown section are covered by the problem of its header
+	}
+
+	if line, ok := keyLines[normalizeConfigKey("api_endpoint")]; ok && !isHTTPURL(config.APIEndpoint) {
+		problems = append(problems, FileProblem{Line: line.Number, Section: line.Section, Key: line.Key, Severity: ProblemError,
+			Message: fmt.Sprintf("invalid api_endpoint URL: %s", config.APIEndpoint)})
+	}
+
+	// --sandbox always enables the sandbox, so the credentials are checked regardless of enabled
+	config.Enabled = true
+	for _, p := range config.validationProblems() {
+		problem := FileProblem{Section: "sakura-cloud", Key: p.Key, Severity: ProblemError, Message: p.Message}
+		if line, ok := keyLines[normalizeConfigKey(p.Key)]; ok {
+			problem.Line, problem.Section, problem.Key = line.Number, line.Section, line.Key
+		}
+		problems = append(problems, problem)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if (problems[i].Line == 0) != (problems[j].Line == 0) {
+			return problems[j].Line == 0
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+// isSandboxConfigSection checks if a section is read into SandboxConfig
+func isSandboxConfigSection(section string) bool {
+	switch section {
+	case "", "sakura-cloud", "sakuracloud", "sandbox", "usacloud-update":
+		return true
+	}
+	return false
+}
+
+// normalizeConfigKey folds the spellings applyConfigValue accepts for a key
+func normalizeConfigKey(key string) string {
+	key = strings.ReplaceAll(strings.ToLower(key), "_", "")
+	if key == "apiurl" {
+		return "apiendpoint"
+	}
+	return key
+}
+
+// isHTTPURL reports whether s is an absolute http(s) URL
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
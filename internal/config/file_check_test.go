@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	content := `[sakura-cloud]
+access_token = ""
+zone = "is1a"
+api_endpoint = "secure.sakura.ad.jp/cloud"
+region = "tokyo"
+
+[sandbox]
+timeout = soon
+retry_count = 1
+
+[validation]
+strict_mode = true
+strict = true
+
+[profiles.ci]
+anything = goes
+
+[extras]
+foo = bar
+broken line
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := CheckFile(path)
+	if err != nil {
+		t.Fatalf("CheckFile() failed: %v", err)
+	}
+
+	want := []FileProblem{
+		{2, "sakura-cloud", "access_token", ProblemError, "SAKURACLOUD_ACCESS_TOKEN is required"},
+		{3, "sakura-cloud", "zone", ProblemError, "SAKURACLOUD_ZONE must be 'tk1v' for sandbox operations"},
+		{4, "sakura-cloud", "api_endpoint", ProblemError, "invalid api_endpoint URL: secure.sakura.ad.jp/cloud"},
+		{5, "sakura-cloud", "region", ProblemError, "unknown sakura-cloud key: region"},
+		{8, "sandbox", "timeout", ProblemError, "invalid timeout value: soon"},
+		{13, "validation", "strict", ProblemWarning, "unknown validation key: strict (ignored)"},
+		{18, "extras", "", ProblemError, "unknown section: extras"},
+		{20, "extras", "", ProblemError, "invalid syntax: broken line"},
+		{0, "sakura-cloud", "access_token_secret", ProblemError, "SAKURACLOUD_ACCESS_TOKEN_SECRET is required"},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("CheckFile() problems:")
+		for _, p := range problems {
+			t.Errorf("  %+v", p)
+		}
+	}
+}
+
+func TestCheckFile_Sample(t *testing.T) {
+	problems, err := CheckFile("../../usacloud-update.conf.sample")
+	if err != nil {
+		t.Fatalf("CheckFile() failed: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("the sample configuration should have no problems: %+v", problems)
+	}
+}
+
+func TestCheckFile_NotFound(t *testing.T) {
+	_, err := CheckFile(filepath.Join(t.TempDir(), "missing.conf"))
+	if !IsConfigNotFound(err) {
+		t.Errorf("expected ConfigNotFoundError, got %v", err)
+	}
+}
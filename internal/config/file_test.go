@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -238,6 +239,9 @@ debug = true
 dry_run = false
 interactive = false
 timeout = 60
+retry_count = 4
+rate_limit = 2.5
+destructive_commands = delete, shutdown
 `
 		err = os.WriteFile(configFile, []byte(configContent), 0644)
 		if err != nil {
@@ -282,6 +286,15 @@ timeout = 60
 		if config.Timeout.Seconds() != 60 {
 			t.Errorf("Timeout = %v, expected 60s", config.Timeout)
 		}
+		if config.RetryCount != 4 {
+			t.Errorf("RetryCount = %d, expected 4", config.RetryCount)
+		}
+		if config.RateLimit != 2.5 {
+			t.Errorf("RateLimit = %g, expected 2.5", config.RateLimit)
+		}
+		if !reflect.DeepEqual(config.Destructive, []string{"delete", "shutdown"}) {
+			t.Errorf("Destructive = %v, expected [delete shutdown]", config.Destructive)
+		}
 	})
 
 	t.Run("InvalidSyntax", func(t *testing.T) {
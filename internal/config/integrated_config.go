@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/ini.v1"
@@ -31,6 +34,7 @@ type IntegratedConfig struct {
 	ConfigVersion string
 	autoSave      bool
 	watchers      []chan ConfigChangeEvent
+	watchersMu    sync.Mutex
 }
 
 type GeneralConfig struct {
@@ -40,24 +44,29 @@ type GeneralConfig struct {
 	Verbose              bool   `ini:"verbose"`
 	InteractiveByDefault bool   `ini:"interactive_by_default"`
 	Profile              string `ini:"profile"`
+	LogLevel             string `ini:"log_level"`
 }
 
 type TransformConfig struct {
-	PreserveComments       bool `ini:"preserve_comments"`
-	AddExplanatoryComments bool `ini:"add_explanatory_comments"`
-	ShowLineNumbers        bool `ini:"show_line_numbers"`
-	BackupOriginal         bool `ini:"backup_original"`
+	PreserveComments       bool   `ini:"preserve_comments"`
+	AddExplanatoryComments bool   `ini:"add_explanatory_comments"`
+	ShowLineNumbers        bool   `ini:"show_line_numbers"`
+	BackupOriginal         bool   `ini:"backup_original"`
+	RuleOrder              string `ini:"rule_order"`
+	EnabledRules           string `ini:"enabled_rules"`
+	DisabledRules          string `ini:"disabled_rules"`
 }
 
 type ValidationConfig struct {
-	EnableValidation        bool `ini:"enable_validation"`
-	StrictMode              bool `ini:"strict_mode"`
-	ValidateBeforeTransform bool `ini:"validate_before_transform"`
-	ValidateAfterTransform  bool `ini:"validate_after_transform"`
-	MaxSuggestions          int  `ini:"max_suggestions"`
-	MaxEditDistance         int  `ini:"max_edit_distance"`
-	SkipDeprecatedWarnings  bool `ini:"skip_deprecated_warnings"`
-	TypoDetectionEnabled    bool `ini:"typo_detection_enabled"`
+	EnableValidation        bool   `ini:"enable_validation"`
+	StrictMode              bool   `ini:"strict_mode"`
+	ValidateBeforeTransform bool   `ini:"validate_before_transform"`
+	ValidateAfterTransform  bool   `ini:"validate_after_transform"`
+	MaxSuggestions          int    `ini:"max_suggestions"`
+	MaxEditDistance         int    `ini:"max_edit_distance"`
+	DistanceAlgorithm       string `ini:"distance_algorithm"`
+	SkipDeprecatedWarnings  bool   `ini:"skip_deprecated_warnings"`
+	TypoDetectionEnabled    bool   `ini:"typo_detection_enabled"`
 }
 
 type ErrorFeedbackConfig struct {
@@ -121,18 +130,19 @@ type ConfigChangeEvent struct {
 func NewIntegratedConfig() *IntegratedConfig {
 	return &IntegratedConfig{
 		General: &GeneralConfig{
-			Version:              "1.9.0",
+			Version:              CurrentConfigVersion,
 			ColorOutput:          true,
 			Language:             "ja",
 			Verbose:              false,
 			InteractiveByDefault: false,
 			Profile:              "default",
+			LogLevel:             "info",
 		},
 		Transform: &TransformConfig{
 			PreserveComments:       true,
 			AddExplanatoryComments: true,
 			ShowLineNumbers:        true,
-			BackupOriginal:         false,
+			BackupOriginal:         true,
 		},
 		Validation: &ValidationConfig{
 			EnableValidation:        true,
@@ -141,6 +151,7 @@ func NewIntegratedConfig() *IntegratedConfig {
 			ValidateAfterTransform:  true,
 			MaxSuggestions:          5,
 			MaxEditDistance:         3,
+			DistanceAlgorithm:       "levenshtein",
 			SkipDeprecatedWarnings:  false,
 			TypoDetectionEnabled:    true,
 		},
@@ -173,7 +184,7 @@ func NewIntegratedConfig() *IntegratedConfig {
 		},
 		Profiles:      make(map[string]*ProfileConfig),
 		Environments:  make(map[string]*EnvironmentConfig),
-		ConfigVersion: "1.9.0",
+		ConfigVersion: CurrentConfigVersion,
 		autoSave:      true,
 	}
 }
@@ -201,6 +212,91 @@ func LoadIntegratedConfig(configPath string) (*IntegratedConfig, error) {
 	return config, nil
 }
 
+// ReadIntegratedConfig loads an existing configuration file without side effects.
+// Unlike LoadIntegratedConfig it never creates the file, and a profile the file
+// does not define is ignored instead of reported as an error.
+func ReadIntegratedConfig(configPath string) (*IntegratedConfig, error) {
+	config := NewIntegratedConfig()
+	config.configPath = configPath
+	config.autoSave = false
+
+	if err := config.loadFromFile(); err != nil {
+		return nil, fmt.Errorf("設定ファイル読み込みに失敗: %w", err)
+	}
+
+	config.applyEnvironmentOverrides()
+
+	if _, exists := config.Profiles[config.General.Profile]; exists {
+		if err := config.applyProfile(config.General.Profile); err != nil {
+			return nil, fmt.Errorf("プロファイル適用に失敗: %w", err)
+		}
+	}
+
+	return config, nil
+}
+
+// ReadIntegratedConfigProfile is ReadIntegratedConfig applying profileName,
+// such as one given on the command line, which takes precedence over
+// USACLOUD_UPDATE_PROFILE and the profile of the file. Besides the profiles
+// the file defines, the built-in ones (default, beginner, expert and ci) can
+// be selected, also with configPath "" to read no file. An unknown profile is
+// an error listing the available ones. With profileName "" it is
+// ReadIntegratedConfig.
+func ReadIntegratedConfigProfile(configPath, profileName string) (*IntegratedConfig, error) {
+	if profileName == "" {
+		return ReadIntegratedConfig(configPath)
+	}
+
+	config := NewIntegratedConfig()
+	config.configPath = configPath
+	config.autoSave = false
+
+	if configPath != "" {
+		if err := config.loadFromFile(); err != nil {
+			return nil, fmt.Errorf("設定ファイル読み込みに失敗: %w", err)
+		}
+	}
+
+	config.applyEnvironmentOverrides()
+	if err := config.selectProfile(profileName); err != nil {
+		return nil, err
+	}
+	if err := config.applyProfile(profileName); err != nil {
+		return nil, fmt.Errorf("プロファイル適用に失敗: %w", err)
+	}
+
+	return config, nil
+}
+
+// selectProfile makes profileName the active profile, which may also name one
+// of the built-in profiles the file does not redefine
+func (ic *IntegratedConfig) selectProfile(profileName string) error {
+	ic.General.Profile = profileName
+
+	builtin := NewIntegratedConfig()
+	builtin.createDefaultProfiles()
+	for name, profile := range builtin.Profiles {
+		if _, exists := ic.Profiles[name]; !exists {
+			ic.Profiles[name] = profile
+		}
+	}
+
+	if _, exists := ic.Profiles[profileName]; !exists {
+		return fmt.Errorf("プロファイル '%s' が見つかりません（利用可能なプロファイル: %s）", profileName, strings.Join(ic.ProfileNames(), ", "))
+	}
+	return nil
+}
+
+// ProfileNames returns the names of the profiles, sorted
+func (ic *IntegratedConfig) ProfileNames() []string {
+	names := make([]string, 0, len(ic.Profiles))
+	for name := range ic.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (ic *IntegratedConfig) loadFromFile() error {
 	if ic.configPath == "" {
 		return fmt.Errorf("設定ファイルパスが指定されていません")
@@ -389,40 +485,59 @@ func (ic *IntegratedConfig) createDefaultEnvironments() {
 	}
 }
 
-func (ic *IntegratedConfig) applyEnvironmentOverrides() {
-	envMappings := map[string]func(string){
-		"USACLOUD_UPDATE_PROFILE": func(v string) {
-			ic.General.Profile = v
-		},
-		"USACLOUD_UPDATE_STRICT_MODE": func(v string) {
-			ic.Validation.StrictMode = (v == "true" || v == "1")
-		},
-		"USACLOUD_UPDATE_PARALLEL": func(v string) {
-			ic.Performance.ParallelProcessing = (v == "true" || v == "1")
-		},
-		"USACLOUD_UPDATE_COLOR": func(v string) {
-			ic.General.ColorOutput = (v == "true" || v == "1")
-		},
-		"USACLOUD_UPDATE_VERBOSE": func(v string) {
-			ic.General.Verbose = (v == "true" || v == "1")
-		},
-	}
+// environmentOverride is an environment variable overriding a setting
+type environmentOverride struct {
+	Name    string
+	Section string
+	Key     string
+	apply   func(ic *IntegratedConfig, value string)
+}
+
+// environmentOverrides are the environment variables applyEnvironmentOverrides reads
+var environmentOverrides = []environmentOverride{
+	{"USACLOUD_UPDATE_PROFILE", "general", "profile", func(ic *IntegratedConfig, v string) {
+		ic.General.Profile = v
+	}},
+	{"USACLOUD_UPDATE_STRICT_MODE", "validation", "strict_mode", func(ic *IntegratedConfig, v string) {
+		ic.Validation.StrictMode = (v == "true" || v == "1")
+	}},
+	{"USACLOUD_UPDATE_PARALLEL", "performance", "parallel_processing", func(ic *IntegratedConfig, v string) {
+		ic.Performance.ParallelProcessing = (v == "true" || v == "1")
+	}},
+	{"USACLOUD_UPDATE_COLOR", "general", "color_output", func(ic *IntegratedConfig, v string) {
+		ic.General.ColorOutput = (v == "true" || v == "1")
+	}},
+	{"USACLOUD_UPDATE_VERBOSE", "general", "verbose", func(ic *IntegratedConfig, v string) {
+		ic.General.Verbose = (v == "true" || v == "1")
+	}},
+}
 
-	for envVar, setter := range envMappings {
-		if value := os.Getenv(envVar); value != "" {
-			setter(value)
+// applyEnvironmentOverrides applies the environment variables that are set
+// and returns them
+func (ic *IntegratedConfig) applyEnvironmentOverrides() []environmentOverride {
+	var applied []environmentOverride
+	for _, override := range environmentOverrides {
+		if value := os.Getenv(override.Name); value != "" {
+			override.apply(ic, value)
+			applied = append(applied, override)
 		}
 	}
+	return applied
 }
 
-func (ic *IntegratedConfig) applyProfile(profileName string) error {
-	if profileName == "" {
-		return nil
-	}
+// profileOverride is a setting a profile overrides
+type profileOverride struct {
+	Profile string // the profile defining the override, profileName or the one it is based on
+	Key     string
+	Value   interface{}
+}
 
+// profileOverrides returns the overrides of a profile in the order they are
+// applied: those of the profile it is based on first
+func (ic *IntegratedConfig) profileOverrides(profileName string) ([]profileOverride, error) {
 	profile, exists := ic.Profiles[profileName]
 	if !exists {
-		return fmt.Errorf("プロファイル '%s' が見つかりません", profileName)
+		return nil, fmt.Errorf("プロファイル '%s' が見つかりません", profileName)
 	}
 
 	baseProfile := profile
@@ -430,25 +545,43 @@ func (ic *IntegratedConfig) applyProfile(profileName string) error {
 		var exists bool
 		baseProfile, exists = ic.Profiles[profile.BasedOn]
 		if !exists {
-			return fmt.Errorf("ベースプロファイル '%s' が見つかりません", profile.BasedOn)
+			return nil, fmt.Errorf("ベースプロファイル '%s' が見つかりません", profile.BasedOn)
 		}
 	}
 
+	var overrides []profileOverride
 	if baseProfile != profile && baseProfile.Overrides != nil {
 		for key, value := range baseProfile.Overrides {
-			ic.applyOverride(key, value)
+			overrides = append(overrides, profileOverride{Profile: baseProfile.Name, Key: key, Value: value})
 		}
 	}
 
 	for key, value := range profile.Overrides {
-		ic.applyOverride(key, value)
+		overrides = append(overrides, profileOverride{Profile: profile.Name, Key: key, Value: value})
+	}
+	return overrides, nil
+}
+
+func (ic *IntegratedConfig) applyProfile(profileName string) error {
+	if profileName == "" {
+		return nil
+	}
+
+	overrides, err := ic.profileOverrides(profileName)
+	if err != nil {
+		return err
+	}
+	for _, override := range overrides {
+		ic.applyOverride(override.Key, override.Value)
 	}
 
 	ic.profileName = profileName
 	return nil
 }
 
-func (ic *IntegratedConfig) applyOverride(key string, value interface{}) {
+// applyOverride sets the setting key of a profile override and reports
+// whether key is one profiles can override
+func (ic *IntegratedConfig) applyOverride(key string, value interface{}) bool {
 	strValue := fmt.Sprintf("%v", value)
 
 	switch key {
@@ -476,7 +609,10 @@ func (ic *IntegratedConfig) applyOverride(key string, value interface{}) {
 		ic.Output.ShowProgress = (strValue == "true")
 	case "report_level":
 		ic.Output.ReportLevel = strValue
+	default:
+		return false
 	}
+	return true
 }
 
 func (ic *IntegratedConfig) Save() error {
@@ -488,12 +624,54 @@ func (ic *IntegratedConfig) SaveAs(configPath string) error {
 		return fmt.Errorf("設定ファイルパスが指定されていません")
 	}
 
-	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
-		return fmt.Errorf("設定ディレクトリ作成に失敗: %w", err)
+	cfg := ini.Empty()
+	if err := keepForeignSections(cfg, configPath); err != nil {
+		return err
+	}
+	if err := ic.writeSections(cfg); err != nil {
+		return err
+	}
+	if err := saveINIFile(cfg, configPath); err != nil {
+		return err
 	}
 
-	cfg := ini.Empty()
+	ic.LastModified = time.Now()
+	return nil
+}
+
+// keepForeignSections copies into cfg the sections of the existing file at
+// configPath that IntegratedConfig does not read, such as the credentials in
+// [sakura-cloud], so that saving does not drop them. Like the sandbox loader
+// it reads '#' and ';' inside a value as part of it, not as a comment.
+func keepForeignSections(cfg *ini.File, configPath string) error {
+	existing, err := ini.LoadSources(ini.LoadOptions{IgnoreInlineComment: true}, configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("既存の設定ファイル読み込みに失敗: %w", err)
+	}
 
+	for _, section := range existing.Sections() {
+		if section.Name() == ini.DefaultSection || isIntegratedConfigSection(strings.ToLower(section.Name())) {
+			continue
+		}
+		kept, err := cfg.NewSection(section.Name())
+		if err != nil {
+			return err
+		}
+		kept.Comment = section.Comment
+		for _, key := range section.Keys() {
+			k := kept.Key(key.Name())
+			k.SetValue(key.Value())
+			k.Comment = key.Comment
+		}
+	}
+	return nil
+}
+
+// writeSections adds the sections of the configuration to cfg
+func (ic *IntegratedConfig) writeSections(cfg *ini.File) error {
 	generalSec, err := cfg.NewSection("general")
 	if err != nil {
 		return err
@@ -564,6 +742,15 @@ func (ic *IntegratedConfig) SaveAs(configPath string) error {
 		ic.writeStructToSection(envSec, env)
 	}
 
+	return nil
+}
+
+// saveINIFile writes cfg to configPath, readable only by the owner as it may hold credentials
+func saveINIFile(cfg *ini.File, configPath string) error {
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("設定ディレクトリ作成に失敗: %w", err)
+	}
+
 	if err := cfg.SaveTo(configPath); err != nil {
 		return fmt.Errorf("設定ファイル保存に失敗: %w", err)
 	}
@@ -572,7 +759,6 @@ func (ic *IntegratedConfig) SaveAs(configPath string) error {
 		return fmt.Errorf("設定ファイル権限設定に失敗: %w", err)
 	}
 
-	ic.LastModified = time.Now()
 	return nil
 }
 
@@ -585,11 +771,15 @@ func (ic *IntegratedConfig) writeStructToSection(section *ini.Section, data inte
 		section.Key("verbose").SetValue(fmt.Sprintf("%t", v.Verbose))
 		section.Key("interactive_by_default").SetValue(fmt.Sprintf("%t", v.InteractiveByDefault))
 		section.Key("profile").SetValue(v.Profile)
+		section.Key("log_level").SetValue(v.LogLevel)
 	case *TransformConfig:
 		section.Key("preserve_comments").SetValue(fmt.Sprintf("%t", v.PreserveComments))
 		section.Key("add_explanatory_comments").SetValue(fmt.Sprintf("%t", v.AddExplanatoryComments))
 		section.Key("show_line_numbers").SetValue(fmt.Sprintf("%t", v.ShowLineNumbers))
 		section.Key("backup_original").SetValue(fmt.Sprintf("%t", v.BackupOriginal))
+		section.Key("rule_order").SetValue(v.RuleOrder)
+		section.Key("enabled_rules").SetValue(v.EnabledRules)
+		section.Key("disabled_rules").SetValue(v.DisabledRules)
 	case *ValidationConfig:
 		section.Key("enable_validation").SetValue(fmt.Sprintf("%t", v.EnableValidation))
 		section.Key("strict_mode").SetValue(fmt.Sprintf("%t", v.StrictMode))
@@ -597,6 +787,7 @@ func (ic *IntegratedConfig) writeStructToSection(section *ini.Section, data inte
 		section.Key("validate_after_transform").SetValue(fmt.Sprintf("%t", v.ValidateAfterTransform))
 		section.Key("max_suggestions").SetValue(fmt.Sprintf("%d", v.MaxSuggestions))
 		section.Key("max_edit_distance").SetValue(fmt.Sprintf("%d", v.MaxEditDistance))
+		section.Key("distance_algorithm").SetValue(v.DistanceAlgorithm)
 		section.Key("skip_deprecated_warnings").SetValue(fmt.Sprintf("%t", v.SkipDeprecatedWarnings))
 		section.Key("typo_detection_enabled").SetValue(fmt.Sprintf("%t", v.TypoDetectionEnabled))
 	case *ErrorFeedbackConfig:
@@ -630,6 +821,8 @@ func (ic *IntegratedConfig) writeStructToSection(section *ini.Section, data inte
 	}
 }
 
+// UpdateSetting sets key of section, a value of the field's type or its text,
+// and notifies the subscribers of the change
 func (ic *IntegratedConfig) UpdateSetting(sectionName, key string, value interface{}) error {
 	oldValue := ic.getSetting(sectionName, key)
 
@@ -641,7 +834,7 @@ func (ic *IntegratedConfig) UpdateSetting(sectionName, key string, value interfa
 		Section:   sectionName,
 		Key:       key,
 		OldValue:  oldValue,
-		NewValue:  value,
+		NewValue:  ic.getSetting(sectionName, key),
 		Timestamp: time.Now(),
 	}
 
@@ -654,15 +847,111 @@ func (ic *IntegratedConfig) UpdateSetting(sectionName, key string, value interfa
 	return nil
 }
 
-func (ic *IntegratedConfig) getSetting(section, key string) interface{} {
+// sectionStruct returns a pointer to the struct of a section, nil for an unknown section
+func (ic *IntegratedConfig) sectionStruct(section string) interface{} {
+	switch section {
+	case "general":
+		return ic.General
+	case "transform":
+		return ic.Transform
+	case "validation":
+		return ic.Validation
+	case "error_feedback":
+		return ic.ErrorFeedback
+	case "help_system":
+		return ic.HelpSystem
+	case "performance":
+		return ic.Performance
+	case "output":
+		return ic.Output
+	}
 	return nil
 }
 
+// settingField returns the field whose ini tag is key in the struct of section
+func (ic *IntegratedConfig) settingField(section, key string) (reflect.Value, error) {
+	s := ic.sectionStruct(section)
+	if s == nil {
+		return reflect.Value{}, fmt.Errorf("不明なセクションです: %s", section)
+	}
+	v := reflect.ValueOf(s).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("ini") == key {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("不明な設定項目です: [%s] %s", section, key)
+}
+
+func (ic *IntegratedConfig) getSetting(section, key string) interface{} {
+	field, err := ic.settingField(section, key)
+	if err != nil {
+		return nil
+	}
+	return field.Interface()
+}
+
 func (ic *IntegratedConfig) setSetting(section, key string, value interface{}) error {
+	field, err := ic.settingField(section, key)
+	if err != nil {
+		return err
+	}
+
+	if err := checkSettingChoice(section, key, value); err != nil {
+		return err
+	}
+
+	if v := reflect.ValueOf(value); v.IsValid() && v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+		return nil
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("[%s] %s に %T の値は設定できません", section, key, value)
+	}
+	text = strings.TrimSpace(text)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("[%s] %s には true または false を指定してください: %s", section, key, text)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return fmt.Errorf("[%s] %s には整数を指定してください: %s", section, key, text)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("[%s] %s には数値を指定してください: %s", section, key, text)
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("[%s] %s は変更できません", section, key)
+	}
 	return nil
 }
 
+// Subscribe returns a channel receiving the changes UpdateSetting makes.
+// Events are dropped rather than blocking UpdateSetting when the channel
+// is full, so receive them promptly.
+func (ic *IntegratedConfig) Subscribe() <-chan ConfigChangeEvent {
+	ch := make(chan ConfigChangeEvent, 16)
+	ic.watchersMu.Lock()
+	ic.watchers = append(ic.watchers, ch)
+	ic.watchersMu.Unlock()
+	return ch
+}
+
 func (ic *IntegratedConfig) notifyConfigChange(event ConfigChangeEvent) {
+	ic.watchersMu.Lock()
+	defer ic.watchersMu.Unlock()
 	for _, watcher := range ic.watchers {
 		select {
 		case watcher <- event:
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -65,6 +66,88 @@ func TestLoadIntegratedConfig_FileNotExists(t *testing.T) {
 	}
 }
 
+func TestReadIntegratedConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "test-config.conf")
+
+	if _, err := ReadIntegratedConfig(configPath); err == nil {
+		t.Error("Expected error for missing config file")
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Error("Expected missing config file not to be created")
+	}
+
+	content := `[sakura-cloud]
+zone = "tk1v"
+
+[general]
+log_level = warn
+
+[validation]
+max_suggestions = 7
+max_edit_distance = 4
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := ReadIntegratedConfig(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.Validation.MaxSuggestions != 7 {
+		t.Errorf("Expected max suggestions 7, got %d", config.Validation.MaxSuggestions)
+	}
+	if config.Validation.MaxEditDistance != 4 {
+		t.Errorf("Expected max edit distance 4, got %d", config.Validation.MaxEditDistance)
+	}
+	if config.General.LogLevel != "warn" {
+		t.Errorf("Expected log level 'warn', got %q", config.General.LogLevel)
+	}
+	if !config.Validation.TypoDetectionEnabled {
+		t.Error("Expected unspecified keys to keep their defaults")
+	}
+}
+
+func TestReadIntegratedConfigProfile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "test-config.conf")
+	content := `[general]
+profile = team
+
+[validation]
+max_suggestions = 7
+
+[profiles.team]
+max_suggestions = 4
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	t.Setenv("USACLOUD_UPDATE_PROFILE", "team")
+
+	// The profile given takes precedence over the environment and the file
+	config, err := ReadIntegratedConfigProfile(configPath, "expert")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.General.Profile != "expert" || config.Validation.MaxSuggestions != 3 || config.Output.ShowProgress {
+		t.Errorf("Expected the built-in expert profile, got %+v %+v", config.General, config.Validation)
+	}
+
+	if config, err = ReadIntegratedConfigProfile(configPath, "team"); err != nil || config.Validation.MaxSuggestions != 4 {
+		t.Errorf("Expected the profile of the file, got %v", err)
+	}
+	if config, err = ReadIntegratedConfigProfile("", "beginner"); err != nil || config.Validation.MaxSuggestions != 8 {
+		t.Errorf("Expected a built-in profile without a file, got %v", err)
+	}
+
+	_, err = ReadIntegratedConfigProfile(configPath, "expret")
+	if err == nil || !strings.Contains(err.Error(), "beginner, ci, default, expert, team") {
+		t.Errorf("Expected an error listing the available profiles, got %v", err)
+	}
+}
+
 func TestCreateDefaultProfiles(t *testing.T) {
 	config := NewIntegratedConfig()
 	config.createDefaultProfiles()
@@ -304,6 +387,34 @@ func TestSaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestSaveKeepsForeignSectionValues(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "test-config.conf")
+	content := `[sakura-cloud]
+access_token = token-123
+access_token_secret = se#cr;et
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	// Saving twice re-reads the quoting written by the first save
+	for i := 0; i < 2; i++ {
+		config := NewIntegratedConfig()
+		config.configPath = configPath
+		if err := config.Save(); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+	}
+
+	sandboxConfig, err := LoadFromFileWithPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromFileWithPath() failed: %v", err)
+	}
+	if sandboxConfig.AccessTokenSecret != "se#cr;et" {
+		t.Errorf("AccessTokenSecret = %q, want %q", sandboxConfig.AccessTokenSecret, "se#cr;et")
+	}
+}
+
 func TestConfigChangeNotification(t *testing.T) {
 	config := NewIntegratedConfig()
 	config.autoSave = false
@@ -335,6 +446,59 @@ func TestConfigChangeNotification(t *testing.T) {
 	}
 }
 
+func TestUpdateSetting(t *testing.T) {
+	config := NewIntegratedConfig()
+	config.autoSave = false
+	events := config.Subscribe()
+
+	tests := []struct {
+		section, key string
+		value        interface{}
+		oldValue     interface{}
+		newValue     interface{}
+	}{
+		{"validation", "max_suggestions", 8, 5, 8},
+		{"validation", "max_edit_distance", "4", 3, 4},
+		{"output", "show_progress", "false", true, false},
+		{"help_system", "skill_level", "expert", "intermediate", "expert"},
+		{"error_feedback", "suggestion_confidence_threshold", "0.8", 0.5, 0.8},
+	}
+	for _, tt := range tests {
+		if err := config.UpdateSetting(tt.section, tt.key, tt.value); err != nil {
+			t.Fatalf("UpdateSetting(%s, %s) failed: %v", tt.section, tt.key, err)
+		}
+		event := <-events
+		if event.Section != tt.section || event.Key != tt.key || event.OldValue != tt.oldValue || event.NewValue != tt.newValue {
+			t.Errorf("Unexpected event %+v", event)
+		}
+		if got := config.getSetting(tt.section, tt.key); got != tt.newValue {
+			t.Errorf("Expected [%s] %s to be %v, got %v", tt.section, tt.key, tt.newValue, got)
+		}
+	}
+	if config.Validation.MaxSuggestions != 8 || config.Output.ShowProgress {
+		t.Error("Expected UpdateSetting to change the section structs")
+	}
+
+	for _, bad := range []struct {
+		section, key string
+		value        interface{}
+	}{
+		{"unknown", "verbose", true},
+		{"general", "unknown", true},
+		{"general", "verbose", "maybe"},
+		{"validation", "max_suggestions", 1.5},
+	} {
+		if err := config.UpdateSetting(bad.section, bad.key, bad.value); err == nil {
+			t.Errorf("Expected an error for [%s] %s = %v", bad.section, bad.key, bad.value)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Errorf("Expected no event for a rejected update, got %+v", event)
+	default:
+	}
+}
+
 func TestConfigValidation(t *testing.T) {
 	config := NewIntegratedConfig()
 
@@ -126,6 +126,10 @@ func (pc *ProfileCommand) ShowProfile(cmd *cobra.Command, args []string) error {
 		fmt.Printf("タグ: %s\n", strings.Join(profile.Tags, ", "))
 	}
 
+	if profile.Encryption != nil {
+		fmt.Printf("機密情報の暗号化: ✓ (%s)\n", profile.Encryption.Algorithm)
+	}
+
 	fmt.Printf("\n設定項目:\n")
 	if len(profile.Config) == 0 {
 		fmt.Printf("  (設定項目なし)\n")
@@ -156,6 +160,7 @@ func (pc *ProfileCommand) CreateProfile(cmd *cobra.Command, args []string) error
 	tags, _ := cmd.Flags().GetStringSlice("tags")
 	setDefault, _ := cmd.Flags().GetBool("default")
 	configFlags, _ := cmd.Flags().GetStringSlice("config")
+	encryptSecrets, _ := cmd.Flags().GetBool("encrypt-secrets")
 
 	// Parse config flags
 	config := make(map[string]string)
@@ -204,16 +209,24 @@ func (pc *ProfileCommand) CreateProfile(cmd *cobra.Command, args []string) error
 
 		// Save the profile through manager
 		opts := ProfileCreateOptions{
-			Name:        profile.Name,
-			Description: profile.Description,
-			Environment: profile.Environment,
-			Config:      profile.Config,
-			Tags:        profile.Tags,
-			SetDefault:  setDefault,
+			Name:           profile.Name,
+			Description:    profile.Description,
+			Environment:    profile.Environment,
+			Config:         profile.Config,
+			Tags:           profile.Tags,
+			SetDefault:     setDefault,
+			EncryptSecrets: encryptSecrets,
 		}
 		profile, err = pc.manager.CreateProfile(opts)
 
 	} else if parentID != "" {
+		// The child inherits the encryption of its parent
+		if encryptSecrets {
+			if parent, err := pc.manager.GetProfile(parentID); err == nil && parent.Encryption == nil {
+				return fmt.Errorf("親プロファイル '%s' は暗号化されていないため --encrypt-secrets は指定できません", parent.Name)
+			}
+		}
+
 		// Create from parent
 		profile, err = pc.manager.CreateProfileFromParent(name, description, parentID, config)
 		if err != nil {
@@ -238,12 +251,13 @@ func (pc *ProfileCommand) CreateProfile(cmd *cobra.Command, args []string) error
 		}
 
 		opts := ProfileCreateOptions{
-			Name:        name,
-			Description: description,
-			Environment: environment,
-			Config:      config,
-			Tags:        tags,
-			SetDefault:  setDefault,
+			Name:           name,
+			Description:    description,
+			Environment:    environment,
+			Config:         config,
+			Tags:           tags,
+			SetDefault:     setDefault,
+			EncryptSecrets: encryptSecrets,
 		}
 		profile, err = pc.manager.CreateProfile(opts)
 	}
@@ -258,6 +272,10 @@ func (pc *ProfileCommand) CreateProfile(cmd *cobra.Command, args []string) error
 		fmt.Println("このプロファイルがデフォルトに設定されました。")
 	}
 
+	if profile.Encryption != nil {
+		fmt.Println("機密情報は暗号化して保存されました。")
+	}
+
 	return nil
 }
 
@@ -384,11 +402,16 @@ func (pc *ProfileCommand) ExportProfile(cmd *cobra.Command, args []string) error
 		return err
 	}
 
-	if err := pc.manager.ExportProfile(profile.ID, outputFile); err != nil {
+	redact, _ := cmd.Flags().GetBool("redact")
+
+	if err := pc.manager.ExportProfile(profile.ID, outputFile, ProfileExportOptions{Redact: redact}); err != nil {
 		return fmt.Errorf("プロファイルをエクスポートできませんでした: %w", err)
 	}
 
 	fmt.Printf("プロファイル '%s' を '%s' にエクスポートしました。\n", profile.Name, outputFile)
+	if redact {
+		fmt.Println("機密情報の設定項目は空の値でエクスポートしました。")
+	}
 	return nil
 }
 
@@ -341,6 +341,45 @@ func TestProfileCommand_ExportProfile(t *testing.T) {
 	}
 }
 
+func TestProfileCommand_ExportProfileRedact(t *testing.T) {
+	tempDir := t.TempDir()
+	manager, err := NewProfileManager(tempDir)
+	if err != nil {
+		t.Fatalf("NewProfileManager() failed: %v", err)
+	}
+
+	profile, err := manager.CreateProfile(ProfileCreateOptions{
+		Name:        "Test Profile",
+		Environment: "test",
+		Config: map[string]string{
+			"SAKURACLOUD_ACCESS_TOKEN":        "test-token",
+			"SAKURACLOUD_ACCESS_TOKEN_SECRET": "test-secret",
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateProfile() failed: %v", err)
+	}
+
+	pc := NewProfileCommand(manager, NewTemplateManager())
+	exportFile := filepath.Join(tempDir, "export.json")
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", exportFile, "Output file")
+	cmd.Flags().Bool("redact", true, "Redact sensitive values")
+
+	if err := pc.ExportProfile(cmd, []string{profile.ID}); err != nil {
+		t.Fatalf("ExportProfile() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(exportFile)
+	if err != nil {
+		t.Fatalf("Failed to read export file: %v", err)
+	}
+	if strings.Contains(string(data), "test-token") || !strings.Contains(string(data), `"SAKURACLOUD_ACCESS_TOKEN": ""`) {
+		t.Errorf("Expected the secrets to be exported empty, got:\n%s", data)
+	}
+}
+
 func TestProfileCommand_ImportProfile(t *testing.T) {
 	tempDir := t.TempDir()
 	manager, err := NewProfileManager(tempDir)
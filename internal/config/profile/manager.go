@@ -8,17 +8,28 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/armaniacs/usacloud-update/internal/validation"
 )
 
-// NewProfileManager creates a new profile manager
+// NewProfileManager creates a new profile manager. The passphrase for
+// encrypted profile secrets is read from USACLOUD_UPDATE_PROFILE_PASSPHRASE.
 func NewProfileManager(configDir string) (*ProfileManager, error) {
-	storage := NewFileStorage(configDir)
+	return NewProfileManagerWithPassphrase(configDir, os.Getenv(PassphraseEnvVar))
+}
+
+// NewProfileManagerWithPassphrase creates a profile manager that encrypts and
+// decrypts the secrets of encrypted profiles with the passphrase
+func NewProfileManagerWithPassphrase(configDir, passphrase string) (*ProfileManager, error) {
+	storage := NewFileStorageWithPassphrase(configDir, passphrase)
 
 	pm := &ProfileManager{
 		profiles:        make(map[string]*Profile),
 		configDir:       configDir,
 		storage:         storage,
 		templateManager: NewTemplateManager(),
+		secrets:         storage.secrets,
+		maxDepth:        DefaultMaxInheritanceDepth,
 	}
 
 	// Load existing profiles
@@ -57,6 +68,17 @@ func (pm *ProfileManager) CreateProfile(opts ProfileCreateOptions) (*Profile, er
 		profile.Config[k] = v
 	}
 
+	if opts.EncryptSecrets {
+		if !pm.secrets.enabled() {
+			return nil, fmt.Errorf("%w: set %s", ErrPassphraseRequired, PassphraseEnvVar)
+		}
+		encryption, err := newSecretEncryption()
+		if err != nil {
+			return nil, err
+		}
+		profile.Encryption = encryption
+	}
+
 	// Check for duplicate names
 	if pm.profileExists(opts.Name) {
 		return nil, fmt.Errorf("profile with name '%s' already exists", opts.Name)
@@ -64,6 +86,9 @@ func (pm *ProfileManager) CreateProfile(opts ProfileCreateOptions) (*Profile, er
 
 	// Apply inheritance if parent is specified
 	if opts.ParentID != "" {
+		if err := pm.validateInheritance(profile); err != nil {
+			return nil, err
+		}
 		if err := pm.applyInheritance(profile, opts.ParentID); err != nil {
 			return nil, fmt.Errorf("failed to apply inheritance: %w", err)
 		}
@@ -118,12 +143,13 @@ func (pm *ProfileManager) CreateProfileFromParent(name, description string, pare
 	}
 
 	opts := ProfileCreateOptions{
-		Name:        name,
-		Description: description,
-		Environment: parent.Environment,
-		Config:      config,
-		ParentID:    parentID,
-		Tags:        append([]string{}, parent.Tags...),
+		Name:           name,
+		Description:    description,
+		Environment:    parent.Environment,
+		Config:         config,
+		ParentID:       parentID,
+		Tags:           append([]string{}, parent.Tags...),
+		EncryptSecrets: parent.Encryption != nil, // inherited secrets stay encrypted
 	}
 
 	return pm.CreateProfile(opts)
@@ -357,20 +383,45 @@ func (pm *ProfileManager) GetDefaultProfile() *Profile {
 	return nil
 }
 
-// ExportProfile exports a profile to a file
-func (pm *ProfileManager) ExportProfile(id, filepath string) error {
+// ExportProfile exports a profile to a file, as JSON for a .json file and as
+// YAML otherwise
+func (pm *ProfileManager) ExportProfile(id, filepath string, opts ...ProfileExportOptions) error {
+	var options ProfileExportOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
 	profile, exists := pm.profiles[id]
 	if !exists {
 		return fmt.Errorf("profile not found: %s", id)
 	}
 
-	return writeYAMLFile(filepath, profile)
+	if options.Redact {
+		redacted := *profile
+		redacted.Config = make(map[string]string, len(profile.Config))
+		for k, v := range profile.Config {
+			if IsSensitiveKey(k) {
+				v = ""
+			}
+			redacted.Config[k] = v
+		}
+		profile = &redacted
+	}
+
+	sealed, err := pm.secrets.seal(profile)
+	if err != nil {
+		return err
+	}
+	return writeProfileFile(filepath, sealed)
 }
 
-// ImportProfile imports a profile from a file
+// ImportProfile imports a profile from a JSON (.json) or YAML file
 func (pm *ProfileManager) ImportProfile(filepath string) (*Profile, error) {
 	var profile Profile
-	if err := readYAMLFile(filepath, &profile); err != nil {
+	if err := readProfileFile(filepath, &profile); err != nil {
+		return nil, fmt.Errorf("failed to read profile file: %w", err)
+	}
+	if err := pm.secrets.open(&profile); err != nil {
 		return nil, fmt.Errorf("failed to read profile file: %w", err)
 	}
 
@@ -393,6 +444,9 @@ func (pm *ProfileManager) ImportProfile(filepath string) (*Profile, error) {
 	if err := pm.validateProfile(&profile); err != nil {
 		return nil, fmt.Errorf("imported profile validation failed: %w", err)
 	}
+	if err := pm.validateInheritance(&profile); err != nil {
+		return nil, fmt.Errorf("imported profile validation failed: %w", err)
+	}
 
 	pm.profiles[profile.ID] = &profile
 
@@ -456,6 +510,50 @@ func (pm *ProfileManager) applyInheritance(profile *Profile, parentID string) er
 	return nil
 }
 
+// validateInheritance walks the parent chain of a profile and returns an error
+// naming the profiles involved if the chain loops or has more ancestors than
+// the maximum depth. A chain ends at a parent that is not loaded.
+func (pm *ProfileManager) validateInheritance(profile *Profile) error {
+	maxDepth := pm.maxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxInheritanceDepth
+	}
+
+	chain := []string{profile.Name}
+	visited := map[string]bool{profile.ID: true}
+	for parentID := profile.ParentID; parentID != ""; {
+		parent, exists := pm.profiles[parentID]
+		if !exists {
+			return nil
+		}
+
+		chain = append(chain, parent.Name)
+		if visited[parent.ID] {
+			return fmt.Errorf("profile inheritance cycle detected: %s", strings.Join(chain, " → "))
+		}
+		if len(chain)-1 > maxDepth {
+			return fmt.Errorf("profile inheritance chain exceeds the maximum depth of %d: %s", maxDepth, strings.Join(chain, " → "))
+		}
+
+		visited[parent.ID] = true
+		parentID = parent.ParentID
+	}
+	return nil
+}
+
+// SetMaxInheritanceDepth sets the maximum number of ancestors a created or
+// imported profile may have (DefaultMaxInheritanceDepth by default)
+func (pm *ProfileManager) SetMaxInheritanceDepth(depth int) error {
+	if depth < 1 {
+		return fmt.Errorf("max inheritance depth must be at least 1, got %d", depth)
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.maxDepth = depth
+	return nil
+}
+
 func (pm *ProfileManager) validateProfile(profile *Profile) error {
 	if profile.Name == "" {
 		return fmt.Errorf("profile name is required")
@@ -499,9 +597,8 @@ func (pm *ProfileManager) validateRequiredConfig(profile *Profile) error {
 
 	// Validate specific keys
 	if zone, exists := profile.Config[ConfigKeyZone]; exists && zone != "" {
-		validZones := []string{"tk1v", "is1a", "is1b", "tk1a", "tk1b"}
 		validZone := false
-		for _, validZ := range validZones {
+		for _, validZ := range validation.Zones {
 			if zone == validZ {
 				validZone = true
 				break
@@ -1,6 +1,8 @@
 package profile
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -502,6 +504,107 @@ func TestExportImportProfile(t *testing.T) {
 	}
 }
 
+func TestExportImportProfileFormats(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewProfileManager(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create profile manager: %v", err)
+	}
+
+	original, err := manager.CreateProfile(ProfileCreateOptions{
+		Name:        "format-test",
+		Environment: EnvironmentDevelopment,
+		Config: map[string]string{
+			ConfigKeyAccessToken:       "format-token",
+			ConfigKeyAccessTokenSecret: "format-secret",
+			ConfigKeyZone:              "tk1v",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create profile: %v", err)
+	}
+
+	tests := []struct {
+		file string
+		json bool
+	}{
+		{"profile.json", true},
+		{"profile.JSON", true},
+		{"profile.yml", false},
+		{"profile.yaml", false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			exportFile := filepath.Join(tmpDir, tt.file)
+			if err := manager.ExportProfile(original.ID, exportFile); err != nil {
+				t.Fatalf("Failed to export profile: %v", err)
+			}
+
+			data, err := os.ReadFile(exportFile)
+			if err != nil {
+				t.Fatalf("Failed to read export file: %v", err)
+			}
+			var decoded map[string]interface{}
+			if err := json.Unmarshal(data, &decoded); (err == nil) != tt.json {
+				t.Errorf("Expected JSON=%v for %s, got:\n%s", tt.json, tt.file, data)
+			}
+
+			imported, err := manager.ImportProfile(exportFile)
+			if err != nil {
+				t.Fatalf("Failed to import profile: %v", err)
+			}
+			if want := fmt.Sprintf("%s (%d)", original.Name, i+1); imported.Name != want {
+				t.Errorf("Expected deduplicated name %q, got %q", want, imported.Name)
+			}
+			if imported.ID == original.ID || imported.Config[ConfigKeyAccessToken] != "format-token" {
+				t.Errorf("Unexpected imported profile: %+v", imported)
+			}
+		})
+	}
+}
+
+func TestExportProfileRedact(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewProfileManager(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create profile manager: %v", err)
+	}
+
+	original, err := manager.CreateProfile(ProfileCreateOptions{
+		Name:        "redact-test",
+		Environment: EnvironmentDevelopment,
+		Config: map[string]string{
+			ConfigKeyAccessToken:       "redact-token",
+			ConfigKeyAccessTokenSecret: "redact-secret",
+			ConfigKeyZone:              "tk1v",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create profile: %v", err)
+	}
+
+	exportFile := filepath.Join(tmpDir, "redacted.json")
+	if err := manager.ExportProfile(original.ID, exportFile, ProfileExportOptions{Redact: true}); err != nil {
+		t.Fatalf("Failed to export profile: %v", err)
+	}
+
+	var exported Profile
+	if err := readJSONFile(exportFile, &exported); err != nil {
+		t.Fatalf("Failed to read export file: %v", err)
+	}
+	if v, ok := exported.Config[ConfigKeyAccessToken]; !ok || v != "" {
+		t.Errorf("Expected the access token to be exported empty, got %q (present: %v)", v, ok)
+	}
+	if exported.Config[ConfigKeyAccessTokenSecret] != "" || exported.Config[ConfigKeyZone] != "tk1v" {
+		t.Errorf("Unexpected redacted config: %v", exported.Config)
+	}
+
+	// The profile in memory keeps its secrets
+	if original.Config[ConfigKeyAccessToken] != "redact-token" {
+		t.Errorf("Redacting should not modify the profile, got %v", original.Config)
+	}
+}
+
 func TestProfileValidation(t *testing.T) {
 	tmpDir := t.TempDir()
 	manager, err := NewProfileManager(tmpDir)
@@ -641,3 +744,82 @@ func TestMaskValue(t *testing.T) {
 		})
 	}
 }
+
+func TestInheritanceCycleDetection(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewProfileManager(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create profile manager: %v", err)
+	}
+
+	config := map[string]string{
+		ConfigKeyAccessToken:       "token",
+		ConfigKeyAccessTokenSecret: "secret",
+	}
+	a, err := manager.CreateProfile(ProfileCreateOptions{Name: "a", Environment: EnvironmentTest, Config: config})
+	if err != nil {
+		t.Fatalf("Failed to create profile: %v", err)
+	}
+	b, err := manager.CreateProfileFromParent("b", "", a.ID, nil)
+	if err != nil {
+		t.Fatalf("Failed to create child profile: %v", err)
+	}
+
+	// Corrupt the chain as a malformed profile file would
+	a.ParentID = b.ID
+
+	_, err = manager.CreateProfileFromParent("c", "", b.ID, nil)
+	if err == nil || !strings.Contains(err.Error(), "cycle detected: c → b → a → b") {
+		t.Errorf("Expected a cycle error naming the profiles, got %v", err)
+	}
+
+	// An imported profile whose parent is in the cycle is rejected
+	importFile := filepath.Join(tmpDir, "import.yaml")
+	if err := writeYAMLFile(importFile, &Profile{ID: "x", Name: "imported", Environment: EnvironmentTest, ParentID: a.ID, Config: config}); err != nil {
+		t.Fatalf("Failed to write import file: %v", err)
+	}
+	if _, err := manager.ImportProfile(importFile); err == nil || !strings.Contains(err.Error(), "imported → a → b → a") {
+		t.Errorf("Expected a cycle error for the import, got %v", err)
+	}
+	if manager.profileExists("imported") {
+		t.Error("Rejected profile should not be added")
+	}
+}
+
+func TestInheritanceDepthLimit(t *testing.T) {
+	manager, err := NewProfileManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create profile manager: %v", err)
+	}
+	if err := manager.SetMaxInheritanceDepth(0); err == nil {
+		t.Error("Expected an error for a depth below 1")
+	}
+	if err := manager.SetMaxInheritanceDepth(2); err != nil {
+		t.Fatalf("SetMaxInheritanceDepth() failed: %v", err)
+	}
+
+	root, err := manager.CreateProfile(ProfileCreateOptions{
+		Name:        "root",
+		Environment: EnvironmentTest,
+		Config: map[string]string{
+			ConfigKeyAccessToken:       "token",
+			ConfigKeyAccessTokenSecret: "secret",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create profile: %v", err)
+	}
+	child, err := manager.CreateProfileFromParent("child", "", root.ID, nil)
+	if err != nil {
+		t.Fatalf("Failed to create child profile: %v", err)
+	}
+	grandchild, err := manager.CreateProfileFromParent("grandchild", "", child.ID, nil)
+	if err != nil {
+		t.Fatalf("Two ancestors should be within the limit: %v", err)
+	}
+
+	_, err = manager.CreateProfileFromParent("too-deep", "", grandchild.ID, nil)
+	if err == nil || !strings.Contains(err.Error(), "maximum depth of 2: too-deep → grandchild → child → root") {
+		t.Errorf("Expected a depth error naming the chain, got %v", err)
+	}
+}
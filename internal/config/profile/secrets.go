@@ -0,0 +1,177 @@
+package profile
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/armaniacs/usacloud-update/internal/security"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseEnvVar is the environment variable NewProfileManager reads the
+// passphrase for encrypted profile secrets from
+const PassphraseEnvVar = "USACLOUD_UPDATE_PROFILE_PASSPHRASE"
+
+// ErrPassphraseRequired is returned when the secrets of an encrypted profile
+// are saved or loaded without a passphrase
+var ErrPassphraseRequired = errors.New("passphrase required for encrypted profile secrets")
+
+const (
+	secretAlgorithm      = "AES-256-GCM"
+	secretKDF            = "scrypt"
+	encryptedValuePrefix = "enc:v1:"
+
+	// scrypt parameters recommended for interactive use
+	scryptN          = 1 << 15
+	scryptR          = 8
+	scryptP          = 1
+	secretKeyLength  = 32
+	secretSaltLength = 16
+)
+
+// SecretEncryption records how the sensitive config values of a profile
+// (those matching IsSensitiveKey) are encrypted at rest
+type SecretEncryption struct {
+	Algorithm string `json:"algorithm" yaml:"algorithm"`
+	KDF       string `json:"kdf" yaml:"kdf"`
+	Salt      string `json:"salt" yaml:"salt"` // base64, the scrypt salt of the key
+}
+
+// newSecretEncryption creates the encryption settings of a new profile with a random salt
+func newSecretEncryption() (*SecretEncryption, error) {
+	salt := make([]byte, secretSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return &SecretEncryption{
+		Algorithm: secretAlgorithm,
+		KDF:       secretKDF,
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+	}, nil
+}
+
+// IsEncryptedValue reports whether a stored config value is encrypted
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, encryptedValuePrefix)
+}
+
+// secretBox encrypts the sensitive values of encrypted profiles on save and
+// decrypts them on load. Profiles without SecretEncryption pass through.
+type secretBox struct {
+	passphrase string
+	cipher     security.Cipher
+	mutex      sync.Mutex
+	keys       map[string][]byte // derived keys by salt
+}
+
+// newSecretBox creates a secret box; an empty passphrase disables encryption
+func newSecretBox(passphrase string) *secretBox {
+	return &secretBox{
+		passphrase: passphrase,
+		cipher:     security.NewAESGCMCipher(),
+		keys:       make(map[string][]byte),
+	}
+}
+
+// enabled reports whether a passphrase is available
+func (b *secretBox) enabled() bool {
+	return b != nil && b.passphrase != ""
+}
+
+// key derives the key of a profile from the passphrase and its salt
+func (b *secretBox) key(enc *SecretEncryption) ([]byte, error) {
+	if !b.enabled() {
+		return nil, ErrPassphraseRequired
+	}
+	if enc.Algorithm != secretAlgorithm || enc.KDF != secretKDF {
+		return nil, fmt.Errorf("unsupported secret encryption: %s/%s", enc.Algorithm, enc.KDF)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if key, ok := b.keys[enc.Salt]; ok {
+		return key, nil
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(enc.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret encryption salt: %w", err)
+	}
+	key, err := scrypt.Key([]byte(b.passphrase), salt, scryptN, scryptR, scryptP, secretKeyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	b.keys[enc.Salt] = key
+	return key, nil
+}
+
+// seal returns a copy of the profile with its sensitive values encrypted.
+// Values that are already encrypted are kept, so sealing is idempotent.
+func (b *secretBox) seal(profile *Profile) (*Profile, error) {
+	if profile.Encryption == nil {
+		return profile, nil
+	}
+	key, err := b.key(profile.Encryption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt profile %s: %w", profile.Name, err)
+	}
+
+	sealed := *profile
+	sealed.Config = make(map[string]string, len(profile.Config))
+	for k, v := range profile.Config {
+		if IsSensitiveKey(k) && v != "" && !IsEncryptedValue(v) {
+			ciphertext, err := b.cipher.Encrypt([]byte(v), key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt %s of profile %s: %w", k, profile.Name, err)
+			}
+			v = encryptedValuePrefix + base64.StdEncoding.EncodeToString(ciphertext)
+		}
+		sealed.Config[k] = v
+	}
+	return &sealed, nil
+}
+
+// open decrypts the encrypted values of a loaded profile in place. Profiles
+// saved before encryption existed have none and load unchanged.
+func (b *secretBox) open(profile *Profile) error {
+	for k, v := range profile.Config {
+		if !IsEncryptedValue(v) {
+			continue
+		}
+		if profile.Encryption == nil {
+			return fmt.Errorf("profile %s has an encrypted %s but no encryption settings", profile.Name, k)
+		}
+		key, err := b.key(profile.Encryption)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt profile %s: %w", profile.Name, err)
+		}
+
+		ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(v, encryptedValuePrefix))
+		if err != nil {
+			return fmt.Errorf("invalid encrypted %s of profile %s: %w", k, profile.Name, err)
+		}
+		plaintext, err := b.cipher.Decrypt(ciphertext, key)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s of profile %s (wrong passphrase?): %w", k, profile.Name, err)
+		}
+		profile.Config[k] = string(plaintext)
+	}
+	return nil
+}
+
+// sealAll returns sealed copies of the profiles, for writing them to a file
+func (b *secretBox) sealAll(profiles map[string]*Profile) (map[string]*Profile, error) {
+	sealed := make(map[string]*Profile, len(profiles))
+	for id, profile := range profiles {
+		p, err := b.seal(profile)
+		if err != nil {
+			return nil, err
+		}
+		sealed[id] = p
+	}
+	return sealed, nil
+}
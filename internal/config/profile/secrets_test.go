@@ -0,0 +1,218 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func createEncryptedProfile(t *testing.T, manager *ProfileManager, name string) *Profile {
+	t.Helper()
+	profile, err := manager.CreateProfile(ProfileCreateOptions{
+		Name:        name,
+		Environment: EnvironmentTest,
+		Config: map[string]string{
+			ConfigKeyAccessToken:       "test-token-value",
+			ConfigKeyAccessTokenSecret: "test-secret-value",
+			ConfigKeyZone:              "tk1v",
+		},
+		EncryptSecrets: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateProfile() failed: %v", err)
+	}
+	return profile
+}
+
+func TestEncryptedProfile_StoredEncrypted(t *testing.T) {
+	tempDir := t.TempDir()
+	manager, err := NewProfileManagerWithPassphrase(tempDir, "correct horse")
+	if err != nil {
+		t.Fatalf("NewProfileManagerWithPassphrase() failed: %v", err)
+	}
+	profile := createEncryptedProfile(t, manager, "Encrypted")
+
+	if profile.Encryption == nil || profile.Encryption.Algorithm != "AES-256-GCM" || profile.Encryption.Salt == "" {
+		t.Fatalf("Expected encryption settings, got %+v", profile.Encryption)
+	}
+	if profile.Config[ConfigKeyAccessToken] != "test-token-value" {
+		t.Errorf("Expected the in-memory profile to hold the plain value, got %s", profile.Config[ConfigKeyAccessToken])
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "profiles", profile.ID+".yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read profile file: %v", err)
+	}
+	content := string(data)
+	if strings.Contains(content, "test-token-value") || strings.Contains(content, "test-secret-value") {
+		t.Errorf("Secrets should not be stored in plain text:\n%s", content)
+	}
+	if strings.Count(content, encryptedValuePrefix) != 2 {
+		t.Errorf("Expected the two sensitive values to be encrypted:\n%s", content)
+	}
+	if !strings.Contains(content, "tk1v") {
+		t.Errorf("Non-sensitive values should stay readable:\n%s", content)
+	}
+}
+
+func TestEncryptedProfile_Reload(t *testing.T) {
+	tempDir := t.TempDir()
+	manager, err := NewProfileManagerWithPassphrase(tempDir, "correct horse")
+	if err != nil {
+		t.Fatalf("NewProfileManagerWithPassphrase() failed: %v", err)
+	}
+	created := createEncryptedProfile(t, manager, "Encrypted")
+
+	// The same passphrase decrypts transparently
+	reloaded, err := NewProfileManagerWithPassphrase(tempDir, "correct horse")
+	if err != nil {
+		t.Fatalf("NewProfileManagerWithPassphrase() failed: %v", err)
+	}
+	profile, err := reloaded.GetProfile("Encrypted")
+	if err != nil {
+		t.Fatalf("GetProfile() failed: %v", err)
+	}
+	if profile.Config[ConfigKeyAccessToken] != "test-token-value" || profile.Config[ConfigKeyAccessTokenSecret] != "test-secret-value" {
+		t.Errorf("Expected decrypted secrets, got %v", profile.Config)
+	}
+
+	// Saving again keeps the values decryptable
+	if err := reloaded.UpdateProfile(profile.ID, ProfileUpdateOptions{Config: map[string]string{ConfigKeyZone: "is1a"}}); err != nil {
+		t.Fatalf("UpdateProfile() failed: %v", err)
+	}
+	loaded, err := NewFileStorageWithPassphrase(tempDir, "correct horse").Load(created.ID)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if loaded.Config[ConfigKeyAccessToken] != "test-token-value" || loaded.Config[ConfigKeyZone] != "is1a" {
+		t.Errorf("Unexpected config after update: %v", loaded.Config)
+	}
+
+	// Without the passphrase, or with a wrong one, the profile cannot be loaded
+	if _, err := NewFileStorage(tempDir).Load(created.ID); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("Expected ErrPassphraseRequired, got %v", err)
+	}
+	if _, err := NewFileStorageWithPassphrase(tempDir, "wrong").Load(created.ID); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Expected a decryption error, got %v", err)
+	}
+}
+
+func TestEncryptedProfile_PlainProfilesStillLoad(t *testing.T) {
+	tempDir := t.TempDir()
+	plain, err := NewProfileManager(tempDir)
+	if err != nil {
+		t.Fatalf("NewProfileManager() failed: %v", err)
+	}
+	if _, err := plain.CreateProfile(ProfileCreateOptions{
+		Name:        "Plain",
+		Environment: EnvironmentTest,
+		Config: map[string]string{
+			ConfigKeyAccessToken:       "plain-token",
+			ConfigKeyAccessTokenSecret: "plain-secret",
+		},
+	}); err != nil {
+		t.Fatalf("CreateProfile() failed: %v", err)
+	}
+
+	manager, err := NewProfileManagerWithPassphrase(tempDir, "correct horse")
+	if err != nil {
+		t.Fatalf("NewProfileManagerWithPassphrase() failed: %v", err)
+	}
+	profile, err := manager.GetProfile("Plain")
+	if err != nil {
+		t.Fatalf("GetProfile() failed: %v", err)
+	}
+	if profile.Encryption != nil || profile.Config[ConfigKeyAccessToken] != "plain-token" {
+		t.Errorf("Expected the plain profile unchanged, got %+v", profile)
+	}
+}
+
+func TestEncryptedProfile_RequiresPassphrase(t *testing.T) {
+	manager, err := NewProfileManagerWithPassphrase(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("NewProfileManagerWithPassphrase() failed: %v", err)
+	}
+	_, err = manager.CreateProfile(ProfileCreateOptions{
+		Name:        "Encrypted",
+		Environment: EnvironmentTest,
+		Config: map[string]string{
+			ConfigKeyAccessToken:       "test-token-value",
+			ConfigKeyAccessTokenSecret: "test-secret-value",
+		},
+		EncryptSecrets: true,
+	})
+	if !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("Expected ErrPassphraseRequired, got %v", err)
+	}
+	if len(manager.ListProfiles()) != 0 {
+		t.Error("No profile should have been created")
+	}
+}
+
+func TestEncryptedProfile_ExportImportAndInheritance(t *testing.T) {
+	tempDir := t.TempDir()
+	manager, err := NewProfileManagerWithPassphrase(tempDir, "correct horse")
+	if err != nil {
+		t.Fatalf("NewProfileManagerWithPassphrase() failed: %v", err)
+	}
+	parent := createEncryptedProfile(t, manager, "Parent")
+
+	child, err := manager.CreateProfileFromParent("Child", "", parent.ID, nil)
+	if err != nil {
+		t.Fatalf("CreateProfileFromParent() failed: %v", err)
+	}
+	if child.Encryption == nil {
+		t.Error("Expected the child to inherit the encryption of its parent")
+	}
+
+	exportPath := filepath.Join(tempDir, "export.yaml")
+	if err := manager.ExportProfile(parent.ID, exportPath); err != nil {
+		t.Fatalf("ExportProfile() failed: %v", err)
+	}
+	data, err := os.ReadFile(exportPath)
+	if err != nil {
+		t.Fatalf("Failed to read export file: %v", err)
+	}
+	if strings.Contains(string(data), "test-token-value") {
+		t.Errorf("Exported secrets should stay encrypted:\n%s", data)
+	}
+
+	imported, err := manager.ImportProfile(exportPath)
+	if err != nil {
+		t.Fatalf("ImportProfile() failed: %v", err)
+	}
+	if imported.Config[ConfigKeyAccessToken] != "test-token-value" {
+		t.Errorf("Expected the imported secrets to be decrypted, got %v", imported.Config)
+	}
+}
+
+func TestProfileCommand_CreateProfileEncryptSecrets(t *testing.T) {
+	manager, err := NewProfileManagerWithPassphrase(t.TempDir(), "correct horse")
+	if err != nil {
+		t.Fatalf("NewProfileManagerWithPassphrase() failed: %v", err)
+	}
+	pc := NewProfileCommand(manager, NewTemplateManager())
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("environment", "test", "Environment")
+	cmd.Flags().Bool("encrypt-secrets", true, "Encrypt secrets")
+	cmd.Flags().StringSlice("config", []string{
+		"SAKURACLOUD_ACCESS_TOKEN=test-token",
+		"SAKURACLOUD_ACCESS_TOKEN_SECRET=test-secret",
+	}, "Configuration")
+
+	if err := pc.CreateProfile(cmd, []string{"Encrypted"}); err != nil {
+		t.Fatalf("CreateProfile() failed: %v", err)
+	}
+	profile, err := manager.GetProfile("Encrypted")
+	if err != nil {
+		t.Fatalf("GetProfile() failed: %v", err)
+	}
+	if profile.Encryption == nil {
+		t.Error("Expected --encrypt-secrets to encrypt the profile secrets")
+	}
+}
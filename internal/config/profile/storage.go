@@ -1,7 +1,9 @@
 package profile
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,12 +15,20 @@ import (
 // FileStorage implements ProfileStorage using YAML files
 type FileStorage struct {
 	configDir string
+	secrets   *secretBox
 }
 
 // NewFileStorage creates a new file-based profile storage
 func NewFileStorage(configDir string) *FileStorage {
+	return NewFileStorageWithPassphrase(configDir, "")
+}
+
+// NewFileStorageWithPassphrase creates a file-based profile storage that
+// encrypts the secrets of encrypted profiles with a key derived from the passphrase
+func NewFileStorageWithPassphrase(configDir, passphrase string) *FileStorage {
 	return &FileStorage{
 		configDir: configDir,
+		secrets:   newSecretBox(passphrase),
 	}
 }
 
@@ -28,8 +38,13 @@ func (fs *FileStorage) Save(profile *Profile) error {
 		return err
 	}
 
+	sealed, err := fs.secrets.seal(profile)
+	if err != nil {
+		return err
+	}
+
 	filename := fs.getProfileFilename(profile.ID)
-	return writeYAMLFile(filename, profile)
+	return writeYAMLFile(filename, sealed)
 }
 
 // Load loads a profile from a YAML file
@@ -40,6 +55,9 @@ func (fs *FileStorage) Load(id string) (*Profile, error) {
 	if err := readYAMLFile(filename, &profile); err != nil {
 		return nil, err
 	}
+	if err := fs.secrets.open(&profile); err != nil {
+		return nil, err
+	}
 
 	return &profile, nil
 }
@@ -70,7 +88,11 @@ func (fs *FileStorage) LoadAll() (map[string]*Profile, error) {
 		filename := filepath.Join(profilesDir, entry.Name())
 		var profile Profile
 
-		if err := readYAMLFile(filename, &profile); err != nil {
+		err := readYAMLFile(filename, &profile)
+		if err == nil {
+			err = fs.secrets.open(&profile)
+		}
+		if err != nil {
 			// Log error but continue loading other profiles
 			fmt.Fprintf(os.Stderr, "Warning: failed to load profile %s: %v\n", filename, err)
 			continue
@@ -129,9 +151,61 @@ func (fs *FileStorage) getDefaultFilename() string {
 	return filepath.Join(fs.configDir, "default-profile")
 }
 
-// Utility functions for YAML I/O
+// Utility functions for YAML and JSON I/O
+
+// isJSONFile reports whether a profile file is JSON by its extension; any
+// other file, including .yaml and .yml, is YAML
+func isJSONFile(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".json")
+}
+
+// writeProfileFile writes data as JSON or YAML depending on the extension
+func writeProfileFile(filename string, data interface{}) error {
+	if isJSONFile(filename) {
+		return writeJSONFile(filename, data)
+	}
+	return writeYAMLFile(filename, data)
+}
+
+// readProfileFile reads JSON or YAML depending on the extension
+func readProfileFile(filename string, data interface{}) error {
+	if isJSONFile(filename) {
+		return readJSONFile(filename, data)
+	}
+	return readYAMLFile(filename, data)
+}
 
 func writeYAMLFile(filename string, data interface{}) error {
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+
+		if err := encoder.Encode(data); err != nil {
+			return fmt.Errorf("failed to encode YAML: %w", err)
+		}
+
+		if err := encoder.Close(); err != nil {
+			return fmt.Errorf("failed to close YAML encoder: %w", err)
+		}
+		return nil
+	})
+}
+
+func writeJSONFile(filename string, data interface{}) error {
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(data); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	})
+}
+
+// writeFileAtomic writes a file through a temp file and a rename, with the
+// 0600 permissions of profile files
+func writeFileAtomic(filename string, encode func(w io.Writer) error) error {
 	// Ensure directory exists
 	dir := filepath.Dir(filename)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -152,15 +226,8 @@ func writeYAMLFile(filename string, data interface{}) error {
 		}
 	}()
 
-	encoder := yaml.NewEncoder(file)
-	encoder.SetIndent(2)
-
-	if err := encoder.Encode(data); err != nil {
-		return fmt.Errorf("failed to encode YAML: %w", err)
-	}
-
-	if err := encoder.Close(); err != nil {
-		return fmt.Errorf("failed to close YAML encoder: %w", err)
+	if err := encode(file); err != nil {
+		return err
 	}
 
 	if err := file.Sync(); err != nil {
@@ -200,6 +267,24 @@ func readYAMLFile(filename string, data interface{}) error {
 	return nil
 }
 
+func readJSONFile(filename string, data interface{}) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("profile not found: %s", filename)
+		}
+		return fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	if err := decoder.Decode(data); err != nil {
+		return fmt.Errorf("failed to decode JSON from %s: %w", filename, err)
+	}
+
+	return nil
+}
+
 // BackupStorage provides backup and restore functionality
 type BackupStorage struct {
 	storage   ProfileStorage
@@ -283,6 +368,9 @@ func (fs *FileStorage) Backup() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to load profiles for backup: %w", err)
 	}
+	if profiles, err = fs.secrets.sealAll(profiles); err != nil {
+		return "", err
+	}
 
 	// Save backup
 	data, err := yaml.Marshal(profiles)
@@ -325,6 +413,9 @@ func (fs *FileStorage) Export(profileID string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load profile for export: %w", err)
 	}
+	if profile, err = fs.secrets.seal(profile); err != nil {
+		return nil, err
+	}
 
 	data, err := yaml.Marshal(profile)
 	if err != nil {
@@ -349,6 +440,9 @@ func (fs *FileStorage) Import(data []byte) (*Profile, error) {
 	if err := fs.Save(&profile); err != nil {
 		return nil, fmt.Errorf("failed to save imported profile %s: %w", profile.Name, err)
 	}
+	if err := fs.secrets.open(&profile); err != nil {
+		return nil, err
+	}
 
 	return &profile, nil
 }
@@ -359,6 +453,9 @@ func (fs *FileStorage) ExportToFile(exportPath string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load profiles for export: %w", err)
 	}
+	if profiles, err = fs.secrets.sealAll(profiles); err != nil {
+		return err
+	}
 
 	data, err := yaml.Marshal(profiles)
 	if err != nil {
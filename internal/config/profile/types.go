@@ -18,6 +18,7 @@ type Profile struct {
 	LastUsedAt  time.Time         `json:"last_used_at" yaml:"last_used_at"`
 	Tags        []string          `json:"tags" yaml:"tags"`
 	IsDefault   bool              `json:"is_default" yaml:"is_default"`
+	Encryption  *SecretEncryption `json:"encryption,omitempty" yaml:"encryption,omitempty"` // nil when secrets are stored in plain text
 }
 
 // ProfileManager manages multiple configuration profiles
@@ -28,6 +29,8 @@ type ProfileManager struct {
 	configDir       string
 	storage         ProfileStorage
 	templateManager *TemplateManager
+	secrets         *secretBox
+	maxDepth        int // maximum number of ancestors of a profile
 }
 
 // ProfileStorage defines the interface for profile persistence
@@ -61,13 +64,14 @@ type ConfigKeyDef struct {
 
 // ProfileCreateOptions contains options for creating a new profile
 type ProfileCreateOptions struct {
-	Name        string
-	Description string
-	Environment string
-	Config      map[string]string
-	ParentID    string
-	Tags        []string
-	SetDefault  bool
+	Name           string
+	Description    string
+	Environment    string
+	Config         map[string]string
+	ParentID       string
+	Tags           []string
+	SetDefault     bool
+	EncryptSecrets bool // store the sensitive config values encrypted with the manager's passphrase
 }
 
 // ProfileUpdateOptions contains options for updating a profile
@@ -80,6 +84,11 @@ type ProfileUpdateOptions struct {
 	SetDefault  *bool
 }
 
+// ProfileExportOptions contains options for exporting a profile
+type ProfileExportOptions struct {
+	Redact bool // replace the sensitive config values with empty strings
+}
+
 // ProfileListOptions contains options for listing profiles
 type ProfileListOptions struct {
 	Environment string
@@ -110,3 +119,6 @@ const (
 const (
 	DefaultProfileName = "default"
 )
+
+// DefaultMaxInheritanceDepth is the default maximum number of ancestors of a profile
+const DefaultMaxInheritanceDepth = 10
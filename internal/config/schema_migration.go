@@ -0,0 +1,289 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// CurrentConfigVersion is the config schema version written to [general] version
+const CurrentConfigVersion = "1.9.0"
+
+// legacyConfigVersion stands for files written before [general] version existed
+const legacyConfigVersion = "0"
+
+// ConfigMigrationResult describes what MigrateConfigFile changed
+type ConfigMigrationResult struct {
+	FromVersion string
+	ToVersion   string
+	BackupPath  string   // empty when the file was already current
+	Changes     []string // one entry per moved, renamed or converted key
+	UnknownKeys []string // "[section] key" entries the current schema does not read
+}
+
+// Migrated reports whether the file was rewritten
+func (r *ConfigMigrationResult) Migrated() bool {
+	return r.BackupPath != ""
+}
+
+// keyMove renames a key, possibly into another section. convert, when set,
+// rewrites the value; returning false drops the key instead of moving it.
+type keyMove struct {
+	fromSection, fromKey string
+	toSection, toKey     string
+	convert              func(value string) (string, bool)
+}
+
+// schemaMigration upgrades a file to version to
+type schemaMigration struct {
+	to    string
+	moves []keyMove
+}
+
+// schemaMigrations are applied in order to files older than their target version
+var schemaMigrations = []schemaMigration{
+	{
+		// Before 1.9.0 files had no version, and keys were either written without
+		// a section or under the names of the early validation/help settings
+		to: "1.9.0",
+		moves: []keyMove{
+			{fromSection: "general", fromKey: "strict_mode", toSection: "validation", toKey: "strict_mode"},
+			{fromSection: "transform", fromKey: "add_comments", toSection: "transform", toKey: "add_explanatory_comments"},
+			{fromSection: "validation", fromKey: "check_typos", toSection: "validation", toKey: "typo_detection_enabled"},
+			{fromSection: "validation", fromKey: "check_deprecated", toSection: "validation", toKey: "skip_deprecated_warnings", convert: invertBool},
+			{fromSection: "validation", fromKey: "suggest_alternatives", toSection: "error_feedback", toKey: "show_alternatives"},
+			{fromSection: "help_system", fromKey: "interactive_help", toSection: "help_system", toKey: "enable_interactive_help"},
+			{fromSection: "help_system", fromKey: "beginner_mode", toSection: "help_system", toKey: "skill_level", convert: beginnerModeToSkillLevel},
+		},
+	},
+}
+
+// sandboxSectionKeys lists the keys of the sections read by LoadFromFileWithPath
+var sandboxSectionKeys = map[string][]string{
+	"sakura-cloud": {"access_token", "access_token_secret", "zone", "api_endpoint"},
+	"sandbox":      {"enabled", "debug", "dry_run", "interactive", "timeout", "retry_count", "rate_limit", "destructive_commands"},
+}
+
+// MigrateConfigFile upgrades the config file at configPath to CurrentConfigVersion.
+// The original is backed up next to it before the upgraded file is written with
+// the same permissions. Sections not owned by the schema (such as
+// [sakura-cloud]) are kept as they are, and files that are already current are
+// left untouched.
+func MigrateConfigFile(configPath string) (*ConfigMigrationResult, error) {
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ini.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイル読み込みに失敗: %w", err)
+	}
+
+	from := cfg.Section("general").Key("version").String()
+	if from == "" {
+		from = legacyConfigVersion
+	}
+	result := &ConfigMigrationResult{FromVersion: from, ToVersion: CurrentConfigVersion}
+
+	switch cmp := compareConfigVersions(from, CurrentConfigVersion); {
+	case cmp > 0:
+		return nil, fmt.Errorf("設定ファイルのバージョン %s は対応しているバージョン %s より新しいため移行できません", from, CurrentConfigVersion)
+	case cmp == 0:
+		result.UnknownKeys = unknownConfigKeys(cfg)
+		return result, nil
+	}
+
+	result.Changes = append(result.Changes, moveSectionlessKeys(cfg)...)
+	for _, m := range schemaMigrations {
+		if compareConfigVersions(from, m.to) >= 0 {
+			continue
+		}
+		for _, mv := range m.moves {
+			if change, ok := applyKeyMove(cfg, mv); ok {
+				result.Changes = append(result.Changes, change)
+			}
+		}
+	}
+	cfg.Section("general").Key("version").SetValue(CurrentConfigVersion)
+	result.UnknownKeys = unknownConfigKeys(cfg)
+
+	var buf bytes.Buffer
+	if _, err := cfg.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("設定変換に失敗: %w", err)
+	}
+
+	result.BackupPath = configPath + ".backup." + time.Now().Format("20060102-150405")
+	if err := NewConfigMigrator(from, CurrentConfigVersion).backupConfig(configPath, result.BackupPath); err != nil {
+		return nil, fmt.Errorf("バックアップ作成に失敗: %w", err)
+	}
+	if err := os.WriteFile(configPath, buf.Bytes(), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("新設定保存に失敗: %w", err)
+	}
+
+	return result, nil
+}
+
+// moveSectionlessKeys moves keys written before any section header into the
+// section that owns them, so that the migrations below also see them. Keys no
+// section owns are left in place.
+func moveSectionlessKeys(cfg *ini.File) []string {
+	owners := make(map[string]string)
+	for _, m := range schemaMigrations {
+		for _, mv := range m.moves {
+			owners[mv.fromKey] = mv.fromSection
+		}
+	}
+	for section, keys := range schemaSectionKeys() {
+		for key := range keys {
+			owners[key] = section
+		}
+	}
+	for section, keys := range sandboxSectionKeys {
+		for _, key := range keys {
+			owners[key] = section
+		}
+	}
+
+	var changes []string
+	for _, key := range cfg.Section(ini.DefaultSection).KeyStrings() {
+		section, ok := owners[key]
+		if !ok {
+			continue
+		}
+		if change, ok := applyKeyMove(cfg, keyMove{fromSection: ini.DefaultSection, fromKey: key, toSection: section, toKey: key}); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// applyKeyMove performs mv if its source key exists. A value already present at
+// the destination wins over the old one.
+func applyKeyMove(cfg *ini.File, mv keyMove) (string, bool) {
+	from := cfg.Section(mv.fromSection)
+	if !from.HasKey(mv.fromKey) {
+		return "", false
+	}
+	value := from.Key(mv.fromKey).String()
+	comment := from.Key(mv.fromKey).Comment
+	from.DeleteKey(mv.fromKey)
+
+	source := fmt.Sprintf("%s %s", sectionLabel(mv.fromSection), mv.fromKey)
+	to := cfg.Section(mv.toSection)
+	if to.HasKey(mv.toKey) {
+		return fmt.Sprintf("%s を削除（%s %s の値を優先）", source, sectionLabel(mv.toSection), mv.toKey), true
+	}
+	if mv.convert != nil {
+		converted, ok := mv.convert(value)
+		if !ok {
+			return fmt.Sprintf("%s = %s を削除（%s %s の既定値と同じ）", source, value, sectionLabel(mv.toSection), mv.toKey), true
+		}
+		value = converted
+	}
+
+	key, err := to.NewKey(mv.toKey, value)
+	if err != nil {
+		return "", false
+	}
+	key.Comment = comment
+	return fmt.Sprintf("%s → %s %s = %s", source, sectionLabel(mv.toSection), mv.toKey, value), true
+}
+
+// unknownConfigKeys lists keys in schema sections that the current schema does not read
+func unknownConfigKeys(cfg *ini.File) []string {
+	var unknown []string
+	for section, keys := range schemaSectionKeys() {
+		if !hasSection(cfg, section) {
+			continue
+		}
+		for _, key := range cfg.Section(section).KeyStrings() {
+			if !keys[key] {
+				unknown = append(unknown, fmt.Sprintf("[%s] %s", section, key))
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// schemaSectionKeys returns the ini keys of each IntegratedConfig section
+func schemaSectionKeys() map[string]map[string]bool {
+	structs := map[string]interface{}{
+		"general":        GeneralConfig{},
+		"transform":      TransformConfig{},
+		"validation":     ValidationConfig{},
+		"error_feedback": ErrorFeedbackConfig{},
+		"help_system":    HelpSystemConfig{},
+		"performance":    PerformanceConfig{},
+		"output":         OutputConfig{},
+	}
+
+	sections := make(map[string]map[string]bool, len(structs))
+	for section, s := range structs {
+		keys := make(map[string]bool)
+		t := reflect.TypeOf(s)
+		for i := 0; i < t.NumField(); i++ {
+			if tag := t.Field(i).Tag.Get("ini"); tag != "" && tag != "-" {
+				keys[tag] = true
+			}
+		}
+		sections[section] = keys
+	}
+	return sections
+}
+
+func hasSection(cfg *ini.File, name string) bool {
+	_, err := cfg.GetSection(name)
+	return err == nil
+}
+
+func sectionLabel(section string) string {
+	if section == ini.DefaultSection {
+		return "(セクションなし)"
+	}
+	return "[" + section + "]"
+}
+
+// invertBool converts check_* flags to their skip_* counterparts; the default (false) is dropped
+func invertBool(value string) (string, bool) {
+	b, err := strconv.ParseBool(value)
+	if err != nil || b {
+		return "", false
+	}
+	return "true", true
+}
+
+// beginnerModeToSkillLevel maps beginner_mode = true to skill_level = beginner
+func beginnerModeToSkillLevel(value string) (string, bool) {
+	if b, err := strconv.ParseBool(value); err == nil && b {
+		return "beginner", true
+	}
+	return "", false
+}
+
+// compareConfigVersions compares dotted version strings numerically
+func compareConfigVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+		if aNum != bNum {
+			return aNum - bNum
+		}
+	}
+	return 0
+}
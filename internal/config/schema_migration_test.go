@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func copyConfigFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "configs", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestMigrateConfigFile_SampleOldConfig(t *testing.T) {
+	configPath := copyConfigFixture(t, "beginner.conf")
+	original, _ := os.ReadFile(configPath)
+
+	result, err := MigrateConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("MigrateConfigFile failed: %v", err)
+	}
+	if !result.Migrated() || result.FromVersion != legacyConfigVersion || result.ToVersion != CurrentConfigVersion {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	backup, err := os.ReadFile(result.BackupPath)
+	if err != nil || string(backup) != string(original) {
+		t.Errorf("Expected backup with the original content at %s (%v)", result.BackupPath, err)
+	}
+	if info, err := os.Stat(configPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions to be kept, got %v (%v)", info.Mode().Perm(), err)
+	}
+
+	migrated, err := ReadIntegratedConfig(configPath)
+	if err != nil {
+		t.Fatalf("Migrated config should load: %v", err)
+	}
+	if migrated.General.Version != CurrentConfigVersion {
+		t.Errorf("Expected version %s, got %s", CurrentConfigVersion, migrated.General.Version)
+	}
+	if migrated.Validation.StrictMode || !migrated.Validation.TypoDetectionEnabled || migrated.Validation.SkipDeprecatedWarnings {
+		t.Errorf("Unexpected validation settings: %+v", migrated.Validation)
+	}
+	if migrated.HelpSystem.SkillLevel != "beginner" || !migrated.HelpSystem.EnableInteractiveHelp {
+		t.Errorf("Unexpected help settings: %+v", migrated.HelpSystem)
+	}
+	if !migrated.Transform.AddExplanatoryComments || !migrated.ErrorFeedback.ShowAlternatives {
+		t.Errorf("Expected renamed keys to be applied: %+v %+v", migrated.Transform, migrated.ErrorFeedback)
+	}
+
+	content, _ := os.ReadFile(configPath)
+	for _, old := range []string{"beginner_mode", "check_typos", "add_comments", "interactive_help"} {
+		if strings.Contains(string(content), "\n"+old+" ") {
+			t.Errorf("Old key %q should be removed, got:\n%s", old, content)
+		}
+	}
+	if !strings.Contains(strings.Join(result.UnknownKeys, "\n"), "[transform] apply_rules") {
+		t.Errorf("Expected keys without a current equivalent to be reported, got %v", result.UnknownKeys)
+	}
+
+	// A current file is left untouched
+	again, err := MigrateConfigFile(configPath)
+	if err != nil || again.Migrated() || len(again.Changes) != 0 {
+		t.Errorf("Expected no migration for a current file, got %+v (%v)", again, err)
+	}
+}
+
+func TestMigrateConfigFile_SectionlessKeys(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	content := "access_token = token\n# 検証を厳格に\nstrict_mode = true\ncolor_output = false\ncheck_deprecated = false\n\n[sandbox]\ndry_run = true\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := MigrateConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("MigrateConfigFile failed: %v", err)
+	}
+	if len(result.Changes) != 5 {
+		t.Errorf("Expected 4 moved keys and 1 rename, got %v", result.Changes)
+	}
+
+	sandbox, err := LoadFromFileWithPath(configPath)
+	if err != nil {
+		t.Fatalf("Sandbox settings should still load: %v", err)
+	}
+	if sandbox.AccessToken != "token" || !sandbox.DryRun {
+		t.Errorf("Unexpected sandbox settings: %+v", sandbox)
+	}
+
+	migrated, err := ReadIntegratedConfig(configPath)
+	if err != nil {
+		t.Fatalf("Migrated config should load: %v", err)
+	}
+	if !migrated.Validation.StrictMode || migrated.General.ColorOutput || !migrated.Validation.SkipDeprecatedWarnings {
+		t.Errorf("Expected sectionless keys to be moved: %+v %+v", migrated.Validation, migrated.General)
+	}
+
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), "# 検証を厳格に") {
+		t.Errorf("Expected comments to be kept, got:\n%s", data)
+	}
+}
+
+func TestMigrateConfigFile_NewerVersion(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	if err := os.WriteFile(configPath, []byte("[general]\nversion = 99.0.0\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateConfigFile(configPath); err == nil || !strings.Contains(err.Error(), "99.0.0") {
+		t.Errorf("Expected error for a newer version, got %v", err)
+	}
+	if _, err := MigrateConfigFile(filepath.Join(t.TempDir(), "missing.conf")); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
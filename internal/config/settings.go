@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/config/profile"
+)
+
+// Kinds of a SettingInfo value
+const (
+	SettingKindBool   = "bool"
+	SettingKindInt    = "int"
+	SettingKindFloat  = "float"
+	SettingKindString = "string"
+)
+
+// SettingSections are the sections of the settings UpdateSetting changes, in file order
+var SettingSections = []string{"general", "transform", "validation", "error_feedback", "help_system", "performance", "output"}
+
+// settingChoices are the valid values of the settings that take one of a fixed set
+var settingChoices = map[string][]string{
+	"general.language":              {"ja", "en"},
+	"general.log_level":             {"debug", "info", "warn", "error"},
+	"validation.distance_algorithm": {"levenshtein", "damerau-levenshtein"},
+	"help_system.skill_level":       {"beginner", "intermediate", "advanced", "expert"},
+	"output.format":                 {"auto", "plain", "colored", "json"},
+	"output.progress_style":         {"bar", "percentage", "dots"},
+	"output.report_level":           {"minimal", "summary", "detailed"},
+}
+
+// checkSettingChoice reports an error when a setting taking one of a fixed set
+// is given another value
+func checkSettingChoice(section, key string, value interface{}) error {
+	choices, ok := settingChoices[section+"."+key]
+	if !ok {
+		return nil
+	}
+	text, _ := value.(string)
+	for _, choice := range choices {
+		if strings.TrimSpace(text) == choice {
+			return nil
+		}
+	}
+	return fmt.Errorf("[%s] %s には %s のいずれかを指定してください: %v", section, key, strings.Join(choices, ", "), value)
+}
+
+// SettingInfo describes a setting and its current value, for settings editors
+type SettingInfo struct {
+	Section   string
+	Key       string
+	Kind      string      // one of the SettingKind constants
+	Value     interface{} // of the Go type of the field
+	Choices   []string    // valid values of a setting taking one of a fixed set, nil otherwise
+	Sensitive bool        // the value should be masked when displayed
+}
+
+// Settings returns the settings UpdateSetting can change with their current
+// values, in section order, then in the order the file writes them
+func (ic *IntegratedConfig) Settings() []SettingInfo {
+	var settings []SettingInfo
+	for _, section := range SettingSections {
+		v := reflect.ValueOf(ic.sectionStruct(section)).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			key := v.Type().Field(i).Tag.Get("ini")
+			if key == "" || key == "-" {
+				continue
+			}
+			info := SettingInfo{
+				Section:   section,
+				Key:       key,
+				Kind:      SettingKindString,
+				Value:     v.Field(i).Interface(),
+				Choices:   settingChoices[section+"."+key],
+				Sensitive: profile.IsSensitiveKey(key),
+			}
+			switch v.Field(i).Kind() {
+			case reflect.Bool:
+				info.Kind = SettingKindBool
+			case reflect.Int:
+				info.Kind = SettingKindInt
+			case reflect.Float64:
+				info.Kind = SettingKindFloat
+			}
+			settings = append(settings, info)
+		}
+	}
+	return settings
+}
+
+// ReadIntegratedConfigFile loads the settings a configuration file writes, for
+// editing it: unlike ReadIntegratedConfig, neither the environment variable
+// overrides nor the profile are applied, so that saving does not write them
+// into the file. A missing file is not an error; the defaults are used and
+// Save creates it.
+func ReadIntegratedConfigFile(configPath string) (*IntegratedConfig, error) {
+	config := NewIntegratedConfig()
+	config.configPath = configPath
+	config.autoSave = false
+
+	if err := config.loadFromFile(); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("設定ファイル読み込みに失敗: %w", err)
+	}
+	return config, nil
+}
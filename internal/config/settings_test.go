@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSettings(t *testing.T) {
+	config := NewIntegratedConfig()
+	settings := config.Settings()
+
+	byKey := make(map[string]SettingInfo)
+	for _, s := range settings {
+		byKey[s.Section+"."+s.Key] = s
+	}
+	if settings[0].Section != "general" || settings[0].Key != "version" {
+		t.Errorf("Expected the settings to start with general.version, got %+v", settings[0])
+	}
+	if s := byKey["validation.max_suggestions"]; s.Kind != SettingKindInt || s.Value != 5 {
+		t.Errorf("Unexpected max_suggestions %+v", s)
+	}
+	if s := byKey["general.verbose"]; s.Kind != SettingKindBool || s.Value != false {
+		t.Errorf("Unexpected verbose %+v", s)
+	}
+	if s := byKey["error_feedback.suggestion_confidence_threshold"]; s.Kind != SettingKindFloat {
+		t.Errorf("Unexpected suggestion_confidence_threshold %+v", s)
+	}
+	if s := byKey["output.report_level"]; strings.Join(s.Choices, ",") != "minimal,summary,detailed" {
+		t.Errorf("Unexpected report_level choices %v", s.Choices)
+	}
+
+	config.autoSave = false
+	if err := config.UpdateSetting("output", "report_level", "verbose"); err == nil || !strings.Contains(err.Error(), "minimal, summary, detailed") {
+		t.Errorf("Expected a value outside the choices to be rejected, got %v", err)
+	}
+}
+
+func TestReadIntegratedConfigFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "test-config.conf")
+	t.Setenv("USACLOUD_UPDATE_VERBOSE", "true")
+
+	config, err := ReadIntegratedConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error for a missing file: %v", err)
+	}
+	if config.General.Verbose {
+		t.Error("Expected the environment variable overrides not to be applied")
+	}
+
+	content := `[sakura-cloud]
+# credentials
+access_token = token
+
+[general]
+profile = expert
+verbose = false
+
+[profiles.expert]
+strict_mode = true
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	config, err = ReadIntegratedConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Validation.StrictMode || config.General.Verbose {
+		t.Error("Expected neither the profile nor the environment to be applied")
+	}
+
+	if err := config.UpdateSetting("validation", "max_suggestions", "7"); err != nil {
+		t.Fatalf("UpdateSetting failed: %v", err)
+	}
+	if err := config.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"[sakura-cloud]", "access_token = token", "# credentials", "max_suggestions", "= 7", "[profiles.expert]"} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("Expected %q in the saved file:\n%s", want, saved)
+		}
+	}
+}
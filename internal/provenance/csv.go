@@ -0,0 +1,59 @@
+package provenance
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/armaniacs/usacloud-update/internal/transform"
+)
+
+// ChangesCSVHeader is the header row written by WriteChangesCSV
+var ChangesCSVHeader = []string{"file", "line", "rule", "before", "after", "comment"}
+
+// ChangeRow is a single change of a rule to a line, as listed for spreadsheet review
+type ChangeRow struct {
+	Path       string
+	LineNumber int
+	RuleName   string
+	Before     string // fragment the rule replaced
+	After      string // fragment it was replaced with
+	Comment    string // reason written into the inline comment
+}
+
+// NewChangeRows returns one row per change of result, in application order.
+// describe returns the reason of a rule, "" when it has none.
+func NewChangeRows(path string, lineNumber int, result *transform.Result, describe func(ruleName string) string) []ChangeRow {
+	if result == nil {
+		return nil
+	}
+	rows := make([]ChangeRow, 0, len(result.Changes))
+	for _, c := range result.Changes {
+		rows = append(rows, ChangeRow{
+			Path:       path,
+			LineNumber: lineNumber,
+			RuleName:   c.RuleName,
+			Before:     c.Before,
+			After:      c.After,
+			Comment:    describe(c.RuleName),
+		})
+	}
+	return rows
+}
+
+// WriteChangesCSV writes rows as RFC 4180 CSV preceded by ChangesCSVHeader.
+// Fields containing quotes, commas or newlines are quoted.
+func WriteChangesCSV(w io.Writer, rows []ChangeRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ChangesCSVHeader); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{r.Path, strconv.Itoa(r.LineNumber), r.RuleName, r.Before, r.After, r.Comment}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,65 @@
+package provenance
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"github.com/armaniacs/usacloud-update/internal/transform"
+)
+
+func TestNewChangeRows(t *testing.T) {
+	eng := transform.NewDefaultEngine()
+	describe := func(name string) string {
+		ex, _ := eng.Explain(name)
+		return ex.Description
+	}
+
+	unchanged := eng.Apply("usacloud server list")
+	if rows := NewChangeRows("deploy.sh", 1, &unchanged, describe); len(rows) != 0 {
+		t.Errorf("expected no rows for an unchanged line, got %+v", rows)
+	}
+
+	changed := eng.Apply("usacloud iso-image list --output-type csv")
+	rows := NewChangeRows("deploy.sh", 2, &changed, describe)
+	if len(rows) != 2 {
+		t.Fatalf("expected a row per change, got %+v", rows)
+	}
+	if rows[0].RuleName != "output-type-csv-tsv" || rows[1].RuleName != "iso-image-to-cdrom" {
+		t.Errorf("unexpected rules: %+v", rows)
+	}
+	for _, r := range rows {
+		if r.Path != "deploy.sh" || r.LineNumber != 2 || r.Before == "" || r.After == "" || r.Comment == "" {
+			t.Errorf("unexpected row: %+v", r)
+		}
+	}
+}
+
+func TestWriteChangesCSV(t *testing.T) {
+	rows := []ChangeRow{
+		{Path: "a,b.sh", LineNumber: 1, RuleName: "r", Before: `say "hi"`, After: "x\ny", Comment: "reason"},
+		{Path: "c.sh", LineNumber: 12, RuleName: "s", Before: "b", After: "a"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteChangesCSV(&buf, rows); err != nil {
+		t.Fatalf("WriteChangesCSV failed: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"a,b.sh",1,r,"say ""hi""","x`+"\n"+`y",reason`)) {
+		t.Errorf("expected quoted fields, got:\n%s", buf.String())
+	}
+
+	got, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	want := [][]string{
+		ChangesCSVHeader,
+		{"a,b.sh", "1", "r", `say "hi"`, "x\ny", "reason"},
+		{"c.sh", "12", "s", "b", "a", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected records:\ngot  %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+// Package provenance builds a per-line audit trail of the transformations
+// applied to a script, written as JSON Lines for compliance review.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/armaniacs/usacloud-update/internal/transform"
+)
+
+// Source identifies the input the records were produced from
+type Source struct {
+	Path   string // input path ("-" for stdin)
+	SHA256 string // hex encoded SHA-256 of the input content
+
+	// Versions the input was migrated between; empty for
+	// transform.MigrationSourceVersion and transform.MigrationTargetVersion
+	SourceVersion string
+	TargetVersion string
+}
+
+// Record is the provenance of a single changed line
+type Record struct {
+	Timestamp     time.Time `json:"timestamp"`
+	InputPath     string    `json:"input_path"`
+	InputSHA256   string    `json:"input_sha256"`
+	LineNumber    int       `json:"line_number"`
+	Original      string    `json:"original"`
+	Transformed   string    `json:"transformed"`
+	Rules         []string  `json:"rules"`
+	SourceVersion string    `json:"source_version"`
+	TargetVersion string    `json:"target_version"`
+}
+
+// HashContent returns the hex encoded SHA-256 of content
+func HashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewRecord builds the record for a transformed line, or returns false if the line was not changed
+func NewRecord(src Source, lineNumber int, result *transform.Result, timestamp time.Time) (Record, bool) {
+	if result == nil || !result.Changed {
+		return Record{}, false
+	}
+
+	rules := make([]string, 0, len(result.Changes))
+	for _, c := range result.Changes {
+		rules = append(rules, c.RuleName)
+	}
+
+	sourceVersion, targetVersion := src.SourceVersion, src.TargetVersion
+	if sourceVersion == "" {
+		sourceVersion = transform.MigrationSourceVersion
+	}
+	if targetVersion == "" {
+		targetVersion = transform.MigrationTargetVersion
+	}
+
+	return Record{
+		Timestamp:     timestamp.UTC(),
+		InputPath:     src.Path,
+		InputSHA256:   src.SHA256,
+		LineNumber:    lineNumber,
+		Original:      result.Original,
+		Transformed:   result.Line,
+		Rules:         rules,
+		SourceVersion: sourceVersion,
+		TargetVersion: targetVersion,
+	}, true
+}
+
+// Write writes records as JSON Lines, one record per line
+func Write(w io.Writer, records []Record) error {
+	encoder := json.NewEncoder(w)
+	for _, r := range records {
+		if err := encoder.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package provenance
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/armaniacs/usacloud-update/internal/transform"
+)
+
+func TestHashContent(t *testing.T) {
+	// SHA-256 of the empty string
+	want := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if got := HashContent(nil); got != want {
+		t.Errorf("HashContent(nil) = %s, want %s", got, want)
+	}
+}
+
+func TestNewRecord(t *testing.T) {
+	eng := transform.NewDefaultEngine()
+	src := Source{Path: "deploy.sh", SHA256: "abc123"}
+	ts := time.Date(2025, 9, 1, 12, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+
+	unchanged := eng.Apply("usacloud server list")
+	if _, ok := NewRecord(src, 1, &unchanged, ts); ok {
+		t.Error("expected no record for an unchanged line")
+	}
+
+	changed := eng.Apply("usacloud iso-image list --output-type csv")
+	record, ok := NewRecord(src, 2, &changed, ts)
+	if !ok {
+		t.Fatal("expected a record for a changed line")
+	}
+
+	if record.InputPath != "deploy.sh" || record.InputSHA256 != "abc123" {
+		t.Errorf("unexpected source fields: %+v", record)
+	}
+	if record.LineNumber != 2 {
+		t.Errorf("expected line number 2, got %d", record.LineNumber)
+	}
+	if record.Original != "usacloud iso-image list --output-type csv" || record.Transformed != changed.Line {
+		t.Errorf("unexpected original/transformed: %q -> %q", record.Original, record.Transformed)
+	}
+	if len(record.Rules) != 2 || record.Rules[0] != "output-type-csv-tsv" || record.Rules[1] != "iso-image-to-cdrom" {
+		t.Errorf("unexpected rules: %v", record.Rules)
+	}
+	if record.SourceVersion != transform.MigrationSourceVersion || record.TargetVersion != transform.MigrationTargetVersion {
+		t.Errorf("unexpected versions: %s -> %s", record.SourceVersion, record.TargetVersion)
+	}
+	if !record.Timestamp.Equal(ts) || record.Timestamp.Location() != time.UTC {
+		t.Errorf("expected timestamp normalized to UTC, got %v", record.Timestamp)
+	}
+
+	src.SourceVersion, src.TargetVersion = "v0", "v1.0"
+	if record, _ := NewRecord(src, 2, &changed, ts); record.TargetVersion != "v1.0" {
+		t.Errorf("expected the source's target version, got %s", record.TargetVersion)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	records := []Record{
+		{LineNumber: 1, Rules: []string{"a"}},
+		{LineNumber: 3, Rules: []string{"b", "c"}},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, records); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var got []Record
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, r)
+	}
+	if len(got) != 2 || got[1].LineNumber != 3 || len(got[1].Rules) != 2 {
+		t.Errorf("unexpected decoded records: %+v", got)
+	}
+}
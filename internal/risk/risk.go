@@ -0,0 +1,76 @@
+// Package risk ranks the files of a multi-file migration by how much manual
+// follow-up they are likely to need, so teams know which scripts to review first.
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Weights of each finding in the risk score
+const (
+	ManualReviewWeight          = 3
+	UnmappableDeprecationWeight = 3
+	LowConfidenceWeight         = 1
+)
+
+// LowConfidenceScore is the similarity score below which a suggestion is not trusted
+const LowConfidenceScore = 0.8
+
+// FileRisk holds the migration findings of a single file
+type FileRisk struct {
+	Path                     string `json:"path"`
+	Score                    int    `json:"score"`
+	ManualReview             int    `json:"manual_review"`              // lines commented out for manual migration
+	UnmappableDeprecations   int    `json:"unmappable_deprecations"`    // deprecated commands without a replacement
+	LowConfidenceSuggestions int    `json:"low_confidence_suggestions"` // invalid commands without a trusted suggestion
+}
+
+// CalculateScore returns the weighted sum of the findings
+func (f FileRisk) CalculateScore() int {
+	return f.ManualReview*ManualReviewWeight +
+		f.UnmappableDeprecations*UnmappableDeprecationWeight +
+		f.LowConfidenceSuggestions*LowConfidenceWeight
+}
+
+// Rank scores every file and orders them from the riskiest to the safest (ties by path)
+func Rank(files []FileRisk) []FileRisk {
+	ranked := make([]FileRisk, len(files))
+	for i, f := range files {
+		f.Score = f.CalculateScore()
+		ranked[i] = f
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Path < ranked[j].Path
+	})
+	return ranked
+}
+
+// WriteText writes the ranking as a table
+func WriteText(w io.Writer, ranked []FileRisk) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-4s %-6s %-7s %-11s %-14s %s\n", "RANK", "SCORE", "MANUAL", "UNMAPPABLE", "LOW-CONFIDENCE", "FILE")
+	for i, f := range ranked {
+		fmt.Fprintf(&b, "%-4d %-6d %-7d %-11d %-14d %s\n", i+1, f.Score, f.ManualReview, f.UnmappableDeprecations, f.LowConfidenceSuggestions, f.Path)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the ranking as an indented JSON array
+func WriteJSON(w io.Writer, ranked []FileRisk) error {
+	if ranked == nil {
+		ranked = []FileRisk{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(ranked)
+}
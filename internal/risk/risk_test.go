@@ -0,0 +1,62 @@
+package risk
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRank(t *testing.T) {
+	ranked := Rank([]FileRisk{
+		{Path: "safe.sh"},
+		{Path: "typo.sh", LowConfidenceSuggestions: 2},
+		{Path: "summary.sh", ManualReview: 1, UnmappableDeprecations: 1},
+		{Path: "also-safe.sh"},
+	})
+
+	expected := []struct {
+		path  string
+		score int
+	}{
+		{"summary.sh", 6},
+		{"typo.sh", 2},
+		{"also-safe.sh", 0},
+		{"safe.sh", 0},
+	}
+	for i, want := range expected {
+		if ranked[i].Path != want.path || ranked[i].Score != want.score {
+			t.Errorf("rank %d: got %s (score %d), want %s (score %d)", i+1, ranked[i].Path, ranked[i].Score, want.path, want.score)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	ranked := Rank([]FileRisk{{Path: "a.sh", ManualReview: 2}, {Path: "b.sh"}})
+
+	var text bytes.Buffer
+	if err := WriteText(&text, ranked); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "1    6") || !strings.HasSuffix(lines[1], "a.sh") {
+		t.Errorf("unexpected text report:\n%s", text.String())
+	}
+
+	var jsonOut bytes.Buffer
+	if err := WriteJSON(&jsonOut, ranked); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded []FileRisk
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0] != ranked[0] {
+		t.Errorf("expected JSON round trip to match, got %+v", decoded)
+	}
+
+	jsonOut.Reset()
+	if err := WriteJSON(&jsonOut, nil); err != nil || strings.TrimSpace(jsonOut.String()) != "[]" {
+		t.Errorf("expected empty JSON array, got %q (err %v)", jsonOut.String(), err)
+	}
+}
@@ -0,0 +1,51 @@
+package sandbox
+
+import "github.com/armaniacs/usacloud-update/internal/validation"
+
+// DestructiveCommand is a command of a script that would delete or stop resources
+type DestructiveCommand struct {
+	LineNumber int
+	Command    string
+	Operation  string // the matching entry of SandboxConfig.Destructive, such as delete
+}
+
+// FindDestructiveCommands returns the commands of lines that ExecuteScript
+// would execute and whose subcommand is one of the configured Destructive
+// operations, so that a batch run can ask before executing them
+func (e *Executor) FindDestructiveCommands(lines []string) []DestructiveCommand {
+	if len(e.config.Destructive) == 0 {
+		return nil
+	}
+
+	parser := validation.NewParser()
+	var found []DestructiveCommand
+	for i, line := range lines {
+		plan := e.PlanLine(line)
+		if !plan.Execute {
+			continue
+		}
+		if op := e.destructiveOperation(parser, plan.Command); op != "" {
+			found = append(found, DestructiveCommand{LineNumber: i + 1, Command: plan.Command, Operation: op})
+		}
+	}
+	return found
+}
+
+// destructiveOperation returns the configured operation the command runs,
+// looking at the resource and subcommand words ("usacloud server delete")
+// wherever the options are written
+func (e *Executor) destructiveOperation(parser *validation.Parser, command string) string {
+	parsed, err := parser.Parse(command)
+	if err != nil {
+		return ""
+	}
+
+	for _, word := range []string{parsed.MainCommand, parsed.SubCommand} {
+		for _, op := range e.config.Destructive {
+			if word == op {
+				return op
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package sandbox
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/armaniacs/usacloud-update/internal/config"
+)
+
+func TestExecutor_FindDestructiveCommands(t *testing.T) {
+	executor := NewExecutor(&config.SandboxConfig{Destructive: config.DefaultDestructiveCommands})
+
+	lines := []string{
+		"#!/bin/bash",
+		"usacloud server list",
+		"usacloud server delete 123456789012 -y",
+		"# usacloud disk delete 1",
+		"usacloud --zone=tk1v server shutdown web",
+		"usacloud server delete 1 --zone=is1a", // rejected by the sandbox, never executed
+		"echo delete",
+		"usacloud disk power-off-history list",
+		"usacloud --zone tk1v disk delete 456",
+		"usacloud server --zone tk1v shutdown 1",
+		"usacloud --zone tk1v server list",
+	}
+	want := []DestructiveCommand{
+		{LineNumber: 3, Command: "usacloud server delete 123456789012 -y", Operation: "delete"},
+		{LineNumber: 5, Command: "usacloud --zone=tk1v server shutdown web", Operation: "shutdown"},
+		{LineNumber: 9, Command: "usacloud --zone tk1v disk delete 456", Operation: "delete"},
+		{LineNumber: 10, Command: "usacloud server --zone tk1v shutdown 1", Operation: "shutdown"},
+	}
+	if got := executor.FindDestructiveCommands(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindDestructiveCommands() = %+v, want %+v", got, want)
+	}
+
+	// The operations come from the configuration
+	executor = NewExecutor(&config.SandboxConfig{Destructive: []string{"update"}})
+	got := executor.FindDestructiveCommands([]string{"usacloud server delete 1", "usacloud server update 1 --name web"})
+	if len(got) != 1 || got[0].LineNumber != 2 || got[0].Operation != "update" {
+		t.Errorf("FindDestructiveCommands() with custom operations = %+v", got)
+	}
+	if got := NewExecutor(&config.SandboxConfig{}).FindDestructiveCommands(lines); got != nil {
+		t.Errorf("expected no destructive commands without configured operations, got %+v", got)
+	}
+}
@@ -1,15 +1,20 @@
 package sandbox
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/armaniacs/usacloud-update/internal/validation"
 	"github.com/fatih/color"
 )
 
@@ -17,17 +22,35 @@ import (
 type ExecutionResult struct {
 	Command    string        `json:"command"`
 	Success    bool          `json:"success"`
-	Output     string        `json:"output"`
+	Output     string        `json:"output"` // stdout and stderr as they were written
+	Stdout     string        `json:"stdout,omitempty"`
+	Stderr     string        `json:"stderr,omitempty"`
 	Error      string        `json:"error,omitempty"`
 	Duration   time.Duration `json:"duration"`
 	Skipped    bool          `json:"skipped"`
 	SkipReason string        `json:"skip_reason,omitempty"`
+	Attempts   int           `json:"attempts,omitempty"` // executions including retries
+	TimedOut   bool          `json:"timed_out,omitempty"`
+	Throttled  time.Duration `json:"throttled,omitempty"` // waited for the rate limit before executions
 }
 
+// ErrCommandTimeout is wrapped by the error of a command that exceeded the timeout
+var ErrCommandTimeout = errors.New("timeout")
+
+// Retry backoff: the first retry waits defaultRetryBaseDelay, doubling up to maxRetryDelay
+const (
+	defaultRetryBaseDelay = 1 * time.Second
+	maxRetryDelay         = 30 * time.Second
+)
+
 // Executor handles sandbox execution of usacloud commands
 type Executor struct {
-	config        *config.SandboxConfig
-	usacloudRegex *regexp.Regexp
+	config         *config.SandboxConfig
+	usacloudRegex  *regexp.Regexp
+	runCommand     func(ctx context.Context, command string) (commandOutput, error)
+	retryBaseDelay time.Duration
+	limiter        *RateLimiter
+	output         io.Writer // debug messages printed during execution
 }
 
 // NewExecutor creates a new sandbox executor
@@ -35,10 +58,26 @@ func NewExecutor(cfg *config.SandboxConfig) *Executor {
 	// Regex to identify usacloud commands
 	usacloudRegex := regexp.MustCompile(`^\s*usacloud\s+`)
 
-	return &Executor{
-		config:        cfg,
-		usacloudRegex: usacloudRegex,
+	e := &Executor{
+		config:         cfg,
+		usacloudRegex:  usacloudRegex,
+		retryBaseDelay: defaultRetryBaseDelay,
+		limiter:        NewRateLimiter(cfg.RateLimit),
+		output:         os.Stderr,
 	}
+	e.runCommand = e.executeUsacloudCommand
+	return e
+}
+
+// SetOutput sets where the debug messages printed during execution go (os.Stderr by default)
+func (e *Executor) SetOutput(w io.Writer) {
+	e.output = w
+}
+
+// SetRateLimiter replaces the executor's own RateLimit limiter, so that
+// several executors can share one limit (nil removes the limit)
+func (e *Executor) SetRateLimiter(l *RateLimiter) {
+	e.limiter = l
 }
 
 // ExecuteScript executes all usacloud commands in the provided script lines
@@ -53,14 +92,14 @@ func (e *Executor) ExecuteScript(lines []string) ([]*ExecutionResult, error) {
 		lineNum := i + 1
 
 		if e.config.Debug {
-			fmt.Fprintf(os.Stderr, color.CyanString("[DEBUG] Processing line %d: %s\n"), lineNum, line)
+			fmt.Fprintf(e.output, color.CyanString("[DEBUG] Processing line %d: %s\n"), lineNum, line)
 		}
 
 		result := e.executeLine(line, lineNum)
 		results = append(results, result)
 
-		// Add small delay between commands to avoid rate limiting
-		if !result.Skipped && !e.config.DryRun {
+		// Without a configured rate limit, add a small delay between commands to avoid rate limiting
+		if e.limiter == nil && !result.Skipped && !e.config.DryRun {
 			time.Sleep(100 * time.Millisecond)
 		}
 	}
@@ -85,32 +124,90 @@ func (e *Executor) executeLine(line string, lineNum int) *ExecutionResult {
 		Success: false,
 	}
 
-	// Skip empty lines and comments
-	trimmed := strings.TrimSpace(line)
-	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
-		result.Skipped = true
-		result.SkipReason = "Empty line or comment"
-		result.Success = true
+	command := e.planLine(line, result)
+	if command == "" {
 		result.Duration = time.Since(start)
 		return result
 	}
 
-	// Skip non-usacloud commands
-	if !e.usacloudRegex.MatchString(trimmed) {
-		result.Skipped = true
-		result.SkipReason = "Not a usacloud command"
+	// Execute in dry-run mode
+	if e.config.DryRun {
+		result.Output = fmt.Sprintf("[DRY RUN] Would execute: %s", command)
 		result.Success = true
 		result.Duration = time.Since(start)
 		return result
 	}
 
+	// Execute the command, retrying transient failures
+	if e.config.Debug {
+		fmt.Fprintf(e.output, color.BlueString("[EXEC] %s\n"), command)
+	}
+
+	output, attempts, err := e.executeWithRetry(command, result)
+	result.Duration = time.Since(start)
+	result.Attempts = attempts
+	result.Output = output.Combined
+	result.Stdout = output.Stdout
+	result.Stderr = output.Stderr
+
+	if err != nil {
+		result.Error = err.Error()
+		result.TimedOut = errors.Is(err, ErrCommandTimeout)
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// LinePlan is what executing a line would do, decided without running anything
+type LinePlan struct {
+	Command    string // usacloud command that would be executed
+	Execute    bool
+	SkipReason string // why the line would not be executed
+}
+
+// PlanLine applies the same checks as ExecuteScript to a line and reports
+// whether its command would be executed, without executing it
+func (e *Executor) PlanLine(line string) LinePlan {
+	result := &ExecutionResult{Command: line}
+	command := e.planLine(line, result)
+	if command == "" {
+		reason := result.SkipReason
+		if reason == "" {
+			reason = result.Error
+		}
+		return LinePlan{SkipReason: reason}
+	}
+	return LinePlan{Command: command, Execute: true}
+}
+
+// planLine returns the usacloud command to execute for a line, or "" after
+// recording in result why the line is skipped or rejected
+func (e *Executor) planLine(line string, result *ExecutionResult) string {
 	// Skip commented usacloud commands (those marked for manual intervention)
+	trimmed := strings.TrimSpace(line)
 	if strings.Contains(trimmed, "# usacloud-update:") && strings.HasPrefix(trimmed, "# ") {
 		result.Skipped = true
 		result.SkipReason = "Commented usacloud command (manual intervention required)"
 		result.Success = true
-		result.Duration = time.Since(start)
-		return result
+		return ""
+	}
+
+	// Skip empty lines and comments
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		result.Skipped = true
+		result.SkipReason = "Empty line or comment"
+		result.Success = true
+		return ""
+	}
+
+	// Skip non-usacloud commands
+	if !e.usacloudRegex.MatchString(trimmed) {
+		result.Skipped = true
+		result.SkipReason = "Not a usacloud command"
+		result.Success = true
+		return ""
 	}
 
 	// Extract the usacloud command
@@ -118,45 +215,183 @@ func (e *Executor) executeLine(line string, lineNum int) *ExecutionResult {
 	if command == "" {
 		result.Skipped = true
 		result.SkipReason = "Failed to extract usacloud command"
-		result.Duration = time.Since(start)
-		return result
+		return ""
 	}
 
 	// Validate command for sandbox safety
 	if err := e.validateCommand(command); err != nil {
 		result.Error = fmt.Sprintf("Command validation failed: %v", err)
-		result.Duration = time.Since(start)
-		return result
+		return ""
 	}
 
-	// Execute in dry-run mode
-	if e.config.DryRun {
-		result.Output = fmt.Sprintf("[DRY RUN] Would execute: %s", command)
-		result.Success = true
-		result.Duration = time.Since(start)
-		return result
+	return command
+}
+
+// executeWithRetry runs the command under the configured timeout through a
+// RetryableExecutor, retrying transient failures up to RetryCount times with
+// exponential backoff. Each execution first waits for the rate limit, adding
+// the wait to result.Throttled.
+func (e *Executor) executeWithRetry(command string, result *ExecutionResult) (commandOutput, int, error) {
+	attempts := &commandAttempts{
+		executor: e,
+		result:   result,
+		readOnly: isReadOnlyCommand(command),
+	}
+	retrier := NewRetryableExecutor(attempts, attempts)
+	retryConfig := e.retryConfig()
+	retryConfig.OnRetry = func(attempt int, err error) {
+		if e.config.Debug {
+			delay := retrier.calculateBackoffDelay(attempt, retryConfig)
+			fmt.Fprintf(e.output, color.YellowString("[RETRY] %s (attempt %d/%d in %s): %v\n"), command, attempt+1, retryConfig.MaxAttempts, delay, err)
+		}
+	}
+
+	// The error of the last attempt is reported as is, not as wrapped by RetryableExecutor
+	_, _ = retrier.ExecuteWithRetry(context.Background(), command, retryConfig)
+	err := attempts.err
+	if err != nil && attempts.count > 1 {
+		err = fmt.Errorf("%w (after %d attempts)", err, attempts.count)
 	}
+	return attempts.output, attempts.count, err
+}
 
-	// Execute the command
-	ctx, cancel := context.WithTimeout(context.Background(), e.config.Timeout)
+// retryConfig returns the backoff used between the executions of a command
+func (e *Executor) retryConfig() *RetryConfig {
+	maxAttempts := e.config.RetryCount + 1
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &RetryConfig{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   e.retryBaseDelay,
+		MaxDelay:    maxRetryDelay,
+		BackoffType: BackoffExponential,
+	}
+}
+
+// executeOnce runs the command once, reporting an exceeded timeout as ErrCommandTimeout
+func (e *Executor) executeOnce(command string) (commandOutput, error) {
+	timeout := e.config.Timeout
+	if timeout <= 0 {
+		timeout = config.DefaultConfig().Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	if e.config.Debug {
-		fmt.Fprintf(os.Stderr, color.BlueString("[EXEC] %s\n"), command)
+	output, err := e.runCommand(ctx, command)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("%w: command timed out after %v", ErrCommandTimeout, timeout)
 	}
+	return output, err
+}
 
-	output, err := e.executeUsacloudCommand(ctx, command)
-	result.Duration = time.Since(start)
+// commandAttempts runs the executions of one command for a RetryableExecutor
+// and decides which of its failures are retried, keeping the output and error
+// of the last execution
+type commandAttempts struct {
+	executor *Executor
+	result   *ExecutionResult
+	readOnly bool // the command only reads resources, so a timeout can be retried
+
+	count  int
+	output commandOutput
+	err    error
+}
 
+// ExecuteCommand runs the command once after waiting for the rate limit
+func (a *commandAttempts) ExecuteCommand(command string) (*ExecutionResult, error) {
+	e := a.executor
+	if waited := e.limiter.Wait(); waited > 0 {
+		a.result.Throttled += waited
+		if e.config.Debug {
+			fmt.Fprintf(e.output, color.YellowString("[THROTTLE] %s (waited %s for the rate limit)\n"), command, waited)
+		}
+	}
+	a.count++
+	a.output, a.err = e.executeOnce(command)
+	return a.result, a.err
+}
+
+// Handle classifies the failure of the last execution, marking it retryable
+// only when it is transient
+func (a *commandAttempts) Handle(err error, cmd string) *SandboxError {
+	sandboxErr := (&ErrorHandler{}).classifyError(err, cmd)
+	sandboxErr.Retryable = isTransientFailure(err, a.output.Combined, a.readOnly)
+	return sandboxErr
+}
+
+// GetRetryRecommendation returns the ErrorHandler recommendation for the failure
+func (a *commandAttempts) GetRetryRecommendation(sandboxErr *SandboxError) *RetryConfig {
+	return (&ErrorHandler{}).GetRetryRecommendation(sandboxErr)
+}
+
+// readOnlySubcommands only read resources, so running them again after a
+// timeout cannot apply a change twice
+var readOnlySubcommands = map[string]bool{
+	"list": true,
+	"read": true,
+}
+
+// isReadOnlyCommand reports whether the usacloud command runs a read-only subcommand
+func isReadOnlyCommand(command string) bool {
+	parsed, err := validation.NewParser().Parse(command)
 	if err != nil {
-		result.Error = err.Error()
-		result.Output = output
-		return result
+		return false
 	}
+	return readOnlySubcommands[parsed.SubCommand]
+}
 
-	result.Success = true
-	result.Output = output
-	return result
+// transientOutputs are API responses that usually succeed when retried
+var transientOutputs = []string{
+	"too many requests",
+	"service unavailable",
+	"bad gateway",
+}
+
+// isTransientFailure reports whether a failed command is worth retrying:
+// network errors and temporary API errors. A timeout is retried only for a
+// read-only command, since a command that changes resources may have taken
+// effect before it timed out. Other failures, such as invalid arguments or
+// authentication errors, fail the same way again.
+func isTransientFailure(err error, output string, readOnly bool) bool {
+	if errors.Is(err, ErrCommandTimeout) {
+		return readOnly
+	}
+
+	combined := err.Error() + "\n" + output
+	switch (&ErrorHandler{}).classifyError(errors.New(combined), "").Type {
+	case ErrorTypeTimeout:
+		return readOnly
+	case ErrorTypeNetwork:
+		return true
+	}
+
+	lower := strings.ToLower(output)
+	for _, s := range transientOutputs {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// commandOutput is what one usacloud run wrote
+type commandOutput struct {
+	Combined string // stdout and stderr interleaved as they were written
+	Stdout   string
+	Stderr   string
+}
+
+// lockedWriter lets stdout and stderr, copied by separate goroutines, share one buffer
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
 }
 
 // extractUsacloudCommand extracts the usacloud command from a line
@@ -213,10 +448,10 @@ func (e *Executor) validateCommand(command string) error {
 }
 
 // executeUsacloudCommand executes a usacloud command with proper environment
-func (e *Executor) executeUsacloudCommand(ctx context.Context, command string) (string, error) {
+func (e *Executor) executeUsacloudCommand(ctx context.Context, command string) (commandOutput, error) {
 	args := strings.Fields(command)
 	if len(args) == 0 {
-		return "", fmt.Errorf("empty command")
+		return commandOutput{}, fmt.Errorf("empty command")
 	}
 
 	// Ensure zone is set to sandbox zone
@@ -226,34 +461,39 @@ func (e *Executor) executeUsacloudCommand(ctx context.Context, command string) (
 	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
 	cmd.Env = e.config.GetUsacloudEnv()
 
-	// Capture both stdout and stderr
-	output, err := cmd.CombinedOutput()
-	outputStr := string(output)
-
-	// Check for context timeout
-	if ctx.Err() == context.DeadlineExceeded {
-		return outputStr, fmt.Errorf("command timed out after %v", e.config.Timeout)
+	// Capture stdout and stderr, separately and interleaved
+	var stdout, stderr, combined bytes.Buffer
+	shared := &lockedWriter{w: &combined}
+	cmd.Stdout = io.MultiWriter(&stdout, shared)
+	cmd.Stderr = io.MultiWriter(&stderr, shared)
+	err := cmd.Run()
+	output := commandOutput{Combined: combined.String(), Stdout: stdout.String(), Stderr: stderr.String()}
+	outputStr := output.Combined
+
+	// A timeout is reported by executeOnce
+	if ctx.Err() != nil {
+		return output, ctx.Err()
 	}
 
 	if err != nil {
 		// Try to provide helpful error messages for common issues
 		if strings.Contains(outputStr, "authentication") || strings.Contains(outputStr, "unauthorized") {
-			return outputStr, fmt.Errorf("authentication failed - check SAKURACLOUD_ACCESS_TOKEN and SAKURACLOUD_ACCESS_TOKEN_SECRET")
+			return output, fmt.Errorf("authentication failed - check SAKURACLOUD_ACCESS_TOKEN and SAKURACLOUD_ACCESS_TOKEN_SECRET")
 		}
 
 		if strings.Contains(outputStr, "not found") && strings.Contains(command, "usacloud") {
-			return outputStr, fmt.Errorf("usacloud command not found - please install usacloud CLI")
+			return output, fmt.Errorf("usacloud command not found - please install usacloud CLI")
 		}
 
-		return outputStr, fmt.Errorf("command failed: %w", err)
+		return output, fmt.Errorf("command failed: %w", err)
 	}
 
 	// Add sandbox warning to output
-	if outputStr != "" {
-		outputStr += "\n" + color.YellowString("⚠️  Executed in Sakura Cloud Sandbox (tk1v) - resources may not function normally")
+	if output.Combined != "" {
+		output.Combined += "\n" + color.YellowString("⚠️  Executed in Sakura Cloud Sandbox (tk1v) - resources may not function normally")
 	}
 
-	return outputStr, nil
+	return output, nil
 }
 
 // ensureSandboxZone ensures that the command uses the sandbox zone
@@ -290,6 +530,10 @@ func (e *Executor) PrintSummary(results []*ExecutionResult) {
 	successful := 0
 	skipped := 0
 	failed := 0
+	retried := 0
+	retries := 0
+	timedOut := 0
+	var throttled time.Duration
 
 	for _, result := range results {
 		if result.Skipped {
@@ -302,6 +546,14 @@ func (e *Executor) PrintSummary(results []*ExecutionResult) {
 				failed++
 			}
 		}
+		if result.Attempts > 1 {
+			retried++
+			retries += result.Attempts - 1
+		}
+		if result.TimedOut {
+			timedOut++
+		}
+		throttled += result.Throttled
 	}
 
 	fmt.Fprintf(os.Stderr, "\n%s\n", color.HiWhiteString("🏖️  Sandbox Execution Summary"))
@@ -310,12 +562,24 @@ func (e *Executor) PrintSummary(results []*ExecutionResult) {
 	fmt.Fprintf(os.Stderr, "Successful:      %s\n", color.GreenString("%d", successful))
 	fmt.Fprintf(os.Stderr, "Failed:          %s\n", color.RedString("%d", failed))
 	fmt.Fprintf(os.Stderr, "Skipped:         %s\n", color.YellowString("%d", skipped))
+	if retried > 0 {
+		fmt.Fprintf(os.Stderr, "Retried:         %s\n", color.YellowString("%d (%d retries)", retried, retries))
+	}
+	if timedOut > 0 {
+		fmt.Fprintf(os.Stderr, "Timed out:       %s\n", color.RedString("%d", timedOut))
+	}
+	if throttled > 0 {
+		fmt.Fprintf(os.Stderr, "Rate limit wait: %s\n", color.YellowString("%s", throttled.Round(time.Millisecond)))
+	}
 
 	if failed > 0 {
 		fmt.Fprintf(os.Stderr, "\n%s\n", color.HiRedString("❌ Failed Commands:"))
 		for i, result := range results {
 			if !result.Success && !result.Skipped {
 				fmt.Fprintf(os.Stderr, "  Line %d: %s\n", i+1, result.Command)
+				if result.Attempts > 1 {
+					fmt.Fprintf(os.Stderr, "  Attempts: %d\n", result.Attempts)
+				}
 				fmt.Fprintf(os.Stderr, "  Error: %s\n\n", color.RedString(result.Error))
 			}
 		}
@@ -327,6 +591,8 @@ func (e *Executor) PrintSummary(results []*ExecutionResult) {
 		fmt.Fprintf(os.Stderr, "API Endpoint:   %s\n", e.config.APIEndpoint)
 		fmt.Fprintf(os.Stderr, "Dry Run:        %t\n", e.config.DryRun)
 		fmt.Fprintf(os.Stderr, "Timeout:        %s\n", e.config.Timeout)
+		fmt.Fprintf(os.Stderr, "Retry Count:    %d\n", e.config.RetryCount)
+		fmt.Fprintf(os.Stderr, "Rate Limit:     %g/s\n", e.config.RateLimit)
 	}
 }
 
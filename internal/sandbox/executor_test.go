@@ -1,8 +1,13 @@
 package sandbox
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -296,6 +301,14 @@ func TestExecutor_PrintSummary(t *testing.T) {
 			SkipReason: "Dry run mode",
 			Duration:   1 * time.Millisecond,
 		},
+		{
+			Command:  "usacloud disk list",
+			Success:  false,
+			Error:    "timeout: command timed out after 30s (after 3 attempts)",
+			Attempts: 3,
+			TimedOut: true,
+			Duration: 90 * time.Second,
+		},
 	}
 
 	// This is mainly a smoke test to ensure PrintSummary doesn't panic
@@ -303,6 +316,194 @@ func TestExecutor_PrintSummary(t *testing.T) {
 	executor.PrintSummary(results)
 }
 
+// newFakeExecutor returns an executor whose commands are run by run instead of usacloud
+func newFakeExecutor(cfg *config.SandboxConfig, run func(ctx context.Context, attempt int) (string, error)) (*Executor, *int) {
+	executor := NewExecutor(cfg)
+	executor.retryBaseDelay = time.Millisecond
+	calls := 0
+	executor.runCommand = func(ctx context.Context, command string) (commandOutput, error) {
+		calls++
+		output, err := run(ctx, calls)
+		return commandOutput{Combined: output, Stdout: output}, err
+	}
+	return executor, &calls
+}
+
+func TestExecutor_RetryTransientFailure(t *testing.T) {
+	executor, calls := newFakeExecutor(&config.SandboxConfig{Timeout: time.Second, RetryCount: 2}, func(ctx context.Context, attempt int) (string, error) {
+		if attempt == 1 {
+			return "503 Service Unavailable", errors.New("exit status 1")
+		}
+		return "ok", nil
+	})
+
+	result := executor.executeLine("usacloud server list", 1)
+	if !result.Success || result.Output != "ok" {
+		t.Fatalf("expected success after retry, got %+v", result)
+	}
+	if *calls != 2 || result.Attempts != 2 {
+		t.Errorf("expected 2 attempts, got calls=%d attempts=%d", *calls, result.Attempts)
+	}
+}
+
+func TestExecutor_SetOutput(t *testing.T) {
+	executor, _ := newFakeExecutor(&config.SandboxConfig{Timeout: time.Second, RetryCount: 1, Debug: true}, func(ctx context.Context, attempt int) (string, error) {
+		if attempt == 1 {
+			return "503 Service Unavailable", errors.New("exit status 1")
+		}
+		return "ok", nil
+	})
+	var buf bytes.Buffer
+	executor.SetOutput(&buf)
+
+	executor.executeLine("usacloud server list", 1)
+	for _, want := range []string{"[EXEC] usacloud server list", "[RETRY] usacloud server list"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in the debug output, got %q", want, buf.String())
+		}
+	}
+}
+
+func TestExecutor_TimeoutIsRecordedAsFailure(t *testing.T) {
+	executor, calls := newFakeExecutor(&config.SandboxConfig{Timeout: 20 * time.Millisecond, RetryCount: 1}, func(ctx context.Context, attempt int) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	result := executor.executeLine("usacloud server list", 1)
+	if result.Success || !result.TimedOut {
+		t.Fatalf("expected a timed out failure, got %+v", result)
+	}
+	if !strings.Contains(result.Error, "timeout") || !strings.Contains(result.Error, "after 2 attempts") {
+		t.Errorf("unexpected error: %s", result.Error)
+	}
+	if *calls != 2 || result.Attempts != 2 {
+		t.Errorf("a timeout should be retried RetryCount times, got calls=%d attempts=%d", *calls, result.Attempts)
+	}
+}
+
+func TestExecutor_TimeoutOfChangingCommandIsNotRetried(t *testing.T) {
+	for _, command := range []string{"usacloud server create --name web", "usacloud disk update 123456789012 --name data"} {
+		executor, calls := newFakeExecutor(&config.SandboxConfig{Timeout: 20 * time.Millisecond, RetryCount: 2}, func(ctx context.Context, attempt int) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+
+		result := executor.executeLine(command, 1)
+		if result.Success || !result.TimedOut {
+			t.Fatalf("%s: expected a timed out failure, got %+v", command, result)
+		}
+		if *calls != 1 || result.Attempts != 1 {
+			t.Errorf("%s: a command that may have taken effect should not be retried, got calls=%d attempts=%d", command, *calls, result.Attempts)
+		}
+	}
+}
+
+func TestExecutor_GatewayTimeoutRetriedOnlyForReadOnlyCommands(t *testing.T) {
+	tests := []struct {
+		command string
+		calls   int
+	}{
+		{"usacloud server list", 2},
+		{"usacloud server read 123456789012", 2},
+		{"usacloud server create --name web", 1},
+	}
+
+	for _, tt := range tests {
+		executor, calls := newFakeExecutor(&config.SandboxConfig{Timeout: time.Second, RetryCount: 1}, func(ctx context.Context, attempt int) (string, error) {
+			return "504 Gateway Timeout", errors.New("exit status 1")
+		})
+
+		executor.executeLine(tt.command, 1)
+		if *calls != tt.calls {
+			t.Errorf("%s: expected %d executions, got %d", tt.command, tt.calls, *calls)
+		}
+	}
+}
+
+func TestExecutor_PermanentFailureIsNotRetried(t *testing.T) {
+	executor, calls := newFakeExecutor(&config.SandboxConfig{Timeout: time.Second, RetryCount: 3}, func(ctx context.Context, attempt int) (string, error) {
+		return "Error: unknown flag: --foo", errors.New("exit status 1")
+	})
+
+	result := executor.executeLine("usacloud server list --foo", 1)
+	if result.Success || result.TimedOut {
+		t.Fatalf("expected a plain failure, got %+v", result)
+	}
+	if *calls != 1 || result.Attempts != 1 {
+		t.Errorf("expected a single attempt, got calls=%d attempts=%d", *calls, result.Attempts)
+	}
+}
+
+func TestExecutor_RetryDelay(t *testing.T) {
+	executor := NewExecutor(&config.SandboxConfig{RetryCount: 2})
+	retryConfig := executor.retryConfig()
+	if retryConfig.MaxAttempts != 3 {
+		t.Errorf("MaxAttempts = %d, want 3", retryConfig.MaxAttempts)
+	}
+	retrier := NewRetryableExecutor(executor, NewErrorHandler(nil, 0, 0))
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	for i, w := range want {
+		if got := retrier.calculateBackoffDelay(i+1, retryConfig); got != w {
+			t.Errorf("delay after attempt %d = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := retrier.calculateBackoffDelay(10, retryConfig); got != maxRetryDelay {
+		t.Errorf("delay after attempt 10 = %v, want %v", got, maxRetryDelay)
+	}
+}
+
+func TestExecutor_SeparatesStdoutAndStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake usacloud is a shell script")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho listed\necho 'warning: deprecated' >&2\n"
+	if err := os.WriteFile(filepath.Join(dir, "usacloud"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	executor := NewExecutor(&config.SandboxConfig{Timeout: 5 * time.Second})
+	result := executor.executeLine("usacloud server list", 1)
+	if !result.Success {
+		t.Fatalf("expected success, got %+v", result)
+	}
+	if result.Stdout != "listed\n" || result.Stderr != "warning: deprecated\n" {
+		t.Errorf("unexpected stdout %q / stderr %q", result.Stdout, result.Stderr)
+	}
+	if !strings.Contains(result.Output, "listed") || !strings.Contains(result.Output, "warning: deprecated") {
+		t.Errorf("combined output should contain both streams: %q", result.Output)
+	}
+}
+
+func TestExecutor_PlanLine(t *testing.T) {
+	executor := NewExecutor(&config.SandboxConfig{})
+
+	tests := []struct {
+		line    string
+		execute bool
+		reason  string
+	}{
+		{"usacloud server list", true, ""},
+		{"usacloud server list && echo ok", true, ""},
+		{"# usacloud summary # usacloud-update: removed", false, "Commented usacloud command (manual intervention required)"},
+		{"# just a comment", false, "Empty line or comment"},
+		{"echo hello", false, "Not a usacloud command"},
+		{"usacloud server list --zone=is1a", false, "Command validation failed: sandbox commands must use --zone=tk1v"},
+	}
+
+	for _, tt := range tests {
+		plan := executor.PlanLine(tt.line)
+		if plan.Execute != tt.execute || plan.SkipReason != tt.reason {
+			t.Errorf("PlanLine(%q) = %+v, want execute=%v reason=%q", tt.line, plan, tt.execute, tt.reason)
+		}
+		if plan.Execute && plan.Command != "usacloud server list" {
+			t.Errorf("PlanLine(%q) command = %q", tt.line, plan.Command)
+		}
+	}
+}
+
 func TestIsUsacloudCommand(t *testing.T) {
 	cfg := &config.SandboxConfig{}
 	executor := NewExecutor(cfg)
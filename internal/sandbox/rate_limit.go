@@ -0,0 +1,48 @@
+package sandbox
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter spaces the commands sent to the Sakura Cloud API so that at
+// most a given number start per second. A command over the limit waits for
+// its turn instead of failing. It is safe for concurrent use, so executors
+// running files in parallel can share one limit (see Executor.SetRateLimiter).
+type RateLimiter struct {
+	limiter *rate.Limiter
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewRateLimiter returns a limiter allowing perSecond commands per second,
+// or nil, which never waits, when perSecond is not positive
+func NewRateLimiter(perSecond float64) *RateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		// A burst of 1 keeps the commands one interval apart
+		limiter: rate.NewLimiter(rate.Limit(perSecond), 1),
+		now:     time.Now,
+		sleep:   time.Sleep,
+	}
+}
+
+// Wait blocks until the next command may start and returns how long it waited
+func (l *RateLimiter) Wait() time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	// Reserve().Delay() on the limiter's clock: the reservation queues
+	// concurrent callers one interval apart without holding a lock while sleeping
+	now := l.now()
+	wait := l.limiter.ReserveN(now, 1).DelayFrom(now)
+	if wait > 0 {
+		l.sleep(wait)
+	}
+	return wait
+}
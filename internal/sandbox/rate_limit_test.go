@@ -0,0 +1,115 @@
+package sandbox
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/armaniacs/usacloud-update/internal/config"
+)
+
+// newFakeRateLimiter returns a limiter on a fake clock that advances only when it sleeps
+func newFakeRateLimiter(perSecond float64) (*RateLimiter, *[]time.Duration) {
+	l := NewRateLimiter(perSecond)
+	clock := time.Unix(0, 0)
+	var mu sync.Mutex
+	var sleeps []time.Duration
+	l.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+	l.sleep = func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		clock = clock.Add(d)
+		sleeps = append(sleeps, d)
+	}
+	return l, &sleeps
+}
+
+func TestNewRateLimiter_Unlimited(t *testing.T) {
+	for _, perSecond := range []float64{0, -1} {
+		if l := NewRateLimiter(perSecond); l != nil {
+			t.Errorf("NewRateLimiter(%g) = %+v, want nil", perSecond, l)
+		}
+	}
+
+	var l *RateLimiter
+	if waited := l.Wait(); waited != 0 {
+		t.Errorf("nil limiter waited %s", waited)
+	}
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	l, sleeps := newFakeRateLimiter(4)
+
+	var total time.Duration
+	for i := 0; i < 3; i++ {
+		total += l.Wait()
+	}
+	// The first command starts at once, the others 250ms apart
+	if total != 500*time.Millisecond || len(*sleeps) != 2 {
+		t.Errorf("waited %s in %d sleeps, want 500ms in 2", total, len(*sleeps))
+	}
+
+	// A command after a pause longer than the interval does not wait
+	l.sleep(time.Second)
+	if waited := l.Wait(); waited != 0 {
+		t.Errorf("waited %s after a pause, want 0", waited)
+	}
+}
+
+func TestExecutor_RateLimit(t *testing.T) {
+	executor, calls := newFakeExecutor(&config.SandboxConfig{Timeout: time.Second, RateLimit: 10}, func(ctx context.Context, attempt int) (string, error) {
+		return "ok", nil
+	})
+	limiter, _ := newFakeRateLimiter(10)
+	executor.SetRateLimiter(limiter)
+
+	var results []*ExecutionResult
+	for _, line := range []string{"usacloud server list", "# comment", "usacloud disk list", "usacloud switch list"} {
+		results = append(results, executor.executeLine(line, 1))
+	}
+	if *calls != 3 {
+		t.Fatalf("expected 3 executions, got %d", *calls)
+	}
+
+	var throttled time.Duration
+	for _, r := range results {
+		if !r.Success {
+			t.Errorf("expected throttled command to succeed, got %+v", r)
+		}
+		throttled += r.Throttled
+	}
+	if throttled != 200*time.Millisecond || results[0].Throttled != 0 {
+		t.Errorf("expected 200ms of waiting after the first command, got %s (first %s)", throttled, results[0].Throttled)
+	}
+}
+
+func TestExecutor_PrintSummary_RateLimitWait(t *testing.T) {
+	executor := NewExecutor(&config.SandboxConfig{})
+	results := []*ExecutionResult{
+		{Command: "usacloud server list", Success: true},
+		{Command: "usacloud disk list", Success: true, Throttled: 1500 * time.Millisecond},
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	executor.PrintSummary(results)
+	os.Stderr = stderr
+	w.Close()
+	output, _ := io.ReadAll(r)
+
+	if !strings.Contains(string(output), "Rate limit wait: 1.5s") {
+		t.Errorf("expected the total rate limit wait in the summary, got:\n%s", output)
+	}
+}
@@ -0,0 +1,70 @@
+package testing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// GoldenSuffix は入力ファイルに対応する期待出力ファイルの拡張子
+const GoldenSuffix = ".golden"
+
+// GoldenPair は入力ファイルと期待出力ファイルの組
+type GoldenPair struct {
+	Name       string // 拡張子を除いた入力ファイル名（サブテスト名に使用）
+	InputPath  string
+	GoldenPath string
+}
+
+// LoadGoldenPairs は dir 内の *.sh と同名の .golden ファイルの組を名前順に返す。
+// 期待出力がまだない入力も返すため、-update で新しいケースの期待出力を生成できる
+func LoadGoldenPairs(dir string) ([]GoldenPair, error) {
+	inputs, err := filepath.Glob(filepath.Join(dir, "*.sh"))
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("入力ファイルが見つかりません: %s", dir)
+	}
+	sort.Strings(inputs)
+
+	pairs := make([]GoldenPair, 0, len(inputs))
+	for _, in := range inputs {
+		base := strings.TrimSuffix(in, filepath.Ext(in))
+		pairs = append(pairs, GoldenPair{
+			Name:       filepath.Base(base),
+			InputPath:  in,
+			GoldenPath: base + GoldenSuffix,
+		})
+	}
+	return pairs, nil
+}
+
+// CheckGolden は got を期待出力と比較する。update が true の場合は期待出力を got で上書きする
+func CheckGolden(t *testing.T, pair GoldenPair, got string, update bool) {
+	t.Helper()
+
+	if update {
+		if err := os.WriteFile(pair.GoldenPath, []byte(got), 0644); err != nil {
+			t.Fatalf("ゴールデンファイル書き込みエラー %s: %v", pair.GoldenPath, err)
+		}
+		t.Logf("ゴールデンファイル更新: %s", pair.GoldenPath)
+		return
+	}
+
+	want, err := os.ReadFile(pair.GoldenPath)
+	if os.IsNotExist(err) {
+		t.Fatalf("ゴールデンファイルがありません: %s（-update で生成してください）", pair.GoldenPath)
+	}
+	if err != nil {
+		t.Fatalf("ゴールデンファイル読み込みエラー %s: %v", pair.GoldenPath, err)
+	}
+
+	if got != string(want) {
+		t.Errorf("ゴールデンファイルと一致しません: %s\n--- want ---\n%s\n--- got ---\n%s\n"+
+			"意図した変更の場合は -update フラグで更新してください", pair.GoldenPath, want, got)
+	}
+}
@@ -0,0 +1,38 @@
+package testing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadGoldenPairs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.sh", "b.golden", "a.sh", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("echo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pairs, err := LoadGoldenPairs(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pairs) != 2 || pairs[0].Name != "a" || pairs[1].Name != "b" {
+		t.Fatalf("unexpected pairs: %+v", pairs)
+	}
+	if pairs[0].GoldenPath != filepath.Join(dir, "a.golden") {
+		t.Errorf("unexpected golden path: %s", pairs[0].GoldenPath)
+	}
+
+	// 期待出力がない入力は -update で生成される
+	CheckGolden(t, pairs[0], "generated\n", true)
+	if got, err := os.ReadFile(pairs[0].GoldenPath); err != nil || string(got) != "generated\n" {
+		t.Errorf("expected golden file to be written, got %q (%v)", got, err)
+	}
+	CheckGolden(t, pairs[0], "generated\n", false)
+
+	if _, err := LoadGoldenPairs(t.TempDir()); err == nil {
+		t.Error("expected error for a directory without inputs")
+	}
+}
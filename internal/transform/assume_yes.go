@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/validation"
+)
+
+// AssumeYesRuleName is the name of the rule WithAssumeYes adds
+const AssumeYesRuleName = "add-assumeyes"
+
+// assumeYesRule adds -y to the commands usacloud v1 asks to confirm (see
+// validation.ConfirmationSubcommands) when they lack it, so that scripts run
+// from cron or CI do not stop at the prompt. It is not one of the
+// DefaultRules: answering yes on the user's behalf also skips the prompt when
+// the script is run by hand.
+type assumeYesRule struct {
+	parser *validation.Parser
+}
+
+func (r *assumeYesRule) Name() string { return AssumeYesRuleName }
+
+// Description returns the reason written into the inline comment
+func (r *assumeYesRule) Description() string {
+	return "v1の削除・停止などの確認付きコマンドは -y がないと端末のない環境で応答待ちになるため -y を付与"
+}
+
+// DocURL returns the documentation URL of the rule
+func (r *assumeYesRule) DocURL() string { return "https://docs.usacloud.jp/usacloud/" }
+
+// Apply inserts -y right after the subcommand, which keeps it inside the
+// usacloud command whatever pipes, redirections or comments follow
+func (r *assumeYesRule) Apply(line string) (string, bool, string, string) {
+	if !strings.HasPrefix(strings.TrimSpace(line), "usacloud ") {
+		return line, false, "", ""
+	}
+	parsed, err := r.parser.Parse(line)
+	if err != nil || !validation.MissingAssumeYes(parsed) || parsed.SubCommandSpan.Empty() {
+		return line, false, "", ""
+	}
+
+	end := parsed.SubCommandSpan.End
+	after := line[:end] + " -y" + line[end:]
+	if !strings.Contains(after, commentMarker) {
+		after += " " + commentMarker + " " + describe(r)
+	}
+	beforeFrag := strings.TrimSpace(line[:end])
+	return after, true, beforeFrag, beforeFrag + " -y"
+}
+
+// WithAssumeYes returns a copy of the engine that, after its other rules,
+// adds -y to the commands that would stop at a confirmation prompt
+func (e *Engine) WithAssumeYes() *Engine {
+	c := *e
+	c.rules = append(append([]Rule(nil), e.rules...), &assumeYesRule{parser: validation.NewParser()})
+	return &c
+}
@@ -0,0 +1,63 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEngine_WithAssumeYes(t *testing.T) {
+	tests := []struct {
+		line string
+		want string // "" when the line is left alone
+	}{
+		{"usacloud server delete 123456789012", "usacloud server delete -y 123456789012"},
+		{`usacloud server shutdown "$SERVER_ID" --zone is1a`, `usacloud server shutdown -y "$SERVER_ID" --zone is1a`},
+		{"usacloud server reset 123456789012 2>&1", "usacloud server reset -y 123456789012 2>&1"},
+		{"  sudo usacloud disk delete 123456789012", "  sudo usacloud disk delete -y 123456789012"},
+		{"usacloud server delete -y 123456789012", ""},
+		{"usacloud server shutdown 123456789012 --assumeyes", ""},
+		{"usacloud server list", ""},
+		{"# usacloud server delete 123456789012", ""},
+	}
+
+	eng := NewDefaultEngine().WithAssumeYes()
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			res := eng.Apply(tt.line)
+			if tt.want == "" {
+				if res.Changed {
+					t.Errorf("expected no change, got %q", res.Line)
+				}
+				return
+			}
+			if !res.Changed || !strings.HasPrefix(res.Line, tt.want+" "+commentMarker) {
+				t.Errorf("expected %q with the inline comment, got %q", tt.want, res.Line)
+			}
+			if len(res.Changes) != 1 || res.Changes[0].RuleName != AssumeYesRuleName {
+				t.Errorf("expected one %s change, got %+v", AssumeYesRuleName, res.Changes)
+			}
+			if again := eng.Apply(res.Line); again.Changed {
+				t.Errorf("expected the output to be left alone, got %q", again.Line)
+			}
+		})
+	}
+}
+
+func TestEngine_WithAssumeYes_OptIn(t *testing.T) {
+	eng := NewDefaultEngine()
+	withYes := eng.WithAssumeYes()
+
+	if res := eng.Apply("usacloud server delete 123456789012"); res.Changed {
+		t.Errorf("expected the default engine not to add -y, got %q", res.Line)
+	}
+	if names := eng.RuleNames(); len(names) != len(DefaultRules()) {
+		t.Errorf("expected WithAssumeYes to leave the engine it copies alone, got %v", names)
+	}
+	names := withYes.RuleNames()
+	if names[len(names)-1] != AssumeYesRuleName {
+		t.Errorf("expected %s to run after the other rules, got %v", AssumeYesRuleName, names)
+	}
+	if _, ok := withYes.Explain(AssumeYesRuleName); !ok {
+		t.Errorf("expected %s to be explained", AssumeYesRuleName)
+	}
+}
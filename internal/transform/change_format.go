@@ -0,0 +1,59 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ChangeLine is a change as a ChangeFormat template sees it
+type ChangeLine struct {
+	Line   int    // 1-based line number of the change
+	Before string // fragment the rule replaced
+	After  string // fragment it was replaced with
+	Rule   string // name of the rule
+}
+
+// ChangeFormat formats the lines of the change stream written by Stream and
+// the CLI's --stats. A nil *ChangeFormat writes the default
+// "#L<n> before => after [rule]" format.
+type ChangeFormat struct {
+	tmpl *template.Template
+}
+
+// ParseChangeFormat parses a text/template over the fields of ChangeLine,
+// such as "{{.Line}}\t{{.Rule}}". The template is tried on a sample change
+// so that unknown fields are reported here rather than when the first line
+// changes. An empty format returns nil, the default format.
+func ParseChangeFormat(format string) (*ChangeFormat, error) {
+	if format == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("change").Option("missingkey=error").Parse(format)
+	if err != nil {
+		return nil, err
+	}
+	f := &ChangeFormat{tmpl: tmpl}
+	if _, err := f.Format(ChangeLine{Line: 1, Before: "iso-image", After: "cdrom", Rule: "iso-image-to-cdrom"}); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Format returns the change stream line for c, without the trailing newline
+func (f *ChangeFormat) Format(c ChangeLine) (string, error) {
+	if f == nil {
+		return fmt.Sprintf("#L%-5d %s => %s [%s]", c.Line, c.Before, c.After, c.Rule), nil
+	}
+	var b strings.Builder
+	if err := f.tmpl.Execute(&b, c); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// SetChangeFormat sets the format Stream writes changes in. A nil format
+// restores the default.
+func (e *Engine) SetChangeFormat(f *ChangeFormat) {
+	e.changeFormat = f
+}
@@ -0,0 +1,54 @@
+package transform
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseChangeFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{"", "#L12    --zone = all => --zone=all [zone-all-normalize]", false},
+		{"{{.Line}}\t{{.Rule}}", "12\tzone-all-normalize", false},
+		{`{{.Before}} -> {{.After}}`, "--zone = all -> --zone=all", false},
+		{"{{.Line", "", true},     // malformed
+		{"{{.Column}}", "", true}, // unknown field
+	}
+
+	c := ChangeLine{Line: 12, Before: "--zone = all", After: "--zone=all", Rule: "zone-all-normalize"}
+	for _, tt := range tests {
+		f, err := ParseChangeFormat(tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseChangeFormat(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		got, err := f.Format(c)
+		if err != nil || got != tt.want {
+			t.Errorf("Format with %q = %q, %v; want %q", tt.format, got, err, tt.want)
+		}
+	}
+}
+
+func TestStream_ChangeFormat(t *testing.T) {
+	f, err := ParseChangeFormat("{{.Line}}:{{.Rule}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := NewDefaultEngine()
+	eng.SetChangeFormat(f)
+
+	var out, stats bytes.Buffer
+	if err := eng.Stream(strings.NewReader("usacloud server list\nusacloud iso-image list\n"), &out, &stats); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if stats.String() != "2:iso-image-to-cdrom\n" {
+		t.Errorf("unexpected change stream: %q", stats.String())
+	}
+}
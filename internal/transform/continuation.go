@@ -0,0 +1,237 @@
+package transform
+
+import "strings"
+
+// LogicalLine is one command of a script: a single physical line, or several
+// joined by trailing backslash continuations
+type LogicalLine struct {
+	Start int      // index of the first physical line
+	Parts []string // the physical lines as written
+
+	// Heredoc is set on the lines of a here-document body, including the
+	// line closing it. They are data rather than commands and are never
+	// joined with the next line.
+	Heredoc bool
+}
+
+// JoinContinuations groups lines into logical lines. A line continues on the
+// next one when it ends with a backslash that is neither escaped, quoted nor
+// part of a comment. A continuation on the last line ends the group. The body
+// of a here-document opened with << or <<- follows the command opening it,
+// one logical line per physical line marked Heredoc.
+func JoinContinuations(lines []string) []LogicalLine {
+	var logical []LogicalLine
+	var bodies []heredoc // here-documents still to be read, in order
+	for i := 0; i < len(lines); i++ {
+		if len(bodies) > 0 {
+			if bodies[0].closes(lines[i]) {
+				bodies = bodies[1:]
+			}
+			logical = append(logical, LogicalLine{Start: i, Parts: []string{lines[i]}, Heredoc: true})
+			continue
+		}
+
+		l := LogicalLine{Start: i, Parts: []string{lines[i]}}
+		for continuesOnNextLine(lines[i]) && i+1 < len(lines) {
+			i++
+			l.Parts = append(l.Parts, lines[i])
+		}
+		bodies = heredocsOpenedBy(l.Text())
+		logical = append(logical, l)
+	}
+	return logical
+}
+
+// continuesOnNextLine reports whether line ends with a line continuation
+func continuesOnNextLine(line string) bool {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			if i == len(line)-1 {
+				return quote == 0
+			}
+			i++ // escaped character
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return false
+		}
+	}
+	return false
+}
+
+// Text returns the command with the continuations removed, as the shell reads it
+func (l LogicalLine) Text() string {
+	if len(l.Parts) == 1 {
+		return l.Parts[0]
+	}
+	var b strings.Builder
+	for k, part := range l.Parts {
+		if k < len(l.Parts)-1 {
+			part = part[:len(part)-1]
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// Split breaks a transformed Text back into as many physical lines as were
+// joined. Each continuation line starts where its start in Text is moved to
+// by the change between Text and transformed. When the change covers that
+// point, the line starts where its first word (with the indentation) is found
+// again in the transformed text, and a line whose first word was rewritten is
+// merged into the previous one and left as a bare backslash, so the command
+// still runs the same. The trailing "# usacloud-update:" comment stays on the
+// last line, and every line is commented out when a rule commented out the
+// command.
+func (l LogicalLine) Split(transformed string) []string {
+	text := l.Text()
+	e := detectEdit(text, transformed)
+	return l.split(transformed, []byteSpan{{
+		originalStart:    e.start,
+		originalEnd:      e.end,
+		transformedStart: e.start,
+		transformedEnd:   e.start + e.length,
+	}})
+}
+
+// split is Split moving the starts of the lines through the spans of the
+// changes from Text to transformed
+func (l LogicalLine) split(transformed string, spans []byteSpan) []string {
+	n := len(l.Parts)
+	if n == 1 {
+		return []string{transformed}
+	}
+	if transformed == l.Text() {
+		return append([]string(nil), l.Parts...)
+	}
+
+	limit := len(transformed)
+	if i := strings.Index(transformed, " "+commentMarker); i >= 0 {
+		limit = i
+	}
+
+	// starts[k] is where line k starts in Text
+	starts := make([]int, n)
+	for k := 1; k < n; k++ {
+		starts[k] = starts[k-1] + len(l.Parts[k-1]) - 1
+	}
+
+	// cuts[k] is where line k starts; lines are searched from the end so that
+	// a word repeated on an earlier line does not move the cut
+	cuts := make([]int, n+1)
+	cuts[n] = len(transformed)
+	next := limit
+	for k := n - 1; k >= 1; k-- {
+		if i, ok := moveThroughSpans(starts[k], spans); ok && i > 0 && i <= next {
+			cuts[k] = i
+			next = i
+			continue
+		}
+		cuts[k] = next
+		anchor := continuationAnchor(l.Parts[k], k == n-1)
+		if anchor == "" {
+			continue
+		}
+		if i := strings.LastIndex(transformed[:next], anchor); i > 0 {
+			cuts[k] = i
+			next = i
+		}
+	}
+
+	commentedOut := strings.HasPrefix(strings.TrimSpace(transformed), "#") &&
+		!strings.HasPrefix(strings.TrimSpace(l.Text()), "#")
+
+	lines := make([]string, n)
+	for k := range lines {
+		body := transformed[cuts[k]:cuts[k+1]]
+		indent := leadingSpace(l.Parts[k])
+		if strings.TrimSpace(body) == "" && k > 0 {
+			body = indent + strings.TrimLeft(body, " \t")
+		}
+		if k > 0 && commentedOut {
+			body = indent + "# " + strings.TrimLeft(body, " \t")
+		}
+		if k < n-1 {
+			body += "\\"
+		}
+		lines[k] = body
+	}
+	return lines
+}
+
+// moveThroughSpans maps pos in the original text to the transformed text,
+// reporting false when a change covers it
+func moveThroughSpans(pos int, spans []byteSpan) (int, bool) {
+	moved, last := pos, -1
+	for _, s := range spans {
+		if s.originalStart < pos && pos < s.originalEnd {
+			return 0, false
+		}
+		if s.originalEnd <= pos && s.originalEnd >= last {
+			moved, last = pos-s.originalEnd+s.transformedEnd, s.originalEnd
+		}
+	}
+	return moved, true
+}
+
+// continuationAnchor returns the indentation and first word of a physical line
+// that follows a continuation, or "" when it has no word
+func continuationAnchor(part string, last bool) string {
+	if !last {
+		part = part[:len(part)-1]
+	}
+	indent := leadingSpace(part)
+	word := strings.TrimLeft(part, " \t")
+	if i := strings.IndexAny(word, " \t"); i >= 0 {
+		word = word[:i]
+	}
+	if word == "" {
+		return ""
+	}
+	return indent + word
+}
+
+// leadingSpace returns the spaces and tabs at the start of s
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+// Expand returns one result per physical line for the result of applying the
+// engine to Text. The first line carries the changes of the whole command;
+// the other lines are marked changed when their text differs.
+func (l LogicalLine) Expand(r Result) []Result {
+	if len(l.Parts) == 1 {
+		return []Result{r}
+	}
+
+	spans := r.spans
+	if spans == nil && r.Changed {
+		spans = locateSpans(r)
+	}
+	var lines []string
+	if spans == nil || r.Original != l.Text() {
+		lines = l.Split(r.Line)
+	} else {
+		lines = l.split(r.Line, spans)
+	}
+	results := make([]Result, len(lines))
+	for k, line := range lines {
+		results[k] = Result{Original: l.Parts[k], Line: line, Changed: line != l.Parts[k]}
+	}
+	results[0].Changed = r.Changed
+	results[0].Changes = r.Changes
+	results[0].Skipped = r.Skipped
+	results[0].Diagnostics = r.Diagnostics
+	return results
+}
@@ -0,0 +1,197 @@
+package transform
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContinuesOnNextLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`usacloud server list \`, true},
+		{`usacloud server list\`, true},
+		{`  \`, true},
+		{`usacloud server list`, false},
+		{`usacloud server list \\`, false},  // escaped backslash
+		{`usacloud server list \\\`, true},  // escaped backslash, then continuation
+		{`echo "a \`, false},                // inside double quotes
+		{`echo 'a \`, false},                // inside single quotes
+		{`echo 'a \' \`, true},              // quote closed before the continuation
+		{`echo "a \" \`, false},             // escaped quote keeps the string open
+		{`echo "a \\" \`, true},             // escaped backslash closes the string
+		{`# usacloud server list \`, false}, // comment
+		{`usacloud server list # note \`, false},
+		{`echo a#b \`, true}, // # inside a word is not a comment
+		{``, false},
+	}
+
+	for _, tt := range tests {
+		if got := continuesOnNextLine(tt.line); got != tt.want {
+			t.Errorf("continuesOnNextLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestJoinContinuations(t *testing.T) {
+	lines := []string{
+		"#!/bin/bash",
+		`usacloud server list \`,
+		`    --zone tk1v \`,
+		`    --output-type csv`,
+		`echo "done \`,
+		`usacloud disk list \`,
+	}
+
+	logical := JoinContinuations(lines)
+	want := []LogicalLine{
+		{Start: 0, Parts: lines[0:1]},
+		{Start: 1, Parts: lines[1:4]},
+		{Start: 4, Parts: lines[4:5]},
+		{Start: 5, Parts: lines[5:6]}, // a continuation on the last line ends the group
+	}
+	if !reflect.DeepEqual(logical, want) {
+		t.Fatalf("JoinContinuations() = %+v, want %+v", logical, want)
+	}
+
+	if got := logical[1].Text(); got != "usacloud server list     --zone tk1v     --output-type csv" {
+		t.Errorf("Text() = %q", got)
+	}
+	if got := logical[3].Text(); got != lines[5] {
+		t.Errorf("Text() of a trailing continuation = %q", got)
+	}
+}
+
+func TestLogicalLine_Split(t *testing.T) {
+	eng := NewDefaultEngine()
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "unchanged",
+			lines: []string{`usacloud server list \`, `  --zone tk1v`},
+			want:  []string{`usacloud server list \`, `  --zone tk1v`},
+		},
+		{
+			name:  "change on a continuation line",
+			lines: []string{`usacloud iso-image list \`, `    --output-type csv \`, `    --zone tk1v`},
+			want:  []string{`usacloud cdrom list \`, `    --output-type json \`, `    --zone tk1v # usacloud-update:`},
+		},
+		{
+			name:  "rewritten first word stays on its line",
+			lines: []string{`usacloud server read \`, `  --selector name=web \`, `  --zone tk1v`},
+			want:  []string{`usacloud server read \`, `  web \`, `  --zone tk1v # usacloud-update:`},
+		},
+		{
+			name:  "change on a middle continuation line",
+			lines: []string{`usacloud server list \`, `  --output-type=csv \`, `  --zone=is1a`},
+			want:  []string{`usacloud server list \`, `  --output-type=json \`, `  --zone=is1a # usacloud-update:`},
+		},
+		{
+			name:  "commented-out command comments out every line",
+			lines: []string{`usacloud summary \`, `  --zone tk1v`},
+			want:  []string{`# usacloud summary \`, `  # --zone tk1v # usacloud-update:`},
+		},
+		{
+			name:  "repeated word on an earlier line",
+			lines: []string{`usacloud iso-image list --zone tk1v \`, `--zone tk1v`},
+			want:  []string{`usacloud cdrom list --zone tk1v \`, `--zone tk1v # usacloud-update:`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := JoinContinuations(tt.lines)[0]
+			if len(l.Parts) != len(tt.lines) {
+				t.Fatalf("Expected the lines to be joined, got %+v", l)
+			}
+			got := l.Split(eng.Apply(l.Text()).Line)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Split() = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if !strings.HasPrefix(got[i], tt.want[i]) {
+					t.Errorf("line %d = %q, want prefix %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyFile_ContinuationsWithSeveralChanges(t *testing.T) {
+	lines := []string{
+		`usacloud iso-image read \`,
+		`  --selector name=web \`,
+		`  --output-type=csv \`,
+		`  --zone=is1a`,
+	}
+	want := []string{
+		`usacloud cdrom read \`,
+		`  web \`,
+		`  --output-type=json \`,
+		`  --zone=is1a # usacloud-update:`,
+	}
+
+	results, _ := NewDefaultEngine().ApplyFile(lines)
+	if len(results) != len(want) {
+		t.Fatalf("Expected one result per physical line, got %d", len(results))
+	}
+	for i, r := range results {
+		if !strings.HasPrefix(r.Line, want[i]) {
+			t.Errorf("line %d = %q, want prefix %q", i, r.Line, want[i])
+		}
+	}
+}
+
+func TestApplyFile_Continuations(t *testing.T) {
+	lines := []string{
+		`usacloud iso-image list \`,
+		`  --output-type csv`,
+		`usacloud server list`,
+	}
+
+	results, stats := NewDefaultEngine().ApplyFile(lines)
+	if len(results) != len(lines) {
+		t.Fatalf("Expected one result per physical line, got %d", len(results))
+	}
+	if results[0].Line != `usacloud cdrom list \` || !results[0].Changed || len(results[0].Changes) != 2 {
+		t.Errorf("Unexpected first line: %+v", results[0])
+	}
+	if !strings.HasPrefix(results[1].Line, "  --output-type json # usacloud-update:") || len(results[1].Changes) != 0 {
+		t.Errorf("Unexpected continuation line: %+v", results[1])
+	}
+	if results[2].Changed {
+		t.Errorf("Unexpected change: %+v", results[2])
+	}
+	if stats.TotalLines != 3 || stats.ChangedLines != 2 || stats.RuleHits["output-type-csv-tsv"] != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestStream_Continuations(t *testing.T) {
+	input := "usacloud iso-image list \\\n  --output-type csv\nusacloud server list \\"
+	eng := NewDefaultEngine()
+
+	var out, stats bytes.Buffer
+	if err := eng.Stream(strings.NewReader(input), &out, &stats); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	results, _ := eng.ApplyFile(strings.Split(input, "\n"))
+	want := GeneratedHeader() + "\n"
+	for _, r := range results {
+		want += r.Line + "\n"
+	}
+	if out.String() != want {
+		t.Errorf("Stream() output = %q, want %q", out.String(), want)
+	}
+	if !strings.Contains(stats.String(), "#L1     ") || strings.Contains(stats.String(), "#L2 ") {
+		t.Errorf("Expected the changes to be reported on the first line of the command, got %q", stats.String())
+	}
+}
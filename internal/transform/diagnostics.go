@@ -0,0 +1,70 @@
+package transform
+
+import "unicode/utf8"
+
+// Diagnostic describes what a rule did to a line and how much attention it
+// needs: SeverityWarning for a line the user has to review (a command
+// commented out because it has no v1 equivalent, or a line only annotated
+// for a check, such as yaml output whose fields changed) and SeverityInfo
+// for a plain rewrite
+type Diagnostic struct {
+	RuleName string
+	Severity Severity
+	Message  string // the rule's reason, as in the inline comment
+
+	// Rune offsets in Line of the text the rule wrote; for a command
+	// continued over several lines (see LogicalLine), in the joined command
+	Start int
+	End   int
+}
+
+// diagnose builds one diagnostic per change of r, using the rules for their
+// descriptions
+func diagnose(r Result, rules []Rule) []Diagnostic {
+	if len(r.Changes) == 0 {
+		return nil
+	}
+
+	byName := make(map[string]Rule, len(rules))
+	for _, rule := range rules {
+		byName[rule.Name()] = rule
+	}
+	spans := r.Diff().Spans
+
+	diagnostics := make([]Diagnostic, 0, len(r.Changes))
+	for _, c := range r.Changes {
+		d := Diagnostic{
+			RuleName: c.RuleName,
+			Severity: SeverityInfo,
+			Message:  c.Before + " => " + c.After,
+			End:      utf8.RuneCountInString(r.Line),
+		}
+		if desc, ok := byName[c.RuleName].(RuleDescriber); ok {
+			d.Message = desc.Description()
+		}
+		if RequiresManualReview(c.RuleName) || c.Before == c.After {
+			d.Severity = SeverityWarning
+		}
+		// Spans follow the changes in order; take the first one of the rule not used yet
+		for i, s := range spans {
+			if s.RuleName == c.RuleName {
+				d.Start, d.End = s.TransformedStart, s.TransformedEnd
+				spans = append(spans[:i:i], spans[i+1:]...)
+				break
+			}
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// Warnings returns the diagnostics of r that need the user's attention
+func (r Result) Warnings() []Diagnostic {
+	var warnings []Diagnostic
+	for _, d := range r.Diagnostics {
+		if d.Severity == SeverityWarning {
+			warnings = append(warnings, d)
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,40 @@
+package transform
+
+import "testing"
+
+func TestApply_Diagnostics(t *testing.T) {
+	eng := NewDefaultEngine()
+
+	tests := []struct {
+		line     string
+		want     []Severity
+		wantText []string // text of Line each diagnostic points at
+	}{
+		{"usacloud iso-image list --output-type csv", []Severity{SeverityInfo, SeverityInfo}, []string{"json", "cdrom"}},
+		{"sudo usacloud iso-image list", []Severity{SeverityInfo}, []string{"cdrom"}},
+		{"usacloud summary", []Severity{SeverityWarning}, nil},
+		{"usacloud server list --output-type yaml", []Severity{SeverityWarning}, nil},
+		{"usacloud server list", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			res := eng.Apply(tt.line)
+			if len(res.Diagnostics) != len(tt.want) || len(res.Diagnostics) != len(res.Changes) {
+				t.Fatalf("expected %d diagnostics, one per change, got %+v", len(tt.want), res.Diagnostics)
+			}
+			runes := []rune(res.Line)
+			for i, d := range res.Diagnostics {
+				if d.RuleName != res.Changes[i].RuleName || d.Severity != tt.want[i] || d.Message == "" {
+					t.Errorf("diagnostic %d = %+v, want severity %v for %s", i, d, tt.want[i], res.Changes[i].RuleName)
+				}
+				if i < len(tt.wantText) && string(runes[d.Start:d.End]) != tt.wantText[i] {
+					t.Errorf("diagnostic %d points at %q, want %q", i, string(runes[d.Start:d.End]), tt.wantText[i])
+				}
+			}
+			if warnings := res.Warnings(); len(tt.want) > 0 && (len(warnings) > 0) != (tt.want[0] == SeverityWarning) {
+				t.Errorf("unexpected warnings %+v", warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package transform
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ChangeSpan は1つの変更が行内で占める範囲
+// オフセットは文字（rune）単位で、End は範囲の直後を指す
+type ChangeSpan struct {
+	RuleName         string
+	Before           string
+	After            string
+	OriginalStart    int
+	OriginalEnd      int
+	TransformedStart int
+	TransformedEnd   int
+}
+
+// LineDiff は1行分の構造化差分
+type LineDiff struct {
+	Original    string
+	Transformed string
+	Changed     bool
+	Spans       []ChangeSpan
+}
+
+// edit は変換途中の行に対する1回の置換（バイトオフセット）
+type edit struct {
+	start, end int // 置換前の範囲
+	length     int // 置換後の長さ
+}
+
+// forward maps a byte position across the edit
+func (e edit) forward(pos int, isEnd bool) int {
+	switch {
+	case pos < e.start || (pos == e.start && !isEnd):
+		return pos
+	case pos >= e.end:
+		return pos + e.length - (e.end - e.start)
+	case isEnd:
+		return e.start + e.length
+	default:
+		return e.start
+	}
+}
+
+// backward maps a byte position from after the edit to before it
+func (e edit) backward(pos int, isEnd bool) int {
+	switch {
+	case pos < e.start || (pos == e.start && !isEnd):
+		return pos
+	case pos >= e.start+e.length:
+		return pos - e.length + (e.end - e.start)
+	case isEnd:
+		return e.end
+	default:
+		return e.start
+	}
+}
+
+// byteSpan は変換中に記録するバイトオフセットの範囲
+type byteSpan struct {
+	ruleName         string
+	originalStart    int
+	originalEnd      int
+	transformedStart int
+	transformedEnd   int
+}
+
+// spanTracker は規則適用ごとの置換を記録し、元の行と変換後の行の範囲を追跡する
+type spanTracker struct {
+	edits []edit
+	spans []byteSpan
+}
+
+// record registers a rule application that turned before into after
+func (t *spanTracker) record(ruleName, before, after string) {
+	e := detectEdit(before, after)
+
+	// 既存の範囲を今回の置換に合わせて移動
+	for i := range t.spans {
+		t.spans[i].transformedStart = e.forward(t.spans[i].transformedStart, false)
+		t.spans[i].transformedEnd = e.forward(t.spans[i].transformedEnd, true)
+	}
+
+	// 今回の範囲を元の行の座標に戻す
+	origStart, origEnd := e.start, e.end
+	for i := len(t.edits) - 1; i >= 0; i-- {
+		origStart = t.edits[i].backward(origStart, false)
+		origEnd = t.edits[i].backward(origEnd, true)
+	}
+
+	t.edits = append(t.edits, e)
+	t.spans = append(t.spans, byteSpan{
+		ruleName:         ruleName,
+		originalStart:    origStart,
+		originalEnd:      origEnd,
+		transformedStart: e.start,
+		transformedEnd:   e.start + e.length,
+	})
+}
+
+// detectEdit finds the replaced region between before and after, ignoring the appended rule comment
+func detectEdit(before, after string) edit {
+	body := after
+	if !strings.Contains(before, "# usacloud-update:") {
+		if i := strings.LastIndex(after, " # usacloud-update:"); i >= 0 {
+			body = after[:i]
+		}
+	}
+
+	prefix := 0
+	for prefix < len(before) && prefix < len(body) && before[prefix] == body[prefix] {
+		prefix++
+	}
+	for prefix > 0 && prefix < len(before) && !utf8.RuneStart(before[prefix]) {
+		prefix--
+	}
+
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(body)-prefix &&
+		before[len(before)-1-suffix] == body[len(body)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && !utf8.RuneStart(before[len(before)-suffix]) {
+		suffix--
+	}
+
+	start, beforeEnd, bodyEnd := prefix, len(before)-suffix, len(body)-suffix
+
+	// 単語の途中で区切られた場合は単語境界まで範囲を広げる
+	if (start < beforeEnd && isWordByte(before[start])) || (start < bodyEnd && isWordByte(body[start])) {
+		for start > 0 && isWordByte(before[start-1]) {
+			start--
+		}
+	}
+	if (beforeEnd > start && isWordByte(before[beforeEnd-1])) || (bodyEnd > start && isWordByte(body[bodyEnd-1])) {
+		for beforeEnd < len(before) && isWordByte(before[beforeEnd]) {
+			beforeEnd++
+			bodyEnd++
+		}
+	}
+
+	return edit{start: start, end: beforeEnd, length: bodyEnd - start}
+}
+
+// isWordByte reports whether b is part of a command or option word
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '-' || b == '_' || b == '.'
+}
+
+// Diff returns a structured diff of the original and transformed line
+func (r Result) Diff() LineDiff {
+	diff := LineDiff{
+		Original:    r.Original,
+		Transformed: r.Line,
+		Changed:     r.Changed,
+	}
+
+	spans := r.spans
+	if spans == nil && r.Changed {
+		spans = locateSpans(r)
+	}
+
+	for _, s := range spans {
+		diff.Spans = append(diff.Spans, ChangeSpan{
+			RuleName:         s.ruleName,
+			Before:           r.Original[s.originalStart:s.originalEnd],
+			After:            r.Line[s.transformedStart:s.transformedEnd],
+			OriginalStart:    utf8.RuneCountInString(r.Original[:s.originalStart]),
+			OriginalEnd:      utf8.RuneCountInString(r.Original[:s.originalEnd]),
+			TransformedStart: utf8.RuneCountInString(r.Line[:s.transformedStart]),
+			TransformedEnd:   utf8.RuneCountInString(r.Line[:s.transformedEnd]),
+		})
+	}
+
+	return diff
+}
+
+// locateSpans derives spans from change fragments for results built without the engine
+func locateSpans(r Result) []byteSpan {
+	var spans []byteSpan
+	origFrom, transFrom := 0, 0
+
+	for _, c := range r.Changes {
+		oi := strings.Index(r.Original[origFrom:], c.Before)
+		ti := strings.Index(r.Line[transFrom:], c.After)
+		if oi < 0 || ti < 0 {
+			continue
+		}
+		oi += origFrom
+		ti += transFrom
+		spans = append(spans, byteSpan{
+			ruleName:         c.RuleName,
+			originalStart:    oi,
+			originalEnd:      oi + len(c.Before),
+			transformedStart: ti,
+			transformedEnd:   ti + len(c.After),
+		})
+		origFrom = oi + len(c.Before)
+		transFrom = ti + len(c.After)
+	}
+
+	return spans
+}
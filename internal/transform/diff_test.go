@@ -0,0 +1,107 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestResult_Diff_MultipleChanges(t *testing.T) {
+	engine := NewDefaultEngine()
+	line := "usacloud iso-image list --output-type=csv --zone = all"
+
+	result := engine.Apply(line)
+	diff := result.Diff()
+
+	if diff.Original != line {
+		t.Errorf("Expected original %q, got %q", line, diff.Original)
+	}
+	if diff.Transformed != result.Line {
+		t.Errorf("Expected transformed %q, got %q", result.Line, diff.Transformed)
+	}
+	if !diff.Changed {
+		t.Fatal("Expected diff to be marked as changed")
+	}
+
+	expected := []ChangeSpan{
+		{RuleName: "output-type-csv-tsv", Before: "csv", After: "json", OriginalStart: 38, OriginalEnd: 41, TransformedStart: 34, TransformedEnd: 38},
+		{RuleName: "iso-image-to-cdrom", Before: "iso-image", After: "cdrom", OriginalStart: 9, OriginalEnd: 18, TransformedStart: 9, TransformedEnd: 14},
+		{RuleName: "zone-all-normalize", Before: " = ", After: "=", OriginalStart: 48, OriginalEnd: 51, TransformedStart: 45, TransformedEnd: 46},
+	}
+
+	if len(diff.Spans) != len(expected) {
+		t.Fatalf("Expected %d spans, got %d: %+v", len(expected), len(diff.Spans), diff.Spans)
+	}
+	for i, want := range expected {
+		got := diff.Spans[i]
+		if got != want {
+			t.Errorf("Span %d: expected %+v, got %+v", i, want, got)
+		}
+		// オフセットが実際の文字列と一致することを確認
+		if string([]rune(diff.Original)[got.OriginalStart:got.OriginalEnd]) != got.Before {
+			t.Errorf("Span %d: original offsets do not match %q", i, got.Before)
+		}
+		if string([]rune(diff.Transformed)[got.TransformedStart:got.TransformedEnd]) != got.After {
+			t.Errorf("Span %d: transformed offsets do not match %q", i, got.After)
+		}
+	}
+}
+
+func TestResult_Diff_RuneOffsets(t *testing.T) {
+	engine := NewDefaultEngine()
+	line := "echo 'サーバ' && usacloud ipv4 list"
+
+	diff := engine.Apply(line).Diff()
+	if len(diff.Spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d: %+v", len(diff.Spans), diff.Spans)
+	}
+
+	span := diff.Spans[0]
+	if span.Before != "ipv4" || span.After != "ipaddress" {
+		t.Errorf("Unexpected span text: %+v", span)
+	}
+	if span.OriginalStart != 23 || span.OriginalEnd != 27 {
+		t.Errorf("Expected rune offsets 23-27, got %d-%d", span.OriginalStart, span.OriginalEnd)
+	}
+}
+
+func TestResult_Diff_WholeLineRule(t *testing.T) {
+	engine := NewDefaultEngine()
+
+	diff := engine.Apply("usacloud summary").Diff()
+	if len(diff.Spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(diff.Spans))
+	}
+
+	span := diff.Spans[0]
+	if span.Before != "" || span.After != "# " || span.OriginalStart != 0 || span.TransformedEnd != 2 {
+		t.Errorf("Expected comment-out insertion at line start, got %+v", span)
+	}
+}
+
+func TestResult_Diff_Unchanged(t *testing.T) {
+	engine := NewDefaultEngine()
+
+	diff := engine.Apply("echo hello").Diff()
+	if diff.Changed || len(diff.Spans) != 0 {
+		t.Errorf("Expected no spans for unchanged line, got %+v", diff)
+	}
+	if diff.Original != "echo hello" || diff.Transformed != "echo hello" {
+		t.Errorf("Unexpected diff lines: %+v", diff)
+	}
+}
+
+func TestResult_Diff_WithoutEngine(t *testing.T) {
+	result := Result{
+		Original: "usacloud ipv4 list",
+		Line:     "usacloud ipaddress list",
+		Changed:  true,
+		Changes:  []Change{{RuleName: "ipv4-to-ipaddress", Before: "ipv4", After: "ipaddress"}},
+	}
+
+	diff := result.Diff()
+	if len(diff.Spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(diff.Spans))
+	}
+	if diff.Spans[0].OriginalStart != 9 || diff.Spans[0].TransformedEnd != 18 {
+		t.Errorf("Unexpected span offsets: %+v", diff.Spans[0])
+	}
+}
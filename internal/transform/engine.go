@@ -1,8 +1,11 @@
 package transform
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/validation"
 )
 
 type Change struct {
@@ -12,9 +15,17 @@ type Change struct {
 }
 
 type Result struct {
-	Line    string
-	Changed bool
-	Changes []Change
+	Original string
+	Line     string
+	Changed  bool
+	Changes  []Change
+	Skipped  []SkippedRule // rules ApplyReverse could not undo
+
+	// Diagnostics describes each change with a severity, so that warnings
+	// can be told apart from plain rewrites; see Warnings
+	Diagnostics []Diagnostic
+
+	spans []byteSpan
 }
 
 type Rule interface {
@@ -22,31 +33,319 @@ type Rule interface {
 	Apply(line string) (string, bool, string, string)
 }
 
-type Engine struct{ rules []Rule }
+// Engine applies rules to each line strictly in slice order. Every rule sees
+// the output of the rules before it, so the order is part of the behavior;
+// see DefaultRules for the default order.
+type Engine struct {
+	rules        []Rule
+	binaryVars   map[string]bool // shell variables holding the usacloud binary
+	postProcess  PostProcessor   // see SetPostProcessor
+	changeFormat *ChangeFormat   // see SetChangeFormat
+
+	sourceVersion, targetVersion string // see WithVersions ("" for the defaults)
+}
 
 func NewDefaultEngine() *Engine {
 	return &Engine{rules: DefaultRules()}
 }
 
+// NewEngineWithOrder creates an engine whose rules named in order run first,
+// in the given sequence. Rules not listed keep their default relative order
+// after them. Unknown or duplicated names are rejected.
+func NewEngineWithOrder(order []string) (*Engine, error) {
+	rules, err := orderRules(DefaultRules(), order)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{rules: rules}, nil
+}
+
+// orderRules moves the rules named in order to the front, keeping the rest in place
+func orderRules(rules []Rule, order []string) ([]Rule, error) {
+	byName := make(map[string]Rule, len(rules))
+	for _, r := range rules {
+		byName[r.Name()] = r
+	}
+
+	ordered := make([]Rule, 0, len(rules))
+	placed := make(map[string]bool, len(order))
+	for _, name := range order {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		r, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown rule %q (available: %s)", name, strings.Join(ruleNames(rules), ", "))
+		}
+		if placed[name] {
+			return nil, fmt.Errorf("rule %q is listed more than once", name)
+		}
+		placed[name] = true
+		ordered = append(ordered, r)
+	}
+
+	for _, r := range rules {
+		if !placed[r.Name()] {
+			ordered = append(ordered, r)
+		}
+	}
+
+	return ordered, nil
+}
+
+// WithBinaryVariables returns a copy of the engine that also transforms
+// commands run through the given shell variables, such as $USACLOUD. Use
+// validation.BinaryVariables to find them in a script.
+func (e *Engine) WithBinaryVariables(binaryVars map[string]bool) *Engine {
+	c := *e
+	c.binaryVars = binaryVars
+	return &c
+}
+
+// withScriptVariables returns a copy of the engine that also knows the binary
+// variables lines assign
+func (e *Engine) withScriptVariables(lines []string) *Engine {
+	vars := make(map[string]bool, len(e.binaryVars))
+	for name := range e.binaryVars {
+		vars[name] = true
+	}
+	for name := range validation.BinaryVariables(lines) {
+		vars[name] = true
+	}
+	return e.WithBinaryVariables(vars)
+}
+
+// unwrap applies apply to the usacloud command of a line that runs it through
+// wrappers such as sudo or env, a path to the binary or a binary variable, and
+// puts them back into the result. It reports false for other lines.
+func (e *Engine) unwrap(line string, apply func(string) Result) (Result, bool) {
+	inv, ok := validation.FindInvocation(line, e.binaryVars)
+	if !ok || !inv.Wrapped() {
+		return Result{}, false
+	}
+
+	r := apply(inv.Command)
+	r.Original = line
+	if r.Line == inv.Command {
+		r.Line = line
+		return r, true
+	}
+	r.spans = rewrapSpans(r.spans, inv, r.Line)
+	r.Line = inv.Rewrap(r.Line)
+	if r.Diagnostics != nil {
+		r.Diagnostics = diagnose(r, e.rules)
+	}
+	return r, true
+}
+
+// rewrapSpans moves spans recorded on an invocation's Command and its
+// transformed text to the line Invocation.Rewrap builds from them
+func rewrapSpans(spans []byteSpan, inv validation.Invocation, transformed string) []byteSpan {
+	const binary = len("usacloud")
+	wrappers := len(strings.TrimLeft(inv.Prefix, " \t"))
+	indent := len(inv.Prefix) - wrappers
+	grow := len(inv.Binary) - binary
+
+	original := func(pos int) int {
+		switch {
+		case pos == 0:
+			return indent
+		case pos < binary:
+			return len(inv.Prefix) + pos
+		default:
+			return len(inv.Prefix) + pos + grow
+		}
+	}
+	at := strings.Index(transformed, "usacloud")
+	rewrapped := func(pos int) int {
+		switch {
+		case at < 0 || pos <= at:
+			return indent + pos
+		case pos < at+binary:
+			return indent + wrappers + pos
+		default:
+			return indent + wrappers + pos + grow
+		}
+	}
+
+	moved := make([]byteSpan, len(spans))
+	for i, s := range spans {
+		moved[i] = byteSpan{
+			ruleName:         s.ruleName,
+			originalStart:    original(s.originalStart),
+			originalEnd:      original(s.originalEnd),
+			transformedStart: rewrapped(s.transformedStart),
+			transformedEnd:   rewrapped(s.transformedEnd),
+		}
+	}
+	return moved
+}
+
+// RuleNames returns the rule names in application order
+func (e *Engine) RuleNames() []string {
+	return ruleNames(e.rules)
+}
+
+func ruleNames(rules []Rule) []string {
+	names := make([]string, len(rules))
+	for i, r := range rules {
+		names[i] = r.Name()
+	}
+	return names
+}
+
 func (e *Engine) Apply(line string) Result {
+	return e.postProcessed(line, e.applyRules(line))
+}
+
+// applyRules is Apply without the post-processor
+func (e *Engine) applyRules(line string) Result {
 	// コメント/空行はスキップ
 	trim := strings.TrimSpace(line)
 	if trim == "" || strings.HasPrefix(trim, "#") {
-		return Result{Line: line}
+		return Result{Original: line, Line: line}
+	}
+	if r, ok := e.unwrap(line, e.applyRules); ok {
+		return r
 	}
 
 	changed := false
 	var changes []Change
+	var applied []Rule
+	var tracker spanTracker
 	cur := line
 	for _, r := range e.rules {
 		after, ok, beforeFrag, afterFrag := r.Apply(cur)
 		if ok {
 			changed = true
 			changes = append(changes, Change{RuleName: r.Name(), Before: beforeFrag, After: afterFrag})
+			applied = append(applied, r)
+			tracker.record(r.Name(), cur, after)
 			cur = after
 		}
 	}
-	return Result{Line: cur, Changed: changed, Changes: changes}
+	if len(applied) > 1 && !strings.Contains(line, commentMarker) {
+		cur = mergeComments(cur, applied)
+	}
+	res := Result{Original: line, Line: cur, Changed: changed, Changes: changes, spans: tracker.spans}
+	res.Diagnostics = diagnose(res, e.rules)
+	return res
+}
+
+// WouldTransform reports whether Apply would change line, without building the
+// result. Rules are tried in order and the first one that matches ends the check.
+// With a post-processor set, the line is transformed to find out.
+func (e *Engine) WouldTransform(line string) bool {
+	trim := strings.TrimSpace(line)
+	if trim == "" || strings.HasPrefix(trim, "#") {
+		return false
+	}
+	if e.postProcess != nil {
+		return e.Apply(line).Changed
+	}
+	if inv, ok := validation.FindInvocation(line, e.binaryVars); ok {
+		line = inv.Command
+	}
+	for _, r := range e.rules {
+		// Earlier rules did not match, so each rule sees the line Apply would give it
+		if _, ok, _, _ := r.Apply(line); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyPasses runs Apply repeatedly on its own output until the line stops
+// changing, so that a rule whose result is matched by an earlier rule is also
+// applied. At most maxPasses passes are run (a single pass when maxPasses <= 1),
+// and the loop also stops when a line seen in an earlier pass comes back. The
+// returned changes are those of every pass that altered the line, in order,
+// and the inline comment lists the reasons of all of them. The post-processor
+// runs once, after the last pass.
+func (e *Engine) ApplyPasses(line string, maxPasses int) Result {
+	return e.postProcessed(line, e.applyPasses(line, maxPasses))
+}
+
+// applyPasses is ApplyPasses without the post-processor
+func (e *Engine) applyPasses(line string, maxPasses int) Result {
+	res := e.applyRules(line)
+	if maxPasses <= 1 || !res.Changed {
+		return res
+	}
+
+	firstPass := len(res.Changes)
+	seen := map[string]bool{line: true, res.Line: true}
+	for pass := 1; pass < maxPasses; pass++ {
+		next := e.applyRules(res.Line)
+		if !next.Changed || next.Line == res.Line {
+			break
+		}
+		res.Line = next.Line
+		res.Changes = append(res.Changes, next.Changes...)
+		if seen[next.Line] {
+			break
+		}
+		seen[next.Line] = true
+	}
+	if len(res.Changes) == firstPass {
+		return res
+	}
+
+	// Later passes keep the comment of the first one; list the reasons of every pass
+	res.spans = nil // positions from separate passes cannot be combined; see locateSpans
+	if !strings.Contains(line, commentMarker) {
+		byName := make(map[string]Rule, len(e.rules))
+		for _, r := range e.rules {
+			byName[r.Name()] = r
+		}
+		applied := make([]Rule, 0, len(res.Changes))
+		for _, c := range res.Changes {
+			applied = append(applied, byName[c.RuleName])
+		}
+		res.Line = mergeComments(res.Line, applied)
+	}
+	res.Diagnostics = diagnose(res, e.rules)
+	return res
+}
+
+// commentMarker introduces the comment appended to changed lines
+const commentMarker = "# usacloud-update:"
+
+// mergeComments replaces the comment written by the first applied rule with
+// one listing every applied rule in application order. Identical entries
+// (e.g. the product-* aliases) are listed once. Lines are left untouched if a
+// rule does not describe itself.
+func mergeComments(line string, applied []Rule) string {
+	i := strings.LastIndex(line, " "+commentMarker)
+	if i < 0 {
+		return line
+	}
+
+	seen := make(map[string]bool, len(applied))
+	entries := make([]string, 0, len(applied))
+	for _, r := range applied {
+		d, ok := r.(RuleDescriber)
+		if !ok {
+			return line
+		}
+		entry := describe(d)
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		entries = append(entries, entry)
+	}
+
+	return line[:i] + " " + commentMarker + " " + strings.Join(entries, ", ")
+}
+
+// describe formats a rule's reason with its documentation URL, if any, for the inline comment
+func describe(d RuleDescriber) string {
+	if d.DocURL() == "" {
+		return d.Description()
+	}
+	return fmt.Sprintf("%s (%s)", d.Description(), d.DocURL())
 }
 
 // utilities
@@ -1,64 +1,195 @@
 package transform
 
 import (
-	"bufio"
-	"flag"
 	"os"
 	"strings"
 	"testing"
+
+	goldenTesting "github.com/armaniacs/usacloud-update/internal/testing"
 )
 
-var update = flag.Bool("update", false, "update golden files")
+func TestEngine_MultiRuleLineIsStable(t *testing.T) {
+	line := "usacloud iso-image list --output-type tsv --selector name=foo"
+	want := "usacloud cdrom list --output-type json foo # usacloud-update: " +
+		"v1.0でcsv/tsvは廃止。jsonに置換し、必要なら --query/jq を利用してください (https://docs.usacloud.jp/usacloud/upgrade/v1_0_0/), " +
+		"--selectorはv1で廃止。ID/名称/タグをコマンド引数に指定する仕様へ移行 (https://docs.usacloud.jp/usacloud/upgrade/v1_0_0/), " +
+		"v1ではリソース名がcdromに統一 (https://manual.sakura.ad.jp/cloud-api/1.1/cdrom/index.html)"
+	wantRules := []string{"output-type-csv-tsv", "selector-to-arg", "iso-image-to-cdrom"}
 
-// applyFile reads a bash script, applies the transform engine line-by-line,
-// and returns the final output string with the generated header and trailing newline.
-func applyFile(t *testing.T, inPath string) string {
-	t.Helper()
+	for i := 0; i < 10; i++ {
+		res := NewDefaultEngine().Apply(line)
+		if res.Line != want {
+			t.Fatalf("run %d: got %q, want %q", i, res.Line, want)
+		}
+		if len(res.Changes) != len(wantRules) {
+			t.Fatalf("run %d: got %d changes, want %d", i, len(res.Changes), len(wantRules))
+		}
+		for j, name := range wantRules {
+			if res.Changes[j].RuleName != name {
+				t.Errorf("run %d: change %d rule = %s, want %s", i, j, res.Changes[j].RuleName, name)
+			}
+		}
+		// The selector rule must not consume the comment appended by an earlier rule
+		if res.Changes[1].Before != "--selector name=foo" {
+			t.Errorf("run %d: selector change before = %q", i, res.Changes[1].Before)
+		}
+	}
+}
 
-	f, err := os.Open(inPath)
+func TestNewEngineWithOrder(t *testing.T) {
+	eng, err := NewEngineWithOrder([]string{"iso-image-to-cdrom", " zone-all-normalize"})
 	if err != nil {
-		t.Fatalf("open input %s: %v", inPath, err)
+		t.Fatalf("unexpected error: %v", err)
 	}
-	defer f.Close()
 
-	sc := bufio.NewScanner(f)
-	// allow long lines
-	sc.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
+	names := eng.RuleNames()
+	if names[0] != "iso-image-to-cdrom" || names[1] != "zone-all-normalize" {
+		t.Errorf("listed rules should run first, got %v", names[:2])
+	}
+	if len(names) != len(DefaultRules()) {
+		t.Errorf("expected all %d rules, got %d", len(DefaultRules()), len(names))
+	}
+	if names[2] != "output-type-csv-tsv" {
+		t.Errorf("unlisted rules should keep default order, got %s at index 2", names[2])
+	}
 
-	eng := NewDefaultEngine()
-	var outLines []string
-	for sc.Scan() {
-		res := eng.Apply(sc.Text())
-		outLines = append(outLines, res.Line)
+	// Renaming first means the rename reason is the one recorded in the comment
+	res := eng.Apply("usacloud iso-image list --output-type tsv")
+	if !strings.Contains(res.Line, "usacloud cdrom list --output-type json") {
+		t.Errorf("unexpected line: %q", res.Line)
+	}
+	if !strings.Contains(res.Line, "v1ではリソース名がcdromに統一") {
+		t.Errorf("expected rename reason in comment, got %q", res.Line)
 	}
-	if err := sc.Err(); err != nil {
-		t.Fatalf("scan %s: %v", inPath, err)
+	if res.Changes[0].RuleName != "iso-image-to-cdrom" {
+		t.Errorf("expected rename to be applied first, got %s", res.Changes[0].RuleName)
+	}
+}
+
+func TestNewEngineWithOrder_Errors(t *testing.T) {
+	if _, err := NewEngineWithOrder([]string{"no-such-rule"}); err == nil || !strings.Contains(err.Error(), "output-type-csv-tsv") {
+		t.Errorf("expected unknown rule error listing available rules, got %v", err)
+	}
+	if _, err := NewEngineWithOrder([]string{"selector-to-arg", "selector-to-arg"}); err == nil {
+		t.Error("expected error for duplicated rule")
 	}
 
-	// Join with LF and ensure terminating newline
-	return strings.Join(append([]string{GeneratedHeader()}, outLines...), "\n") + "\n"
+	eng, err := NewEngineWithOrder(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(eng.RuleNames(), ",") != strings.Join(NewDefaultEngine().RuleNames(), ",") {
+		t.Error("empty order should match the default engine")
+	}
 }
 
-func TestGolden_SampleMixed(t *testing.T) {
-	inPath := "../../testdata/sample_v0_v1_mixed.sh"
-	wantPath := "../../testdata/expected_v1_1.sh"
+func TestMergeComments_Deduplicates(t *testing.T) {
+	first := mk("first", `foo`, func(m []string) string { return "bar" }, "same reason", "https://example.com/doc")
+	second := mk("second", `baz`, func(m []string) string { return "qux" }, "same reason", "https://example.com/doc")
+	third := mk("third", `qux`, func(m []string) string { return "quux" }, "other reason", "https://example.com/other")
 
-	got := applyFile(t, inPath)
+	eng := &Engine{rules: []Rule{first, second, third}}
+	res := eng.Apply("foo baz")
 
-	if *update {
-		if err := os.WriteFile(wantPath, []byte(got), 0o644); err != nil {
-			t.Fatalf("update golden %s: %v", wantPath, err)
-		}
-		return
+	want := "bar quux # usacloud-update: same reason (https://example.com/doc), other reason (https://example.com/other)"
+	if res.Line != want {
+		t.Errorf("got %q, want %q", res.Line, want)
+	}
+	if len(res.Changes) != 3 {
+		t.Errorf("expected every change to be recorded, got %d", len(res.Changes))
 	}
+}
 
-	wantBytes, err := os.ReadFile(wantPath)
+func TestMergeComments_KeepsExistingComment(t *testing.T) {
+	line := "usacloud iso-image list --output-type tsv # usacloud-update: manual note"
+	res := NewDefaultEngine().Apply(line)
+	if !strings.HasSuffix(res.Line, "# usacloud-update: manual note") {
+		t.Errorf("existing comment should be preserved, got %q", res.Line)
+	}
+}
+
+func TestWouldTransform_MatchesApply(t *testing.T) {
+	lines := []string{
+		"",
+		"# usacloud iso-image list",
+		"echo 'usacloud server list'",
+		"usacloud server list --output-type csv",
+		"usacloud disk read --selector name=mydisk",
+		"usacloud summary",
+		"usacloud server list --zone = all",
+		"usacloud server list",
+		"  usacloud product-disk list",
+		"sudo usacloud summary",
+		"env ZONE=tk1v usacloud server list",
+	}
+	pairs, err := goldenTesting.LoadGoldenPairs(goldenDir)
 	if err != nil {
-		t.Fatalf("open expected %s: %v", wantPath, err)
+		t.Fatal(err)
+	}
+	for _, pair := range pairs {
+		data, err := os.ReadFile(pair.InputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.Split(string(data), "\n")...)
+	}
+
+	eng := NewDefaultEngine()
+	for _, line := range lines {
+		if got, want := eng.WouldTransform(line), eng.Apply(line).Changed; got != want {
+			t.Errorf("WouldTransform(%q) = %v, Apply(...).Changed = %v", line, got, want)
+		}
+	}
+}
+
+func TestApply_WrappedInvocations(t *testing.T) {
+	eng := NewDefaultEngine().WithBinaryVariables(map[string]bool{"USACLOUD": true})
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"sudo usacloud iso-image list", "sudo usacloud cdrom list"},
+		{"  sudo -u deploy usacloud summary", "  # sudo -u deploy usacloud summary"},
+		{"env ZONE=tk1v /usr/local/bin/usacloud ojs list", "# env ZONE=tk1v /usr/local/bin/usacloud ojs list"},
+		{`"$USACLOUD" startup-script list`, `"$USACLOUD" note list`},
+		{"$DOCKER startup-script list", "$DOCKER startup-script list"},
+		{"sudo usacloud server list", "sudo usacloud server list"},
 	}
-	want := string(wantBytes)
 
-	if got != want {
-		t.Errorf("golden mismatch.\n--- want ---\n%s\n--- got ---\n%s", want, got)
+	for _, tt := range tests {
+		res := eng.Apply(tt.line)
+		if res.Original != tt.line || !strings.HasPrefix(res.Line, tt.want) || res.Changed != (tt.want != tt.line) {
+			t.Errorf("Apply(%q) = %+v, want line %q", tt.line, res, tt.want)
+		}
+		if got := eng.WouldTransform(tt.line); got != res.Changed {
+			t.Errorf("WouldTransform(%q) = %v, want %v", tt.line, got, res.Changed)
+		}
+	}
+
+	// Spans point into the wrapped line
+	spans := eng.Apply(`"$USACLOUD" iso-image list`).Diff().Spans
+	if len(spans) != 1 || spans[0].Before != "iso-image" || spans[0].After != "cdrom" || spans[0].OriginalStart != 12 {
+		t.Errorf("Unexpected spans %+v", spans)
+	}
+	spans = eng.Apply("  sudo usacloud summary").Diff().Spans
+	if len(spans) != 1 || spans[0].After != "# " || spans[0].OriginalStart != 2 || spans[0].TransformedStart != 2 {
+		t.Errorf("Unexpected spans of a commented-out line %+v", spans)
+	}
+}
+
+func TestApplyFile_BinaryVariables(t *testing.T) {
+	lines := []string{
+		"USACLOUD=${USACLOUD:-usacloud}",
+		"$USACLOUD iso-image list",
+		"$OTHER iso-image list",
+	}
+
+	results, _ := NewDefaultEngine().ApplyFile(lines)
+	if !strings.HasPrefix(results[1].Line, "$USACLOUD cdrom list # usacloud-update:") {
+		t.Errorf("Unexpected line %q", results[1].Line)
+	}
+	if results[0].Changed || results[2].Changed {
+		t.Errorf("Unexpected changes: %+v, %+v", results[0], results[2])
 	}
 }
@@ -0,0 +1,135 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RuleDescriber is implemented by rules that expose their documentation metadata
+type RuleDescriber interface {
+	Description() string
+	DocURL() string
+}
+
+// Description returns the reason written into the inline comment
+func (r *simpleRule) Description() string { return r.reason }
+
+// DocURL returns the documentation URL of the rule
+func (r *simpleRule) DocURL() string { return r.url }
+
+// Explanation は変換ルールの詳細な説明
+type Explanation struct {
+	RuleName    string
+	Description string
+	DocURL      string
+	Semantics   string // v0とv1の仕様の違い
+	Caveats     string // 注意事項
+}
+
+// ruleDetail はルールごとの補足説明
+type ruleDetail struct {
+	semantics string
+	caveats   string
+}
+
+// ruleDetails はルール名（または接頭辞）ごとの補足説明
+var ruleDetails = map[string]ruleDetail{
+	"output-type-csv-tsv": {
+		semantics: "v0では --output-type に csv/tsv を指定できましたが、v1.0以降は json/yaml/table のみ対応しています。",
+		caveats:   "出力をcut/awk等で列として処理している場合は、jq や --query を使った処理への書き換えが必要です。",
+	},
+	"selector-to-arg": {
+		semantics: "v0の --selector name=xxx などによるリソース指定は、v1ではID・名称・タグをコマンド引数として直接指定する方式に変わりました。",
+		caveats:   "複数のセレクタを組み合わせていた場合は、引数の指定で同じリソースが選択されるか確認してください。",
+	},
+	"iso-image-to-cdrom": {
+		semantics: "v0の iso-image コマンドは、v1では cdrom コマンドに名称変更されました。",
+		caveats:   "サブコマンドやオプション名も v1 の cdrom コマンドの仕様に合わせて確認してください。",
+	},
+	"startup-script-to-note": {
+		semantics: "v0の startup-script コマンドは、v1では note コマンドに統合されました。",
+		caveats:   "note にはスタートアップスクリプト以外の種類もあるため、クラス指定が必要な場合があります。",
+	},
+	"ipv4-to-ipaddress": {
+		semantics: "v0の ipv4 コマンドは、v1では ipaddress コマンドに整理されました。",
+		caveats:   "IPv6関連の操作は別コマンドのため、スクリプト内の該当箇所を個別に確認してください。",
+	},
+	"product-alias-": {
+		semantics: "v0の product-* コマンドは、v1では *-plan コマンドに名称が統一されました。",
+		caveats:   "プラン一覧の出力項目が変わっている場合があるため、出力を解析している処理を確認してください。",
+	},
+	"summary-removed": {
+		semantics: "summary コマンドはv1で廃止され、代替となる単一のコマンドはありません。",
+		caveats:   "自動変換できないためコメントアウトしています。bill/self/各list コマンドや rest コマンドで必要な情報を取得してください。",
+	},
+	"object-storage-removed-": {
+		semantics: "v1ではオブジェクトストレージの操作は非対応となりました。",
+		caveats:   "自動変換できないためコメントアウトしています。S3互換ツールやTerraformなどへの移行を検討してください。",
+	},
+	"output-type-yaml": {
+		semantics: "yaml 出力は v1 でも利用できますが、出力される項目名や構造が v0 から変わっています。v1 で受け付ける表記は yaml です。",
+		caveats:   "yq などで yaml 出力の項目を参照している場合は、v1 の出力に合わせて参照先を確認してください。",
+	},
+	"zone-all-normalize": {
+		semantics: "全ゾーンを対象にする指定は v1 でも --zone=all で利用できます。",
+		caveats:   "= の前後に空白があると正しく解釈されない場合があるため、記法を正規化しています。",
+	},
+	AssumeYesRuleName: {
+		semantics: "v1 の delete・shutdown・reset は実行前に確認を求め、-y (--assumeyes) を指定しない限り応答を待ちます。",
+		caveats:   "手動で実行した場合も確認なしで削除・停止されます。対話的に実行するスクリプトでは付与した -y を取り除いてください。",
+	},
+}
+
+// lookupRuleDetail returns the detail for a rule name, matching exact names first and then prefixes
+func lookupRuleDetail(name string) (ruleDetail, bool) {
+	if d, ok := ruleDetails[name]; ok {
+		return d, true
+	}
+	for key, d := range ruleDetails {
+		if strings.HasSuffix(key, "-") && strings.HasPrefix(name, key) {
+			return d, true
+		}
+	}
+	return ruleDetail{}, false
+}
+
+// Explain returns the explanation of the named rule
+func (e *Engine) Explain(ruleName string) (Explanation, bool) {
+	for _, r := range e.rules {
+		if r.Name() != ruleName {
+			continue
+		}
+
+		explanation := Explanation{RuleName: ruleName}
+		if d, ok := r.(RuleDescriber); ok {
+			explanation.Description = d.Description()
+			explanation.DocURL = d.DocURL()
+		}
+		if detail, ok := lookupRuleDetail(ruleName); ok {
+			explanation.Semantics = detail.semantics
+			explanation.Caveats = detail.caveats
+		}
+		return explanation, true
+	}
+
+	return Explanation{}, false
+}
+
+// Format returns the explanation as an indented paragraph
+func (ex Explanation) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "  [%s]\n", ex.RuleName)
+	if ex.Description != "" {
+		fmt.Fprintf(&b, "    理由: %s\n", ex.Description)
+	}
+	if ex.Semantics != "" {
+		fmt.Fprintf(&b, "    v0とv1の違い: %s\n", ex.Semantics)
+	}
+	if ex.Caveats != "" {
+		fmt.Fprintf(&b, "    注意点: %s\n", ex.Caveats)
+	}
+	if ex.DocURL != "" {
+		fmt.Fprintf(&b, "    参考: %s\n", ex.DocURL)
+	}
+	return b.String()
+}
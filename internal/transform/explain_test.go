@@ -0,0 +1,48 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEngine_Explain_AllDefaultRules(t *testing.T) {
+	engine := NewDefaultEngine()
+
+	for _, rule := range DefaultRules() {
+		explanation, ok := engine.Explain(rule.Name())
+		if !ok {
+			t.Errorf("Expected explanation for rule %s", rule.Name())
+			continue
+		}
+		if explanation.Description == "" || explanation.DocURL == "" {
+			t.Errorf("Rule %s: description and doc URL should be set, got %+v", rule.Name(), explanation)
+		}
+		if explanation.Semantics == "" || explanation.Caveats == "" {
+			t.Errorf("Rule %s: semantics and caveats should be set, got %+v", rule.Name(), explanation)
+		}
+	}
+}
+
+func TestEngine_Explain_UnknownRule(t *testing.T) {
+	engine := NewDefaultEngine()
+
+	if _, ok := engine.Explain("no-such-rule"); ok {
+		t.Error("Expected no explanation for unknown rule")
+	}
+}
+
+func TestExplanation_Format(t *testing.T) {
+	engine := NewDefaultEngine()
+
+	explanation, ok := engine.Explain("product-alias-product-disk")
+	if !ok {
+		t.Fatal("Expected explanation for product-alias-product-disk")
+	}
+
+	formatted := explanation.Format()
+	for _, expected := range []string{"[product-alias-product-disk]", "理由:", "v0とv1の違い:", "注意点:", "参考: https://"} {
+		if !strings.Contains(formatted, expected) {
+			t.Errorf("Expected formatted explanation to contain %q, got:\n%s", expected, formatted)
+		}
+	}
+}
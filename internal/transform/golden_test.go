@@ -0,0 +1,70 @@
+package transform
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goldenTesting "github.com/armaniacs/usacloud-update/internal/testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenDir holds input scripts (*.sh) next to their expected output (*.golden).
+// New cases are added by dropping in a script and running `make golden`.
+const goldenDir = "../../testdata/transform"
+
+// applyFile reads a bash script, applies eng line-by-line, and returns the
+// final output string with the generated header and trailing newline.
+func applyFile(t *testing.T, eng *Engine, inPath string) string {
+	t.Helper()
+
+	f, err := os.Open(inPath)
+	if err != nil {
+		t.Fatalf("open input %s: %v", inPath, err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	// allow long lines
+	sc.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
+
+	var outLines []string
+	for sc.Scan() {
+		res := eng.Apply(sc.Text())
+		outLines = append(outLines, res.Line)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("scan %s: %v", inPath, err)
+	}
+
+	// Join with LF and ensure terminating newline
+	return strings.Join(append([]string{GeneratedHeader()}, outLines...), "\n") + "\n"
+}
+
+func TestGolden(t *testing.T) {
+	pairs, err := goldenTesting.LoadGoldenPairs(goldenDir)
+	if err != nil {
+		t.Fatalf("load golden pairs: %v", err)
+	}
+
+	for _, pair := range pairs {
+		t.Run(pair.Name, func(t *testing.T) {
+			goldenTesting.CheckGolden(t, pair, applyFile(t, NewDefaultEngine(), pair.InputPath), *update)
+		})
+	}
+}
+
+// The opt-in add-assumeyes rule is checked against the destructive command
+// fixture of goldenDir, with its expected output kept apart
+func TestGolden_AssumeYes(t *testing.T) {
+	pair := goldenTesting.GoldenPair{
+		Name:       "destructive_commands",
+		InputPath:  filepath.Join(goldenDir, "destructive_commands.sh"),
+		GoldenPath: filepath.Join(goldenDir, "assumeyes", "destructive_commands"+goldenTesting.GoldenSuffix),
+	}
+	goldenTesting.CheckGolden(t, pair, applyFile(t, NewDefaultEngine().WithAssumeYes(), pair.InputPath), *update)
+}
@@ -0,0 +1,102 @@
+package transform
+
+import "strings"
+
+// heredoc is a here-document opened by a command, whose body runs until a
+// line consisting of the delimiter
+type heredoc struct {
+	delimiter string
+	stripTabs bool // <<- also accepts the delimiter after leading tabs
+}
+
+// closes reports whether line ends the body of h
+func (h heredoc) closes(line string) bool {
+	if h.stripTabs {
+		line = strings.TrimLeft(line, "\t")
+	}
+	return line == h.delimiter
+}
+
+// heredocsOpenedBy returns the here-documents a command opens with << or <<-,
+// in order. Here-strings (<<<), operators inside quotes or comments and the
+// shift operator of arithmetic expansions are ignored. Quotes and backslashes
+// in the delimiter word are removed, as the shell does.
+func heredocsOpenedBy(command string) []heredoc {
+	var opened []heredoc
+	var quote byte
+	arithmetic := 0
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			i++ // escaped character
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#' && (i == 0 || command[i-1] == ' ' || command[i-1] == '\t'):
+			return opened
+		case strings.HasPrefix(command[i:], "(("):
+			arithmetic++
+			i++
+		case strings.HasPrefix(command[i:], "))") && arithmetic > 0:
+			arithmetic--
+			i++
+		case strings.HasPrefix(command[i:], "<<<"):
+			i += 2
+		case strings.HasPrefix(command[i:], "<<") && arithmetic == 0:
+			h := heredoc{}
+			i += 2
+			if i < len(command) && command[i] == '-' {
+				h.stripTabs = true
+				i++
+			}
+			for i < len(command) && (command[i] == ' ' || command[i] == '\t') {
+				i++
+			}
+			var end int
+			h.delimiter, end = heredocWord(command[i:])
+			if h.delimiter == "" {
+				continue
+			}
+			opened = append(opened, h)
+			i += end - 1
+		}
+	}
+	return opened
+}
+
+// heredocWord reads the delimiter word at the start of s, returning it with
+// quotes and backslashes removed and the number of bytes it takes in s
+func heredocWord(s string) (string, int) {
+	var b strings.Builder
+	var quote byte
+	i := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				b.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case strings.IndexByte(" \t;|&<>()", c) >= 0:
+			return b.String(), i
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), i
+}
@@ -0,0 +1,61 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHeredocsOpenedBy(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []heredoc
+	}{
+		{`cat <<EOF`, []heredoc{{delimiter: "EOF"}}},
+		{`cat << EOF > out.sh`, []heredoc{{delimiter: "EOF"}}},
+		{`cat <<-END`, []heredoc{{delimiter: "END", stripTabs: true}}},
+		{`ssh host <<'EOF'`, []heredoc{{delimiter: "EOF"}}},
+		{`ssh host <<"E O F"`, []heredoc{{delimiter: "E O F"}}},
+		{`ssh host <<\EOF`, []heredoc{{delimiter: "EOF"}}},
+		{`cat <<A; cat <<-B`, []heredoc{{delimiter: "A"}, {delimiter: "B", stripTabs: true}}},
+		{`cat <<EOF|grep x`, []heredoc{{delimiter: "EOF"}}},
+		{`cat <<< "usacloud server list"`, nil}, // here-string
+		{`echo "<<EOF"`, nil},
+		{`echo '<<EOF'`, nil},
+		{`echo a # cat <<EOF`, nil},
+		{`echo $((1<<2))`, nil},
+		{`usacloud server list`, nil},
+	}
+
+	for _, tt := range tests {
+		if got := heredocsOpenedBy(tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("heredocsOpenedBy(%q) = %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestJoinContinuations_Heredoc(t *testing.T) {
+	lines := []string{
+		`ssh host <<EOF \`,
+		`  -T`,
+		`usacloud iso-image list \`,
+		`EOF`,
+		"cat <<-A; cat <<B",
+		"\tusacloud summary",
+		"\tA",
+		"B",
+		"usacloud iso-image list",
+	}
+
+	var heredoc []int
+	for _, l := range JoinContinuations(lines) {
+		if l.Heredoc {
+			heredoc = append(heredoc, l.Start)
+			if len(l.Parts) != 1 {
+				t.Errorf("expected heredoc line %d not to be joined, got %q", l.Start, l.Parts)
+			}
+		}
+	}
+	if want := []int{2, 3, 5, 6, 7}; !reflect.DeepEqual(heredoc, want) {
+		t.Errorf("heredoc lines = %v, want %v", heredoc, want)
+	}
+}
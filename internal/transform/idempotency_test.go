@@ -0,0 +1,61 @@
+package transform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Running the tool on its own output must be a no-op, so that pipelines can
+// run it repeatedly
+func TestRules_Idempotent(t *testing.T) {
+	extra := map[string][]string{
+		"output-type-csv-tsv": {"usacloud disk list -o tsv --zone=is1a"},
+		"selector-to-arg":     {"usacloud server delete --selector tag=web -y"},
+		"zone-all-normalize":  {"usacloud server list --zone=all", "usacloud disk list --zone =all -q"},
+		"output-type-yaml":    {"usacloud server list --output-type=yaml", `usacloud disk list -o "yml"`},
+	}
+
+	for _, r := range DefaultRules() {
+		inputs := append([]string{ruleExamples[r.Name()]}, extra[r.Name()]...)
+		for _, input := range inputs {
+			t.Run(r.Name()+"/"+input, func(t *testing.T) {
+				first, ok, _, _ := r.Apply(input)
+				if !ok {
+					t.Fatalf("expected the rule to apply to %q", input)
+				}
+				if second, ok, _, _ := r.Apply(first); ok || second != first {
+					t.Errorf("expected no change on the second run:\nfirst:  %s\nsecond: %s", first, second)
+				}
+
+				// The whole engine, with the other rules, is idempotent too
+				once := NewDefaultEngine().Apply(input)
+				if twice := NewDefaultEngine().Apply(once.Line); twice.Changed || twice.Line != once.Line {
+					t.Errorf("expected the engine to leave its output unchanged:\nonce:  %s\ntwice: %s (%v)", once.Line, twice.Line, twice.Changes)
+				}
+			})
+		}
+	}
+}
+
+func TestEngine_IdempotentOnGoldenInputs(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join(goldenDir, "*.sh"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no golden inputs found in %s: %v", goldenDir, err)
+	}
+
+	eng := NewDefaultEngine()
+	for _, path := range inputs {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, line := range strings.Split(string(content), "\n") {
+			once := eng.Apply(line)
+			if twice := eng.Apply(once.Line); twice.Changed || twice.Line != once.Line {
+				t.Errorf("%s:%d: second run changed %q to %q", filepath.Base(path), i+1, once.Line, twice.Line)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package transform
+
+import "strings"
+
+// PostProcessorRuleName is the rule name the changes of a post-processor are recorded under
+const PostProcessorRuleName = "post-processor"
+
+// PostProcessor rewrites a line after the rules: line is the input line and
+// res what the rules made of it. The returned line becomes the final line.
+type PostProcessor func(line string, res Result) string
+
+// SetPostProcessor sets a hook run on every line after the rules, for
+// organization-specific rewrites such as appending "|| true" to commands or
+// renaming zones. Blank and comment lines are not passed to it, and neither
+// are lines of ApplyReverse. When it returns a different line, the change is
+// recorded under PostProcessorRuleName. A nil hook removes it.
+func (e *Engine) SetPostProcessor(hook PostProcessor) {
+	e.postProcess = hook
+}
+
+// postProcessed runs the post-processor, if any, on the result of the rules for line
+func (e *Engine) postProcessed(line string, res Result) Result {
+	trim := strings.TrimSpace(line)
+	if e.postProcess == nil || trim == "" || strings.HasPrefix(trim, "#") {
+		return res
+	}
+
+	out := e.postProcess(line, res)
+	if out == res.Line {
+		return res
+	}
+
+	ed := detectEdit(res.Line, out)
+	res.Changes = append(res.Changes, Change{
+		RuleName: PostProcessorRuleName,
+		Before:   strings.TrimSpace(res.Line[ed.start:ed.end]),
+		After:    strings.TrimSpace(out[ed.start : ed.start+ed.length]),
+	})
+	res.Line = out
+	res.Changed = true
+	res.spans = nil // the hook's rewrite is not tracked; see locateSpans
+	res.Diagnostics = diagnose(res, e.rules)
+	return res
+}
@@ -0,0 +1,60 @@
+package transform
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// orTrue appends "|| true" to the usacloud commands the rules migrated
+func orTrue(line string, res Result) string {
+	if !res.Changed {
+		return res.Line
+	}
+	body, comment, _ := strings.Cut(res.Line, " "+commentMarker)
+	if comment != "" {
+		comment = " " + commentMarker + comment
+	}
+	return body + " || true" + comment
+}
+
+func TestSetPostProcessor(t *testing.T) {
+	eng := NewDefaultEngine()
+	eng.SetPostProcessor(orTrue)
+
+	res := eng.Apply("sudo usacloud iso-image list")
+	if !strings.HasPrefix(res.Line, "sudo usacloud cdrom list || true # usacloud-update:") {
+		t.Errorf("expected the hook to rewrite the final line, got %q", res.Line)
+	}
+	last := res.Changes[len(res.Changes)-1]
+	if len(res.Changes) != 2 || last.RuleName != PostProcessorRuleName || last.After != "|| true" {
+		t.Errorf("expected the hook's change to be recorded, got %+v", res.Changes)
+	}
+	if len(res.Diagnostics) != len(res.Changes) {
+		t.Errorf("expected a diagnostic per change, got %+v", res.Diagnostics)
+	}
+
+	for _, line := range []string{"usacloud server list", "# usacloud iso-image list", ""} {
+		if res := eng.Apply(line); res.Changed || res.Line != line {
+			t.Errorf("expected %q to be left alone, got %+v", line, res)
+		}
+	}
+
+	// The hook runs once, after every pass
+	if res := eng.ApplyPasses("usacloud iso-image list", 3); strings.Count(res.Line, "|| true") != 1 {
+		t.Errorf("expected a single post-processing, got %q", res.Line)
+	}
+
+	var out bytes.Buffer
+	if err := eng.Stream(strings.NewReader("usacloud iso-image list\n"), &out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "cdrom list || true") {
+		t.Errorf("expected Stream to post-process lines, got %q", out.String())
+	}
+
+	eng.SetPostProcessor(nil)
+	if res := eng.Apply("usacloud iso-image list"); strings.Contains(res.Line, "|| true") || len(res.Changes) != 1 {
+		t.Errorf("expected no post-processing after removing the hook, got %+v", res)
+	}
+}
@@ -0,0 +1,156 @@
+package transform
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SkippedRule is a rule ApplyReverse could not undo on a line
+type SkippedRule struct {
+	RuleName string
+	Reason   string
+}
+
+// reverseRule rewrites v1 syntax back to the v0 form a forward rule replaced
+type reverseRule struct {
+	re   *regexp.Regexp
+	repl func([]string) string
+}
+
+func (r *reverseRule) apply(line string) (string, bool, string, string) {
+	m := r.re.FindStringSubmatch(line)
+	if m == nil {
+		return line, false, "", ""
+	}
+	after := strings.Replace(line, m[0], r.repl(m), 1)
+	return after, true, strings.TrimSpace(m[0]), strings.TrimSpace(r.repl(m))
+}
+
+// irreversibleRule describes why a forward rule cannot be undone. detect, when
+// set, matches the lines the rule produced; otherwise the rule is recognized by
+// its reason in the inline comment.
+type irreversibleRule struct {
+	detect *regexp.Regexp
+	reason string
+}
+
+// reverseRules maps forward rule names to their inverse
+var reverseRules = buildReverseRules()
+
+// irreversibleRules maps forward rule names that lose information to the reason
+var irreversibleRules = buildIrreversibleRules()
+
+func buildReverseRules() map[string]*reverseRule {
+	rules := map[string]*reverseRule{
+		// json could have been csv or tsv; csv is chosen
+		"output-type-csv-tsv": {
+			re:   regexp.MustCompile(`(?i)\busacloud\s+[^\s]*\s+.*?(--output-type|\s-o)\s*=?\s*(json)\b`),
+			repl: func(m []string) string { return m[0][:len(m[0])-len(m[2])] + "csv" },
+		},
+	}
+
+	renames := map[string][2]string{
+		"iso-image-to-cdrom":     {"cdrom", "iso-image"},
+		"startup-script-to-note": {"note", "startup-script"},
+		"ipv4-to-ipaddress":      {"ipaddress", "ipv4"},
+	}
+	for _, pair := range [][2]string{{"product-disk", "disk-plan"}, {"product-internet", "internet-plan"}, {"product-server", "server-plan"}} {
+		renames["product-alias-"+pair[0]] = [2]string{pair[1], pair[0]}
+	}
+	for name, pair := range renames {
+		v1, v0 := pair[0], pair[1]
+		rules[name] = &reverseRule{
+			re:   regexp.MustCompile(`\busacloud\s+` + v1 + `\b`),
+			repl: func(m []string) string { return strings.Replace(m[0], v1, v0, 1) },
+		}
+	}
+
+	return rules
+}
+
+func buildIrreversibleRules() map[string]irreversibleRule {
+	rules := map[string]irreversibleRule{
+		"selector-to-arg": {
+			reason: "引数がどのセレクタ（name/id/tag）から移行されたかは記録されないため --selector を復元できません",
+		},
+		"summary-removed": {
+			detect: regexp.MustCompile(`^\s*#\s*usacloud\s+summary\b`),
+			reason: "コメントアウトされたsummaryコマンドはv1に対応するコマンドがないため元に戻せません",
+		},
+		"zone-all-normalize": {
+			reason: "--zone の = 前後の空白は記録されないため元の表記を復元できません（--zone=all はv0でも有効）",
+		},
+		"output-type-yaml": {
+			reason: "元の表記が yml だったかは記録されないため yaml のままにします（yaml はv0でも有効）",
+		},
+	}
+	for _, alias := range []string{"object-storage", "ojs"} {
+		rules["object-storage-removed-"+alias] = irreversibleRule{
+			detect: regexp.MustCompile(`^\s*#\s*usacloud\s+` + alias + `\b`),
+			reason: "コメントアウトされたオブジェクトストレージ操作はv1に対応するコマンドがないため元に戻せません",
+		}
+	}
+	return rules
+}
+
+// reverseReasonUnknown is reported for rules without a defined inverse, such as user rules
+const reverseReasonUnknown = "逆変換が定義されていないルールです"
+
+// ApplyReverse rewrites a v1 line toward v0 syntax by undoing the engine's
+// rules in reverse order. The result is lossy: rules that discard
+// information are not undone but listed in Result.Skipped when the line shows
+// signs of them (their inline comment, or a commented-out command). The
+// "# usacloud-update:" comment of a line that was changed is dropped, since
+// it describes the forward migration.
+func (e *Engine) ApplyReverse(line string) Result {
+	trim := strings.TrimSpace(line)
+	if trim == "" {
+		return Result{Original: line, Line: line}
+	}
+	if r, ok := e.unwrap(line, e.ApplyReverse); ok {
+		return r
+	}
+	isComment := strings.HasPrefix(trim, "#")
+
+	body, note := line, ""
+	if i := strings.Index(line, " "+commentMarker); i >= 0 {
+		body, note = line[:i], line[i+len(commentMarker)+1:]
+	}
+
+	var changes []Change
+	var skipped []SkippedRule
+	cur := body
+	for i := len(e.rules) - 1; i >= 0; i-- {
+		r := e.rules[i]
+		if rev, ok := reverseRules[r.Name()]; ok {
+			if isComment {
+				continue
+			}
+			after, ok, beforeFrag, afterFrag := rev.apply(cur)
+			if ok {
+				changes = append(changes, Change{RuleName: r.Name(), Before: beforeFrag, After: afterFrag})
+				cur = after
+			}
+			continue
+		}
+
+		irr, known := irreversibleRules[r.Name()]
+		if !known {
+			irr.reason = reverseReasonUnknown
+		}
+		var applied bool
+		if irr.detect != nil {
+			applied = irr.detect.MatchString(line)
+		} else if d, ok := r.(RuleDescriber); ok && note != "" {
+			applied = strings.Contains(note, d.Description())
+		}
+		if applied {
+			skipped = append(skipped, SkippedRule{RuleName: r.Name(), Reason: irr.reason})
+		}
+	}
+
+	if len(changes) == 0 {
+		return Result{Original: line, Line: line, Skipped: skipped}
+	}
+	return Result{Original: line, Line: strings.TrimRight(cur, " \t"), Changed: true, Changes: changes, Skipped: skipped}
+}
@@ -0,0 +1,117 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestApplyReverse(t *testing.T) {
+	eng := NewDefaultEngine()
+
+	tests := []struct {
+		name        string
+		line        string
+		want        string
+		wantRules   []string
+		wantSkipped []string
+	}{
+		{
+			name:      "rename and output type",
+			line:      "usacloud cdrom list --output-type=json",
+			want:      "usacloud iso-image list --output-type=csv",
+			wantRules: []string{"iso-image-to-cdrom", "output-type-csv-tsv"},
+		},
+		{
+			name:      "forward comment is dropped",
+			line:      forwardLine(t, eng, "usacloud startup-script list --output-type tsv"),
+			want:      "usacloud startup-script list --output-type csv",
+			wantRules: []string{"startup-script-to-note", "output-type-csv-tsv"},
+		},
+		{
+			name:      "product alias",
+			line:      "usacloud server-plan list",
+			want:      "usacloud product-server list",
+			wantRules: []string{"product-alias-product-server"},
+		},
+		{
+			name:        "selector is reported as skipped",
+			line:        forwardLine(t, eng, "usacloud ipv4 read --selector name=web"),
+			want:        "usacloud ipv4 read web",
+			wantRules:   []string{"ipv4-to-ipaddress"},
+			wantSkipped: []string{"selector-to-arg"},
+		},
+		{
+			name:        "commented-out summary",
+			line:        forwardLine(t, eng, "usacloud summary"),
+			wantSkipped: []string{"summary-removed"},
+		},
+		{
+			name:        "commented-out object storage without comment",
+			line:        "# usacloud ojs bucket list",
+			wantSkipped: []string{"object-storage-removed-ojs"},
+		},
+		{
+			name:        "yaml output type is kept",
+			line:        forwardLine(t, eng, "usacloud server list -o yml"),
+			wantSkipped: []string{"output-type-yaml"},
+		},
+		{
+			name: "unrelated line",
+			line: "echo cdrom json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := eng.ApplyReverse(tt.line)
+
+			want := tt.want
+			if want == "" {
+				want = tt.line
+			}
+			if res.Line != want {
+				t.Errorf("got %q, want %q", res.Line, want)
+			}
+			if res.Changed != (len(tt.wantRules) > 0) {
+				t.Errorf("Changed = %v", res.Changed)
+			}
+			if len(res.Changes) != len(tt.wantRules) {
+				t.Fatalf("got changes %+v, want rules %v", res.Changes, tt.wantRules)
+			}
+			for i, name := range tt.wantRules {
+				if res.Changes[i].RuleName != name {
+					t.Errorf("change %d rule = %s, want %s", i, res.Changes[i].RuleName, name)
+				}
+			}
+			if len(res.Skipped) != len(tt.wantSkipped) {
+				t.Fatalf("got skipped %+v, want %v", res.Skipped, tt.wantSkipped)
+			}
+			for i, name := range tt.wantSkipped {
+				if res.Skipped[i].RuleName != name || res.Skipped[i].Reason == "" {
+					t.Errorf("skipped %d = %+v, want %s with a reason", i, res.Skipped[i], name)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyReverse_UserRules(t *testing.T) {
+	path := writeRulesFile(t, "rules.yaml", "rules:\n  - {name: org-rule, match: 'tk1v', replace: 'is1a', comment: 'zone policy'}\n")
+	eng, err := NewEngineWithRules(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := eng.ApplyReverse(eng.Apply("usacloud disk list --zone tk1v").Line)
+	if len(res.Skipped) != 1 || res.Skipped[0].RuleName != "org-rule" || res.Skipped[0].Reason != reverseReasonUnknown {
+		t.Errorf("expected user rule to be reported as skipped, got %+v", res.Skipped)
+	}
+}
+
+func forwardLine(t *testing.T, eng *Engine, line string) string {
+	t.Helper()
+	res := eng.Apply(line)
+	if !res.Changed {
+		t.Fatalf("expected %q to be transformed", line)
+	}
+	return res.Line
+}
@@ -0,0 +1,96 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RuleInfo is the metadata of a rule, for documentation and editor integrations
+type RuleInfo struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`              // reason written into the inline comment
+	DocURL        string `json:"doc_url,omitempty"`        // documentation of the change
+	ExampleBefore string `json:"example_before,omitempty"` // a line the rule rewrites ("" when unknown)
+	ExampleAfter  string `json:"example_after,omitempty"`  // the line after the rule alone, without the inline comment
+	Reversible    bool   `json:"reversible"`               // ApplyReverse can undo the rule
+	Version       string `json:"version,omitempty"`        // usacloud version the rule migrates to ("" for every migration)
+}
+
+// ruleExamples are lines each built-in rule rewrites
+var ruleExamples = map[string]string{
+	"output-type-csv-tsv":                   "usacloud server list --output-type=csv",
+	"selector-to-arg":                       "usacloud server read --selector name=web",
+	"iso-image-to-cdrom":                    "usacloud iso-image list",
+	"startup-script-to-note":                "usacloud startup-script list",
+	"ipv4-to-ipaddress":                     "usacloud ipv4 list",
+	"product-alias-product-disk":            "usacloud product-disk list",
+	"product-alias-product-internet":        "usacloud product-internet list",
+	"product-alias-product-server":          "usacloud product-server list",
+	"summary-removed":                       "usacloud summary",
+	"object-storage-removed-object-storage": "usacloud object-storage list",
+	"object-storage-removed-ojs":            "usacloud ojs list",
+	"zone-all-normalize":                    "usacloud server list --zone = all",
+	"output-type-yaml":                      "usacloud server list --output-type yml",
+	AssumeYesRuleName:                       "usacloud server delete 123456789012",
+}
+
+// Rules returns the metadata of the engine's rules in application order.
+// The example after is what the rule alone makes of the example before.
+func (e *Engine) Rules() []RuleInfo {
+	infos := make([]RuleInfo, 0, len(e.rules))
+	for _, r := range e.rules {
+		info := RuleInfo{Name: r.Name()}
+		if d, ok := r.(RuleDescriber); ok {
+			info.Description = d.Description()
+			info.DocURL = d.DocURL()
+		}
+		if before, ok := ruleExamples[info.Name]; ok {
+			after, _, _, _ := r.Apply(before)
+			if i := strings.Index(after, " "+commentMarker); i >= 0 {
+				after = after[:i]
+			}
+			info.ExampleBefore, info.ExampleAfter = before, after
+		}
+		_, info.Reversible = reverseRules[info.Name]
+		info.Version = RuleVersion(r)
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// WriteRulesText writes the rule metadata as one indented block per rule
+func WriteRulesText(w io.Writer, infos []RuleInfo) error {
+	var b strings.Builder
+	for i, info := range infos {
+		reversible := "逆変換不可"
+		if info.Reversible {
+			reversible = "逆変換可"
+		}
+		fmt.Fprintf(&b, "%d. %s (%s)\n", i+1, info.Name, reversible)
+		if info.Version != "" {
+			fmt.Fprintf(&b, "   対象バージョン: %s\n", info.Version)
+		}
+		if info.Description != "" {
+			fmt.Fprintf(&b, "   説明: %s\n", info.Description)
+		}
+		if info.ExampleBefore != "" {
+			fmt.Fprintf(&b, "   変換前: %s\n", info.ExampleBefore)
+			fmt.Fprintf(&b, "   変換後: %s\n", info.ExampleAfter)
+		}
+		if info.DocURL != "" {
+			fmt.Fprintf(&b, "   参考: %s\n", info.DocURL)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteRulesJSON writes the rule metadata as an indented JSON array
+func WriteRulesJSON(w io.Writer, infos []RuleInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(infos)
+}
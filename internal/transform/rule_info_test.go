@@ -0,0 +1,76 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEngine_Rules(t *testing.T) {
+	eng := NewDefaultEngine()
+	infos := eng.Rules()
+
+	names := eng.RuleNames()
+	if len(infos) != len(names) {
+		t.Fatalf("Expected one entry per rule, got %d for %d rules", len(infos), len(names))
+	}
+	for i, info := range infos {
+		if info.Name != names[i] {
+			t.Errorf("entry %d = %q, want %q in application order", i, info.Name, names[i])
+		}
+		if info.Description == "" || info.DocURL == "" {
+			t.Errorf("%s: expected a description and documentation URL, got %+v", info.Name, info)
+		}
+		if info.ExampleBefore == "" || info.ExampleAfter == info.ExampleBefore {
+			t.Errorf("%s: expected an example the rule rewrites, got %+v", info.Name, info)
+		}
+		if strings.Contains(info.ExampleAfter, commentMarker) {
+			t.Errorf("%s: unexpected inline comment in %q", info.Name, info.ExampleAfter)
+		}
+		_, reversible := reverseRules[info.Name]
+		if info.Reversible != reversible {
+			t.Errorf("%s: reversible = %v, want %v", info.Name, info.Reversible, reversible)
+		}
+		if info.Version != "v1.0" {
+			t.Errorf("%s: version = %q, want v1.0", info.Name, info.Version)
+		}
+	}
+}
+
+func TestEngine_Rules_UserRule(t *testing.T) {
+	rule, err := newUserRule(RuleFileEntry{Name: "custom", Match: "foo", Replace: "bar", Comment: "custom reason"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos := (&Engine{rules: []Rule{rule}}).Rules()
+	if len(infos) != 1 || infos[0].Description != "custom reason" || infos[0].ExampleBefore != "" || infos[0].Reversible {
+		t.Errorf("Unexpected metadata of a user rule: %+v", infos)
+	}
+}
+
+func TestWriteRules(t *testing.T) {
+	infos := NewDefaultEngine().Rules()
+
+	var text bytes.Buffer
+	if err := WriteRulesText(&text, infos); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"1. output-type-csv-tsv (逆変換可)", "selector-to-arg (逆変換不可)", "変換前: usacloud iso-image list", "変換後: usacloud cdrom list"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, text.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WriteRulesJSON(&out, infos); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []RuleInfo
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(decoded) != len(infos) || decoded[0] != infos[0] {
+		t.Errorf("Unexpected JSON round trip: %+v", decoded)
+	}
+}
@@ -0,0 +1,140 @@
+package transform
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RuleSourceDefault marks rules whose status was not changed by any override
+const RuleSourceDefault = "default"
+
+// RuleOverride enables or disables rules from a single source such as the
+// config file or command line flags
+type RuleOverride struct {
+	Source  string
+	Only    []string // when set, every other rule is disabled
+	Enable  []string
+	Disable []string
+}
+
+// EngineOptions selects which of the default rules an engine runs. Rule names
+// are those returned by Engine.RuleNames.
+type EngineOptions struct {
+	Order        []string // rules to run first, as in NewEngineWithOrder
+	EnableOnly   []string // when set, only these rules run
+	DisableRules []string // rules to skip
+}
+
+// NewEngineWithOptions creates an engine running the default rules selected
+// by opts. Unknown rule names, and rules both enabled and disabled, are
+// rejected with the list of valid names.
+func NewEngineWithOptions(opts EngineOptions) (*Engine, error) {
+	effective, err := ResolveRules(opts.Order, RuleOverride{Source: "options", Only: opts.EnableOnly, Disable: opts.DisableRules})
+	if err != nil {
+		return nil, err
+	}
+	return NewEngineFromEffectiveRules(effective), nil
+}
+
+// EffectiveRule is a rule with its resolved status
+type EffectiveRule struct {
+	Name    string
+	Enabled bool
+	Source  string // where the status was decided
+
+	rule Rule
+}
+
+// ResolveRules returns the default rules in application order with every
+// override applied. Overrides are applied in the given sequence, so a later
+// source wins over an earlier one. Within a source, Only is applied first and
+// Enable adds to it. Naming a rule as both enabled (in Only or Enable) and
+// disabled by the same source is an error, as is naming an unknown rule.
+func ResolveRules(order []string, overrides ...RuleOverride) ([]EffectiveRule, error) {
+	rules, err := orderRules(DefaultRules(), order)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := make([]EffectiveRule, len(rules))
+	index := make(map[string]int, len(rules))
+	for i, r := range rules {
+		effective[i] = EffectiveRule{Name: r.Name(), Enabled: true, Source: RuleSourceDefault, rule: r}
+		index[r.Name()] = i
+	}
+
+	for _, o := range overrides {
+		enable := make(map[string]bool, len(o.Only)+len(o.Enable))
+		if only := trimNames(o.Only); len(only) > 0 {
+			for _, name := range only {
+				if _, ok := index[name]; !ok {
+					return nil, fmt.Errorf("%s: unknown rule %q (available: %s)", o.Source, name, strings.Join(ruleNames(rules), ", "))
+				}
+				enable[name] = true
+			}
+			for i := range effective {
+				effective[i].Enabled = enable[effective[i].Name]
+				effective[i].Source = o.Source
+			}
+		}
+		for _, name := range trimNames(o.Enable) {
+			if _, ok := index[name]; !ok {
+				return nil, fmt.Errorf("%s: unknown rule %q (available: %s)", o.Source, name, strings.Join(ruleNames(rules), ", "))
+			}
+			enable[name] = true
+			effective[index[name]].Enabled = true
+			effective[index[name]].Source = o.Source
+		}
+		for _, name := range trimNames(o.Disable) {
+			if _, ok := index[name]; !ok {
+				return nil, fmt.Errorf("%s: unknown rule %q (available: %s)", o.Source, name, strings.Join(ruleNames(rules), ", "))
+			}
+			if enable[name] {
+				return nil, fmt.Errorf("%s: rule %q is both enabled and disabled", o.Source, name)
+			}
+			effective[index[name]].Enabled = false
+			effective[index[name]].Source = o.Source
+		}
+	}
+
+	return effective, nil
+}
+
+// NewEngineFromEffectiveRules creates an engine running only the enabled rules
+func NewEngineFromEffectiveRules(effective []EffectiveRule) *Engine {
+	rules := make([]Rule, 0, len(effective))
+	for _, er := range effective {
+		if er.Enabled {
+			rules = append(rules, er.rule)
+		}
+	}
+	return &Engine{rules: rules}
+}
+
+// WriteEffectiveRules writes the resolved rule set as an ordered table
+func WriteEffectiveRules(w io.Writer, effective []EffectiveRule) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-3s %-32s %-8s %s\n", "#", "RULE", "STATUS", "SOURCE")
+	for i, er := range effective {
+		status := "enabled"
+		if !er.Enabled {
+			status = "disabled"
+		}
+		fmt.Fprintf(&b, "%-3d %-32s %-8s %s\n", i+1, er.Name, status, er.Source)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// trimNames drops surrounding spaces and empty entries from a name list
+func trimNames(names []string) []string {
+	trimmed := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			trimmed = append(trimmed, name)
+		}
+	}
+	return trimmed
+}
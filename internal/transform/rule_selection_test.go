@@ -0,0 +1,160 @@
+package transform
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func findEffective(t *testing.T, effective []EffectiveRule, name string) EffectiveRule {
+	t.Helper()
+	for _, er := range effective {
+		if er.Name == name {
+			return er
+		}
+	}
+	t.Fatalf("rule %s not found", name)
+	return EffectiveRule{}
+}
+
+func TestResolveRules_Defaults(t *testing.T) {
+	effective, err := ResolveRules(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(effective) != len(DefaultRules()) {
+		t.Fatalf("expected %d rules, got %d", len(DefaultRules()), len(effective))
+	}
+	for _, er := range effective {
+		if !er.Enabled || er.Source != RuleSourceDefault {
+			t.Errorf("expected %s to be enabled by default, got enabled=%v source=%s", er.Name, er.Enabled, er.Source)
+		}
+	}
+}
+
+func TestResolveRules_ConflictingSources(t *testing.T) {
+	effective, err := ResolveRules(
+		[]string{"iso-image-to-cdrom"},
+		RuleOverride{Source: "config", Disable: []string{"iso-image-to-cdrom", "selector-to-arg"}},
+		RuleOverride{Source: "--enable-rules", Enable: []string{" iso-image-to-cdrom"}},
+		RuleOverride{Source: "--disable-rules", Disable: []string{"zone-all-normalize"}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if effective[0].Name != "iso-image-to-cdrom" {
+		t.Errorf("expected rule order to be applied, got %s first", effective[0].Name)
+	}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		source  string
+	}{
+		{"iso-image-to-cdrom", true, "--enable-rules"},
+		{"selector-to-arg", false, "config"},
+		{"zone-all-normalize", false, "--disable-rules"},
+		{"output-type-csv-tsv", true, RuleSourceDefault},
+	}
+	for _, tt := range tests {
+		er := findEffective(t, effective, tt.name)
+		if er.Enabled != tt.enabled || er.Source != tt.source {
+			t.Errorf("%s: got enabled=%v source=%s, want enabled=%v source=%s", tt.name, er.Enabled, er.Source, tt.enabled, tt.source)
+		}
+	}
+
+	eng := NewEngineFromEffectiveRules(effective)
+	res := eng.Apply("usacloud server read --selector name=web --zone = all")
+	if strings.Contains(res.Line, "--zone=all") || !strings.Contains(res.Line, "--selector name=web") {
+		t.Errorf("disabled rules should not run, got %q", res.Line)
+	}
+	if len(eng.RuleNames()) != len(effective)-2 {
+		t.Errorf("expected %d enabled rules, got %d", len(effective)-2, len(eng.RuleNames()))
+	}
+}
+
+func TestResolveRules_Errors(t *testing.T) {
+	if _, err := ResolveRules(nil, RuleOverride{Source: "flag", Enable: []string{"selector-to-arg"}, Disable: []string{"selector-to-arg"}}); err == nil {
+		t.Error("expected error for a rule enabled and disabled by the same source")
+	}
+	if _, err := ResolveRules(nil, RuleOverride{Source: "config", Disable: []string{"no-such-rule"}}); err == nil || !strings.Contains(err.Error(), "config") {
+		t.Errorf("expected unknown rule error naming the source, got %v", err)
+	}
+	if _, err := ResolveRules([]string{"no-such-rule"}); err == nil {
+		t.Error("expected error for unknown rule in order")
+	}
+}
+
+func TestNewEngineWithOptions(t *testing.T) {
+	eng, err := NewEngineWithOptions(EngineOptions{EnableOnly: []string{"output-type-csv-tsv", "iso-image-to-cdrom"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := eng.RuleNames(); len(names) != 2 || names[0] != "output-type-csv-tsv" || names[1] != "iso-image-to-cdrom" {
+		t.Errorf("expected only the listed rules in default order, got %v", names)
+	}
+	if res := eng.Apply("usacloud iso-image list --selector name=foo"); !strings.Contains(res.Line, "--selector") {
+		t.Errorf("disabled rules should be skipped, got %q", res.Line)
+	}
+
+	eng, err = NewEngineWithOptions(EngineOptions{DisableRules: []string{"selector-to-arg", "summary-removed"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := eng.RuleNames(); len(names) != len(DefaultRules())-2 {
+		t.Errorf("expected two rules to be disabled, got %v", names)
+	}
+	if eng.WouldTransform("usacloud summary") {
+		t.Error("expected summary-removed to be skipped")
+	}
+
+	for _, opts := range []EngineOptions{
+		{DisableRules: []string{"selector-removal"}},
+		{EnableOnly: []string{"output-type-json"}},
+		{EnableOnly: []string{"selector-to-arg"}, DisableRules: []string{"selector-to-arg"}},
+	} {
+		if _, err := NewEngineWithOptions(opts); err == nil || (!strings.Contains(err.Error(), "available: output-type-csv-tsv") && !strings.Contains(err.Error(), "both enabled and disabled")) {
+			t.Errorf("expected error for %+v, got %v", opts, err)
+		}
+	}
+}
+
+func TestResolveRules_Only(t *testing.T) {
+	effective, err := ResolveRules(nil,
+		RuleOverride{Source: "config", Disable: []string{"iso-image-to-cdrom"}},
+		RuleOverride{Source: "command line", Only: []string{"selector-to-arg"}, Enable: []string{"iso-image-to-cdrom"}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, er := range effective {
+		want := er.Name == "selector-to-arg" || er.Name == "iso-image-to-cdrom"
+		if er.Enabled != want || er.Source != "command line" {
+			t.Errorf("%s: enabled = %v (%s), want %v", er.Name, er.Enabled, er.Source, want)
+		}
+	}
+}
+
+func TestWriteEffectiveRules(t *testing.T) {
+	effective, err := ResolveRules(nil, RuleOverride{Source: "config", Disable: []string{"summary-removed"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteEffectiveRules(&buf, effective); err != nil {
+		t.Fatalf("WriteEffectiveRules failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(effective)+1 {
+		t.Fatalf("expected header and %d rows, got %d lines", len(effective), len(lines))
+	}
+	if !strings.Contains(lines[1], "output-type-csv-tsv") || !strings.Contains(lines[1], "enabled") {
+		t.Errorf("unexpected first row: %q", lines[1])
+	}
+	if !strings.Contains(buf.String(), "summary-removed") || !strings.Contains(buf.String(), "disabled config") {
+		t.Errorf("expected disabled rule with its source, got:\n%s", buf.String())
+	}
+}
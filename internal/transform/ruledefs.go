@@ -2,15 +2,72 @@ package transform
 
 import "strings"
 
+// Versions the default rules migrate scripts between
+const (
+	MigrationSourceVersion = "v0"
+	MigrationTargetVersion = "v1.1"
+)
+
+// GeneratedHeader is the first line of scripts transformed for MigrationTargetVersion
 func GeneratedHeader() string {
-	return "# Updated for usacloud v1.1 by usacloud-update — DO NOT EDIT ABOVE THIS LINE"
+	return GeneratedHeaderFor(MigrationTargetVersion)
+}
+
+// The generated header around the target version
+const (
+	generatedHeaderPrefix = "# Updated for usacloud "
+	generatedHeaderSuffix = " by usacloud-update — DO NOT EDIT ABOVE THIS LINE"
+)
+
+// GeneratedHeaderFor is the first line of scripts transformed for the given usacloud version
+func GeneratedHeaderFor(targetVersion string) string {
+	return generatedHeaderPrefix + targetVersion + generatedHeaderSuffix
 }
 
+// IsGeneratedHeader reports whether line is the header of a transformed
+// script, for any target version, such as in a script transformed twice
+func IsGeneratedHeader(line string) bool {
+	line = strings.TrimRight(line, " \t\r")
+	return strings.HasPrefix(line, generatedHeaderPrefix) && strings.HasSuffix(line, generatedHeaderSuffix)
+}
+
+// ReverseHeader is the first line of scripts rewritten by Engine.ApplyReverse
+func ReverseHeader() string {
+	return "# Reverted toward usacloud " + MigrationSourceVersion + " syntax by usacloud-update — FOR REFERENCE ONLY, NOT A FAITHFUL RESTORE"
+}
+
+// RequiresManualReview reports whether a rule comments out a command that has
+// no v1 equivalent, leaving the migration of that line to the user
+func RequiresManualReview(ruleName string) bool {
+	return ruleName == "summary-removed" || strings.HasPrefix(ruleName, "object-storage-removed-")
+}
+
+// DefaultRules returns the built-in rules in their application order.
+//
+// Each rule rewrites the output of the previous one, and the engine lists the
+// reasons of all applied rules in this order in the trailing
+// "# usacloud-update:" comment. The order is:
+//
+//  1. output-type-csv-tsv
+//  2. selector-to-arg
+//  3. resource renames: iso-image, startup-script, ipv4, product-*
+//  4. summary-removed / object-storage-removed-* (whole-line comment-outs)
+//  5. zone-all-normalize
+//  6. output-type-yaml
+//
+// Every rule migrates to v1.0, where the syntax it rewrites stopped working;
+// see RulesOutsideVersions.
+//
+// The rules are idempotent: a line they have already rewritten and annotated
+// is left alone, so transforming the output again changes nothing.
+//
+// Use NewEngineWithOrder to move specific rules to the front.
 func DefaultRules() []Rule {
+	const v1 = "v1.0"
 	var rules []Rule
 
 	// 1) 出力タイプcsv/tsvの廃止 -> jsonへ (usacloud文脈に限定)
-	rules = append(rules, mk(
+	rules = append(rules, mkFor(v1,
 		"output-type-csv-tsv",
 		`(?i)\busacloud\s+[^\s]*\s+.*?(--output-type|\s-o)\s*=?\s*(csv|tsv)`,
 		func(m []string) string { return strings.Replace(m[0], m[2], "json", 1) },
@@ -19,9 +76,9 @@ func DefaultRules() []Rule {
 	))
 
 	// 2) --selector の廃止 -> 引数へ
-	rules = append(rules, mk(
+	rules = append(rules, mkFor(v1,
 		"selector-to-arg",
-		`--selector\s+([^\\s]+)`,
+		`--selector\s+([^\s]+)`,
 		func(m []string) string {
 			// サブケース: name=xxx / id=xxx / tag=xxx などの最右辺を引数へ移行
 			kv := m[1]
@@ -36,7 +93,7 @@ func DefaultRules() []Rule {
 	))
 
 	// 3) リソース名の変更: iso-image -> cdrom
-	rules = append(rules, mk(
+	rules = append(rules, mkFor(v1,
 		"iso-image-to-cdrom",
 		`\busacloud\s+iso-image\b`,
 		func(m []string) string { return strings.Replace(m[0], "iso-image", "cdrom", 1) },
@@ -45,7 +102,7 @@ func DefaultRules() []Rule {
 	))
 
 	// 4) リソース名の変更: startup-script -> note
-	rules = append(rules, mk(
+	rules = append(rules, mkFor(v1,
 		"startup-script-to-note",
 		`\busacloud\s+startup-script\b`,
 		func(m []string) string { return strings.Replace(m[0], "startup-script", "note", 1) },
@@ -54,7 +111,7 @@ func DefaultRules() []Rule {
 	))
 
 	// 5) リソース名の変更: ipv4 -> ipaddress
-	rules = append(rules, mk(
+	rules = append(rules, mkFor(v1,
 		"ipv4-to-ipaddress",
 		`\busacloud\s+ipv4\b`,
 		func(m []string) string { return strings.Replace(m[0], "ipv4", "ipaddress", 1) },
@@ -65,7 +122,7 @@ func DefaultRules() []Rule {
 	// 6) product-* -> *-plan (v0系の別名整理)
 	for _, pair := range [][2]string{{"product-disk", "disk-plan"}, {"product-internet", "internet-plan"}, {"product-server", "server-plan"}} {
 		old, new := pair[0], pair[1]
-		rules = append(rules, mk(
+		rules = append(rules, mkFor(v1,
 			"product-alias-"+old,
 			`\busacloud\s+`+old+`\b`,
 			func(m []string) string { return strings.Replace(m[0], old, new, 1) },
@@ -75,7 +132,7 @@ func DefaultRules() []Rule {
 	}
 
 	// 7) summary の廃止 -> コメントアウト(手動対応)
-	rules = append(rules, mk(
+	rules = append(rules, mkFor(v1,
 		"summary-removed",
 		`^\s*usacloud\s+summary\b.*$`,
 		func(m []string) string { return "# " + m[0] },
@@ -85,7 +142,7 @@ func DefaultRules() []Rule {
 
 	// 8) object-storageサブコマンドの非サポート(v1方針)
 	for _, alias := range []string{"object-storage", "ojs"} {
-		rules = append(rules, mk(
+		rules = append(rules, mkFor(v1,
 			"object-storage-removed-"+alias,
 			`^\s*usacloud\s+`+alias+`\b.*$`,
 			func(m []string) string { return "# " + m[0] },
@@ -95,7 +152,7 @@ func DefaultRules() []Rule {
 	}
 
 	// 9) --zone all の有効化: 変換は不要だが誤記修正(=の周辺空白) (usacloud文脈に限定)
-	rules = append(rules, mk(
+	rules = append(rules, mkFor(v1,
 		"zone-all-normalize",
 		`(\busacloud\s+[^\s]*\s+.*?)--zone\s*=\s*all`,
 		func(m []string) string {
@@ -105,5 +162,14 @@ func DefaultRules() []Rule {
 		"https://docs.usacloud.jp/usacloud/upgrade/v1_0_0/",
 	))
 
+	// 10) 出力タイプyaml/yml: v1でも利用可能だが出力項目が変わるため注記し、ymlはyamlに統一 (usacloud文脈に限定)
+	rules = append(rules, mkFor(v1,
+		"output-type-yaml",
+		`(?i)(\busacloud\s+[^\s]*\s+.*?(?:--output-type|\s-o)(?:\s*=\s*|\s+))(["']?)(yaml|yml)(["']?)(\s|$)`,
+		func(m []string) string { return m[1] + m[2] + "yaml" + m[4] + m[5] },
+		"v1のyaml出力は項目名が変わるため yq 等での処理を確認してください（ymlはyamlに統一）",
+		"https://docs.usacloud.jp/usacloud/upgrade/v1_0_0/",
+	))
+
 	return rules
 }
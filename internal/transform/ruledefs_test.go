@@ -29,6 +29,19 @@ func TestGeneratedHeader(t *testing.T) {
 	}
 }
 
+func TestIsGeneratedHeader(t *testing.T) {
+	for _, line := range []string{GeneratedHeader(), GeneratedHeaderFor("v1.0") + "\r"} {
+		if !IsGeneratedHeader(line) {
+			t.Errorf("Expected %q to be a generated header", line)
+		}
+	}
+	for _, line := range []string{ReverseHeader(), "# Updated for usacloud v1.1", "usacloud server list", ""} {
+		if IsGeneratedHeader(line) {
+			t.Errorf("Expected %q not to be a generated header", line)
+		}
+	}
+}
+
 func TestDefaultRulesNotEmpty(t *testing.T) {
 	rules := DefaultRules()
 
@@ -169,6 +182,24 @@ func TestSelectorToArgRule(t *testing.T) {
 	}
 }
 
+func TestSelectorToArgRule_StopsAtWhitespace(t *testing.T) {
+	eng := NewDefaultEngine()
+
+	// The value contains an "s" and is followed by the output-type rule's comment
+	res := eng.Apply("usacloud disk list --output-type csv --selector name=mydisk")
+	if !res.Changed {
+		t.Fatal("expected line to be changed")
+	}
+	for _, c := range res.Changes {
+		if c.RuleName == "selector-to-arg" && c.Before != "--selector name=mydisk" {
+			t.Errorf("selector change before = %q, want %q", c.Before, "--selector name=mydisk")
+		}
+	}
+	if !strings.Contains(res.Line, "usacloud disk list --output-type json mydisk #") {
+		t.Errorf("unexpected line: %q", res.Line)
+	}
+}
+
 func TestResourceNameChanges(t *testing.T) {
 	rules := DefaultRules()
 
@@ -435,6 +466,67 @@ func TestZoneAllNormalizeRule(t *testing.T) {
 	}
 }
 
+func TestOutputTypeYamlRule(t *testing.T) {
+	var rule Rule
+	for _, r := range DefaultRules() {
+		if r.Name() == "output-type-yaml" {
+			rule = r
+			break
+		}
+	}
+	if rule == nil {
+		t.Fatal("output-type-yaml rule not found")
+	}
+
+	testCases := []struct {
+		input        string
+		expected     string
+		shouldChange bool
+	}{
+		{"usacloud server list --output-type=yaml", "usacloud server list --output-type=yaml", true},
+		{"usacloud server list --output-type=yml", "usacloud server list --output-type=yaml", true},
+		{"usacloud server list --output-type yml", "usacloud server list --output-type yaml", true},
+		{"usacloud server list --output-type  yaml | yq .", "usacloud server list --output-type  yaml | yq .", true},
+		{"usacloud server list --output-type = yml", "usacloud server list --output-type = yaml", true},
+		{"usacloud server list -o yml", "usacloud server list -o yaml", true},
+		{"usacloud server list -o=yaml", "usacloud server list -o=yaml", true},
+		{`usacloud server list --output-type="yml"`, `usacloud server list --output-type="yaml"`, true},
+		{"usacloud server list --output-type 'yml' --zone tk1v", "usacloud server list --output-type 'yaml' --zone tk1v", true},
+		{"usacloud server list --output-type=YML", "usacloud server list --output-type=yaml", true},
+		{"usacloud server list --output-type=yamlx", "usacloud server list --output-type=yamlx", false},
+		{"usacloud server list --output-type=json", "usacloud server list --output-type=json", false},
+		{"yq --output-type=yaml", "yq --output-type=yaml", false}, // non-usacloud context
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			line, changed, before, after := rule.Apply(tc.input)
+
+			if changed != tc.shouldChange {
+				t.Fatalf("Expected changed=%v, got %v for input: %s", tc.shouldChange, changed, tc.input)
+			}
+			if !tc.shouldChange {
+				if line != tc.expected {
+					t.Errorf("Expected line '%s', got '%s'", tc.expected, line)
+				}
+				return
+			}
+			if !strings.HasPrefix(line, tc.expected+" # usacloud-update:") {
+				t.Errorf("Expected line to start with '%s' and the usacloud-update comment, got '%s'", tc.expected, line)
+			}
+			if before == "" || after == "" || !strings.Contains(after, "yaml") {
+				t.Errorf("Unexpected change fragments: %q => %q", before, after)
+			}
+		})
+	}
+
+	// The change is recorded by the engine even when the value is already yaml
+	result := NewDefaultEngine().Apply("usacloud server list --output-type yaml")
+	if !result.Changed || len(result.Changes) != 1 || result.Changes[0].RuleName != "output-type-yaml" {
+		t.Errorf("Expected a single output-type-yaml change, got %+v", result.Changes)
+	}
+}
+
 func TestRuleNamesUnique(t *testing.T) {
 	rules := DefaultRules()
 	nameMap := make(map[string]bool)
@@ -7,11 +7,12 @@ import (
 )
 
 type simpleRule struct {
-	name   string
-	re     *regexp.Regexp
-	repl   func([]string) string
-	reason string
-	url    string
+	name    string
+	re      *regexp.Regexp
+	repl    func([]string) string
+	reason  string
+	url     string
+	version string // usacloud version the rule migrates to ("" for every migration)
 }
 
 func (r *simpleRule) Name() string { return r.name }
@@ -26,6 +27,10 @@ func (r *simpleRule) Apply(line string) (string, bool, string, string) {
 	if !strings.Contains(after, "# usacloud-update:") {
 		after += comment
 	}
+	if after == line {
+		// Already rewritten and annotated, such as by an earlier run: nothing to do
+		return line, false, "", ""
+	}
 	beforeFrag := strings.TrimSpace(m[0])
 	afterFrag := strings.TrimSpace(r.repl(m))
 	return after, true, beforeFrag, afterFrag
@@ -35,3 +40,13 @@ func (r *simpleRule) Apply(line string) (string, bool, string, string) {
 func mk(name, pattern string, repl func([]string) string, reason, url string) Rule {
 	return &simpleRule{name: name, re: regexp.MustCompile(pattern), repl: repl, reason: reason, url: url}
 }
+
+// mkFor is mk for a rule migrating scripts to the given usacloud version
+func mkFor(version, name, pattern string, repl func([]string) string, reason, url string) Rule {
+	r := mk(name, pattern, repl, reason, url).(*simpleRule)
+	r.version = version
+	return r
+}
+
+// TargetVersion returns the usacloud version the rule migrates to
+func (r *simpleRule) TargetVersion() string { return r.version }
@@ -0,0 +1,132 @@
+package transform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleFileEntry is one user-defined rule in a rules file
+type RuleFileEntry struct {
+	Name    string `yaml:"name"`
+	Match   string `yaml:"match"`
+	Replace string `yaml:"replace"` // may refer to capture groups as $1 or ${name}
+	Comment string `yaml:"comment"`
+	URL     string `yaml:"url"` // optional, written after the comment
+}
+
+// RuleFile is the layout of a rules file. JSON files are accepted as well,
+// since JSON is valid YAML.
+type RuleFile struct {
+	Rules []RuleFileEntry `yaml:"rules"`
+}
+
+// NewEngineWithRules creates an engine running the default rules followed by
+// the user rules loaded from path
+func NewEngineWithRules(path string) (*Engine, error) {
+	userRules, err := LoadRules(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{rules: append(DefaultRules(), userRules...)}, nil
+}
+
+// LoadRules reads user-defined regex replacement rules from a YAML or JSON file.
+// Every pattern is compiled here so that mistakes are reported at load time.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules file: %w", err)
+	}
+
+	var file RuleFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("%s: no rules defined", path)
+	}
+
+	builtin := make(map[string]bool)
+	for _, name := range ruleNames(DefaultRules()) {
+		builtin[name] = true
+	}
+
+	rules := make([]Rule, 0, len(file.Rules))
+	seen := make(map[string]bool, len(file.Rules))
+	for i, entry := range file.Rules {
+		rule, err := newUserRule(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: rule #%d: %w", path, i+1, err)
+		}
+		if builtin[entry.Name] {
+			return nil, fmt.Errorf("%s: rule #%d: %q is the name of a built-in rule", path, i+1, entry.Name)
+		}
+		if seen[entry.Name] {
+			return nil, fmt.Errorf("%s: rule #%d: %q is defined more than once", path, i+1, entry.Name)
+		}
+		seen[entry.Name] = true
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// userRule replaces every match of a user-supplied pattern
+type userRule struct {
+	name        string
+	re          *regexp.Regexp
+	replacement string
+	reason      string
+	url         string
+}
+
+func newUserRule(entry RuleFileEntry) (*userRule, error) {
+	if strings.TrimSpace(entry.Name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if entry.Match == "" {
+		return nil, fmt.Errorf("%q: match is required", entry.Name)
+	}
+	if strings.TrimSpace(entry.Comment) == "" {
+		return nil, fmt.Errorf("%q: comment is required", entry.Name)
+	}
+	re, err := regexp.Compile(entry.Match)
+	if err != nil {
+		return nil, fmt.Errorf("%q: invalid match pattern: %w", entry.Name, err)
+	}
+	return &userRule{name: entry.Name, re: re, replacement: entry.Replace, reason: entry.Comment, url: entry.URL}, nil
+}
+
+func (r *userRule) Name() string { return r.name }
+
+// Description returns the comment written into the inline comment
+func (r *userRule) Description() string { return r.reason }
+
+// DocURL returns the documentation URL of the rule, if any
+func (r *userRule) DocURL() string { return r.url }
+
+func (r *userRule) Apply(line string) (string, bool, string, string) {
+	loc := r.re.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return line, false, "", ""
+	}
+	after := r.re.ReplaceAllString(line, r.replacement)
+	if after == line {
+		return line, false, "", ""
+	}
+	if !strings.Contains(after, commentMarker) {
+		after += " " + commentMarker + " " + describe(r)
+	}
+	beforeFrag := strings.TrimSpace(line[loc[0]:loc[1]])
+	afterFrag := strings.TrimSpace(string(r.re.ExpandString(nil, r.replacement, line, loc)))
+	return after, true, beforeFrag, afterFrag
+}
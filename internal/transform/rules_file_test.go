@@ -0,0 +1,160 @@
+package transform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRulesFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewEngineWithRules(t *testing.T) {
+	path := writeRulesFile(t, "rules.yaml", `
+rules:
+  - name: org-zone-default
+    match: '--zone=tk1v\b'
+    replace: '--zone=is1a'
+    comment: 'tk1v は社内標準の is1a に移行'
+  - name: org-tag-rename
+    match: '--tags (legacy-)?(\w+)'
+    replace: '--tags team-$2'
+    comment: 'タグ命名規則の変更'
+    url: 'https://example.com/tags'
+`)
+
+	eng, err := NewEngineWithRules(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := eng.RuleNames()
+	if len(names) != len(DefaultRules())+2 || names[len(names)-1] != "org-tag-rename" {
+		t.Fatalf("user rules should run after the built-in ones, got %v", names)
+	}
+
+	res := eng.Apply("usacloud server list --output-type csv --zone=tk1v --tags legacy-web")
+	want := "usacloud server list --output-type json --zone=is1a --tags team-web # usacloud-update: " +
+		"v1.0でcsv/tsvは廃止。jsonに置換し、必要なら --query/jq を利用してください (https://docs.usacloud.jp/usacloud/upgrade/v1_0_0/), " +
+		"tk1v は社内標準の is1a に移行, タグ命名規則の変更 (https://example.com/tags)"
+	if res.Line != want {
+		t.Errorf("got  %q\nwant %q", res.Line, want)
+	}
+
+	if len(res.Changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", res.Changes)
+	}
+	last := res.Changes[2]
+	if last.RuleName != "org-tag-rename" || last.Before != "--tags legacy-web" || last.After != "--tags team-web" {
+		t.Errorf("unexpected change: %+v", last)
+	}
+
+	// A user rule on its own line writes its own comment
+	res = eng.Apply("usacloud disk list --zone=tk1v")
+	if res.Line != "usacloud disk list --zone=is1a # usacloud-update: tk1v は社内標準の is1a に移行" {
+		t.Errorf("unexpected line: %q", res.Line)
+	}
+}
+
+func TestLoadRules_JSON(t *testing.T) {
+	path := writeRulesFile(t, "rules.json", `{"rules": [{"name": "drop-debug", "match": " --debug", "replace": "", "comment": "debug flag is not allowed in CI"}]}`)
+
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	line, ok, before, after := rules[0].Apply("usacloud server list --debug")
+	if !ok || line != "usacloud server list # usacloud-update: debug flag is not allowed in CI" || before != "--debug" || after != "" {
+		t.Errorf("unexpected result: %q %v %q %q", line, ok, before, after)
+	}
+
+	// A match that does not alter the line is not a change
+	if _, ok, _, _ := rules[0].Apply("usacloud server list"); ok {
+		t.Error("expected no change without a match")
+	}
+}
+
+func TestLoadRules_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"invalid regex", "rules:\n  - {name: bad, match: '(unclosed', comment: x}\n", `rule #1: "bad": invalid match pattern`},
+		{"missing name", "rules:\n  - {match: foo, comment: x}\n", "rule #1: name is required"},
+		{"missing match", "rules:\n  - {name: a, comment: x}\n", `"a": match is required`},
+		{"missing comment", "rules:\n  - {name: a, match: foo}\n", `"a": comment is required`},
+		{"duplicate", "rules:\n  - {name: a, match: foo, comment: x}\n  - {name: a, match: bar, comment: y}\n", `rule #2: "a" is defined more than once`},
+		{"built-in name", "rules:\n  - {name: selector-to-arg, match: foo, comment: x}\n", "built-in rule"},
+		{"unknown field", "rules:\n  - {name: a, match: foo, comment: x, replacement: y}\n", "replacement"},
+		{"empty", "", "no rules defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeRulesFile(t, "rules.yaml", tt.content)
+			_, err := NewEngineWithRules(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), path) {
+				t.Errorf("expected error containing %q and the path, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := LoadRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestApplyPasses_ChainedConversion(t *testing.T) {
+	// The user rule runs after iso-image-to-cdrom, so its result is only renamed in a second pass
+	path := writeRulesFile(t, "rules.yaml", "rules:\n  - {name: fix-underscore, match: 'usacloud iso_image', replace: 'usacloud iso-image', comment: 'typo'}\n")
+	eng, err := NewEngineWithRules(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	line := "usacloud iso_image list"
+
+	single := eng.ApplyPasses(line, 1)
+	if !strings.HasPrefix(single.Line, "usacloud iso-image list") {
+		t.Fatalf("single pass should match Apply, got %q", single.Line)
+	}
+
+	res := eng.ApplyPasses(line, 5)
+	if res.Line != "usacloud cdrom list # usacloud-update: typo, v1ではリソース名がcdromに統一 (https://manual.sakura.ad.jp/cloud-api/1.1/cdrom/index.html)" {
+		t.Errorf("expected converged output, got %q", res.Line)
+	}
+	if res.Original != line || len(res.Changes) != 2 || res.Changes[0].RuleName != "fix-underscore" || res.Changes[1].RuleName != "iso-image-to-cdrom" {
+		t.Errorf("expected changes of both passes, got %+v", res)
+	}
+	if d := res.Diff(); !d.Changed || d.Transformed != res.Line {
+		t.Errorf("unexpected diff: %+v", d)
+	}
+
+	// Already converged lines stay as they are
+	if again := eng.ApplyPasses(res.Line, 5); again.Line != res.Line {
+		t.Errorf("expected fixed point, got %q", again.Line)
+	}
+}
+
+func TestApplyPasses_LoopGuard(t *testing.T) {
+	// A rule that matches its own output never converges
+	path := writeRulesFile(t, "rules.yaml", "rules:\n  - {name: grow, match: '\\bping\\b', replace: 'ping ping', comment: 'grow'}\n")
+	eng, err := NewEngineWithRules(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := eng.ApplyPasses("usacloud server ping", 4)
+	if len(res.Changes) != 4 {
+		t.Errorf("expected the loop to stop after 4 passes, got %d changes", len(res.Changes))
+	}
+	if n := strings.Count(res.Line, "ping"); n != 16 {
+		t.Errorf("expected 16 pings after 4 passes, got %d in %q", n, res.Line)
+	}
+}
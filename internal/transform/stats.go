@@ -0,0 +1,90 @@
+package transform
+
+import "sort"
+
+// Stats aggregates the results of a batch of lines
+type Stats struct {
+	TotalLines   int            `json:"total_lines"`
+	ChangedLines int            `json:"changed_lines"`
+	RuleHits     map[string]int `json:"rule_hits"` // number of times each rule changed a line
+}
+
+// RuleHit is the number of times a rule was applied
+type RuleHit struct {
+	RuleName string
+	Count    int
+}
+
+// Add counts a single line's result
+func (s *Stats) Add(r Result) {
+	s.TotalLines++
+	if !r.Changed {
+		return
+	}
+	s.ChangedLines++
+	if s.RuleHits == nil {
+		s.RuleHits = make(map[string]int)
+	}
+	for _, c := range r.Changes {
+		s.RuleHits[c.RuleName]++
+	}
+}
+
+// Merge adds the counts of o, e.g. to total the stats of several files
+func (s *Stats) Merge(o Stats) {
+	s.TotalLines += o.TotalLines
+	s.ChangedLines += o.ChangedLines
+	for name, n := range o.RuleHits {
+		if s.RuleHits == nil {
+			s.RuleHits = make(map[string]int)
+		}
+		s.RuleHits[name] += n
+	}
+}
+
+// SortedRuleHits returns the rule hit counts, most applied first (ties by name)
+func (s Stats) SortedRuleHits() []RuleHit {
+	hits := make([]RuleHit, 0, len(s.RuleHits))
+	for name, n := range s.RuleHits {
+		hits = append(hits, RuleHit{RuleName: name, Count: n})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Count != hits[j].Count {
+			return hits[i].Count > hits[j].Count
+		}
+		return hits[i].RuleName < hits[j].RuleName
+	})
+	return hits
+}
+
+// ApplyFile applies the engine to every line and returns the per-line results,
+// in input order, together with their aggregate stats. Lines joined by
+// backslash continuations are transformed as one command; see LogicalLine.
+// Here-document bodies are data and are returned unchanged, as the CLI does
+// without --transform-heredocs. Commands run through a variable the file
+// assigns the usacloud binary to are transformed too; see
+// validation.BinaryVariables.
+func (e *Engine) ApplyFile(lines []string) ([]Result, Stats) {
+	return ApplyEach(lines, e.withScriptVariables(lines).Apply)
+}
+
+// ApplyEach is ApplyFile for another per-line function, such as ApplyReverse
+// or ApplyPasses with a fixed number of passes. Binary variables are not
+// detected; use Engine.WithBinaryVariables for the engine behind apply.
+func ApplyEach(lines []string, apply func(string) Result) ([]Result, Stats) {
+	results := make([]Result, 0, len(lines))
+	var stats Stats
+	for _, l := range JoinContinuations(lines) {
+		if l.Heredoc {
+			r := Result{Original: l.Text(), Line: l.Text()}
+			results = append(results, r)
+			stats.Add(r)
+			continue
+		}
+		for _, r := range l.Expand(apply(l.Text())) {
+			results = append(results, r)
+			stats.Add(r)
+		}
+	}
+	return results, stats
+}
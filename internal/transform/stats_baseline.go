@@ -0,0 +1,84 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Names of the totals compared by CompareStats alongside the rule names
+const (
+	StatsTotalLines   = "total_lines"
+	StatsChangedLines = "changed_lines"
+)
+
+// StatsDrift is a count that differs between a stats baseline and a current run
+type StatsDrift struct {
+	Name     string // StatsTotalLines, StatsChangedLines or a rule name
+	Baseline int
+	Current  int
+}
+
+// Change returns the relative change from the baseline, e.g. 0.25 for 25% more.
+// A count that was zero in the baseline is reported as +Inf.
+func (d StatsDrift) Change() float64 {
+	if d.Baseline == 0 {
+		return math.Inf(1)
+	}
+	return float64(d.Current-d.Baseline) / float64(d.Baseline)
+}
+
+// WriteStatsBaseline writes s as an indented JSON baseline
+func WriteStatsBaseline(w io.Writer, s Stats) error {
+	if s.RuleHits == nil {
+		s.RuleHits = map[string]int{}
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadStatsBaseline reads a baseline written by WriteStatsBaseline
+func ReadStatsBaseline(r io.Reader) (Stats, error) {
+	var s Stats
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return Stats{}, fmt.Errorf("invalid stats baseline: %w", err)
+	}
+	return s, nil
+}
+
+// CompareStats returns the counts of current that drift from baseline by more
+// than tolerance (a ratio, 0.1 allows 10% either way). Rules that appear in
+// only one of them are compared against zero, so a rule that stops firing or
+// starts firing always drifts. Totals come first, then rules by name.
+func CompareStats(baseline, current Stats, tolerance float64) []StatsDrift {
+	var drifts []StatsDrift
+	check := func(name string, base, cur int) {
+		if math.Abs(float64(cur-base)) > tolerance*float64(base) {
+			drifts = append(drifts, StatsDrift{Name: name, Baseline: base, Current: cur})
+		}
+	}
+
+	check(StatsTotalLines, baseline.TotalLines, current.TotalLines)
+	check(StatsChangedLines, baseline.ChangedLines, current.ChangedLines)
+
+	names := make([]string, 0, len(baseline.RuleHits)+len(current.RuleHits))
+	for name := range baseline.RuleHits {
+		names = append(names, name)
+	}
+	for name := range current.RuleHits {
+		if _, ok := baseline.RuleHits[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		check(name, baseline.RuleHits[name], current.RuleHits[name])
+	}
+	return drifts
+}
@@ -0,0 +1,143 @@
+package transform
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyFile(t *testing.T) {
+	lines := []string{
+		"#!/bin/bash",
+		"usacloud server list --output-type csv",
+		"usacloud iso-image list --output-type tsv",
+		"",
+		"usacloud disk list --output-type json",
+		"usacloud summary",
+	}
+
+	results, stats := NewDefaultEngine().ApplyFile(lines)
+	if len(results) != len(lines) {
+		t.Fatalf("expected %d results, got %d", len(lines), len(results))
+	}
+	for i, line := range lines {
+		if want := NewDefaultEngine().Apply(line); !reflect.DeepEqual(results[i], want) {
+			t.Errorf("line %d: ApplyFile result differs from Apply: %+v", i+1, results[i])
+		}
+	}
+
+	if stats.TotalLines != 6 || stats.ChangedLines != 3 {
+		t.Errorf("unexpected totals: %+v", stats)
+	}
+	want := []RuleHit{{"output-type-csv-tsv", 2}, {"iso-image-to-cdrom", 1}, {"summary-removed", 1}}
+	if got := stats.SortedRuleHits(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got rule hits %v, want %v", got, want)
+	}
+}
+
+func TestApplyFile_HeredocBodyUnchanged(t *testing.T) {
+	lines := []string{
+		"cat <<EOF",
+		"usacloud iso-image list",
+		"EOF",
+		"usacloud iso-image list",
+	}
+
+	results, stats := NewDefaultEngine().ApplyFile(lines)
+	if results[1].Changed || results[1].Line != lines[1] {
+		t.Errorf("expected the heredoc body to be unchanged, got %+v", results[1])
+	}
+	if !results[3].Changed || stats.TotalLines != 4 || stats.ChangedLines != 1 {
+		t.Errorf("expected only the command after the heredoc to change, got %+v", stats)
+	}
+}
+
+func TestStats_Merge(t *testing.T) {
+	eng := NewDefaultEngine()
+	_, a := eng.ApplyFile([]string{"usacloud server list --output-type csv"})
+	_, b := ApplyEach([]string{"usacloud cdrom list", "echo done"}, eng.ApplyReverse)
+
+	var total Stats
+	total.Merge(a)
+	total.Merge(b)
+	if total.TotalLines != 3 || total.ChangedLines != 2 || total.RuleHits["output-type-csv-tsv"] != 1 || total.RuleHits["iso-image-to-cdrom"] != 1 {
+		t.Errorf("unexpected merged stats: %+v", total)
+	}
+
+	var empty Stats
+	empty.Merge(Stats{TotalLines: 1})
+	if len(empty.SortedRuleHits()) != 0 {
+		t.Errorf("expected no rule hits, got %v", empty.SortedRuleHits())
+	}
+}
+
+// statsBaselinePath is the baseline of the rule hits over statsCorpus,
+// regenerated with `go test ./internal/transform -run TestStatsBaseline -update`
+const (
+	statsCorpus       = "../../testdata/inputs/sample_v0_v1_mixed.sh"
+	statsBaselinePath = "../../testdata/stats/sample_v0_v1_mixed.baseline.json"
+)
+
+func TestStatsBaseline(t *testing.T) {
+	data, err := os.ReadFile(statsCorpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	_, stats := NewDefaultEngine().ApplyFile(lines)
+
+	if *update {
+		var buf bytes.Buffer
+		if err := WriteStatsBaseline(&buf, stats); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(statsBaselinePath, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(statsBaselinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	baseline, err := ReadStatsBaseline(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drifts := CompareStats(baseline, stats, 0); len(drifts) != 0 {
+		t.Errorf("default engine drifted from the baseline: %+v", drifts)
+	}
+
+	// Dropping a rule makes it stop firing and lowers the changed line count
+	eng, err := NewEngineWithOptions(EngineOptions{DisableRules: []string{"iso-image-to-cdrom"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, drifting := eng.ApplyFile(lines)
+	drifts := CompareStats(baseline, drifting, 0)
+	want := []StatsDrift{
+		{Name: StatsChangedLines, Baseline: baseline.ChangedLines, Current: baseline.ChangedLines - 1},
+		{Name: "iso-image-to-cdrom", Baseline: 1, Current: 0},
+	}
+	if !reflect.DeepEqual(drifts, want) {
+		t.Errorf("got drifts %+v, want %+v", drifts, want)
+	}
+}
+
+func TestCompareStats_Tolerance(t *testing.T) {
+	baseline := Stats{TotalLines: 100, ChangedLines: 20, RuleHits: map[string]int{"a": 10, "b": 10}}
+	current := Stats{TotalLines: 100, ChangedLines: 22, RuleHits: map[string]int{"a": 12, "b": 10, "c": 1}}
+
+	if got := CompareStats(baseline, current, 0.2); !reflect.DeepEqual(got, []StatsDrift{{Name: "c", Baseline: 0, Current: 1}}) {
+		t.Errorf("a new rule should drift regardless of tolerance, got %+v", got)
+	}
+	if got := CompareStats(baseline, current, 0.1); len(got) != 2 || got[0].Name != "a" || got[0].Change() != 0.2 || got[1].Name != "c" {
+		t.Errorf("only counts beyond 10%% should drift, got %+v", got)
+	}
+	if got := CompareStats(current, current, 0); len(got) != 0 {
+		t.Errorf("identical stats should not drift: %+v", got)
+	}
+}
@@ -0,0 +1,274 @@
+package transform
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/armaniacs/usacloud-update/internal/validation"
+)
+
+// DefaultMaxLineSize is the longest line Stream accepts (1MB), the same limit
+// the CLI uses when it reads whole files
+const DefaultMaxLineSize = 1024 * 1024
+
+// Stream transforms r line by line into w, writing GeneratedHeader first.
+// Memory use is bounded by the longest line rather than the input size, so
+// it suits generated scripts too large to hold as a []string. Each change
+// is written to stats as it happens in the "#L<n> before => after [rule]"
+// format of --stats, or the one set with SetChangeFormat; stats may be nil.
+// Output lines end with "\n". Lines joined by backslash continuations are
+// held until the command ends and are transformed as one; see LogicalLine.
+// Here-document bodies are written unchanged, as by ApplyFile. A variable
+// assigned the usacloud binary is recognized in the commands after its
+// assignment.
+func (e *Engine) Stream(r io.Reader, w io.Writer, stats io.Writer) error {
+	return e.StreamWithMaxLineSize(r, w, stats, DefaultMaxLineSize)
+}
+
+// StreamWithMaxLineSize is Stream with a different limit on the line length.
+// A longer line, or a command continued over lines longer than that in
+// total, stops the stream with bufio.ErrTooLong.
+func (e *Engine) StreamWithMaxLineSize(r io.Reader, w io.Writer, stats io.Writer, maxLineSize int) error {
+	var hooks StreamHooks
+	if stats != nil {
+		hooks.Result = func(lineNumber int, result Result) error {
+			for _, c := range result.Changes {
+				line, err := e.changeFormat.Format(ChangeLine{Line: lineNumber, Before: c.Before, After: c.After, Rule: c.RuleName})
+				if err != nil {
+					return err
+				}
+				if _, err := fmt.Fprintln(stats, line); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return e.StreamWithHooks(r, w, maxLineSize, hooks)
+}
+
+// StreamHooks are called by StreamWithHooks as it writes the output. Nil
+// hooks are skipped.
+type StreamHooks struct {
+	// Result is called with each output line, numbered from 1, before it is
+	// written. Lines written unchanged come with a Result without changes,
+	// whose Line is empty for a line passed through by Oversized. An error
+	// stops the stream.
+	Result func(lineNumber int, result Result) error
+
+	// Oversized is called for a line longer than maxLineSize, or the first
+	// line of a command continued past maxLineSize bytes, with its size
+	// (here-document bodies are written unchanged anyway). The line, and the
+	// rest of its command, is written unchanged in pieces, so memory stays
+	// bounded. Without this hook such a line stops the stream with
+	// bufio.ErrTooLong.
+	Oversized func(lineNumber, size int)
+}
+
+// StreamWithHooks is StreamWithMaxLineSize reporting each output line and
+// each oversized line to hooks instead of writing the changes to a writer
+func (e *Engine) StreamWithHooks(r io.Reader, w io.Writer, maxLineSize int, hooks StreamHooks) error {
+	in := &lineReader{r: bufio.NewReaderSize(r, min(maxLineSize, 64*1024)), max: maxLineSize}
+
+	out := bufio.NewWriter(w)
+	if _, err := fmt.Fprintln(out, GeneratedHeaderFor(e.TargetVersion())); err != nil {
+		return err
+	}
+
+	eng := e.withScriptVariables(nil)
+	lineNumber := 0
+	var pending []string // physical lines of the command being continued
+	pendingSize := 0
+	passing := false     // the rest of the current command is written unchanged
+	var bodies []heredoc // here-documents still to be read, in order
+	write := func(n int, result Result) error {
+		if hooks.Result != nil {
+			if err := hooks.Result(n, result); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintln(out, result.Line)
+		return err
+	}
+	writeUnchanged := func(line string) error {
+		return write(lineNumber, Result{Original: line, Line: line})
+	}
+	flush := func() error {
+		l := LogicalLine{Start: lineNumber - len(pending), Parts: pending}
+		pending, pendingSize = nil, 0
+		bodies = heredocsOpenedBy(l.Text())
+		for name := range validation.BinaryVariables([]string{l.Text()}) {
+			eng.binaryVars[name] = true
+		}
+		for k, result := range l.Expand(eng.Apply(l.Text())) {
+			if err := write(l.Start+k+1, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// writePending writes the lines of the command being continued unchanged,
+	// the last of them being line end
+	writePending := func(end int) error {
+		start := end - len(pending)
+		for k, line := range pending {
+			if err := write(start+k+1, Result{Original: line, Line: line}); err != nil {
+				return err
+			}
+		}
+		pending, pendingSize = nil, 0
+		return nil
+	}
+
+	for {
+		line, tooLong, err := in.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber+1, err)
+		}
+		lineNumber++
+
+		if tooLong {
+			if hooks.Oversized == nil {
+				return fmt.Errorf("line %d: %w", lineNumber, bufio.ErrTooLong)
+			}
+			if err := writePending(lineNumber - 1); err != nil {
+				return err
+			}
+			if hooks.Result != nil {
+				if err := hooks.Result(lineNumber, Result{}); err != nil {
+					return err
+				}
+			}
+			size, continues, err := in.copyLine(out)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			if len(bodies) == 0 {
+				if !passing {
+					hooks.Oversized(lineNumber, size)
+				}
+				passing = continues
+			}
+			continue
+		}
+
+		text := string(line)
+		if len(bodies) > 0 {
+			if bodies[0].closes(text) {
+				bodies = bodies[1:]
+			}
+			if err := writeUnchanged(text); err != nil {
+				return err
+			}
+			continue
+		}
+		if passing {
+			passing = continuesOnNextLine(text)
+			if err := writeUnchanged(text); err != nil {
+				return err
+			}
+			continue
+		}
+
+		pending = append(pending, text)
+		pendingSize += len(text)
+		if continuesOnNextLine(text) {
+			if pendingSize > maxLineSize {
+				if hooks.Oversized == nil {
+					return fmt.Errorf("line %d: %w", lineNumber, bufio.ErrTooLong)
+				}
+				hooks.Oversized(lineNumber-len(pending)+1, pendingSize)
+				if err := writePending(lineNumber); err != nil {
+					return err
+				}
+				passing = true
+			}
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	if len(pending) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	return out.Flush()
+}
+
+// lineReader reads the lines of a stream, holding at most about max bytes of
+// a line
+type lineReader struct {
+	r    *bufio.Reader
+	max  int
+	line []byte
+	rest bool // the line returned by next continues in r
+}
+
+// next returns the next line without its line ending, or io.EOF after the
+// last one. For a line longer than max it returns tooLong with the part read
+// so far, and copyLine must be called to write the line.
+func (lr *lineReader) next() (line []byte, tooLong bool, err error) {
+	lr.line, lr.rest = lr.line[:0], false
+	for {
+		chunk, err := lr.r.ReadSlice('\n')
+		lr.line = append(lr.line, chunk...)
+		switch {
+		case err == bufio.ErrBufferFull:
+			if len(bytes.TrimSuffix(lr.line, []byte("\r"))) > lr.max {
+				lr.rest = true
+				return lr.line, true, nil
+			}
+			continue
+		case err == io.EOF:
+			if len(lr.line) == 0 {
+				return nil, false, io.EOF
+			}
+		case err != nil:
+			return nil, false, err
+		}
+
+		// Like bufio.ScanLines, drop the line ending and a carriage return before it
+		lr.line = bytes.TrimSuffix(bytes.TrimSuffix(lr.line, []byte("\n")), []byte("\r"))
+		return lr.line, len(lr.line) > lr.max, nil
+	}
+}
+
+// copyLine writes the line next returned as tooLong to w, followed by "\n",
+// and returns its size and whether it ends with a continuation backslash
+func (lr *lineReader) copyLine(w io.Writer) (size int, continues bool, err error) {
+	chunk := lr.line
+	for {
+		last := !lr.rest
+		if last {
+			chunk = bytes.TrimSuffix(bytes.TrimSuffix(chunk, []byte("\n")), []byte("\r"))
+		}
+		if len(chunk) > 0 {
+			continues = chunk[len(chunk)-1] == '\\'
+		}
+		size += len(chunk)
+		if _, err := w.Write(chunk); err != nil {
+			return size, false, err
+		}
+		if last {
+			_, err := io.WriteString(w, "\n")
+			return size, continues, err
+		}
+
+		chunk, err = lr.r.ReadSlice('\n')
+		switch {
+		case err == bufio.ErrBufferFull:
+		case err == nil || err == io.EOF:
+			lr.rest = false
+		default:
+			return size, false, err
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package transform
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStream_MatchesApply(t *testing.T) {
+	input := "#!/bin/bash\nusacloud server list --output-type csv\n\nusacloud iso-image list\r\nusacloud disk list"
+	eng := NewDefaultEngine()
+
+	var out, stats bytes.Buffer
+	if err := eng.Stream(strings.NewReader(input), &out, &stats); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	want := []string{GeneratedHeader()}
+	var wantStats string
+	for i, line := range strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n") {
+		result := eng.Apply(line)
+		want = append(want, result.Line)
+		for _, c := range result.Changes {
+			wantStats += fmt.Sprintf("#L%-5d %s => %s [%s]\n", i+1, c.Before, c.After, c.RuleName)
+		}
+	}
+	if got := out.String(); got != strings.Join(want, "\n")+"\n" {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+	if stats.String() != wantStats {
+		t.Errorf("got stats:\n%s\nwant:\n%s", stats.String(), wantStats)
+	}
+}
+
+func TestStream_BinaryVariables(t *testing.T) {
+	// The variable is only known from its assignment on
+	input := "$UC iso-image list\nUC=/usr/bin/usacloud\n$UC iso-image list"
+
+	var out bytes.Buffer
+	if err := NewDefaultEngine().Stream(strings.NewReader(input), &out, nil); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if lines[1] != "$UC iso-image list" || !strings.HasPrefix(lines[3], "$UC cdrom list # usacloud-update:") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestStream_NilStatsAndEmptyInput(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewDefaultEngine().Stream(strings.NewReader(""), &out, nil); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if out.String() != GeneratedHeader()+"\n" {
+		t.Errorf("expected only the header, got %q", out.String())
+	}
+}
+
+func TestStreamWithMaxLineSize(t *testing.T) {
+	long := "usacloud server list --name " + strings.Repeat("x", 200)
+	input := "usacloud server list\n" + long + "\n"
+	eng := NewDefaultEngine()
+
+	err := eng.StreamWithMaxLineSize(strings.NewReader(input), io.Discard, nil, 100)
+	if !errors.Is(err, bufio.ErrTooLong) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected ErrTooLong on line 2, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := eng.StreamWithMaxLineSize(strings.NewReader(input), &out, nil, 1024); err != nil {
+		t.Fatalf("StreamWithMaxLineSize() error = %v", err)
+	}
+	if !strings.Contains(out.String(), long) {
+		t.Error("expected the long line in the output")
+	}
+}
+
+func TestStreamWithMaxLineSize_LongContinuedCommand(t *testing.T) {
+	input := "usacloud server list \\\n" + strings.Repeat("  --name web \\\n", 20) + "  --zone tk1v\n"
+
+	err := NewDefaultEngine().StreamWithMaxLineSize(strings.NewReader(input), io.Discard, nil, 100)
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("expected ErrTooLong for a command continued past the limit, got %v", err)
+	}
+}
+
+func TestStreamWithHooks_Oversized(t *testing.T) {
+	long := "usacloud iso-image list --name " + strings.Repeat("x", 200)
+	continued := "usacloud iso-image list \\\n" + strings.Repeat("  --name web \\\n", 20) + "  --zone tk1v"
+	input := "usacloud iso-image list\n" + long + "\r\n" + continued + "\nusacloud iso-image list\n"
+
+	var out bytes.Buffer
+	var oversized []int
+	var stats Stats
+	hooks := StreamHooks{
+		Result: func(lineNumber int, result Result) error {
+			stats.Add(result)
+			return nil
+		},
+		Oversized: func(lineNumber, size int) {
+			oversized = append(oversized, lineNumber)
+		},
+	}
+	if err := NewDefaultEngine().StreamWithHooks(strings.NewReader(input), &out, 100, hooks); err != nil {
+		t.Fatalf("StreamWithHooks() error = %v", err)
+	}
+
+	want := GeneratedHeader() + "\n" + strings.Join([]string{
+		NewDefaultEngine().Apply("usacloud iso-image list").Line,
+		long,
+		continued,
+		NewDefaultEngine().Apply("usacloud iso-image list").Line,
+	}, "\n") + "\n"
+	if out.String() != want {
+		t.Errorf("oversized lines should be written unchanged:\ngot:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if len(oversized) != 2 || oversized[0] != 2 || oversized[1] != 3 {
+		t.Errorf("expected oversized lines 2 and 3, got %v", oversized)
+	}
+	if stats.TotalLines != 25 || stats.ChangedLines != 2 {
+		t.Errorf("expected 25 lines with 2 changed, got %+v", stats)
+	}
+}
+
+// repeatReader yields the same line n times without holding the whole input
+type repeatReader struct {
+	line    []byte
+	n       int
+	pending []byte
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		if r.n == 0 {
+			return 0, io.EOF
+		}
+		r.n--
+		r.pending = r.line
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// countingWriter counts lines without keeping them
+type countingWriter struct{ lines int }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.lines += bytes.Count(p, []byte("\n"))
+	return len(p), nil
+}
+
+func TestStream_LargeInput(t *testing.T) {
+	const n = 20000
+	r := &repeatReader{line: []byte("usacloud iso-image list --output-type csv\n"), n: n}
+	out, stats := &countingWriter{}, &countingWriter{}
+	if err := NewDefaultEngine().Stream(r, out, stats); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if out.lines != n+1 {
+		t.Errorf("expected %d output lines, got %d", n+1, out.lines)
+	}
+	if stats.lines != 2*n {
+		t.Errorf("expected %d stats lines, got %d", 2*n, stats.lines)
+	}
+}
+
+func TestStream_HeredocBodyUnchanged(t *testing.T) {
+	input := "cat <<EOF\nusacloud iso-image list\nEOF\nusacloud iso-image list"
+
+	var out, stats bytes.Buffer
+	if err := NewDefaultEngine().Stream(strings.NewReader(input), &out, &stats); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if lines[2] != "usacloud iso-image list" || !strings.HasPrefix(lines[4], "usacloud cdrom list") {
+		t.Errorf("expected only the command after the heredoc to change:\n%s", out.String())
+	}
+	if !strings.HasPrefix(stats.String(), "#L4 ") || strings.Count(stats.String(), "\n") != 1 {
+		t.Errorf("expected one change on line 4, got:\n%s", stats.String())
+	}
+}
@@ -0,0 +1,97 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MigrationVersions are the usacloud versions scripts can be migrated
+// between, oldest first
+var MigrationVersions = []string{MigrationSourceVersion, "v1.0", MigrationTargetVersion}
+
+// NormalizeVersion returns the entry of MigrationVersions version names,
+// accepting it without the leading "v" (such as "1.0")
+func NormalizeVersion(version string) (string, error) {
+	normalized := "v" + strings.TrimPrefix(strings.TrimSpace(version), "v")
+	for _, v := range MigrationVersions {
+		if v == normalized {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unknown usacloud version %q (available: %s)", version, strings.Join(MigrationVersions, ", "))
+}
+
+// versionIndex returns the position of a normalized version in MigrationVersions
+func versionIndex(version string) int {
+	for i, v := range MigrationVersions {
+		if v == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// RuleVersion returns the usacloud version a rule migrates to, or "" for
+// rules that apply to every migration, such as user rules
+func RuleVersion(r Rule) string {
+	if v, ok := r.(interface{ TargetVersion() string }); ok {
+		return v.TargetVersion()
+	}
+	return ""
+}
+
+// RulesOutsideVersions returns the names of the default rules that do not
+// belong to a migration from since to target: the rules for a version the
+// script already runs on (since or older) and those for a version newer than
+// target. Disabling them keeps a partially migrated script from being
+// "fixed" again.
+func RulesOutsideVersions(since, target string) ([]string, error) {
+	from, err := NormalizeVersion(since)
+	if err != nil {
+		return nil, err
+	}
+	to, err := NormalizeVersion(target)
+	if err != nil {
+		return nil, err
+	}
+	if versionIndex(from) >= versionIndex(to) {
+		return nil, fmt.Errorf("the version migrated from (%s) must be older than the target version (%s)", from, to)
+	}
+
+	var names []string
+	for _, r := range DefaultRules() {
+		v := RuleVersion(r)
+		if v == "" {
+			continue
+		}
+		if i := versionIndex(v); i <= versionIndex(from) || i > versionIndex(to) {
+			names = append(names, r.Name())
+		}
+	}
+	return names, nil
+}
+
+// WithVersions returns a copy of the engine that reports migrating scripts
+// from since to target, such as in its GeneratedHeaderFor. The rules are not
+// changed; select them with RulesOutsideVersions.
+func (e *Engine) WithVersions(since, target string) *Engine {
+	c := *e
+	c.sourceVersion, c.targetVersion = since, target
+	return &c
+}
+
+// SourceVersion returns the usacloud version the engine migrates scripts from
+func (e *Engine) SourceVersion() string {
+	if e.sourceVersion == "" {
+		return MigrationSourceVersion
+	}
+	return e.sourceVersion
+}
+
+// TargetVersion returns the usacloud version the engine migrates scripts to
+func (e *Engine) TargetVersion() string {
+	if e.targetVersion == "" {
+		return MigrationTargetVersion
+	}
+	return e.targetVersion
+}
@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeVersion(t *testing.T) {
+	for input, want := range map[string]string{"v0": "v0", "1.0": "v1.0", " v1.1 ": "v1.1"} {
+		got, err := NormalizeVersion(input)
+		if err != nil || got != want {
+			t.Errorf("NormalizeVersion(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := NormalizeVersion("v2.0"); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+}
+
+func TestRulesOutsideVersions(t *testing.T) {
+	outside, err := RulesOutsideVersions("v0", "v1.1")
+	if err != nil || len(outside) != 0 {
+		t.Errorf("expected every rule for v0 -> v1.1, got %v (%v)", outside, err)
+	}
+
+	// Every default rule migrates to v1.0, so a v1.0 script needs none of them
+	outside, err = RulesOutsideVersions("1.0", "1.1")
+	if err != nil || len(outside) != len(DefaultRules()) {
+		t.Errorf("expected all %d rules outside v1.0 -> v1.1, got %v (%v)", len(DefaultRules()), outside, err)
+	}
+
+	for _, pair := range [][2]string{{"v1.1", "v1.0"}, {"v1.0", "v1.0"}, {"v0", "v9"}} {
+		if _, err := RulesOutsideVersions(pair[0], pair[1]); err == nil {
+			t.Errorf("expected an error for %s -> %s", pair[0], pair[1])
+		}
+	}
+}
+
+func TestEngine_WithVersions(t *testing.T) {
+	eng := NewDefaultEngine()
+	if eng.SourceVersion() != MigrationSourceVersion || eng.TargetVersion() != MigrationTargetVersion {
+		t.Errorf("unexpected default versions: %s -> %s", eng.SourceVersion(), eng.TargetVersion())
+	}
+
+	versioned := eng.WithVersions("v0", "v1.0")
+	if versioned.TargetVersion() != "v1.0" || eng.TargetVersion() != MigrationTargetVersion {
+		t.Errorf("expected only the copy to target v1.0, got %s and %s", versioned.TargetVersion(), eng.TargetVersion())
+	}
+
+	var out bytes.Buffer
+	if err := versioned.Stream(strings.NewReader("usacloud server list\n"), &out, nil); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), GeneratedHeaderFor("v1.0")) {
+		t.Errorf("expected the header for v1.0, got %q", out.String())
+	}
+}
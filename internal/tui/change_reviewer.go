@@ -0,0 +1,366 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/armaniacs/usacloud-update/internal/transform"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// reviewItem is one change offered for review: the physical lines of one
+// command, which are accepted or rejected together
+type reviewItem struct {
+	start, end int // range of the command's lines in the results
+	accepted   bool
+}
+
+// ChangeReviewer represents a TUI for reviewing the changes of a transformed
+// script before they are written. Every change starts accepted; a rejected
+// change keeps the original lines in the output.
+type ChangeReviewer struct {
+	app     *tview.Application
+	results []transform.Result
+	items   []reviewItem
+
+	// UI components
+	changeList    *tview.List
+	beforePane    *tview.TextView
+	afterPane     *tview.TextView
+	statusBar     *tview.TextView
+	helpText      *tview.TextView
+	previewNotice *tview.TextView
+	mainGrid      *tview.Grid
+
+	// State
+	helpVisible bool
+
+	// Callbacks
+	onApply  func([]transform.Result)
+	onCancel func()
+}
+
+// NewChangeReviewer creates a reviewer for the per-line results of
+// transforming lines, such as those of Engine.ApplyFile. Lines joined by
+// backslash continuations are reviewed as one change.
+func NewChangeReviewer(lines []string, results []transform.Result) *ChangeReviewer {
+	cr := &ChangeReviewer{
+		app:         tview.NewApplication(),
+		results:     results,
+		helpVisible: true,
+	}
+
+	for _, l := range transform.JoinContinuations(lines) {
+		item := reviewItem{start: l.Start, end: l.Start + len(l.Parts), accepted: true}
+		if item.end > len(results) {
+			break
+		}
+		for _, r := range results[item.start:item.end] {
+			if r.Changed {
+				cr.items = append(cr.items, item)
+				break
+			}
+		}
+	}
+
+	cr.setupUI()
+	return cr
+}
+
+// SetOnApply sets the callback for when the user applies the accepted changes
+func (cr *ChangeReviewer) SetOnApply(callback func([]transform.Result)) {
+	cr.onApply = callback
+}
+
+// SetOnCancel sets the callback for when the user cancels
+func (cr *ChangeReviewer) SetOnCancel(callback func()) {
+	cr.onCancel = callback
+}
+
+// Run starts the reviewer
+func (cr *ChangeReviewer) Run() error {
+	cr.populateChangeList()
+	cr.updateStatusBar()
+	return cr.app.Run()
+}
+
+// Stop stops the reviewer
+func (cr *ChangeReviewer) Stop() {
+	cr.app.Stop()
+}
+
+// ReviewedResults returns the results with the rejected changes undone: their
+// lines are the original lines, unchanged
+func (cr *ChangeReviewer) ReviewedResults() []transform.Result {
+	reviewed := make([]transform.Result, len(cr.results))
+	copy(reviewed, cr.results)
+	for _, item := range cr.items {
+		if item.accepted {
+			continue
+		}
+		for i := item.start; i < item.end; i++ {
+			reviewed[i] = transform.Result{Original: cr.results[i].Original, Line: cr.results[i].Original}
+		}
+	}
+	return reviewed
+}
+
+// AcceptedCount returns how many of the changes are accepted
+func (cr *ChangeReviewer) AcceptedCount() int {
+	count := 0
+	for _, item := range cr.items {
+		if item.accepted {
+			count++
+		}
+	}
+	return count
+}
+
+// setupUI initializes the UI components
+func (cr *ChangeReviewer) setupUI() {
+	cr.setupChangeList()
+	cr.setupDiffPanes()
+	cr.setupStatusBar()
+	cr.setupHelpText()
+	cr.setupPreviewNotice()
+	cr.setupLayout()
+	cr.setupKeyBindings()
+}
+
+// setupChangeList initializes the change list widget
+func (cr *ChangeReviewer) setupChangeList() {
+	cr.changeList = tview.NewList().
+		ShowSecondaryText(true).
+		SetHighlightFullLine(true).
+		SetChangedFunc(cr.onSelectionChanged)
+
+	cr.changeList.SetTitle("🔄 Changes").SetBorder(true)
+	cr.changeList.SetTitleAlign(tview.AlignLeft)
+}
+
+// setupDiffPanes initializes the side-by-side before/after panes
+func (cr *ChangeReviewer) setupDiffPanes() {
+	cr.beforePane = tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(true)
+	cr.beforePane.SetTitle("Before").SetBorder(true)
+	cr.beforePane.SetTitleAlign(tview.AlignLeft)
+
+	cr.afterPane = tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(true)
+	cr.afterPane.SetTitle("After").SetBorder(true)
+	cr.afterPane.SetTitleAlign(tview.AlignLeft)
+}
+
+// setupStatusBar initializes the status bar
+func (cr *ChangeReviewer) setupStatusBar() {
+	cr.statusBar = tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter)
+}
+
+// setupHelpText initializes the help text
+func (cr *ChangeReviewer) setupHelpText() {
+	helpContent := `[yellow]Key Bindings:[white]
+[green]Space[white] - Accept/reject change  [green]Enter[white] - Apply accepted changes  [green]a[white] - Accept all
+[green]n[white] - Reject all              [green]q[white] - Cancel                  [green]?[white] - Toggle help
+[green]↑↓[white] - Navigate`
+
+	cr.helpText = tview.NewTextView().
+		SetText(helpContent).
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignLeft)
+
+	cr.helpText.SetTitle("❓ Help").SetBorder(true)
+}
+
+// setupPreviewNotice initializes the preview notice text
+func (cr *ChangeReviewer) setupPreviewNotice() {
+	cr.previewNotice = tview.NewTextView().
+		SetText("[black:yellow:b] TUIはPreviewとして提供中 [::-]").
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true)
+}
+
+// setupLayout creates the main layout
+func (cr *ChangeReviewer) setupLayout() {
+	cr.mainGrid = tview.NewGrid()
+	cr.updateLayout()
+	cr.app.SetRoot(cr.mainGrid, true)
+}
+
+// updateLayout updates the grid layout based on help visibility
+func (cr *ChangeReviewer) updateLayout() {
+	cr.mainGrid.Clear()
+
+	// Change list, before/after, status bar, (help,) preview notice
+	rows := []int{0, 0, 1, 4, 1}
+	if !cr.helpVisible {
+		rows = []int{0, 0, 1, 1}
+	}
+	cr.mainGrid.SetRows(rows...).
+		SetColumns(0, 0).
+		SetBorders(false)
+
+	cr.mainGrid.AddItem(cr.changeList, 0, 0, 1, 2, 0, 0, true).
+		AddItem(cr.beforePane, 1, 0, 1, 1, 0, 0, false).
+		AddItem(cr.afterPane, 1, 1, 1, 1, 0, 0, false).
+		AddItem(cr.statusBar, 2, 0, 1, 2, 0, 0, false)
+	if cr.helpVisible {
+		cr.mainGrid.AddItem(cr.helpText, 3, 0, 1, 2, 0, 0, false).
+			AddItem(cr.previewNotice, 4, 0, 1, 2, 0, 0, false)
+	} else {
+		cr.mainGrid.AddItem(cr.previewNotice, 3, 0, 1, 2, 0, 0, false)
+	}
+}
+
+// setupKeyBindings configures global key bindings
+func (cr *ChangeReviewer) setupKeyBindings() {
+	cr.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'q':
+			cr.cancel()
+			return nil
+		case ' ':
+			cr.toggleChange(cr.changeList.GetCurrentItem())
+			return nil
+		case 'a':
+			cr.setAllAccepted(true)
+			return nil
+		case 'n':
+			cr.setAllAccepted(false)
+			return nil
+		case '?':
+			cr.toggleHelp()
+			return nil
+		}
+
+		switch event.Key() {
+		case tcell.KeyCtrlC:
+			cr.cancel()
+			return nil
+		case tcell.KeyEnter:
+			cr.applyChanges()
+			return nil
+		}
+
+		return event
+	})
+}
+
+// populateChangeList populates the list with the changes to review
+func (cr *ChangeReviewer) populateChangeList() {
+	current := cr.changeList.GetCurrentItem()
+	cr.changeList.Clear()
+
+	if len(cr.items) == 0 {
+		cr.changeList.AddItem("[gray]No changes[white]", "    Nothing in the script is transformed", 0, nil)
+		return
+	}
+
+	for _, item := range cr.items {
+		prefix := "✗ "
+		if item.accepted {
+			prefix = "✓ "
+		}
+		first := cr.results[item.start]
+		mainText := fmt.Sprintf("%s[yellow]L%d[white] %s", prefix, item.start+1, tview.Escape(strings.TrimSpace(first.Original)))
+		secondaryText := "    " + strings.Join(cr.ruleNames(item), ", ")
+		cr.changeList.AddItem(mainText, secondaryText, 0, nil)
+	}
+
+	if current < len(cr.items) {
+		cr.changeList.SetCurrentItem(current)
+	}
+}
+
+// ruleNames returns the names of the rules that changed an item, in order and without duplicates
+func (cr *ChangeReviewer) ruleNames(item reviewItem) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, r := range cr.results[item.start:item.end] {
+		for _, c := range r.Changes {
+			if !seen[c.RuleName] {
+				seen[c.RuleName] = true
+				names = append(names, c.RuleName)
+			}
+		}
+	}
+	return names
+}
+
+// onSelectionChanged shows the before/after of the selected change
+func (cr *ChangeReviewer) onSelectionChanged(index int, mainText, secondaryText string, shortcut rune) {
+	if index < 0 || index >= len(cr.items) {
+		cr.beforePane.Clear()
+		cr.afterPane.Clear()
+		return
+	}
+
+	var before, after strings.Builder
+	for _, r := range cr.results[cr.items[index].start:cr.items[index].end] {
+		if r.Changed {
+			before.WriteString("[red]" + tview.Escape(r.Original) + "[white]\n")
+			after.WriteString("[green]" + tview.Escape(r.Line) + "[white]\n")
+		} else {
+			before.WriteString(tview.Escape(r.Original) + "\n")
+			after.WriteString(tview.Escape(r.Line) + "\n")
+		}
+	}
+	cr.beforePane.SetText(before.String())
+	cr.afterPane.SetText(after.String())
+}
+
+// toggleChange accepts or rejects the change at index
+func (cr *ChangeReviewer) toggleChange(index int) {
+	if index < 0 || index >= len(cr.items) {
+		return
+	}
+	cr.items[index].accepted = !cr.items[index].accepted
+	cr.populateChangeList()
+	cr.updateStatusBar()
+}
+
+// setAllAccepted accepts or rejects every change
+func (cr *ChangeReviewer) setAllAccepted(accepted bool) {
+	for i := range cr.items {
+		cr.items[i].accepted = accepted
+	}
+	cr.populateChangeList()
+	cr.updateStatusBar()
+}
+
+// updateStatusBar updates the status bar text
+func (cr *ChangeReviewer) updateStatusBar() {
+	accepted := cr.AcceptedCount()
+	cr.statusBar.SetText(fmt.Sprintf(
+		"[blue]Changes:[white] %d  [green]Accepted:[white] %d  [red]Rejected:[white] %d",
+		len(cr.items), accepted, len(cr.items)-accepted))
+}
+
+// applyChanges passes the reviewed results to the apply callback and stops
+func (cr *ChangeReviewer) applyChanges() {
+	if cr.onApply != nil {
+		cr.onApply(cr.ReviewedResults())
+	}
+	cr.app.Stop()
+}
+
+// cancel calls the cancel callback and stops
+func (cr *ChangeReviewer) cancel() {
+	if cr.onCancel != nil {
+		cr.onCancel()
+	}
+	cr.app.Stop()
+}
+
+// toggleHelp toggles the visibility of the help text
+func (cr *ChangeReviewer) toggleHelp() {
+	cr.helpVisible = !cr.helpVisible
+	cr.updateLayout()
+	cr.app.Draw()
+}
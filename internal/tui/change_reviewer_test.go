@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/armaniacs/usacloud-update/internal/transform"
+)
+
+func newTestReviewer(t *testing.T, lines []string) *ChangeReviewer {
+	t.Helper()
+	results, _ := transform.NewDefaultEngine().ApplyFile(lines)
+	return NewChangeReviewer(lines, results)
+}
+
+func TestNewChangeReviewer(t *testing.T) {
+	lines := []string{
+		"#!/bin/bash",
+		"usacloud iso-image list",
+		"usacloud server list",
+		`usacloud startup-script list \`,
+		"  --output-type csv",
+	}
+
+	cr := newTestReviewer(t, lines)
+	if cr.app == nil || cr.changeList == nil || cr.beforePane == nil || cr.afterPane == nil {
+		t.Fatal("NewChangeReviewer() did not initialize the UI")
+	}
+
+	// Only changed commands are listed, a continued command as one change
+	if len(cr.items) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", cr.items)
+	}
+	if cr.items[1].start != 3 || cr.items[1].end != 5 {
+		t.Errorf("Expected the continued command to span lines 4-5, got %+v", cr.items[1])
+	}
+	if cr.AcceptedCount() != 2 {
+		t.Errorf("Expected every change to start accepted, got %d", cr.AcceptedCount())
+	}
+	if names := cr.ruleNames(cr.items[1]); strings.Join(names, ",") != "output-type-csv-tsv,startup-script-to-note" {
+		t.Errorf("Unexpected rule names %v", names)
+	}
+}
+
+func TestChangeReviewer_ReviewedResults(t *testing.T) {
+	lines := []string{
+		"usacloud iso-image list",
+		`usacloud startup-script list \`,
+		"  --output-type csv",
+	}
+
+	cr := newTestReviewer(t, lines)
+	cr.toggleChange(1)
+	cr.toggleChange(99) // out of range is ignored
+
+	reviewed := cr.ReviewedResults()
+	if len(reviewed) != len(lines) {
+		t.Fatalf("Expected %d results, got %d", len(lines), len(reviewed))
+	}
+	if !strings.HasPrefix(reviewed[0].Line, "usacloud cdrom list") || !reviewed[0].Changed {
+		t.Errorf("Expected the accepted change to be kept, got %+v", reviewed[0])
+	}
+	for i := 1; i < 3; i++ {
+		if reviewed[i].Line != lines[i] || reviewed[i].Changed || len(reviewed[i].Changes) != 0 {
+			t.Errorf("Expected rejected line %d to stay original, got %+v", i+1, reviewed[i])
+		}
+	}
+	if cr.results[1].Line == lines[1] {
+		t.Error("ReviewedResults() must not modify the reviewed results")
+	}
+
+	cr.setAllAccepted(false)
+	for i, r := range cr.ReviewedResults() {
+		if r.Line != lines[i] {
+			t.Errorf("Expected every line to stay original after rejecting all, got %q", r.Line)
+		}
+	}
+	cr.setAllAccepted(true)
+	if cr.AcceptedCount() != 2 {
+		t.Errorf("Expected all changes accepted, got %d", cr.AcceptedCount())
+	}
+}
+
+func TestChangeReviewer_Panes(t *testing.T) {
+	cr := newTestReviewer(t, []string{"usacloud iso-image list [x]"})
+	cr.populateChangeList()
+	cr.onSelectionChanged(0, "", "", 0)
+
+	if before := cr.beforePane.GetText(true); !strings.Contains(before, "usacloud iso-image list [x]") {
+		t.Errorf("Unexpected before pane %q", before)
+	}
+	if after := cr.afterPane.GetText(true); !strings.Contains(after, "usacloud cdrom list [x]") {
+		t.Errorf("Unexpected after pane %q", after)
+	}
+
+	cr.updateStatusBar()
+	if status := cr.statusBar.GetText(true); !strings.Contains(status, "Accepted: 1") {
+		t.Errorf("Unexpected status %q", status)
+	}
+}
+
+func TestChangeReviewer_Callbacks(t *testing.T) {
+	cr := newTestReviewer(t, []string{"usacloud iso-image list"})
+
+	var applied []transform.Result
+	cr.SetOnApply(func(r []transform.Result) { applied = r })
+	cancelled := false
+	cr.SetOnCancel(func() { cancelled = true })
+
+	cr.onApply(cr.ReviewedResults())
+	cr.onCancel()
+	if len(applied) != 1 || !cancelled {
+		t.Errorf("Expected the callbacks to be called, got %v, %v", applied, cancelled)
+	}
+}
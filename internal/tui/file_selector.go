@@ -1,12 +1,15 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/armaniacs/usacloud-update/internal/config"
 	"github.com/armaniacs/usacloud-update/internal/scanner"
+	"github.com/armaniacs/usacloud-update/internal/transform"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -24,11 +27,13 @@ type FileSelector struct {
 	app           *tview.Application
 	config        *config.SandboxConfig
 	scanner       *scanner.Scanner
+	engine        *transform.Engine
 	scanResult    *scanner.BasicScanResult
 	selectedFiles []string
 
 	// UI components
 	fileList      *tview.List
+	searchInput   *tview.InputField
 	previewPane   *tview.TextView
 	statusBar     *tview.TextView
 	helpText      *tview.TextView
@@ -37,6 +42,12 @@ type FileSelector struct {
 
 	// State
 	helpVisible bool
+	searching   bool   // the search box has focus
+	filterText  string // the list shows only the files whose path contains it
+
+	// Change scan: whether each scanned file has transformable lines, nil before a scan
+	changedFiles map[string]bool
+	scanCancel   context.CancelFunc // non-nil while a change scan runs
 
 	// Callbacks
 	onFilesSelected func([]string)
@@ -49,6 +60,7 @@ func NewFileSelector(cfg *config.SandboxConfig) *FileSelector {
 		app:           tview.NewApplication(),
 		config:        cfg,
 		scanner:       scanner.NewScanner(),
+		engine:        transform.NewDefaultEngine(),
 		selectedFiles: make([]string, 0),
 		helpVisible:   true, // Default to visible
 	}
@@ -90,6 +102,7 @@ func (fs *FileSelector) Stop() {
 // setupUI initializes the UI components
 func (fs *FileSelector) setupUI() {
 	fs.setupFileList()
+	fs.setupSearchInput()
 	fs.setupPreviewPane()
 	fs.setupStatusBar()
 	fs.setupHelpText()
@@ -110,6 +123,15 @@ func (fs *FileSelector) setupFileList() {
 	fs.fileList.SetTitleAlign(tview.AlignLeft)
 }
 
+// setupSearchInput initializes the incremental search box
+func (fs *FileSelector) setupSearchInput() {
+	fs.searchInput = tview.NewInputField().
+		SetLabel("🔍 / ").
+		SetPlaceholder("filter by path").
+		SetChangedFunc(fs.setFilter).
+		SetDoneFunc(fs.onSearchDone)
+}
+
 // setupPreviewPane initializes the preview pane
 func (fs *FileSelector) setupPreviewPane() {
 	fs.previewPane = tview.NewTextView().
@@ -133,7 +155,8 @@ func (fs *FileSelector) setupHelpText() {
 	helpContent := `[yellow]Key Bindings:[white]
 [green]Space[white] - Select/deselect file    [green]Enter[white] - Confirm selection    [green]a[white] - Select all
 [green]n[white] - Select none                [green]u[white] - Toggle usacloud files   [green]q[white] - Cancel
-[green]↑↓[white] - Navigate                 [green]Tab[white] - Switch panes         [green]?[white] - Toggle help`
+[green]↑↓[white] - Navigate                 [green]Tab[white] - Switch panes         [green]?[white] - Toggle help
+[green]/[white] - Search files               [green]Esc[white] - Clear search/scan   [green]c[white] - Select files with changes`
 
 	fs.helpText = tview.NewTextView().
 		SetText(helpContent).
@@ -166,13 +189,21 @@ func (fs *FileSelector) setupLayout() {
 func (fs *FileSelector) updateLayout() {
 	fs.mainGrid.Clear()
 
+	// The search box takes the top line of the file list while it is in use
+	var fileList tview.Primitive = fs.fileList
+	if fs.searching || fs.filterText != "" {
+		fileList = tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(fs.searchInput, 1, 0, fs.searching).
+			AddItem(fs.fileList, 0, 1, !fs.searching)
+	}
+
 	if fs.helpVisible {
 		// Layout with help: Main content, status bar, help, preview notice
-		fs.mainGrid.SetRows(0, 1, 4, 1).
+		fs.mainGrid.SetRows(0, 1, 5, 1).
 			SetColumns(0, 0).
 			SetBorders(false)
 
-		fs.mainGrid.AddItem(fs.fileList, 0, 0, 1, 1, 0, 0, true).
+		fs.mainGrid.AddItem(fileList, 0, 0, 1, 1, 0, 0, true).
 			AddItem(fs.previewPane, 0, 1, 1, 1, 0, 0, false).
 			AddItem(fs.statusBar, 1, 0, 1, 2, 0, 0, false).
 			AddItem(fs.helpText, 2, 0, 1, 2, 0, 0, false).
@@ -183,7 +214,7 @@ func (fs *FileSelector) updateLayout() {
 			SetColumns(0, 0).
 			SetBorders(false)
 
-		fs.mainGrid.AddItem(fs.fileList, 0, 0, 1, 1, 0, 0, true).
+		fs.mainGrid.AddItem(fileList, 0, 0, 1, 1, 0, 0, true).
 			AddItem(fs.previewPane, 0, 1, 1, 1, 0, 0, false).
 			AddItem(fs.statusBar, 1, 0, 1, 2, 0, 0, false).
 			AddItem(fs.previewNotice, 2, 0, 1, 2, 0, 0, false)
@@ -193,12 +224,18 @@ func (fs *FileSelector) updateLayout() {
 // setupKeyBindings configures global key bindings
 func (fs *FileSelector) setupKeyBindings() {
 	fs.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if fs.searching {
+			// Keys are typed into the search box, which handles Enter and Esc
+			if event.Key() == tcell.KeyCtrlC {
+				fs.cancel()
+				return nil
+			}
+			return event
+		}
+
 		switch event.Rune() {
 		case 'q':
-			if fs.onCancel != nil {
-				fs.onCancel()
-			}
-			fs.app.Stop()
+			fs.cancel()
 			return nil
 		case 'a':
 			fs.selectAll()
@@ -215,18 +252,32 @@ func (fs *FileSelector) setupKeyBindings() {
 		case '?':
 			fs.toggleHelp()
 			return nil
+		case '/':
+			fs.startSearch()
+			return nil
+		case 'c':
+			if fs.scanCancel != nil {
+				fs.stopChangeScan()
+			} else {
+				fs.startChangeScan()
+			}
+			return nil
 		}
 
 		switch event.Key() {
 		case tcell.KeyCtrlC:
-			if fs.onCancel != nil {
-				fs.onCancel()
-			}
-			fs.app.Stop()
+			fs.cancel()
 			return nil
 		case tcell.KeyEnter:
 			fs.confirmSelection()
 			return nil
+		case tcell.KeyEscape:
+			if fs.scanCancel != nil {
+				fs.stopChangeScan()
+			} else {
+				fs.clearFilter()
+			}
+			return nil
 		}
 
 		return event
@@ -243,11 +294,67 @@ func (fs *FileSelector) populateFileList() {
 		return
 	}
 
-	for _, file := range fs.scanResult.Files {
+	files := fs.filteredFiles()
+	if len(files) == 0 {
+		fs.fileList.AddItem("[gray]No matching files[white]",
+			fmt.Sprintf("    No file path contains %q (Esc to clear)", fs.filterText), 0, nil)
+		return
+	}
+
+	for _, file := range files {
 		fs.addFileToList(file)
 	}
 }
 
+// filteredFiles returns the scanned files shown in the list: those whose
+// relative path contains the search text, ignoring case
+func (fs *FileSelector) filteredFiles() []*scanner.FileInfo {
+	if fs.scanResult == nil {
+		return nil
+	}
+	if fs.filterText == "" {
+		return fs.scanResult.Files
+	}
+
+	var files []*scanner.FileInfo
+	for _, file := range fs.scanResult.Files {
+		if start, _ := indexFold(file.GetRelativePath(fs.scanResult.Directory), fs.filterText); start >= 0 {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// highlightMatch escapes path for display and highlights the first match of the search text
+func (fs *FileSelector) highlightMatch(path string) string {
+	i, end := -1, -1
+	if fs.filterText != "" {
+		i, end = indexFold(path, fs.filterText)
+	}
+	if i < 0 {
+		return tview.Escape(path)
+	}
+	return tview.Escape(path[:i]) + "[black:yellow]" + tview.Escape(path[i:end]) + "[-:-]" + tview.Escape(path[end:])
+}
+
+// indexFold returns the byte offsets in s of the first case-insensitive match
+// of substr, or -1, -1. The offsets are those of s itself, whose case may be
+// encoded in a different number of bytes than in substr.
+func indexFold(s, substr string) (int, int) {
+	n := utf8.RuneCountInString(substr)
+	for i := range s {
+		end := i
+		for j := 0; j < n && end < len(s); j++ {
+			_, size := utf8.DecodeRuneInString(s[end:])
+			end += size
+		}
+		if strings.EqualFold(s[i:end], substr) {
+			return i, end
+		}
+	}
+	return -1, -1
+}
+
 // addFileToList adds a single file to the list
 func (fs *FileSelector) addFileToList(file *scanner.FileInfo) {
 	// Check if file is selected
@@ -259,7 +366,10 @@ func (fs *FileSelector) addFileToList(file *scanner.FileInfo) {
 
 	// Create main text
 	relPath := file.GetRelativePath(fs.scanResult.Directory)
-	mainText := fmt.Sprintf("%s[white]%s", prefix, relPath)
+	mainText := fmt.Sprintf("%s[white]%s", prefix, fs.highlightMatch(relPath))
+	if changed, scanned := fs.changedFiles[file.Path]; scanned && !changed {
+		mainText = fmt.Sprintf("%s[gray]%s[white]", prefix, fs.highlightMatch(relPath))
+	}
 
 	// Create secondary text with file info
 	var badges []string
@@ -283,11 +393,12 @@ func (fs *FileSelector) addFileToList(file *scanner.FileInfo) {
 
 // onFileToggle handles file selection toggle
 func (fs *FileSelector) onFileToggle(index int, mainText, secondaryText string, shortcut rune) {
-	if fs.scanResult == nil || index >= len(fs.scanResult.Files) {
+	files := fs.filteredFiles()
+	if index < 0 || index >= len(files) {
 		return
 	}
 
-	file := fs.scanResult.Files[index]
+	file := files[index]
 
 	// Toggle selection
 	if fs.isFileSelected(file.Path) {
@@ -304,12 +415,13 @@ func (fs *FileSelector) onFileToggle(index int, mainText, secondaryText string,
 
 // onSelectionChanged handles list selection changes for preview
 func (fs *FileSelector) onSelectionChanged(index int, mainText, secondaryText string, shortcut rune) {
-	if fs.scanResult == nil || index >= len(fs.scanResult.Files) {
+	files := fs.filteredFiles()
+	if index < 0 || index >= len(files) {
 		fs.previewPane.Clear()
 		return
 	}
 
-	file := fs.scanResult.Files[index]
+	file := files[index]
 	fs.updatePreview(file)
 }
 
@@ -395,7 +507,7 @@ func (fs *FileSelector) updateStatusBar() {
 	}
 
 	totalFiles := len(fs.scanResult.Files)
-	selectedCount := len(fs.selectedFiles)
+	selectedCount := len(fs.selectedInView())
 
 	var usacloudCount int
 	for _, file := range fs.scanResult.Files {
@@ -412,6 +524,20 @@ func (fs *FileSelector) updateStatusBar() {
 		status += fmt.Sprintf("  [red]Errors:[white] %d", len(fs.scanResult.Errors))
 	}
 
+	if fs.filterText != "" {
+		status += fmt.Sprintf("  [yellow]Filter:[white] %q (%d shown)", fs.filterText, len(fs.filteredFiles()))
+	}
+
+	if fs.changedFiles != nil {
+		changedCount := 0
+		for _, changed := range fs.changedFiles {
+			if changed {
+				changedCount++
+			}
+		}
+		status += fmt.Sprintf("  [green]%d of %d files contain changes[white]", changedCount, len(fs.changedFiles))
+	}
+
 	fs.statusBar.SetText(status)
 }
 
@@ -435,14 +561,15 @@ func (fs *FileSelector) removeSelectedFile(filePath string) {
 	}
 }
 
-// selectAll selects all files
+// selectAll selects all files in the list
 func (fs *FileSelector) selectAll() {
 	if fs.scanResult == nil {
 		return
 	}
 
-	fs.selectedFiles = make([]string, 0, len(fs.scanResult.Files))
-	for _, file := range fs.scanResult.Files {
+	files := fs.filteredFiles()
+	fs.selectedFiles = make([]string, 0, len(files))
+	for _, file := range files {
 		fs.selectedFiles = append(fs.selectedFiles, file.Path)
 	}
 
@@ -457,7 +584,7 @@ func (fs *FileSelector) selectNone() {
 	fs.updateStatusBar()
 }
 
-// toggleUsacloudFiles selects/deselects files containing usacloud commands
+// toggleUsacloudFiles selects/deselects files in the list containing usacloud commands
 func (fs *FileSelector) toggleUsacloudFiles() {
 	if fs.scanResult == nil {
 		return
@@ -465,7 +592,7 @@ func (fs *FileSelector) toggleUsacloudFiles() {
 
 	// Check if any usacloud files are currently selected
 	var usacloudFiles []string
-	for _, file := range fs.scanResult.Files {
+	for _, file := range fs.filteredFiles() {
 		if hasUsacloud, err := file.HasUsacloudCommands(); err == nil && hasUsacloud {
 			usacloudFiles = append(usacloudFiles, file.Path)
 		}
@@ -498,19 +625,41 @@ func (fs *FileSelector) toggleUsacloudFiles() {
 	fs.updateStatusBar()
 }
 
-// confirmSelection confirms the current selection
+// confirmSelection confirms the current selection. While the list is
+// filtered, only the selected files shown in it are reported.
 func (fs *FileSelector) confirmSelection() {
-	if len(fs.selectedFiles) == 0 {
+	selected := fs.selectedInView()
+	if len(selected) == 0 {
 		return // No files selected, do nothing
 	}
 
+	fs.stopChangeScan()
 	if fs.onFilesSelected != nil {
-		fs.onFilesSelected(fs.selectedFiles)
+		fs.onFilesSelected(selected)
 	}
 
 	fs.app.Stop()
 }
 
+// selectedInView returns the selected files shown in the list, in the order they were selected
+func (fs *FileSelector) selectedInView() []string {
+	if fs.filterText == "" || fs.scanResult == nil {
+		return fs.selectedFiles
+	}
+
+	shown := make(map[string]bool)
+	for _, file := range fs.filteredFiles() {
+		shown[file.Path] = true
+	}
+	selected := make([]string, 0, len(fs.selectedFiles))
+	for _, path := range fs.selectedFiles {
+		if shown[path] {
+			selected = append(selected, path)
+		}
+	}
+	return selected
+}
+
 // GetSelectedFiles returns the currently selected files
 func (fs *FileSelector) GetSelectedFiles() []string {
 	result := make([]string, len(fs.selectedFiles))
@@ -535,3 +684,131 @@ func (fs *FileSelector) toggleHelp() {
 	// Force redraw
 	fs.app.Draw()
 }
+
+// startSearch focuses the search box
+func (fs *FileSelector) startSearch() {
+	fs.searching = true
+	fs.updateLayout()
+	fs.app.SetFocus(fs.searchInput)
+}
+
+// onSearchDone returns the focus to the file list: Enter keeps the filter, Esc clears it
+func (fs *FileSelector) onSearchDone(key tcell.Key) {
+	if key == tcell.KeyEscape {
+		fs.clearFilter()
+		return
+	}
+	fs.searching = false
+	fs.updateLayout()
+	fs.app.SetFocus(fs.fileList)
+}
+
+// setFilter shows only the files whose path contains text
+func (fs *FileSelector) setFilter(text string) {
+	fs.filterText = text
+	fs.populateFileList()
+	fs.fileList.SetCurrentItem(0)
+	fs.updateStatusBar()
+}
+
+// clearFilter empties the search box and shows every file again
+func (fs *FileSelector) clearFilter() {
+	fs.searching = false
+	fs.searchInput.SetText("") // calls setFilter
+	fs.filterText = ""
+	fs.populateFileList()
+	fs.updateStatusBar()
+	fs.updateLayout()
+	fs.app.SetFocus(fs.fileList)
+}
+
+// cancel calls the cancel callback and stops
+func (fs *FileSelector) cancel() {
+	fs.stopChangeScan()
+	if fs.onCancel != nil {
+		fs.onCancel()
+	}
+	fs.app.Stop()
+}
+
+// startChangeScan transforms each file in the list in the background and,
+// when every file is scanned, selects only the files with transformable lines
+func (fs *FileSelector) startChangeScan() {
+	files := fs.filteredFiles()
+	if len(files) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fs.scanCancel = cancel
+	fs.showScanProgress(0, len(files))
+
+	go func() {
+		changed, complete := fs.scanForChanges(ctx, files, func(done int) {
+			fs.app.QueueUpdateDraw(func() { fs.showScanProgress(done, len(files)) })
+		})
+		fs.app.QueueUpdateDraw(func() {
+			if !complete || ctx.Err() != nil {
+				return // canceled
+			}
+			fs.scanCancel = nil
+			fs.applyChangeScan(files, changed)
+		})
+	}()
+}
+
+// stopChangeScan cancels a running change scan, keeping the current selection
+func (fs *FileSelector) stopChangeScan() {
+	if fs.scanCancel == nil {
+		return
+	}
+	fs.scanCancel()
+	fs.scanCancel = nil
+	fs.updateStatusBar()
+	fs.statusBar.SetText(fs.statusBar.GetText(false) + "  [red]Scan canceled[white]")
+}
+
+// scanForChanges reports for each file whether the engine changes any of its
+// lines, calling progress after each file. complete is false when ctx is
+// canceled before every file is scanned; unreadable files have no changes.
+func (fs *FileSelector) scanForChanges(ctx context.Context, files []*scanner.FileInfo, progress func(done int)) (changed map[string]bool, complete bool) {
+	changed = make(map[string]bool, len(files))
+	for i, file := range files {
+		if ctx.Err() != nil {
+			return changed, false
+		}
+
+		content, err := os.ReadFile(file.Path)
+		if err == nil {
+			_, stats := fs.engine.ApplyFile(strings.Split(string(content), "\n"))
+			changed[file.Path] = stats.ChangedLines > 0
+		} else {
+			changed[file.Path] = false
+		}
+
+		if progress != nil {
+			progress(i + 1)
+		}
+	}
+	return changed, ctx.Err() == nil
+}
+
+// showScanProgress shows how many files the change scan has done
+func (fs *FileSelector) showScanProgress(done, total int) {
+	fs.statusBar.SetText(fmt.Sprintf("[yellow]Scanning for changes:[white] %d/%d  [gray](c or Esc to cancel)[white]", done, total))
+}
+
+// applyChangeScan records the result of a change scan of files and selects
+// the ones with changes, dimming the rest in the list
+func (fs *FileSelector) applyChangeScan(files []*scanner.FileInfo, changed map[string]bool) {
+	fs.changedFiles = changed
+	fs.selectedFiles = make([]string, 0, len(files))
+	for _, file := range files {
+		if changed[file.Path] {
+			fs.selectedFiles = append(fs.selectedFiles, file.Path)
+		}
+	}
+
+	fs.populateFileList()
+	fs.updateStatusBar()
+}
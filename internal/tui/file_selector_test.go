@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -432,3 +433,137 @@ func TestFileSelector_ToggleUsacloudFiles(t *testing.T) {
 	// Note: Full testing of toggleUsacloudFiles would require mocking
 	// the HasUsacloudCommands() method or creating real files with content
 }
+
+func TestFileSelector_Filter(t *testing.T) {
+	fs := NewFileSelector(&config.SandboxConfig{})
+	fs.scanResult = &scanner.BasicScanResult{
+		Directory: "/test",
+		Files: []*scanner.FileInfo{
+			{Path: "/test/deploy/web.sh", Name: "web.sh"},
+			{Path: "/test/deploy/db.sh", Name: "db.sh"},
+			{Path: "/test/backup.sh", Name: "backup.sh"},
+		},
+	}
+
+	fs.setFilter("DEPLOY")
+	if got := fs.filteredFiles(); len(got) != 2 || got[0].Name != "web.sh" || got[1].Name != "db.sh" {
+		t.Fatalf("filteredFiles() = %v, want the two files under deploy", got)
+	}
+	if fs.fileList.GetItemCount() != 2 {
+		t.Errorf("Expected the list to show 2 files, got %d", fs.fileList.GetItemCount())
+	}
+	if main, _ := fs.fileList.GetItemText(0); !strings.Contains(main, "[black:yellow]deploy[-:-]/web.sh") {
+		t.Errorf("Expected the match to be highlighted, got %q", main)
+	}
+
+	// Indexes refer to the filtered list
+	fs.onFileToggle(1, "", "", 0)
+	if len(fs.selectedFiles) != 1 || fs.selectedFiles[0] != "/test/deploy/db.sh" {
+		t.Fatalf("Expected db.sh to be selected, got %v", fs.selectedFiles)
+	}
+
+	// Only the selected files shown in the filtered list are reported
+	fs.selectedFiles = append(fs.selectedFiles, "/test/backup.sh")
+	var reported []string
+	fs.SetOnFilesSelected(func(files []string) { reported = files })
+	fs.confirmSelection()
+	if len(reported) != 1 || reported[0] != "/test/deploy/db.sh" {
+		t.Errorf("Expected only db.sh to be reported, got %v", reported)
+	}
+
+	fs.selectAll()
+	if len(fs.selectedFiles) != 2 {
+		t.Errorf("Expected select all to select the 2 shown files, got %v", fs.selectedFiles)
+	}
+
+	fs.setFilter("nothing")
+	if main, _ := fs.fileList.GetItemText(0); fs.fileList.GetItemCount() != 1 || !strings.Contains(main, "No matching files") {
+		t.Errorf("Expected a no match message, got %q", main)
+	}
+	fs.onFileToggle(0, "", "", 0)
+	if len(fs.selectedFiles) != 2 {
+		t.Errorf("Toggling the no match message should not change the selection, got %v", fs.selectedFiles)
+	}
+
+	fs.startSearch()
+	fs.clearFilter()
+	if fs.searching || fs.filterText != "" || fs.searchInput.GetText() != "" {
+		t.Errorf("Expected Esc to clear the search, got searching=%v filter=%q", fs.searching, fs.filterText)
+	}
+	if fs.fileList.GetItemCount() != 3 {
+		t.Errorf("Expected every file to be shown again, got %d", fs.fileList.GetItemCount())
+	}
+}
+
+func TestFileSelector_HighlightMatchFoldedCase(t *testing.T) {
+	fs := NewFileSelector(&config.SandboxConfig{})
+
+	// The Kelvin sign takes three bytes, its lower case "k" only one
+	path := "\u212Aeys/deploy.sh"
+	fs.filterText = "DEPLOY"
+	if got, want := fs.highlightMatch(path), "\u212Aeys/[black:yellow]deploy[-:-].sh"; got != want {
+		t.Errorf("highlightMatch(%q) = %q, want %q", path, got, want)
+	}
+	fs.filterText = "keys"
+	if got, want := fs.highlightMatch(path), "[black:yellow]\u212Aeys[-:-]/deploy.sh"; got != want {
+		t.Errorf("highlightMatch(%q) = %q, want %q", path, got, want)
+	}
+}
+
+func TestFileSelector_ChangeScan(t *testing.T) {
+	tempDir := t.TempDir()
+	contents := map[string]string{
+		"old.sh":     "#!/bin/bash\nusacloud iso-image list\n",
+		"current.sh": "#!/bin/bash\nusacloud server list\n",
+		"plain.sh":   "#!/bin/bash\necho hello\n",
+	}
+	var files []*scanner.FileInfo
+	for _, name := range []string{"old.sh", "current.sh", "plain.sh", "missing.sh"} {
+		path := filepath.Join(tempDir, name)
+		if content, ok := contents[name]; ok {
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		files = append(files, &scanner.FileInfo{Path: path, Name: name})
+	}
+
+	fs := NewFileSelector(&config.SandboxConfig{})
+	fs.scanResult = &scanner.BasicScanResult{Directory: tempDir, Files: files}
+
+	var progress []int
+	changed, complete := fs.scanForChanges(context.Background(), files, func(done int) { progress = append(progress, done) })
+	if !complete {
+		t.Fatal("Expected the scan to complete")
+	}
+	want := map[string]bool{files[0].Path: true, files[1].Path: false, files[2].Path: false, files[3].Path: false}
+	for path, w := range want {
+		if got, ok := changed[path]; !ok || got != w {
+			t.Errorf("changed[%s] = %v (scanned %v), want %v", filepath.Base(path), got, ok, w)
+		}
+	}
+	if len(progress) != 4 || progress[3] != 4 {
+		t.Errorf("Unexpected progress %v", progress)
+	}
+
+	fs.selectedFiles = []string{files[2].Path}
+	fs.applyChangeScan(files, changed)
+	if len(fs.selectedFiles) != 1 || fs.selectedFiles[0] != files[0].Path {
+		t.Errorf("Expected only old.sh to be selected, got %v", fs.selectedFiles)
+	}
+	if status := fs.statusBar.GetText(true); !strings.Contains(status, "1 of 4 files contain changes") {
+		t.Errorf("Expected the change count in the status bar, got %q", status)
+	}
+	if main, _ := fs.fileList.GetItemText(2); !strings.Contains(main, "[gray]") {
+		t.Errorf("Expected files without changes to be dimmed, got %q", main)
+	}
+	if main, _ := fs.fileList.GetItemText(0); strings.Contains(main, "[gray]") {
+		t.Errorf("Expected files with changes not to be dimmed, got %q", main)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if changed, complete := fs.scanForChanges(ctx, files, nil); complete || len(changed) != 0 {
+		t.Errorf("Expected a canceled scan to stop, got complete=%v changed=%v", complete, changed)
+	}
+}
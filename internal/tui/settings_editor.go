@@ -0,0 +1,377 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/armaniacs/usacloud-update/internal/config"
+	"github.com/armaniacs/usacloud-update/internal/config/profile"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// SettingsEditor represents a TUI for editing the settings of an
+// IntegratedConfig. Boolean settings toggle, settings taking one of a fixed
+// set are chosen from a drop-down and the others are typed, numbers being
+// checked as they are typed. Changes are made with UpdateSetting and written
+// with Save when the user saves.
+type SettingsEditor struct {
+	app      *tview.Application
+	config   *config.IntegratedConfig
+	settings []config.SettingInfo
+
+	// UI components
+	settingList   *tview.List
+	editForm      *tview.Form
+	statusBar     *tview.TextView
+	helpText      *tview.TextView
+	previewNotice *tview.TextView
+	mainGrid      *tview.Grid
+
+	// State
+	helpVisible bool
+	editing     bool // the edit form has focus
+	modified    bool // changed since the last save
+	confirmQuit bool // q was pressed once with unsaved changes
+	populating  bool // the list is being rebuilt
+}
+
+// NewSettingsEditor creates an editor for cfg, such as one loaded with
+// config.ReadIntegratedConfigFile
+func NewSettingsEditor(cfg *config.IntegratedConfig) *SettingsEditor {
+	se := &SettingsEditor{
+		app:         tview.NewApplication(),
+		config:      cfg,
+		settings:    cfg.Settings(),
+		helpVisible: true,
+	}
+
+	se.setupUI()
+	return se
+}
+
+// Run starts the editor
+func (se *SettingsEditor) Run() error {
+	se.populateSettingList()
+	se.updateStatusBar("")
+	return se.app.Run()
+}
+
+// Stop stops the editor
+func (se *SettingsEditor) Stop() {
+	se.app.Stop()
+}
+
+// Modified reports whether settings were changed since they were last saved
+func (se *SettingsEditor) Modified() bool {
+	return se.modified
+}
+
+// setupUI initializes the UI components
+func (se *SettingsEditor) setupUI() {
+	se.setupSettingList()
+	se.setupEditForm()
+	se.setupStatusBar()
+	se.setupHelpText()
+	se.setupPreviewNotice()
+	se.setupLayout()
+	se.setupKeyBindings()
+}
+
+// setupSettingList initializes the setting list widget
+func (se *SettingsEditor) setupSettingList() {
+	se.settingList = tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetSelectedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+			se.activateSetting(index)
+		}).
+		SetChangedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+			// Sensitive values are shown only for the selected setting
+			se.populateSettingList()
+		})
+
+	se.settingList.SetTitle("⚙️  Settings").SetBorder(true)
+	se.settingList.SetTitleAlign(tview.AlignLeft)
+}
+
+// setupEditForm initializes the form a setting is edited in
+func (se *SettingsEditor) setupEditForm() {
+	se.editForm = tview.NewForm()
+	se.editForm.SetTitle("Edit").SetBorder(true)
+	se.editForm.SetTitleAlign(tview.AlignLeft)
+}
+
+// setupStatusBar initializes the status bar
+func (se *SettingsEditor) setupStatusBar() {
+	se.statusBar = tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter)
+}
+
+// setupHelpText initializes the help text
+func (se *SettingsEditor) setupHelpText() {
+	helpContent := `[yellow]Key Bindings:[white]
+[green]Enter[white] - Toggle/edit setting  [green]s[white] - Save       [green]q[white] - Quit  [green]?[white] - Toggle help
+[green]Esc[white] - Cancel edit            [green]↑↓[white] - Navigate`
+
+	se.helpText = tview.NewTextView().
+		SetText(helpContent).
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignLeft)
+
+	se.helpText.SetTitle("❓ Help").SetBorder(true)
+}
+
+// setupPreviewNotice initializes the preview notice text
+func (se *SettingsEditor) setupPreviewNotice() {
+	se.previewNotice = tview.NewTextView().
+		SetText("[black:yellow:b] TUIはPreviewとして提供中 [::-]").
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true)
+}
+
+// setupLayout creates the main layout
+func (se *SettingsEditor) setupLayout() {
+	se.mainGrid = tview.NewGrid()
+	se.updateLayout()
+	se.app.SetRoot(se.mainGrid, true)
+}
+
+// updateLayout updates the grid layout based on help visibility
+func (se *SettingsEditor) updateLayout() {
+	se.mainGrid.Clear()
+
+	// Setting list, edit form, status bar, (help,) preview notice
+	rows := []int{0, 5, 1, 4, 1}
+	if !se.helpVisible {
+		rows = []int{0, 5, 1, 1}
+	}
+	se.mainGrid.SetRows(rows...).
+		SetColumns(0).
+		SetBorders(false)
+
+	se.mainGrid.AddItem(se.settingList, 0, 0, 1, 1, 0, 0, !se.editing).
+		AddItem(se.editForm, 1, 0, 1, 1, 0, 0, se.editing).
+		AddItem(se.statusBar, 2, 0, 1, 1, 0, 0, false)
+	if se.helpVisible {
+		se.mainGrid.AddItem(se.helpText, 3, 0, 1, 1, 0, 0, false).
+			AddItem(se.previewNotice, 4, 0, 1, 1, 0, 0, false)
+	} else {
+		se.mainGrid.AddItem(se.previewNotice, 3, 0, 1, 1, 0, 0, false)
+	}
+}
+
+// setupKeyBindings configures global key bindings
+func (se *SettingsEditor) setupKeyBindings() {
+	se.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if se.editing {
+			// Keys are typed into the edit form, which handles Enter and Esc
+			if event.Key() == tcell.KeyCtrlC {
+				se.app.Stop()
+				return nil
+			}
+			return event
+		}
+
+		switch event.Rune() {
+		case 'q':
+			se.quit()
+			return nil
+		case 's':
+			se.save()
+			return nil
+		case '?':
+			se.toggleHelp()
+			return nil
+		}
+
+		if event.Key() == tcell.KeyCtrlC {
+			se.app.Stop()
+			return nil
+		}
+
+		return event
+	})
+}
+
+// populateSettingList lists the settings with their current values
+func (se *SettingsEditor) populateSettingList() {
+	if se.populating {
+		return
+	}
+	se.populating = true
+	defer func() { se.populating = false }()
+
+	current := se.settingList.GetCurrentItem()
+	if se.settingList.GetItemCount() != len(se.settings) {
+		se.settingList.Clear()
+		for range se.settings {
+			se.settingList.AddItem("", "", 0, nil)
+		}
+		if current < len(se.settings) {
+			se.settingList.SetCurrentItem(current)
+		}
+	}
+
+	for i, s := range se.settings {
+		mainText := fmt.Sprintf("[yellow]%s[white].%s = %s", s.Section, s.Key, tview.Escape(settingDisplayValue(s, i == current)))
+		se.settingList.SetItemText(i, mainText, "")
+	}
+}
+
+// settingDisplayValue returns the value of a setting as listed: masked for a
+// sensitive setting unless it is selected
+func settingDisplayValue(s config.SettingInfo, selected bool) string {
+	value := settingText(s)
+	if s.Sensitive && !selected {
+		return profile.MaskValue(value)
+	}
+	return value
+}
+
+// settingText returns the value of a setting as it is typed
+func settingText(s config.SettingInfo) string {
+	if f, ok := s.Value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", s.Value)
+}
+
+// activateSetting toggles a boolean setting and opens the edit form for the others
+func (se *SettingsEditor) activateSetting(index int) {
+	if index < 0 || index >= len(se.settings) {
+		return
+	}
+	s := se.settings[index]
+
+	if s.Kind == config.SettingKindBool {
+		value, _ := s.Value.(bool)
+		if err := se.updateSetting(index, !value); err != nil {
+			se.updateStatusBar("[red]" + tview.Escape(err.Error()) + "[white]")
+		}
+		return
+	}
+
+	se.editForm.Clear(true)
+	label := s.Section + "." + s.Key + ": "
+	if len(s.Choices) > 0 {
+		selected := 0
+		for i, choice := range s.Choices {
+			if choice == settingText(s) {
+				selected = i
+			}
+		}
+		dropDown := tview.NewDropDown().
+			SetLabel(label).
+			SetOptions(s.Choices, nil).
+			SetCurrentOption(selected)
+		dropDown.SetSelectedFunc(func(text string, _ int) {
+			se.finishEdit(index, text)
+		})
+		dropDown.SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEscape {
+				se.endEdit()
+			}
+		})
+		se.editForm.AddFormItem(dropDown)
+	} else {
+		input := tview.NewInputField().
+			SetLabel(label).
+			SetText(settingText(s)).
+			SetFieldWidth(40)
+		switch s.Kind {
+		case config.SettingKindInt:
+			input.SetAcceptanceFunc(tview.InputFieldInteger)
+		case config.SettingKindFloat:
+			input.SetAcceptanceFunc(tview.InputFieldFloat)
+		}
+		input.SetDoneFunc(func(key tcell.Key) {
+			switch key {
+			case tcell.KeyEnter:
+				se.finishEdit(index, input.GetText())
+			case tcell.KeyEscape:
+				se.endEdit()
+			}
+		})
+		se.editForm.AddFormItem(input)
+	}
+
+	se.editing = true
+	se.updateLayout()
+	se.app.SetFocus(se.editForm)
+	se.updateStatusBar("[yellow]Enter to apply, Esc to cancel[white]")
+}
+
+// finishEdit applies the value typed or chosen in the edit form, keeping the
+// form open when it is rejected
+func (se *SettingsEditor) finishEdit(index int, text string) {
+	if err := se.updateSetting(index, text); err != nil {
+		se.updateStatusBar("[red]" + tview.Escape(err.Error()) + "[white]")
+		return
+	}
+	se.endEdit()
+}
+
+// endEdit closes the edit form
+func (se *SettingsEditor) endEdit() {
+	se.editing = false
+	se.editForm.Clear(true)
+	se.updateLayout()
+	se.app.SetFocus(se.settingList)
+	se.updateStatusBar("")
+}
+
+// updateSetting changes the setting at index and refreshes the list
+func (se *SettingsEditor) updateSetting(index int, value interface{}) error {
+	s := se.settings[index]
+	if err := se.config.UpdateSetting(s.Section, s.Key, value); err != nil {
+		return err
+	}
+	se.settings = se.config.Settings()
+	se.modified = true
+	se.confirmQuit = false
+	se.populateSettingList()
+	se.updateStatusBar("")
+	return nil
+}
+
+// save writes the settings to the configuration file
+func (se *SettingsEditor) save() {
+	if err := se.config.Save(); err != nil {
+		se.updateStatusBar("[red]" + tview.Escape(err.Error()) + "[white]")
+		return
+	}
+	se.modified = false
+	se.updateStatusBar("[green]Saved[white]")
+}
+
+// quit stops the editor, asking for a second q when there are unsaved changes
+func (se *SettingsEditor) quit() {
+	if se.modified && !se.confirmQuit {
+		se.confirmQuit = true
+		se.updateStatusBar("[yellow]Unsaved changes: press s to save or q again to discard[white]")
+		return
+	}
+	se.app.Stop()
+}
+
+// updateStatusBar shows the number of settings and whether there are unsaved
+// changes, followed by message
+func (se *SettingsEditor) updateStatusBar(message string) {
+	text := fmt.Sprintf("[blue]Settings:[white] %d", len(se.settings))
+	if se.modified {
+		text += "  [yellow]Modified[white]"
+	}
+	if message != "" {
+		text += "  " + message
+	}
+	se.statusBar.SetText(text)
+}
+
+// toggleHelp toggles the visibility of the help text
+func (se *SettingsEditor) toggleHelp() {
+	se.helpVisible = !se.helpVisible
+	se.updateLayout()
+	se.app.Draw()
+}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/armaniacs/usacloud-update/internal/config"
+)
+
+func newTestSettingsEditor(t *testing.T) (*SettingsEditor, string) {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "usacloud-update.conf")
+	cfg, err := config.ReadIntegratedConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadIntegratedConfigFile failed: %v", err)
+	}
+	se := NewSettingsEditor(cfg)
+	se.populateSettingList()
+	return se, configPath
+}
+
+// settingIndex returns the position of a setting in the editor's list
+func settingIndex(t *testing.T, se *SettingsEditor, section, key string) int {
+	t.Helper()
+	for i, s := range se.settings {
+		if s.Section == section && s.Key == key {
+			return i
+		}
+	}
+	t.Fatalf("Setting [%s] %s not listed", section, key)
+	return -1
+}
+
+func TestSettingsEditor_EditSettings(t *testing.T) {
+	se, configPath := newTestSettingsEditor(t)
+	if se.settingList.GetItemCount() != len(se.settings) || len(se.settings) == 0 {
+		t.Fatalf("Expected every setting to be listed, got %d items", se.settingList.GetItemCount())
+	}
+
+	// A boolean setting toggles without the edit form
+	verbose := settingIndex(t, se, "general", "verbose")
+	se.activateSetting(verbose)
+	if se.editing || !se.config.General.Verbose || !se.Modified() {
+		t.Error("Expected verbose to be toggled on")
+	}
+
+	// A number is typed in the edit form; an invalid one keeps it open
+	maxSuggestions := settingIndex(t, se, "validation", "max_suggestions")
+	se.activateSetting(maxSuggestions)
+	if !se.editing {
+		t.Fatal("Expected the edit form to open for a number")
+	}
+	se.finishEdit(maxSuggestions, "many")
+	if !se.editing || !strings.Contains(se.statusBar.GetText(false), "max_suggestions") {
+		t.Errorf("Expected an invalid number to be rejected, status %q", se.statusBar.GetText(false))
+	}
+	se.finishEdit(maxSuggestions, "8")
+	if se.editing || se.config.Validation.MaxSuggestions != 8 {
+		t.Errorf("Expected max_suggestions 8, got %d", se.config.Validation.MaxSuggestions)
+	}
+
+	// A setting taking one of a fixed set only accepts those values
+	reportLevel := settingIndex(t, se, "output", "report_level")
+	se.activateSetting(reportLevel)
+	se.finishEdit(reportLevel, "verbose")
+	if se.config.Output.ReportLevel != "summary" {
+		t.Errorf("Expected an unknown report level to be rejected, got %q", se.config.Output.ReportLevel)
+	}
+	se.finishEdit(reportLevel, "detailed")
+	if se.config.Output.ReportLevel != "detailed" {
+		t.Errorf("Expected report level detailed, got %q", se.config.Output.ReportLevel)
+	}
+
+	se.save()
+	if se.Modified() {
+		t.Error("Expected saving to clear the modified state")
+	}
+	saved, err := config.ReadIntegratedConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadIntegratedConfigFile failed: %v", err)
+	}
+	if !saved.General.Verbose || saved.Validation.MaxSuggestions != 8 || saved.Output.ReportLevel != "detailed" {
+		t.Errorf("Expected the edits to be saved, got %+v %+v %+v", saved.General, saved.Validation, saved.Output)
+	}
+}
+
+func TestSettingsEditor_QuitWithUnsavedChanges(t *testing.T) {
+	se, _ := newTestSettingsEditor(t)
+	se.activateSetting(settingIndex(t, se, "general", "verbose"))
+
+	se.quit()
+	if !se.confirmQuit || !strings.Contains(se.statusBar.GetText(false), "Unsaved changes") {
+		t.Error("Expected the first q to ask for confirmation")
+	}
+}
+
+func TestSettingDisplayValue(t *testing.T) {
+	secret := config.SettingInfo{Key: "api_token", Kind: config.SettingKindString, Value: "abcdefghijklmnop", Sensitive: true}
+	if got := settingDisplayValue(secret, false); got == "abcdefghijklmnop" || got == "" {
+		t.Errorf("Expected a sensitive value to be masked, got %q", got)
+	}
+	if got := settingDisplayValue(secret, true); got != "abcdefghijklmnop" {
+		t.Errorf("Expected the selected sensitive value to be shown, got %q", got)
+	}
+	threshold := config.SettingInfo{Key: "suggestion_confidence_threshold", Kind: config.SettingKindFloat, Value: 0.5}
+	if got := settingDisplayValue(threshold, false); got != "0.5" {
+		t.Errorf("Expected 0.5, got %q", got)
+	}
+}
@@ -0,0 +1,159 @@
+// Package validation provides command validation functionality for usacloud-update
+package validation
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCatalogVersion is the usacloud version validated against when none is specified
+const DefaultCatalogVersion = "1.1"
+
+//go:embed catalogs/*.json
+var catalogFS embed.FS
+
+// CommandCatalog represents the set of main commands available in one usacloud version
+type CommandCatalog struct {
+	Version string   `json:"version"` // usacloud version (e.g. "1.1")
+	IaaS    []string `json:"iaas"`    // IaaS commands
+	Misc    []string `json:"misc"`    // Miscellaneous commands
+	Root    []string `json:"root"`    // Root commands
+
+	// FileOptions lists, per main command and subcommand, the options whose
+	// value is a local file path (without the leading "--")
+	FileOptions map[string]map[string][]string `json:"file_options"`
+
+	// Options lists, per main command and subcommand, the options the command
+	// accepts besides the common ones (without the leading "--"). Only the
+	// commands listed here are checked for unknown options.
+	Options map[string]map[string][]string `json:"options"`
+}
+
+var (
+	catalogsOnce sync.Once
+	catalogs     map[string]*CommandCatalog
+	catalogsErr  error
+)
+
+// loadCatalogs parses every embedded catalog once
+func loadCatalogs() (map[string]*CommandCatalog, error) {
+	catalogsOnce.Do(func() {
+		entries, err := catalogFS.ReadDir("catalogs")
+		if err != nil {
+			catalogsErr = err
+			return
+		}
+
+		catalogs = make(map[string]*CommandCatalog, len(entries))
+		for _, entry := range entries {
+			data, err := catalogFS.ReadFile(path.Join("catalogs", entry.Name()))
+			if err != nil {
+				catalogsErr = err
+				return
+			}
+
+			var catalog CommandCatalog
+			if err := json.Unmarshal(data, &catalog); err != nil {
+				catalogsErr = fmt.Errorf("invalid command catalog %s: %w", entry.Name(), err)
+				return
+			}
+			catalogs[catalog.Version] = &catalog
+		}
+	})
+
+	return catalogs, catalogsErr
+}
+
+// AvailableCatalogVersions returns the embedded catalog versions in ascending order
+func AvailableCatalogVersions() []string {
+	loaded, err := loadCatalogs()
+	if err != nil {
+		return nil
+	}
+
+	versions := make([]string, 0, len(loaded))
+	for version := range loaded {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+
+	return versions
+}
+
+// LoadCommandCatalog returns the embedded catalog for the given usacloud version.
+// An empty version selects DefaultCatalogVersion.
+func LoadCommandCatalog(version string) (*CommandCatalog, error) {
+	if version == "" {
+		version = DefaultCatalogVersion
+	}
+
+	loaded, err := loadCatalogs()
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, exists := loaded[strings.TrimPrefix(version, "v")]
+	if !exists {
+		return nil, fmt.Errorf("unknown usacloud version %q (available: %s)",
+			version, strings.Join(AvailableCatalogVersions(), ", "))
+	}
+
+	return catalog, nil
+}
+
+// compareVersions compares dotted version strings numerically
+func compareVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+		if aNum != bNum {
+			return aNum - bNum
+		}
+	}
+
+	return 0
+}
+
+// catalogVersionsWithCommand returns the catalog versions that contain the given main command
+func catalogVersionsWithCommand(command string) []string {
+	var versions []string
+	for _, version := range AvailableCatalogVersions() {
+		catalog, err := LoadCommandCatalog(version)
+		if err != nil {
+			continue
+		}
+		if catalog.contains(command) {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions
+}
+
+// contains checks whether the catalog defines the given main command
+func (c *CommandCatalog) contains(command string) bool {
+	for _, list := range [][]string{c.IaaS, c.Misc, c.Root} {
+		for _, cmd := range list {
+			if cmd == command {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAvailableCatalogVersions(t *testing.T) {
+	versions := AvailableCatalogVersions()
+
+	expected := []string{"1.0", "1.1"}
+	if len(versions) != len(expected) {
+		t.Fatalf("Expected versions %v, got %v", expected, versions)
+	}
+	for i, version := range expected {
+		if versions[i] != version {
+			t.Errorf("Expected version %s at index %d, got %s", version, i, versions[i])
+		}
+	}
+}
+
+func TestLoadCommandCatalog(t *testing.T) {
+	tests := []struct {
+		name            string
+		version         string
+		expectedVersion string
+	}{
+		{"default version", "", DefaultCatalogVersion},
+		{"explicit 1.1", "1.1", "1.1"},
+		{"explicit 1.0", "1.0", "1.0"},
+		{"v prefix", "v1.0", "1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog, err := LoadCommandCatalog(tt.version)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if catalog.Version != tt.expectedVersion {
+				t.Errorf("Expected version %s, got %s", tt.expectedVersion, catalog.Version)
+			}
+			if len(catalog.IaaS) == 0 || len(catalog.Misc) == 0 || len(catalog.Root) == 0 {
+				t.Errorf("Expected all command groups to be populated for version %s", catalog.Version)
+			}
+		})
+	}
+}
+
+func TestLoadCommandCatalogUnknownVersion(t *testing.T) {
+	_, err := LoadCommandCatalog("9.9")
+	if err == nil {
+		t.Fatal("Expected error for unknown version")
+	}
+
+	for _, version := range AvailableCatalogVersions() {
+		if !strings.Contains(err.Error(), version) {
+			t.Errorf("Expected error to list available version %s, got: %v", version, err)
+		}
+	}
+}
+
+func TestNewMainCommandValidatorForVersion(t *testing.T) {
+	if _, err := NewMainCommandValidatorForVersion("0.1"); err == nil {
+		t.Error("Expected error for unknown version")
+	}
+
+	validator, err := NewMainCommandValidatorForVersion("1.0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if validator.Version() != "1.0" {
+		t.Errorf("Expected version 1.0, got %s", validator.Version())
+	}
+
+	if NewMainCommandValidator().Version() != DefaultCatalogVersion {
+		t.Errorf("Expected default validator to use version %s", DefaultCatalogVersion)
+	}
+}
+
+func TestValidateAcrossCatalogVersions(t *testing.T) {
+	v10, err := NewMainCommandValidatorForVersion("1.0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	v11, err := NewMainCommandValidatorForVersion("1.1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		command    string
+		validIn10  bool
+		validIn11  bool
+		errorIn10  string
+		messageHas string
+	}{
+		{"server", true, true, "", ""},
+		{"autoscale", false, true, "unknown_command", "1.1"},
+		{"certificateauthority", false, true, "unknown_command", "1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			result10 := v10.Validate(tt.command)
+			result11 := v11.Validate(tt.command)
+
+			if result10.IsValid != tt.validIn10 {
+				t.Errorf("1.0: expected IsValid=%v, got %v", tt.validIn10, result10.IsValid)
+			}
+			if result11.IsValid != tt.validIn11 {
+				t.Errorf("1.1: expected IsValid=%v, got %v", tt.validIn11, result11.IsValid)
+			}
+			if tt.errorIn10 != "" && result10.ErrorType != tt.errorIn10 {
+				t.Errorf("1.0: expected error type %s, got %s", tt.errorIn10, result10.ErrorType)
+			}
+			if tt.messageHas != "" && !strings.Contains(result10.Message, tt.messageHas) {
+				t.Errorf("1.0: expected message to mention %s, got: %s", tt.messageHas, result10.Message)
+			}
+		})
+	}
+}
+
+func TestSubcommandValidatorFollowsCatalogVersion(t *testing.T) {
+	v10, err := NewMainCommandValidatorForVersion("1.0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	subValidator10 := NewSubcommandValidator(v10)
+	subValidator11 := NewSubcommandValidator(NewMainCommandValidator())
+
+	if subValidator10.IsValidSubcommand("autoscale", "list") {
+		t.Error("Expected 'autoscale list' to be invalid in 1.0")
+	}
+	if !subValidator11.IsValidSubcommand("autoscale", "list") {
+		t.Error("Expected 'autoscale list' to be valid in 1.1")
+	}
+}
@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	compoundCommandsOnce sync.Once
+	compoundCommands     map[string]string // "iso image" -> "iso-image"
+)
+
+// loadCompoundCommands collects the hyphenated command names of every catalog
+// version, keyed by their space-separated spelling. Deprecated v0 commands
+// such as iso-image are left out, as usacloud never accepted them as two
+// words, and so are names whose first word is itself a command (e.g.
+// "server plan"), since "server" followed by a subcommand is the more likely
+// reading.
+func loadCompoundCommands() map[string]string {
+	compoundCommandsOnce.Do(func() {
+		known := make(map[string]bool)
+		loaded, _ := loadCatalogs()
+		for _, catalog := range loaded {
+			for _, names := range [][]string{catalog.IaaS, catalog.Misc, catalog.Root} {
+				for _, name := range names {
+					known[name] = true
+				}
+			}
+		}
+
+		compoundCommands = make(map[string]string)
+		for name := range known {
+			first, rest, ok := strings.Cut(name, "-")
+			if !ok || strings.Contains(rest, "-") || known[first] {
+				continue
+			}
+			compoundCommands[first+" "+rest] = name
+		}
+	})
+
+	return compoundCommands
+}
+
+// CompoundCommandName returns the hyphenated command name written as two
+// words, e.g. ("iso", "image") -> "iso-image"
+func CompoundCommandName(first, second string) (string, bool) {
+	name, ok := loadCompoundCommands()[first+" "+second]
+	return name, ok
+}
@@ -16,6 +16,27 @@ type ErrorContext struct {
 	HelpURL         string             // Help URL
 }
 
+// LineErrorContext is the error context of one line of a file
+type LineErrorContext struct {
+	LineNumber int
+	Context    *ErrorContext
+}
+
+// maxGroupedLines is the number of line numbers listed per group before eliding the rest
+const maxGroupedLines = 10
+
+// Suggestion levels: how many suggestions are shown and in how much detail.
+// Level 1 shows the best suggestion alone, levels 3 and up add similarity
+// scores and level 5 adds example usage from the help database.
+const (
+	MinSuggestionLevel     = 1
+	MaxSuggestionLevel     = 5
+	DefaultSuggestionLevel = 3
+)
+
+// maxSuggestionExamples is the number of examples shown per suggestion at MaxSuggestionLevel
+const maxSuggestionExamples = 2
+
 // ValidationIssue represents a validation issue found
 type ValidationIssue struct {
 	Type      IssueType       // Issue type
@@ -34,6 +55,10 @@ const (
 	IssueDeprecatedCommand
 	IssueSyntaxError
 	IssueAmbiguousCommand
+	IssueOutputFormatMismatch
+	IssueMissingFile
+	IssueInvalidOption
+	IssueMissingAssumeYes
 )
 
 // UserIntent represents inferred user intent
@@ -77,6 +102,9 @@ type Messages struct {
 	SeeAlso            string
 	MultipleIssues     string
 	FixedExample       string
+	Occurrences        string
+	DidYouMean         string
+	ExampleUsage       string
 }
 
 // ComprehensiveErrorFormatter provides comprehensive error formatting
@@ -86,6 +114,7 @@ type ComprehensiveErrorFormatter struct {
 	deprecatedDetector *DeprecatedCommandDetector
 	colorEnabled       bool
 	language           string // "ja" or "en"
+	suggestionLevel    int    // MinSuggestionLevel to MaxSuggestionLevel
 }
 
 // NewComprehensiveErrorFormatter creates a new comprehensive error formatter
@@ -106,6 +135,7 @@ func NewComprehensiveErrorFormatter(
 		deprecatedDetector: detector,
 		colorEnabled:       colorEnabled,
 		language:           language,
+		suggestionLevel:    DefaultSuggestionLevel,
 	}
 }
 
@@ -197,6 +227,88 @@ func (f *ComprehensiveErrorFormatter) FormatSubcommandResult(result *SubcommandV
 	return f.FormatError(context)
 }
 
+// FormatGroupedErrors renders the issues of a whole file with every issue of
+// the same type and component (e.g. the same misspelled command) collapsed
+// into one block listing the lines it occurs on. Groups are ordered by their
+// first occurrence.
+func (f *ComprehensiveErrorFormatter) FormatGroupedErrors(lines []LineErrorContext) string {
+	type issueKey struct {
+		issueType IssueType
+		component string
+	}
+	type issueGroup struct {
+		issue      ValidationIssue
+		lines      []int
+		suggestion *SimilarityResult
+	}
+
+	var order []issueKey
+	groups := make(map[issueKey]*issueGroup)
+	for _, l := range lines {
+		if l.Context == nil {
+			continue
+		}
+		for _, issue := range l.Context.DetectedIssues {
+			key := issueKey{issue.Type, issue.Component}
+			g, ok := groups[key]
+			if !ok {
+				g = &issueGroup{issue: issue}
+				groups[key] = g
+				order = append(order, key)
+			}
+			if len(g.lines) == 0 || g.lines[len(g.lines)-1] != l.LineNumber {
+				g.lines = append(g.lines, l.LineNumber)
+			}
+
+			// Suggestions belong to the whole line, so they are only used when it has a single issue
+			if len(l.Context.DetectedIssues) != 1 || (issue.Type != IssueInvalidMainCommand && issue.Type != IssueInvalidSubCommand) {
+				continue
+			}
+			for i, s := range l.Context.Suggestions {
+				if s.Command != issue.Component && (g.suggestion == nil || s.Score > g.suggestion.Score) {
+					g.suggestion = &l.Context.Suggestions[i]
+				}
+			}
+		}
+	}
+	if len(order) == 0 {
+		return f.formatUnknownError()
+	}
+
+	visual := f.getVisualElements()
+	messages := f.getMessages()
+	blocks := make([]string, 0, len(order))
+	for _, key := range order {
+		g := groups[key]
+		icon := visual.ErrorIcon
+		if g.issue.Severity == SeverityWarning {
+			icon = visual.WarningIcon
+		}
+
+		block := fmt.Sprintf("%s %s\n   %s", icon, f.generateLocalizedMessage(&g.issue, messages),
+			fmt.Sprintf(messages.Occurrences, len(g.lines), formatLineList(g.lines)))
+		if g.suggestion != nil {
+			block += fmt.Sprintf("\n%s %s", visual.SuggestionIcon, fmt.Sprintf(messages.DidYouMean, g.suggestion.Command))
+		}
+		blocks = append(blocks, f.applyColor(block, g.issue.Severity))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// formatLineList lists line numbers, eliding those after maxGroupedLines
+func formatLineList(lines []int) string {
+	parts := make([]string, 0, maxGroupedLines+1)
+	for i, n := range lines {
+		if i == maxGroupedLines {
+			parts = append(parts, "...")
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d", n))
+	}
+	return strings.Join(parts, ", ")
+}
+
 // analyzeErrorContext analyzes the error context and returns analysis
 func (f *ComprehensiveErrorFormatter) analyzeErrorContext(context *ErrorContext) *ErrorAnalysis {
 	analysis := &ErrorAnalysis{
@@ -305,28 +417,47 @@ func (f *ComprehensiveErrorFormatter) formatSuggestions(context *ErrorContext, v
 		return ""
 	}
 
+	// The lowest level shows the best suggestion alone, tersely
+	if f.suggestionLevel <= MinSuggestionLevel {
+		return fmt.Sprintf("%s %s", visual.SuggestionIcon, fmt.Sprintf(messages.DidYouMean, context.Suggestions[0].Command))
+	}
+
 	var sections []string
 
 	// Suggestions header
 	header := fmt.Sprintf("%s %s", visual.SuggestionIcon, messages.SuggestionsHeader)
 	sections = append(sections, header)
 
-	// List suggestions with scores
+	// List up to one suggestion per level, with scores from level 3
 	for i, suggestion := range context.Suggestions {
-		if i >= 3 { // Limit to top 3 suggestions
+		if i >= f.suggestionLevel {
 			break
 		}
-		scorePercent := int(suggestion.Score * 100)
-		suggestionLine := fmt.Sprintf("   • %s (%s: %d%%)",
-			suggestion.Command,
-			f.getScoreLabel(),
-			scorePercent)
+		suggestionLine := fmt.Sprintf("   • %s", suggestion.Command)
+		if f.suggestionLevel >= DefaultSuggestionLevel {
+			suggestionLine += fmt.Sprintf(" (%s: %d%%)", f.getScoreLabel(), int(suggestion.Score*100))
+		}
 		sections = append(sections, suggestionLine)
+
+		if f.suggestionLevel >= MaxSuggestionLevel {
+			for _, example := range f.suggestionExamples(suggestion.Command) {
+				sections = append(sections, "     "+fmt.Sprintf(messages.ExampleUsage, example))
+			}
+		}
 	}
 
 	return strings.Join(sections, "\n")
 }
 
+// suggestionExamples returns example usage of a suggested command from the help database
+func (f *ComprehensiveErrorFormatter) suggestionExamples(command string) []string {
+	examples := newHelpDatabase(f.language).Examples(command)
+	if len(examples) > maxSuggestionExamples {
+		examples = examples[:maxSuggestionExamples]
+	}
+	return examples
+}
+
 // formatMigrationInfo formats migration/deprecation information
 func (f *ComprehensiveErrorFormatter) formatMigrationInfo(context *ErrorContext, visual *VisualElements, messages *Messages) string {
 	if context.DeprecationInfo == nil {
@@ -493,6 +624,9 @@ func (f *ComprehensiveErrorFormatter) getMessages() *Messages {
 			SeeAlso:            "See also: %s",
 			MultipleIssues:     "Multiple issues detected:",
 			FixedExample:       "Fixed example:",
+			Occurrences:        "used %d times (lines %s)",
+			DidYouMean:         "did you mean '%s'?",
+			ExampleUsage:       "e.g. %s",
 		}
 	}
 
@@ -508,6 +642,9 @@ func (f *ComprehensiveErrorFormatter) getMessages() *Messages {
 		SeeAlso:            "詳細情報: %s",
 		MultipleIssues:     "複数の問題が検出されました:",
 		FixedExample:       "修正例:",
+		Occurrences:        "%d回使用 (行 %s)",
+		DidYouMean:         "もしかして '%s' ですか？",
+		ExampleUsage:       "例: %s",
 	}
 }
 
@@ -568,6 +705,19 @@ func (f *ComprehensiveErrorFormatter) SetColorEnabled(enabled bool) {
 	}
 }
 
+// SetSuggestionLevel sets how many suggestions are shown and in how much
+// detail; levels outside MinSuggestionLevel to MaxSuggestionLevel are ignored
+func (f *ComprehensiveErrorFormatter) SetSuggestionLevel(level int) {
+	if level >= MinSuggestionLevel && level <= MaxSuggestionLevel {
+		f.suggestionLevel = level
+	}
+}
+
+// SuggestionLevel returns the suggestion level
+func (f *ComprehensiveErrorFormatter) SuggestionLevel() int {
+	return f.suggestionLevel
+}
+
 // IsColorEnabled returns whether color output is enabled
 func (f *ComprehensiveErrorFormatter) IsColorEnabled() bool {
 	return f.colorEnabled
@@ -598,6 +748,14 @@ func GetIssueTypeString(issueType IssueType) string {
 		return "SyntaxError"
 	case IssueAmbiguousCommand:
 		return "AmbiguousCommand"
+	case IssueOutputFormatMismatch:
+		return "OutputFormatMismatch"
+	case IssueMissingFile:
+		return "MissingFile"
+	case IssueInvalidOption:
+		return "InvalidOption"
+	case IssueMissingAssumeYes:
+		return "MissingAssumeYes"
 	default:
 		return "Unknown"
 	}
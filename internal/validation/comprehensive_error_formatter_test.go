@@ -668,3 +668,92 @@ func TestGenerateDynamicHelp(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatGroupedErrors(t *testing.T) {
+	formatter := NewDefaultComprehensiveErrorFormatter()
+	formatter.SetColorEnabled(false)
+
+	invalidServ := func() *ErrorContext {
+		return &ErrorContext{
+			InputCommand:   "usacloud serv list",
+			DetectedIssues: []ValidationIssue{{Type: IssueInvalidMainCommand, Severity: SeverityError, Component: "serv"}},
+			Suggestions:    []SimilarityResult{{Command: "server", Score: 0.9}, {Command: "service", Score: 0.6}},
+		}
+	}
+	var lines []LineErrorContext
+	for i := 1; i <= 12; i++ {
+		lines = append(lines, LineErrorContext{LineNumber: i * 2, Context: invalidServ()})
+	}
+	lines = append(lines, LineErrorContext{LineNumber: 5, Context: &ErrorContext{
+		InputCommand:   "usacloud summary",
+		DetectedIssues: []ValidationIssue{{Type: IssueDeprecatedCommand, Severity: SeverityWarning, Component: "summary", Message: "'summary' は廃止されたコマンドです"}},
+	}})
+
+	got := formatter.FormatGroupedErrors(lines)
+	want := "❌ エラー: 'serv' は有効なusacloudコマンドではありません\n" +
+		"   12回使用 (行 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, ...)\n" +
+		"💡 もしかして 'server' ですか？\n\n" +
+		"⚠️ 'summary' は廃止されたコマンドです\n" +
+		"   1回使用 (行 5)"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	formatter.SetLanguage("en")
+	if got := formatter.FormatGroupedErrors(lines[:2]); !strings.Contains(got, "used 2 times (lines 2, 4)") || !strings.Contains(got, "did you mean 'server'?") {
+		t.Errorf("unexpected English output:\n%s", got)
+	}
+
+	if got := formatter.FormatGroupedErrors(nil); !strings.Contains(got, "unknown error") {
+		t.Errorf("expected unknown error for no issues, got %q", got)
+	}
+}
+
+func TestFormatError_SuggestionLevel(t *testing.T) {
+	context := &ErrorContext{
+		InputCommand:   "usacloud serv list",
+		DetectedIssues: []ValidationIssue{{Type: IssueInvalidMainCommand, Severity: SeverityError, Component: "serv"}},
+		Suggestions: []SimilarityResult{
+			{Command: "server", Score: 0.86},
+			{Command: "self", Score: 0.5},
+			{Command: "sim", Score: 0.4},
+			{Command: "sshkey", Score: 0.3},
+		},
+	}
+
+	formatter := NewDefaultComprehensiveErrorFormatter()
+	formatter.SetColorEnabled(false)
+	if formatter.SuggestionLevel() != DefaultSuggestionLevel {
+		t.Fatalf("Expected the default level, got %d", formatter.SuggestionLevel())
+	}
+
+	tests := []struct {
+		level   int
+		want    []string
+		notWant []string
+	}{
+		{1, []string{"もしかして 'server' ですか？"}, []string{"self", "類似度"}},
+		{2, []string{"• server", "• self"}, []string{"• sim", "類似度"}},
+		{3, []string{"• server (類似度: 86%)", "• sim (類似度: 40%)"}, []string{"sshkey", "例:"}},
+		{5, []string{"• sshkey (類似度: 30%)", "例: usacloud server read [ID]"}, nil},
+	}
+	for _, tt := range tests {
+		formatter.SetSuggestionLevel(tt.level)
+		out := formatter.FormatError(context)
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("level %d: expected %q in:\n%s", tt.level, want, out)
+			}
+		}
+		for _, notWant := range tt.notWant {
+			if strings.Contains(out, notWant) {
+				t.Errorf("level %d: unexpected %q in:\n%s", tt.level, notWant, out)
+			}
+		}
+	}
+
+	formatter.SetSuggestionLevel(MaxSuggestionLevel + 1)
+	if formatter.SuggestionLevel() != 5 {
+		t.Errorf("Expected an out-of-range level to be ignored, got %d", formatter.SuggestionLevel())
+	}
+}
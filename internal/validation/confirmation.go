@@ -0,0 +1,50 @@
+// Package validation provides command validation functionality for usacloud-update
+package validation
+
+// ConfirmationSubcommands are the destructive subcommands usacloud v1 asks to
+// confirm before running. Without -y/--assumeyes the command waits for an
+// answer, so scripts run without a terminal, such as from cron or CI, hang or
+// fail at the prompt.
+var ConfirmationSubcommands = []string{"delete", "shutdown", "reset"}
+
+// RequiresConfirmation reports whether cmdLine runs one of the ConfirmationSubcommands
+func RequiresConfirmation(cmdLine *CommandLine) bool {
+	if cmdLine == nil {
+		return false
+	}
+	for _, sub := range ConfirmationSubcommands {
+		if cmdLine.SubCommand == sub {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAssumeYes reports whether cmdLine answers the confirmation up front with
+// -y or --assumeyes
+func HasAssumeYes(cmdLine *CommandLine) bool {
+	if cmdLine == nil {
+		return false
+	}
+	if cmdLine.HasFlag("assumeyes") || cmdLine.HasOption("assumeyes") {
+		return true
+	}
+	for _, arg := range cmdLine.Arguments {
+		if arg == "-y" {
+			return true
+		}
+	}
+	// The parser reads the word after an unknown option as its value
+	for _, value := range cmdLine.Options {
+		if value == "-y" {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingAssumeYes reports whether cmdLine stops at a confirmation prompt
+// when run without a terminal
+func MissingAssumeYes(cmdLine *CommandLine) bool {
+	return RequiresConfirmation(cmdLine) && !HasAssumeYes(cmdLine)
+}
@@ -0,0 +1,70 @@
+package validation
+
+import (
+	"bufio"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMissingAssumeYes(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"usacloud server delete 123456789012", true},
+		{"usacloud server shutdown $SERVER_ID --zone is1a", true},
+		{"usacloud disk delete 123456789012 | tee delete.log", true},
+		{"usacloud server delete -y 123456789012", false},
+		{"usacloud server delete 123456789012 --zone is1a -y", false},
+		{"usacloud server shutdown 123456789012 --assumeyes", false},
+		{"usacloud server reset --assumeyes=true 123456789012", false},
+		{"usacloud server list", false},
+		{"usacloud server boot 123456789012", false},
+	}
+
+	parser := NewParser()
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			cmdLine, err := parser.Parse(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := MissingAssumeYes(cmdLine); got != tt.want {
+				t.Errorf("MissingAssumeYes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if MissingAssumeYes(nil) {
+		t.Error("expected no confirmation for a nil command")
+	}
+}
+
+func TestMissingAssumeYes_Fixture(t *testing.T) {
+	f, err := os.Open("../../testdata/transform/destructive_commands.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	parser := NewParser()
+	var got []int
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		cmdLine, err := parser.Parse(scanner.Text())
+		if err != nil {
+			continue
+		}
+		if MissingAssumeYes(cmdLine) {
+			got = append(got, n)
+		}
+	}
+
+	// The four commands without -y and the two v0 lines; not those with
+	// -y/--assumeyes or without a prompt
+	want := []int{5, 6, 7, 8, 15, 16}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got lines %v, want %v", got, want)
+	}
+}
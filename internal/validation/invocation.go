@@ -0,0 +1,184 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Invocation is the usacloud command found at the start of a shell line
+type Invocation struct {
+	Prefix  string // text before the binary: indentation, assignments and wrappers such as sudo or env
+	Binary  string // the binary as written: usacloud, a path to it or a variable holding one
+	Command string // the line from the binary on, with the binary written as "usacloud"
+}
+
+// invocationWrappers lists the commands that run their arguments as a command,
+// with the options of each that take a separate value
+var invocationWrappers = map[string]map[string]bool{
+	"sudo":    {"-u": true, "-g": true, "-C": true, "-D": true, "-h": true, "-p": true, "-U": true, "-r": true, "-t": true, "-T": true},
+	"env":     {"-u": true, "-C": true},
+	"command": {},
+	"exec":    {"-a": true},
+	"nohup":   {},
+	"time":    {},
+	"nice":    {"-n": true},
+}
+
+var (
+	assignmentPattern       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+	binaryAssignmentPattern = regexp.MustCompile(`^\s*(?:(?:export|readonly|local|declare(?:\s+-\w+)*)\s+)?([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+	variablePattern         = regexp.MustCompile(`^\$(?:([A-Za-z_][A-Za-z0-9_]*)|\{([A-Za-z_][A-Za-z0-9_]*)\})$`)
+)
+
+// FindInvocation locates the usacloud command a line runs. Leading variable
+// assignments and wrappers (sudo, env, command, exec, nohup, time, nice) with
+// their options are skipped; the command word is then usacloud, a path ending
+// in /usacloud, or a reference to one of binaryVars, the shell variables known
+// to hold the path of the binary (see BinaryVariables). Commands elsewhere in
+// the line, such as after a pipe, are not found.
+func FindInvocation(line string, binaryVars map[string]bool) (Invocation, bool) {
+	var options map[string]bool // options of the current wrapper, nil outside one
+	wrapper := ""
+	for start, end := nextWord(line, 0); start < end; start, end = nextWord(line, end) {
+		word := line[start:end]
+		switch {
+		case options != nil && word == "--":
+			wrapper, options = "", nil // the command follows
+		case options != nil && strings.HasPrefix(word, "-") && len(word) > 1:
+			if wrapper == "command" && (word == "-v" || word == "-V") {
+				return Invocation{}, false // looks the command up instead of running it
+			}
+			if options[word] {
+				_, end = nextWord(line, end)
+			}
+		case (options == nil || wrapper == "env") && assignmentPattern.MatchString(word):
+			// NAME=value before the command
+		case invocationWrappers[word] != nil:
+			wrapper, options = word, invocationWrappers[word]
+		case isBinaryWord(word, binaryVars):
+			return Invocation{
+				Prefix:  line[:start],
+				Binary:  word,
+				Command: "usacloud" + line[end:],
+			}, true
+		default:
+			return Invocation{}, false
+		}
+	}
+	return Invocation{}, false
+}
+
+// Wrapped reports whether the line differs from a plain "usacloud ..." command
+func (inv Invocation) Wrapped() bool {
+	return strings.TrimSpace(inv.Prefix) != "" || inv.Binary != "usacloud"
+}
+
+// Rewrap puts the prefix and binary of the original line back into a
+// transformed Command. Text a transformation added before the command, such
+// as the "# " of a commented-out line, stays after the indentation.
+func (inv Invocation) Rewrap(transformed string) string {
+	i := strings.Index(transformed, "usacloud")
+	if i < 0 {
+		return transformed
+	}
+	wrappers := strings.TrimLeft(inv.Prefix, " \t")
+	indent := inv.Prefix[:len(inv.Prefix)-len(wrappers)]
+	return indent + transformed[:i] + wrappers + inv.Binary + transformed[i+len("usacloud"):]
+}
+
+// BinaryVariables returns the shell variables lines assign the usacloud binary
+// to, such as USACLOUD=/usr/local/bin/usacloud, USACLOUD=$(which usacloud) or
+// USACLOUD=${USACLOUD:-usacloud}, optionally after export, readonly, local or declare
+func BinaryVariables(lines []string) map[string]bool {
+	vars := map[string]bool{}
+	for _, line := range lines {
+		m := binaryAssignmentPattern.FindStringSubmatch(line)
+		if m != nil && isBinaryValue(m[2]) {
+			vars[m[1]] = true
+		}
+	}
+	return vars
+}
+
+// isBinaryValue reports whether the value of an assignment is the usacloud binary
+func isBinaryValue(value string) bool {
+	value = unquote(strings.TrimSpace(value))
+	switch {
+	case strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}"):
+		if i := strings.Index(value, ":-"); i >= 0 {
+			return isBinaryValue(value[i+2 : len(value)-1])
+		}
+		return false
+	case strings.HasPrefix(value, "$(") && strings.HasSuffix(value, ")"):
+		value = value[2 : len(value)-1]
+	case strings.HasPrefix(value, "`") && strings.HasSuffix(value, "`") && len(value) > 1:
+		value = value[1 : len(value)-1]
+	default:
+		return isBinaryPath(value)
+	}
+
+	// $(which usacloud) / $(command -v usacloud)
+	fields := strings.Fields(value)
+	switch {
+	case len(fields) == 2 && fields[0] == "which":
+		return isBinaryPath(fields[1])
+	case len(fields) == 3 && fields[0] == "command" && fields[1] == "-v":
+		return isBinaryPath(fields[2])
+	}
+	return false
+}
+
+// isBinaryWord reports whether a command word runs the usacloud binary
+func isBinaryWord(word string, binaryVars map[string]bool) bool {
+	word = unquote(word)
+	if m := variablePattern.FindStringSubmatch(word); m != nil {
+		return binaryVars[m[1]+m[2]]
+	}
+	return isBinaryPath(word)
+}
+
+// isBinaryPath reports whether path names the usacloud binary
+func isBinaryPath(path string) bool {
+	return path == "usacloud" || strings.HasSuffix(path, "/usacloud")
+}
+
+// unquote removes one pair of matching surrounding quotes
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// nextWord returns the bounds of the first word of line at or after from.
+// Quoted text and escaped characters are part of the word; start == end when
+// there are no more words.
+func nextWord(line string, from int) (start, end int) {
+	start = from
+	for start < len(line) && (line[start] == ' ' || line[start] == '\t') {
+		start++
+	}
+	var quote byte
+	end = start
+	for ; end < len(line); end++ {
+		c := line[end]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				end++
+			}
+		case c == '\\':
+			end++
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ' ' || c == '\t':
+			return start, end
+		}
+	}
+	if end > len(line) {
+		end = len(line)
+	}
+	return start, end
+}
@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindInvocation(t *testing.T) {
+	vars := map[string]bool{"USACLOUD": true}
+
+	tests := []struct {
+		line string
+		want Invocation
+		ok   bool
+	}{
+		{"usacloud server list", Invocation{Prefix: "", Binary: "usacloud", Command: "usacloud server list"}, true},
+		{"  sudo usacloud server list", Invocation{Prefix: "  sudo ", Binary: "usacloud", Command: "usacloud server list"}, true},
+		{"sudo -u deploy -E usacloud disk list", Invocation{Prefix: "sudo -u deploy -E ", Binary: "usacloud", Command: "usacloud disk list"}, true},
+		{"env -i ZONE=tk1v usacloud zone list", Invocation{Prefix: "env -i ZONE=tk1v ", Binary: "usacloud", Command: "usacloud zone list"}, true},
+		{`FOO="a b" nice -n 5 usacloud server list`, Invocation{Prefix: `FOO="a b" nice -n 5 `, Binary: "usacloud", Command: "usacloud server list"}, true},
+		{"sudo -- usacloud server list", Invocation{Prefix: "sudo -- ", Binary: "usacloud", Command: "usacloud server list"}, true},
+		{"/usr/local/bin/usacloud server list", Invocation{Prefix: "", Binary: "/usr/local/bin/usacloud", Command: "usacloud server list"}, true},
+		{"$USACLOUD server list", Invocation{Prefix: "", Binary: "$USACLOUD", Command: "usacloud server list"}, true},
+		{`"${USACLOUD}" server list`, Invocation{Prefix: "", Binary: `"${USACLOUD}"`, Command: "usacloud server list"}, true},
+		{"$DOCKER server list", Invocation{}, false}, // not a binary variable
+		{"command -v usacloud", Invocation{}, false},
+		{"echo usacloud server list", Invocation{}, false},
+		{"USACLOUD=/usr/bin/usacloud", Invocation{}, false},
+		{"usacloud-update --in a.sh", Invocation{}, false},
+		{"# sudo usacloud server list", Invocation{}, false},
+		{"", Invocation{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := FindInvocation(tt.line, vars)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindInvocation(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestInvocation_Rewrap(t *testing.T) {
+	inv, _ := FindInvocation("  sudo usacloud summary", nil)
+	if !inv.Wrapped() {
+		t.Fatal("Expected a sudo invocation to be wrapped")
+	}
+	if got := inv.Rewrap("usacloud cdrom list"); got != "  sudo usacloud cdrom list" {
+		t.Errorf("Rewrap() = %q", got)
+	}
+	if got := inv.Rewrap("# usacloud summary # note"); got != "  # sudo usacloud summary # note" {
+		t.Errorf("Rewrap() of a commented-out command = %q", got)
+	}
+
+	if plain, _ := FindInvocation("usacloud server list", nil); plain.Wrapped() {
+		t.Error("Expected a plain invocation not to be wrapped")
+	}
+}
+
+func TestBinaryVariables(t *testing.T) {
+	lines := []string{
+		"USACLOUD=/usr/local/bin/usacloud",
+		`export UC="$(which usacloud)"`,
+		"readonly CLI=`command -v usacloud`",
+		"local BIN=${BIN:-usacloud}",
+		"declare -r USA=usacloud",
+		"DOCKER=/usr/bin/docker",
+		"OUT=$(usacloud server list)",
+		"# COMMENTED=usacloud",
+	}
+
+	want := map[string]bool{"USACLOUD": true, "UC": true, "CLI": true, "BIN": true, "USA": true}
+	if got := BinaryVariables(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("BinaryVariables() = %v, want %v", got, want)
+	}
+}
+
+func TestParseWrappedCommands(t *testing.T) {
+	parser := NewParserWithBinaryVariables(map[string]bool{"USACLOUD": true})
+
+	for _, line := range []string{
+		"sudo usacloud server list --zone tk1v",
+		"env ZONE=tk1v /opt/usacloud/bin/usacloud server list --zone tk1v",
+		`"$USACLOUD" server list --zone tk1v`,
+	} {
+		parsed, err := parser.Parse(line)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", line, err)
+		}
+		if parsed.Raw != line || parsed.MainCommand != "server" || parsed.SubCommand != "list" || parsed.GetOption("zone") != "tk1v" {
+			t.Errorf("Parse(%q) = %v", line, parsed)
+		}
+	}
+
+	if _, err := NewParser().Parse("$USACLOUD server list"); err != ErrNotUsacloudCommand {
+		t.Errorf("Expected an unknown variable to be rejected, got %v", err)
+	}
+}
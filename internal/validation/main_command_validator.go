@@ -18,10 +18,13 @@ type ValidationResult struct {
 
 // MainCommandValidator represents a main command validator
 type MainCommandValidator struct {
+	version      string // usacloud catalog version
 	iaasCommands map[string]bool
 	miscCommands map[string]bool
 	rootCommands map[string]bool
 	allCommands  map[string]string // command -> type mapping
+	fileOptions  map[string]map[string][]string
+	options      map[string]map[string][]string
 }
 
 // Standalone commands that don't take subcommands
@@ -30,64 +33,66 @@ var standaloneCommands = map[string]bool{
 	"update-self": true,
 }
 
-// NewMainCommandValidator creates a new main command validator
+// NewMainCommandValidator creates a new main command validator using the default catalog
 func NewMainCommandValidator() *MainCommandValidator {
+	validator, err := NewMainCommandValidatorForVersion(DefaultCatalogVersion)
+	if err != nil {
+		// The default catalog is embedded, so this only happens on a broken build
+		panic(err)
+	}
+
+	return validator
+}
+
+// NewMainCommandValidatorForVersion creates a main command validator for a specific usacloud version
+func NewMainCommandValidatorForVersion(version string) (*MainCommandValidator, error) {
+	catalog, err := LoadCommandCatalog(version)
+	if err != nil {
+		return nil, err
+	}
+
 	validator := &MainCommandValidator{
+		version:      catalog.Version,
 		iaasCommands: make(map[string]bool),
 		miscCommands: make(map[string]bool),
 		rootCommands: make(map[string]bool),
 		allCommands:  make(map[string]string),
+		fileOptions:  catalog.FileOptions,
+		options:      catalog.Options,
 	}
 
 	// Initialize command dictionaries
-	validator.initializeCommands()
+	validator.initializeCommands(catalog)
 
-	return validator
+	return validator, nil
 }
 
-// initializeCommands initializes the command dictionaries
-func (v *MainCommandValidator) initializeCommands() {
-	// IaaS commands (44 commands)
-	iaasCommandList := []string{
-		"server", "disk", "database", "loadbalancer", "dns", "gslb", "proxylb",
-		"autobackup", "archive", "cdrom", "bridge", "packetfilter", "internet",
-		"ipaddress", "ipv6addr", "ipv6net", "subnet", "swytch", "localrouter",
-		"vpcrouter", "mobilegateway", "sim", "nfs", "license", "licenseinfo",
-		"sshkey", "note", "icon", "privatehost", "privatehostplan", "zone",
-		"region", "bill", "coupon", "authstatus", "self", "serviceclass",
-		"enhanceddb", "containerregistry", "certificateauthority", "esme",
-		"simplemonitor", "autoscale", "category", "disk-plan", "internet-plan", "server-plan",
-	}
-
-	// Misc commands (3 commands)
-	miscCommandList := []string{
-		"config", "rest", "webaccelerator",
-	}
-
-	// Root commands (3 commands)
-	rootCommandList := []string{
-		"completion", "version", "update-self",
-	}
-
+// initializeCommands initializes the command dictionaries from a catalog
+func (v *MainCommandValidator) initializeCommands(catalog *CommandCatalog) {
 	// Populate IaaS commands
-	for _, cmd := range iaasCommandList {
+	for _, cmd := range catalog.IaaS {
 		v.iaasCommands[cmd] = true
 		v.allCommands[cmd] = "iaas"
 	}
 
 	// Populate misc commands
-	for _, cmd := range miscCommandList {
+	for _, cmd := range catalog.Misc {
 		v.miscCommands[cmd] = true
 		v.allCommands[cmd] = "misc"
 	}
 
 	// Populate root commands
-	for _, cmd := range rootCommandList {
+	for _, cmd := range catalog.Root {
 		v.rootCommands[cmd] = true
 		v.allCommands[cmd] = "root"
 	}
 }
 
+// Version returns the usacloud version of the catalog this validator uses
+func (v *MainCommandValidator) Version() string {
+	return v.version
+}
+
 // Validate validates a main command
 func (v *MainCommandValidator) Validate(command string) *ValidationResult {
 	if command == "" {
@@ -132,11 +137,16 @@ func (v *MainCommandValidator) Validate(command string) *ValidationResult {
 
 		// Command doesn't exist
 		suggestions := v.getSimilarCommands(normalized, 2)
+		message := fmt.Sprintf("コマンド '%s' は存在しません", originalCommand)
+		if versions := catalogVersionsWithCommand(normalized); len(versions) > 0 {
+			message = fmt.Sprintf("コマンド '%s' は usacloud %s では利用できません（利用可能なバージョン: %s）",
+				originalCommand, v.version, strings.Join(versions, ", "))
+		}
 		return &ValidationResult{
 			IsValid:     false,
 			Command:     originalCommand,
 			ErrorType:   "unknown_command",
-			Message:     fmt.Sprintf("コマンド '%s' は存在しません", originalCommand),
+			Message:     message,
 			Suggestions: suggestions,
 		}
 	}
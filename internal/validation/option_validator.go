@@ -0,0 +1,176 @@
+// Package validation provides command validation functionality for usacloud-update
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Zones are the Sakura Cloud zones a command, profile or environment can target
+var Zones = []string{"tk1a", "tk1b", "is1a", "is1b", "tk1v"}
+
+// ValidZones are the zones accepted by --zone ("all" runs list commands in every zone)
+var ValidZones = append(append([]string(nil), Zones...), "all")
+
+// ValidOutputTypes are the values accepted by --output-type in usacloud v1
+var ValidOutputTypes = []string{"table", "json", "yaml"}
+
+// migratedOutputTypes are v0 output types the transform rules rewrite, so
+// they are not reported
+var migratedOutputTypes = map[string]bool{"csv": true, "tsv": true}
+
+// commonOptions are the options every usacloud command accepts: the global
+// options and the options shared by the commands (without the leading "--")
+var commonOptions = []string{
+	// global options
+	"profile", "token", "secret", "zones", "no-color", "trace", "fake", "fake-store",
+	"process-timeout-sec", "help", "version",
+	// common command options
+	"zone", "parameters", "parameters-file", "generate-skeleton", "example",
+	"output-type", "quiet", "format", "format-file", "query", "query-file",
+	"query-driven", "assumeyes", "dry-run", "force", "debug",
+}
+
+// valueOptions are the options that require a value
+var valueOptions = map[string]bool{"zone": true, "output-type": true, "profile": true}
+
+// OptionIssue is a problem with an option of a usacloud command
+type OptionIssue struct {
+	Option      string   // option name without the leading "--"
+	Value       string   // value as written in the command ("" for a flag)
+	Unknown     bool     // the option is not known for the command; the rest are invalid values
+	Message     string   // Issue description
+	Suggestions []string // valid values or known options similar to the one written
+}
+
+// OptionValidator checks the options of usacloud commands: the values of
+// --zone and --output-type, options given without their value and, for the
+// commands whose options the catalog lists, unknown options
+type OptionValidator struct {
+	mainValidator *MainCommandValidator
+	suggester     *SimilarCommandSuggester
+}
+
+// NewOptionValidator creates a new option validator using the catalog of mainValidator
+func NewOptionValidator(mainValidator *MainCommandValidator) *OptionValidator {
+	return &OptionValidator{
+		mainValidator: mainValidator,
+		suggester:     NewDefaultSimilarCommandSuggester(),
+	}
+}
+
+// CommandOptions returns the options of the command the catalog lists besides
+// the common ones, and whether the catalog lists the command at all
+func (v *MainCommandValidator) CommandOptions(command, subcommand string) ([]string, bool) {
+	options, ok := v.options[command][subcommand]
+	return options, ok
+}
+
+// Validate returns the problems with the options of cmdLine, in option name
+// order. Values that depend on the shell ($VAR or command substitution)
+// cannot be checked and are skipped.
+func (ov *OptionValidator) Validate(cmdLine *CommandLine) []OptionIssue {
+	if cmdLine == nil {
+		return nil
+	}
+
+	var issues []OptionIssue
+
+	names := make([]string, 0, len(cmdLine.Options))
+	for name := range cmdLine.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := strings.Trim(cmdLine.Options[name], `"'`)
+		if strings.ContainsAny(value, "$`") {
+			continue
+		}
+		switch name {
+		case "zone":
+			if !contains(ValidZones, value) {
+				issues = append(issues, ov.invalidValue(name, value, ValidZones))
+			}
+		case "output-type":
+			if !contains(ValidOutputTypes, strings.ToLower(value)) && !migratedOutputTypes[strings.ToLower(value)] {
+				issues = append(issues, ov.invalidValue(name, value, ValidOutputTypes))
+			}
+		}
+	}
+
+	for _, flag := range cmdLine.Flags {
+		if valueOptions[flag] {
+			issues = append(issues, OptionIssue{
+				Option:  flag,
+				Message: fmt.Sprintf("--%s に値が指定されていません", flag),
+			})
+		}
+	}
+
+	return append(issues, ov.unknownOptions(cmdLine, names)...)
+}
+
+// invalidValue reports a value that is not one of valid, suggesting the
+// similar valid values, or all of them when none is similar
+func (ov *OptionValidator) invalidValue(option, value string, valid []string) OptionIssue {
+	suggestions := ov.similar(value, valid)
+	if len(suggestions) == 0 {
+		suggestions = valid
+	}
+	return OptionIssue{
+		Option:      option,
+		Value:       value,
+		Message:     fmt.Sprintf("'%s' は --%s の有効な値ではありません（%s のいずれかを指定してください）", value, option, strings.Join(suggestions, ", ")),
+		Suggestions: suggestions,
+	}
+}
+
+// unknownOptions reports the options and flags the command does not accept,
+// for the commands whose options the catalog lists
+func (ov *OptionValidator) unknownOptions(cmdLine *CommandLine, optionNames []string) []OptionIssue {
+	commandOptions, ok := ov.mainValidator.CommandOptions(cmdLine.MainCommand, cmdLine.SubCommand)
+	if !ok {
+		return nil
+	}
+	known := append(append([]string{}, commonOptions...), commandOptions...)
+
+	var issues []OptionIssue
+	for _, name := range append(optionNames, cmdLine.Flags...) {
+		if contains(known, name) {
+			continue
+		}
+		issue := OptionIssue{
+			Option:      name,
+			Value:       cmdLine.Options[name],
+			Unknown:     true,
+			Message:     fmt.Sprintf("--%s は %s %s コマンドのオプションではありません", name, cmdLine.MainCommand, cmdLine.SubCommand),
+			Suggestions: ov.similar(name, known),
+		}
+		if len(issue.Suggestions) > 0 {
+			issue.Message += fmt.Sprintf("（もしかして: --%s）", strings.Join(issue.Suggestions, ", --"))
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// similar returns the candidates similar to input, best first
+func (ov *OptionValidator) similar(input string, candidates []string) []string {
+	var names []string
+	for _, r := range ov.suggester.rankCandidates(input, candidates) {
+		names = append(names, r.Command)
+	}
+	return names
+}
+
+// contains reports whether list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptionValidator_Validate(t *testing.T) {
+	validator := NewOptionValidator(NewMainCommandValidator())
+	parser := NewParser()
+
+	tests := []struct {
+		name string
+		line string
+		want []OptionIssue
+	}{
+		{
+			name: "valid options",
+			line: "usacloud server list --zone=is1a --output-type json --names web --profile prod",
+		},
+		{
+			name: "v0 output type is left to the transform rules",
+			line: "usacloud server list --output-type csv",
+		},
+		{
+			name: "value from a variable",
+			line: "usacloud server list --zone $ZONE --output-type=\"${FORMAT}\"",
+		},
+		{
+			name: "invalid zone with similar zones",
+			line: "usacloud server list --zone tk1x",
+			want: []OptionIssue{{Option: "zone", Value: "tk1x", Suggestions: []string{"tk1a", "tk1b", "tk1v"}}},
+		},
+		{
+			name: "invalid output type without a similar value",
+			line: "usacloud disk read 123 --output-type=xml",
+			want: []OptionIssue{{Option: "output-type", Value: "xml", Suggestions: ValidOutputTypes}},
+		},
+		{
+			name: "option without its value",
+			line: "usacloud server read 123 --zone --output-type json",
+			want: []OptionIssue{{Option: "zone"}},
+		},
+		{
+			name: "unknown option of a cataloged command",
+			line: "usacloud server list --nmes web --sort name",
+			want: []OptionIssue{
+				{Option: "nmes", Value: "web", Unknown: true, Suggestions: []string{"names"}},
+				{Option: "sort", Value: "name", Unknown: true},
+			},
+		},
+		{
+			name: "words of a trailing comment are not options",
+			line: "usacloud server list # try --foo later, don't --nmes",
+		},
+		{
+			name: "a quoted or embedded # does not start a comment",
+			line: "usacloud server list --names '# web' --tags a#b --nmes web",
+			want: []OptionIssue{{Option: "nmes", Value: "web", Unknown: true, Suggestions: []string{"names"}}},
+		},
+		{
+			name: "options of commands missing from the catalog are not checked",
+			line: "usacloud server create --name web --disk-size 20",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmdLine, err := parser.Parse(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := validator.Validate(cmdLine)
+			for i := range got {
+				if got[i].Message == "" {
+					t.Errorf("Expected a message for %+v", got[i])
+				}
+				got[i].Message = ""
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandOptions(t *testing.T) {
+	validator := NewMainCommandValidator()
+	if opts, ok := validator.CommandOptions("server", "list"); !ok || len(opts) == 0 {
+		t.Errorf("Expected the options of server list, got %v, %v", opts, ok)
+	}
+	if _, ok := validator.CommandOptions("server", "create"); ok {
+		t.Error("Expected no options for server create")
+	}
+}
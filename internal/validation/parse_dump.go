@@ -0,0 +1,91 @@
+// Package validation provides command validation functionality for usacloud-update
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ParsedOption represents a single parsed option
+type ParsedOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ParseDump represents the parser's interpretation of a command line for troubleshooting
+type ParseDump struct {
+	Input       string         `json:"input"`
+	MainCommand string         `json:"main_command"`
+	SubCommand  string         `json:"sub_command"`
+	Arguments   []string       `json:"arguments"`
+	Options     []ParsedOption `json:"options"`
+	Flags       []string       `json:"flags"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// DumpParse parses the command line and returns its structured interpretation.
+// Parse errors are recorded in the dump rather than returned.
+func DumpParse(commandLine string) *ParseDump {
+	dump := &ParseDump{
+		Input:     commandLine,
+		Arguments: []string{},
+		Options:   []ParsedOption{},
+		Flags:     []string{},
+	}
+
+	cmdLine, err := NewParser().Parse(commandLine)
+	if err != nil {
+		dump.Error = err.Error()
+		return dump
+	}
+
+	dump.MainCommand = cmdLine.MainCommand
+	dump.SubCommand = cmdLine.SubCommand
+	dump.Arguments = append(dump.Arguments, cmdLine.Arguments...)
+	dump.Flags = append(dump.Flags, cmdLine.Flags...)
+
+	// Options are stored in a map, so sort them for stable output
+	names := make([]string, 0, len(cmdLine.Options))
+	for name := range cmdLine.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dump.Options = append(dump.Options, ParsedOption{Name: name, Value: cmdLine.Options[name]})
+	}
+
+	return dump
+}
+
+// WriteText writes the dump in a human-readable form
+func (d *ParseDump) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "入力:           %s\n", d.Input)
+	if d.Error != "" {
+		fmt.Fprintf(&b, "解析エラー:     %s\n", d.Error)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	fmt.Fprintf(&b, "メインコマンド: %s\n", d.MainCommand)
+	fmt.Fprintf(&b, "サブコマンド:   %s\n", d.SubCommand)
+	fmt.Fprintf(&b, "位置引数:       %s\n", strings.Join(d.Arguments, ", "))
+	b.WriteString("オプション:\n")
+	for _, opt := range d.Options {
+		fmt.Fprintf(&b, "  --%s = %q\n", opt.Name, opt.Value)
+	}
+	fmt.Fprintf(&b, "フラグ:         %s\n", strings.Join(d.Flags, ", "))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the dump as indented JSON
+func (d *ParseDump) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
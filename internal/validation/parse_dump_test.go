@@ -0,0 +1,78 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDumpParse(t *testing.T) {
+	dump := DumpParse("usacloud server list web01 --zone=tk1v --output-type json --force")
+
+	if dump.Error != "" {
+		t.Fatalf("Unexpected parse error: %s", dump.Error)
+	}
+	if dump.MainCommand != "server" {
+		t.Errorf("Expected main command 'server', got %q", dump.MainCommand)
+	}
+	if dump.SubCommand != "list" {
+		t.Errorf("Expected subcommand 'list', got %q", dump.SubCommand)
+	}
+	if !reflect.DeepEqual(dump.Arguments, []string{"web01"}) {
+		t.Errorf("Expected arguments [web01], got %v", dump.Arguments)
+	}
+
+	expectedOptions := []ParsedOption{
+		{Name: "output-type", Value: "json"},
+		{Name: "zone", Value: "tk1v"},
+	}
+	if !reflect.DeepEqual(dump.Options, expectedOptions) {
+		t.Errorf("Expected options %v, got %v", expectedOptions, dump.Options)
+	}
+	if !reflect.DeepEqual(dump.Flags, []string{"force"}) {
+		t.Errorf("Expected flags [force], got %v", dump.Flags)
+	}
+}
+
+func TestDumpParse_Error(t *testing.T) {
+	dump := DumpParse(`usacloud server list --name="unclosed`)
+	if dump.Error == "" {
+		t.Fatal("Expected parse error to be recorded")
+	}
+	if !strings.Contains(dump.Error, "unclosed quote") {
+		t.Errorf("Expected unclosed quote error, got %q", dump.Error)
+	}
+
+	dump = DumpParse("ls -la")
+	if dump.Error != ErrNotUsacloudCommand.Error() {
+		t.Errorf("Expected %q, got %q", ErrNotUsacloudCommand.Error(), dump.Error)
+	}
+}
+
+func TestParseDump_Write(t *testing.T) {
+	dump := DumpParse("usacloud disk read 123 --zone=is1a")
+
+	var text bytes.Buffer
+	if err := dump.WriteText(&text); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	for _, want := range []string{"disk", "read", "123", `--zone = "is1a"`} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("Expected text output to contain %q, got:\n%s", want, text.String())
+		}
+	}
+
+	var jsonOut bytes.Buffer
+	if err := dump.WriteJSON(&jsonOut); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded ParseDump
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, dump) {
+		t.Errorf("Expected JSON round trip to match, got %+v", decoded)
+	}
+}
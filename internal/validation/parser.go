@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"unicode"
 )
 
 // CommandLine represents parsed command line information
@@ -15,6 +16,21 @@ type CommandLine struct {
 	Arguments   []string          // Positional arguments
 	Options     map[string]string // Options (--name=value)
 	Flags       []string          // Flags (--force, --dry-run, etc.)
+
+	// Positions of the command words in Raw, empty when the word is absent
+	MainCommandSpan Span // both words of a main command written as two ("iso image")
+	SubCommandSpan  Span
+}
+
+// Span is the byte range [Start, End) of a token in a command line
+type Span struct {
+	Start int
+	End   int
+}
+
+// Empty reports whether the span holds no token
+func (s Span) Empty() bool {
+	return s.End <= s.Start
 }
 
 // ParseError represents a parsing error
@@ -30,7 +46,7 @@ func (e *ParseError) Error() string {
 
 // Parser represents a command line parser
 type Parser struct {
-	// Configuration and cache can be added here later
+	binaryVars map[string]bool // shell variables holding the usacloud binary
 }
 
 // Common parsing errors
@@ -46,10 +62,17 @@ func NewParser() *Parser {
 	return &Parser{}
 }
 
-// IsUsacloudCommand checks if the command line starts with usacloud
+// NewParserWithBinaryVariables creates a parser that also accepts commands run
+// through the given shell variables, such as $USACLOUD (see BinaryVariables)
+func NewParserWithBinaryVariables(binaryVars map[string]bool) *Parser {
+	return &Parser{binaryVars: binaryVars}
+}
+
+// IsUsacloudCommand checks if the command line runs usacloud, possibly through
+// wrappers such as sudo or env, a path to the binary or a binary variable
 func (p *Parser) IsUsacloudCommand(commandLine string) bool {
-	trimmed := strings.TrimSpace(commandLine)
-	return strings.HasPrefix(trimmed, "usacloud ") || trimmed == "usacloud"
+	_, ok := FindInvocation(commandLine, p.binaryVars)
+	return ok
 }
 
 // Parse parses a command line string into CommandLine struct
@@ -63,7 +86,8 @@ func (p *Parser) Parse(commandLine string) (*CommandLine, error) {
 		return nil, ErrEmptyCommand
 	}
 
-	if !p.IsUsacloudCommand(trimmed) {
+	invocation, ok := FindInvocation(trimmed, p.binaryVars)
+	if !ok {
 		return nil, ErrNotUsacloudCommand
 	}
 
@@ -75,12 +99,19 @@ func (p *Parser) Parse(commandLine string) (*CommandLine, error) {
 		Flags:     []string{},
 	}
 
-	// Tokenize the command line
-	tokens, err := p.tokenize(trimmed)
+	// Tokenize the command line from the usacloud binary on
+	tokens, spans, err := p.tokenize(invocation.Command)
 	if err != nil {
 		return nil, err
 	}
 
+	// Offset of invocation.Command in Raw, past the trimmed space; the first
+	// token is the binary, which Command writes as "usacloud"
+	base := len(commandLine) - len(strings.TrimLeftFunc(commandLine, unicode.IsSpace)) + len(trimmed) - len(invocation.Command)
+	rawSpan := func(s Span) Span {
+		return Span{Start: base + s.Start, End: base + s.End}
+	}
+
 	// Skip "usacloud" token
 	if len(tokens) == 0 || tokens[0] != "usacloud" {
 		return nil, &ParseError{
@@ -89,20 +120,44 @@ func (p *Parser) Parse(commandLine string) (*CommandLine, error) {
 			Input:    trimmed,
 		}
 	}
-	tokens = tokens[1:]
+	tokens, spans = tokens[1:], spans[1:]
 
 	if len(tokens) == 0 {
 		// Just "usacloud" with no arguments
 		return result, nil
 	}
 
+	// Options may come before the main command ("usacloud --zone tk1v server list")
+	if tokens, spans, err = p.parseLeadingOptions(tokens, spans, result); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return result, nil
+	}
+
 	// Parse main command
 	result.MainCommand = tokens[0]
-	tokens = tokens[1:]
+	result.MainCommandSpan = rawSpan(spans[0])
+	tokens, spans = tokens[1:], spans[1:]
+
+	// Hyphenated commands written as two words ("iso image") are one main command
+	if len(tokens) > 0 {
+		if name, ok := CompoundCommandName(result.MainCommand, tokens[0]); ok {
+			result.MainCommand = name
+			result.MainCommandSpan.End = rawSpan(spans[0]).End
+			tokens, spans = tokens[1:], spans[1:]
+		}
+	}
 
-	// Parse subcommand if it doesn't start with --
-	if len(tokens) > 0 && !strings.HasPrefix(tokens[0], "--") {
+	// Options may also come before the subcommand ("usacloud server --zone tk1v list")
+	if tokens, spans, err = p.parseLeadingOptions(tokens, spans, result); err != nil {
+		return nil, err
+	}
+
+	// Parse subcommand
+	if len(tokens) > 0 {
 		result.SubCommand = tokens[0]
+		result.SubCommandSpan = rawSpan(spans[0])
 		tokens = tokens[1:]
 	}
 
@@ -115,15 +170,27 @@ func (p *Parser) Parse(commandLine string) (*CommandLine, error) {
 	return result, nil
 }
 
-// tokenize splits command line into tokens, respecting quotes
-func (p *Parser) tokenize(commandLine string) ([]string, error) {
+// tokenize splits command line into tokens up to a shell comment, respecting quotes, and returns
+// the span of each token in commandLine, quotes included
+func (p *Parser) tokenize(commandLine string) ([]string, []Span, error) {
 	var tokens []string
+	var spans []Span
 	var current strings.Builder
 	var inQuotes bool
 	var quoteChar byte
+	start := -1 // start of the current token, -1 between tokens
 
 	for i := 0; i < len(commandLine); i++ {
 		char := commandLine[i]
+		if start < 0 && (inQuotes || (char != ' ' && char != '\t')) {
+			start = i
+		}
+		// An unquoted # starting a word begins a shell comment, whose words,
+		// such as the "--query/jq" of an inline annotation, are not options
+		if !inQuotes && char == '#' && start == i && current.Len() == 0 {
+			start = -1
+			break
+		}
 
 		switch {
 		case char == '"' || char == '\'':
@@ -142,8 +209,10 @@ func (p *Parser) tokenize(commandLine string) ([]string, error) {
 			} else {
 				if current.Len() > 0 {
 					tokens = append(tokens, current.String())
+					spans = append(spans, Span{Start: start, End: i})
 					current.Reset()
 				}
+				start = -1
 			}
 		case char == '\\' && i+1 < len(commandLine):
 			// Handle escape sequences
@@ -170,7 +239,7 @@ func (p *Parser) tokenize(commandLine string) ([]string, error) {
 	}
 
 	if inQuotes {
-		return nil, &ParseError{
+		return nil, nil, &ParseError{
 			Message:  "unclosed quote",
 			Position: len(commandLine) - 1,
 			Input:    commandLine,
@@ -179,9 +248,10 @@ func (p *Parser) tokenize(commandLine string) ([]string, error) {
 
 	if current.Len() > 0 {
 		tokens = append(tokens, current.String())
+		spans = append(spans, Span{Start: start, End: len(commandLine)})
 	}
 
-	return tokens, nil
+	return tokens, spans, nil
 }
 
 // parseOptionsAndArguments parses options, flags, and positional arguments
@@ -204,6 +274,25 @@ func (p *Parser) parseOptionsAndArguments(tokens []string, result *CommandLine)
 	return nil
 }
 
+// parseLeadingOptions parses the options in front of a command word and
+// returns the tokens from that word on
+func (p *Parser) parseLeadingOptions(tokens []string, spans []Span, result *CommandLine) ([]string, []Span, error) {
+	for len(tokens) > 0 && strings.HasPrefix(tokens[0], "-") {
+		if !strings.HasPrefix(tokens[0], "--") {
+			// Short flags such as -y are kept with the arguments, as after the subcommand
+			result.Arguments = append(result.Arguments, tokens[0])
+			tokens, spans = tokens[1:], spans[1:]
+			continue
+		}
+		i := 0
+		if err := p.parseOption(tokens[0], tokens, &i, result); err != nil {
+			return nil, nil, err
+		}
+		tokens, spans = tokens[i+1:], spans[i+1:]
+	}
+	return tokens, spans, nil
+}
+
 // parseOption parses a single option or flag
 func (p *Parser) parseOption(token string, tokens []string, index *int, result *CommandLine) error {
 	optionName := token[2:] // Remove "--" prefix
@@ -235,6 +235,91 @@ func TestParseComplexCommands(t *testing.T) {
 	}
 }
 
+func TestParseHyphenatedCommands(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		input        string
+		expectedMain string
+		expectedSub  string
+		expectedArgs []string
+	}{
+		{"usacloud disk-plan list", "disk-plan", "list", []string{}},
+		{"usacloud update-self", "update-self", "", []string{}},
+		{"usacloud iso-image read 123", "iso-image", "read", []string{"123"}},
+		{"usacloud private-host list", "private-host", "list", []string{}},
+		{"usacloud proxy-lb read 123", "proxy-lb", "read", []string{"123"}},
+		{"usacloud private host read 123", "private-host", "read", []string{"123"}},
+		{"usacloud packet filter list", "packet-filter", "list", []string{}},
+		{"usacloud ssh key", "ssh-key", "", []string{}},
+		// Deprecated v0 commands were never written as two words
+		{"usacloud iso image read 123", "iso", "image", []string{"read", "123"}},
+		{"usacloud object storage", "object", "storage", []string{}},
+		// A known main command followed by a word stays main + subcommand
+		{"usacloud server plan", "server", "plan", []string{}},
+		{"usacloud disk plan list", "disk", "plan", []string{"list"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.input, err)
+			}
+			if result.MainCommand != tt.expectedMain || result.SubCommand != tt.expectedSub {
+				t.Errorf("Parse(%q): expected %s/%s, got %s/%s", tt.input, tt.expectedMain, tt.expectedSub, result.MainCommand, result.SubCommand)
+			}
+			if len(result.Arguments) != len(tt.expectedArgs) {
+				t.Errorf("Parse(%q): expected arguments %v, got %v", tt.input, tt.expectedArgs, result.Arguments)
+			}
+		})
+	}
+}
+
+func TestParseOptionsBeforeCommandWords(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		input        string
+		expectedMain string
+		expectedSub  string
+		expectedArgs []string
+	}{
+		{"usacloud --zone tk1v disk delete 456", "disk", "delete", []string{"456"}},
+		{"usacloud --zone=tk1v server shutdown web", "server", "shutdown", []string{"web"}},
+		{"usacloud server --zone tk1v shutdown 1", "server", "shutdown", []string{"1"}},
+		{"usacloud --zone tk1v private host --force read 123", "private-host", "read", []string{"123"}},
+		{"usacloud -y server delete 1", "server", "delete", []string{"-y", "1"}},
+		{"usacloud --version", "", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.input, err)
+			}
+			if result.MainCommand != tt.expectedMain || result.SubCommand != tt.expectedSub {
+				t.Errorf("Parse(%q): expected %s/%s, got %s/%s", tt.input, tt.expectedMain, tt.expectedSub, result.MainCommand, result.SubCommand)
+			}
+			if strings.Join(result.Arguments, " ") != strings.Join(tt.expectedArgs, " ") {
+				t.Errorf("Parse(%q): expected arguments %v, got %v", tt.input, tt.expectedArgs, result.Arguments)
+			}
+		})
+	}
+
+	result, err := parser.Parse("usacloud --zone tk1v server --force delete 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Options["zone"] != "tk1v" || !result.HasFlag("force") {
+		t.Errorf("Expected the options before the command words to be parsed, got %+v", result)
+	}
+	if raw := result.Raw[result.SubCommandSpan.Start:result.SubCommandSpan.End]; raw != "delete" {
+		t.Errorf("SubCommandSpan = %+v (%q), want the span of delete", result.SubCommandSpan, raw)
+	}
+}
+
 func TestParseWithQuotes(t *testing.T) {
 	parser := NewParser()
 
@@ -488,6 +573,45 @@ func TestCommandLineString(t *testing.T) {
 	}
 }
 
+func TestParseCommandSpans(t *testing.T) {
+	parser := NewParserWithBinaryVariables(map[string]bool{"USACLOUD": true})
+
+	tests := []struct {
+		input string
+		main  string // text of MainCommandSpan
+		sub   string // text of SubCommandSpan
+	}{
+		{"usacloud server list", "server", "list"},
+		{"  usacloud  sever   list  # sever list", "sever", "list"},
+		{"sudo -u admin /opt/bin/usacloud server lst --zone is1a", "server", "lst"},
+		{"$USACLOUD private host list", "private host", "list"},
+		{`usacloud "server" list`, `"server"`, "list"},
+		{"usacloud server --help", "server", ""},
+		{"usacloud", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.input, err)
+			}
+			text := func(s Span) string {
+				if s.Empty() {
+					return ""
+				}
+				return tt.input[s.Start:s.End]
+			}
+			if got := text(result.MainCommandSpan); got != tt.main {
+				t.Errorf("MainCommandSpan = %v (%q), expected %q", result.MainCommandSpan, got, tt.main)
+			}
+			if got := text(result.SubCommandSpan); got != tt.sub {
+				t.Errorf("SubCommandSpan = %v (%q), expected %q", result.SubCommandSpan, got, tt.sub)
+			}
+		})
+	}
+}
+
 func TestTokenizeEdgeCases(t *testing.T) {
 	parser := NewParser()
 
@@ -505,7 +629,7 @@ func TestTokenizeEdgeCases(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			tokens, err := parser.tokenize(tt.input)
+			tokens, _, err := parser.tokenize(tt.input)
 			if err != nil {
 				t.Fatalf("tokenize(%q) returned error: %v", tt.input, err)
 			}
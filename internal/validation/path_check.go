@@ -0,0 +1,59 @@
+// Package validation provides command validation functionality for usacloud-update
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MissingPath is a local file referenced by a command option that does not exist
+type MissingPath struct {
+	Option string // option name without the leading "--"
+	Path   string // path as written in the command
+}
+
+// FileOptions returns the options of the command that take a local file path,
+// as listed in the catalog
+func (v *MainCommandValidator) FileOptions(command, subcommand string) []string {
+	return v.fileOptions[command][subcommand]
+}
+
+// CheckFilePaths returns the file path options of cmdLine whose files do not
+// exist, in catalog order. Relative paths are resolved against baseDir and
+// "~/" against the home directory. Values that depend on the shell ($VAR,
+// command substitution or globs) cannot be checked and are skipped.
+func (v *MainCommandValidator) CheckFilePaths(cmdLine *CommandLine, baseDir string) []MissingPath {
+	if cmdLine == nil {
+		return nil
+	}
+
+	var missing []MissingPath
+	for _, option := range v.FileOptions(cmdLine.MainCommand, cmdLine.SubCommand) {
+		value, ok := cmdLine.Options[option]
+		if !ok {
+			continue
+		}
+		path := strings.Trim(value, `"'`)
+		if path == "" || strings.ContainsAny(path, "$`*?[") {
+			continue
+		}
+
+		resolved := path
+		if strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				continue
+			}
+			resolved = filepath.Join(home, path[2:])
+		} else if !filepath.IsAbs(path) {
+			resolved = filepath.Join(baseDir, path)
+		}
+
+		if _, err := os.Stat(resolved); os.IsNotExist(err) {
+			missing = append(missing, MissingPath{Option: option, Path: path})
+		}
+	}
+
+	return missing
+}
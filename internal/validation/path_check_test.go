@@ -0,0 +1,59 @@
+package validation
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckFilePaths(t *testing.T) {
+	const fixtureDir = "../../testdata/paths"
+	f, err := os.Open(filepath.Join(fixtureDir, "upload.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	validator := NewMainCommandValidator()
+	parser := NewParser()
+	var got []MissingPath
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		cmdLine, err := parser.Parse(scanner.Text())
+		if err != nil {
+			continue
+		}
+		got = append(got, validator.CheckFilePaths(cmdLine, fixtureDir)...)
+	}
+
+	want := []MissingPath{
+		{Option: "iso-file", Path: "missing.iso"},
+		{Option: "source-file", Path: "images/missing.img"},
+		{Option: "key", Path: "keys/missing_rsa"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFileOptions(t *testing.T) {
+	for _, version := range AvailableCatalogVersions() {
+		validator, err := NewMainCommandValidatorForVersion(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts := validator.FileOptions("cdrom", "create"); !reflect.DeepEqual(opts, []string{"iso-file"}) {
+			t.Errorf("%s: unexpected cdrom create file options %v", version, opts)
+		}
+		if opts := validator.FileOptions("disk", "create"); len(opts) != 0 {
+			t.Errorf("%s: expected no file options for disk create, got %v", version, opts)
+		}
+	}
+
+	cmdLine, _ := NewParser().Parse("usacloud cdrom create --iso-file /no/such/file.iso")
+	if missing := NewMainCommandValidator().CheckFilePaths(cmdLine, "."); len(missing) != 1 || missing[0].Path != "/no/such/file.iso" {
+		t.Errorf("expected absolute path to be checked as is, got %+v", missing)
+	}
+}
@@ -0,0 +1,122 @@
+// Package validation provides command validation functionality for usacloud-update
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PipelineAdvisory represents an advisory about a usacloud command piped to another tool
+type PipelineAdvisory struct {
+	Command    string // usacloud stage of the pipeline
+	Consumer   string // Consuming tool (e.g. "jq")
+	OutputType string // Detected output type ("" when not specified)
+	Message    string // Advisory message
+	Suggestion string // Suggested fix
+}
+
+// PipelineAnalyzer detects output format mismatches in shell pipelines
+type PipelineAnalyzer struct {
+	outputTypePattern *regexp.Regexp
+}
+
+// NewPipelineAnalyzer creates a new pipeline analyzer
+func NewPipelineAnalyzer() *PipelineAnalyzer {
+	return &PipelineAnalyzer{
+		outputTypePattern: regexp.MustCompile(`(?:--output-type|(?:^|\s)-o)(?:\s*=\s*|\s+)["']?([A-Za-z]+)`),
+	}
+}
+
+// Analyze checks whether a usacloud command piped to jq produces JSON output.
+// Returns nil when the line has no such pipeline or the output is already JSON.
+func (pa *PipelineAnalyzer) Analyze(line string) *PipelineAdvisory {
+	stages := splitPipeline(line)
+	if len(stages) < 2 {
+		return nil
+	}
+
+	usacloudStage := ""
+	for _, stage := range stages {
+		if isUsacloudStage(stage) {
+			usacloudStage = stage
+			continue
+		}
+		if usacloudStage != "" && isJqStage(stage) {
+			return pa.analyzeStage(usacloudStage)
+		}
+	}
+
+	return nil
+}
+
+// analyzeStage builds an advisory for a usacloud stage consumed by jq
+func (pa *PipelineAnalyzer) analyzeStage(stage string) *PipelineAdvisory {
+	outputType := ""
+	if m := pa.outputTypePattern.FindStringSubmatch(stage); m != nil {
+		outputType = strings.ToLower(m[1])
+	}
+
+	if outputType == "json" {
+		return nil
+	}
+
+	advisory := &PipelineAdvisory{
+		Command:    stage,
+		Consumer:   "jq",
+		OutputType: outputType,
+		Suggestion: "--output-type=json を指定してください",
+	}
+
+	if outputType == "" {
+		advisory.Message = "jq にパイプしていますが出力形式が指定されていません。v1のデフォルト出力はJSONではないため jq が失敗します"
+	} else {
+		advisory.Message = fmt.Sprintf("jq にパイプしていますが出力形式が %s です。jq はJSON入力が必要です", outputType)
+	}
+
+	return advisory
+}
+
+// splitPipeline splits a shell line by pipe operators, ignoring "||" and quoted text
+func splitPipeline(line string) []string {
+	var stages []string
+	var current strings.Builder
+	var quoteChar byte
+
+	for i := 0; i < len(line); i++ {
+		char := line[i]
+
+		switch {
+		case quoteChar != 0:
+			if char == quoteChar {
+				quoteChar = 0
+			}
+			current.WriteByte(char)
+		case char == '"' || char == '\'':
+			quoteChar = char
+			current.WriteByte(char)
+		case char == '|' && i+1 < len(line) && line[i+1] == '|':
+			current.WriteString("||")
+			i++
+		case char == '|':
+			stages = append(stages, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteByte(char)
+		}
+	}
+
+	return append(stages, strings.TrimSpace(current.String()))
+}
+
+// isUsacloudStage reports whether a pipeline stage invokes usacloud
+func isUsacloudStage(stage string) bool {
+	fields := strings.Fields(stage)
+	return len(fields) > 0 && fields[0] == "usacloud"
+}
+
+// isJqStage reports whether a pipeline stage invokes jq
+func isJqStage(stage string) bool {
+	fields := strings.Fields(stage)
+	return len(fields) > 0 && fields[0] == "jq"
+}
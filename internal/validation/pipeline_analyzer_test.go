@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"testing"
+)
+
+func TestPipelineAnalyzer_Analyze(t *testing.T) {
+	analyzer := NewPipelineAnalyzer()
+
+	tests := []struct {
+		name           string
+		line           string
+		expectAdvisory bool
+		outputType     string
+	}{
+		{"csv piped to jq", "usacloud server list --output-type=csv | jq '.[]'", true, "csv"},
+		{"tsv short option piped to jq", "usacloud disk list -o tsv | jq .", true, "tsv"},
+		{"table piped to jq", "usacloud server list --output-type table | jq -r '.[].Name'", true, "table"},
+		{"no output type piped to jq", "usacloud server list | jq '.[].ID'", true, ""},
+		{"intermediate stage before jq", "usacloud server list --output-type=csv | head -n 5 | jq .", true, "csv"},
+		{"json piped to jq", "usacloud server list --output-type=json | jq '.[]'", false, ""},
+		{"json short option piped to jq", "usacloud server list -o json | jq .", false, ""},
+		{"csv piped to grep", "usacloud server list --output-type=csv | grep web", false, ""},
+		{"no pipeline", "usacloud server list --output-type=csv", false, ""},
+		{"logical or is not a pipe", "usacloud server list --output-type=csv || jq .", false, ""},
+		{"pipe inside quotes", "usacloud server list --query 'a|jq' --output-type=csv", false, ""},
+		{"non usacloud pipeline", "cat servers.json | jq .", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			advisory := analyzer.Analyze(tt.line)
+			if (advisory != nil) != tt.expectAdvisory {
+				t.Fatalf("Analyze(%q) advisory = %+v, expectAdvisory %v", tt.line, advisory, tt.expectAdvisory)
+			}
+			if advisory == nil {
+				return
+			}
+			if advisory.OutputType != tt.outputType {
+				t.Errorf("Expected output type %q, got %q", tt.outputType, advisory.OutputType)
+			}
+			if advisory.Consumer != "jq" {
+				t.Errorf("Expected consumer jq, got %q", advisory.Consumer)
+			}
+			if advisory.Message == "" || advisory.Suggestion == "" {
+				t.Errorf("Advisory should have message and suggestion: %+v", advisory)
+			}
+		})
+	}
+}
+
+func TestSplitPipeline(t *testing.T) {
+	stages := splitPipeline(`usacloud server list | jq -r ".[] | .Name" | sort`)
+	expected := []string{"usacloud server list", `jq -r ".[] | .Name"`, "sort"}
+
+	if len(stages) != len(expected) {
+		t.Fatalf("Expected %d stages, got %d: %v", len(expected), len(stages), stages)
+	}
+	for i := range expected {
+		if stages[i] != expected[i] {
+			t.Errorf("Stage %d: expected %q, got %q", i, expected[i], stages[i])
+		}
+	}
+}
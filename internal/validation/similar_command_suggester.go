@@ -2,6 +2,7 @@
 package validation
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 )
@@ -9,16 +10,58 @@ import (
 // SimilarityResult represents a similarity search result
 type SimilarityResult struct {
 	Command  string  // Candidate command
-	Distance int     // Levenshtein distance
+	Distance int     // Edit distance under the suggester's DistanceAlgorithm
 	Score    float64 // Similarity score (0.0-1.0)
 }
 
+// CommandCandidate represents a corrected "main sub" command pair
+type CommandCandidate struct {
+	MainCommand string  // Corrected (or original valid) main command
+	SubCommand  string  // Subcommand valid under MainCommand
+	Score       float64 // Combined confidence (main score × subcommand score)
+}
+
+// String returns the candidate as "main sub"
+func (c CommandCandidate) String() string {
+	return c.MainCommand + " " + c.SubCommand
+}
+
 // SimilarCommandSuggester provides similar command suggestions
 type SimilarCommandSuggester struct {
 	allCommands        []string            // All available commands
 	commandSubcommands map[string][]string // Command -> subcommand mapping
 	maxDistance        int                 // Maximum allowable distance
 	maxSuggestions     int                 // Maximum number of suggestions
+	algorithm          DistanceAlgorithm   // Edit distance used to rank candidates
+	cache              *suggestionCache    // nil unless SetCacheSize enabled it
+}
+
+// DistanceAlgorithm selects the edit distance used to compare commands
+type DistanceAlgorithm string
+
+// Supported distance algorithms
+const (
+	// DistanceLevenshtein counts insertions, deletions and substitutions
+	DistanceLevenshtein DistanceAlgorithm = "levenshtein"
+	// DistanceDamerauLevenshtein also counts a transposition of two adjacent
+	// characters as a single edit, so "sevrer" is one edit away from "server"
+	DistanceDamerauLevenshtein DistanceAlgorithm = "damerau-levenshtein"
+)
+
+// ParseDistanceAlgorithm returns the algorithm named name ("" selects Levenshtein)
+func ParseDistanceAlgorithm(name string) (DistanceAlgorithm, error) {
+	switch DistanceAlgorithm(strings.ToLower(strings.TrimSpace(name))) {
+	case "", DistanceLevenshtein:
+		return DistanceLevenshtein, nil
+	case DistanceDamerauLevenshtein:
+		return DistanceDamerauLevenshtein, nil
+	}
+	return "", fmt.Errorf("unknown distance algorithm %q (want %s or %s)", name, DistanceLevenshtein, DistanceDamerauLevenshtein)
+}
+
+// SuggesterOptions holds optional settings of a SimilarCommandSuggester
+type SuggesterOptions struct {
+	Algorithm DistanceAlgorithm // Defaults to DistanceLevenshtein
 }
 
 // Common configuration constants
@@ -44,12 +87,17 @@ var CommonTypoPatterns = map[string][]string{
 }
 
 // NewSimilarCommandSuggester creates a new command suggester
-func NewSimilarCommandSuggester(maxDistance, maxSuggestions int) *SimilarCommandSuggester {
+func NewSimilarCommandSuggester(maxDistance, maxSuggestions int, opts ...SuggesterOptions) *SimilarCommandSuggester {
+	algorithm := DistanceLevenshtein
+	if len(opts) > 0 && opts[0].Algorithm != "" {
+		algorithm = opts[0].Algorithm
+	}
 	return &SimilarCommandSuggester{
 		allCommands:        getAllCommands(),
 		commandSubcommands: getAllCommandSubcommands(),
 		maxDistance:        maxDistance,
 		maxSuggestions:     maxSuggestions,
+		algorithm:          algorithm,
 	}
 }
 
@@ -108,39 +156,137 @@ func (s *SimilarCommandSuggester) LevenshteinDistance(s1, s2 string) int {
 	return matrix[len(s1)][len(s2)]
 }
 
+// DamerauLevenshteinDistance calculates the edit distance between two strings
+// counting a transposition of two adjacent characters as a single edit
+// (optimal string alignment)
+func (s *SimilarCommandSuggester) DamerauLevenshteinDistance(s1, s2 string) int {
+	s1 = strings.ToLower(s1)
+	s2 = strings.ToLower(s2)
+
+	if s1 == s2 {
+		return 0
+	}
+
+	if len(s1) == 0 {
+		return len(s2)
+	}
+
+	if len(s2) == 0 {
+		return len(s1)
+	}
+
+	matrix := make([][]int, len(s1)+1)
+	for i := range matrix {
+		matrix[i] = make([]int, len(s2)+1)
+		matrix[i][0] = i
+	}
+	for j := 0; j <= len(s2); j++ {
+		matrix[0][j] = j
+	}
+
+	for i := 1; i <= len(s1); i++ {
+		for j := 1; j <= len(s2); j++ {
+			cost := 0
+			if s1[i-1] != s2[j-1] {
+				cost = 1
+			}
+
+			deletion := matrix[i-1][j] + 1
+			insertion := matrix[i][j-1] + 1
+			substitution := matrix[i-1][j-1] + cost
+			matrix[i][j] = suggesterMin(deletion, suggesterMin(insertion, substitution))
+
+			if i > 1 && j > 1 && s1[i-1] == s2[j-2] && s1[i-2] == s2[j-1] {
+				matrix[i][j] = suggesterMin(matrix[i][j], matrix[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return matrix[len(s1)][len(s2)]
+}
+
+// Distance calculates the distance between two strings with the suggester's algorithm
+func (s *SimilarCommandSuggester) Distance(s1, s2 string) int {
+	if s.algorithm == DistanceDamerauLevenshtein {
+		return s.DamerauLevenshteinDistance(s1, s2)
+	}
+	return s.LevenshteinDistance(s1, s2)
+}
+
+// Algorithm returns the distance algorithm used to rank candidates
+func (s *SimilarCommandSuggester) Algorithm() DistanceAlgorithm {
+	return s.algorithm
+}
+
 // SuggestMainCommands suggests main command candidates
 func (s *SimilarCommandSuggester) SuggestMainCommands(input string) []SimilarityResult {
 	if input == "" {
 		return nil
 	}
+	return s.cached("main\x00"+input, func() []SimilarityResult {
+		return s.rankCandidates(input, s.allCommands)
+	})
+}
 
-	var results []SimilarityResult
-	maxDistance := s.getAdaptiveMaxDistance(input)
+// SuggestSubcommands suggests subcommand candidates
+func (s *SimilarCommandSuggester) SuggestSubcommands(mainCommand, input string) []SimilarityResult {
+	if input == "" || mainCommand == "" {
+		return nil
+	}
 
-	// Filter candidates by prefix for performance
-	candidates := s.filterByPrefix(input, s.allCommands)
+	subcommands, exists := s.commandSubcommands[mainCommand]
+	if !exists {
+		return nil
+	}
+	return s.cached("sub\x00"+mainCommand+"\x00"+input, func() []SimilarityResult {
+		return s.rankCandidates(input, subcommands)
+	})
+}
 
-	for _, command := range candidates {
-		distance := s.LevenshteinDistance(input, command)
+// SuggestCommandPairs suggests full "main sub" candidates for a command whose
+// main command and subcommand may both be mistyped. The main command is
+// corrected first and the subcommand is then matched against the subcommands
+// valid under each corrected main command. Tokens that are already valid score
+// 1.0, so a valid main command only has its subcommand corrected.
+func (s *SimilarCommandSuggester) SuggestCommandPairs(mainInput, subInput string) []CommandCandidate {
+	if mainInput == "" || subInput == "" {
+		return nil
+	}
 
-		if distance <= maxDistance {
-			score := s.calculateScore(input, command, distance)
-			if score >= MinScore {
-				results = append(results, SimilarityResult{
-					Command:  command,
-					Distance: distance,
-					Score:    score,
-				})
+	mainCandidates := []SimilarityResult{{Command: mainInput, Score: 1.0}}
+	if _, exists := s.commandSubcommands[mainInput]; !exists {
+		mainCandidates = s.SuggestMainCommands(mainInput)
+	}
+
+	var results []CommandCandidate
+	for _, main := range mainCandidates {
+		subcommands, exists := s.commandSubcommands[main.Command]
+		if !exists {
+			continue
+		}
+
+		subCandidates := s.SuggestSubcommands(main.Command, subInput)
+		for _, sub := range subcommands {
+			if sub == subInput {
+				subCandidates = []SimilarityResult{{Command: sub, Score: 1.0}}
+				break
 			}
 		}
+
+		for _, sub := range subCandidates {
+			results = append(results, CommandCandidate{
+				MainCommand: main.Command,
+				SubCommand:  sub.Command,
+				Score:       main.Score * sub.Score,
+			})
+		}
 	}
 
-	// Sort by score (descending)
-	sort.Slice(results, func(i, j int) bool {
+	// Sort by combined score (descending), keeping main command ranking on ties
+	sort.SliceStable(results, func(i, j int) bool {
 		return results[i].Score > results[j].Score
 	})
 
-	// Limit to maximum suggestions
 	if len(results) > s.maxSuggestions {
 		results = results[:s.maxSuggestions]
 	}
@@ -148,31 +294,47 @@ func (s *SimilarCommandSuggester) SuggestMainCommands(input string) []Similarity
 	return results
 }
 
-// SuggestSubcommands suggests subcommand candidates
-func (s *SimilarCommandSuggester) SuggestSubcommands(mainCommand, input string) []SimilarityResult {
-	if input == "" || mainCommand == "" {
-		return nil
+// SetCacheSize enables an LRU cache of the suggestions by input, holding up to
+// about maxBytes of results; 0 or less disables it. The counters are reset.
+func (s *SimilarCommandSuggester) SetCacheSize(maxBytes int64) {
+	if maxBytes <= 0 {
+		s.cache = nil
+		return
 	}
+	s.cache = newSuggestionCache(maxBytes)
+}
 
-	subcommands, exists := s.commandSubcommands[mainCommand]
-	if !exists {
-		return nil
+// CacheStats returns the hit and miss counters of the suggestion cache
+func (s *SimilarCommandSuggester) CacheStats() CacheStats {
+	return s.cache.stats()
+}
+
+// cached returns the cached results of key, or computes and caches them
+func (s *SimilarCommandSuggester) cached(key string, compute func() []SimilarityResult) []SimilarityResult {
+	if results, ok := s.cache.get(key); ok {
+		return results
 	}
+	results := compute()
+	s.cache.put(key, results)
+	return results
+}
 
+// rankCandidates returns the candidates similar to input, best first
+func (s *SimilarCommandSuggester) rankCandidates(input string, all []string) []SimilarityResult {
 	var results []SimilarityResult
 	maxDistance := s.getAdaptiveMaxDistance(input)
 
 	// Filter candidates by prefix for performance
-	candidates := s.filterByPrefix(input, subcommands)
+	candidates := s.filterByPrefix(input, all)
 
-	for _, subcommand := range candidates {
-		distance := s.LevenshteinDistance(input, subcommand)
+	for _, command := range candidates {
+		distance := s.Distance(input, command)
 
 		if distance <= maxDistance {
-			score := s.calculateScore(input, subcommand, distance)
+			score := s.calculateScore(input, command, distance)
 			if score >= MinScore {
 				results = append(results, SimilarityResult{
-					Command:  subcommand,
+					Command:  command,
 					Distance: distance,
 					Score:    score,
 				})
@@ -63,6 +63,96 @@ func TestLevenshteinDistance(t *testing.T) {
 	}
 }
 
+func TestDamerauLevenshteinDistance(t *testing.T) {
+	suggester := NewDefaultSimilarCommandSuggester()
+
+	tests := []struct {
+		s1       string
+		s2       string
+		expected int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"kitten", "sitting", 3},
+		{"sevrer", "server", 1}, // Adjacent transposition
+		{"lsit", "list", 1},
+		{"dsik", "disk", 1},
+		{"ca", "abc", 3}, // Optimal string alignment does not edit a substring twice
+		{"SEVRER", "server", 1},
+		{"server", "sever", 1},
+	}
+
+	for _, tt := range tests {
+		result := suggester.DamerauLevenshteinDistance(tt.s1, tt.s2)
+		if result != tt.expected {
+			t.Errorf("DamerauLevenshteinDistance(%q, %q) = %d, expected %d", tt.s1, tt.s2, result, tt.expected)
+		}
+	}
+}
+
+func TestParseDistanceAlgorithm(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected DistanceAlgorithm
+		wantErr  bool
+	}{
+		{"", DistanceLevenshtein, false},
+		{"levenshtein", DistanceLevenshtein, false},
+		{"Damerau-Levenshtein", DistanceDamerauLevenshtein, false},
+		{"hamming", "", true},
+	}
+
+	for _, tt := range tests {
+		algorithm, err := ParseDistanceAlgorithm(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDistanceAlgorithm(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if algorithm != tt.expected {
+			t.Errorf("ParseDistanceAlgorithm(%q) = %q, expected %q", tt.name, algorithm, tt.expected)
+		}
+	}
+}
+
+func TestDamerauLevenshteinRanksTranspositionsHigher(t *testing.T) {
+	levenshtein := NewDefaultSimilarCommandSuggester()
+	damerau := NewSimilarCommandSuggester(DefaultMaxDistance, DefaultMaxSuggestions,
+		SuggesterOptions{Algorithm: DistanceDamerauLevenshtein})
+
+	if levenshtein.Algorithm() != DistanceLevenshtein || damerau.Algorithm() != DistanceDamerauLevenshtein {
+		t.Fatalf("Unexpected algorithms: %q, %q", levenshtein.Algorithm(), damerau.Algorithm())
+	}
+
+	scoreOf := func(results []SimilarityResult, command string) float64 {
+		for _, r := range results {
+			if r.Command == command {
+				return r.Score
+			}
+		}
+		return 0
+	}
+
+	for _, tt := range []struct{ input, want string }{{"sevrer", "server"}, {"dsik", "disk"}, {"databaes", "database"}} {
+		plain := scoreOf(levenshtein.SuggestMainCommands(tt.input), tt.want)
+		transposed := damerau.SuggestMainCommands(tt.input)
+		if len(transposed) == 0 || transposed[0].Command != tt.want {
+			t.Errorf("Damerau-Levenshtein SuggestMainCommands(%q) should rank %q first, got %+v", tt.input, tt.want, transposed)
+			continue
+		}
+		if transposed[0].Distance != 1 {
+			t.Errorf("Expected a transposition to count as one edit for %q, got %d", tt.input, transposed[0].Distance)
+		}
+		if transposed[0].Score <= plain {
+			t.Errorf("Expected %q to score higher under Damerau-Levenshtein (%f) than Levenshtein (%f)", tt.input, transposed[0].Score, plain)
+		}
+	}
+
+	subs := damerau.SuggestSubcommands("server", "lsit")
+	if len(subs) == 0 || subs[0].Command != "list" {
+		t.Errorf("Expected 'list' first for 'lsit', got %+v", subs)
+	}
+}
+
 func TestGetAdaptiveMaxDistance(t *testing.T) {
 	suggester := NewDefaultSimilarCommandSuggester()
 
@@ -370,6 +460,57 @@ func TestSuggestSubcommands(t *testing.T) {
 	}
 }
 
+func TestSuggestCommandPairs(t *testing.T) {
+	suggester := NewDefaultSimilarCommandSuggester()
+
+	tests := []struct {
+		mainInput string
+		subInput  string
+		wantFirst string
+	}{
+		{"serv", "lst", "server list"},       // Both tokens mistyped
+		{"sever", "list", "server list"},     // Only the main command mistyped
+		{"server", "creat", "server create"}, // Only the subcommand mistyped
+		{"databse", "shutdwn", "database shutdown"},
+	}
+
+	for _, tt := range tests {
+		results := suggester.SuggestCommandPairs(tt.mainInput, tt.subInput)
+		if len(results) == 0 {
+			t.Errorf("SuggestCommandPairs(%q, %q) expected candidates but got none", tt.mainInput, tt.subInput)
+			continue
+		}
+		if got := results[0].String(); got != tt.wantFirst {
+			t.Errorf("SuggestCommandPairs(%q, %q) first = %q, want %q", tt.mainInput, tt.subInput, got, tt.wantFirst)
+		}
+		for i, r := range results {
+			if r.Score <= 0 || r.Score > 1.0 {
+				t.Errorf("SuggestCommandPairs(%q, %q) score out of range: %+v", tt.mainInput, tt.subInput, r)
+			}
+			if i > 0 && results[i-1].Score < r.Score {
+				t.Errorf("SuggestCommandPairs(%q, %q) results not properly sorted by score", tt.mainInput, tt.subInput)
+			}
+		}
+		if len(results) > suggester.maxSuggestions {
+			t.Errorf("SuggestCommandPairs(%q, %q) returned %d candidates, max is %d",
+				tt.mainInput, tt.subInput, len(results), suggester.maxSuggestions)
+		}
+	}
+
+	// The combined score is lower than either part when both are corrected
+	both := suggester.SuggestCommandPairs("serv", "lst")[0]
+	mainOnly := suggester.SuggestCommandPairs("serv", "list")[0]
+	if both.Score >= mainOnly.Score {
+		t.Errorf("Expected correcting both tokens to score lower (%f) than the main command only (%f)", both.Score, mainOnly.Score)
+	}
+
+	for _, tt := range [][2]string{{"", "list"}, {"server", ""}, {"xyz", "qqq"}} {
+		if results := suggester.SuggestCommandPairs(tt[0], tt[1]); len(results) != 0 {
+			t.Errorf("SuggestCommandPairs(%q, %q) expected no candidates but got %+v", tt[0], tt[1], results)
+		}
+	}
+}
+
 func TestSuggesterGetAllCommands(t *testing.T) {
 	commands := getAllCommands()
 
@@ -0,0 +1,125 @@
+package validation
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Approximate memory used by a cache entry besides its key and command names
+const (
+	cacheEntryOverhead  = 96 // list element, map slot and entry header
+	cacheResultOverhead = 40 // SimilarityResult without its command name
+)
+
+// CacheStats reports how the suggestion cache of a SimilarCommandSuggester was used
+type CacheStats struct {
+	Hits     uint64
+	Misses   uint64
+	Entries  int
+	Bytes    int64 // approximate memory used by the entries
+	MaxBytes int64
+}
+
+// suggestionCache is an LRU cache of suggestion results bounded by an
+// approximate size in bytes. It is safe for concurrent use.
+type suggestionCache struct {
+	mutex    sync.Mutex
+	maxBytes int64
+	bytes    int64
+	entries  map[string]*list.Element
+	order    *list.List // most recently used first
+	hits     uint64
+	misses   uint64
+}
+
+// cacheEntry is an element of suggestionCache.order
+type cacheEntry struct {
+	key     string
+	results []SimilarityResult
+	size    int64
+}
+
+// newSuggestionCache creates a cache holding up to maxBytes of results
+func newSuggestionCache(maxBytes int64) *suggestionCache {
+	return &suggestionCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns a copy of the cached results of key, so that callers may
+// append to them. A nil cache always misses without counting.
+func (c *suggestionCache) get(key string) ([]SimilarityResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return copyResults(element.Value.(*cacheEntry).results), true
+}
+
+// put stores a copy of the results of key, evicting the least recently used
+// entries to stay within maxBytes. Results larger than the cache are not stored.
+func (c *suggestionCache) put(key string, results []SimilarityResult) {
+	if c == nil {
+		return
+	}
+	size := entrySize(key, results)
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	for c.bytes+size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, results: copyResults(results), size: size})
+	c.bytes += size
+}
+
+// remove drops an entry; the mutex must be held
+func (c *suggestionCache) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+// stats returns the counters; a nil cache reports zeros
+func (c *suggestionCache) stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries), Bytes: c.bytes, MaxBytes: c.maxBytes}
+}
+
+// entrySize estimates the memory used by an entry
+func entrySize(key string, results []SimilarityResult) int64 {
+	size := int64(cacheEntryOverhead + len(key))
+	for _, r := range results {
+		size += int64(cacheResultOverhead + len(r.Command))
+	}
+	return size
+}
+
+// copyResults copies results; nil stays nil
+func copyResults(results []SimilarityResult) []SimilarityResult {
+	if results == nil {
+		return nil
+	}
+	return append([]SimilarityResult(nil), results...)
+}
@@ -0,0 +1,112 @@
+package validation
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSuggestionCache_LRUEviction(t *testing.T) {
+	results := []SimilarityResult{{Command: "server", Distance: 1, Score: 0.8}}
+	size := entrySize("k1", results)
+	cache := newSuggestionCache(2 * size)
+
+	cache.put("k1", results)
+	cache.put("k2", results)
+	if _, ok := cache.get("k1"); !ok {
+		t.Fatal("Expected k1 to be cached")
+	}
+	// k2 is now the least recently used and makes room for k3
+	cache.put("k3", results)
+	if _, ok := cache.get("k2"); ok {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	for _, key := range []string{"k1", "k3"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("Expected %s to stay cached", key)
+		}
+	}
+
+	stats := cache.stats()
+	if stats.Hits != 3 || stats.Misses != 1 || stats.Entries != 2 || stats.Bytes != 2*size {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// Results larger than the whole cache are not stored
+	cache.put("big", make([]SimilarityResult, 100))
+	if _, ok := cache.get("big"); ok {
+		t.Error("Expected an entry larger than the cache not to be stored")
+	}
+}
+
+func TestSuggestionCache_ReturnsCopies(t *testing.T) {
+	cache := newSuggestionCache(1 << 10)
+	cache.put("k", []SimilarityResult{{Command: "server"}})
+
+	got, _ := cache.get("k")
+	got[0].Command = "changed"
+	if again, _ := cache.get("k"); again[0].Command != "server" {
+		t.Error("Expected callers not to modify the cached results")
+	}
+
+	cache.put("none", nil)
+	if got, ok := cache.get("none"); !ok || got != nil {
+		t.Errorf("Expected an empty result to be cached as nil, got %v %v", got, ok)
+	}
+}
+
+func TestSimilarCommandSuggester_Cache(t *testing.T) {
+	uncached := NewDefaultSimilarCommandSuggester()
+	suggester := NewDefaultSimilarCommandSuggester()
+	suggester.SetCacheSize(1 << 20)
+
+	for i := 0; i < 3; i++ {
+		if got, want := suggester.SuggestMainCommands("sever"), uncached.SuggestMainCommands("sever"); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected cached suggestions %v, got %v", want, got)
+		}
+		if got, want := suggester.SuggestSubcommands("server", "lst"), uncached.SuggestSubcommands("server", "lst"); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected cached suggestions %v, got %v", want, got)
+		}
+	}
+	if stats := suggester.CacheStats(); stats.Hits != 4 || stats.Misses != 2 || stats.Entries != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// The same input as a main command and as a subcommand are cached apart
+	suggester.SuggestSubcommands("disk", "sever")
+	if stats := suggester.CacheStats(); stats.Misses != 3 {
+		t.Errorf("Expected a miss for another kind of suggestion, got %+v", stats)
+	}
+
+	suggester.SetCacheSize(0)
+	suggester.SuggestMainCommands("sever")
+	if stats := suggester.CacheStats(); stats != (CacheStats{}) {
+		t.Errorf("Expected no stats without a cache, got %+v", stats)
+	}
+}
+
+func TestSimilarCommandSuggester_CacheConcurrent(t *testing.T) {
+	suggester := NewDefaultSimilarCommandSuggester()
+	// Small enough to evict while the goroutines run
+	suggester.SetCacheSize(entrySize("main\x00sever", suggester.SuggestMainCommands("sever")) * 3)
+	inputs := []string{"sever", "dsk", "databse", "swich", "archiv"}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				input := inputs[(g+i)%len(inputs)]
+				results := suggester.SuggestMainCommands(input)
+				_ = append(results, SimilarityResult{Command: "extra"})
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	stats := suggester.CacheStats()
+	if stats.Hits+stats.Misses != 8*200 || stats.Bytes > stats.MaxBytes {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
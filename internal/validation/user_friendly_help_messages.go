@@ -0,0 +1,382 @@
+// Package validation provides command validation functionality for usacloud-update
+package validation
+
+import "fmt"
+
+// helpMessages holds the localized text of the help system
+type helpMessages struct {
+	// Help panels, printed line by line
+	BeginnerHelp     []string
+	IntermediateHelp []string
+	AdvancedHelp     []string
+	ExampleHelp      []string
+	DetailedTitle    []string
+	DetailedMistakes string
+
+	// Interactive command builder
+	InteractiveDisabled   string
+	BuilderWelcome        []string
+	BuildFailed           string // format: error
+	GeneratedCommand      string // format: command
+	ProfileSaveFailed     string // format: error
+	SelectMainCommand     []string
+	EnterPrompt           string
+	InvalidCommand        string // format: command
+	DidYouMeanCommand     string
+	SelectSuggestion      string
+	SelectSubCommand      string // format: main command
+	NoSubcommands         string
+	AvailableSubcommands  string
+	InvalidSubcommand     string // format: subcommand
+	DidYouMeanSubcommands string
+
+	// Tutorial and common mistakes
+	TutorialTitle      []string
+	TutorialPreparing  string
+	TryCommands        string
+	Tips               string
+	MistakesTitle      []string
+	MistakesPreparing  string
+	WrongExample       string // format: pattern
+	CorrectExamples    string
+	MistakeExplanation string // format: explanation
+}
+
+var jaHelpMessages = &helpMessages{
+	BeginnerHelp: []string{
+		"🎯 usacloud ヘルプ - 初心者向けガイド",
+		"",
+		"基本的な使い方:",
+		"  usacloud [コマンド] [サブコマンド] [オプション]",
+		"",
+		"まず試してみましょう:",
+		"  usacloud config list          # 設定確認",
+		"  usacloud server list          # サーバー一覧表示",
+		"",
+		"よく使うコマンド:",
+		"  • server  - サーバー操作 (作成、一覧、詳細等)",
+		"  • disk    - ディスク操作 (作成、一覧、接続等)",
+		"  • config  - 設定操作 (プロファイル管理等)",
+		"",
+		"🚀 インタラクティブモード: usacloud --interactive",
+		"📚 詳細な学習ガイド: usacloud --tutorial",
+		"❓ 困ったとき: usacloud --help [コマンド名]",
+	},
+	IntermediateHelp: []string{
+		"📋 usacloud ヘルプ - 中級者向けガイド",
+		"",
+		"よく使う操作パターン:",
+		"  usacloud server list --output-type json",
+		"  usacloud server read [ID] --format table",
+		"  usacloud disk create --size 20 --name my-disk",
+		"",
+		"効率的な使い方:",
+		"  • JSON出力でデータ処理: --output-type json",
+		"  • フィルタリング: --selector や引数での絞り込み",
+		"  • ゾーン指定: --zone で処理範囲を限定",
+		"",
+		"🔧 高度な機能:",
+		"  usacloud rest get /v1/server  # 直接API呼び出し",
+		"  usacloud config current        # 現在の設定確認",
+	},
+	AdvancedHelp: []string{
+		"⚡ usacloud 高度な使用方法",
+		"",
+		"効率的な使い方:",
+		"  usacloud server list --output-type json | jq '.[] | select(.Name | contains(\"web\"))'",
+		"  usacloud server read --format table --selector 'Name=\"web-server\"'",
+		"",
+		"自動化Tips:",
+		"  • JSON出力 + jq でのフィルタリング",
+		"  • --output-type csv でのデータ処理",
+		"  • 環境変数での認証設定",
+		"",
+		"パフォーマンス最適化:",
+		"  • --zone 指定で検索範囲を限定",
+		"  • --selector での効率的なフィルタリング",
+		"  • バッチ処理でのAPI呼び出し削減",
+		"",
+		"🔧 開発者向け機能:",
+		"  • usacloud rest - 直接API呼び出し",
+		"  • --debug でデバッグ情報表示",
+		"  • カスタムプロファイルでの環境切替",
+	},
+	ExampleHelp: []string{
+		"💡 usacloud 実例集",
+		"================",
+		"",
+		"🖥️  サーバー操作:",
+		"  usacloud server list                    # 全サーバー表示",
+		"  usacloud server list web-server         # 名前で検索",
+		"  usacloud server read 123456789          # サーバー詳細",
+		"  usacloud server power-on 123456789      # サーバー起動",
+		"",
+		"💾 ディスク操作:",
+		"  usacloud disk list                      # 全ディスク表示",
+		"  usacloud disk create --size 20 --name my-disk  # ディスク作成",
+		"  usacloud disk connect 123456789 987654321      # ディスク接続",
+		"",
+		"⚙️  設定操作:",
+		"  usacloud config list                    # プロファイル一覧",
+		"  usacloud config current                 # 現在の設定",
+		"  usacloud config use production          # プロファイル切替",
+	},
+	DetailedTitle: []string{
+		"📖 usacloud 詳細ヘルプ",
+		"===================",
+	},
+	DetailedMistakes: "\n⚠️  よくある間違い:",
+
+	InteractiveDisabled: "インタラクティブモードが無効になっています。",
+	BuilderWelcome: []string{
+		"🚀 usacloudコマンド構築ヘルパーへようこそ！",
+		"   ステップごとにコマンドを作成していきます。",
+		"   終了するには 'quit' と入力してください。",
+	},
+	BuildFailed:       "コマンド構築中にエラーが発生しました: %w",
+	GeneratedCommand:  "✅ 生成されたコマンド: %s\n",
+	ProfileSaveFailed: "⚠️  プロフィールを保存できませんでした: %v\n",
+	SelectMainCommand: []string{
+		"📋 1. メインコマンドを選択してください:",
+		"   よく使われるコマンド:",
+		"   • server    - サーバー操作",
+		"   • disk      - ディスク操作",
+		"   • database  - データベース操作",
+		"   • config    - 設定操作",
+		"",
+		"   すべてのコマンド: usacloud --help",
+	},
+	EnterPrompt:           "\n入力してください: ",
+	InvalidCommand:        "\n❓ '%s' は有効なコマンドではありません。\n",
+	DidYouMeanCommand:     "   もしかして以下のコマンドですか？",
+	SelectSuggestion:      "\n番号を選択するか、正しいコマンドを入力してください: ",
+	SelectSubCommand:      "\n📋 2. '%s' コマンドのサブコマンドを選択してください:\n",
+	NoSubcommands:         "   このコマンドにはサブコマンドがありません。",
+	AvailableSubcommands:  "   利用可能なサブコマンド:",
+	InvalidSubcommand:     "\n❓ '%s' は有効なサブコマンドではありません。\n",
+	DidYouMeanSubcommands: "   もしかして以下のサブコマンドですか？",
+
+	TutorialTitle: []string{
+		"📚 usacloud チュートリアル",
+		"======================",
+	},
+	TutorialPreparing: "チュートリアルコンテンツを準備中です。",
+	TryCommands:       "   試してみるコマンド:",
+	Tips:              "   💡 Tips:",
+	MistakesTitle: []string{
+		"⚠️  よくある間違いと解決方法",
+		"==========================",
+	},
+	MistakesPreparing:  "よくある間違いのデータベースを構築中です。",
+	WrongExample:       "   間違い例: %s\n",
+	CorrectExamples:    "   正しい例:",
+	MistakeExplanation: "   説明: %s\n",
+}
+
+var enHelpMessages = &helpMessages{
+	BeginnerHelp: []string{
+		"🎯 usacloud help - Beginner's guide",
+		"",
+		"Basic usage:",
+		"  usacloud [command] [subcommand] [options]",
+		"",
+		"Try these first:",
+		"  usacloud config list          # Check your settings",
+		"  usacloud server list          # List servers",
+		"",
+		"Frequently used commands:",
+		"  • server  - Server operations (create, list, read, ...)",
+		"  • disk    - Disk operations (create, list, connect, ...)",
+		"  • config  - Settings (profile management, ...)",
+		"",
+		"🚀 Interactive mode: usacloud --interactive",
+		"📚 Step-by-step guide: usacloud --tutorial",
+		"❓ When stuck: usacloud --help [command]",
+	},
+	IntermediateHelp: []string{
+		"📋 usacloud help - Intermediate guide",
+		"",
+		"Common patterns:",
+		"  usacloud server list --output-type json",
+		"  usacloud server read [ID] --format table",
+		"  usacloud disk create --size 20 --name my-disk",
+		"",
+		"Working efficiently:",
+		"  • Process data as JSON: --output-type json",
+		"  • Filtering: narrow down with --selector or arguments",
+		"  • Zones: limit the scope with --zone",
+		"",
+		"🔧 Advanced features:",
+		"  usacloud rest get /v1/server  # Call the API directly",
+		"  usacloud config current        # Show the current settings",
+	},
+	AdvancedHelp: []string{
+		"⚡ usacloud advanced usage",
+		"",
+		"Working efficiently:",
+		"  usacloud server list --output-type json | jq '.[] | select(.Name | contains(\"web\"))'",
+		"  usacloud server read --format table --selector 'Name=\"web-server\"'",
+		"",
+		"Automation tips:",
+		"  • Filter JSON output with jq",
+		"  • Process data with --output-type csv",
+		"  • Configure credentials with environment variables",
+		"",
+		"Performance:",
+		"  • Limit the search scope with --zone",
+		"  • Filter efficiently with --selector",
+		"  • Reduce API calls with batch processing",
+		"",
+		"🔧 Developer features:",
+		"  • usacloud rest - Call the API directly",
+		"  • --debug to show debug information",
+		"  • Switch environments with custom profiles",
+	},
+	ExampleHelp: []string{
+		"💡 usacloud examples",
+		"===================",
+		"",
+		"🖥️  Servers:",
+		"  usacloud server list                    # Show all servers",
+		"  usacloud server list web-server         # Search by name",
+		"  usacloud server read 123456789          # Server details",
+		"  usacloud server power-on 123456789      # Start a server",
+		"",
+		"💾 Disks:",
+		"  usacloud disk list                      # Show all disks",
+		"  usacloud disk create --size 20 --name my-disk  # Create a disk",
+		"  usacloud disk connect 123456789 987654321      # Connect a disk",
+		"",
+		"⚙️  Settings:",
+		"  usacloud config list                    # List profiles",
+		"  usacloud config current                 # Current settings",
+		"  usacloud config use production          # Switch profiles",
+	},
+	DetailedTitle: []string{
+		"📖 usacloud detailed help",
+		"========================",
+	},
+	DetailedMistakes: "\n⚠️  Common mistakes:",
+
+	InteractiveDisabled: "Interactive mode is disabled.",
+	BuilderWelcome: []string{
+		"🚀 Welcome to the usacloud command builder!",
+		"   We will build the command step by step.",
+		"   Type 'quit' to exit.",
+	},
+	BuildFailed:       "failed to build the command: %w",
+	GeneratedCommand:  "✅ Generated command: %s\n",
+	ProfileSaveFailed: "⚠️  Could not save your profile: %v\n",
+	SelectMainCommand: []string{
+		"📋 1. Select a main command:",
+		"   Frequently used commands:",
+		"   • server    - Server operations",
+		"   • disk      - Disk operations",
+		"   • database  - Database operations",
+		"   • config    - Settings",
+		"",
+		"   All commands: usacloud --help",
+	},
+	EnterPrompt:           "\nEnter: ",
+	InvalidCommand:        "\n❓ '%s' is not a valid command.\n",
+	DidYouMeanCommand:     "   Did you mean one of these commands?",
+	SelectSuggestion:      "\nSelect a number or enter the correct command: ",
+	SelectSubCommand:      "\n📋 2. Select a subcommand of '%s':\n",
+	NoSubcommands:         "   This command has no subcommands.",
+	AvailableSubcommands:  "   Available subcommands:",
+	InvalidSubcommand:     "\n❓ '%s' is not a valid subcommand.\n",
+	DidYouMeanSubcommands: "   Did you mean one of these subcommands?",
+
+	TutorialTitle: []string{
+		"📚 usacloud tutorial",
+		"===================",
+	},
+	TutorialPreparing: "The tutorial is not available yet.",
+	TryCommands:       "   Commands to try:",
+	Tips:              "   💡 Tips:",
+	MistakesTitle: []string{
+		"⚠️  Common mistakes and how to fix them",
+		"=====================================",
+	},
+	MistakesPreparing:  "The common mistakes database is not available yet.",
+	WrongExample:       "   Wrong: %s\n",
+	CorrectExamples:    "   Correct:",
+	MistakeExplanation: "   Explanation: %s\n",
+}
+
+// IsSupportedHelpLanguage reports whether the help system has text for language
+func IsSupportedHelpLanguage(language string) bool {
+	return language == "ja" || language == "en"
+}
+
+// getHelpMessages returns the help text for language, Japanese by default
+func getHelpMessages(language string) *helpMessages {
+	if language == "en" {
+		return enHelpMessages
+	}
+	return jaHelpMessages
+}
+
+// printLines prints each line of a help panel
+func printLines(lines []string) {
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+// getEnglishCommonMistakes returns the English version of getCommonMistakes
+func getEnglishCommonMistakes() []CommonMistake {
+	return []CommonMistake{
+		{
+			Pattern:         "usacloud server show",
+			Description:     "'show' in v0 was renamed to 'read'",
+			CorrectExamples: []string{"usacloud server read [ID]"},
+			Explanation:     "For consistency, usacloud v1 uses the 'read' command to get a single resource",
+			RelatedTopics:   []string{"CRUD operations", "v0 to v1 migration"},
+			Frequency:       95,
+		},
+		{
+			Pattern:         "usacloud server list --selector",
+			Description:     "Selectors were removed; pass names or IDs as arguments instead",
+			CorrectExamples: []string{"usacloud server list [NAME_OR_ID]"},
+			Explanation:     "The --selector option was removed. Specify the name or ID directly as an argument",
+			RelatedTopics:   []string{"selector deprecation", "argument passing"},
+			Frequency:       87,
+		},
+		{
+			Pattern:         "usacloud iso-image list",
+			Description:     "The iso-image command was renamed to 'cdrom'",
+			CorrectExamples: []string{"usacloud cdrom list"},
+			Explanation:     "ISO image operations were merged into the 'cdrom' command",
+			RelatedTopics:   []string{"command renaming", "iso to cdrom migration"},
+			Frequency:       76,
+		},
+	}
+}
+
+// getEnglishTutorialSteps returns the English version of getTutorialSteps
+func getEnglishTutorialSteps() []TutorialStep {
+	return []TutorialStep{
+		{
+			StepID:      "step1",
+			Title:       "Check your settings",
+			Description: "Start by checking the current settings",
+			Commands:    []string{"usacloud config current", "usacloud config list"},
+			Tips:        []string{"Use several profiles to manage development and production environments"},
+		},
+		{
+			StepID:      "step2",
+			Title:       "List resources",
+			Description: "Learn the basic list operations",
+			Commands:    []string{"usacloud server list", "usacloud disk list"},
+			Tips:        []string{"--output-type json is handy for processing the data"},
+		},
+		{
+			StepID:      "step3",
+			Title:       "Get details",
+			Description: "Look at the details of a specific resource",
+			Commands:    []string{"usacloud server read [ID]", "usacloud disk read [ID]"},
+			Tips:        []string{"You can search by name instead of ID"},
+		},
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 )
@@ -174,6 +175,7 @@ type UserFriendlyHelpSystem struct {
 	helpDatabase           *HelpDatabase
 	userProfile            *UserProfile
 	interactiveModeEnabled bool
+	language               string // "ja" or "en"
 }
 
 // InteractiveCommandBuilder provides interactive command building
@@ -198,14 +200,20 @@ func NewUserFriendlyHelpSystem(
 	subValidator *SubcommandValidator,
 	formatter *ComprehensiveErrorFormatter,
 	interactive bool,
+	language string,
 ) *UserFriendlyHelpSystem {
+	if !IsSupportedHelpLanguage(language) {
+		language = "ja" // Default to Japanese
+	}
+
 	system := &UserFriendlyHelpSystem{
 		commandValidator:       cmdValidator,
 		subcommandValidator:    subValidator,
 		errorFormatter:         formatter,
-		helpDatabase:           NewHelpDatabase(),
+		helpDatabase:           newHelpDatabase(language),
 		userProfile:            loadOrCreateUserProfile(),
 		interactiveModeEnabled: interactive,
+		language:               language,
 	}
 
 	return system
@@ -218,9 +226,20 @@ func NewDefaultUserFriendlyHelpSystem() *UserFriendlyHelpSystem {
 		NewSubcommandValidator(NewMainCommandValidator()),
 		NewDefaultComprehensiveErrorFormatter(),
 		true,
+		"ja",
 	)
 }
 
+// GetLanguage returns the help output language
+func (h *UserFriendlyHelpSystem) GetLanguage() string {
+	return h.language
+}
+
+// messages returns the help text in the output language
+func (h *UserFriendlyHelpSystem) messages() *helpMessages {
+	return getHelpMessages(h.language)
+}
+
 // ShowHelp displays context-dependent help
 func (h *UserFriendlyHelpSystem) ShowHelp(context *HelpContext) error {
 	if context == nil {
@@ -244,8 +263,9 @@ func (h *UserFriendlyHelpSystem) ShowHelp(context *HelpContext) error {
 
 // ShowInteractiveHelp displays interactive help
 func (h *UserFriendlyHelpSystem) ShowInteractiveHelp() error {
+	msg := h.messages()
 	if !h.interactiveModeEnabled {
-		fmt.Println("インタラクティブモードが無効になっています。")
+		fmt.Println(msg.InteractiveDisabled)
 		return nil
 	}
 
@@ -254,17 +274,21 @@ func (h *UserFriendlyHelpSystem) ShowInteractiveHelp() error {
 		options:    make(map[string]string),
 	}
 
-	fmt.Println("🚀 usacloudコマンド構築ヘルパーへようこそ！")
-	fmt.Println("   ステップごとにコマンドを作成していきます。")
-	fmt.Println("   終了するには 'quit' と入力してください。")
+	printLines(msg.BuilderWelcome)
 
 	command, err := builder.BuildCommand()
+	if err != nil || command != "" {
+		h.userProfile.recordCommand(command, err == nil, time.Now())
+		if saveErr := h.saveProfile(); saveErr != nil {
+			fmt.Printf(msg.ProfileSaveFailed, saveErr)
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("コマンド構築中にエラーが発生しました: %w", err)
+		return fmt.Errorf(msg.BuildFailed, err)
 	}
 
 	if command != "" {
-		fmt.Printf("✅ 生成されたコマンド: %s\n", command)
+		fmt.Printf(msg.GeneratedCommand, command)
 	}
 
 	return nil
@@ -272,12 +296,12 @@ func (h *UserFriendlyHelpSystem) ShowInteractiveHelp() error {
 
 // ShowTutorial displays tutorial content
 func (h *UserFriendlyHelpSystem) ShowTutorial() error {
-	fmt.Println("📚 usacloud チュートリアル")
-	fmt.Println("======================")
+	msg := h.messages()
+	printLines(msg.TutorialTitle)
 
 	steps := h.helpDatabase.tutorialSteps
 	if len(steps) == 0 {
-		fmt.Println("チュートリアルコンテンツを準備中です。")
+		fmt.Println(msg.TutorialPreparing)
 		return nil
 	}
 
@@ -286,14 +310,14 @@ func (h *UserFriendlyHelpSystem) ShowTutorial() error {
 		fmt.Printf("   %s\n", step.Description)
 
 		if len(step.Commands) > 0 {
-			fmt.Println("   試してみるコマンド:")
+			fmt.Println(msg.TryCommands)
 			for _, cmd := range step.Commands {
 				fmt.Printf("   $ %s\n", cmd)
 			}
 		}
 
 		if len(step.Tips) > 0 {
-			fmt.Println("   💡 Tips:")
+			fmt.Println(msg.Tips)
 			for _, tip := range step.Tips {
 				fmt.Printf("   • %s\n", tip)
 			}
@@ -305,28 +329,28 @@ func (h *UserFriendlyHelpSystem) ShowTutorial() error {
 
 // ShowCommonMistakes displays common mistakes and solutions
 func (h *UserFriendlyHelpSystem) ShowCommonMistakes() error {
-	fmt.Println("⚠️  よくある間違いと解決方法")
-	fmt.Println("==========================")
+	msg := h.messages()
+	printLines(msg.MistakesTitle)
 
 	mistakes := h.helpDatabase.commonMistakes
 	if len(mistakes) == 0 {
-		fmt.Println("よくある間違いのデータベースを構築中です。")
+		fmt.Println(msg.MistakesPreparing)
 		return nil
 	}
 
 	for i, mistake := range mistakes {
 		fmt.Printf("\n%d. %s\n", i+1, mistake.Description)
-		fmt.Printf("   間違い例: %s\n", mistake.Pattern)
+		fmt.Printf(msg.WrongExample, mistake.Pattern)
 
 		if len(mistake.CorrectExamples) > 0 {
-			fmt.Println("   正しい例:")
+			fmt.Println(msg.CorrectExamples)
 			for _, example := range mistake.CorrectExamples {
 				fmt.Printf("   ✅ %s\n", example)
 			}
 		}
 
 		if mistake.Explanation != "" {
-			fmt.Printf("   説明: %s\n", mistake.Explanation)
+			fmt.Printf(msg.MistakeExplanation, mistake.Explanation)
 		}
 	}
 
@@ -357,15 +381,9 @@ func (b *InteractiveCommandBuilder) BuildCommand() (string, error) {
 
 // selectMainCommand selects main command interactively
 func (b *InteractiveCommandBuilder) selectMainCommand(reader *bufio.Reader) (string, error) {
-	fmt.Println("📋 1. メインコマンドを選択してください:")
-	fmt.Println("   よく使われるコマンド:")
-	fmt.Println("   • server    - サーバー操作")
-	fmt.Println("   • disk      - ディスク操作")
-	fmt.Println("   • database  - データベース操作")
-	fmt.Println("   • config    - 設定操作")
-	fmt.Println("")
-	fmt.Println("   すべてのコマンド: usacloud --help")
-	fmt.Printf("\n入力してください: ")
+	msg := b.helpSystem.messages()
+	printLines(msg.SelectMainCommand)
+	fmt.Print(msg.EnterPrompt)
 
 	command, _ := reader.ReadString('\n')
 	command = strings.TrimSpace(command)
@@ -378,12 +396,12 @@ func (b *InteractiveCommandBuilder) selectMainCommand(reader *bufio.Reader) (str
 	if !b.helpSystem.commandValidator.IsValidCommand(command) {
 		suggestions := b.helpSystem.commandValidator.getSimilarCommands(command, 3)
 		if len(suggestions) > 0 {
-			fmt.Printf("\n❓ '%s' は有効なコマンドではありません。\n", command)
-			fmt.Println("   もしかして以下のコマンドですか？")
+			fmt.Printf(msg.InvalidCommand, command)
+			fmt.Println(msg.DidYouMeanCommand)
 			for i, suggestion := range suggestions {
 				fmt.Printf("   %d. %s\n", i+1, suggestion)
 			}
-			fmt.Printf("\n番号を選択するか、正しいコマンドを入力してください: ")
+			fmt.Print(msg.SelectSuggestion)
 
 			choice, _ := reader.ReadString('\n')
 			choice = strings.TrimSpace(choice)
@@ -405,19 +423,20 @@ func (b *InteractiveCommandBuilder) selectMainCommand(reader *bufio.Reader) (str
 
 // selectSubCommand selects subcommand interactively
 func (b *InteractiveCommandBuilder) selectSubCommand(reader *bufio.Reader, mainCmd string) (string, error) {
-	fmt.Printf("\n📋 2. '%s' コマンドのサブコマンドを選択してください:\n", mainCmd)
+	msg := b.helpSystem.messages()
+	fmt.Printf(msg.SelectSubCommand, mainCmd)
 
 	available := b.helpSystem.subcommandValidator.GetAvailableSubcommands(mainCmd)
 	if len(available) == 0 {
-		fmt.Println("   このコマンドにはサブコマンドがありません。")
+		fmt.Println(msg.NoSubcommands)
 		return "", nil
 	}
 
-	fmt.Println("   利用可能なサブコマンド:")
+	fmt.Println(msg.AvailableSubcommands)
 	for _, sub := range available {
 		fmt.Printf("   • %s\n", sub)
 	}
-	fmt.Printf("\n入力してください: ")
+	fmt.Print(msg.EnterPrompt)
 
 	subCommand, _ := reader.ReadString('\n')
 	subCommand = strings.TrimSpace(subCommand)
@@ -430,8 +449,8 @@ func (b *InteractiveCommandBuilder) selectSubCommand(reader *bufio.Reader, mainC
 	if !b.helpSystem.subcommandValidator.IsValidSubcommand(mainCmd, subCommand) {
 		suggestions := b.helpSystem.subcommandValidator.getSimilarSubcommands(mainCmd, subCommand)
 		if len(suggestions) > 0 {
-			fmt.Printf("\n❓ '%s' は有効なサブコマンドではありません。\n", subCommand)
-			fmt.Println("   もしかして以下のサブコマンドですか？")
+			fmt.Printf(msg.InvalidSubcommand, subCommand)
+			fmt.Println(msg.DidYouMeanSubcommands)
 			for i, suggestion := range suggestions {
 				fmt.Printf("   %d. %s\n", i+1, suggestion)
 			}
@@ -487,78 +506,26 @@ func (h *UserFriendlyHelpSystem) showBasicHelp(context *HelpContext) error {
 
 // showBeginnerHelp displays beginner-friendly help
 func (h *UserFriendlyHelpSystem) showBeginnerHelp(context *HelpContext) error {
-	fmt.Println("🎯 usacloud ヘルプ - 初心者向けガイド")
-	fmt.Println("")
-	fmt.Println("基本的な使い方:")
-	fmt.Println("  usacloud [コマンド] [サブコマンド] [オプション]")
-	fmt.Println("")
-	fmt.Println("まず試してみましょう:")
-	fmt.Println("  usacloud config list          # 設定確認")
-	fmt.Println("  usacloud server list          # サーバー一覧表示")
-	fmt.Println("")
-	fmt.Println("よく使うコマンド:")
-	fmt.Println("  • server  - サーバー操作 (作成、一覧、詳細等)")
-	fmt.Println("  • disk    - ディスク操作 (作成、一覧、接続等)")
-	fmt.Println("  • config  - 設定操作 (プロファイル管理等)")
-	fmt.Println("")
-	fmt.Println("🚀 インタラクティブモード: usacloud --interactive")
-	fmt.Println("📚 詳細な学習ガイド: usacloud --tutorial")
-	fmt.Println("❓ 困ったとき: usacloud --help [コマンド名]")
-
+	printLines(h.messages().BeginnerHelp)
 	return nil
 }
 
 // showIntermediateHelp displays intermediate help
 func (h *UserFriendlyHelpSystem) showIntermediateHelp(context *HelpContext) error {
-	fmt.Println("📋 usacloud ヘルプ - 中級者向けガイド")
-	fmt.Println("")
-	fmt.Println("よく使う操作パターン:")
-	fmt.Println("  usacloud server list --output-type json")
-	fmt.Println("  usacloud server read [ID] --format table")
-	fmt.Println("  usacloud disk create --size 20 --name my-disk")
-	fmt.Println("")
-	fmt.Println("効率的な使い方:")
-	fmt.Println("  • JSON出力でデータ処理: --output-type json")
-	fmt.Println("  • フィルタリング: --selector や引数での絞り込み")
-	fmt.Println("  • ゾーン指定: --zone で処理範囲を限定")
-	fmt.Println("")
-	fmt.Println("🔧 高度な機能:")
-	fmt.Println("  usacloud rest get /v1/server  # 直接API呼び出し")
-	fmt.Println("  usacloud config current        # 現在の設定確認")
-
+	printLines(h.messages().IntermediateHelp)
 	return nil
 }
 
 // showAdvancedHelp displays advanced help
 func (h *UserFriendlyHelpSystem) showAdvancedHelp(context *HelpContext) error {
-	fmt.Println("⚡ usacloud 高度な使用方法")
-	fmt.Println("")
-	fmt.Println("効率的な使い方:")
-	fmt.Println("  usacloud server list --output-type json | jq '.[] | select(.Name | contains(\"web\"))'")
-	fmt.Println("  usacloud server read --format table --selector 'Name=\"web-server\"'")
-	fmt.Println("")
-	fmt.Println("自動化Tips:")
-	fmt.Println("  • JSON出力 + jq でのフィルタリング")
-	fmt.Println("  • --output-type csv でのデータ処理")
-	fmt.Println("  • 環境変数での認証設定")
-	fmt.Println("")
-	fmt.Println("パフォーマンス最適化:")
-	fmt.Println("  • --zone 指定で検索範囲を限定")
-	fmt.Println("  • --selector での効率的なフィルタリング")
-	fmt.Println("  • バッチ処理でのAPI呼び出し削減")
-	fmt.Println("")
-	fmt.Println("🔧 開発者向け機能:")
-	fmt.Println("  • usacloud rest - 直接API呼び出し")
-	fmt.Println("  • --debug でデバッグ情報表示")
-	fmt.Println("  • カスタムプロファイルでの環境切替")
-
+	printLines(h.messages().AdvancedHelp)
 	return nil
 }
 
 // showDetailedHelp displays detailed help
 func (h *UserFriendlyHelpSystem) showDetailedHelp(context *HelpContext) error {
-	fmt.Println("📖 usacloud 詳細ヘルプ")
-	fmt.Println("===================")
+	msg := h.messages()
+	printLines(msg.DetailedTitle)
 
 	// Show basic help first
 	err := h.showBasicHelp(context)
@@ -567,7 +534,7 @@ func (h *UserFriendlyHelpSystem) showDetailedHelp(context *HelpContext) error {
 	}
 
 	// Show common mistakes if available
-	fmt.Println("\n⚠️  よくある間違い:")
+	fmt.Println(msg.DetailedMistakes)
 	mistakes := h.helpDatabase.commonMistakes
 	for i, mistake := range mistakes {
 		if i >= 3 { // Show only top 3
@@ -586,33 +553,26 @@ func (h *UserFriendlyHelpSystem) showDetailedHelp(context *HelpContext) error {
 
 // showExampleHelp displays example-focused help
 func (h *UserFriendlyHelpSystem) showExampleHelp(context *HelpContext) error {
-	fmt.Println("💡 usacloud 実例集")
-	fmt.Println("================")
-	fmt.Println("")
-	fmt.Println("🖥️  サーバー操作:")
-	fmt.Println("  usacloud server list                    # 全サーバー表示")
-	fmt.Println("  usacloud server list web-server         # 名前で検索")
-	fmt.Println("  usacloud server read 123456789          # サーバー詳細")
-	fmt.Println("  usacloud server power-on 123456789      # サーバー起動")
-	fmt.Println("")
-	fmt.Println("💾 ディスク操作:")
-	fmt.Println("  usacloud disk list                      # 全ディスク表示")
-	fmt.Println("  usacloud disk create --size 20 --name my-disk  # ディスク作成")
-	fmt.Println("  usacloud disk connect 123456789 987654321      # ディスク接続")
-	fmt.Println("")
-	fmt.Println("⚙️  設定操作:")
-	fmt.Println("  usacloud config list                    # プロファイル一覧")
-	fmt.Println("  usacloud config current                 # 現在の設定")
-	fmt.Println("  usacloud config use production          # プロファイル切替")
-
+	printLines(h.messages().ExampleHelp)
 	return nil
 }
 
 // NewHelpDatabase creates a new help database
 func NewHelpDatabase() *HelpDatabase {
+	return newHelpDatabase("ja")
+}
+
+// newHelpDatabase creates a help database whose mistakes and tutorial steps
+// are in the given language
+func newHelpDatabase(language string) *HelpDatabase {
+	mistakes, steps := getCommonMistakes(), getTutorialSteps()
+	if language == "en" {
+		mistakes, steps = getEnglishCommonMistakes(), getEnglishTutorialSteps()
+	}
+
 	db := &HelpDatabase{
-		commonMistakes:  getCommonMistakes(),
-		tutorialSteps:   getTutorialSteps(),
+		commonMistakes:  mistakes,
+		tutorialSteps:   steps,
 		conceptMap:      make(map[string]*ConceptExplanation),
 		migrationGuides: make(map[string]*MigrationGuide),
 	}
@@ -626,6 +586,40 @@ func NewHelpDatabase() *HelpDatabase {
 	return db
 }
 
+// Examples returns the example commands of the database that run command
+// (such as "server" or "server list"), without duplicates
+func (db *HelpDatabase) Examples(command string) []string {
+	var candidates []string
+	for _, mistake := range db.commonMistakes {
+		candidates = append(candidates, mistake.CorrectExamples...)
+	}
+	for _, step := range db.tutorialSteps {
+		candidates = append(candidates, step.Commands...)
+	}
+	guideIDs := make([]string, 0, len(db.migrationGuides))
+	for id := range db.migrationGuides {
+		guideIDs = append(guideIDs, id)
+	}
+	sort.Strings(guideIDs)
+	for _, id := range guideIDs {
+		for _, example := range db.migrationGuides[id].Examples {
+			candidates = append(candidates, example.NewCommand)
+		}
+	}
+
+	var examples []string
+	seen := map[string]bool{}
+	prefix := "usacloud " + command
+	for _, example := range candidates {
+		if seen[example] || (example != prefix && !strings.HasPrefix(example, prefix+" ")) {
+			continue
+		}
+		seen[example] = true
+		examples = append(examples, example)
+	}
+	return examples
+}
+
 // getCommonMistakes returns list of common mistakes
 func getCommonMistakes() []CommonMistake {
 	return []CommonMistake{
@@ -726,21 +720,23 @@ func (db *HelpDatabase) initializeMigrationGuides() {
 	}
 }
 
-// loadOrCreateUserProfile loads or creates user profile
+// loadOrCreateUserProfile loads the user profile from
+// ~/.config/usacloud-update/profile.json, or creates a default one
 func loadOrCreateUserProfile() *UserProfile {
-	// In a real implementation, this would load from a file or database
-	// For now, return a default profile
-	return &UserProfile{
-		UserID:          "default",
-		SkillLevel:      SkillBeginner,
-		PreferredFormat: FormatBasic,
-		CompletedTasks:  []CompletedTask{},
-		LearningGoals:   []LearningGoal{},
-		LastActivity:    time.Now(),
-		TotalCommands:   0,
-		ErrorCount:      0,
-		SuccessRate:     0.0,
+	path, err := userProfilePath()
+	if err != nil {
+		return newDefaultUserProfile()
+	}
+	return loadUserProfile(path)
+}
+
+// saveProfile persists the user profile so the help adapts across runs
+func (h *UserFriendlyHelpSystem) saveProfile() error {
+	path, err := userProfilePath()
+	if err != nil {
+		return err
 	}
+	return saveUserProfile(path, h.userProfile)
 }
 
 // GetSkillLevelString returns skill level as string
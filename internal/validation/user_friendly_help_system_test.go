@@ -2,6 +2,9 @@ package validation
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -12,7 +15,7 @@ func TestNewUserFriendlyHelpSystem(t *testing.T) {
 	subValidator := NewSubcommandValidator(cmdValidator)
 	formatter := NewDefaultComprehensiveErrorFormatter()
 
-	helpSystem := NewUserFriendlyHelpSystem(cmdValidator, subValidator, formatter, true)
+	helpSystem := NewUserFriendlyHelpSystem(cmdValidator, subValidator, formatter, true, "ja")
 	if helpSystem == nil {
 		t.Error("Expected help system to be created, got nil")
 	}
@@ -578,3 +581,113 @@ func TestLearningTrackerStructures(t *testing.T) {
 		t.Error("Expected goal to have steps")
 	}
 }
+
+// captureHelpOutput returns what fn prints to stdout
+func captureHelpOutput(t *testing.T, fn func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	fnErr := fn()
+	os.Stdout = stdout
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fnErr != nil {
+		t.Fatalf("unexpected error: %v", fnErr)
+	}
+	return string(out)
+}
+
+func TestUserFriendlyHelpSystem_Language(t *testing.T) {
+	newSystem := func(language string) *UserFriendlyHelpSystem {
+		cmdValidator := NewMainCommandValidator()
+		return NewUserFriendlyHelpSystem(cmdValidator, NewSubcommandValidator(cmdValidator), NewDefaultComprehensiveErrorFormatter(), false, language)
+	}
+
+	en := newSystem("en")
+	outputs := map[string]string{
+		"beginner": captureHelpOutput(t, func() error {
+			return en.ShowHelp(&HelpContext{PreferredFormat: FormatBasic, UserSkillLevel: SkillBeginner})
+		}),
+		"detailed": captureHelpOutput(t, func() error {
+			return en.ShowHelp(&HelpContext{PreferredFormat: FormatDetailed, UserSkillLevel: SkillExpert})
+		}),
+		"example":     captureHelpOutput(t, func() error { return en.ShowHelp(&HelpContext{PreferredFormat: FormatExample}) }),
+		"tutorial":    captureHelpOutput(t, en.ShowTutorial),
+		"mistakes":    captureHelpOutput(t, en.ShowCommonMistakes),
+		"interactive": captureHelpOutput(t, en.ShowInteractiveHelp),
+	}
+	want := map[string]string{
+		"beginner":    "Beginner's guide",
+		"detailed":    "'show' in v0 was renamed to 'read'",
+		"example":     "usacloud examples",
+		"tutorial":    "Check your settings",
+		"mistakes":    "The iso-image command was renamed to 'cdrom'",
+		"interactive": "Interactive mode is disabled.",
+	}
+	for name, out := range outputs {
+		if !strings.Contains(out, want[name]) {
+			t.Errorf("%s: expected English output to contain %q, got:\n%s", name, want[name], out)
+		}
+		for _, r := range out {
+			if (r >= 0x3040 && r <= 0x30ff) || (r >= 0x4e00 && r <= 0x9fff) {
+				t.Errorf("%s: unexpected Japanese text in English output:\n%s", name, out)
+				break
+			}
+		}
+	}
+
+	for _, language := range []string{"ja", "", "fr"} {
+		system := newSystem(language)
+		if system.GetLanguage() != "ja" {
+			t.Errorf("language %q: expected fallback to ja, got %q", language, system.GetLanguage())
+		}
+		if out := captureHelpOutput(t, system.ShowTutorial); !strings.Contains(out, "設定確認") {
+			t.Errorf("language %q: expected Japanese tutorial, got:\n%s", language, out)
+		}
+	}
+}
+
+func TestHelpMessages_Complete(t *testing.T) {
+	for language, msg := range map[string]*helpMessages{"ja": jaHelpMessages, "en": enHelpMessages} {
+		v := reflect.ValueOf(*msg)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).Len() == 0 {
+				t.Errorf("%s: %s is empty", language, v.Type().Field(i).Name)
+			}
+		}
+	}
+	if len(getEnglishCommonMistakes()) != len(getCommonMistakes()) || len(getEnglishTutorialSteps()) != len(getTutorialSteps()) {
+		t.Error("English mistakes and tutorial steps should match the Japanese ones")
+	}
+}
+
+func TestHelpDatabase_Examples(t *testing.T) {
+	db := NewHelpDatabase()
+
+	examples := db.Examples("server")
+	if len(examples) == 0 {
+		t.Fatal("Expected examples of server")
+	}
+	seen := map[string]bool{}
+	for _, example := range examples {
+		if !strings.HasPrefix(example, "usacloud server ") {
+			t.Errorf("Unexpected example of server: %q", example)
+		}
+		if seen[example] {
+			t.Errorf("Duplicate example: %q", example)
+		}
+		seen[example] = true
+	}
+
+	if examples := db.Examples("serv"); len(examples) != 0 {
+		t.Errorf("Expected no examples of a partial command name, got %v", examples)
+	}
+}
@@ -0,0 +1,150 @@
+// Package validation provides command validation functionality for usacloud-update
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxStoredCompletedTasks bounds the task history kept in the profile file
+const maxStoredCompletedTasks = 100
+
+// persistedUserProfile is the part of UserProfile stored across runs
+type persistedUserProfile struct {
+	SkillLevel      SkillLevel      `json:"skill_level"`
+	PreferredFormat HelpFormat      `json:"preferred_format"`
+	CompletedTasks  []CompletedTask `json:"completed_tasks"`
+	TotalCommands   int             `json:"total_commands"`
+	ErrorCount      int             `json:"error_count"`
+	SuccessRate     float64         `json:"success_rate"`
+}
+
+// userProfilePath returns ~/.config/usacloud-update/profile.json
+func userProfilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "usacloud-update", "profile.json"), nil
+}
+
+// newDefaultUserProfile returns the profile of a user with no history
+func newDefaultUserProfile() *UserProfile {
+	return &UserProfile{
+		UserID:          "default",
+		SkillLevel:      SkillBeginner,
+		PreferredFormat: FormatBasic,
+		CompletedTasks:  []CompletedTask{},
+		LearningGoals:   []LearningGoal{},
+		LastActivity:    time.Now(),
+		TotalCommands:   0,
+		ErrorCount:      0,
+		SuccessRate:     0.0,
+	}
+}
+
+// loadUserProfile reads the profile at path, falling back to the default
+// profile when the file is missing, unreadable or corrupt
+func loadUserProfile(path string) *UserProfile {
+	profile := newDefaultUserProfile()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return profile
+	}
+	var stored persistedUserProfile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return profile
+	}
+	if stored.SkillLevel < SkillBeginner || stored.SkillLevel > SkillExpert ||
+		stored.PreferredFormat < FormatBasic || stored.PreferredFormat > FormatExample ||
+		stored.TotalCommands < 0 || stored.ErrorCount < 0 || stored.ErrorCount > stored.TotalCommands {
+		return profile
+	}
+
+	profile.SkillLevel = stored.SkillLevel
+	profile.PreferredFormat = stored.PreferredFormat
+	if stored.CompletedTasks != nil {
+		profile.CompletedTasks = stored.CompletedTasks
+	}
+	profile.TotalCommands = stored.TotalCommands
+	profile.ErrorCount = stored.ErrorCount
+	profile.SuccessRate = stored.SuccessRate
+	return profile
+}
+
+// saveUserProfile writes the persisted fields of profile to path
+func saveUserProfile(path string, profile *UserProfile) error {
+	stored := persistedUserProfile{
+		SkillLevel:      profile.SkillLevel,
+		PreferredFormat: profile.PreferredFormat,
+		CompletedTasks:  profile.CompletedTasks,
+		TotalCommands:   profile.TotalCommands,
+		ErrorCount:      profile.ErrorCount,
+		SuccessRate:     profile.SuccessRate,
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode user profile: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	// Write through a temporary file so an interrupted save never leaves a corrupt profile
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write user profile: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write user profile: %w", err)
+	}
+	return nil
+}
+
+// recordCommand updates the profile after a command was built, raising the
+// skill level as successful commands accumulate (it is never lowered)
+func (p *UserProfile) recordCommand(command string, success bool, now time.Time) {
+	p.TotalCommands++
+	if !success {
+		p.ErrorCount++
+	}
+	p.SuccessRate = float64(p.TotalCommands-p.ErrorCount) / float64(p.TotalCommands)
+	p.LastActivity = now
+
+	if success {
+		p.CompletedTasks = append(p.CompletedTasks, CompletedTask{
+			TaskID:     "interactive-builder",
+			Command:    command,
+			Timestamp:  now,
+			Difficulty: 1,
+			Success:    true,
+		})
+		if len(p.CompletedTasks) > maxStoredCompletedTasks {
+			p.CompletedTasks = p.CompletedTasks[len(p.CompletedTasks)-maxStoredCompletedTasks:]
+		}
+	}
+
+	if level := skillLevelFor(p.TotalCommands-p.ErrorCount, p.SuccessRate); level > p.SkillLevel {
+		p.SkillLevel = level
+	}
+}
+
+// skillLevelFor estimates the skill level from the number of successful
+// commands and the overall success rate
+func skillLevelFor(successes int, successRate float64) SkillLevel {
+	switch {
+	case successes >= 50 && successRate >= 0.9:
+		return SkillExpert
+	case successes >= 20 && successRate >= 0.8:
+		return SkillAdvanced
+	case successes >= 5 && successRate >= 0.6:
+		return SkillIntermediate
+	default:
+		return SkillBeginner
+	}
+}
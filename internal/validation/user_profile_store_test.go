@@ -0,0 +1,102 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoadUserProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "profile.json")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	profile := newDefaultUserProfile()
+	profile.PreferredFormat = FormatExample
+	profile.recordCommand("usacloud server list", true, now)
+	profile.recordCommand("", false, now)
+	if err := saveUserProfile(path, profile); err != nil {
+		t.Fatalf("saveUserProfile() error = %v", err)
+	}
+
+	loaded := loadUserProfile(path)
+	if loaded.PreferredFormat != FormatExample || loaded.TotalCommands != 2 || loaded.ErrorCount != 1 || loaded.SuccessRate != 0.5 {
+		t.Errorf("Unexpected loaded profile: %+v", loaded)
+	}
+	if len(loaded.CompletedTasks) != 1 || loaded.CompletedTasks[0].Command != "usacloud server list" || !loaded.CompletedTasks[0].Timestamp.Equal(now) {
+		t.Errorf("Unexpected completed tasks: %+v", loaded.CompletedTasks)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary file to be removed, got %v", err)
+	}
+}
+
+func TestLoadUserProfile_FallsBackToDefault(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"corrupt":              `{"skill_level": `,
+		"not an object":        `[1, 2, 3]`,
+		"invalid skill level":  `{"skill_level": 7, "preferred_format": 0}`,
+		"invalid format":       `{"skill_level": 0, "preferred_format": -1}`,
+		"more errors than all": `{"total_commands": 1, "error_count": 3}`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name+".json")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			profile := loadUserProfile(path)
+			if profile.SkillLevel != SkillBeginner || profile.TotalCommands != 0 || profile.CompletedTasks == nil {
+				t.Errorf("Expected the default profile, got %+v", profile)
+			}
+		})
+	}
+
+	if profile := loadUserProfile(filepath.Join(dir, "missing.json")); profile.UserID != "default" {
+		t.Errorf("Expected the default profile for a missing file, got %+v", profile)
+	}
+}
+
+func TestLoadOrCreateUserProfile_PersistsAcrossRuns(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	first := NewDefaultUserFriendlyHelpSystem()
+	for i := 0; i < 5; i++ {
+		first.userProfile.recordCommand("usacloud server list", true, time.Now())
+	}
+	if err := first.saveProfile(); err != nil {
+		t.Fatalf("saveProfile() error = %v", err)
+	}
+
+	second := NewDefaultUserFriendlyHelpSystem()
+	if second.userProfile.TotalCommands != 5 || second.userProfile.SkillLevel != SkillIntermediate {
+		t.Errorf("Expected the saved profile to be loaded, got %+v", second.userProfile)
+	}
+	if ctx := second.createDefaultContext(); ctx.UserSkillLevel != SkillIntermediate {
+		t.Errorf("Expected help to adapt to the saved skill level, got %v", ctx.UserSkillLevel)
+	}
+}
+
+func TestUserProfile_recordCommand(t *testing.T) {
+	profile := newDefaultUserProfile()
+	profile.SkillLevel = SkillAdvanced
+	profile.recordCommand("", false, time.Now())
+	if profile.SkillLevel != SkillAdvanced {
+		t.Errorf("Expected the skill level never to be lowered, got %v", profile.SkillLevel)
+	}
+	if len(profile.CompletedTasks) != 0 || profile.SuccessRate != 0 {
+		t.Errorf("Expected a failed command not to complete a task: %+v", profile)
+	}
+
+	for i := 0; i < maxStoredCompletedTasks+10; i++ {
+		profile.recordCommand("usacloud disk list", true, time.Now())
+	}
+	if len(profile.CompletedTasks) != maxStoredCompletedTasks {
+		t.Errorf("Expected at most %d stored tasks, got %d", maxStoredCompletedTasks, len(profile.CompletedTasks))
+	}
+	if profile.SkillLevel != SkillExpert {
+		t.Errorf("Expected many successful commands to reach expert, got %v", profile.SkillLevel)
+	}
+}
@@ -0,0 +1,46 @@
+#!/bin/bash
+# ハイフンを含むコマンド名の解析確認用
+# 各コマンド行の直前のコメントは期待するメインコマンド名
+
+# disk-plan
+usacloud disk-plan list
+# internet-plan
+usacloud internet-plan list --zone=is1a
+# server-plan
+usacloud server-plan list --output-type=json
+# update-self
+usacloud update-self
+# iso-image
+usacloud iso-image list
+# startup-script
+usacloud startup-script read 123456789012
+# product-disk
+usacloud product-disk list
+# product-internet
+usacloud product-internet list
+# product-server
+usacloud product-server list
+# object-storage
+usacloud object-storage list
+# private-host
+usacloud private-host list
+# private-host-plan
+usacloud private-host-plan list
+# proxy-lb
+usacloud proxy-lb read 123456789012
+# packet-filter
+usacloud packet-filter list
+# auto-backup
+usacloud auto-backup list
+# ssh-key
+usacloud ssh-key list
+
+# 2語に分けて書かれたハイフン付きコマンド名
+# private-host
+usacloud private host list
+# proxy-lb
+usacloud proxy lb list
+# ssh-key
+usacloud ssh key list
+# update-self
+usacloud update self
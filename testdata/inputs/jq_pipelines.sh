@@ -0,0 +1,16 @@
+#!/bin/bash
+# jq にパイプするコマンドの出力形式チェック用
+
+# 警告対象: csv/tsv出力をjqに渡している
+usacloud server list --output-type=csv | jq '.[].Name'
+usacloud disk list -o tsv | jq -r '.[].ID'
+
+# 警告対象: 出力形式未指定でjqに渡している
+usacloud switch list | jq '.[] | .Name'
+
+# 問題なし: json出力をjqに渡している
+usacloud server list --output-type=json | jq '.[].Name'
+usacloud disk list -o json | jq length
+
+# 問題なし: jq以外へのパイプ
+usacloud server list --output-type=csv | grep web
@@ -0,0 +1,4 @@
+#!/bin/bash
+# T[oꗗCSVŎ擾iShift_JISj
+usacloud server list --output-type=csv
+echo "܂"
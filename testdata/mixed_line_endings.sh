@@ -0,0 +1,4 @@
+#!/usr/bin/env bash
+usacloud server list --output-type=csv
+usacloud disk list
+echo done
@@ -0,0 +1,9 @@
+#!/bin/bash
+# Fixture for --check-paths: paths are relative to this directory
+usacloud cdrom create --name ubuntu --size 5 --iso-file ./ubuntu.iso
+usacloud cdrom upload --iso-file=missing.iso ubuntu
+usacloud archive create --name backup --source-file "$HOME/backup.img"
+usacloud archive create --name restored --source-file images/missing.img
+usacloud server ssh --key keys/id_rsa web
+usacloud server ssh --key keys/missing_rsa web
+usacloud disk create --name data --size 20
@@ -0,0 +1,20 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+# 確認付きコマンド: -y なし（端末のない環境で応答待ちになる）
+usacloud server shutdown "$SERVER_ID" --zone is1a
+usacloud server delete "$SERVER_ID" --zone is1a
+usacloud disk delete 123456789012 | tee delete.log
+usacloud server reset 123456789012 2>&1
+
+# 確認付きコマンド: -y / --assumeyes 指定済み
+usacloud server delete -y 123456789012
+usacloud server shutdown 123456789012 --assumeyes
+
+# v0の構文と組み合わせ
+usacloud iso-image delete 123456789012
+usacloud server delete --selector tag=to-be-removed
+
+# 確認のないコマンド
+usacloud server list --zone is1a
+usacloud server boot 123456789012
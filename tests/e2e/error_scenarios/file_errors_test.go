@@ -60,7 +60,7 @@ func TestFileErrors_InputFileIssues(t *testing.T) {
 					"--in", inputFile,
 					"--out", "/tmp/output.sh",
 				},
-				ExpectedExitCode: 1,
+				ExpectedExitCode: 3, // 入出力エラー
 			}
 
 			// 期待メッセージが設定されている場合のみチェック
@@ -93,7 +93,7 @@ func TestFileErrors_OutputFileIssues(t *testing.T) {
 				"--in", inputFile,
 				"--out", outputFile,
 			},
-			ExpectedExitCode: 1,
+			ExpectedExitCode: 3, // 入出力エラー
 			ExpectedStderr: []string{
 				"権限エラー",
 				"書き込み権限がありません",
@@ -113,7 +113,7 @@ func TestFileErrors_OutputFileIssues(t *testing.T) {
 				"--in", inputFile,
 				"--out", outputDir,
 			},
-			ExpectedExitCode: 1,
+			ExpectedExitCode: 3, // 入出力エラー
 			ExpectedStderr: []string{
 				"ディレクトリ",
 			},
@@ -100,7 +100,7 @@ max_suggestions = -5  # invalid number
 				"--validate-only",
 				"/dev/null", // ダミー入力
 			},
-			ExpectedExitCode: 1,
+			ExpectedExitCode: 4, // 設定エラー
 			ExpectedStderr: []string{
 				"設定",
 				"エラー",
@@ -120,7 +120,7 @@ max_suggestions = -5  # invalid number
 				"--validate-only",
 				"/dev/null",
 			},
-			ExpectedExitCode: 1,
+			ExpectedExitCode: 4, // 設定エラー
 			ExpectedStderr: []string{
 				"設定ファイル",
 				"見つかりません",
@@ -66,7 +66,7 @@ func TestEndToEnd_BasicValidation(t *testing.T) {
 				Content:   "usacloud serv list",
 			},
 			Expected: ScenarioExpected{
-				ExitCode: 1,
+				ExitCode: 2,
 				ErrorContains: []string{
 					"問題が見つかりました",
 					"server",
@@ -81,7 +81,7 @@ func TestEndToEnd_BasicValidation(t *testing.T) {
 				Content:   "usacloud iso-image list",
 			},
 			Expected: ScenarioExpected{
-				ExitCode: 1,
+				ExitCode: 2,
 				ErrorContains: []string{
 					"iso-image",
 					"廃止",
@@ -111,7 +111,7 @@ func TestEndToEnd_ErrorHandling(t *testing.T) {
 				Content:   "usacloud invalid-cmd invalid-sub",
 			},
 			Expected: ScenarioExpected{
-				ExitCode: 1,
+				ExitCode: 2,
 				ErrorContains: []string{
 					"invalid-cmd",
 					"有効なusacloudコマンドではありません",
@@ -136,7 +136,7 @@ func TestEndToEnd_ErrorHandling(t *testing.T) {
 				Content:   "usacloud invalid-very-long-command-name list",
 			},
 			Expected: ScenarioExpected{
-				ExitCode: 1,
+				ExitCode: 2,
 				ErrorContains: []string{
 					"invalid-very-long-command-name",
 					"有効なusacloudコマンドではありません",
@@ -186,7 +186,7 @@ func TestEndToEnd_ProfileIntegration(t *testing.T) {
 					Content:   profile.command,
 				},
 				Expected: ScenarioExpected{
-					ExitCode: 1,
+					ExitCode: 2,
 					ErrorContains: []string{
 						"有効なusacloudコマンドではありません",
 					},
@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/armaniacs/usacloud-update/internal/benchmark"
 	"github.com/armaniacs/usacloud-update/internal/transform"
 )
 
@@ -699,20 +700,7 @@ usacloud iso-image list --output-type tsv`,
 }
 
 func (suite *PerformanceRegressionTestSuite) generateLargeInput(lines int) string {
-	var input string
-	commands := []string{
-		"usacloud server list --output-type csv",
-		"usacloud disk list --output-type tsv",
-		"usacloud iso-image list",
-		"usacloud server read --selector name=test",
-	}
-
-	for i := 0; i < lines; i++ {
-		cmd := commands[i%len(commands)]
-		input += cmd + "\n"
-	}
-
-	return input
+	return benchmark.GenerateWorkload(lines)
 }
 
 func (suite *PerformanceRegressionTestSuite) compareBenchmarkResults(baseline, current *BenchmarkResult, testName string) bool {